@@ -0,0 +1,183 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/spf13/cobra"
+)
+
+var Cmd = &cobra.Command{
+	Use:   "audio",
+	Short: "Local audio processing",
+	Long:  "Post-process audio files locally (concat, trim, silence-trim, normalize, resample, mix, info) without ffmpeg.",
+}
+
+// pcmInput describes raw .pcm inputs, which carry no header.
+var pcmInput = common.DecodeOptions{SampleRate: 24000, Channels: 1}
+
+func init() {
+	Cmd.PersistentFlags().IntVar(&pcmInput.SampleRate, "pcm-rate", pcmInput.SampleRate, "Sample rate of raw .pcm inputs")
+	Cmd.PersistentFlags().IntVar(&pcmInput.Channels, "pcm-channels", pcmInput.Channels, "Channels of raw .pcm inputs")
+
+	Cmd.AddCommand(concatCmd)
+	Cmd.AddCommand(trimCmd)
+	Cmd.AddCommand(silenceTrimCmd)
+	Cmd.AddCommand(normalizeCmd)
+	Cmd.AddCommand(resampleCmd)
+	Cmd.AddCommand(mixCmd)
+	Cmd.AddCommand(infoCmd)
+}
+
+// clip is decoded audio as interleaved samples in [-1, 1].
+type clip struct {
+	rate     int
+	channels int
+	samples  []float32
+}
+
+func (c *clip) frames() int {
+	return len(c.samples) / c.channels
+}
+
+func (c *clip) duration() float64 {
+	return float64(c.frames()) / float64(c.rate)
+}
+
+// frameAt converts a time in seconds to a frame index within the clip.
+func (c *clip) frameAt(seconds float64) int {
+	return min(max(int(math.Round(seconds*float64(c.rate))), 0), c.frames())
+}
+
+// slice returns the frames [from, to) of the clip.
+func (c *clip) slice(from, to int) *clip {
+	return &clip{rate: c.rate, channels: c.channels, samples: c.samples[from*c.channels : to*c.channels]}
+}
+
+// errInput marks input files that cannot be read or decoded.
+var errInput = errors.New("cannot read input")
+
+// loadClip decodes an audio file.
+func loadClip(path string) (*clip, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInput, err)
+	}
+	defer file.Close()
+
+	// Only the pure-Go decoders are used, so results do not depend on ffmpeg
+	stream, err := common.DecodeAudioNative(file, filepath.Ext(path), pcmInput)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", errInput, path, err)
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", errInput, path, err)
+	}
+	if stream.Format.SampleRate <= 0 || stream.Format.Channels <= 0 {
+		return nil, fmt.Errorf("%w: %s: invalid format %+v", errInput, path, stream.Format)
+	}
+
+	c := &clip{rate: stream.Format.SampleRate, channels: stream.Format.Channels}
+	switch stream.Format.Encoding {
+	case common.PCMU8:
+		c.samples = make([]float32, len(data))
+		for i, b := range data {
+			c.samples[i] = (float32(b) - 128) / 128
+		}
+	case common.PCMF32LE:
+		c.samples = make([]float32, len(data)/4)
+		for i := range c.samples {
+			c.samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+		}
+	default:
+		c.samples = make([]float32, len(data)/2)
+		for i := range c.samples {
+			c.samples[i] = float32(int16(binary.LittleEndian.Uint16(data[i*2:]))) / 32768
+		}
+	}
+	// Drop a partial trailing frame
+	c.samples = c.samples[:c.frames()*c.channels]
+	return c, nil
+}
+
+// outputPath defaults the output to WAV and checks that it is a format the
+// toolbox can write.
+func outputPath(output string) (string, error) {
+	output = common.DefaultExt(output, ".wav")
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".wav", ".pcm":
+		return output, nil
+	}
+	return "", fmt.Errorf("unsupported output format '%s', supported: wav, pcm", filepath.Ext(output))
+}
+
+// writeClip writes the clip as 16-bit PCM, in a WAV container unless path
+// ends in .pcm.
+func writeClip(path string, c *clip) error {
+	pcm := make([]byte, len(c.samples)*2)
+	for i, s := range c.samples {
+		v := math.Round(float64(s) * 32768)
+		v = min(max(v, -32768), 32767)
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(int16(v)))
+	}
+	data := pcm
+	if !strings.EqualFold(filepath.Ext(path), ".pcm") {
+		data = common.PCMToWAV(pcm, common.AudioFormat{SampleRate: c.rate, Channels: c.channels, Encoding: common.PCMS16LE})
+	}
+	_, err := common.WriteOutput(path, data, common.OutputVars{})
+	return err
+}
+
+// writeLoadError reports a loadClip failure.
+func writeLoadError(cmd *cobra.Command, err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return common.WriteError(cmd, "file_not_found", err.Error())
+	}
+	if errors.Is(err, common.ErrUnsupportedAudio) {
+		return common.WriteError(cmd, "unsupported_format", err.Error())
+	}
+	return common.WriteError(cmd, "decode_error", err.Error())
+}
+
+// saveClip writes the clip and returns its absolute path, reporting write
+// failures on cmd.
+func saveClip(cmd *cobra.Command, path string, c *clip) (string, error) {
+	if err := writeClip(path, c); err != nil {
+		return "", common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	return absPath, nil
+}
+
+// seconds rounds a duration to milliseconds for reporting.
+func seconds(s float64) float64 {
+	return math.Round(s*1000) / 1000
+}
+
+// decibels rounds a level to 0.01 dB for reporting; silence is reported as
+// the floor of 16-bit audio.
+func decibels(db float64) float64 {
+	if math.IsInf(db, -1) || db < -96 {
+		return -96
+	}
+	return math.Round(db*100) / 100
+}
+
+func toDB(gain float64) float64 {
+	return 20 * math.Log10(gain)
+}
+
+func fromDB(db float64) float64 {
+	return math.Pow(10, db/20)
+}
@@ -0,0 +1,183 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func executeCommand(cmd *cobra.Command, args ...string) (stdout, stderr string, err error) {
+	stdoutBuf := new(bytes.Buffer)
+	stderrBuf := new(bytes.Buffer)
+
+	cmd.SetOut(stdoutBuf)
+	cmd.SetErr(stderrBuf)
+	cmd.SetArgs(args)
+
+	err = cmd.Execute()
+	return stdoutBuf.String(), stderrBuf.String(), err
+}
+
+func expectErrorCode(t *testing.T, stderr, code string) {
+	t.Helper()
+	var resp map[string]any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); err != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != code {
+		t.Errorf("expected error code '%s', got: %s", code, errorObj["code"])
+	}
+}
+
+func decodeResponse(t *testing.T, stdout string) map[string]any {
+	t.Helper()
+	var resp map[string]any
+	if err := json.Unmarshal([]byte(stdout), &resp); err != nil {
+		t.Fatalf("expected JSON output, got: %s", stdout)
+	}
+	return resp
+}
+
+// sine returns a sine tone with the given peak amplitude on every channel.
+func sine(rate, channels int, freq, amplitude, duration float64) *clip {
+	frames := int(duration * float64(rate))
+	c := &clip{rate: rate, channels: channels, samples: make([]float32, frames*channels)}
+	for i := 0; i < frames; i++ {
+		v := float32(amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+		for ch := 0; ch < channels; ch++ {
+			c.samples[i*channels+ch] = v
+		}
+	}
+	return c
+}
+
+func silence(rate, channels int, duration float64) *clip {
+	return &clip{rate: rate, channels: channels, samples: make([]float32, int(duration*float64(rate))*channels)}
+}
+
+func join(clips ...*clip) *clip {
+	out := &clip{rate: clips[0].rate, channels: clips[0].channels}
+	for _, c := range clips {
+		out.samples = append(out.samples, c.samples...)
+	}
+	return out
+}
+
+func rms(samples []float32) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+// writeTestClip writes c as a WAV file in a temporary directory.
+func writeTestClip(t *testing.T, name string, c *clip) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := writeClip(path, c); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWriteClip_RoundTrip(t *testing.T) {
+	c := sine(16000, 2, 440, 0.5, 0.1)
+	loaded, err := loadClip(writeTestClip(t, "tone.wav", c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.rate != 16000 || loaded.channels != 2 || loaded.frames() != c.frames() {
+		t.Fatalf("unexpected clip: rate %d, channels %d, frames %d", loaded.rate, loaded.channels, loaded.frames())
+	}
+	for i := range c.samples {
+		if math.Abs(float64(loaded.samples[i]-c.samples[i])) > 1.0/32768 {
+			t.Fatalf("sample %d: expected %f, got %f", i, c.samples[i], loaded.samples[i])
+		}
+	}
+}
+
+func TestWriteClip_Clips(t *testing.T) {
+	c := &clip{rate: 8000, channels: 1, samples: []float32{1.5, -1.5}}
+	loaded, err := loadClip(writeTestClip(t, "loud.wav", c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.samples[0] < 0.999 || loaded.samples[1] != -1 {
+		t.Errorf("expected samples clipped to full scale, got %v", loaded.samples)
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	if path, err := outputPath("out"); err != nil || path != "out.wav" {
+		t.Errorf("expected out.wav, got %q (%v)", path, err)
+	}
+	if _, err := outputPath("out.mp3"); err == nil {
+		t.Error("expected an error for mp3 output")
+	}
+}
+
+func TestInfo(t *testing.T) {
+	path := writeTestClip(t, "tone.wav", sine(48000, 2, 1000, fromDB(-23), 3))
+
+	cmd := newInfoCmd()
+	stdout, stderr, err := executeCommand(cmd, path, "--loudness")
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+
+	resp := decodeResponse(t, stdout)
+	if resp["duration"] != 3.0 || resp["sample_rate"] != 48000.0 || resp["channels"] != 2.0 || resp["format"] != "wav" {
+		t.Errorf("unexpected info: %v", resp)
+	}
+	if peak := resp["peak_dbfs"].(float64); math.Abs(peak+23) > 0.1 {
+		t.Errorf("expected peak near -23 dBFS, got %v", peak)
+	}
+	if loudness := resp["loudness_lufs"].(float64); math.Abs(loudness+23) > 0.1 {
+		t.Errorf("expected loudness near -23 LUFS, got %v", loudness)
+	}
+}
+
+func TestInfo_FileNotFound(t *testing.T) {
+	cmd := newInfoCmd()
+	_, stderr, err := executeCommand(cmd, "missing.wav")
+	if err == nil {
+		t.Fatal("expected error for missing file")
+	}
+	expectErrorCode(t, stderr, "file_not_found")
+}
+
+func TestInfo_UnsupportedFormat(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "speech.aac")
+	if err := os.WriteFile(input, []byte{0xFF, 0xF1, 0x50, 0x80}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := newInfoCmd()
+	_, stderr, err := executeCommand(cmd, input)
+	if err == nil {
+		t.Fatal("expected error for unsupported input")
+	}
+	expectErrorCode(t, stderr, "unsupported_format")
+}
+
+func TestInfo_DecodeError(t *testing.T) {
+	bad := filepath.Join(t.TempDir(), "bad.wav")
+	if err := os.WriteFile(bad, []byte("not audio"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := newInfoCmd()
+	_, stderr, err := executeCommand(cmd, bad)
+	if err == nil {
+		t.Fatal("expected error for undecodable file")
+	}
+	expectErrorCode(t, stderr, "decode_error")
+}
@@ -0,0 +1,152 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/spf13/cobra"
+)
+
+type concatFlags struct {
+	output    string
+	gap       float64
+	crossfade float64
+	rate      int
+	channels  int
+}
+
+type concatResponse struct {
+	Success    bool      `json:"success"`
+	File       string    `json:"file"`
+	Duration   float64   `json:"duration"`
+	SampleRate int       `json:"sample_rate"`
+	Channels   int       `json:"channels"`
+	Offsets    []float64 `json:"offsets"`
+}
+
+var concatCmd = newConcatCmd()
+
+func newConcatCmd() *cobra.Command {
+	flags := &concatFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "concat <file>... -o <output>",
+		Short: "Join audio files end to end",
+		Long: `Join audio files end to end into one WAV or PCM file.
+
+Inputs are converted to the sample rate and channel count of the first input
+unless --rate or --channels is given. The response lists the start time of
+each input in the output.`,
+		Example: `  rawgenai audio concat intro.mp3 part1.wav part2.wav -o episode.wav
+  rawgenai audio concat a.wav b.wav --gap 0.5 -o joined.wav
+  rawgenai audio concat a.wav b.wav --crossfade 0.2 -o joined.wav`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConcat(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.wav, .pcm)")
+	cmd.Flags().Float64Var(&flags.gap, "gap", 0, "Seconds of silence between inputs")
+	cmd.Flags().Float64Var(&flags.crossfade, "crossfade", 0, "Seconds to crossfade between inputs")
+	cmd.Flags().IntVar(&flags.rate, "rate", 0, "Output sample rate (default: first input)")
+	cmd.Flags().IntVar(&flags.channels, "channels", 0, "Output channels (default: first input)")
+
+	return cmd
+}
+
+func runConcat(cmd *cobra.Command, args []string, flags *concatFlags) error {
+	if len(args) < 2 {
+		return common.WriteError(cmd, "missing_input", "at least two input files are required")
+	}
+	if flags.output == "" {
+		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
+	}
+	output, err := outputPath(flags.output)
+	if err != nil {
+		return common.WriteError(cmd, "unsupported_format", err.Error())
+	}
+	if flags.gap < 0 {
+		return common.WriteError(cmd, "invalid_gap", "gap must not be negative")
+	}
+	if flags.crossfade < 0 {
+		return common.WriteError(cmd, "invalid_crossfade", "crossfade must not be negative")
+	}
+	if flags.gap > 0 && flags.crossfade > 0 {
+		return common.WriteError(cmd, "conflicting_flags", "--gap and --crossfade cannot be used together")
+	}
+	if flags.rate < 0 || flags.channels < 0 {
+		return common.WriteError(cmd, "invalid_format", "rate and channels must be positive")
+	}
+
+	clips := make([]*clip, len(args))
+	for i, path := range args {
+		c, err := loadClip(path)
+		if err != nil {
+			return writeLoadError(cmd, err)
+		}
+		clips[i] = c
+	}
+
+	rate, channels := clips[0].rate, clips[0].channels
+	if flags.rate > 0 {
+		rate = flags.rate
+	}
+	if flags.channels > 0 {
+		channels = flags.channels
+	}
+
+	gap := int(flags.gap * float64(rate))
+	overlap := int(flags.crossfade * float64(rate))
+	out := &clip{rate: rate, channels: channels}
+	offsets := make([]float64, len(clips))
+	for i, c := range clips {
+		c = convert(c, rate, channels)
+		if i > 0 {
+			out.samples = append(out.samples, make([]float32, gap*channels)...)
+		}
+		// The crossfade cannot be longer than either side
+		n := 0
+		if i > 0 {
+			n = min(overlap, out.frames(), c.frames())
+		}
+		start := out.frames() - n
+		offsets[i] = seconds(float64(start) / float64(rate))
+		crossfade(out.samples[start*channels:], c.samples[:n*channels], channels)
+		out.samples = append(out.samples, c.samples[n*channels:]...)
+	}
+
+	absPath, err := saveClip(cmd, output, out)
+	if err != nil {
+		return err
+	}
+	return common.WriteSuccess(cmd, concatResponse{
+		Success:    true,
+		File:       absPath,
+		Duration:   seconds(out.duration()),
+		SampleRate: rate,
+		Channels:   channels,
+		Offsets:    offsets,
+	})
+}
+
+// crossfade mixes the start of next into the tail of prev in place, with
+// equal-power gains so the overlap keeps its loudness.
+func crossfade(prev, next []float32, channels int) {
+	frames := len(next) / channels
+	for i := 0; i < frames; i++ {
+		t := (float64(i) + 0.5) / float64(frames)
+		fadeOut, fadeIn := cosFade(t)
+		for ch := 0; ch < channels; ch++ {
+			j := i*channels + ch
+			prev[j] = float32(float64(prev[j])*fadeOut + float64(next[j])*fadeIn)
+		}
+	}
+}
+
+// cosFade returns the equal-power gains at position t in [0, 1] of a fade.
+func cosFade(t float64) (out, in float64) {
+	return math.Cos(t * math.Pi / 2), math.Sin(t * math.Pi / 2)
+}
@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestConcat(t *testing.T) {
+	a := writeTestClip(t, "a.wav", sine(16000, 1, 440, 0.5, 1))
+	// Different format: converted to the first input's
+	b := writeTestClip(t, "b.wav", sine(8000, 2, 440, 0.5, 0.5))
+	output := filepath.Join(t.TempDir(), "joined.wav")
+
+	cmd := newConcatCmd()
+	stdout, stderr, err := executeCommand(cmd, a, b, "--gap", "0.25", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+
+	resp := decodeResponse(t, stdout)
+	if resp["duration"] != 1.75 || resp["sample_rate"] != 16000.0 || resp["channels"] != 1.0 {
+		t.Errorf("unexpected response: %v", resp)
+	}
+	offsets := resp["offsets"].([]any)
+	if offsets[0] != 0.0 || offsets[1] != 1.25 {
+		t.Errorf("expected offsets [0 1.25], got %v", offsets)
+	}
+
+	joined, err := loadClip(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined.frames() != 28000 {
+		t.Errorf("expected 28000 frames, got %d", joined.frames())
+	}
+	if level := rms(joined.samples[16000:20000]); level != 0 {
+		t.Errorf("expected silence in the gap, got RMS %f", level)
+	}
+}
+
+func TestConcat_Crossfade(t *testing.T) {
+	a := writeTestClip(t, "a.wav", sine(16000, 1, 440, 0.5, 1))
+	b := writeTestClip(t, "b.wav", sine(16000, 1, 440, 0.5, 1))
+	output := filepath.Join(t.TempDir(), "joined.wav")
+
+	cmd := newConcatCmd()
+	stdout, stderr, err := executeCommand(cmd, a, b, "--crossfade", "0.5", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+	resp := decodeResponse(t, stdout)
+	if resp["duration"] != 1.5 || resp["offsets"].([]any)[1] != 0.5 {
+		t.Errorf("unexpected response: %v", resp)
+	}
+}
+
+func TestConcat_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"one input", []string{"a.wav", "-o", "out.wav"}, "missing_input"},
+		{"no output", []string{"a.wav", "b.wav"}, "missing_output"},
+		{"mp3 output", []string{"a.wav", "b.wav", "-o", "out.mp3"}, "unsupported_format"},
+		{"negative gap", []string{"a.wav", "b.wav", "-o", "out.wav", "--gap", "-1"}, "invalid_gap"},
+		{"gap and crossfade", []string{"a.wav", "b.wav", "-o", "out.wav", "--gap", "1", "--crossfade", "1"}, "conflicting_flags"},
+		{"missing input", []string{"a.wav", "b.wav", "-o", "out.wav"}, "file_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newConcatCmd()
+			_, stderr, err := executeCommand(cmd, tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			expectErrorCode(t, stderr, tt.code)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package audio
+
+import (
+	"math"
+)
+
+// sincZeroCrossings is the half-width of the interpolation kernel, in zero
+// crossings of the sinc.
+const sincZeroCrossings = 16
+
+// sincResolution is the number of kernel table entries per zero crossing.
+const sincResolution = 256
+
+// sincTable holds a Blackman-windowed sinc from 0 to sincZeroCrossings.
+var sincTable = func() []float64 {
+	table := make([]float64, sincZeroCrossings*sincResolution+2)
+	for i := range table {
+		x := float64(i) / sincResolution
+		if x > sincZeroCrossings {
+			break
+		}
+		w := x/sincZeroCrossings/2 + 0.5 // position within the window
+		window := 0.42 - 0.5*math.Cos(2*math.Pi*w) + 0.08*math.Cos(4*math.Pi*w)
+		table[i] = sinc(x) * window
+	}
+	return table
+}()
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// kernel evaluates the windowed sinc at x zero crossings from its center.
+func kernel(x float64) float64 {
+	x = math.Abs(x) * sincResolution
+	i := int(x)
+	if i >= sincZeroCrossings*sincResolution {
+		return 0
+	}
+	frac := x - float64(i)
+	return sincTable[i]*(1-frac) + sincTable[i+1]*frac
+}
+
+// interpolate returns channel ch of the clip at fractional frame t, band
+// limited to cutoff times the Nyquist frequency.
+func (c *clip) interpolate(t float64, ch int, cutoff float64) float64 {
+	width := sincZeroCrossings / cutoff
+	first := max(int(math.Floor(t-width))+1, 0)
+	last := min(int(math.Floor(t+width)), c.frames()-1)
+	var sum float64
+	for j := first; j <= last; j++ {
+		sum += float64(c.samples[j*c.channels+ch]) * kernel((t-float64(j))*cutoff)
+	}
+	return sum * cutoff
+}
+
+// resample converts the clip to rate with band-limited interpolation.
+func resample(c *clip, rate int) *clip {
+	if rate == c.rate || c.frames() == 0 {
+		return &clip{rate: rate, channels: c.channels, samples: c.samples}
+	}
+	ratio := float64(rate) / float64(c.rate)
+	// Downsampling filters out what the new rate cannot hold, with a little
+	// room for the filter's transition band
+	cutoff := 1.0
+	if ratio < 1 {
+		cutoff = ratio * 0.97
+	}
+
+	frames := int(math.Round(float64(c.frames()) * ratio))
+	out := &clip{rate: rate, channels: c.channels, samples: make([]float32, frames*c.channels)}
+	for i := 0; i < frames; i++ {
+		t := float64(i) / ratio
+		for ch := 0; ch < c.channels; ch++ {
+			out.samples[i*c.channels+ch] = float32(c.interpolate(t, ch, cutoff))
+		}
+	}
+	return out
+}
+
+// remix converts the clip to the given number of channels: mono is spread to
+// every channel, and any layout is averaged down to mono.
+func remix(c *clip, channels int) *clip {
+	if channels == c.channels {
+		return c
+	}
+	frames := c.frames()
+	out := &clip{rate: c.rate, channels: channels, samples: make([]float32, frames*channels)}
+	for i := 0; i < frames; i++ {
+		in := c.samples[i*c.channels : (i+1)*c.channels]
+		if channels == 1 {
+			var sum float32
+			for _, s := range in {
+				sum += s
+			}
+			out.samples[i] = sum / float32(c.channels)
+			continue
+		}
+		for ch := 0; ch < channels; ch++ {
+			out.samples[i*channels+ch] = in[ch%c.channels]
+		}
+	}
+	return out
+}
+
+// convert brings the clip to the given rate and channel count.
+func convert(c *clip, rate, channels int) *clip {
+	return resample(remix(c, channels), rate)
+}
+
+// applyGain scales the clip in place.
+func applyGain(c *clip, gain float64) {
+	for i, s := range c.samples {
+		c.samples[i] = float32(float64(s) * gain)
+	}
+}
+
+// fade ramps the first fadeIn and last fadeOut frames of the clip in place.
+func fade(c *clip, fadeIn, fadeOut int) {
+	frames := c.frames()
+	fadeIn, fadeOut = min(fadeIn, frames), min(fadeOut, frames)
+	for i := 0; i < fadeIn; i++ {
+		gain := float32(i) / float32(fadeIn)
+		for ch := 0; ch < c.channels; ch++ {
+			c.samples[i*c.channels+ch] *= gain
+		}
+	}
+	for i := 0; i < fadeOut; i++ {
+		gain := float32(i) / float32(fadeOut)
+		frame := frames - 1 - i
+		for ch := 0; ch < c.channels; ch++ {
+			c.samples[frame*c.channels+ch] *= gain
+		}
+	}
+}
+
+// peak returns the largest absolute sample value.
+func peak(c *clip) float64 {
+	var p float32
+	for _, s := range c.samples {
+		if s < 0 {
+			s = -s
+		}
+		p = max(p, s)
+	}
+	return float64(p)
+}
+
+// truePeak estimates the peak of the reconstructed signal by also
+// interpolating three points between every pair of samples, as BS.1770
+// suggests for true-peak metering.
+func truePeak(c *clip) float64 {
+	p := peak(c)
+	frames := c.frames()
+	for i := 0; i+1 < frames; i++ {
+		for ch := 0; ch < c.channels; ch++ {
+			a, b := c.samples[i*c.channels+ch], c.samples[(i+1)*c.channels+ch]
+			// Overshoot needs a fast change or a sample near the peak
+			if max(abs32(a), abs32(b)) < float32(p)*0.5 {
+				continue
+			}
+			for _, phase := range []float64{0.25, 0.5, 0.75} {
+				p = max(p, math.Abs(c.interpolate(float64(i)+phase, ch, 1)))
+			}
+		}
+	}
+	return p
+}
+
+func abs32(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// levels returns the RMS level, over all channels, of consecutive windows of
+// the given number of frames.
+func levels(c *clip, window int) []float64 {
+	window = max(window, 1)
+	frames := c.frames()
+	out := make([]float64, 0, frames/window+1)
+	for start := 0; start < frames; start += window {
+		end := min(start+window, frames)
+		var sum float64
+		for _, s := range c.samples[start*c.channels : end*c.channels] {
+			sum += float64(s) * float64(s)
+		}
+		out = append(out, math.Sqrt(sum/float64((end-start)*c.channels)))
+	}
+	return out
+}
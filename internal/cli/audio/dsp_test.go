@@ -0,0 +1,80 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResample_KeepsTone(t *testing.T) {
+	in := sine(48000, 1, 1000, 0.5, 1)
+	out := resample(in, 16000)
+
+	if out.rate != 16000 || out.frames() != 16000 {
+		t.Fatalf("expected 16000 frames at 16 kHz, got %d at %d", out.frames(), out.rate)
+	}
+	// Skip the edges, where the filter runs off the input
+	expected := 0.5 / math.Sqrt2
+	if level := rms(out.samples[1000:15000]); math.Abs(level-expected) > expected*0.01 {
+		t.Errorf("expected RMS %f, got %f", expected, level)
+	}
+}
+
+func TestResample_RemovesAliases(t *testing.T) {
+	// 10 kHz cannot be represented at 16 kHz and must not fold back to 6 kHz
+	out := resample(sine(48000, 1, 10000, 0.5, 1), 16000)
+	if level := rms(out.samples[1000:15000]); level > 0.005 {
+		t.Errorf("expected the tone to be filtered out, got RMS %f", level)
+	}
+}
+
+func TestResample_Upsample(t *testing.T) {
+	in := sine(16000, 2, 440, 0.5, 0.5)
+	out := resample(in, 44100)
+	if out.frames() != 22050 || out.channels != 2 {
+		t.Fatalf("expected 22050 stereo frames, got %d x %d", out.frames(), out.channels)
+	}
+	reference := sine(44100, 2, 440, 0.5, 0.5)
+	for i := 2000; i < 20000; i++ {
+		if d := math.Abs(float64(out.samples[i] - reference.samples[i])); d > 0.002 {
+			t.Fatalf("sample %d differs from the reference by %f", i, d)
+		}
+	}
+}
+
+func TestRemix(t *testing.T) {
+	stereo := &clip{rate: 8000, channels: 2, samples: []float32{0.2, 0.4, -0.2, 0}}
+	mono := remix(stereo, 1)
+	if mono.channels != 1 || len(mono.samples) != 2 || math.Abs(float64(mono.samples[0])-0.3) > 1e-6 {
+		t.Errorf("unexpected downmix: %v", mono.samples)
+	}
+
+	back := remix(mono, 2)
+	if len(back.samples) != 4 || back.samples[0] != back.samples[1] {
+		t.Errorf("unexpected upmix: %v", back.samples)
+	}
+}
+
+func TestFade(t *testing.T) {
+	c := &clip{rate: 8000, channels: 1, samples: []float32{1, 1, 1, 1, 1, 1}}
+	fade(c, 2, 2)
+	expected := []float32{0, 0.5, 1, 1, 0.5, 0}
+	for i, s := range c.samples {
+		if s != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, c.samples)
+		}
+	}
+}
+
+func TestTruePeak(t *testing.T) {
+	// A quarter-rate sine sampled 45 degrees off its peaks
+	c := &clip{rate: 48000, channels: 1, samples: make([]float32, 4800)}
+	for i := range c.samples {
+		c.samples[i] = float32(math.Sin(math.Pi/2*float64(i) + math.Pi/4))
+	}
+	if p := peak(c); math.Abs(p-math.Sqrt2/2) > 1e-3 {
+		t.Fatalf("unexpected sample peak %f", p)
+	}
+	if tp := truePeak(c); tp < 0.99 {
+		t.Errorf("expected a true peak near 1.0, got %f", tp)
+	}
+}
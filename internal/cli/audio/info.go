@@ -0,0 +1,84 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/spf13/cobra"
+)
+
+type infoFlags struct {
+	loudness bool
+}
+
+type infoResponse struct {
+	Success    bool     `json:"success"`
+	File       string   `json:"file"`
+	Format     string   `json:"format"`
+	Size       int64    `json:"size"`
+	Duration   float64  `json:"duration"`
+	SampleRate int      `json:"sample_rate"`
+	Channels   int      `json:"channels"`
+	Peak       float64  `json:"peak_dbfs"`
+	Loudness   *float64 `json:"loudness_lufs,omitempty"`
+	TruePeak   *float64 `json:"true_peak_dbtp,omitempty"`
+}
+
+var infoCmd = newInfoCmd()
+
+func newInfoCmd() *cobra.Command {
+	flags := &infoFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "info <file>",
+		Short: "Show duration, sample rate and channels of an audio file",
+		Long: `Decode an audio file and report its duration, sample rate, channel count
+and sample peak. --loudness also measures integrated loudness (EBU R128)
+and true peak.`,
+		Example: `  rawgenai audio info speech.mp3
+  rawgenai audio info episode.wav --loudness`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInfo(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.loudness, "loudness", false, "Also measure loudness and true peak")
+
+	return cmd
+}
+
+func runInfo(cmd *cobra.Command, args []string, flags *infoFlags) error {
+	path := args[0]
+	c, err := loadClip(path)
+	if err != nil {
+		return writeLoadError(cmd, err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	result := infoResponse{
+		Success:    true,
+		File:       absPath,
+		Format:     strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."),
+		Duration:   seconds(c.duration()),
+		SampleRate: c.rate,
+		Channels:   c.channels,
+		Peak:       decibels(toDB(peak(c))),
+	}
+	if stat, err := os.Stat(path); err == nil {
+		result.Size = stat.Size()
+	}
+	if flags.loudness {
+		loudness := decibels(integratedLoudness(c))
+		tp := decibels(toDB(truePeak(c)))
+		result.Loudness, result.TruePeak = &loudness, &tp
+	}
+	return common.WriteSuccess(cmd, result)
+}
@@ -0,0 +1,149 @@
+package audio
+
+import (
+	"math"
+)
+
+// biquad is a second-order IIR filter in direct form I.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting returns the two BS.1770 K-weighting stages for rate: a high
+// shelf modelling the head, then a high-pass. The coefficients are derived
+// from the analog prototypes so that any sample rate matches the 48 kHz
+// values given in the standard.
+func kWeighting(rate int) (*biquad, *biquad) {
+	// Stage 1: high shelf, +4 dB above about 1.7 kHz
+	f0, gain, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / float64(rate))
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := &biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	// Stage 2: high-pass at about 38 Hz
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / float64(rate))
+	a0 = 1 + k/q + k*k
+	highPass := &biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, highPass
+}
+
+// channelWeights returns the BS.1770 weight of each channel: surround
+// channels of a 5.1 layout count 1.41 and the LFE channel is ignored.
+func channelWeights(channels int) []float64 {
+	weights := make([]float64, channels)
+	for i := range weights {
+		weights[i] = 1
+	}
+	if channels == 6 {
+		weights[3] = 0
+		weights[4], weights[5] = 1.41, 1.41
+	}
+	return weights
+}
+
+const (
+	// loudnessBlock and loudnessStep are the gating block length and hop, in
+	// seconds.
+	loudnessBlock = 0.4
+	loudnessStep  = 0.1
+
+	absoluteGate = -70.0 // LUFS
+	relativeGate = -10.0 // LU below the ungated loudness
+)
+
+// integratedLoudness measures the clip's integrated loudness in LUFS as
+// specified by EBU R128 (ITU-R BS.1770-4). It returns -Inf for silence.
+func integratedLoudness(c *clip) float64 {
+	frames := c.frames()
+	if frames == 0 {
+		return math.Inf(-1)
+	}
+
+	// Mean square of the K-weighted signal per 100 ms step, summed over the
+	// weighted channels
+	step := max(int(float64(c.rate)*loudnessStep), 1)
+	steps := (frames + step - 1) / step
+	power := make([]float64, steps)
+	weights := channelWeights(c.channels)
+	for ch := 0; ch < c.channels; ch++ {
+		if weights[ch] == 0 {
+			continue
+		}
+		shelf, highPass := kWeighting(c.rate)
+		for i := 0; i < frames; i++ {
+			y := highPass.process(shelf.process(float64(c.samples[i*c.channels+ch])))
+			power[i/step] += weights[ch] * y * y
+		}
+	}
+
+	// Overlapping 400 ms blocks; shorter clips are measured as one block
+	perBlock := min(int(math.Round(loudnessBlock/loudnessStep)), steps)
+	var blocks []float64
+	for start := 0; start+perBlock <= steps; start++ {
+		end := start + perBlock
+		var sum float64
+		for _, p := range power[start:end] {
+			sum += p
+		}
+		count := min(end*step, frames) - start*step
+		blocks = append(blocks, sum/float64(count))
+	}
+
+	gated := gateBlocks(blocks, absoluteGate)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+	relative := blockLoudness(mean(gated)) + relativeGate
+	gated = gateBlocks(gated, relative)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+	return blockLoudness(mean(gated))
+}
+
+// blockLoudness converts a weighted mean square to LUFS.
+func blockLoudness(power float64) float64 {
+	return -0.691 + 10*math.Log10(power)
+}
+
+// gateBlocks keeps the blocks louder than gate LUFS.
+func gateBlocks(blocks []float64, gate float64) []float64 {
+	var kept []float64
+	for _, b := range blocks {
+		if blockLoudness(b) > gate {
+			kept = append(kept, b)
+		}
+	}
+	return kept
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,49 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntegratedLoudness_ReferenceTone(t *testing.T) {
+	// EBU Tech 3341: a stereo 1 kHz sine at -23 dBFS reads -23 LUFS
+	for _, rate := range []int{48000, 44100, 24000} {
+		c := sine(rate, 2, 1000, fromDB(-23), 5)
+		if loudness := integratedLoudness(c); math.Abs(loudness+23) > 0.1 {
+			t.Errorf("%d Hz: expected -23 LUFS, got %.2f", rate, loudness)
+		}
+	}
+}
+
+func TestIntegratedLoudness_Mono(t *testing.T) {
+	// One channel carries half the power of two
+	c := sine(48000, 1, 1000, fromDB(-20), 3)
+	if loudness := integratedLoudness(c); math.Abs(loudness+23.01) > 0.1 {
+		t.Errorf("expected -23 LUFS, got %.2f", loudness)
+	}
+}
+
+func TestIntegratedLoudness_Gating(t *testing.T) {
+	// Long enough that blocks straddling the edges barely count
+	tone := sine(48000, 2, 1000, fromDB(-23), 20)
+	withSilence := join(silence(48000, 2, 5), tone, silence(48000, 2, 5))
+	if loudness := integratedLoudness(withSilence); math.Abs(loudness+23) > 0.1 {
+		t.Errorf("silence should be gated out, got %.2f LUFS", loudness)
+	}
+
+	// A quiet passage 20 LU down falls under the relative gate
+	withQuiet := join(tone, sine(48000, 2, 1000, fromDB(-43), 20))
+	if loudness := integratedLoudness(withQuiet); math.Abs(loudness+23) > 0.2 {
+		t.Errorf("quiet passage should be gated out, got %.2f LUFS", loudness)
+	}
+}
+
+func TestIntegratedLoudness_Silence(t *testing.T) {
+	if loudness := integratedLoudness(silence(48000, 1, 1)); !math.IsInf(loudness, -1) {
+		t.Errorf("expected -Inf for silence, got %f", loudness)
+	}
+	short := sine(48000, 2, 1000, fromDB(-23), 0.2)
+	if loudness := integratedLoudness(short); math.Abs(loudness+23) > 0.2 {
+		t.Errorf("expected a clip shorter than a block to be measured, got %.2f", loudness)
+	}
+}
@@ -0,0 +1,191 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/spf13/cobra"
+)
+
+// duckWindow is the analysis window for voice activity, in seconds.
+const duckWindow = 0.01
+
+type mixFlags struct {
+	output      string
+	musicGain   float64
+	duck        float64
+	threshold   float64
+	attack      float64
+	release     float64
+	loop        bool
+	tail        float64
+	musicOffset float64
+}
+
+type mixResponse struct {
+	Success    bool    `json:"success"`
+	File       string  `json:"file"`
+	Duration   float64 `json:"duration"`
+	SampleRate int     `json:"sample_rate"`
+	Channels   int     `json:"channels"`
+	Ducked     float64 `json:"ducked"`
+	Peak       float64 `json:"peak_dbfs"`
+}
+
+var mixCmd = newMixCmd()
+
+func newMixCmd() *cobra.Command {
+	flags := &mixFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "mix <voice> <music> -o <output>",
+		Short: "Mix a voice track over music with ducking",
+		Long: `Mix a voice track over a music bed. The music is lowered by --duck dB
+whenever the voice is louder than --threshold, fading down over --attack
+seconds ahead of the voice and back up over --release seconds after it.
+
+The output lasts as long as the voice plus --tail seconds of music, which
+fade out. The response reports how many seconds the music was ducked.`,
+		Example: `  rawgenai audio mix narration.wav bed.mp3 -o episode.wav
+  rawgenai audio mix narration.wav bed.mp3 --music-gain -18 --duck 10 --loop --tail 3 -o episode.wav`,
+		Args:          cobra.ExactArgs(2),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMix(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.wav, .pcm)")
+	cmd.Flags().Float64Var(&flags.musicGain, "music-gain", -12, "Music level in dB")
+	cmd.Flags().Float64Var(&flags.duck, "duck", 12, "Extra music attenuation under the voice, in dB")
+	cmd.Flags().Float64Var(&flags.threshold, "threshold", -40, "Voice level that triggers ducking, in dBFS")
+	cmd.Flags().Float64Var(&flags.attack, "attack", 0.15, "Seconds to duck the music before the voice starts")
+	cmd.Flags().Float64Var(&flags.release, "release", 0.6, "Seconds to bring the music back after the voice stops")
+	cmd.Flags().BoolVar(&flags.loop, "loop", false, "Loop the music when it is shorter than the voice")
+	cmd.Flags().Float64Var(&flags.tail, "tail", 0, "Seconds of music after the voice ends, faded out")
+	cmd.Flags().Float64Var(&flags.musicOffset, "music-offset", 0, "Start the music this many seconds into its file")
+
+	return cmd
+}
+
+func runMix(cmd *cobra.Command, args []string, flags *mixFlags) error {
+	if flags.output == "" {
+		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
+	}
+	output, err := outputPath(flags.output)
+	if err != nil {
+		return common.WriteError(cmd, "unsupported_format", err.Error())
+	}
+	if flags.duck < 0 {
+		return common.WriteError(cmd, "invalid_duck", "duck must not be negative")
+	}
+	if flags.threshold >= 0 {
+		return common.WriteError(cmd, "invalid_threshold", "threshold must be below 0 dBFS")
+	}
+	if flags.attack < 0 || flags.release < 0 {
+		return common.WriteError(cmd, "invalid_timing", "attack and release must not be negative")
+	}
+	if flags.tail < 0 || flags.musicOffset < 0 {
+		return common.WriteError(cmd, "invalid_timing", "tail and music offset must not be negative")
+	}
+
+	voice, err := loadClip(args[0])
+	if err != nil {
+		return writeLoadError(cmd, err)
+	}
+	music, err := loadClip(args[1])
+	if err != nil {
+		return writeLoadError(cmd, err)
+	}
+
+	// Mix at the higher rate and channel count of the two
+	rate, channels := max(voice.rate, music.rate), max(voice.channels, music.channels)
+	voice = convert(voice, rate, channels)
+	music = convert(music, rate, channels)
+	music = music.slice(music.frameAt(flags.musicOffset), music.frames())
+	if music.frames() == 0 {
+		return common.WriteError(cmd, "invalid_timing", "music offset is beyond the end of the music")
+	}
+
+	frames := voice.frames() + int(flags.tail*float64(rate))
+	gains, ducked := duckGains(voice, frames, flags)
+
+	out := &clip{rate: rate, channels: channels, samples: make([]float32, frames*channels)}
+	copy(out.samples, voice.samples)
+	musicGain := fromDB(flags.musicGain)
+	tailStart := voice.frames()
+	for i := 0; i < frames; i++ {
+		m := i
+		if flags.loop {
+			m %= music.frames()
+		} else if m >= music.frames() {
+			break
+		}
+		gain := musicGain * gains[i]
+		if i >= tailStart {
+			gain *= 1 - float64(i-tailStart)/float64(frames-tailStart)
+		}
+		for ch := 0; ch < channels; ch++ {
+			out.samples[i*channels+ch] += float32(float64(music.samples[m*channels+ch]) * gain)
+		}
+	}
+
+	absPath, err := saveClip(cmd, output, out)
+	if err != nil {
+		return err
+	}
+	return common.WriteSuccess(cmd, mixResponse{
+		Success:    true,
+		File:       absPath,
+		Duration:   seconds(out.duration()),
+		SampleRate: rate,
+		Channels:   channels,
+		Ducked:     seconds(ducked),
+		Peak:       decibels(toDB(peak(out))),
+	})
+}
+
+// duckGains returns the music gain for each of frames frames and the time,
+// in seconds, the voice held the music down. The gain falls to -duck dB
+// over the attack time before voice activity and recovers over the release
+// time after it.
+func duckGains(voice *clip, frames int, flags *mixFlags) ([]float64, float64) {
+	window := max(int(duckWindow*float64(voice.rate)), 1)
+	threshold := fromDB(flags.threshold)
+	loud := levels(voice, window)
+
+	// Ducking starts the attack time ahead of the voice
+	lead := int(math.Ceil(flags.attack / duckWindow))
+	active := make([]bool, len(loud))
+	activeWindows := 0
+	for i, level := range loud {
+		if level < threshold {
+			continue
+		}
+		activeWindows++
+		for j := max(i-lead, 0); j <= i; j++ {
+			active[j] = true
+		}
+	}
+
+	ducked := fromDB(-flags.duck)
+	attack := max(flags.attack*float64(voice.rate), 1)
+	release := max(flags.release*float64(voice.rate), 1)
+	gains := make([]float64, frames)
+	gain := 1.0
+	for i := range gains {
+		target := 1.0
+		if w := i / window; w < len(active) && active[w] {
+			target = ducked
+		}
+		// Linear ramps in dB take the full attack or release time
+		if target < gain {
+			gain = max(gain*math.Pow(ducked, 1/attack), target)
+		} else if target > gain {
+			gain = min(gain/math.Pow(ducked, 1/release), target)
+		}
+		gains[i] = gain
+	}
+	return gains, float64(activeWindows*window) / float64(voice.rate)
+}
@@ -0,0 +1,70 @@
+package audio
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMix_Ducking(t *testing.T) {
+	// Voice from 1s to 2s over 3 seconds of music
+	voice := join(silence(16000, 1, 1), sine(16000, 1, 300, 0.5, 1), silence(16000, 1, 1))
+	music := sine(16000, 1, 2000, 0.5, 3)
+	voicePath := writeTestClip(t, "voice.wav", voice)
+	musicPath := writeTestClip(t, "music.wav", music)
+	output := filepath.Join(t.TempDir(), "mix.wav")
+
+	cmd := newMixCmd()
+	stdout, stderr, err := executeCommand(cmd, voicePath, musicPath, "--music-gain", "0", "--duck", "20", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+
+	resp := decodeResponse(t, stdout)
+	if resp["duration"] != 3.0 || resp["ducked"] != 1.0 {
+		t.Errorf("unexpected response: %v", resp)
+	}
+
+	mixed, err := loadClip(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Music alone before the voice, ducked 20 dB (to a tenth) under it
+	before := rms(mixed.samples[4000:12000])
+	under := rms(subtract(mixed.samples[20000:28000], voice.samples[20000:28000]))
+	if ratio := under / before; ratio < 0.09 || ratio > 0.11 {
+		t.Errorf("expected the music ducked to 0.1, got %f", ratio)
+	}
+}
+
+func TestMix_LoopAndTail(t *testing.T) {
+	voicePath := writeTestClip(t, "voice.wav", sine(16000, 1, 300, 0.5, 2))
+	musicPath := writeTestClip(t, "music.wav", sine(44100, 2, 2000, 0.5, 0.5))
+	output := filepath.Join(t.TempDir(), "mix.wav")
+
+	cmd := newMixCmd()
+	stdout, stderr, err := executeCommand(cmd, voicePath, musicPath, "--loop", "--tail", "1", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+
+	resp := decodeResponse(t, stdout)
+	if resp["duration"] != 3.0 || resp["sample_rate"] != 44100.0 || resp["channels"] != 2.0 {
+		t.Errorf("unexpected response: %v", resp)
+	}
+	mixed, err := loadClip(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Looped music keeps playing in the tail
+	if level := rms(mixed.samples[2*44100*2 : 2*44100*2+8820]); level == 0 {
+		t.Error("expected looped music in the tail")
+	}
+}
+
+func subtract(a, b []float32) []float32 {
+	out := make([]float32, len(a))
+	for i := range a {
+		out[i] = a[i] - b[i]
+	}
+	return out
+}
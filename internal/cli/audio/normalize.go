@@ -0,0 +1,102 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/spf13/cobra"
+)
+
+type normalizeFlags struct {
+	output   string
+	target   float64
+	truePeak float64
+}
+
+type normalizeResponse struct {
+	Success        bool    `json:"success"`
+	File           string  `json:"file"`
+	InputLoudness  float64 `json:"input_lufs"`
+	OutputLoudness float64 `json:"output_lufs"`
+	Gain           float64 `json:"gain_db"`
+	TruePeak       float64 `json:"true_peak_dbtp"`
+	Limited        bool    `json:"limited"`
+}
+
+var normalizeCmd = newNormalizeCmd()
+
+func newNormalizeCmd() *cobra.Command {
+	flags := &normalizeFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "normalize <file> -o <output>",
+		Short: "Normalize loudness to an EBU R128 target",
+		Long: `Measure integrated loudness as specified by EBU R128 (ITU-R BS.1770) and
+apply the gain that brings it to --target.
+
+The gain is lowered when it would push the true peak above --true-peak; the
+response then reports "limited": true and an output below the target.`,
+		Example: `  rawgenai audio normalize speech.mp3 -o speech.wav
+  rawgenai audio normalize podcast.wav --target -16 -o podcast_norm.wav`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runNormalize(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.wav, .pcm)")
+	cmd.Flags().Float64Var(&flags.target, "target", -23, "Target integrated loudness in LUFS")
+	cmd.Flags().Float64Var(&flags.truePeak, "true-peak", -1, "Maximum true peak in dBTP")
+
+	return cmd
+}
+
+func runNormalize(cmd *cobra.Command, args []string, flags *normalizeFlags) error {
+	if flags.output == "" {
+		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
+	}
+	output, err := outputPath(flags.output)
+	if err != nil {
+		return common.WriteError(cmd, "unsupported_format", err.Error())
+	}
+	if flags.target >= 0 || flags.target < -70 {
+		return common.WriteError(cmd, "invalid_target", "target must be between -70 and 0 LUFS")
+	}
+	if flags.truePeak > 0 {
+		return common.WriteError(cmd, "invalid_true_peak", "true peak must not be above 0 dBTP")
+	}
+
+	c, err := loadClip(args[0])
+	if err != nil {
+		return writeLoadError(cmd, err)
+	}
+
+	input := integratedLoudness(c)
+	if math.IsInf(input, -1) {
+		return common.WriteError(cmd, "silent_input", "the input is too quiet to measure its loudness")
+	}
+
+	gain := flags.target - input
+	limited := false
+	if p := truePeak(c); p > 0 && toDB(p)+gain > flags.truePeak {
+		gain = flags.truePeak - toDB(p)
+		limited = true
+	}
+	applyGain(c, fromDB(gain))
+
+	absPath, err := saveClip(cmd, output, c)
+	if err != nil {
+		return err
+	}
+	return common.WriteSuccess(cmd, normalizeResponse{
+		Success:        true,
+		File:           absPath,
+		InputLoudness:  decibels(input),
+		OutputLoudness: decibels(input + gain),
+		Gain:           decibels(gain),
+		TruePeak:       decibels(toDB(truePeak(c))),
+		Limited:        limited,
+	})
+}
@@ -0,0 +1,74 @@
+package audio
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	input := writeTestClip(t, "quiet.wav", sine(48000, 2, 1000, fromDB(-30), 3))
+	output := filepath.Join(t.TempDir(), "norm.wav")
+
+	cmd := newNormalizeCmd()
+	stdout, stderr, err := executeCommand(cmd, input, "--target", "-16", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+
+	resp := decodeResponse(t, stdout)
+	if gain := resp["gain_db"].(float64); math.Abs(gain-14) > 0.2 {
+		t.Errorf("expected about 14 dB of gain, got %v", gain)
+	}
+	if resp["limited"] != false {
+		t.Errorf("expected no limiting, got %v", resp)
+	}
+
+	normalized, err := loadClip(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loudness := integratedLoudness(normalized); math.Abs(loudness+16) > 0.1 {
+		t.Errorf("expected -16 LUFS, got %.2f", loudness)
+	}
+}
+
+func TestNormalize_TruePeakLimit(t *testing.T) {
+	// A stereo sine reads its peak level in LUFS, so -0.5 LUFS needs a -0.5 dB peak
+	input := writeTestClip(t, "loud.wav", sine(48000, 2, 1000, fromDB(-3), 3))
+	output := filepath.Join(t.TempDir(), "norm.wav")
+
+	cmd := newNormalizeCmd()
+	stdout, stderr, err := executeCommand(cmd, input, "--target", "-0.5", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+
+	resp := decodeResponse(t, stdout)
+	if resp["limited"] != true {
+		t.Errorf("expected the gain to be limited, got %v", resp)
+	}
+	if tp := resp["true_peak_dbtp"].(float64); tp > -0.99 {
+		t.Errorf("expected the true peak at most -1 dBTP, got %v", tp)
+	}
+}
+
+func TestNormalize_SilentInput(t *testing.T) {
+	input := writeTestClip(t, "silence.wav", silence(48000, 1, 1))
+
+	cmd := newNormalizeCmd()
+	_, stderr, err := executeCommand(cmd, input, "-o", filepath.Join(t.TempDir(), "out.wav"))
+	if err == nil {
+		t.Fatal("expected error for silent input")
+	}
+	expectErrorCode(t, stderr, "silent_input")
+}
+
+func TestNormalize_InvalidTarget(t *testing.T) {
+	cmd := newNormalizeCmd()
+	_, stderr, err := executeCommand(cmd, "in.wav", "--target", "3", "-o", "out.wav")
+	if err == nil {
+		t.Fatal("expected error for a positive target")
+	}
+	expectErrorCode(t, stderr, "invalid_target")
+}
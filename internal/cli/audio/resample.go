@@ -0,0 +1,92 @@
+package audio
+
+import (
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/spf13/cobra"
+)
+
+type resampleFlags struct {
+	output   string
+	rate     int
+	channels int
+}
+
+type resampleResponse struct {
+	Success    bool    `json:"success"`
+	File       string  `json:"file"`
+	Duration   float64 `json:"duration"`
+	SampleRate int     `json:"sample_rate"`
+	Channels   int     `json:"channels"`
+}
+
+var resampleCmd = newResampleCmd()
+
+func newResampleCmd() *cobra.Command {
+	flags := &resampleFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "resample <file> -o <output>",
+		Short: "Change the sample rate or channel count",
+		Long: `Convert an audio file to another sample rate with band-limited (windowed
+sinc) interpolation, and optionally to mono or stereo.`,
+		Example: `  rawgenai audio resample speech.mp3 --rate 16000 --channels 1 -o speech_16k.wav
+  rawgenai audio resample voice.pcm --pcm-rate 24000 --rate 48000 -o voice_48k.wav`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runResample(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.wav, .pcm)")
+	cmd.Flags().IntVar(&flags.rate, "rate", 0, "Output sample rate in Hz (default: keep)")
+	cmd.Flags().IntVar(&flags.channels, "channels", 0, "Output channels (default: keep)")
+
+	return cmd
+}
+
+func runResample(cmd *cobra.Command, args []string, flags *resampleFlags) error {
+	if flags.output == "" {
+		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
+	}
+	output, err := outputPath(flags.output)
+	if err != nil {
+		return common.WriteError(cmd, "unsupported_format", err.Error())
+	}
+	if flags.rate == 0 && flags.channels == 0 {
+		return common.WriteError(cmd, "missing_format", "--rate or --channels is required")
+	}
+	if flags.rate < 0 || flags.rate > 384000 {
+		return common.WriteError(cmd, "invalid_rate", "rate must be between 1 and 384000 Hz")
+	}
+	if flags.channels < 0 || flags.channels > 8 {
+		return common.WriteError(cmd, "invalid_channels", "channels must be between 1 and 8")
+	}
+
+	c, err := loadClip(args[0])
+	if err != nil {
+		return writeLoadError(cmd, err)
+	}
+
+	rate, channels := c.rate, c.channels
+	if flags.rate > 0 {
+		rate = flags.rate
+	}
+	if flags.channels > 0 {
+		channels = flags.channels
+	}
+	out := convert(c, rate, channels)
+
+	absPath, err := saveClip(cmd, output, out)
+	if err != nil {
+		return err
+	}
+	return common.WriteSuccess(cmd, resampleResponse{
+		Success:    true,
+		File:       absPath,
+		Duration:   seconds(out.duration()),
+		SampleRate: out.rate,
+		Channels:   out.channels,
+	})
+}
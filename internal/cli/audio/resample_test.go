@@ -0,0 +1,42 @@
+package audio
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResampleCmd(t *testing.T) {
+	input := writeTestClip(t, "tone.wav", sine(48000, 2, 440, 0.5, 1))
+	output := filepath.Join(t.TempDir(), "tone_16k.pcm")
+
+	cmd := newResampleCmd()
+	stdout, stderr, err := executeCommand(cmd, input, "--rate", "16000", "--channels", "1", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+
+	resp := decodeResponse(t, stdout)
+	if resp["sample_rate"] != 16000.0 || resp["channels"] != 1.0 || resp["duration"] != 1.0 {
+		t.Errorf("unexpected response: %v", resp)
+	}
+
+	saved := pcmInput
+	pcmInput.SampleRate, pcmInput.Channels = 16000, 1
+	defer func() { pcmInput = saved }()
+	resampled, err := loadClip(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resampled.frames() != 16000 {
+		t.Errorf("expected 16000 frames, got %d", resampled.frames())
+	}
+}
+
+func TestResampleCmd_MissingFormat(t *testing.T) {
+	cmd := newResampleCmd()
+	_, stderr, err := executeCommand(cmd, "in.wav", "-o", "out.wav")
+	if err == nil {
+		t.Fatal("expected error without --rate or --channels")
+	}
+	expectErrorCode(t, stderr, "missing_format")
+}
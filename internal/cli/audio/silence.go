@@ -0,0 +1,146 @@
+package audio
+
+import (
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/spf13/cobra"
+)
+
+// silenceWindow is the analysis window for silence detection, in seconds.
+const silenceWindow = 0.01
+
+type silenceTrimFlags struct {
+	output     string
+	threshold  float64
+	padding    float64
+	minSilence float64
+}
+
+type silenceRange struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+type silenceTrimResponse struct {
+	Success      bool           `json:"success"`
+	File         string         `json:"file,omitempty"`
+	Duration     float64        `json:"duration"`
+	RemovedStart float64        `json:"removed_start"`
+	RemovedEnd   float64        `json:"removed_end"`
+	Silences     []silenceRange `json:"silences"`
+}
+
+var silenceTrimCmd = newSilenceTrimCmd()
+
+func newSilenceTrimCmd() *cobra.Command {
+	flags := &silenceTrimFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "silence-trim <file> [-o <output>]",
+		Short: "Detect silence and trim it from both ends",
+		Long: `Detect silence in an audio file and trim it from the start and end.
+
+Audio quieter than --threshold counts as silence. The response lists how much
+was removed from each end and the pauses inside the audio that last at least
+--min-silence, with times relative to the trimmed output. Without -o the
+silence is only detected.`,
+		Example: `  rawgenai audio silence-trim speech.wav -o trimmed.wav
+  rawgenai audio silence-trim speech.mp3 --threshold -45 --padding 0.2 -o trimmed.wav
+  rawgenai audio silence-trim speech.mp3 --min-silence 1`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSilenceTrim(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.wav, .pcm); detect only if omitted")
+	cmd.Flags().Float64Var(&flags.threshold, "threshold", -50, "Silence threshold in dBFS")
+	cmd.Flags().Float64Var(&flags.padding, "padding", 0.1, "Seconds of silence to keep at each end")
+	cmd.Flags().Float64Var(&flags.minSilence, "min-silence", 0.5, "Shortest pause to report, in seconds")
+
+	return cmd
+}
+
+func runSilenceTrim(cmd *cobra.Command, args []string, flags *silenceTrimFlags) error {
+	var output string
+	if flags.output != "" {
+		var err error
+		if output, err = outputPath(flags.output); err != nil {
+			return common.WriteError(cmd, "unsupported_format", err.Error())
+		}
+	}
+	if flags.threshold >= 0 {
+		return common.WriteError(cmd, "invalid_threshold", "threshold must be below 0 dBFS")
+	}
+	if flags.padding < 0 {
+		return common.WriteError(cmd, "invalid_padding", "padding must not be negative")
+	}
+	if flags.minSilence <= 0 {
+		return common.WriteError(cmd, "invalid_min_silence", "min-silence must be positive")
+	}
+
+	c, err := loadClip(args[0])
+	if err != nil {
+		return writeLoadError(cmd, err)
+	}
+
+	window := max(int(silenceWindow*float64(c.rate)), 1)
+	threshold := fromDB(flags.threshold)
+	loud := levels(c, window)
+	first, last := -1, -1
+	for i, level := range loud {
+		if level >= threshold {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return common.WriteError(cmd, "silent_input", "the input is silent at this threshold")
+	}
+
+	padding := int(flags.padding * float64(c.rate))
+	from := max(first*window-padding, 0)
+	to := min((last+1)*window+padding, c.frames())
+
+	// Pauses between the first and last sound
+	var silences []silenceRange
+	minWindows := int(flags.minSilence/silenceWindow + 0.5)
+	for i := first; i <= last; {
+		if loud[i] >= threshold {
+			i++
+			continue
+		}
+		j := i
+		for j <= last && loud[j] < threshold {
+			j++
+		}
+		if j-i >= minWindows {
+			silences = append(silences, silenceRange{
+				Start: seconds(float64(i*window-from) / float64(c.rate)),
+				End:   seconds(float64(j*window-from) / float64(c.rate)),
+			})
+		}
+		i = j
+	}
+
+	out := c.slice(from, to)
+	result := silenceTrimResponse{
+		Success:      true,
+		Duration:     seconds(out.duration()),
+		RemovedStart: seconds(float64(from) / float64(c.rate)),
+		RemovedEnd:   seconds(float64(c.frames()-to) / float64(c.rate)),
+		Silences:     silences,
+	}
+	if result.Silences == nil {
+		result.Silences = []silenceRange{}
+	}
+	if output != "" {
+		if result.File, err = saveClip(cmd, output, out); err != nil {
+			return err
+		}
+	}
+	return common.WriteSuccess(cmd, result)
+}
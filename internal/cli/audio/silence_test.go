@@ -0,0 +1,73 @@
+package audio
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSilenceTrim(t *testing.T) {
+	speech := join(
+		silence(16000, 1, 1),
+		sine(16000, 1, 440, 0.5, 1),
+		silence(16000, 1, 0.8),
+		sine(16000, 1, 440, 0.5, 1),
+		silence(16000, 1, 2),
+	)
+	input := writeTestClip(t, "speech.wav", speech)
+	output := filepath.Join(t.TempDir(), "trimmed.wav")
+
+	cmd := newSilenceTrimCmd()
+	stdout, stderr, err := executeCommand(cmd, input, "--padding", "0.1", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+
+	resp := decodeResponse(t, stdout)
+	if resp["removed_start"] != 0.9 || resp["removed_end"] != 1.9 || resp["duration"] != 3.0 {
+		t.Errorf("unexpected response: %v", resp)
+	}
+	silences := resp["silences"].([]any)
+	if len(silences) != 1 {
+		t.Fatalf("expected one pause, got %v", silences)
+	}
+	pause := silences[0].(map[string]any)
+	if pause["start"] != 1.1 || pause["end"] != 1.9 {
+		t.Errorf("expected the pause at 1.1-1.9s, got %v", pause)
+	}
+
+	trimmed, err := loadClip(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trimmed.frames() != 48000 {
+		t.Errorf("expected 48000 frames, got %d", trimmed.frames())
+	}
+}
+
+func TestSilenceTrim_DetectOnly(t *testing.T) {
+	input := writeTestClip(t, "speech.wav", join(silence(16000, 1, 0.5), sine(16000, 1, 440, 0.5, 1)))
+
+	cmd := newSilenceTrimCmd()
+	stdout, stderr, err := executeCommand(cmd, input, "--padding", "0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+	resp := decodeResponse(t, stdout)
+	if _, ok := resp["file"]; ok {
+		t.Error("expected no file without -o")
+	}
+	if resp["removed_start"] != 0.5 || resp["removed_end"] != 0.0 {
+		t.Errorf("unexpected response: %v", resp)
+	}
+}
+
+func TestSilenceTrim_SilentInput(t *testing.T) {
+	input := writeTestClip(t, "silence.wav", silence(16000, 1, 1))
+
+	cmd := newSilenceTrimCmd()
+	_, stderr, err := executeCommand(cmd, input)
+	if err == nil {
+		t.Fatal("expected error for silent input")
+	}
+	expectErrorCode(t, stderr, "silent_input")
+}
@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"fmt"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/spf13/cobra"
+)
+
+type trimFlags struct {
+	output   string
+	start    float64
+	end      float64
+	duration float64
+	fadeIn   float64
+	fadeOut  float64
+}
+
+type trimResponse struct {
+	Success    bool    `json:"success"`
+	File       string  `json:"file"`
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Duration   float64 `json:"duration"`
+	SampleRate int     `json:"sample_rate"`
+	Channels   int     `json:"channels"`
+}
+
+var trimCmd = newTrimCmd()
+
+func newTrimCmd() *cobra.Command {
+	flags := &trimFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "trim <file> -o <output>",
+		Short: "Cut a time range out of an audio file",
+		Long: `Cut a time range out of an audio file, with optional fades at both ends.
+
+Times are in seconds. Without --end or --duration the range runs to the end
+of the input.`,
+		Example: `  rawgenai audio trim speech.mp3 --start 1.5 --end 12 -o cut.wav
+  rawgenai audio trim music.wav --duration 30 --fade-out 2 -o intro.wav`,
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTrim(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.wav, .pcm)")
+	cmd.Flags().Float64Var(&flags.start, "start", 0, "Start time in seconds")
+	cmd.Flags().Float64Var(&flags.end, "end", 0, "End time in seconds")
+	cmd.Flags().Float64Var(&flags.duration, "duration", 0, "Length in seconds (instead of --end)")
+	cmd.Flags().Float64Var(&flags.fadeIn, "fade-in", 0, "Fade-in length in seconds")
+	cmd.Flags().Float64Var(&flags.fadeOut, "fade-out", 0, "Fade-out length in seconds")
+
+	return cmd
+}
+
+func runTrim(cmd *cobra.Command, args []string, flags *trimFlags) error {
+	if flags.output == "" {
+		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
+	}
+	output, err := outputPath(flags.output)
+	if err != nil {
+		return common.WriteError(cmd, "unsupported_format", err.Error())
+	}
+	if cmd.Flags().Changed("end") && cmd.Flags().Changed("duration") {
+		return common.WriteError(cmd, "conflicting_flags", "--end and --duration cannot be used together")
+	}
+	if flags.start < 0 {
+		return common.WriteError(cmd, "invalid_range", "start must not be negative")
+	}
+	end := flags.end
+	if cmd.Flags().Changed("duration") {
+		if flags.duration <= 0 {
+			return common.WriteError(cmd, "invalid_range", "duration must be positive")
+		}
+		end = flags.start + flags.duration
+	}
+	if (cmd.Flags().Changed("end") || cmd.Flags().Changed("duration")) && end <= flags.start {
+		return common.WriteError(cmd, "invalid_range", "end must be after start")
+	}
+	if flags.fadeIn < 0 || flags.fadeOut < 0 {
+		return common.WriteError(cmd, "invalid_fade", "fade lengths must not be negative")
+	}
+
+	c, err := loadClip(args[0])
+	if err != nil {
+		return writeLoadError(cmd, err)
+	}
+
+	from := c.frameAt(flags.start)
+	to := c.frames()
+	if end > 0 {
+		to = c.frameAt(end)
+	}
+	if from >= c.frames() {
+		return common.WriteError(cmd, "invalid_range", fmt.Sprintf("start %.3fs is beyond the end of the input (%.3fs)", flags.start, c.duration()))
+	}
+
+	out := c.slice(from, to)
+	fade(out, int(flags.fadeIn*float64(c.rate)), int(flags.fadeOut*float64(c.rate)))
+
+	absPath, err := saveClip(cmd, output, out)
+	if err != nil {
+		return err
+	}
+	return common.WriteSuccess(cmd, trimResponse{
+		Success:    true,
+		File:       absPath,
+		Start:      seconds(float64(from) / float64(c.rate)),
+		End:        seconds(float64(to) / float64(c.rate)),
+		Duration:   seconds(out.duration()),
+		SampleRate: out.rate,
+		Channels:   out.channels,
+	})
+}
@@ -0,0 +1,71 @@
+package audio
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTrim(t *testing.T) {
+	input := writeTestClip(t, "tone.wav", sine(16000, 1, 440, 0.5, 3))
+	output := filepath.Join(t.TempDir(), "cut.wav")
+
+	cmd := newTrimCmd()
+	stdout, stderr, err := executeCommand(cmd, input, "--start", "0.5", "--duration", "1", "--fade-out", "0.1", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+
+	resp := decodeResponse(t, stdout)
+	if resp["start"] != 0.5 || resp["end"] != 1.5 || resp["duration"] != 1.0 {
+		t.Errorf("unexpected response: %v", resp)
+	}
+	cut, err := loadClip(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cut.frames() != 16000 {
+		t.Errorf("expected 16000 frames, got %d", cut.frames())
+	}
+	if last := cut.samples[len(cut.samples)-1]; last != 0 {
+		t.Errorf("expected the fade-out to end in silence, got %f", last)
+	}
+}
+
+func TestTrim_EndBeyondInput(t *testing.T) {
+	input := writeTestClip(t, "tone.wav", sine(16000, 1, 440, 0.5, 1))
+	output := filepath.Join(t.TempDir(), "cut.wav")
+
+	cmd := newTrimCmd()
+	stdout, stderr, err := executeCommand(cmd, input, "--start", "0.5", "--end", "5", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+	if resp := decodeResponse(t, stdout); resp["end"] != 1.0 {
+		t.Errorf("expected the range clamped to the input, got %v", resp)
+	}
+}
+
+func TestTrim_InvalidRange(t *testing.T) {
+	input := writeTestClip(t, "tone.wav", sine(16000, 1, 440, 0.5, 1))
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"end before start", []string{"--start", "1", "--end", "0.5"}, "invalid_range"},
+		{"start beyond input", []string{"--start", "2"}, "invalid_range"},
+		{"end and duration", []string{"--end", "1", "--duration", "1"}, "conflicting_flags"},
+		{"negative fade", []string{"--fade-in", "-1"}, "invalid_fade"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newTrimCmd()
+			args := append([]string{input, "-o", filepath.Join(t.TempDir(), "out.wav")}, tt.args...)
+			_, stderr, err := executeCommand(cmd, args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			expectErrorCode(t, stderr, tt.code)
+		})
+	}
+}
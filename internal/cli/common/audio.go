@@ -0,0 +1,241 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// ErrUnsupportedAudio is returned for audio the built-in decoders cannot play.
+var ErrUnsupportedAudio = errors.New("unsupported audio format")
+
+// PCMEncoding is the sample layout of decoded audio.
+type PCMEncoding int
+
+const (
+	PCMS16LE PCMEncoding = iota
+	PCMU8
+	PCMF32LE
+)
+
+// BytesPerSample returns the size of one sample of one channel.
+func (e PCMEncoding) BytesPerSample() int {
+	switch e {
+	case PCMU8:
+		return 1
+	case PCMF32LE:
+		return 4
+	default:
+		return 2
+	}
+}
+
+// AudioFormat describes interleaved PCM samples.
+type AudioFormat struct {
+	SampleRate int
+	Channels   int
+	Encoding   PCMEncoding
+}
+
+// AudioStream is decoded, interleaved PCM in Format.
+type AudioStream struct {
+	Format AudioFormat
+	io.Reader
+}
+
+// DecodeOptions describe raw input that carries no header of its own.
+type DecodeOptions struct {
+	SampleRate int
+	// Channels defaults to 1.
+	Channels int
+}
+
+// AudioDecoder turns an encoded stream into PCM. It returns an error wrapping
+// ErrUnsupportedAudio for input it recognizes but cannot decode.
+type AudioDecoder func(r io.Reader, opts DecodeOptions) (*AudioStream, error)
+
+var audioDecoders = map[string]AudioDecoder{}
+
+// RegisterAudioDecoder makes decoder handle files with the given extensions.
+func RegisterAudioDecoder(decoder AudioDecoder, exts ...string) {
+	for _, ext := range exts {
+		audioDecoders[strings.ToLower(ext)] = decoder
+	}
+}
+
+func init() {
+	RegisterAudioDecoder(decodeMP3, ".mp3")
+	RegisterAudioDecoder(decodeWAV, ".wav")
+	RegisterAudioDecoder(decodePCM, ".pcm", ".raw")
+	RegisterAudioDecoder(decodeFLAC, ".flac")
+	RegisterAudioDecoder(decodeOggOpus, ".opus", ".ogg")
+}
+
+// DecodeAudioNative decodes r with the built-in decoder for the file
+// extension ext, without falling back to ffmpeg. Formats it cannot decode
+// return an error wrapping ErrUnsupportedAudio.
+func DecodeAudioNative(r io.Reader, ext string, opts DecodeOptions) (*AudioStream, error) {
+	decoder, ok := audioDecoders[strings.ToLower(ext)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAudio, ext)
+	}
+	return decoder(r, opts)
+}
+
+// replayLimit caps how much input is kept for handing over to ffmpeg.
+const replayLimit = 4 << 20
+
+// DecodeAudio decodes r according to the file extension ext. Formats without
+// a built-in decoder, and streams the built-in decoder rejects as
+// unsupported, are decoded by ffmpeg when it is installed.
+func DecodeAudio(r io.Reader, ext string, opts DecodeOptions) (*AudioStream, error) {
+	ext = strings.ToLower(ext)
+	decoder, ok := audioDecoders[ext]
+	if !ok {
+		return decodeFFmpeg(r, fmt.Errorf("%w: %s", ErrUnsupportedAudio, ext))
+	}
+
+	rec := &recordingReader{r: r}
+	stream, err := decoder(rec, opts)
+	if errors.Is(err, ErrUnsupportedAudio) && rec.buf.Len() <= replayLimit {
+		return decodeFFmpeg(io.MultiReader(&rec.buf, r), err)
+	}
+	rec.stop()
+	return stream, err
+}
+
+// recordingReader keeps what the built-in decoder consumed while it probes
+// the stream, so the input can be replayed to ffmpeg.
+type recordingReader struct {
+	r       io.Reader
+	buf     bytes.Buffer
+	stopped bool
+}
+
+func (rr *recordingReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	if !rr.stopped {
+		if rr.buf.Len()+n > replayLimit {
+			rr.stop()
+		} else {
+			rr.buf.Write(p[:n])
+		}
+	}
+	return n, err
+}
+
+func (rr *recordingReader) stop() {
+	rr.stopped = true
+	rr.buf = bytes.Buffer{}
+}
+
+func decodePCM(r io.Reader, opts DecodeOptions) (*AudioStream, error) {
+	if opts.SampleRate <= 0 {
+		return nil, fmt.Errorf("raw PCM needs a sample rate")
+	}
+	channels := opts.Channels
+	if channels <= 0 {
+		channels = 1
+	}
+	return &AudioStream{
+		Format: AudioFormat{SampleRate: opts.SampleRate, Channels: channels, Encoding: PCMS16LE},
+		Reader: r,
+	}, nil
+}
+
+func decodeWAV(r io.Reader, _ DecodeOptions) (*AudioStream, error) {
+	header, err := parseWAVHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var encoding PCMEncoding
+	switch header.BitsPerSample {
+	case 8:
+		encoding = PCMU8
+	case 16:
+		encoding = PCMS16LE
+	case 32:
+		encoding = PCMF32LE
+	default:
+		return nil, fmt.Errorf("unsupported bits per sample: %d", header.BitsPerSample)
+	}
+
+	return &AudioStream{
+		Format: AudioFormat{SampleRate: int(header.SampleRate), Channels: int(header.NumChannels), Encoding: encoding},
+		// Limit reader to data size
+		Reader: io.LimitReader(r, int64(header.DataSize)),
+	}, nil
+}
+
+// ffmpegCommand is the ffmpeg binary used for formats without a built-in decoder.
+var ffmpegCommand = "ffmpeg"
+
+// ffmpegFormat is what ffmpeg is asked to produce.
+var ffmpegFormat = AudioFormat{SampleRate: 48000, Channels: 2, Encoding: PCMS16LE}
+
+func decodeFFmpeg(r io.Reader, cause error) (*AudioStream, error) {
+	bin, err := exec.LookPath(ffmpegCommand)
+	if err != nil {
+		return nil, fmt.Errorf("%w (install ffmpeg to play it)", cause)
+	}
+
+	cmd := exec.Command(bin, "-hide_banner", "-loglevel", "error", "-i", "pipe:0",
+		"-f", "s16le", "-ac", fmt.Sprint(ffmpegFormat.Channels), "-ar", fmt.Sprint(ffmpegFormat.SampleRate), "pipe:1")
+	cmd.Stdin = r
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("cannot start ffmpeg: %w", err)
+	}
+	return &AudioStream{Format: ffmpegFormat, Reader: &commandReader{out: out, cmd: cmd, stderr: &stderr}}, nil
+}
+
+// commandReader reads a command's stdout and reports its exit status at EOF.
+type commandReader struct {
+	out    io.Reader
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+func (c *commandReader) Read(p []byte) (int, error) {
+	n, err := c.out.Read(p)
+	if err == io.EOF {
+		if waitErr := c.cmd.Wait(); waitErr != nil {
+			return n, fmt.Errorf("ffmpeg: %s", strings.TrimSpace(c.stderr.String()))
+		}
+	}
+	return n, err
+}
+
+// int16Buffer serves decoded blocks of samples as S16LE bytes.
+type int16Buffer struct {
+	next func() ([]int16, error)
+	buf  []byte
+	err  error
+}
+
+func (b *int16Buffer) Read(p []byte) (int, error) {
+	for len(b.buf) == 0 {
+		if b.err != nil {
+			return 0, b.err
+		}
+		var samples []int16
+		samples, b.err = b.next()
+		b.buf = b.buf[:0]
+		for _, s := range samples {
+			b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(s))
+		}
+	}
+	n := copy(p, b.buf)
+	b.buf = b.buf[n:]
+	return n, nil
+}
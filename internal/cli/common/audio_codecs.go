@@ -0,0 +1,198 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hajimehoshi/go-mp3"
+	"github.com/mewkiz/flac"
+	"github.com/pion/opus"
+)
+
+func decodeMP3(r io.Reader, _ DecodeOptions) (*AudioStream, error) {
+	decoder, err := mp3.NewDecoder(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode mp3: %w", err)
+	}
+	return &AudioStream{
+		Format: AudioFormat{SampleRate: decoder.SampleRate(), Channels: 2, Encoding: PCMS16LE},
+		Reader: decoder,
+	}, nil
+}
+
+func decodeFLAC(r io.Reader, _ DecodeOptions) (*AudioStream, error) {
+	stream, err := flac.New(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode flac: %w", err)
+	}
+	info := stream.Info
+	shift := int(info.BitsPerSample) - 16
+
+	next := func() ([]int16, error) {
+		frame, err := stream.ParseNext()
+		if err != nil {
+			return nil, err
+		}
+		channels := len(frame.Subframes)
+		samples := make([]int16, 0, int(frame.BlockSize)*channels)
+		for i := 0; i < int(frame.BlockSize); i++ {
+			for _, sub := range frame.Subframes {
+				s := sub.Samples[i]
+				if shift > 0 {
+					s >>= shift
+				} else {
+					s <<= -shift
+				}
+				samples = append(samples, int16(s))
+			}
+		}
+		return samples, nil
+	}
+
+	return &AudioStream{
+		Format: AudioFormat{SampleRate: int(info.SampleRate), Channels: int(info.NChannels), Encoding: PCMS16LE},
+		Reader: &int16Buffer{next: next},
+	}, nil
+}
+
+// opusSampleRate is the rate Opus is decoded at; pre-skip is counted in it.
+const opusSampleRate = 48000
+
+// maxOpusPacketSamples is the longest Opus packet (120 ms) per channel.
+const maxOpusPacketSamples = 5760
+
+func decodeOggOpus(r io.Reader, _ DecodeOptions) (*AudioStream, error) {
+	ogg := newOggPacketReader(r)
+
+	head, err := ogg.next()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read ogg stream: %w", err)
+	}
+	if !bytes.HasPrefix(head, []byte("OpusHead")) || len(head) < 19 {
+		return nil, fmt.Errorf("%w: ogg stream is not opus", ErrUnsupportedAudio)
+	}
+	channels := int(head[9])
+	preSkip := int(binary.LittleEndian.Uint16(head[10:12]))
+	if head[18] != 0 || channels < 1 || channels > 2 {
+		return nil, fmt.Errorf("%w: opus channel mapping %d with %d channels", ErrUnsupportedAudio, head[18], channels)
+	}
+	// OpusTags
+	if _, err := ogg.next(); err != nil {
+		return nil, fmt.Errorf("cannot read ogg stream: %w", err)
+	}
+
+	decoder, err := opus.NewDecoderWithOutput(opusSampleRate, channels)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]int16, maxOpusPacketSamples*channels)
+
+	next := func() ([]int16, error) {
+		for {
+			packet, err := ogg.next()
+			if err != nil {
+				return nil, err
+			}
+			if len(packet) == 0 {
+				continue
+			}
+			n, err := decoder.DecodeToInt16(packet, out)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode opus: %w", err)
+			}
+			samples := out[:n*channels]
+			if preSkip > 0 {
+				skip := min(preSkip, n)
+				preSkip -= skip
+				samples = samples[skip*channels:]
+			}
+			if len(samples) > 0 {
+				return samples, nil
+			}
+		}
+	}
+
+	// Decode the first packet now so unsupported streams are reported
+	// before playback starts
+	first, err := next()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	first = append([]int16(nil), first...)
+	pending := true
+
+	return &AudioStream{
+		Format: AudioFormat{SampleRate: opusSampleRate, Channels: channels, Encoding: PCMS16LE},
+		Reader: &int16Buffer{next: func() ([]int16, error) {
+			if pending {
+				pending = false
+				if len(first) > 0 || err != nil {
+					return first, err
+				}
+			}
+			return next()
+		}},
+	}, nil
+}
+
+// oggPacketReader reassembles the packets of a single logical Ogg stream.
+type oggPacketReader struct {
+	r       *bufio.Reader
+	lacing  []byte
+	partial []byte
+}
+
+func newOggPacketReader(r io.Reader) *oggPacketReader {
+	return &oggPacketReader{r: bufio.NewReader(r)}
+}
+
+func (o *oggPacketReader) next() ([]byte, error) {
+	for {
+		for len(o.lacing) > 0 {
+			size := int(o.lacing[0])
+			o.lacing = o.lacing[1:]
+			segment := make([]byte, size)
+			if _, err := io.ReadFull(o.r, segment); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			o.partial = append(o.partial, segment...)
+			if size < 255 {
+				packet := o.partial
+				o.partial = nil
+				return packet, nil
+			}
+		}
+		if err := o.readPageHeader(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (o *oggPacketReader) readPageHeader() error {
+	var header [27]byte
+	if _, err := io.ReadFull(o.r, header[:]); err != nil {
+		if errors.Is(err, io.EOF) && len(o.partial) == 0 {
+			return io.EOF
+		}
+		return unexpectedEOF(err)
+	}
+	if string(header[0:4]) != "OggS" {
+		return fmt.Errorf("invalid ogg page signature")
+	}
+	o.lacing = make([]byte, header[26])
+	if _, err := io.ReadFull(o.r, o.lacing); err != nil {
+		return unexpectedEOF(err)
+	}
+	return nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,257 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CanJoinAudio reports whether JoinAudio supports the format named by ext.
+func CanJoinAudio(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".mp3", ".wav", ".pcm":
+		return true
+	}
+	return false
+}
+
+// JoinAudio concatenates audio clips of the format named by ext into one
+// file: sample-accurate for WAV and raw PCM, frame-accurate for MP3.
+func JoinAudio(ext string, parts [][]byte) ([]byte, error) {
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	switch strings.ToLower(ext) {
+	case ".pcm":
+		return bytes.Join(parts, nil), nil
+	case ".wav":
+		return joinWAV(parts)
+	case ".mp3":
+		return joinMP3(parts), nil
+	}
+	return nil, fmt.Errorf("%w: cannot join %s", ErrUnsupportedAudio, ext)
+}
+
+func joinWAV(parts [][]byte) ([]byte, error) {
+	var format AudioFormat
+	var pcm bytes.Buffer
+	for i, part := range parts {
+		partFormat, data, err := splitWAV(part)
+		if err != nil {
+			return nil, fmt.Errorf("part %d: %w", i+1, err)
+		}
+		if i == 0 {
+			format = partFormat
+		} else if partFormat != format {
+			return nil, fmt.Errorf("part %d: format %+v differs from %+v", i+1, partFormat, format)
+		}
+		pcm.Write(data)
+	}
+	return PCMToWAV(pcm.Bytes(), format), nil
+}
+
+// splitWAV returns the format and sample data of a WAV file.
+func splitWAV(data []byte) (AudioFormat, []byte, error) {
+	r := bytes.NewReader(data)
+	stream, err := decodeWAV(r, DecodeOptions{})
+	if err != nil {
+		return AudioFormat{}, nil, err
+	}
+	samples, err := io.ReadAll(stream)
+	if err != nil {
+		return AudioFormat{}, nil, err
+	}
+	// Streamed WAVs often carry a zero data size; the samples run to the end
+	if len(samples) == 0 {
+		samples = data[len(data)-r.Len():]
+	}
+	frame := stream.Format.Channels * stream.Format.Encoding.BytesPerSample()
+	if frame > 0 {
+		samples = samples[:len(samples)/frame*frame]
+	}
+	return stream.Format, samples, nil
+}
+
+// joinMP3 keeps the audio frames of every part, dropping ID3 tags and the
+// Xing/Info/VBRI header frames whose frame counts would be wrong for the
+// joined stream.
+func joinMP3(parts [][]byte) []byte {
+	var out bytes.Buffer
+	for _, part := range parts {
+		for _, frame := range mp3Frames(stripID3(part)) {
+			if !isMP3InfoFrame(frame) {
+				out.Write(frame)
+			}
+		}
+	}
+	return out.Bytes()
+}
+
+func stripID3(data []byte) []byte {
+	if len(data) >= 10 && string(data[:3]) == "ID3" {
+		size := int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f)
+		size += 10
+		if data[5]&0x10 != 0 {
+			size += 10 // footer
+		}
+		data = data[min(size, len(data)):]
+	}
+	if len(data) >= 128 && string(data[len(data)-128:len(data)-125]) == "TAG" {
+		data = data[:len(data)-128]
+	}
+	return data
+}
+
+var (
+	mp3Bitrates = [2][3][16]int{
+		// MPEG-1: layer I, II, III
+		{
+			{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+			{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		},
+		// MPEG-2 and 2.5: layer I, II, III
+		{
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		},
+	}
+	mp3SampleRates = [3]int{44100, 48000, 32000}
+)
+
+// mp3FrameSize returns the length of the frame whose header starts h, or 0
+// when h is not a valid frame header.
+func mp3FrameSize(h []byte) int {
+	if len(h) < 4 || h[0] != 0xff || h[1]&0xe0 != 0xe0 {
+		return 0
+	}
+	version := (h[1] >> 3) & 3 // 0: 2.5, 2: 2, 3: 1
+	layer := (h[1] >> 1) & 3   // 1: III, 2: II, 3: I
+	bitrateIndex := h[2] >> 4
+	rateIndex := (h[2] >> 2) & 3
+	padding := int(h[2]>>1) & 1
+	if version == 1 || layer == 0 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return 0
+	}
+
+	v := 0
+	sampleRate := mp3SampleRates[rateIndex]
+	switch version {
+	case 2:
+		v, sampleRate = 1, sampleRate/2
+	case 0:
+		v, sampleRate = 1, sampleRate/4
+	}
+	bitrate := mp3Bitrates[v][3-layer][bitrateIndex] * 1000
+
+	switch {
+	case layer == 3: // Layer I
+		return (12*bitrate/sampleRate + padding) * 4
+	case layer == 1 && v == 1: // Layer III, MPEG-2/2.5
+		return 72*bitrate/sampleRate + padding
+	default:
+		return 144*bitrate/sampleRate + padding
+	}
+}
+
+// mp3Frames returns the complete frames in data, skipping anything between
+// them that is not a frame.
+func mp3Frames(data []byte) [][]byte {
+	var frames [][]byte
+	for i := 0; i+4 <= len(data); {
+		size := mp3FrameSize(data[i:])
+		if size == 0 || i+size > len(data) {
+			i++
+			continue
+		}
+		frames = append(frames, data[i:i+size])
+		i += size
+	}
+	return frames
+}
+
+func isMP3InfoFrame(frame []byte) bool {
+	// The tag sits after the side information, at most 36 bytes in
+	head := frame[:min(len(frame), 48)]
+	return bytes.Contains(head, []byte("Xing")) || bytes.Contains(head, []byte("Info")) || bytes.Contains(head, []byte("VBRI"))
+}
+
+// MP3Duration returns the playing time of MP3 data in seconds, counted from
+// its frames.
+func MP3Duration(data []byte) float64 {
+	var seconds float64
+	for _, frame := range mp3Frames(stripID3(data)) {
+		if isMP3InfoFrame(frame) {
+			continue
+		}
+		seconds += float64(mp3FrameSamples(frame)) / float64(mp3FrameRate(frame))
+	}
+	return seconds
+}
+
+// AudioClock measures the playing time of audio that arrives in pieces.
+type AudioClock struct {
+	ext  string
+	opts DecodeOptions
+	data []byte
+}
+
+// NewAudioClock returns a clock for audio in the format named by ext.
+func NewAudioClock(ext string, opts DecodeOptions) *AudioClock {
+	return &AudioClock{ext: ext, opts: opts}
+}
+
+// Write records audio received.
+func (c *AudioClock) Write(p []byte) (int, error) {
+	c.data = append(c.data, p...)
+	return len(p), nil
+}
+
+// Duration returns the playing time in seconds of the audio received so far.
+func (c *AudioClock) Duration() (float64, error) {
+	return AudioDuration(c.data, c.ext, c.opts)
+}
+
+// AudioDuration returns the playing time in seconds of audio data in the
+// format named by ext.
+func AudioDuration(data []byte, ext string, opts DecodeOptions) (float64, error) {
+	if strings.EqualFold(ext, ".mp3") {
+		return MP3Duration(data), nil
+	}
+	stream, err := DecodeAudio(bytes.NewReader(data), ext, opts)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(io.Discard, stream)
+	if err != nil {
+		return 0, err
+	}
+	f := stream.Format
+	return float64(n) / float64(f.SampleRate*f.Channels*f.Encoding.BytesPerSample()), nil
+}
+
+func mp3FrameRate(h []byte) int {
+	rate := mp3SampleRates[(h[2]>>2)&3]
+	switch (h[1] >> 3) & 3 {
+	case 2:
+		return rate / 2
+	case 0:
+		return rate / 4
+	}
+	return rate
+}
+
+func mp3FrameSamples(h []byte) int {
+	version := (h[1] >> 3) & 3
+	switch (h[1] >> 1) & 3 {
+	case 3: // Layer I
+		return 384
+	case 1: // Layer III
+		if version != 3 {
+			return 576
+		}
+	}
+	return 1152
+}
@@ -0,0 +1,108 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+// testMP3Frame returns a silent MPEG-1 Layer III frame at 128 kbps, 44.1 kHz.
+func testMP3Frame(tag string) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+	copy(frame[36:], tag)
+	return frame
+}
+
+func TestJoinAudio_PCM(t *testing.T) {
+	joined, err := JoinAudio(".pcm", [][]byte{{1, 2}, {3, 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(joined, []byte{1, 2, 3, 4}) {
+		t.Errorf("unexpected result: %v", joined)
+	}
+}
+
+func TestJoinAudio_WAV(t *testing.T) {
+	format := AudioFormat{SampleRate: 16000, Channels: 1, Encoding: PCMS16LE}
+	first := PCMToWAV([]byte{1, 0, 2, 0}, format)
+	// A streamed WAV with an unknown data size
+	second := PCMToWAV([]byte{3, 0, 4, 0, 5}, format)
+	copy(second[40:44], []byte{0, 0, 0, 0})
+
+	joined, err := JoinAudio(".wav", [][]byte{first, second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := PCMToWAV([]byte{1, 0, 2, 0, 3, 0, 4, 0}, format)
+	if !bytes.Equal(joined, expected) {
+		t.Errorf("expected %v, got %v", expected, joined)
+	}
+}
+
+func TestJoinAudio_WAVFormatMismatch(t *testing.T) {
+	first := PCMToWAV([]byte{0, 0}, AudioFormat{SampleRate: 16000, Channels: 1, Encoding: PCMS16LE})
+	second := PCMToWAV([]byte{0, 0}, AudioFormat{SampleRate: 24000, Channels: 1, Encoding: PCMS16LE})
+	if _, err := JoinAudio(".wav", [][]byte{first, second}); err == nil {
+		t.Error("expected an error for different sample rates")
+	}
+}
+
+func TestJoinAudio_MP3(t *testing.T) {
+	id3 := []byte("ID3\x03\x00\x00\x00\x00\x00\x04tags")
+	first := append(append(append([]byte{}, id3...), testMP3Frame("Xing")...), testMP3Frame("")...)
+	// A truncated frame at the end is dropped
+	second := append(append([]byte{}, testMP3Frame("")...), testMP3Frame("")[:100]...)
+
+	joined, err := JoinAudio(".mp3", [][]byte{first, second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(joined) != 2*417 {
+		t.Fatalf("expected 2 frames, got %d bytes", len(joined))
+	}
+	if !bytes.Equal(joined[:417], testMP3Frame("")) {
+		t.Error("expected the audio frame of the first part")
+	}
+
+	duration := MP3Duration(joined)
+	if math.Abs(duration-2*1152.0/44100) > 1e-9 {
+		t.Errorf("unexpected duration: %f", duration)
+	}
+}
+
+func TestJoinAudio_Unsupported(t *testing.T) {
+	if CanJoinAudio(".opus") {
+		t.Error("opus should not be joinable")
+	}
+	if _, err := JoinAudio(".opus", [][]byte{{1}, {2}}); !errors.Is(err, ErrUnsupportedAudio) {
+		t.Errorf("expected ErrUnsupportedAudio, got: %v", err)
+	}
+}
+
+func TestAudioDuration(t *testing.T) {
+	format := AudioFormat{SampleRate: 16000, Channels: 1, Encoding: PCMS16LE}
+	pcm := make([]byte, 16000)
+
+	tests := []struct {
+		name string
+		data []byte
+		ext  string
+		want float64
+	}{
+		{"pcm", pcm, ".pcm", 0.5},
+		{"wav", PCMToWAV(pcm, format), ".wav", 0.5},
+		{"mp3", append(testMP3Frame(""), testMP3Frame("")...), ".mp3", 2 * 1152.0 / 44100},
+	}
+	for _, tt := range tests {
+		got, err := AudioDuration(tt.data, tt.ext, DecodeOptions{SampleRate: 16000})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
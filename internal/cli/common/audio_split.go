@@ -0,0 +1,231 @@
+package common
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// Long recordings are transcribed in chunks. Chunks are mono 16-bit WAV at
+// no more than ChunkSampleRate, which is what speech models work at anyway
+// and keeps an hour of audio around 115 MB in memory.
+const (
+	ChunkSampleRate = 16000
+
+	// DefaultChunkOverlap is the audio, in seconds, that consecutive chunks
+	// share so that a word at a cut is heard whole by one of them.
+	DefaultChunkOverlap = 2.0
+
+	// silenceWindow is the length, in seconds, of the windows compared when
+	// looking for the quietest place to cut.
+	silenceWindow = 0.1
+)
+
+// AudioChunk is a piece of a longer recording, encoded as WAV. Offset and
+// Duration are in seconds; Offset is where the chunk starts in the
+// recording.
+type AudioChunk struct {
+	Offset   float64
+	Duration float64
+	Data     []byte
+}
+
+// End returns where the chunk ends in the recording, in seconds.
+func (c AudioChunk) End() float64 {
+	return c.Offset + c.Duration
+}
+
+// SpoolAudio copies audio read from r, such as stdin, to a temporary file
+// named after the audio format of its content, so it can be checked and split like
+// a file given by path. defaultExt names content that is not recognized. The
+// caller removes the file.
+func SpoolAudio(r io.Reader, prefix, defaultExt string) (string, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(512)
+	ext := SniffExt(head)
+	if _, ok := audioDecoders[ext]; !ok {
+		ext = defaultExt
+	}
+	file, err := os.CreateTemp("", prefix+"*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("cannot create temp file: %w", err)
+	}
+	if _, err := io.Copy(file, br); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("cannot read stdin: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// CanSplitAudio reports whether SplitAudio supports the format named by ext.
+func CanSplitAudio(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".mp3", ".wav":
+		return true
+	}
+	return false
+}
+
+// SplitAudio decodes the recording in r and cuts it into chunks of at most
+// maxDuration seconds. Each cut is placed at the quietest moment of the last
+// quarter of its chunk, and every chunk after the first starts overlap
+// seconds before the previous cut. A recording shorter than maxDuration is
+// returned as a single chunk.
+func SplitAudio(r io.Reader, ext string, maxDuration, overlap float64) ([]AudioChunk, error) {
+	if !CanSplitAudio(ext) {
+		return nil, fmt.Errorf("%w: cannot split %s", ErrUnsupportedAudio, ext)
+	}
+	// Catch files that are not what their extension says before decoding
+	// them, which would scan the whole file
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(512)
+	if SniffExt(head) != strings.ToLower(ext) {
+		return nil, fmt.Errorf("%w: not a %s file", ErrUnsupportedAudio, strings.TrimPrefix(strings.ToLower(ext), "."))
+	}
+	stream, err := DecodeAudio(br, ext, DecodeOptions{})
+	if err != nil {
+		return nil, err
+	}
+	samples, rate, err := readMono(stream)
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("audio contains no samples")
+	}
+
+	maxLen := int(maxDuration * float64(rate))
+	search := maxLen / 4
+	overlapLen := min(int(overlap*float64(rate)), search/2)
+	format := AudioFormat{SampleRate: rate, Channels: 1, Encoding: PCMS16LE}
+
+	var chunks []AudioChunk
+	for start := 0; start < len(samples); {
+		end := len(samples)
+		if end-start > maxLen {
+			end = quietestPoint(samples, start+maxLen-search, start+maxLen, int(silenceWindow*float64(rate)))
+		}
+		chunks = append(chunks, AudioChunk{
+			Offset:   float64(start) / float64(rate),
+			Duration: float64(end-start) / float64(rate),
+			Data:     PCMToWAV(int16Bytes(samples[start:end]), format),
+		})
+		if end == len(samples) {
+			break
+		}
+		start = end - overlapLen
+	}
+	return chunks, nil
+}
+
+// readMono reads stream to the end, downmixing it to mono and decimating it
+// to at most ChunkSampleRate by averaging the samples that fall into each
+// output sample.
+func readMono(stream *AudioStream) ([]int16, int, error) {
+	format := stream.Format
+	if format.Channels <= 0 || format.SampleRate <= 0 {
+		return nil, 0, fmt.Errorf("invalid audio format: %d channels at %d Hz", format.Channels, format.SampleRate)
+	}
+	rate := min(format.SampleRate, ChunkSampleRate)
+	sampleSize := format.Encoding.BytesPerSample()
+	frameSize := sampleSize * format.Channels
+
+	var out []int16
+	var frame int64
+	var sum float64
+	var n int
+	bucket := int64(-1)
+
+	buf := make([]byte, frameSize*4096)
+	for {
+		read, err := io.ReadFull(stream, buf)
+		for off := 0; off+frameSize <= read; off += frameSize {
+			var v float64
+			for c := 0; c < format.Channels; c++ {
+				v += sampleValue(buf[off+c*sampleSize:], format.Encoding)
+			}
+			v /= float64(format.Channels)
+
+			if k := frame * int64(rate) / int64(format.SampleRate); k != bucket {
+				if n > 0 {
+					out = append(out, toInt16(sum/float64(n)))
+				}
+				bucket, sum, n = k, 0, 0
+			}
+			sum += v
+			n++
+			frame++
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	if n > 0 {
+		out = append(out, toInt16(sum/float64(n)))
+	}
+	return out, rate, nil
+}
+
+// sampleValue decodes the sample at the start of b as a value in [-1, 1].
+func sampleValue(b []byte, encoding PCMEncoding) float64 {
+	switch encoding {
+	case PCMU8:
+		return (float64(b[0]) - 128) / 128
+	case PCMF32LE:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	default:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / 32768
+	}
+}
+
+func toInt16(v float64) int16 {
+	return int16(math.Max(-32768, math.Min(32767, math.Round(v*32768))))
+}
+
+func int16Bytes(samples []int16) []byte {
+	out := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(s))
+	}
+	return out
+}
+
+// quietestPoint returns the middle of the window of samples[from:to] with
+// the least energy. Later windows win ties so chunks stay as long as
+// possible.
+func quietestPoint(samples []int16, from, to, window int) int {
+	if window <= 0 || to-from < window {
+		return to
+	}
+	best, bestEnergy := to, math.Inf(1)
+	for start := from; start+window <= to; start += window / 2 {
+		var energy float64
+		for _, s := range samples[start : start+window] {
+			energy += float64(s) * float64(s)
+		}
+		if energy <= bestEnergy {
+			best, bestEnergy = start+window/2, energy
+		}
+	}
+	return best
+}
+
+// TranscribeChunks calls transcribe for every chunk with at most concurrency
+// calls in flight and returns the transcripts in chunk order. The first
+// error cancels the calls still running and is returned.
+func TranscribeChunks(ctx context.Context, chunks []AudioChunk, concurrency int, transcribe func(ctx context.Context, i int, chunk AudioChunk) (*Transcript, error)) ([]*Transcript, error) {
+	return runChunks(ctx, chunks, concurrency, transcribe)
+}
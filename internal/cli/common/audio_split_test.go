@@ -0,0 +1,120 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// speechWAV returns stereo 44.1 kHz WAV of tone bursts separated by a short
+// silence at each of the given seconds.
+func speechWAV(seconds float64, pauses ...float64) []byte {
+	const rate = 44100
+	frames := int(seconds * rate)
+	pcm := make([]byte, frames*4)
+	for i := 0; i < frames; i++ {
+		t := float64(i) / rate
+		v := int16(8000 * math.Sin(2*math.Pi*220*t))
+		for _, p := range pauses {
+			if t >= p-0.15 && t < p+0.15 {
+				v = 0
+			}
+		}
+		binary.LittleEndian.PutUint16(pcm[i*4:], uint16(v))
+		binary.LittleEndian.PutUint16(pcm[i*4+2:], uint16(v))
+	}
+	return PCMToWAV(pcm, AudioFormat{SampleRate: rate, Channels: 2, Encoding: PCMS16LE})
+}
+
+func TestSplitAudio_Short(t *testing.T) {
+	chunks, err := SplitAudio(bytes.NewReader(speechWAV(2)), ".wav", 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || chunks[0].Offset != 0 || math.Abs(chunks[0].Duration-2) > 0.001 {
+		t.Fatalf("expected one 2s chunk, got %+v", chunks)
+	}
+	format, pcm, err := splitWAV(chunks[0].Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != (AudioFormat{SampleRate: ChunkSampleRate, Channels: 1, Encoding: PCMS16LE}) {
+		t.Errorf("expected 16 kHz mono, got %+v", format)
+	}
+	if len(pcm) != 2*ChunkSampleRate*2 {
+		t.Errorf("expected 2s of samples, got %d bytes", len(pcm))
+	}
+}
+
+func TestSplitAudio_CutsAtSilence(t *testing.T) {
+	chunks, err := SplitAudio(bytes.NewReader(speechWAV(25, 8.6, 17)), ".wav", 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %+v", chunks)
+	}
+	for i, want := range []float64{8.6, 17} {
+		if cut := chunks[i].End(); math.Abs(cut-want) > 0.15 {
+			t.Errorf("chunk %d: expected a cut in the pause at %v, got %v", i, want, cut)
+		}
+		if overlap := chunks[i].End() - chunks[i+1].Offset; math.Abs(overlap-1) > 0.001 {
+			t.Errorf("chunk %d: expected 1s overlap, got %v", i, overlap)
+		}
+	}
+	if end := chunks[2].End(); math.Abs(end-25) > 0.001 {
+		t.Errorf("expected the last chunk to end at 25s, got %v", end)
+	}
+}
+
+func TestSplitAudio_Unsupported(t *testing.T) {
+	if CanSplitAudio(".flac") {
+		t.Error("flac should not be splittable")
+	}
+	if _, err := SplitAudio(bytes.NewReader(nil), ".flac", 10, 1); err == nil {
+		t.Error("expected an error")
+	}
+	if _, err := SplitAudio(bytes.NewReader([]byte("not audio")), ".wav", 10, 1); err == nil {
+		t.Error("expected an error for invalid WAV")
+	}
+}
+
+func TestSpoolAudio_NamedAfterContent(t *testing.T) {
+	path, err := SpoolAudio(bytes.NewReader(speechWAV(0.1)), "stdin-", ".mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(path)
+	if filepath.Ext(path) != ".wav" {
+		t.Errorf("expected WAV input to be spooled as .wav, got %s", path)
+	}
+
+	path, err = SpoolAudio(strings.NewReader("not audio"), "stdin-", ".mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(path)
+	if filepath.Ext(path) != ".mp3" {
+		t.Errorf("expected unknown input to use the default extension, got %s", path)
+	}
+}
+
+func TestTranscribeChunks_Order(t *testing.T) {
+	chunks := []AudioChunk{{Offset: 0}, {Offset: 10}, {Offset: 20}}
+	parts, err := TranscribeChunks(context.Background(), chunks, 2, func(ctx context.Context, i int, c AudioChunk) (*Transcript, error) {
+		return &Transcript{Text: string(rune('a' + i))}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range parts {
+		if p.Text != string(rune('a'+i)) {
+			t.Errorf("part %d out of order: %q", i, p.Text)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func decodeFixture(t *testing.T, name string, opts DecodeOptions) (AudioFormat, []byte) {
+	t.Helper()
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stream, err := DecodeAudio(file, filepath.Ext(name), opts)
+	if err != nil {
+		t.Fatalf("DecodeAudio(%s) error: %v", name, err)
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("reading %s: %v", name, err)
+	}
+	return stream.Format, data
+}
+
+func TestDecodeAudio_FLAC(t *testing.T) {
+	format, data := decodeFixture(t, "stereo_16k.flac", DecodeOptions{})
+
+	expected := AudioFormat{SampleRate: 16000, Channels: 2, Encoding: PCMS16LE}
+	if format != expected {
+		t.Errorf("format = %+v, want %+v", format, expected)
+	}
+	if len(data) != 1600*2*2 {
+		t.Fatalf("expected %d bytes, got %d", 1600*2*2, len(data))
+	}
+	// Right channel is the left one halved and inverted
+	for i := 0; i < len(data); i += 4 {
+		left := int16(binary.LittleEndian.Uint16(data[i:]))
+		right := int16(binary.LittleEndian.Uint16(data[i+2:]))
+		if diff := int(right) + int(left)/2; diff < -1 || diff > 1 {
+			t.Fatalf("sample %d: left %d, right %d", i/4, left, right)
+		}
+	}
+}
+
+func TestDecodeAudio_OggOpus(t *testing.T) {
+	format, data := decodeFixture(t, "mono_silk.opus", DecodeOptions{})
+
+	expected := AudioFormat{SampleRate: 48000, Channels: 1, Encoding: PCMS16LE}
+	if format != expected {
+		t.Errorf("format = %+v, want %+v", format, expected)
+	}
+	// 5 packets of 20 ms at 48 kHz, minus the pre-skip
+	if samples := len(data) / 2; samples != 5*960-120 {
+		t.Errorf("expected %d samples, got %d", 5*960-120, samples)
+	}
+}
+
+// toneAmplitude returns the amplitude of freq in 48 kHz S16LE samples.
+func toneAmplitude(data []byte, freq float64) float64 {
+	var re, im float64
+	n := len(data) / 2
+	for i := 0; i < n; i++ {
+		v := float64(int16(binary.LittleEndian.Uint16(data[2*i:]))) / 32768
+		phase := 2 * math.Pi * freq * float64(i) / 48000
+		re += v * math.Cos(phase)
+		im += v * math.Sin(phase)
+	}
+	return 2 * math.Hypot(re, im) / float64(n)
+}
+
+// The CELT and hybrid fixtures are 25 packets of 20 ms of three tones (220 Hz
+// at 0.25, 660 Hz at 0.15 and 1500 Hz at 0.1) encoded by libopus 1.5.2: the
+// first at 64 kbps for audio, all CELT fullband frames; the second at 32 kbps
+// for voice, which moves from SILK to hybrid to CELT frames.
+func testDecodeOpusTones(t *testing.T, name string, wantConfigs func(config byte) bool) {
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ogg := newOggPacketReader(file)
+	for n := 0; ; n++ {
+		packet, err := ogg.next()
+		if err != nil {
+			break
+		}
+		if n >= 2 && !wantConfigs(packet[0]>>3) {
+			t.Fatalf("unexpected configuration %d in %s", packet[0]>>3, name)
+		}
+	}
+	file.Close()
+
+	format, data := decodeFixture(t, name, DecodeOptions{})
+	expected := AudioFormat{SampleRate: 48000, Channels: 1, Encoding: PCMS16LE}
+	if format != expected {
+		t.Errorf("format = %+v, want %+v", format, expected)
+	}
+	if samples := len(data) / 2; samples != 25*960-312 {
+		t.Fatalf("expected %d samples, got %d", 25*960-312, samples)
+	}
+	// Skip the first packet, where the encoder starts up
+	data = data[2*960:]
+	for _, tone := range []struct{ freq, amplitude float64 }{{220, 0.25}, {660, 0.15}, {1500, 0.1}, {1000, 0}} {
+		if got := toneAmplitude(data, tone.freq); math.Abs(got-tone.amplitude) > 0.01 {
+			t.Errorf("%v Hz: amplitude %.3f, want %.3f", tone.freq, got, tone.amplitude)
+		}
+	}
+}
+
+func TestDecodeAudio_OggOpusCELT(t *testing.T) {
+	testDecodeOpusTones(t, "mono_celt.opus", func(config byte) bool { return config == 31 })
+}
+
+func TestDecodeAudio_OggOpusHybrid(t *testing.T) {
+	hybrid := false
+	testDecodeOpusTones(t, "mono_hybrid.opus", func(config byte) bool {
+		hybrid = hybrid || config >= 12 && config <= 15
+		return true
+	})
+	if !hybrid {
+		t.Error("fixture has no hybrid frames")
+	}
+}
+
+func TestDecodeAudio_OggNotOpus(t *testing.T) {
+	saved := ffmpegCommand
+	ffmpegCommand = "rawgenai-no-ffmpeg"
+	defer func() { ffmpegCommand = saved }()
+
+	page := append([]byte("OggS\x00\x02"), make([]byte, 20)...)
+	page = append(page, 1, 7)
+	page = append(page, "\x01vorbis"...)
+
+	_, err := DecodeAudio(bytes.NewReader(page), ".ogg", DecodeOptions{})
+	if !errors.Is(err, ErrUnsupportedAudio) || !strings.Contains(err.Error(), "install ffmpeg") {
+		t.Errorf("expected unsupported audio error, got: %v", err)
+	}
+}
+
+func TestDecodeAudio_PCM(t *testing.T) {
+	format, data := decodeFixture(t, "mono_8k_s16le.pcm", DecodeOptions{SampleRate: 8000})
+
+	expected := AudioFormat{SampleRate: 8000, Channels: 1, Encoding: PCMS16LE}
+	if format != expected {
+		t.Errorf("format = %+v, want %+v", format, expected)
+	}
+	if len(data) != 1600 {
+		t.Errorf("expected 1600 bytes, got %d", len(data))
+	}
+}
+
+func TestDecodeAudio_PCMWithoutSampleRate(t *testing.T) {
+	_, err := DecodeAudio(bytes.NewReader(make([]byte, 16)), ".pcm", DecodeOptions{})
+	if err == nil || !strings.Contains(err.Error(), "sample rate") {
+		t.Errorf("expected sample rate error, got: %v", err)
+	}
+}
+
+func TestDecodeAudio_WAV(t *testing.T) {
+	var wav bytes.Buffer
+	wav.WriteString("RIFF")
+	binary.Write(&wav, binary.LittleEndian, uint32(36+8))
+	wav.WriteString("WAVEfmt ")
+	for _, v := range []any{uint32(16), uint16(1), uint16(1), uint32(24000), uint32(48000), uint16(2), uint16(16)} {
+		binary.Write(&wav, binary.LittleEndian, v)
+	}
+	wav.WriteString("data")
+	binary.Write(&wav, binary.LittleEndian, uint32(8))
+	wav.Write(make([]byte, 8))
+	wav.WriteString("trailing")
+
+	stream, err := DecodeAudio(&wav, ".WAV", DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(stream)
+	if stream.Format.SampleRate != 24000 || len(data) != 8 {
+		t.Errorf("unexpected wav decode: %+v, %d bytes", stream.Format, len(data))
+	}
+}
+
+func TestDecodeAudio_UnknownWithoutFFmpeg(t *testing.T) {
+	saved := ffmpegCommand
+	ffmpegCommand = "rawgenai-no-ffmpeg"
+	defer func() { ffmpegCommand = saved }()
+
+	_, err := DecodeAudio(bytes.NewReader([]byte("data")), ".aac", DecodeOptions{})
+	if !errors.Is(err, ErrUnsupportedAudio) || !strings.Contains(err.Error(), ".aac") {
+		t.Errorf("expected unsupported .aac error, got: %v", err)
+	}
+}
+
+func TestRegisterAudioDecoder(t *testing.T) {
+	called := false
+	RegisterAudioDecoder(func(r io.Reader, opts DecodeOptions) (*AudioStream, error) {
+		called = true
+		return decodePCM(r, DecodeOptions{SampleRate: 16000})
+	}, ".TEST")
+	defer delete(audioDecoders, ".test")
+
+	if _, err := DecodeAudio(bytes.NewReader(nil), ".test", DecodeOptions{}); err != nil || !called {
+		t.Errorf("registered decoder not used: %v", err)
+	}
+}
+
+func TestPlayFile_NullOutput(t *testing.T) {
+	output := UseNullAudioOutput(t)
+
+	if err := PlayFile(filepath.Join("testdata", "stereo_16k.flac")); err != nil {
+		t.Fatal(err)
+	}
+	if err := PlayFileWithOptions(filepath.Join("testdata", "mono_8k_s16le.pcm"), DecodeOptions{SampleRate: 8000}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(output.Formats) != 2 || output.Formats[0].SampleRate != 16000 || output.Formats[1].SampleRate != 8000 {
+		t.Errorf("unexpected formats played: %+v", output.Formats)
+	}
+	if output.Bytes != 1600*4+1600 {
+		t.Errorf("expected %d bytes played, got %d", 1600*4+1600, output.Bytes)
+	}
+}
+
+func TestPlayFile_PCMNeedsOptions(t *testing.T) {
+	UseNullAudioOutput(t)
+
+	if err := PlayFile(filepath.Join("testdata", "mono_8k_s16le.pcm")); err == nil {
+		t.Error("expected error for raw PCM without a sample rate")
+	}
+}
@@ -0,0 +1,214 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultChunkConcurrency bounds the requests in flight when long text is
+// synthesized in chunks.
+const DefaultChunkConcurrency = 3
+
+// SplitText splits text into chunks no longer than limit, as measured by
+// length (runes when nil). It prefers paragraph breaks, then sentence ends,
+// then clause punctuation and spaces, and cuts inside a word only as a last
+// resort. Sentence and clause punctuation includes the CJK forms, which need
+// no following space.
+func SplitText(text string, limit int, length func(string) int) []string {
+	if length == nil {
+		length = utf8.RuneCountInString
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if limit <= 0 {
+		return []string{text}
+	}
+	return splitText(text, limit, length, 0)
+}
+
+// splitLevels are tried in order until every unit fits.
+var splitLevels = []func(string) []string{
+	splitParagraphs,
+	splitSentences,
+	splitClauses,
+	splitWords,
+	splitRunes,
+}
+
+func splitText(text string, limit int, length func(string) int, level int) []string {
+	if length(text) <= limit || level >= len(splitLevels) {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+	}
+
+	for _, unit := range splitLevels[level](text) {
+		if length(strings.TrimSpace(unit)) > limit {
+			flush()
+			chunks = append(chunks, splitText(strings.TrimSpace(unit), limit, length, level+1)...)
+			continue
+		}
+		if current.Len() > 0 && length(strings.TrimSpace(current.String()+unit)) > limit {
+			flush()
+		}
+		current.WriteString(unit)
+	}
+	flush()
+	return chunks
+}
+
+func splitParagraphs(text string) []string {
+	return splitAfter(text, func(r rune, next rune) bool {
+		return r == '\n' && next != '\n'
+	})
+}
+
+// sentenceEnds need a following space; cjkSentenceEnds do not.
+const (
+	sentenceEnds    = ".!?"
+	cjkSentenceEnds = "。！？…"
+	clauseEnds      = ",;:"
+	cjkClauseEnds   = "，；：、"
+	closers         = "\"')]”’」』）》"
+)
+
+func splitSentences(text string) []string {
+	return splitPunctuation(text, sentenceEnds, cjkSentenceEnds)
+}
+
+func splitClauses(text string) []string {
+	return splitPunctuation(text, clauseEnds, cjkClauseEnds)
+}
+
+func splitWords(text string) []string {
+	return splitAfter(text, func(r rune, next rune) bool {
+		return unicode.IsSpace(r) && !unicode.IsSpace(next)
+	})
+}
+
+func splitRunes(text string) []string {
+	return splitAfter(text, func(rune, rune) bool { return true })
+}
+
+// splitPunctuation cuts after ends followed by a space and after any of
+// cjkEnds, keeping closing quotes and trailing spaces with the piece before.
+func splitPunctuation(text, ends, cjkEnds string) []string {
+	var pieces []string
+	runes := []rune(text)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if !strings.ContainsRune(ends, r) && !strings.ContainsRune(cjkEnds, r) {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && (strings.ContainsRune(closers, runes[j]) || strings.ContainsRune(cjkEnds, runes[j]) || strings.ContainsRune(ends, runes[j])) {
+			j++
+		}
+		cjk := strings.ContainsRune(cjkEnds, r)
+		if !cjk && j < len(runes) && !unicode.IsSpace(runes[j]) {
+			continue
+		}
+		for j < len(runes) && unicode.IsSpace(runes[j]) {
+			j++
+		}
+		pieces = append(pieces, string(runes[start:j]))
+		start = j
+		i = j - 1
+	}
+	if start < len(runes) {
+		pieces = append(pieces, string(runes[start:]))
+	}
+	return pieces
+}
+
+// splitAfter cuts text after every rune for which cut returns true; next is
+// the following rune, or 0 at the end.
+func splitAfter(text string, cut func(r rune, next rune) bool) []string {
+	var pieces []string
+	runes := []rune(text)
+	start := 0
+	for i, r := range runes {
+		var next rune
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		if cut(r, next) {
+			pieces = append(pieces, string(runes[start:i+1]))
+			start = i + 1
+		}
+	}
+	if start < len(runes) {
+		pieces = append(pieces, string(runes[start:]))
+	}
+	return pieces
+}
+
+// SynthesizeChunks calls synth for every chunk with at most concurrency calls
+// in flight and returns the results in chunk order. The first error cancels
+// the calls still running and is returned.
+func SynthesizeChunks(ctx context.Context, chunks []string, concurrency int, synth func(ctx context.Context, i int, chunk string) ([]byte, error)) ([][]byte, error) {
+	return runChunks(ctx, chunks, concurrency, synth)
+}
+
+// runChunks calls fn for every chunk with at most concurrency calls in
+// flight and returns the results in chunk order. The first error cancels the
+// calls still running and is returned.
+func runChunks[C, R any](ctx context.Context, chunks []C, concurrency int, fn func(ctx context.Context, i int, chunk C) (R, error)) ([]R, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultChunkConcurrency
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]R, len(chunks))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i, chunk := range chunks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, chunk C) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result, err := fn(ctx, i, chunk)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = result
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,131 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSplitText_FitsInOneChunk(t *testing.T) {
+	chunks := SplitText("  Hello world.  ", 100, nil)
+	if len(chunks) != 1 || chunks[0] != "Hello world." {
+		t.Errorf("unexpected chunks: %q", chunks)
+	}
+	if chunks := SplitText("   ", 100, nil); chunks != nil {
+		t.Errorf("expected no chunks for blank text, got %q", chunks)
+	}
+}
+
+func TestSplitText_Sentences(t *testing.T) {
+	text := "First sentence here. Second one follows! Is this the third? Yes."
+	chunks := SplitText(text, 30, nil)
+	expected := []string{"First sentence here.", "Second one follows!", "Is this the third? Yes."}
+	if strings.Join(chunks, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected %q, got %q", expected, chunks)
+	}
+}
+
+func TestSplitText_PrefersParagraphs(t *testing.T) {
+	text := "One. Two.\n\nThree. Four."
+	chunks := SplitText(text, 15, nil)
+	expected := []string{"One. Two.", "Three. Four."}
+	if strings.Join(chunks, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected %q, got %q", expected, chunks)
+	}
+}
+
+func TestSplitText_KeepsDecimalsAndAbbreviations(t *testing.T) {
+	chunks := SplitText("It costs 3.50 today. Tomorrow more.", 25, nil)
+	if chunks[0] != "It costs 3.50 today." {
+		t.Errorf("decimal point treated as a sentence end: %q", chunks)
+	}
+}
+
+func TestSplitText_CJK(t *testing.T) {
+	text := "今天天气很好。我们去公园散步吧！你觉得怎么样？"
+	chunks := SplitText(text, 10, nil)
+	expected := []string{"今天天气很好。", "我们去公园散步吧！", "你觉得怎么样？"}
+	if strings.Join(chunks, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected %q, got %q", expected, chunks)
+	}
+}
+
+func TestSplitText_ClosingQuotes(t *testing.T) {
+	chunks := SplitText("他说：“好的。”然后离开了。", 8, nil)
+	if chunks[0] != "他说：“好的。”" {
+		t.Errorf("closing quote split from its sentence: %q", chunks)
+	}
+}
+
+func TestSplitText_FallsBackToClausesAndWords(t *testing.T) {
+	text := "a long clause without an end, another clause here, and the last one"
+	for _, chunk := range SplitText(text, 30, nil) {
+		if utf8.RuneCountInString(chunk) > 30 {
+			t.Errorf("chunk %q exceeds the limit", chunk)
+		}
+	}
+
+	chunks := SplitText(strings.Repeat("x", 25), 10, nil)
+	if len(chunks) != 3 || chunks[2] != "xxxxx" {
+		t.Errorf("expected a hard split into 3 chunks, got %q", chunks)
+	}
+}
+
+func TestSplitText_CustomLength(t *testing.T) {
+	// Count bytes instead of runes: each CJK character takes 3
+	chunks := SplitText("你好。世界。", 9, func(s string) int { return len(s) })
+	if len(chunks) != 2 {
+		t.Errorf("expected 2 chunks, got %q", chunks)
+	}
+}
+
+func TestSynthesizeChunks_Order(t *testing.T) {
+	chunks := []string{"a", "b", "c", "d", "e"}
+	var inFlight, peak int32
+	results, err := SynthesizeChunks(context.Background(), chunks, 2, func(ctx context.Context, i int, chunk string) ([]byte, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		// Later chunks finish first
+		time.Sleep(time.Duration(len(chunks)-i) * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return []byte(chunk), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if string(result) != chunks[i] {
+			t.Errorf("result %d: expected %q, got %q", i, chunks[i], result)
+		}
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 calls in flight, got %d", peak)
+	}
+}
+
+func TestSynthesizeChunks_Error(t *testing.T) {
+	failure := errors.New("boom")
+	var calls int32
+	_, err := SynthesizeChunks(context.Background(), make([]string, 20), 1, func(ctx context.Context, i int, chunk string) ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		if i == 1 {
+			return nil, failure
+		}
+		return nil, nil
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("expected the synth error, got: %v", err)
+	}
+	if calls > 3 {
+		t.Errorf("expected remaining chunks to be skipped, got %d calls", calls)
+	}
+}
@@ -0,0 +1,576 @@
+package common
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Download errors
+var (
+	ErrOutputExists       = errors.New("output file already exists, remove --no-clobber to replace it")
+	ErrIncompleteDownload = errors.New("download incomplete")
+	ErrInvalidContainer   = errors.New("downloaded file is not a valid container")
+	ErrConflictingFlags   = errors.New("cannot use --overwrite and --no-clobber together")
+)
+
+// defaultDownloadRetries is used when DownloadOptions.Retries is zero.
+const defaultDownloadRetries = 3
+
+// downloadRetryDelay is the base backoff between download attempts.
+var downloadRetryDelay = time.Second
+
+// HTTPStatusError is returned when the server answers a download with a non-success status.
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("download failed with status %d", e.StatusCode)
+}
+
+// DownloadFlags holds the flags shared by commands that write downloaded files.
+type DownloadFlags struct {
+	Overwrite bool
+	NoClobber bool
+	NoVerify  bool
+}
+
+// AddDownloadFlags registers --overwrite, --no-clobber and --no-verify.
+// Existing output files are replaced unless --no-clobber is given. The
+// command writes through Download or SaveBytes, which fill {task_id} and
+// {seed} in -o from the result.
+func AddDownloadFlags(cmd *cobra.Command, flags *DownloadFlags) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[ResultOutputAnnotation] = "true"
+	cmd.Flags().BoolVar(&flags.Overwrite, "overwrite", false, "Replace the output file if it exists (default)")
+	cmd.Flags().BoolVar(&flags.NoClobber, "no-clobber", false, "Fail instead of replacing an existing output file")
+	cmd.Flags().BoolVar(&flags.NoVerify, "no-verify", false, "Skip the container check of the downloaded file")
+}
+
+// Validate checks that the flags are not contradictory.
+func (f *DownloadFlags) Validate() error {
+	if f.Overwrite && f.NoClobber {
+		return ErrConflictingFlags
+	}
+	return nil
+}
+
+// DownloadOptions controls how Download fetches and writes a file.
+type DownloadOptions struct {
+	DownloadFlags
+
+	// Vars fills {task_id} and {seed} in the output path from the result.
+	Vars OutputVars
+	// Source identifies the remote file, so that a ".part" file left by an
+	// earlier run is only resumed for the same file. DownloadURL sets it
+	// from the URL.
+	Source string
+	// Header is added to every request made by DownloadURL.
+	Header http.Header
+	// Client is used by DownloadURL. Defaults to a client without overall timeout.
+	Client *http.Client
+	// Retries is the number of additional attempts after an interrupted
+	// transfer. Zero means the default (3), negative disables retries.
+	Retries int
+	// Refresh is called by DownloadURL when the URL has expired or is
+	// rejected as gone. Without it such downloads fail with ResultExpiredError.
+	Refresh RefreshFunc
+}
+
+// DownloadResult describes a completed download.
+type DownloadResult struct {
+	Path    string
+	Bytes   int64
+	Resumed bool
+}
+
+// OpenFunc starts (or resumes) a transfer. When offset > 0 the implementation
+// should request the remaining bytes with an HTTP Range header.
+type OpenFunc func(ctx context.Context, offset int64) (*http.Response, error)
+
+// DownloadURL downloads url to path. See Download. Expired signed URLs are
+// refreshed through opts.Refresh, or reported as ResultExpiredError.
+func DownloadURL(ctx context.Context, url, path string, opts DownloadOptions) (*DownloadResult, error) {
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+	path = ResolveOutput(path, opts.Vars)
+	if NeedsExt(path) {
+		path = FillExt(path, URLExt(url, ""))
+	}
+	return downloadFresh(ctx, url, opts, func(url string) (*DownloadResult, error) {
+		opts.Source = downloadSource(url)
+		open := func(ctx context.Context, offset int64) (*http.Response, error) {
+			req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range opts.Header {
+				req.Header[k] = v
+			}
+			if offset > 0 {
+				req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			}
+			return client.Do(req)
+		}
+		return Download(ctx, path, open, opts)
+	})
+}
+
+// SaveBytes writes data to path with the same templates, overwrite, atomic
+// rename and verification rules as Download.
+func SaveBytes(data []byte, path string, opts DownloadOptions) (*DownloadResult, error) {
+	flags := opts.DownloadFlags
+	path = ResolveOutput(path, opts.Vars)
+	if NeedsExt(path) {
+		path = FillExt(path, SniffExt(data))
+	}
+	absPath, err := prepareOutput(path, flags)
+	if err != nil {
+		return nil, err
+	}
+
+	part := absPath + ".part"
+	if err := os.WriteFile(part, data, 0644); err != nil {
+		return nil, err
+	}
+	if err := finishDownload(part, absPath, flags); err != nil {
+		return nil, err
+	}
+	return &DownloadResult{Path: absPath, Bytes: int64(len(data))}, nil
+}
+
+// Download writes a remote file to path through a temporary ".part" file that
+// is renamed into place only once complete. Interrupted transfers are resumed
+// with HTTP Range requests, the final size is checked against Content-Length,
+// and mp4/image/wav outputs are checked for a valid container unless
+// NoVerify is set. A ".part" file left by an earlier run is resumed only when
+// its recorded source and ETag match opts.Source and the server's.
+// When path has no extension, it is taken from the response Content-Type.
+func Download(ctx context.Context, path string, open OpenFunc, opts DownloadOptions) (*DownloadResult, error) {
+	path = ResolveOutput(path, opts.Vars)
+	if NeedsExt(path) {
+		resp, err := open(ctx, 0)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode/100 != 2 {
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+		}
+		ext := ExtFromContentType(resp.Header.Get("Content-Type"))
+		if ext == "" {
+			ext = ".bin"
+		}
+		path = FillExt(path, ext)
+
+		// Hand the response already in flight to the first attempt
+		first, next := resp, open
+		open = func(ctx context.Context, offset int64) (*http.Response, error) {
+			if first != nil {
+				resp, first = first, nil
+				return resp, nil
+			}
+			return next(ctx, offset)
+		}
+		defer func() {
+			if first != nil {
+				first.Body.Close()
+			}
+		}()
+	}
+
+	absPath, err := prepareOutput(path, opts.DownloadFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start over unless the part file was left by a download of the same
+	// source
+	part := absPath + ".part"
+	meta := readPartMeta(part)
+	flag := os.O_CREATE | os.O_WRONLY
+	if opts.Source == "" || meta.Source != opts.Source {
+		flag |= os.O_TRUNC
+		meta = partMeta{Source: opts.Source}
+	}
+	file, err := os.OpenFile(part, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	retries := opts.Retries
+	if retries == 0 {
+		retries = defaultDownloadRetries
+	} else if retries < 0 {
+		retries = 0
+	}
+
+	result := &DownloadResult{Path: absPath}
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt) * downloadRetryDelay):
+			}
+		}
+
+		done, resumed, err := downloadAttempt(ctx, file, open, &meta)
+		if resumed {
+			result.Resumed = true
+		}
+		if err == nil && done {
+			lastErr = nil
+			break
+		}
+		lastErr = err
+		if !isRetryableDownloadError(err) {
+			break
+		}
+	}
+	if lastErr != nil {
+		// Nothing worth resuming, e.g. an expired URL that was refused
+		if info, err := file.Stat(); err == nil && info.Size() == 0 {
+			file.Close()
+			os.Remove(part)
+			os.Remove(partMetaPath(part))
+		}
+		return nil, lastErr
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	result.Bytes = info.Size()
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := finishDownload(part, absPath, opts.DownloadFlags); err != nil {
+		return nil, err
+	}
+	os.Remove(partMetaPath(part))
+	return result, nil
+}
+
+// partMeta records where a ".part" file came from, next to it.
+type partMeta struct {
+	Source string `json:"source"`
+	ETag   string `json:"etag,omitempty"`
+}
+
+func partMetaPath(part string) string {
+	return part + ".meta"
+}
+
+func readPartMeta(part string) partMeta {
+	var meta partMeta
+	if data, err := os.ReadFile(partMetaPath(part)); err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+	return meta
+}
+
+func writePartMeta(part string, meta partMeta) error {
+	if meta.Source == "" {
+		return nil
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(partMetaPath(part), data, 0644)
+}
+
+// downloadSource identifies a URL without its query, which for signed URLs
+// changes every time they are issued.
+func downloadSource(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		u.RawQuery, u.Fragment = "", ""
+		return u.String()
+	}
+	return rawURL
+}
+
+// downloadAttempt continues the transfer from the current end of file. meta
+// holds the ETag of the part file, which a resumed response must match.
+func downloadAttempt(ctx context.Context, file *os.File, open OpenFunc, meta *partMeta) (done, resumed bool, err error) {
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, false, err
+	}
+
+	resp, err := open(ctx, offset)
+	if err != nil {
+		return false, false, err
+	}
+	defer resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	expected := int64(-1)
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if etag != "" && meta.ETag != "" && etag != meta.ETag {
+			// The remote file changed since the part file was written
+			if err := restartFile(file); err != nil {
+				return false, false, err
+			}
+			return false, false, fmt.Errorf("%w: remote file changed", ErrIncompleteDownload)
+		}
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			// Server resumed from the wrong place, start over
+			if err := restartFile(file); err != nil {
+				return false, false, err
+			}
+			return false, false, fmt.Errorf("%w: unexpected Content-Range %q", ErrIncompleteDownload, resp.Header.Get("Content-Range"))
+		}
+		resumed = true
+		expected = total
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The part file may already hold the whole body
+		if _, total, ok := parseContentRange(resp.Header.Get("Content-Range")); ok && total == offset {
+			return true, true, nil
+		}
+		if err := restartFile(file); err != nil {
+			return false, false, err
+		}
+		return false, false, fmt.Errorf("%w: cannot resume from byte %d", ErrIncompleteDownload, offset)
+	case resp.StatusCode/100 == 2:
+		// Full body: the server ignored the range or there was none
+		if offset > 0 {
+			if err := restartFile(file); err != nil {
+				return false, false, err
+			}
+		}
+		expected = resp.ContentLength
+		meta.ETag = etag
+		if err := writePartMeta(file.Name(), *meta); err != nil {
+			return false, false, err
+		}
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return false, false, &HTTPStatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		return false, resumed, err
+	}
+
+	if expected >= 0 {
+		info, err := file.Stat()
+		if err != nil {
+			return false, resumed, err
+		}
+		if info.Size() != expected {
+			return false, resumed, fmt.Errorf("%w: got %d of %d bytes", ErrIncompleteDownload, info.Size(), expected)
+		}
+	}
+	return true, resumed, nil
+}
+
+func restartFile(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := file.Seek(0, io.SeekStart)
+	return err
+}
+
+func isRetryableDownloadError(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pathErr *os.PathError
+	return !errors.As(err, &pathErr)
+}
+
+// parseContentRange parses "bytes start-end/total" (or "bytes */total").
+func parseContentRange(value string) (start, total int64, ok bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "bytes ")
+	rng, totalStr, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, false
+	}
+	total, err := strconv.ParseInt(totalStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if rng == "*" {
+		return 0, total, true
+	}
+	startStr, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err = strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
+
+// prepareOutput resolves the absolute output path and applies the overwrite
+// rules: an existing file is replaced unless NoClobber is set.
+func prepareOutput(path string, flags DownloadFlags) (absPath string, err error) {
+	if err := flags.Validate(); err != nil {
+		return "", err
+	}
+	if err := ValidateSingleOutput(path); err != nil {
+		return "", err
+	}
+	absPath, err = filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	if _, err := os.Stat(absPath); err == nil && flags.NoClobber {
+		return absPath, ErrOutputExists
+	}
+	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+		return absPath, err
+	}
+	return absPath, nil
+}
+
+// finishDownload verifies the part file and atomically moves it into place.
+func finishDownload(part, absPath string, flags DownloadFlags) error {
+	if !flags.NoVerify {
+		if err := VerifyContainer(part, filepath.Ext(absPath)); err != nil {
+			os.Remove(part)
+			return err
+		}
+	}
+	return os.Rename(part, absPath)
+}
+
+// VerifyContainer checks that path looks like a complete file of the type
+// implied by ext. Unknown extensions are not checked.
+func VerifyContainer(path, ext string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var head [12]byte
+	n, _ := io.ReadFull(file, head[:])
+
+	switch strings.ToLower(ext) {
+	case ".mp4", ".mov", ".m4a", ".m4v":
+		if err := verifyMP4(file); err != nil {
+			return err
+		}
+	case ".png", ".jpg", ".jpeg", ".webp":
+		// Providers pick the image format, so accept any image signature
+		if !isImageSignature(head[:n]) {
+			return fmt.Errorf("%w: not a PNG, JPEG or WebP image", ErrInvalidContainer)
+		}
+	case ".wav":
+		if n < 12 || string(head[0:4]) != "RIFF" || string(head[8:12]) != "WAVE" {
+			return fmt.Errorf("%w: missing RIFF/WAVE header", ErrInvalidContainer)
+		}
+	}
+	return nil
+}
+
+func isImageSignature(head []byte) bool {
+	switch {
+	case len(head) >= 8 && string(head[:8]) == "\x89PNG\r\n\x1a\n":
+		return true
+	case len(head) >= 3 && head[0] == 0xFF && head[1] == 0xD8 && head[2] == 0xFF:
+		return true
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return true
+	}
+	return false
+}
+
+// verifyMP4 walks the top-level ISO BMFF boxes and requires a moov box.
+func verifyMP4(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	var offset int64
+	for offset < size {
+		var header [16]byte
+		if _, err := file.ReadAt(header[:8], offset); err != nil {
+			return fmt.Errorf("%w: truncated mp4 box header", ErrInvalidContainer)
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		boxType := string(header[4:8])
+
+		switch boxSize {
+		case 0:
+			boxSize = size - offset
+		case 1:
+			if _, err := file.ReadAt(header[8:16], offset+8); err != nil {
+				return fmt.Errorf("%w: truncated mp4 box header", ErrInvalidContainer)
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+		}
+		if boxSize < 8 || offset+boxSize > size {
+			return fmt.Errorf("%w: mp4 box '%s' is truncated", ErrInvalidContainer, boxType)
+		}
+		if boxType == "moov" {
+			return nil
+		}
+		offset += boxSize
+	}
+	return fmt.Errorf("%w: mp4 has no moov box", ErrInvalidContainer)
+}
+
+// DownloadErrorCode maps an error from Download to a CLI error code.
+func DownloadErrorCode(err error) string {
+	var statusErr *HTTPStatusError
+	var pathErr *os.PathError
+	var expiredErr *ResultExpiredError
+	switch {
+	case errors.As(err, &expiredErr):
+		return "result_expired"
+	case errors.Is(err, ErrOutputExists):
+		return "output_exists"
+	case errors.Is(err, ErrIndexWithoutAll):
+		return "invalid_output"
+	case errors.Is(err, ErrIncompleteDownload):
+		return "incomplete_download"
+	case errors.Is(err, ErrInvalidContainer):
+		return "invalid_container"
+	case errors.As(err, &statusErr):
+		return "download_error"
+	case errors.As(err, &pathErr):
+		return "output_write_error"
+	case errors.Is(err, ErrConflictingFlags):
+		return "conflicting_flags"
+	default:
+		return "download_error"
+	}
+}
@@ -0,0 +1,308 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// mp4Fixture builds a minimal mp4: ftyp + moov + mdat boxes.
+func mp4Fixture(withMoov bool) []byte {
+	box := func(typ string, payload []byte) []byte {
+		b := make([]byte, 8, 8+len(payload))
+		binary.BigEndian.PutUint32(b[0:4], uint32(8+len(payload)))
+		copy(b[4:8], typ)
+		return append(b, payload...)
+	}
+	var buf bytes.Buffer
+	buf.Write(box("ftyp", []byte("isom\x00\x00\x02\x00")))
+	if withMoov {
+		buf.Write(box("moov", bytes.Repeat([]byte{1}, 64)))
+	}
+	buf.Write(box("mdat", bytes.Repeat([]byte{2}, 4096)))
+	return buf.Bytes()
+}
+
+// rangeServer serves content with Range support. If cutAfter > 0, the first
+// full request is cut off after that many bytes.
+func rangeServer(t *testing.T, content []byte, cutAfter int) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		start := 0
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)-start))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content[start:])
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		if n == 1 && cutAfter > 0 {
+			w.Write(content[:cutAfter])
+			w.(http.Flusher).Flush()
+			// Drop the connection before the body is complete
+			hj, _ := w.(http.Hijacker)
+			conn, _, _ := hj.Hijack()
+			conn.Close()
+			return
+		}
+		w.Write(content)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func init() {
+	downloadRetryDelay = 0
+}
+
+func TestDownloadURL_Complete(t *testing.T) {
+	content := mp4Fixture(true)
+	server, _ := rangeServer(t, content, 0)
+
+	output := filepath.Join(t.TempDir(), "video.mp4")
+	result, err := DownloadURL(context.Background(), server.URL, output, DownloadOptions{})
+	if err != nil {
+		t.Fatalf("DownloadURL error: %v", err)
+	}
+
+	data, _ := os.ReadFile(output)
+	if !bytes.Equal(data, content) {
+		t.Error("downloaded content mismatch")
+	}
+	if result.Bytes != int64(len(content)) || result.Resumed {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if _, err := os.Stat(output + ".part"); !os.IsNotExist(err) {
+		t.Error("expected .part file to be removed")
+	}
+}
+
+func TestDownloadURL_ResumesAfterInterruption(t *testing.T) {
+	content := mp4Fixture(true)
+	server, requests := rangeServer(t, content, 1000)
+
+	output := filepath.Join(t.TempDir(), "video.mp4")
+	result, err := DownloadURL(context.Background(), server.URL, output, DownloadOptions{})
+	if err != nil {
+		t.Fatalf("DownloadURL error: %v", err)
+	}
+
+	data, _ := os.ReadFile(output)
+	if !bytes.Equal(data, content) {
+		t.Error("resumed content mismatch")
+	}
+	if !result.Resumed {
+		t.Error("expected download to be resumed")
+	}
+	if atomic.LoadInt32(requests) != 2 {
+		t.Errorf("expected 2 requests, got %d", atomic.LoadInt32(requests))
+	}
+}
+
+func TestDownloadURL_ResumesLeftoverPartFile(t *testing.T) {
+	content := mp4Fixture(true)
+	server, _ := rangeServer(t, content, 0)
+
+	output := filepath.Join(t.TempDir(), "video.mp4")
+	os.WriteFile(output+".part", content[:500], 0644)
+	os.WriteFile(output+".part.meta", []byte(`{"source":"`+server.URL+`/video.mp4"}`), 0644)
+
+	// Signed URLs of the same file differ only in the query
+	result, err := DownloadURL(context.Background(), server.URL+"/video.mp4?sig=2", output, DownloadOptions{})
+	if err != nil {
+		t.Fatalf("DownloadURL error: %v", err)
+	}
+	data, _ := os.ReadFile(output)
+	if !bytes.Equal(data, content) || !result.Resumed {
+		t.Errorf("expected resumed complete download, resumed=%v", result.Resumed)
+	}
+	if _, err := os.Stat(output + ".part.meta"); !os.IsNotExist(err) {
+		t.Error("expected .part.meta file to be removed")
+	}
+}
+
+func TestDownloadURL_DiscardsPartFileOfOtherSource(t *testing.T) {
+	content := mp4Fixture(true)
+	server, _ := rangeServer(t, content, 0)
+
+	for name, meta := range map[string]string{
+		"no meta":      "",
+		"other source": `{"source":"https://example.com/other.mp4"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "video.mp4")
+			os.WriteFile(output+".part", bytes.Repeat([]byte{9}, 500), 0644)
+			if meta != "" {
+				os.WriteFile(output+".part.meta", []byte(meta), 0644)
+			}
+
+			result, err := DownloadURL(context.Background(), server.URL+"/video.mp4", output, DownloadOptions{})
+			if err != nil {
+				t.Fatalf("DownloadURL error: %v", err)
+			}
+			data, _ := os.ReadFile(output)
+			if !bytes.Equal(data, content) || result.Resumed {
+				t.Errorf("expected a fresh complete download, resumed=%v", result.Resumed)
+			}
+		})
+	}
+}
+
+func TestDownloadURL_RestartsWhenETagChanges(t *testing.T) {
+	content := mp4Fixture(true)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("ETag", `"v2"`)
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content[start:])
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "video.mp4")
+	os.WriteFile(output+".part", bytes.Repeat([]byte{9}, 500), 0644)
+	os.WriteFile(output+".part.meta", []byte(`{"source":"`+server.URL+`","etag":"\"v1\""}`), 0644)
+
+	result, err := DownloadURL(context.Background(), server.URL, output, DownloadOptions{})
+	if err != nil {
+		t.Fatalf("DownloadURL error: %v", err)
+	}
+	data, _ := os.ReadFile(output)
+	if !bytes.Equal(data, content) || result.Resumed {
+		t.Errorf("expected a restarted complete download, resumed=%v", result.Resumed)
+	}
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("expected 2 requests, got %d", atomic.LoadInt32(&requests))
+	}
+}
+
+func TestDownloadURL_NoRetryLeavesPartFile(t *testing.T) {
+	content := mp4Fixture(true)
+	server, _ := rangeServer(t, content, 1000)
+
+	output := filepath.Join(t.TempDir(), "video.mp4")
+	_, err := DownloadURL(context.Background(), server.URL, output, DownloadOptions{Retries: -1})
+	if err == nil {
+		t.Fatal("expected error for interrupted download")
+	}
+	if _, statErr := os.Stat(output); !os.IsNotExist(statErr) {
+		t.Error("final output must not exist after an interrupted download")
+	}
+	if _, statErr := os.Stat(output + ".part"); statErr != nil {
+		t.Error("expected .part file to be kept for resuming")
+	}
+}
+
+func TestDownloadURL_OverwriteRules(t *testing.T) {
+	content := mp4Fixture(true)
+	server, requests := rangeServer(t, content, 0)
+
+	output := filepath.Join(t.TempDir(), "video.mp4")
+	os.WriteFile(output, []byte("old"), 0644)
+
+	_, err := DownloadURL(context.Background(), server.URL, output, DownloadOptions{DownloadFlags: DownloadFlags{NoClobber: true}})
+	if !errors.Is(err, ErrOutputExists) || DownloadErrorCode(err) != "output_exists" {
+		t.Errorf("expected output_exists, got: %v", err)
+	}
+	if atomic.LoadInt32(requests) != 0 {
+		t.Errorf("expected no requests, got %d", atomic.LoadInt32(requests))
+	}
+
+	// Existing files are replaced by default
+	if _, err := DownloadURL(context.Background(), server.URL, output, DownloadOptions{}); err != nil {
+		t.Fatalf("overwrite error: %v", err)
+	}
+	data, _ := os.ReadFile(output)
+	if !bytes.Equal(data, content) {
+		t.Error("expected file to be overwritten")
+	}
+
+	_, err = DownloadURL(context.Background(), server.URL, output, DownloadOptions{DownloadFlags: DownloadFlags{Overwrite: true, NoClobber: true}})
+	if DownloadErrorCode(err) != "conflicting_flags" {
+		t.Errorf("expected conflicting_flags, got: %v", err)
+	}
+}
+
+func TestDownloadURL_VerifiesMP4(t *testing.T) {
+	server, _ := rangeServer(t, mp4Fixture(false), 0)
+
+	output := filepath.Join(t.TempDir(), "video.mp4")
+	_, err := DownloadURL(context.Background(), server.URL, output, DownloadOptions{})
+	if DownloadErrorCode(err) != "invalid_container" {
+		t.Fatalf("expected invalid_container, got: %v", err)
+	}
+	if _, statErr := os.Stat(output); !os.IsNotExist(statErr) {
+		t.Error("invalid file must not be moved into place")
+	}
+
+	if _, err := DownloadURL(context.Background(), server.URL, output, DownloadOptions{DownloadFlags: DownloadFlags{NoVerify: true}}); err != nil {
+		t.Errorf("expected --no-verify to skip the check, got: %v", err)
+	}
+}
+
+func TestDownloadURL_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "a.png")
+	_, err := DownloadURL(context.Background(), server.URL, output, DownloadOptions{})
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expected HTTPStatusError 403, got: %v", err)
+	}
+	if _, statErr := os.Stat(output + ".part"); !os.IsNotExist(statErr) {
+		t.Error("expected empty .part file to be removed")
+	}
+}
+
+func TestVerifyContainer(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"ok.png", []byte("\x89PNG\r\n\x1a\nrest"), false},
+		{"bad.png", []byte("<html>"), true},
+		{"ok.jpg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, false},
+		{"jpeg-named.png", []byte{0xFF, 0xD8, 0xFF, 0xE0}, false},
+		{"bad.jpeg", []byte("nope"), true},
+		{"ok.wav", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), false},
+		{"ok.mp4", mp4Fixture(true), false},
+		{"truncated.mp4", mp4Fixture(true)[:50], true},
+		{"any.bin", []byte("x"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			os.WriteFile(path, tt.data, 0644)
+			err := VerifyContainer(path, filepath.Ext(tt.name))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyContainer(%s) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package common
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Embedding is the vector of one input of an embed command. Exactly one of
+// Text and Image is set.
+type Embedding struct {
+	Index  int       `json:"index"`
+	Text   string    `json:"text,omitempty"`
+	Image  string    `json:"image,omitempty"`
+	Vector []float32 `json:"embedding"`
+}
+
+// Embedding output formats, named after their file extensions. JSONL holds
+// one Embedding per line; NPY holds a float32 matrix with a row per input.
+const (
+	EmbeddingJSONL = "jsonl"
+	EmbeddingNPY   = "npy"
+)
+
+// EmbeddingFormat returns the output format of path from its extension.
+func EmbeddingFormat(path string) (string, error) {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext {
+	case EmbeddingJSONL, EmbeddingNPY:
+		return ext, nil
+	default:
+		return "", fmt.Errorf("unsupported output format '.%s', use .jsonl or .npy", ext)
+	}
+}
+
+// ReadEmbeddingInputs returns the texts to embed: the positional arguments,
+// one per argument, or else the non-empty lines of the file or of stdin. It
+// returns no texts without error when none are given, as images may be.
+func ReadEmbeddingInputs(args []string, filePath string, stdin io.Reader) ([]string, error) {
+	var texts []string
+	for _, arg := range args {
+		if text := strings.TrimSpace(arg); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) > 0 {
+		return texts, nil
+	}
+
+	var r io.Reader
+	if filePath != "" {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	} else if stdin != nil {
+		if f, ok := stdin.(*os.File); ok {
+			stat, _ := f.Stat()
+			if (stat.Mode() & os.ModeCharDevice) != 0 {
+				return nil, nil
+			}
+		}
+		r = stdin
+	} else {
+		return nil, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if text := strings.TrimSpace(scanner.Text()); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read input: %w", err)
+	}
+	return texts, nil
+}
+
+// BatchTexts splits texts into batches of at most maxItems texts and, when
+// maxBytes > 0, at most maxBytes bytes of UTF-8. A text longer than maxBytes
+// gets a batch of its own.
+func BatchTexts(texts []string, maxItems, maxBytes int) [][]string {
+	var batches [][]string
+	start, size := 0, 0
+	for i, text := range texts {
+		n := len(text)
+		if i > start && (i-start >= maxItems || (maxBytes > 0 && size+n > maxBytes)) {
+			batches = append(batches, texts[start:i])
+			start, size = i, 0
+		}
+		size += n
+	}
+	if start < len(texts) {
+		batches = append(batches, texts[start:])
+	}
+	return batches
+}
+
+// WriteEmbeddings writes embeddings to path in the format of its extension
+// and returns the absolute path.
+func WriteEmbeddings(path string, embeddings []Embedding) (string, error) {
+	format, err := EmbeddingFormat(path)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	if dir := filepath.Dir(absPath); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	f, err := os.Create(absPath)
+	if err != nil {
+		return "", err
+	}
+	w := bufio.NewWriter(f)
+	if format == EmbeddingNPY {
+		err = writeNPY(w, embeddings)
+	} else {
+		enc := json.NewEncoder(w)
+		for _, e := range embeddings {
+			if err = enc.Encode(e); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(absPath)
+		return "", err
+	}
+	return absPath, nil
+}
+
+// writeNPY writes the vectors as a little-endian float32 matrix in NumPy's
+// .npy format, version 1.0.
+func writeNPY(w io.Writer, embeddings []Embedding) error {
+	dims := 0
+	if len(embeddings) > 0 {
+		dims = len(embeddings[0].Vector)
+	}
+	for _, e := range embeddings {
+		if len(e.Vector) != dims {
+			return errors.New("vectors have different dimensions, use .jsonl")
+		}
+	}
+
+	// The header is padded with spaces so that the data starts on a multiple
+	// of 64 bytes, and ends with a newline
+	header := fmt.Sprintf("{'descr': '<f4', 'fortran_order': False, 'shape': (%d, %d), }", len(embeddings), dims)
+	const preamble = 10 // magic, version and header length
+	pad := 64 - (preamble+len(header)+1)%64
+	if pad == 64 {
+		pad = 0
+	}
+	header += strings.Repeat(" ", pad) + "\n"
+
+	if _, err := w.Write([]byte("\x93NUMPY\x01\x00")); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint16(len(header))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	buf := make([]byte, 4*dims)
+	for _, e := range embeddings {
+		for i, v := range e.Vector {
+			binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package common
+
+import (
+	"bufio"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddingFormat(t *testing.T) {
+	for path, want := range map[string]string{"out.jsonl": "jsonl", "OUT.NPY": "npy"} {
+		if got, err := EmbeddingFormat(path); err != nil || got != want {
+			t.Errorf("%s: got %q, %v", path, got, err)
+		}
+	}
+	for _, path := range []string{"out.json", "out"} {
+		if _, err := EmbeddingFormat(path); err == nil {
+			t.Errorf("%s: expected error", path)
+		}
+	}
+}
+
+func TestReadEmbeddingInputs(t *testing.T) {
+	texts, err := ReadEmbeddingInputs([]string{"a cat", " ", "a dog"}, "ignored.txt", strings.NewReader("ignored"))
+	if err != nil || !reflect.DeepEqual(texts, []string{"a cat", "a dog"}) {
+		t.Errorf("args: got %v, %v", texts, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "prompts.txt")
+	os.WriteFile(path, []byte("a cat\n\n  a dog  \r\n"), 0644)
+	texts, err = ReadEmbeddingInputs(nil, path, strings.NewReader("ignored"))
+	if err != nil || !reflect.DeepEqual(texts, []string{"a cat", "a dog"}) {
+		t.Errorf("file: got %v, %v", texts, err)
+	}
+
+	texts, err = ReadEmbeddingInputs(nil, "", strings.NewReader("one\ntwo"))
+	if err != nil || !reflect.DeepEqual(texts, []string{"one", "two"}) {
+		t.Errorf("stdin: got %v, %v", texts, err)
+	}
+
+	texts, err = ReadEmbeddingInputs(nil, "", strings.NewReader(""))
+	if err != nil || len(texts) != 0 {
+		t.Errorf("empty: got %v, %v", texts, err)
+	}
+
+	if _, err := ReadEmbeddingInputs(nil, filepath.Join(t.TempDir(), "missing.txt"), nil); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestBatchTexts(t *testing.T) {
+	texts := []string{"aaaa", "bb", "cccccc", "d", "ee"}
+
+	got := BatchTexts(texts, 2, 0)
+	want := [][]string{{"aaaa", "bb"}, {"cccccc", "d"}, {"ee"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("by count: got %v", got)
+	}
+
+	// A text over the size limit gets a batch of its own
+	got = BatchTexts(texts, 10, 5)
+	want = [][]string{{"aaaa"}, {"bb"}, {"cccccc"}, {"d", "ee"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("by size: got %v", got)
+	}
+
+	if got := BatchTexts(nil, 10, 0); len(got) != 0 {
+		t.Errorf("empty: got %v", got)
+	}
+}
+
+func TestWriteEmbeddings_JSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "out.jsonl")
+	embeddings := []Embedding{
+		{Index: 0, Text: "a cat", Vector: []float32{0.5, -1}},
+		{Index: 1, Image: "dog.png", Vector: []float32{0.25, 2}},
+	}
+	absPath, err := WriteEmbeddings(path, embeddings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := os.Open(absPath)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	want := []string{
+		`{"index":0,"text":"a cat","embedding":[0.5,-1]}`,
+		`{"index":1,"image":"dog.png","embedding":[0.25,2]}`,
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("got %v", lines)
+	}
+}
+
+func TestWriteEmbeddings_NPY(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.npy")
+	embeddings := []Embedding{
+		{Vector: []float32{1, 2, 3}},
+		{Vector: []float32{-0.5, 0, 0.125}},
+	}
+	if _, err := WriteEmbeddings(path, embeddings); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data[:8]) != "\x93NUMPY\x01\x00" {
+		t.Fatalf("bad magic: %q", data[:8])
+	}
+	headerLen := int(binary.LittleEndian.Uint16(data[8:10]))
+	if (10+headerLen)%64 != 0 {
+		t.Errorf("data does not start on a 64-byte boundary: header length %d", headerLen)
+	}
+	header := string(data[10 : 10+headerLen])
+	if !strings.HasPrefix(header, "{'descr': '<f4', 'fortran_order': False, 'shape': (2, 3), }") || !strings.HasSuffix(header, "\n") {
+		t.Errorf("unexpected header: %q", header)
+	}
+
+	body := data[10+headerLen:]
+	if len(body) != 2*3*4 {
+		t.Fatalf("expected 24 bytes of data, got %d", len(body))
+	}
+	var values []float32
+	for i := 0; i < len(body); i += 4 {
+		values = append(values, math.Float32frombits(binary.LittleEndian.Uint32(body[i:])))
+	}
+	if !reflect.DeepEqual(values, []float32{1, 2, 3, -0.5, 0, 0.125}) {
+		t.Errorf("unexpected data: %v", values)
+	}
+}
+
+func TestWriteEmbeddings_NPYRagged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.npy")
+	_, err := WriteEmbeddings(path, []Embedding{{Vector: []float32{1, 2}}, {Vector: []float32{1}}})
+	if err == nil {
+		t.Fatal("expected error for vectors of different dimensions")
+	}
+	if _, statErr := os.Stat(path); statErr == nil {
+		t.Error("expected no file to be left behind")
+	}
+}
@@ -0,0 +1,174 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResultExpiredError is returned when a result URL can no longer be downloaded
+// and no fresh link could be obtained.
+type ResultExpiredError struct {
+	// ExpiresAt is the expiry of the original URL, zero when unknown.
+	ExpiresAt  time.Time
+	StatusCode int
+}
+
+func (e *ResultExpiredError) Error() string {
+	if !e.ExpiresAt.IsZero() {
+		return fmt.Sprintf("result URL expired at %s, the result is no longer available", e.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("result URL is no longer available (status %d)", e.StatusCode)
+}
+
+// RefreshFunc returns a fresh URL for a result whose URL has expired, usually
+// by querying the task status again. Returning the stale URL means the
+// provider has nothing newer.
+type RefreshFunc func(ctx context.Context, staleURL string) (string, error)
+
+// RefreshFromList builds a RefreshFunc for providers that list result URLs in
+// a stable order: the stale URL is looked up in urls and replaced by the URL
+// at the same position of a fresh listing.
+func RefreshFromList(urls []string, list func(ctx context.Context) ([]string, error)) RefreshFunc {
+	return func(ctx context.Context, staleURL string) (string, error) {
+		pos := -1
+		for i, u := range urls {
+			if u == staleURL {
+				pos = i
+				break
+			}
+		}
+		if pos < 0 {
+			return staleURL, nil
+		}
+		fresh, err := list(ctx)
+		if err != nil {
+			return "", err
+		}
+		if pos >= len(fresh) || fresh[pos] == "" {
+			return staleURL, nil
+		}
+		return fresh[pos], nil
+	}
+}
+
+// URLExpiry returns when a signed URL expires, reading the query parameters
+// used by OSS (Expires, x-oss-expires), TOS and S3 (X-*-Date plus
+// X-*-Expires) and COS (q-sign-time / q-key-time).
+func URLExpiry(rawURL string) (time.Time, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return time.Time{}, false
+	}
+	// Parsed by hand: url.Query drops pairs holding a raw ';' as COS writes them
+	query := make(map[string]string)
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		k, v, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(v); err == nil {
+			v = unescaped
+		}
+		if key := strings.ToLower(k); query[key] == "" {
+			query[key] = v
+		}
+	}
+
+	// Signature v4: signing date plus lifetime in seconds
+	for _, prefix := range []string{"x-amz-", "x-tos-", "x-oss-"} {
+		date, lifetime := query[prefix+"date"], query[prefix+"expires"]
+		if date == "" || lifetime == "" {
+			continue
+		}
+		signed, err := time.Parse("20060102T150405Z", date)
+		seconds, err2 := strconv.ParseInt(lifetime, 10, 64)
+		if err == nil && err2 == nil {
+			return signed.Add(time.Duration(seconds) * time.Second), true
+		}
+	}
+
+	// OSS v1 and CDN style: absolute unix time
+	if value := query["expires"]; value != "" {
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(seconds, 0), true
+		}
+	}
+
+	// COS: "start;end" in unix seconds
+	for _, key := range []string{"q-sign-time", "q-key-time"} {
+		if _, end, ok := strings.Cut(query[key], ";"); ok {
+			if seconds, err := strconv.ParseInt(end, 10, 64); err == nil {
+				return time.Unix(seconds, 0), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// ExpiresAt returns the earliest expiry of the given URLs in RFC 3339, or ""
+// when none can be parsed. Used for the expires_at field of status commands.
+func ExpiresAt(urls ...string) string {
+	var earliest time.Time
+	for _, u := range urls {
+		if t, ok := URLExpiry(u); ok && (earliest.IsZero() || t.Before(earliest)) {
+			earliest = t
+		}
+	}
+	if earliest.IsZero() {
+		return ""
+	}
+	return earliest.UTC().Format(time.RFC3339)
+}
+
+// isExpired reports whether url carries an expiry that has passed.
+func isExpired(url string) (time.Time, bool) {
+	t, ok := URLExpiry(url)
+	return t, ok && !time.Now().Before(t)
+}
+
+// isGoneStatus reports whether err is the answer object stores give for
+// expired or deleted signed URLs.
+func isGoneStatus(err error) (int, bool) {
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		return 0, false
+	}
+	switch statusErr.StatusCode {
+	case http.StatusForbidden, http.StatusNotFound, http.StatusGone:
+		return statusErr.StatusCode, true
+	}
+	return 0, false
+}
+
+// downloadFresh runs download with url, refreshing it once through
+// opts.Refresh when it has expired or the server rejects it as gone.
+func downloadFresh(ctx context.Context, url string, opts DownloadOptions, download func(url string) (*DownloadResult, error)) (*DownloadResult, error) {
+	expiresAt, expired := isExpired(url)
+	status := 0
+	if !expired {
+		result, err := download(url)
+		code, gone := isGoneStatus(err)
+		if !gone {
+			return result, err
+		}
+		// An unsigned URL may be rejected for other reasons
+		if expiresAt.IsZero() && opts.Refresh == nil {
+			return nil, err
+		}
+		status = code
+	}
+
+	if opts.Refresh != nil {
+		fresh, err := opts.Refresh(ctx, url)
+		if err != nil {
+			return nil, fmt.Errorf("cannot refresh result URL: %w", err)
+		}
+		if _, freshExpired := isExpired(fresh); fresh != "" && fresh != url && !freshExpired {
+			return download(fresh)
+		}
+	}
+	return nil, &ResultExpiredError{ExpiresAt: expiresAt, StatusCode: status}
+}
@@ -0,0 +1,173 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLExpiry(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected time.Time
+		ok       bool
+	}{
+		{"oss v1", "https://bucket.oss-cn-beijing.aliyuncs.com/a.mp4?Expires=1735787045&OSSAccessKeyId=x&Signature=y", time.Unix(1735787045, 0), true},
+		{"tos v4", "https://bucket.tos-cn-beijing.volces.com/a.mp4?X-Tos-Algorithm=TOS4-HMAC-SHA256&X-Tos-Date=20250101T000000Z&X-Tos-Expires=86400", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), true},
+		{"s3 v4", "https://s3.amazonaws.com/b/a.png?X-Amz-Date=20250101T120000Z&X-Amz-Expires=3600", time.Date(2025, 1, 1, 13, 0, 0, 0, time.UTC), true},
+		{"oss v4", "https://b.oss.aliyuncs.com/a?x-oss-date=20250101T000000Z&x-oss-expires=60", time.Date(2025, 1, 1, 0, 1, 0, 0, time.UTC), true},
+		{"cos", "https://b.cos.ap-guangzhou.myqcloud.com/a.png?q-sign-algorithm=sha1&q-sign-time=1735700000;1735786400", time.Unix(1735786400, 0), true},
+		{"unsigned", "https://cdn.example.com/a.mp4", time.Time{}, false},
+		{"bad value", "https://cdn.example.com/a.mp4?Expires=soon", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := URLExpiry(tt.url)
+			if ok != tt.ok || !got.Equal(tt.expected) {
+				t.Errorf("URLExpiry() = %v, %v, want %v, %v", got, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestExpiresAt(t *testing.T) {
+	got := ExpiresAt("https://a/x", "https://a/y?Expires=1735787045", "https://a/z?Expires=1735700000", "")
+	if got != "2025-01-01T02:53:20Z" {
+		t.Errorf("ExpiresAt() = %q, want earliest expiry", got)
+	}
+	if got := ExpiresAt("https://a/x"); got != "" {
+		t.Errorf("ExpiresAt() = %q, want empty", got)
+	}
+}
+
+func expiredURL(base string) string {
+	return fmt.Sprintf("%s?Expires=%d", base, time.Now().Add(-time.Hour).Unix())
+}
+
+func validURL(base string) string {
+	return fmt.Sprintf("%s?Expires=%d", base, time.Now().Add(time.Hour).Unix())
+}
+
+func newExpiryServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/gone") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("data" + r.URL.Path))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadURL_ExpiredWithoutRefresh(t *testing.T) {
+	server := newExpiryServer(t)
+	output := filepath.Join(t.TempDir(), "out.bin")
+
+	_, err := DownloadURL(context.Background(), expiredURL(server.URL+"/a"), output, DownloadOptions{})
+	var expired *ResultExpiredError
+	if !errors.As(err, &expired) {
+		t.Fatalf("expected ResultExpiredError, got: %v", err)
+	}
+	if expired.ExpiresAt.IsZero() || !strings.Contains(err.Error(), "expired at") {
+		t.Errorf("expected expiry time in error, got: %v", err)
+	}
+	if code := DownloadErrorCode(err); code != "result_expired" {
+		t.Errorf("expected result_expired, got: %s", code)
+	}
+}
+
+func TestDownloadURL_RefreshesExpiredURL(t *testing.T) {
+	server := newExpiryServer(t)
+	output := filepath.Join(t.TempDir(), "out.bin")
+
+	stale := expiredURL(server.URL + "/old")
+	calls := 0
+	opts := DownloadOptions{Refresh: func(ctx context.Context, staleURL string) (string, error) {
+		calls++
+		if staleURL != stale {
+			t.Errorf("refresh got %q, want %q", staleURL, stale)
+		}
+		return validURL(server.URL + "/new"), nil
+	}}
+
+	result, err := DownloadURL(context.Background(), stale, output, opts)
+	if err != nil {
+		t.Fatalf("DownloadURL error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 refresh, got %d", calls)
+	}
+	data, _ := os.ReadFile(result.Path)
+	if string(data) != "data/new" {
+		t.Errorf("unexpected content: %s", data)
+	}
+}
+
+func TestDownloadURL_RefreshesRejectedURL(t *testing.T) {
+	server := newExpiryServer(t)
+	output := filepath.Join(t.TempDir(), "out.bin")
+
+	opts := DownloadOptions{Refresh: func(ctx context.Context, staleURL string) (string, error) {
+		return server.URL + "/fresh", nil
+	}}
+	result, err := DownloadURL(context.Background(), server.URL+"/gone", output, opts)
+	if err != nil {
+		t.Fatalf("DownloadURL error: %v", err)
+	}
+	data, _ := os.ReadFile(result.Path)
+	if string(data) != "data/fresh" {
+		t.Errorf("unexpected content: %s", data)
+	}
+}
+
+func TestDownloadURL_RefreshReturnsSameURL(t *testing.T) {
+	server := newExpiryServer(t)
+	output := filepath.Join(t.TempDir(), "out.bin")
+
+	stale := validURL(server.URL + "/gone")
+	opts := DownloadOptions{Refresh: func(ctx context.Context, staleURL string) (string, error) {
+		return staleURL, nil
+	}}
+	_, err := DownloadURL(context.Background(), stale, output, opts)
+	var expired *ResultExpiredError
+	if !errors.As(err, &expired) {
+		t.Fatalf("expected ResultExpiredError, got: %v", err)
+	}
+	if expired.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", expired.StatusCode)
+	}
+}
+
+func TestDownloadURL_UnsignedRejectedKeepsStatusError(t *testing.T) {
+	server := newExpiryServer(t)
+	output := filepath.Join(t.TempDir(), "out.bin")
+
+	_, err := DownloadURL(context.Background(), server.URL+"/gone", output, DownloadOptions{})
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 HTTPStatusError, got: %v", err)
+	}
+}
+
+func TestRefreshFromList(t *testing.T) {
+	refresh := RefreshFromList([]string{"a0", "a1", "a2"}, func(ctx context.Context) ([]string, error) {
+		return []string{"b0", "b1", "b2"}, nil
+	})
+
+	if got, _ := refresh(context.Background(), "a1"); got != "b1" {
+		t.Errorf("expected b1, got %s", got)
+	}
+	if got, _ := refresh(context.Background(), "unknown"); got != "unknown" {
+		t.Errorf("expected unknown URL to be returned as is, got %s", got)
+	}
+}
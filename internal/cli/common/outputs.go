@@ -0,0 +1,185 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// IndexPlaceholder is replaced by the result index in output paths.
+const IndexPlaceholder = "{index}"
+
+// ErrInvalidAllOutput is returned when --all is used with a single-file output path.
+var ErrInvalidAllOutput = errors.New("--all requires -o to be a directory (ending with /) or a template containing {index}")
+
+// ErrIndexWithoutAll is returned when a single-file output path contains {index}.
+var ErrIndexWithoutAll = errors.New("-o contains {index}, which is only filled in with --all")
+
+// DownloadItem is one artifact of a multi-result task.
+type DownloadItem struct {
+	Index int
+	// Kind describes the artifact in the response, e.g. "video", "watermark", "audio".
+	Kind string
+	// Suffix is appended to the file name to keep variants of the same index apart.
+	// Empty for the main artifact.
+	Suffix string
+	// Ext is the file extension including the dot. Defaults to the output extension.
+	Ext string
+
+	URL    string
+	Header http.Header
+	// Open is used instead of URL when set.
+	Open OpenFunc
+}
+
+// OutputFile is one saved artifact in a command response.
+type OutputFile struct {
+	Index int    `json:"index"`
+	Kind  string `json:"kind,omitempty"`
+	File  string `json:"file"`
+}
+
+// IsDirOutput reports whether output names a directory: it ends with a path
+// separator or is an existing directory.
+func IsDirOutput(output string) bool {
+	if strings.HasSuffix(output, "/") || strings.HasSuffix(output, string(os.PathSeparator)) {
+		return true
+	}
+	info, err := os.Stat(output)
+	return err == nil && info.IsDir()
+}
+
+// ValidateAllOutput checks that output can hold more than one file.
+func ValidateAllOutput(output string) error {
+	if IsDirOutput(output) || strings.Contains(output, IndexPlaceholder) {
+		return nil
+	}
+	return ErrInvalidAllOutput
+}
+
+// ValidateSingleOutput checks that output names a single file, the
+// counterpart of ValidateAllOutput when --all is not set.
+func ValidateSingleOutput(output string) error {
+	if strings.Contains(output, IndexPlaceholder) {
+		return ErrIndexWithoutAll
+	}
+	return nil
+}
+
+// IndexedPath returns the path for the result at index. "{index}" in output is
+// replaced by the index. A directory output gets "<name>_<index><ext>" inside it.
+// Otherwise, when total > 1, "_<index>" is inserted before the extension.
+// ext is only used when output has no extension of its own.
+func IndexedPath(output, name string, index, total int, ext string) string {
+	return ItemPath(output, name, DownloadItem{Index: index, Ext: ext}, total)
+}
+
+// ItemPath returns the path for a download item, see IndexedPath. Variants
+// (items with a Suffix) always use their own extension.
+func ItemPath(output, name string, item DownloadItem, total int) string {
+	if IsDirOutput(output) {
+		return filepath.Join(output, fmt.Sprintf("%s_%d%s%s", name, item.Index, item.Suffix, item.Ext))
+	}
+
+	p := output
+	if strings.Contains(p, IndexPlaceholder) {
+		p = strings.ReplaceAll(p, IndexPlaceholder, fmt.Sprintf("%d", item.Index))
+	} else if total > 1 {
+		ext := filepath.Ext(p)
+		p = fmt.Sprintf("%s_%d%s", strings.TrimSuffix(p, ext), item.Index, ext)
+	}
+
+	ext := filepath.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	if item.Ext != "" && (ext == "" || item.Suffix != "") {
+		ext = item.Ext
+	}
+	return base + item.Suffix + ext
+}
+
+// DownloadAll downloads every item to its own path under output, stopping at
+// the first failure. Files saved before the failure are returned with the error.
+func DownloadAll(ctx context.Context, output, name string, items []DownloadItem, opts DownloadOptions) ([]OutputFile, error) {
+	files := make([]OutputFile, 0, len(items))
+	for _, item := range items {
+		itemPath := ItemPath(output, name, item, len(items))
+
+		var result *DownloadResult
+		var err error
+		if item.Open != nil {
+			result, err = Download(ctx, itemPath, item.Open, opts)
+		} else {
+			itemOpts := opts
+			if item.Header != nil {
+				itemOpts.Header = item.Header
+			}
+			result, err = DownloadURL(ctx, item.URL, itemPath, itemOpts)
+		}
+		if err != nil {
+			return files, fmt.Errorf("%s %d: %w", kindOrFile(item.Kind), item.Index, err)
+		}
+		files = append(files, OutputFile{
+			Index: item.Index,
+			Kind:  item.Kind,
+			File:  result.Path,
+		})
+	}
+	return files, nil
+}
+
+// downloadAllErrorResponse is the error response of DownloadAll, with the
+// files saved before the failure.
+type downloadAllErrorResponse struct {
+	ErrorResponse
+	Files []OutputFile `json:"files"`
+}
+
+// WriteDownloadAllError writes an error from DownloadAll to stderr like
+// WriteError, listing the files that were saved before it.
+func WriteDownloadAllError(cmd *cobra.Command, err error, files []OutputFile) error {
+	return WriteErrorWithFiles(cmd, DownloadErrorCode(err), err.Error(), files)
+}
+
+// WriteErrorWithFiles writes an error response like WriteError, adding the
+// files an --all download saved before it failed.
+func WriteErrorWithFiles(cmd *cobra.Command, code, message string, files []OutputFile) error {
+	if files == nil {
+		files = []OutputFile{}
+	}
+	resp := downloadAllErrorResponse{
+		ErrorResponse: ErrorResponse{Error: &ErrorInfo{Code: code, Message: message}},
+		Files:         files,
+	}
+	output, _ := json.Marshal(resp)
+	fmt.Fprintln(cmd.ErrOrStderr(), string(output))
+	return errors.New(code)
+}
+
+// URLExt returns the file extension of a URL path, or fallback when there is none.
+func URLExt(rawURL, fallback string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fallback
+	}
+	ext := strings.ToLower(path.Ext(u.Path))
+	if ext == "" || len(ext) > 5 {
+		return fallback
+	}
+	return ext
+}
+
+func kindOrFile(kind string) string {
+	if kind == "" {
+		return "file"
+	}
+	return kind
+}
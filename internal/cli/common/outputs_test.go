@@ -0,0 +1,159 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestItemPath(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		output   string
+		item     DownloadItem
+		total    int
+		expected string
+	}{
+		{"single file", "out.mp4", DownloadItem{Index: 0}, 1, "out.mp4"},
+		{"multiple files get index", "out.png", DownloadItem{Index: 2}, 3, "out_2.png"},
+		{"template", "shots/out_{index}.png", DownloadItem{Index: 1}, 3, "shots/out_1.png"},
+		{"template keeps own extension", "out_{index}.png", DownloadItem{Index: 1, Ext: ".jpg"}, 3, "out_1.png"},
+		{"template variant", "out_{index}.mp4", DownloadItem{Index: 0, Suffix: "_audio", Ext: ".mp3"}, 3, "out_0_audio.mp3"},
+		{"missing extension filled", "out", DownloadItem{Index: 0, Ext: ".mp4"}, 1, "out.mp4"},
+		{"trailing slash", "clips/", DownloadItem{Index: 1, Ext: ".mp4"}, 2, filepath.Join("clips", "task_1.mp4")},
+		{"existing directory", dir, DownloadItem{Index: 0, Suffix: "_watermark", Ext: ".png"}, 2, filepath.Join(dir, "task_0_watermark.png")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ItemPath(tt.output, "task", tt.item, tt.total); got != tt.expected {
+				t.Errorf("ItemPath(%q) = %q, want %q", tt.output, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateAllOutput(t *testing.T) {
+	for _, output := range []string{"out/", "out_{index}.mp4", t.TempDir()} {
+		if err := ValidateAllOutput(output); err != nil {
+			t.Errorf("ValidateAllOutput(%q) unexpected error: %v", output, err)
+		}
+	}
+	if err := ValidateAllOutput("out.mp4"); !errors.Is(err, ErrInvalidAllOutput) {
+		t.Errorf("expected ErrInvalidAllOutput, got: %v", err)
+	}
+}
+
+func TestValidateSingleOutput(t *testing.T) {
+	if err := ValidateSingleOutput("out.mp4"); err != nil {
+		t.Errorf("ValidateSingleOutput unexpected error: %v", err)
+	}
+	if err := ValidateSingleOutput("out_{index}.mp4"); !errors.Is(err, ErrIndexWithoutAll) {
+		t.Errorf("expected ErrIndexWithoutAll, got: %v", err)
+	}
+
+	// Commands that save one file reject the placeholder instead of writing index 0
+	_, err := SaveBytes([]byte("data"), filepath.Join(t.TempDir(), "out_{index}.bin"), DownloadOptions{})
+	if code := DownloadErrorCode(err); code != "invalid_output" {
+		t.Errorf("expected invalid_output, got: %v", err)
+	}
+}
+
+func TestURLExt(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://cdn.example.com/a/b.JPG?sig=1", ".jpg"},
+		{"https://cdn.example.com/a/b", ".png"},
+		{"https://cdn.example.com/a/b.averylongext", ".png"},
+	}
+	for _, tt := range tests {
+		if got := URLExt(tt.url, ".png"); got != tt.expected {
+			t.Errorf("URLExt(%q) = %q, want %q", tt.url, got, tt.expected)
+		}
+	}
+}
+
+func TestDownloadAll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("data" + r.URL.Path))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir() + "/"
+	items := []DownloadItem{
+		{Index: 0, Kind: "audio", Ext: ".bin", URL: server.URL + "/0"},
+		{Index: 1, Kind: "audio", Ext: ".bin", URL: server.URL + "/1"},
+		{Index: 1, Kind: "watermark", Suffix: "_watermark", Ext: ".bin", URL: server.URL + "/1w"},
+	}
+
+	files, err := DownloadAll(context.Background(), dir, "task", items, DownloadOptions{})
+	if err != nil {
+		t.Fatalf("DownloadAll error: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(files))
+	}
+
+	want := []string{"task_0.bin", "task_1.bin", "task_1_watermark.bin"}
+	for i, f := range files {
+		if filepath.Base(f.File) != want[i] {
+			t.Errorf("file %d: expected %s, got %s", i, want[i], f.File)
+		}
+		if f.Kind != items[i].Kind || f.Index != items[i].Index {
+			t.Errorf("file %d: unexpected kind/index %s/%d", i, f.Kind, f.Index)
+		}
+	}
+	data, _ := os.ReadFile(files[2].File)
+	if string(data) != "data/1w" {
+		t.Errorf("unexpected content: %s", data)
+	}
+
+	// A failure returns the files saved so far
+	items = append(items, DownloadItem{Index: 2, Kind: "audio", Ext: ".bin", URL: server.URL + "/missing"})
+	files, err = DownloadAll(context.Background(), dir, "task", items, DownloadOptions{DownloadFlags: DownloadFlags{Overwrite: true}})
+	if err == nil {
+		t.Fatal("expected error for missing item")
+	}
+	if len(files) != 3 {
+		t.Errorf("expected 3 files before the failure, got %d", len(files))
+	}
+	if code := DownloadErrorCode(err); code != "download_error" {
+		t.Errorf("expected download_error, got: %s", code)
+	}
+
+	// The error response lists the files saved before the failure
+	cmd := &cobra.Command{}
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+	WriteDownloadAllError(cmd, err, files)
+	var resp struct {
+		Success bool         `json:"success"`
+		Error   *ErrorInfo   `json:"error"`
+		Files   []OutputFile `json:"files"`
+	}
+	if err := json.Unmarshal(stderr.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid error output %q: %v", stderr.String(), err)
+	}
+	if resp.Success || resp.Error == nil || resp.Error.Code != "download_error" {
+		t.Errorf("unexpected error output: %s", stderr.String())
+	}
+	if len(resp.Files) != 3 || resp.Files[2].File != files[2].File {
+		t.Errorf("expected the 3 saved files in the error output, got: %s", stderr.String())
+	}
+}
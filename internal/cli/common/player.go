@@ -6,56 +6,89 @@ import (
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/ebitengine/oto/v3"
-	"github.com/hajimehoshi/go-mp3"
 )
 
+// AudioOutput plays decoded audio until the stream ends.
+type AudioOutput interface {
+	Play(stream *AudioStream) error
+}
+
+// audioOutput is the device used by PlayAudio; tests swap in a null output.
+var audioOutput AudioOutput = &otoOutput{}
+
 // PlayFile plays an audio file through the system speakers.
-// Supported formats: mp3, wav
+// Supported formats: mp3, wav, flac, ogg/opus, plus anything ffmpeg decodes
+// when it is installed. Raw PCM needs PlayFileWithOptions.
 func PlayFile(path string) error {
-	ext := strings.ToLower(filepath.Ext(path))
+	return PlayFileWithOptions(path, DecodeOptions{})
+}
 
+// PlayFileWithOptions plays an audio file, using opts for headerless input.
+func PlayFileWithOptions(path string, opts DecodeOptions) error {
 	file, err := os.Open(path)
 	if err != nil {
 		return fmt.Errorf("cannot open file: %w", err)
 	}
 	defer file.Close()
 
-	switch ext {
-	case ".mp3":
-		return PlayMP3(file)
-	case ".wav":
-		return playWAV(file)
-	default:
-		return fmt.Errorf("unsupported audio format: %s", ext)
+	return PlayAudio(file, filepath.Ext(path), opts)
+}
+
+// PlayAudio decodes r as the format named by ext (".mp3", ".pcm", ...) and
+// plays it (supports streaming).
+func PlayAudio(r io.Reader, ext string, opts DecodeOptions) error {
+	stream, err := DecodeAudio(r, ext, opts)
+	if err != nil {
+		return err
 	}
+	return audioOutput.Play(stream)
 }
 
 // PlayMP3 plays MP3 audio from a reader (supports streaming).
 func PlayMP3(r io.Reader) error {
-	return playMP3(r)
+	return PlayAudio(r, ".mp3", DecodeOptions{})
 }
 
-func playMP3(r io.Reader) error {
-	decoder, err := mp3.NewDecoder(r)
-	if err != nil {
-		return fmt.Errorf("cannot decode mp3: %w", err)
-	}
+// otoOutput plays through the system speakers. oto allows a single context
+// per process, so it is created on first use and reused afterwards.
+type otoOutput struct {
+	once   sync.Once
+	ctx    *oto.Context
+	format AudioFormat
+	err    error
+}
 
-	otoCtx, ready, err := oto.NewContext(&oto.NewContextOptions{
-		SampleRate:   decoder.SampleRate(),
-		ChannelCount: 2,
-		Format:       oto.FormatSignedInt16LE,
+var otoFormats = map[PCMEncoding]oto.Format{
+	PCMS16LE: oto.FormatSignedInt16LE,
+	PCMU8:    oto.FormatUnsignedInt8,
+	PCMF32LE: oto.FormatFloat32LE,
+}
+
+func (o *otoOutput) Play(stream *AudioStream) error {
+	o.once.Do(func() {
+		var ready chan struct{}
+		o.format = stream.Format
+		o.ctx, ready, o.err = oto.NewContext(&oto.NewContextOptions{
+			SampleRate:   stream.Format.SampleRate,
+			ChannelCount: stream.Format.Channels,
+			Format:       otoFormats[stream.Format.Encoding],
+		})
+		if o.err == nil {
+			<-ready
+		}
 	})
-	if err != nil {
-		return fmt.Errorf("cannot create audio context: %w", err)
+	if o.err != nil {
+		return fmt.Errorf("cannot create audio context: %w", o.err)
+	}
+	if stream.Format != o.format {
+		return fmt.Errorf("audio device is already open at %d Hz, %d channels", o.format.SampleRate, o.format.Channels)
 	}
-	<-ready
 
-	player := otoCtx.NewPlayer(decoder)
+	player := o.ctx.NewPlayer(stream)
 	defer player.Close()
 
 	player.Play()
@@ -63,7 +96,7 @@ func playMP3(r io.Reader) error {
 		time.Sleep(10 * time.Millisecond)
 	}
 
-	return nil
+	return player.Err()
 }
 
 // wavHeader represents a minimal WAV file header
@@ -121,45 +154,3 @@ func parseWAVHeader(r io.Reader) (*wavHeader, error) {
 
 	return header, nil
 }
-
-func playWAV(r io.Reader) error {
-	header, err := parseWAVHeader(r)
-	if err != nil {
-		return err
-	}
-
-	var format oto.Format
-	switch header.BitsPerSample {
-	case 8:
-		format = oto.FormatUnsignedInt8
-	case 16:
-		format = oto.FormatSignedInt16LE
-	case 32:
-		format = oto.FormatFloat32LE
-	default:
-		return fmt.Errorf("unsupported bits per sample: %d", header.BitsPerSample)
-	}
-
-	otoCtx, ready, err := oto.NewContext(&oto.NewContextOptions{
-		SampleRate:   int(header.SampleRate),
-		ChannelCount: int(header.NumChannels),
-		Format:       format,
-	})
-	if err != nil {
-		return fmt.Errorf("cannot create audio context: %w", err)
-	}
-	<-ready
-
-	// Limit reader to data size
-	dataReader := io.LimitReader(r, int64(header.DataSize))
-
-	player := otoCtx.NewPlayer(dataReader)
-	defer player.Close()
-
-	player.Play()
-	for player.IsPlaying() {
-		time.Sleep(10 * time.Millisecond)
-	}
-
-	return nil
-}
@@ -0,0 +1,25 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadJSONSchema reads a JSON schema given inline (starting with "{") or as
+// a file path. The schema must be a JSON object.
+func LoadJSONSchema(value string) (map[string]any, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, fmt.Errorf("cannot read schema file: %w", err)
+		}
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("schema is not a JSON object: %w", err)
+	}
+	return schema, nil
+}
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadJSONSchema(t *testing.T) {
+	schema, err := LoadJSONSchema(` {"type":"object"}`)
+	if err != nil || schema["type"] != "object" {
+		t.Fatalf("inline schema: got %v, %v", schema, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "schema.json")
+	os.WriteFile(path, []byte(`{"type":"array"}`), 0644)
+	schema, err = LoadJSONSchema(path)
+	if err != nil || schema["type"] != "array" {
+		t.Fatalf("schema file: got %v, %v", schema, err)
+	}
+
+	for _, value := range []string{"{not json", filepath.Join(t.TempDir(), "missing.json")} {
+		if _, err := LoadJSONSchema(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+	os.WriteFile(path, []byte(`[1, 2]`), 0644)
+	if _, err := LoadJSONSchema(path); err == nil {
+		t.Error("expected error for a schema that is not an object")
+	}
+}
@@ -0,0 +1,226 @@
+package common
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultJitterBuffer is how much decoded audio a StreamPlayer collects before
+// playback starts, so late network chunks do not cause dropouts.
+const DefaultJitterBuffer = 200 * time.Millisecond
+
+// StreamPlayer plays audio while it is still arriving, e.g. MP3 or PCM chunks
+// from a streaming TTS API. Writes never wait for playback, so a slow sound
+// device does not stall the connection.
+type StreamPlayer struct {
+	input *chunkQueue
+	start time.Time
+	done  chan struct{}
+
+	mu         sync.Mutex
+	firstAudio time.Duration
+	err        error
+}
+
+// NewStreamPlayer starts playback of audio in the format named by ext (".mp3",
+// ".pcm", ...). The time to first audio is measured from this call, so create
+// the player right before sending the request.
+func NewStreamPlayer(ext string, opts DecodeOptions) *StreamPlayer {
+	return newStreamPlayer(ext, opts, DefaultJitterBuffer)
+}
+
+func newStreamPlayer(ext string, opts DecodeOptions, jitter time.Duration) *StreamPlayer {
+	p := &StreamPlayer{
+		input: newChunkQueue(),
+		start: time.Now(),
+		done:  make(chan struct{}),
+	}
+	go p.run(ext, opts, jitter)
+	return p
+}
+
+func (p *StreamPlayer) run(ext string, opts DecodeOptions, jitter time.Duration) {
+	defer close(p.done)
+
+	stream, err := DecodeAudio(p.input, ext, opts)
+	if err == nil {
+		format := stream.Format
+		frame := format.Channels * format.Encoding.BytesPerSample()
+		prefill := int(jitter.Seconds()*float64(format.SampleRate)) * frame
+		err = audioOutput.Play(&AudioStream{
+			Format: format,
+			Reader: &jitterReader{r: stream, prefill: prefill, onStart: p.markFirstAudio},
+		})
+	}
+	if err != nil && !p.input.aborted() {
+		p.mu.Lock()
+		p.err = err
+		p.mu.Unlock()
+		// Let the writer notice instead of buffering forever
+		p.input.fail(err)
+	}
+}
+
+func (p *StreamPlayer) markFirstAudio() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.firstAudio == 0 {
+		p.firstAudio = time.Since(p.start)
+	}
+}
+
+// Write queues an encoded chunk. It fails once playback has failed.
+func (p *StreamPlayer) Write(chunk []byte) (int, error) {
+	return p.input.Write(chunk)
+}
+
+// Close marks the end of the audio and waits until it has been played.
+func (p *StreamPlayer) Close() error {
+	p.input.close()
+	<-p.done
+	return p.Err()
+}
+
+// Abort stops playback after the audio source failed with cause, which may be
+// nil. It returns the playback error when playback had failed first, which is
+// then the more useful error to report. Abort does nothing after Close.
+func (p *StreamPlayer) Abort(cause error) error {
+	if cause == nil {
+		cause = io.ErrClosedPipe
+	}
+	err := p.Err()
+	p.input.abort(cause)
+	<-p.done
+	return err
+}
+
+// Err returns the playback error, if playback has failed so far.
+func (p *StreamPlayer) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// TimeToFirstAudio is the time from NewStreamPlayer until the first audio
+// reached the output, or zero when nothing was played.
+func (p *StreamPlayer) TimeToFirstAudio() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.firstAudio
+}
+
+// jitterReader holds back the first prefill bytes of decoded audio until they
+// are all available, then passes the stream through.
+type jitterReader struct {
+	r       io.Reader
+	prefill int
+	onStart func()
+	started bool
+	buf     []byte
+	err     error
+}
+
+func (j *jitterReader) Read(p []byte) (int, error) {
+	if !j.started {
+		j.started = true
+		j.buf = make([]byte, max(j.prefill, 1))
+		n, err := io.ReadFull(j.r, j.buf)
+		j.buf = j.buf[:n]
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		} else if err != nil && err != io.EOF {
+			j.buf = nil
+		}
+		j.err = err
+		if n > 0 {
+			j.onStart()
+		}
+	}
+	if len(j.buf) > 0 {
+		n := copy(p, j.buf)
+		j.buf = j.buf[n:]
+		return n, nil
+	}
+	if j.err != nil {
+		return 0, j.err
+	}
+	return j.r.Read(p)
+}
+
+// chunkQueue is an unbounded pipe: writes append, reads block until data
+// arrives or the queue is closed.
+type chunkQueue struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	buf       bytes.Buffer
+	closed    bool
+	err       error
+	isAborted bool
+}
+
+func newChunkQueue() *chunkQueue {
+	q := &chunkQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *chunkQueue) Write(b []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err != nil {
+		return 0, q.err
+	}
+	if q.closed {
+		return 0, io.ErrClosedPipe
+	}
+	q.buf.Write(b)
+	q.cond.Broadcast()
+	return len(b), nil
+}
+
+func (q *chunkQueue) Read(p []byte) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.buf.Len() == 0 && !q.closed && q.err == nil {
+		q.cond.Wait()
+	}
+	if q.err != nil {
+		return 0, q.err
+	}
+	if q.buf.Len() > 0 {
+		return q.buf.Read(p)
+	}
+	return 0, io.EOF
+}
+
+func (q *chunkQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+func (q *chunkQueue) fail(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err == nil {
+		q.err = err
+	}
+	q.buf.Reset()
+	q.cond.Broadcast()
+}
+
+func (q *chunkQueue) abort(cause error) {
+	q.mu.Lock()
+	q.isAborted = true
+	q.mu.Unlock()
+	q.fail(cause)
+}
+
+func (q *chunkQueue) aborted() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.isAborted
+}
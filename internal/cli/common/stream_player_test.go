@@ -0,0 +1,107 @@
+package common
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStreamPlayer_PCMChunks(t *testing.T) {
+	output := UseNullAudioOutput(t)
+
+	player := newStreamPlayer(".pcm", DecodeOptions{SampleRate: 8000}, 50*time.Millisecond)
+	chunk := make([]byte, 160)
+	for i := 0; i < 10; i++ {
+		if _, err := player.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := player.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.Bytes != 1600 {
+		t.Errorf("expected 1600 bytes played, got %d", output.Bytes)
+	}
+	if len(output.Formats) != 1 || output.Formats[0].SampleRate != 8000 {
+		t.Errorf("unexpected formats played: %+v", output.Formats)
+	}
+	if player.TimeToFirstAudio() <= 0 {
+		t.Error("expected time to first audio to be recorded")
+	}
+}
+
+func TestStreamPlayer_WaitsForJitterBuffer(t *testing.T) {
+	UseNullAudioOutput(t)
+
+	// 100 ms at 8 kHz mono is 1600 bytes
+	player := newStreamPlayer(".pcm", DecodeOptions{SampleRate: 8000}, 100*time.Millisecond)
+	player.Write(make([]byte, 800))
+	time.Sleep(20 * time.Millisecond)
+	if player.TimeToFirstAudio() != 0 {
+		t.Error("playback started before the jitter buffer was filled")
+	}
+
+	player.Write(make([]byte, 800))
+	if err := player.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if player.TimeToFirstAudio() < 20*time.Millisecond {
+		t.Errorf("time to first audio %v should include the wait for data", player.TimeToFirstAudio())
+	}
+}
+
+func TestStreamPlayer_ShortStream(t *testing.T) {
+	output := UseNullAudioOutput(t)
+
+	data, err := os.ReadFile(filepath.Join("testdata", "stereo_16k.flac"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	player := NewStreamPlayer(".flac", DecodeOptions{})
+	for len(data) > 0 {
+		n := min(100, len(data))
+		player.Write(data[:n])
+		data = data[n:]
+	}
+	if err := player.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Shorter than the jitter buffer: played once the stream ends
+	if output.Bytes != 1600*4 {
+		t.Errorf("expected %d bytes played, got %d", 1600*4, output.Bytes)
+	}
+}
+
+func TestStreamPlayer_PlaybackFailure(t *testing.T) {
+	UseNullAudioOutput(t)
+	saved := ffmpegCommand
+	ffmpegCommand = "rawgenai-no-ffmpeg"
+	defer func() { ffmpegCommand = saved }()
+
+	player := NewStreamPlayer(".aac", DecodeOptions{})
+	<-player.done
+
+	if _, err := player.Write([]byte("data")); !errors.Is(err, ErrUnsupportedAudio) {
+		t.Errorf("expected write to fail with the playback error, got: %v", err)
+	}
+	if err := player.Abort(errors.New("stopped")); !errors.Is(err, ErrUnsupportedAudio) {
+		t.Errorf("expected Abort to report the playback error, got: %v", err)
+	}
+}
+
+func TestStreamPlayer_Abort(t *testing.T) {
+	output := UseNullAudioOutput(t)
+
+	player := NewStreamPlayer(".pcm", DecodeOptions{SampleRate: 24000})
+	player.Write(make([]byte, 100))
+	if err := player.Abort(errors.New("connection lost")); err != nil {
+		t.Errorf("expected no playback error, got: %v", err)
+	}
+	if output.Bytes != 0 {
+		t.Errorf("expected nothing played, got %d bytes", output.Bytes)
+	}
+}
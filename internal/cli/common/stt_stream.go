@@ -0,0 +1,254 @@
+package common
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// StreamFlags are the flags of STT commands that can transcribe audio piped
+// to stdin as it arrives.
+type StreamFlags struct {
+	Enabled    bool
+	SampleRate int
+	Channels   int
+}
+
+// AddStreamFlags registers --stream, --input-rate and --input-channels.
+func AddStreamFlags(cmd *cobra.Command, flags *StreamFlags) {
+	cmd.Flags().BoolVar(&flags.Enabled, "stream", false, "Transcribe PCM or WAV audio from stdin as it arrives, printing NDJSON events")
+	cmd.Flags().IntVar(&flags.SampleRate, "input-rate", 16000, "Sample rate of raw PCM on stdin (--stream; WAV carries its own)")
+	cmd.Flags().IntVar(&flags.Channels, "input-channels", 1, "Channels of raw PCM on stdin (--stream; WAV carries its own)")
+}
+
+// Validate checks the raw PCM format.
+func (f StreamFlags) Validate() error {
+	if f.SampleRate < 8000 || f.SampleRate > 192000 {
+		return fmt.Errorf("--input-rate must be between 8000 and 192000")
+	}
+	if f.Channels < 1 || f.Channels > 8 {
+		return fmt.Errorf("--input-channels must be between 1 and 8")
+	}
+	return nil
+}
+
+// OpenPCMStream converts the audio arriving on r to 16-bit little-endian
+// mono PCM at rate. Input starting with a WAV header is read in the format
+// it declares, anything else as raw 16-bit little-endian PCM in the format
+// of the flags. Reads return as soon as some audio has arrived, so the
+// stream can be forwarded live.
+func (f StreamFlags) OpenPCMStream(r io.Reader, rate int) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	format := AudioFormat{SampleRate: f.SampleRate, Channels: f.Channels, Encoding: PCMS16LE}
+	var src io.Reader = br
+	if head, _ := br.Peek(4); string(head) == "RIFF" {
+		header, err := parseWAVHeader(br)
+		if err != nil {
+			return nil, err
+		}
+		format.SampleRate, format.Channels = int(header.SampleRate), int(header.NumChannels)
+		switch header.BitsPerSample {
+		case 8:
+			format.Encoding = PCMU8
+		case 16:
+			format.Encoding = PCMS16LE
+		case 32:
+			format.Encoding = PCMF32LE
+		default:
+			return nil, fmt.Errorf("unsupported bits per sample: %d", header.BitsPerSample)
+		}
+		// Streamed WAVs carry a zero or maximal data size
+		if header.DataSize != 0 && header.DataSize != math.MaxUint32 {
+			src = io.LimitReader(br, int64(header.DataSize))
+		}
+	}
+	if format.SampleRate <= 0 || format.Channels <= 0 {
+		return nil, fmt.Errorf("invalid audio format: %d channels at %d Hz", format.Channels, format.SampleRate)
+	}
+	return ConvertPCM(&AudioStream{Format: format, Reader: src}, rate), nil
+}
+
+// ConvertPCM converts decoded audio to 16-bit little-endian mono PCM at
+// rate, as it is read.
+func ConvertPCM(stream *AudioStream, rate int) io.Reader {
+	return &pcmConverter{src: stream.Reader, in: stream.Format, step: float64(stream.Format.SampleRate) / float64(rate)}
+}
+
+// pcmConverter downmixes PCM to mono and resamples it by linear
+// interpolation, one read of its source at a time.
+type pcmConverter struct {
+	src     io.Reader
+	in      AudioFormat
+	step    float64
+	partial []byte    // bytes of an incomplete input frame
+	samples []float64 // mono input samples not yet interpolated past
+	pos     float64   // position of the next output sample in samples
+	out     []byte
+	eof     bool
+}
+
+func (c *pcmConverter) Read(p []byte) (int, error) {
+	for len(c.out) == 0 {
+		if c.eof {
+			return 0, io.EOF
+		}
+		if err := c.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, c.out)
+	c.out = c.out[n:]
+	return n, nil
+}
+
+// fill reads once from the source and converts what arrived.
+func (c *pcmConverter) fill() error {
+	buf := make([]byte, 8192)
+	n, err := c.src.Read(buf)
+	data := append(c.partial, buf[:n]...)
+
+	sampleSize := c.in.Encoding.BytesPerSample()
+	frameSize := sampleSize * c.in.Channels
+	frames := len(data) / frameSize
+	for i := 0; i < frames; i++ {
+		var v float64
+		for ch := 0; ch < c.in.Channels; ch++ {
+			v += sampleValue(data[i*frameSize+ch*sampleSize:], c.in.Encoding)
+		}
+		c.samples = append(c.samples, v/float64(c.in.Channels))
+	}
+	c.partial = append([]byte(nil), data[frames*frameSize:]...)
+
+	if err == io.EOF {
+		c.eof = true
+	} else if err != nil {
+		return err
+	}
+
+	for {
+		i := int(c.pos)
+		var v float64
+		switch {
+		case i+1 < len(c.samples):
+			frac := c.pos - float64(i)
+			v = c.samples[i]*(1-frac) + c.samples[i+1]*frac
+		case c.eof && i < len(c.samples):
+			v = c.samples[i]
+		default:
+			// Keep the samples the next output interpolates from
+			i = min(i, len(c.samples))
+			c.samples = c.samples[i:]
+			c.pos -= float64(i)
+			return nil
+		}
+		c.out = binaryAppendInt16(c.out, toInt16(v))
+		c.pos += c.step
+	}
+}
+
+func binaryAppendInt16(b []byte, v int16) []byte {
+	return append(b, byte(v), byte(uint16(v)>>8))
+}
+
+// Streaming transcript event types.
+const (
+	TranscriptEventPartial = "partial"
+	TranscriptEventFinal   = "final"
+	TranscriptEventDone    = "done"
+)
+
+// TranscriptEvent is a line of the NDJSON output of streaming STT. Partial
+// events carry the text recognized so far for the current utterance and may
+// be revised; a final event carries an utterance that will not change. The
+// done event closes the stream with the full text and, with --output, the
+// file written. Times are in seconds from the start of the stream.
+type TranscriptEvent struct {
+	Type     string           `json:"type"`
+	Text     string           `json:"text"`
+	Start    *float64         `json:"start,omitempty"`
+	End      *float64         `json:"end,omitempty"`
+	Speaker  string           `json:"speaker,omitempty"`
+	Language string           `json:"language,omitempty"`
+	Words    []TranscriptWord `json:"words,omitempty"`
+	File     string           `json:"file,omitempty"`
+}
+
+// TranscriptStream writes transcript events as NDJSON and collects the
+// final ones into a Transcript. It is safe for concurrent use.
+type TranscriptStream struct {
+	mu          sync.Mutex
+	enc         *json.Encoder
+	lastPartial string
+	transcript  Transcript
+}
+
+// NewTranscriptStream returns a TranscriptStream writing to w.
+func NewTranscriptStream(w io.Writer) *TranscriptStream {
+	return &TranscriptStream{enc: json.NewEncoder(w)}
+}
+
+// Partial writes a partial event. Empty text and text unchanged since the
+// last partial event are skipped.
+func (s *TranscriptStream) Partial(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if text == "" || text == s.lastPartial {
+		return nil
+	}
+	s.lastPartial = text
+	return s.enc.Encode(TranscriptEvent{Type: TranscriptEventPartial, Text: text})
+}
+
+// Final writes a final event for segment, with its times when it has any,
+// and adds segment and words to the transcript.
+func (s *TranscriptStream) Final(segment TranscriptSegment, language string, words []TranscriptWord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastPartial = ""
+	if segment.Text == "" {
+		return nil
+	}
+	event := TranscriptEvent{Type: TranscriptEventFinal, Text: segment.Text, Speaker: segment.Speaker, Language: language, Words: words}
+	if segment.End > 0 {
+		event.Start, event.End = &segment.Start, &segment.End
+	}
+	if s.transcript.Language == "" {
+		s.transcript.Language = language
+	}
+	s.transcript.Segments = append(s.transcript.Segments, segment)
+	s.transcript.Words = append(s.transcript.Words, words...)
+	return s.enc.Encode(event)
+}
+
+// Pending reports whether there is partial text that no final event has
+// settled yet.
+func (s *TranscriptStream) Pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastPartial != ""
+}
+
+// Transcript returns the final events received so far as a transcript.
+func (s *TranscriptStream) Transcript() *Transcript {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.transcript
+	t.Segments = append([]TranscriptSegment(nil), t.Segments...)
+	t.Words = append([]TranscriptWord(nil), t.Words...)
+	t.Normalize()
+	return &t
+}
+
+// Done writes the done event with the full text and the file written, if
+// any.
+func (s *TranscriptStream) Done(file string) error {
+	t := s.Transcript()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(TranscriptEvent{Type: TranscriptEventDone, Text: t.Text, Language: t.Language, File: file})
+}
@@ -0,0 +1,147 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func pcm16(samples ...int16) []byte {
+	out := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(s))
+	}
+	return out
+}
+
+func readPCM16(t *testing.T, r io.Reader) []int16 {
+	t.Helper()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := make([]int16, len(data)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(data[i*2:]))
+	}
+	return samples
+}
+
+func TestOpenPCMStream_Raw(t *testing.T) {
+	flags := StreamFlags{SampleRate: 16000, Channels: 2}
+	r, err := flags.OpenPCMStream(bytes.NewReader(pcm16(100, 300, -200, -400)), 16000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readPCM16(t, r)
+	if len(got) != 2 || got[0] != 200 || got[1] != -300 {
+		t.Errorf("expected the channels averaged, got %v", got)
+	}
+}
+
+func TestOpenPCMStream_WAVResampled(t *testing.T) {
+	// A streamed WAV declares no data size
+	format := AudioFormat{SampleRate: 8000, Channels: 1, Encoding: PCMS16LE}
+	wav := append(buildWAVHeader(format, 0), pcm16(0, 1000, 2000, 3000)...)
+
+	flags := StreamFlags{SampleRate: 44100, Channels: 2}
+	r, err := flags.OpenPCMStream(bytes.NewReader(wav), 16000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readPCM16(t, r)
+	want := []int16{0, 500, 1000, 1500, 2000, 2500, 3000}
+	if len(got) < len(want) {
+		t.Fatalf("expected at least %v, got %v", want, got)
+	}
+	for i, w := range want {
+		if d := got[i] - w; d < -1 || d > 1 {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestConvertPCM_Float(t *testing.T) {
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint32(data, math.Float32bits(0.5))
+	binary.LittleEndian.PutUint32(data[4:], math.Float32bits(-0.25))
+	stream := &AudioStream{Format: AudioFormat{SampleRate: 24000, Channels: 1, Encoding: PCMF32LE}, Reader: bytes.NewReader(data)}
+
+	got := readPCM16(t, ConvertPCM(stream, 24000))
+	if len(got) != 2 || got[0] != 16384 || got[1] != -8192 {
+		t.Errorf("expected float samples as 16-bit, got %v", got)
+	}
+}
+
+// trickleReader returns one byte per read, like a slow pipe.
+type trickleReader struct{ data []byte }
+
+func (r *trickleReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	p[0] = r.data[0]
+	r.data = r.data[1:]
+	return 1, nil
+}
+
+func TestOpenPCMStream_PartialFrames(t *testing.T) {
+	flags := StreamFlags{SampleRate: 16000, Channels: 1}
+	r, _ := flags.OpenPCMStream(&trickleReader{data: pcm16(1, 2, 3)}, 16000)
+	if got := readPCM16(t, r); len(got) != 3 || got[2] != 3 {
+		t.Errorf("expected all samples, got %v", got)
+	}
+}
+
+func TestStreamFlags_Validate(t *testing.T) {
+	if err := (StreamFlags{SampleRate: 16000, Channels: 1}).Validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := (StreamFlags{SampleRate: 100, Channels: 1}).Validate(); err == nil {
+		t.Error("expected an error for --input-rate 100")
+	}
+	if err := (StreamFlags{SampleRate: 16000, Channels: 0}).Validate(); err == nil {
+		t.Error("expected an error for --input-channels 0")
+	}
+}
+
+func TestTranscriptStream(t *testing.T) {
+	var out bytes.Buffer
+	s := NewTranscriptStream(&out)
+	s.Partial("hel")
+	s.Partial("hel")
+	s.Partial("hello")
+	if !s.Pending() {
+		t.Error("expected partial text to be pending")
+	}
+	s.Final(TranscriptSegment{Start: 0, End: 1.2, Text: "Hello."}, "en", nil)
+	if s.Pending() {
+		t.Error("expected nothing pending after a final event")
+	}
+	s.Final(TranscriptSegment{Text: "Bye."}, "", nil)
+	s.Done("/tmp/out.txt")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{
+		`{"type":"partial","text":"hel"}`,
+		`{"type":"partial","text":"hello"}`,
+		`{"type":"final","text":"Hello.","start":0,"end":1.2,"language":"en"}`,
+		`{"type":"final","text":"Bye."}`,
+		`{"type":"done","text":"Hello. Bye.","language":"en","file":"/tmp/out.txt"}`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got:\n%s", len(want), out.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: expected %s, got %s", i, want[i], lines[i])
+		}
+		if !json.Valid([]byte(lines[i])) {
+			t.Errorf("line %d is not JSON", i)
+		}
+	}
+}
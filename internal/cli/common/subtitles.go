@@ -0,0 +1,271 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// TimedText is a piece of text with the time it is spoken, in seconds from
+// the start of the audio.
+type TimedText struct {
+	Text  string  `json:"text"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Timestamp granularities accepted by --timestamps.
+const (
+	TimestampsCharacter = "character"
+	TimestampsWord      = "word"
+	TimestampsSentence  = "sentence"
+)
+
+var (
+	// ErrInvalidTimestamps reports a granularity the provider cannot align.
+	ErrInvalidTimestamps = errors.New("invalid timestamps")
+	// ErrInvalidSubtitles reports a subtitle path that is not .srt or .vtt.
+	ErrInvalidSubtitles = errors.New("invalid subtitles")
+)
+
+// TimestampFlags holds the --timestamps and --subtitles flags of TTS commands.
+type TimestampFlags struct {
+	Timestamps string
+	Subtitles  string
+
+	granularities []string
+}
+
+// AddTimestampFlags registers --timestamps and --subtitles. granularities
+// lists what the provider can align; the first is used when --timestamps is
+// given without a value.
+func AddTimestampFlags(cmd *cobra.Command, flags *TimestampFlags, granularities ...string) {
+	flags.granularities = granularities
+	cmd.Flags().StringVar(&flags.Timestamps, "timestamps", "", fmt.Sprintf("Return timestamps in the response: %s", strings.Join(granularities, ", ")))
+	cmd.Flags().Lookup("timestamps").NoOptDefVal = granularities[0]
+	cmd.Flags().StringVar(&flags.Subtitles, "subtitles", "", "Write subtitles synced to the audio (.srt, .vtt)")
+}
+
+// Enabled reports whether the audio has to be requested with timings.
+func (f *TimestampFlags) Enabled() bool {
+	return f.Timestamps != "" || f.Subtitles != ""
+}
+
+// Validate checks the granularity and the subtitle file extension.
+func (f *TimestampFlags) Validate() error {
+	if f.Timestamps != "" && !contains(f.granularities, f.Timestamps) {
+		return fmt.Errorf("%w: timestamps must be %s", ErrInvalidTimestamps, strings.Join(f.granularities, ", "))
+	}
+	if f.Subtitles != "" && !IsSubtitlePath(f.Subtitles) {
+		return fmt.Errorf("%w: subtitles file must end in .srt or .vtt", ErrInvalidSubtitles)
+	}
+	return nil
+}
+
+// TimestampErrorCode maps a Validate error to the JSON error code.
+func TimestampErrorCode(err error) string {
+	if errors.Is(err, ErrInvalidSubtitles) {
+		return "invalid_subtitles"
+	}
+	return "invalid_timestamps"
+}
+
+// WriteSubtitles writes cues to the --subtitles file, if any, and returns
+// its absolute path.
+func (f *TimestampFlags) WriteSubtitles(cues []TimedText) (string, error) {
+	if f.Subtitles == "" {
+		return "", nil
+	}
+	absPath, err := filepath.Abs(f.Subtitles)
+	if err != nil {
+		absPath = f.Subtitles
+	}
+	return absPath, WriteSubtitles(absPath, cues)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// trailingPunctuation stays with the word before it.
+const trailingPunctuation = sentenceEnds + cjkSentenceEnds + clauseEnds + cjkClauseEnds + closers
+
+// isCJK reports whether r is written without spaces between words, so each
+// character is a word of its own.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// WordsFromCharacters groups character timings into words. Whitespace
+// separates words, each CJK character is a word of its own and closing
+// punctuation stays with the word before it.
+func WordsFromCharacters(chars []TimedText) []TimedText {
+	var words []TimedText
+	inWord, afterSpace := false, false
+	for _, c := range chars {
+		r, _ := utf8.DecodeRuneInString(c.Text)
+		switch {
+		case strings.TrimSpace(c.Text) == "":
+			inWord, afterSpace = false, true
+			continue
+		case isCJK(r):
+			words = append(words, c)
+			inWord = false
+		case len(words) > 0 && (inWord || !afterSpace && strings.ContainsRune(trailingPunctuation, r)):
+			last := &words[len(words)-1]
+			last.Text += c.Text
+			last.End = c.End
+		default:
+			words = append(words, c)
+			inWord = true
+		}
+		afterSpace = false
+	}
+	return words
+}
+
+// SentencesFromWords groups word timings into sentences, which end at
+// sentence punctuation.
+func SentencesFromWords(words []TimedText) []TimedText {
+	return groupWords(words, func(TimedText, TimedText) bool { return false })
+}
+
+// Subtitle cues end with a sentence or when they grow too long to read.
+const (
+	maxCueCharacters = 84
+	maxCueDuration   = 7.0
+)
+
+// SubtitleCues groups word timings into subtitle cues: sentences, split
+// further when one would be longer than maxCueCharacters or maxCueDuration.
+func SubtitleCues(words []TimedText) []TimedText {
+	return groupWords(words, func(cue, word TimedText) bool {
+		return utf8.RuneCountInString(joinWords(cue.Text, word.Text)) > maxCueCharacters ||
+			word.End-cue.Start > maxCueDuration
+	})
+}
+
+// AlignCharacters returns character timings at the given granularity.
+func AlignCharacters(chars []TimedText, granularity string) []TimedText {
+	if granularity == TimestampsCharacter {
+		return roundTimes(chars)
+	}
+	return AlignWords(WordsFromCharacters(chars), granularity)
+}
+
+// AlignWords returns word timings at the given granularity.
+func AlignWords(words []TimedText, granularity string) []TimedText {
+	if granularity == TimestampsSentence {
+		words = SentencesFromWords(words)
+	}
+	return roundTimes(words)
+}
+
+// roundTimes returns spans with their times rounded to milliseconds.
+func roundTimes(spans []TimedText) []TimedText {
+	out := make([]TimedText, len(spans))
+	for i, s := range spans {
+		out[i] = TimedText{Text: s.Text, Start: roundMillis(s.Start), End: roundMillis(s.End)}
+	}
+	return out
+}
+
+// groupWords joins words into groups that end at sentence punctuation, or
+// before a word when split reports that the group is full.
+func groupWords(words []TimedText, split func(group, word TimedText) bool) []TimedText {
+	var groups []TimedText
+	var current *TimedText
+	for _, w := range words {
+		if current != nil && split(*current, w) {
+			current = nil
+		}
+		if current == nil {
+			groups = append(groups, TimedText{Text: w.Text, Start: w.Start, End: w.End})
+			current = &groups[len(groups)-1]
+		} else {
+			current.Text = joinWords(current.Text, w.Text)
+			current.End = w.End
+		}
+		if endsSentence(w.Text) {
+			current = nil
+		}
+	}
+	return groups
+}
+
+func endsSentence(word string) bool {
+	word = strings.TrimRight(word, closers)
+	r, _ := utf8.DecodeLastRuneInString(word)
+	return strings.ContainsRune(sentenceEnds, r) || strings.ContainsRune(cjkSentenceEnds, r)
+}
+
+// joinWords joins two words with a space unless either side is CJK.
+func joinWords(a, b string) string {
+	last, _ := utf8.DecodeLastRuneInString(a)
+	first, _ := utf8.DecodeRuneInString(b)
+	if isCJK(last) || isCJK(first) || strings.ContainsRune(cjkSentenceEnds+cjkClauseEnds, last) {
+		return a + b
+	}
+	return a + " " + b
+}
+
+func roundMillis(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
+
+// IsSubtitlePath reports whether path names a subtitle format WriteSubtitles
+// supports.
+func IsSubtitlePath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".srt", ".vtt":
+		return true
+	}
+	return false
+}
+
+// WriteSubtitles writes cues as SRT or WebVTT, chosen by the extension of
+// path, with the writers of transcripts.
+func WriteSubtitles(path string, cues []TimedText) error {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if format != TranscriptSRT && format != TranscriptVTT {
+		return fmt.Errorf("%w: unsupported subtitle format %q", ErrInvalidSubtitles, filepath.Ext(path))
+	}
+	lines := make([]TranscriptCue, len(cues))
+	for i, c := range cues {
+		lines[i] = TranscriptCue{Start: c.Start, End: c.End, Lines: []string{c.Text}}
+	}
+	data, err := formatCues(lines, format, "", SubtitleOptions{})
+	if err != nil {
+		return err
+	}
+	_, err = WriteOutput(path, data, OutputVars{})
+	return err
+}
+
+// AppendTimings appends timings to dst. Providers that time each segment of
+// a stream from zero are handled by shifting src by offset, the duration of
+// the audio before the segment, when src would start before dst ends.
+func AppendTimings(dst, src []TimedText, offset float64) []TimedText {
+	if len(dst) == 0 || len(src) == 0 || src[0].Start >= dst[len(dst)-1].End-timingTolerance {
+		return append(dst, src...)
+	}
+	for _, s := range src {
+		dst = append(dst, TimedText{Text: s.Text, Start: s.Start + offset, End: s.End + offset})
+	}
+	return dst
+}
+
+// timingTolerance allows neighbouring segments to overlap slightly without
+// being taken for a restarted timeline.
+const timingTolerance = 0.05
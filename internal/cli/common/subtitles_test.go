@@ -0,0 +1,168 @@
+package common
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testCharacters spaces the runes of text 0.1 s apart.
+func testCharacters(text string) []TimedText {
+	var chars []TimedText
+	for i, r := range []rune(text) {
+		chars = append(chars, TimedText{Text: string(r), Start: float64(i) * 0.1, End: float64(i+1) * 0.1})
+	}
+	return chars
+}
+
+func texts(spans []TimedText) []string {
+	var out []string
+	for _, s := range spans {
+		out = append(out, s.Text)
+	}
+	return out
+}
+
+func TestWordsFromCharacters(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello, world.", []string{"Hello,", "world."}},
+		{`She said "hi" loudly!`, []string{"She", "said", `"hi"`, "loudly!"}},
+		{"你好，世界。", []string{"你", "好，", "世", "界。"}},
+		{"用AI生成", []string{"用", "AI", "生", "成"}},
+	}
+	for _, tt := range tests {
+		got := texts(WordsFromCharacters(testCharacters(tt.text)))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: expected %q, got %q", tt.text, tt.want, got)
+		}
+	}
+}
+
+func TestWordsFromCharacters_Times(t *testing.T) {
+	words := WordsFromCharacters(testCharacters("ab cd"))
+	if len(words) != 2 {
+		t.Fatalf("expected 2 words, got %d", len(words))
+	}
+	if math.Abs(words[1].Start-0.3) > 1e-9 || math.Abs(words[1].End-0.5) > 1e-9 {
+		t.Errorf("unexpected times for %q: %v-%v", words[1].Text, words[1].Start, words[1].End)
+	}
+}
+
+func TestAlignCharacters(t *testing.T) {
+	chars := testCharacters("Hi there. 你好。")
+
+	sentences := AlignCharacters(chars, TimestampsSentence)
+	if got, want := texts(sentences), []string{"Hi there.", "你好。"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if sentences[1].Start != 1 || sentences[1].End != 1.3 {
+		t.Errorf("unexpected times: %v-%v", sentences[1].Start, sentences[1].End)
+	}
+
+	if got := AlignCharacters(chars, TimestampsCharacter); len(got) != len(chars) {
+		t.Errorf("expected %d characters, got %d", len(chars), len(got))
+	}
+}
+
+func TestSubtitleCues_SplitsLongSentences(t *testing.T) {
+	var words []TimedText
+	for i := 0; i < 30; i++ {
+		words = append(words, TimedText{Text: "word", Start: float64(i) * 0.3, End: float64(i)*0.3 + 0.25})
+	}
+	words[len(words)-1].Text = "end."
+
+	cues := SubtitleCues(words)
+	if len(cues) < 2 {
+		t.Fatalf("expected the sentence to be split, got %q", texts(cues))
+	}
+	for _, c := range cues {
+		if len([]rune(c.Text)) > maxCueCharacters {
+			t.Errorf("cue too long: %q", c.Text)
+		}
+		if c.End-c.Start > maxCueDuration {
+			t.Errorf("cue lasts %v s: %q", c.End-c.Start, c.Text)
+		}
+	}
+}
+
+func TestFormatSRT(t *testing.T) {
+	cues := []TimedText{{Text: "Hello.", Start: 0, End: 1.25}, {Text: "Bye.", Start: 3661.5, End: 3662}}
+	want := "1\n00:00:00,000 --> 00:00:01,250\nHello.\n\n2\n01:01:01,500 --> 01:01:02,000\nBye.\n\n"
+	if got := formatSRT(cues); got != want {
+		t.Errorf("unexpected SRT:\n%s", got)
+	}
+}
+
+func TestFormatVTT(t *testing.T) {
+	cues := []TimedText{{Text: "Hello.", Start: 0.5, End: 1.25}}
+	want := "WEBVTT\n\n00:00:00.500 --> 00:00:01.250\nHello.\n\n"
+	if got := formatVTT(cues); got != want {
+		t.Errorf("unexpected VTT:\n%s", got)
+	}
+}
+
+func TestWriteSubtitles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.vtt")
+	if err := WriteSubtitles(path, []TimedText{{Text: "Tom & Jerry <3", End: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "WEBVTT\n\n00:00:00.000 --> 00:00:01.000\nTom &amp; Jerry &lt;3\n\n"; string(data) != want {
+		t.Errorf("expected escaped WebVTT, got %q", data)
+	}
+
+	if err := WriteSubtitles(filepath.Join(t.TempDir(), "out.txt"), nil); !errors.Is(err, ErrInvalidSubtitles) {
+		t.Errorf("expected ErrInvalidSubtitles, got %v", err)
+	}
+}
+
+func TestTimestampFlags_Validate(t *testing.T) {
+	tests := []struct {
+		flags TimestampFlags
+		code  string
+	}{
+		{TimestampFlags{Timestamps: "word"}, ""},
+		{TimestampFlags{Timestamps: "character"}, "invalid_timestamps"},
+		{TimestampFlags{Subtitles: "out.srt"}, ""},
+		{TimestampFlags{Subtitles: "out.txt"}, "invalid_subtitles"},
+	}
+	for _, tt := range tests {
+		tt.flags.granularities = []string{TimestampsWord, TimestampsSentence}
+		err := tt.flags.Validate()
+		if tt.code == "" {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", tt.flags, err)
+			}
+			continue
+		}
+		if err == nil || TimestampErrorCode(err) != tt.code {
+			t.Errorf("%+v: expected %s, got %v", tt.flags, tt.code, err)
+		}
+	}
+}
+
+func TestAppendTimings(t *testing.T) {
+	first := []TimedText{{Text: "a", Start: 0, End: 1}}
+
+	// Continuous times are kept
+	got := AppendTimings(first, []TimedText{{Text: "b", Start: 1.2, End: 2}}, 1.3)
+	if got[1].Start != 1.2 {
+		t.Errorf("expected continuous times to be kept, got %v", got[1].Start)
+	}
+
+	// Times that restart at zero are shifted by the audio before them, not
+	// by the end of the last cue
+	got = AppendTimings(first, []TimedText{{Text: "b", Start: 0.25, End: 0.5}}, 1.25)
+	if got[1].Start != 1.5 || got[1].End != 1.75 {
+		t.Errorf("expected shifted times, got %v-%v", got[1].Start, got[1].End)
+	}
+}
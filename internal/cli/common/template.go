@@ -0,0 +1,314 @@
+package common
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+// ExtPlaceholder is replaced by the file extension (without dot) in output paths.
+const ExtPlaceholder = "{ext}"
+
+// promptSlugLength caps {prompt_slug} so paths stay readable.
+const promptSlugLength = 40
+
+// templateNow is the clock used for {date}.
+var templateNow = time.Now
+
+// ResultOutputAnnotation marks commands that write through Download,
+// SaveBytes or WriteOutput with the values of the result, so {task_id} and
+// {seed} are left in -o for those helpers to fill in.
+const ResultOutputAnnotation = "rawgenai/result-output"
+
+// OutputVars holds the values substituted into -o templates.
+type OutputVars struct {
+	Provider string
+	Model    string
+	TaskID   string
+	Seed     string
+	Prompt   string
+	Date     time.Time
+}
+
+// ExpandOutput substitutes {provider}, {model}, {task_id}, {date}, {seed} and
+// {prompt_slug} in an output path. Placeholders without a value are left in
+// place; {index} and {ext} are always left for IndexedPath and FillExt.
+func ExpandOutput(tmpl string, vars OutputVars) string {
+	if !strings.Contains(tmpl, "{") {
+		return tmpl
+	}
+
+	var pairs []string
+	add := func(name, value string) {
+		if value != "" {
+			pairs = append(pairs, "{"+name+"}", pathSafe(value))
+		}
+	}
+	add("provider", vars.Provider)
+	add("model", vars.Model)
+	add("task_id", vars.TaskID)
+	add("seed", vars.Seed)
+	add("prompt_slug", Slugify(vars.Prompt, promptSlugLength))
+	if !vars.Date.IsZero() {
+		add("date", vars.Date.Format("20060102-150405"))
+	}
+	if len(pairs) == 0 {
+		return tmpl
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+var unresolvedPlaceholder = regexp.MustCompile(`\{(provider|model|task_id|seed|prompt_slug|date)\}`)
+var unresolvedRequestPlaceholder = regexp.MustCompile(`\{(provider|model|prompt_slug|date)\}`)
+var repeatedSeparator = regexp.MustCompile(`([_\-.])[_\-.]*([_\-.])`)
+
+// ResolveOutput fills an output path with the values of a result and drops
+// the variables that are still without a value.
+func ResolveOutput(path string, vars OutputVars) string {
+	return dropUnresolved(ExpandOutput(path, vars), unresolvedPlaceholder)
+}
+
+// dropUnresolved removes the template variables matched by placeholders, and
+// the separators they leave behind (e.g. "cat_{seed}.png" -> "cat.png").
+func dropUnresolved(path string, placeholders *regexp.Regexp) string {
+	if !placeholders.MatchString(path) {
+		return path
+	}
+	dir, base := filepath.Split(placeholders.ReplaceAllString(path, ""))
+	base = repeatedSeparator.ReplaceAllString(base, "$2")
+	base = strings.TrimLeft(base, "_-")
+	return dir + base
+}
+
+// Slugify turns a prompt into a lowercase, path-safe slug of at most max runes.
+func Slugify(s string, max int) string {
+	var b strings.Builder
+	n := 0
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if n >= max {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				if n+2 > max {
+					break
+				}
+				b.WriteByte('-')
+				n++
+			}
+			b.WriteRune(r)
+			n++
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+// pathSafe keeps template values from introducing path separators.
+func pathSafe(s string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(strings.TrimSpace(s))
+}
+
+// NeedsExt reports whether the output path leaves the extension to be filled in.
+func NeedsExt(path string) bool {
+	if strings.Contains(path, ExtPlaceholder) {
+		return true
+	}
+	if path == "" || IsDirOutput(path) {
+		return false
+	}
+	return filepath.Ext(path) == ""
+}
+
+// FillExt replaces {ext} with ext (given with a leading dot), or appends ext
+// when the path has no extension.
+func FillExt(path, ext string) string {
+	if ext == "" {
+		return path
+	}
+	if strings.Contains(path, ExtPlaceholder) {
+		return strings.ReplaceAll(path, ExtPlaceholder, strings.TrimPrefix(ext, "."))
+	}
+	if filepath.Ext(path) == "" {
+		return path + ext
+	}
+	return path
+}
+
+var contentTypeExts = map[string]string{
+	"video/mp4":            ".mp4",
+	"video/quicktime":      ".mov",
+	"video/webm":           ".webm",
+	"image/png":            ".png",
+	"image/jpeg":           ".jpg",
+	"image/webp":           ".webp",
+	"image/gif":            ".gif",
+	"audio/mpeg":           ".mp3",
+	"audio/mp3":            ".mp3",
+	"audio/wav":            ".wav",
+	"audio/wave":           ".wav",
+	"audio/x-wav":          ".wav",
+	"audio/vnd.wave":       ".wav",
+	"audio/ogg":            ".ogg",
+	"application/ogg":      ".ogg",
+	"audio/opus":           ".opus",
+	"audio/flac":           ".flac",
+	"audio/x-flac":         ".flac",
+	"audio/aac":            ".aac",
+	"audio/mp4":            ".m4a",
+	"text/plain":           ".txt",
+	"application/json":     ".json",
+	"text/vtt":             ".vtt",
+	"application/x-subrip": ".srt",
+}
+
+// ExtFromContentType maps a Content-Type header to a file extension, or "".
+func ExtFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return contentTypeExts[mediaType]
+}
+
+// SniffExt guesses the extension of data from its leading bytes, falling back to ".bin".
+func SniffExt(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return ".flac"
+	case len(data) > 1 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return ".mp3"
+	}
+	if ext := ExtFromContentType(http.DetectContentType(data)); ext != "" {
+		return ext
+	}
+	return ".bin"
+}
+
+// formatExts maps --format values that are not extensions themselves.
+var formatExts = map[string]string{
+	"video":    ".mp4",
+	"text":     ".txt",
+	"ogg_opus": ".ogg",
+	"pcm":      ".pcm",
+}
+
+// formatExt derives an extension from a --format value like "mp3_44100_128".
+func formatExt(format string) string {
+	if ext, ok := formatExts[format]; ok {
+		return ext
+	}
+	name, _, _ := strings.Cut(format, "_")
+	if name == "" || strings.ContainsAny(name, "./") {
+		return ""
+	}
+	return "." + name
+}
+
+// ApplyOutputTemplate expands the variables of the request in the -o flag of
+// cmd: {provider} from the command path, {model} from --model, {date} and
+// {prompt_slug} from the prompt arguments or --prompt-file. {task_id} and
+// {seed} come from the result, so commands marked with
+// ResultOutputAnnotation keep them for the output helpers; other commands
+// only know the seed of --seed. A missing extension is taken from --format
+// when the command has one, otherwise it is filled in when the file is written.
+func ApplyOutputTemplate(cmd *cobra.Command, args []string) {
+	flag := cmd.Flags().Lookup("output")
+	if flag == nil || flag.Value.String() == "" {
+		return
+	}
+	output := flag.Value.String()
+
+	if strings.Contains(output, "{") {
+		vars := outputVarsFor(cmd, args)
+		if cmd.Annotations[ResultOutputAnnotation] != "" {
+			output = dropUnresolved(ExpandOutput(output, vars), unresolvedRequestPlaceholder)
+		} else {
+			if f := cmd.Flags().Lookup("seed"); f != nil && f.Changed {
+				vars.Seed = f.Value.String()
+			}
+			output = ResolveOutput(output, vars)
+		}
+	}
+	if NeedsExt(output) {
+		if format := cmd.Flags().Lookup("format"); format != nil {
+			output = FillExt(output, formatExt(format.Value.String()))
+		}
+	}
+	if output != flag.Value.String() {
+		flag.Value.Set(output)
+	}
+}
+
+func outputVarsFor(cmd *cobra.Command, args []string) OutputVars {
+	vars := OutputVars{Date: templateNow()}
+
+	if parts := strings.Fields(cmd.CommandPath()); len(parts) > 1 {
+		vars.Provider = parts[1]
+	}
+	if f := cmd.Flags().Lookup("model"); f != nil {
+		vars.Model = f.Value.String()
+	}
+	if cmd.Annotations[ResultOutputAnnotation] != "" {
+		// Arguments of result commands are IDs, not prompts
+		return vars
+	}
+
+	vars.Prompt = strings.Join(args, " ")
+	if vars.Prompt == "" {
+		if f := cmd.Flags().Lookup("prompt-file"); f != nil && f.Value.String() != "" {
+			if data, err := os.ReadFile(f.Value.String()); err == nil {
+				vars.Prompt = string(data)
+			}
+		}
+	}
+	return vars
+}
+
+// WriteOutput writes data to path, filling in the variables of the result, a
+// missing extension from the content and the parent directory. It returns
+// the absolute path.
+func WriteOutput(path string, data []byte, vars OutputVars) (string, error) {
+	path = ResolveOutput(path, vars)
+	if NeedsExt(path) {
+		path = FillExt(path, SniffExt(data))
+	}
+	path = strings.ReplaceAll(path, IndexPlaceholder, "0")
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+		return absPath, err
+	}
+	return absPath, os.WriteFile(absPath, data, 0644)
+}
+
+// CreateOutput creates path for writing as it streams, creating the parent
+// directory.
+func CreateOutput(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+// DefaultExt fills in ext when path leaves its extension open, so commands
+// with a fixed or default format can validate the result as usual.
+func DefaultExt(path, ext string) string {
+	if NeedsExt(path) {
+		return FillExt(path, ext)
+	}
+	return path
+}
@@ -0,0 +1,268 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestExpandOutput(t *testing.T) {
+	date := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	vars := OutputVars{
+		Provider: "openai",
+		Model:    "gpt-image-1",
+		TaskID:   "task/123",
+		Seed:     "42",
+		Prompt:   "A cat, sitting on the Moon!",
+		Date:     date,
+	}
+
+	tests := []struct {
+		tmpl     string
+		expected string
+	}{
+		{"out.png", "out.png"},
+		{"{provider}/{model}_{seed}.png", "openai/gpt-image-1_42.png"},
+		{"{date}_{prompt_slug}.{ext}", "20260102-030405_a-cat-sitting-on-the-moon.{ext}"},
+		{"{task_id}_{index}.mp4", "task_123_{index}.mp4"},
+		{"{unknown}.png", "{unknown}.png"},
+	}
+	for _, tt := range tests {
+		if got := ExpandOutput(tt.tmpl, vars); got != tt.expected {
+			t.Errorf("ExpandOutput(%q) = %q, want %q", tt.tmpl, got, tt.expected)
+		}
+	}
+}
+
+func TestDropUnresolved(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"cat_{seed}.png", "cat.png"},
+		{"{seed}_cat.png", "cat.png"},
+		{"out/{model}-{seed}_{index}.png", "out/{index}.png"},
+		{"plain.png", "plain.png"},
+	}
+	for _, tt := range tests {
+		if got := dropUnresolved(tt.path, unresolvedPlaceholder); got != tt.expected {
+			t.Errorf("dropUnresolved(%q) = %q, want %q", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input    string
+		max      int
+		expected string
+	}{
+		{"Hello, World!", 40, "hello-world"},
+		{"  --spaces--  ", 40, "spaces"},
+		{"一只猫 in space", 40, "一只猫-in-space"},
+		{"abcdefghij", 5, "abcde"},
+		{"ab cd", 3, "ab"},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.input, tt.max); got != tt.expected {
+			t.Errorf("Slugify(%q, %d) = %q, want %q", tt.input, tt.max, got, tt.expected)
+		}
+	}
+}
+
+func TestFillExt(t *testing.T) {
+	tests := []struct {
+		path     string
+		ext      string
+		expected string
+	}{
+		{"out", ".mp4", "out.mp4"},
+		{"out.{ext}", ".png", "out.png"},
+		{"out.wav", ".mp3", "out.wav"},
+		{"out", "", "out"},
+	}
+	for _, tt := range tests {
+		if got := FillExt(tt.path, tt.ext); got != tt.expected {
+			t.Errorf("FillExt(%q, %q) = %q, want %q", tt.path, tt.ext, got, tt.expected)
+		}
+	}
+
+	if NeedsExt("") || NeedsExt("out.png") || NeedsExt("dir/") {
+		t.Error("NeedsExt should be false for empty, complete and directory outputs")
+	}
+	if !NeedsExt("out") || !NeedsExt("out.{ext}") {
+		t.Error("NeedsExt should be true for outputs without an extension")
+	}
+	if got := DefaultExt("out", ".jpg"); got != "out.jpg" {
+		t.Errorf("DefaultExt = %q, want out.jpg", got)
+	}
+}
+
+func TestSniffExt(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected string
+	}{
+		{[]byte("\x89PNG\r\n\x1a\n0000"), ".png"},
+		{[]byte("\xff\xd8\xff\xe0"), ".jpg"},
+		{[]byte("fLaC\x00\x00"), ".flac"},
+		{[]byte("ID3\x03\x00"), ".mp3"},
+		{[]byte("\xff\xfb\x90\x00"), ".mp3"},
+		{[]byte("RIFF\x00\x00\x00\x00WAVEfmt "), ".wav"},
+		{[]byte{0x00, 0x01, 0x02}, ".bin"},
+	}
+	for _, tt := range tests {
+		if got := SniffExt(tt.data); got != tt.expected {
+			t.Errorf("SniffExt(%q) = %q, want %q", tt.data, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatExt(t *testing.T) {
+	tests := map[string]string{
+		"mp3_44100_128": ".mp3",
+		"wav":           ".wav",
+		"video":         ".mp4",
+		"text":          ".txt",
+		"ogg_opus":      ".ogg",
+		"":              "",
+	}
+	for format, expected := range tests {
+		if got := formatExt(format); got != expected {
+			t.Errorf("formatExt(%q) = %q, want %q", format, got, expected)
+		}
+	}
+}
+
+func newTemplateTestCmd(use string, run func(cmd *cobra.Command, args []string)) *cobra.Command {
+	root := &cobra.Command{
+		Use: "rawgenai",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			ApplyOutputTemplate(cmd, args)
+		},
+	}
+	provider := &cobra.Command{Use: "openai"}
+	cmd := &cobra.Command{Use: use, Run: run}
+	cmd.Flags().StringP("output", "o", "", "")
+	cmd.Flags().String("model", "tts-1", "")
+	cmd.Flags().Int("seed", 0, "")
+	cmd.Flags().String("format", "mp3", "")
+	provider.AddCommand(cmd)
+	root.AddCommand(provider)
+	root.SetOut(new(bytes.Buffer))
+	root.SetErr(new(bytes.Buffer))
+	return root
+}
+
+func TestApplyOutputTemplate(t *testing.T) {
+	saved := templateNow
+	templateNow = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	defer func() { templateNow = saved }()
+
+	dir := t.TempDir()
+
+	var output string
+	capture := func(cmd *cobra.Command, args []string) {
+		output, _ = cmd.Flags().GetString("output")
+	}
+
+	root := newTemplateTestCmd("tts <text>", capture)
+	root.SetArgs([]string{"openai", "tts", "Hello there", "-o", filepath.Join(dir, "{provider}", "{model}_{seed}_{prompt_slug}")})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, "openai", "tts-1_hello-there.mp3"); output != expected {
+		t.Errorf("output = %q, want %q", output, expected)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "openai")); !os.IsNotExist(err) {
+		t.Errorf("expected no directory before the output is written")
+	}
+
+	root = newTemplateTestCmd("create <prompt>", capture)
+	root.SetArgs([]string{"openai", "create", "abc", "--seed", "7", "-o", filepath.Join(dir, "{task_id}-{seed}-{date}.wav")})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, "7-20260102-030405.wav"); output != expected {
+		t.Errorf("output = %q, want %q", output, expected)
+	}
+}
+
+func TestApplyOutputTemplate_ResultOutput(t *testing.T) {
+	saved := templateNow
+	templateNow = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	defer func() { templateNow = saved }()
+
+	var output string
+	root := newTemplateTestCmd("download <task_id>", func(cmd *cobra.Command, args []string) {
+		output, _ = cmd.Flags().GetString("output")
+	})
+	download := root.Commands()[0].Commands()[0]
+	var flags DownloadFlags
+	AddDownloadFlags(download, &flags)
+
+	// {task_id} and {seed} are left for the result; the argument is not a prompt
+	root.SetArgs([]string{"openai", "download", "abc", "--seed", "7", "-o", "{task_id}-{seed}-{prompt_slug}{date}.wav"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if output != "{task_id}-{seed}-20260102-030405.wav" {
+		t.Errorf("output = %q", output)
+	}
+}
+
+func TestWriteOutput(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := WriteOutput(filepath.Join(dir, "nested", "{task_id}_{seed}_image"), []byte("\x89PNG\r\n\x1a\n0000"), OutputVars{TaskID: "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(path) != "abc_image.png" {
+		t.Errorf("expected abc_image.png, got %s", path)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("file not written: %v", err)
+	}
+}
+
+func TestSaveBytes_ResultVars(t *testing.T) {
+	dir := t.TempDir()
+	result, err := SaveBytes([]byte("text"), filepath.Join(dir, "{task_id}", "{seed}.txt"), DownloadOptions{Vars: OutputVars{TaskID: "task/1", Seed: "42"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, "task_1", "42.txt"); result.Path != expected {
+		t.Errorf("path = %q, want %q", result.Path, expected)
+	}
+}
+
+func TestDownload_ExtFromContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write([]byte("audio data"))
+	}))
+	defer server.Close()
+
+	open := func(ctx context.Context, offset int64) (*http.Response, error) {
+		return http.Get(server.URL + "/stream")
+	}
+	result, err := Download(context.Background(), filepath.Join(t.TempDir(), "speech.{ext}"), open, DownloadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(result.Path) != "speech.mp3" {
+		t.Errorf("expected speech.mp3, got %s", result.Path)
+	}
+	data, _ := os.ReadFile(result.Path)
+	if string(data) != "audio data" {
+		t.Errorf("unexpected content: %s", data)
+	}
+}
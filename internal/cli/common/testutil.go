@@ -2,6 +2,7 @@ package common
 
 import (
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -16,6 +17,7 @@ func SetupNoConfigEnv(t *testing.T) {
 		t.Fatal(err)
 	}
 	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_STATE_HOME", "")
 	t.Cleanup(func() {
 		os.RemoveAll(tmpDir)
 	})
@@ -30,6 +32,7 @@ func SetupConfigWithAPIKey(t *testing.T, keys map[string]string) {
 		t.Fatal(err)
 	}
 	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_STATE_HOME", "")
 	t.Cleanup(func() {
 		os.RemoveAll(tmpDir)
 	})
@@ -48,3 +51,29 @@ func SetupConfigWithAPIKey(t *testing.T, keys map[string]string) {
 		t.Fatal(err)
 	}
 }
+
+// NullAudioOutput records what would have been played instead of opening a
+// sound device.
+type NullAudioOutput struct {
+	Formats []AudioFormat
+	Bytes   int64
+}
+
+func (n *NullAudioOutput) Play(stream *AudioStream) error {
+	n.Formats = append(n.Formats, stream.Format)
+	written, err := io.Copy(io.Discard, stream)
+	n.Bytes += written
+	return err
+}
+
+// UseNullAudioOutput routes playback to a NullAudioOutput for the test.
+func UseNullAudioOutput(t *testing.T) *NullAudioOutput {
+	t.Helper()
+	saved := audioOutput
+	null := &NullAudioOutput{}
+	audioOutput = null
+	t.Cleanup(func() {
+		audioOutput = saved
+	})
+	return null
+}
@@ -0,0 +1,501 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// Transcript is the result of speech recognition in a provider independent
+// form. Every STT command converts its response into one so that the same
+// writers can render it as json, txt, srt, vtt, ass or ttml.
+type Transcript struct {
+	Text       string              `json:"text"`
+	Language   string              `json:"language,omitempty"`
+	Duration   float64             `json:"duration,omitempty"`
+	Confidence float64             `json:"confidence,omitempty"`
+	Speakers   []string            `json:"speakers,omitempty"`
+	Segments   []TranscriptSegment `json:"segments,omitempty"`
+	Words      []TranscriptWord    `json:"words,omitempty"`
+}
+
+// TranscriptSegment is a sentence or utterance. Times are in seconds.
+type TranscriptSegment struct {
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Text       string  `json:"text"`
+	Speaker    string  `json:"speaker,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// TranscriptWord is a single recognized word. Times are in seconds.
+type TranscriptWord struct {
+	Text       string  `json:"text"`
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Speaker    string  `json:"speaker,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// Transcript output formats, named after their file extensions.
+const (
+	TranscriptJSON = "json"
+	TranscriptText = "txt"
+	TranscriptSRT  = "srt"
+	TranscriptVTT  = "vtt"
+	TranscriptASS  = "ass"
+	TranscriptTTML = "ttml"
+)
+
+// TranscriptFormats lists the formats WriteTranscript supports.
+var TranscriptFormats = []string{TranscriptJSON, TranscriptText, TranscriptSRT, TranscriptVTT, TranscriptASS, TranscriptTTML}
+
+// ErrNoTimestamps is returned when a subtitle format is requested for a
+// transcript without segment times.
+var ErrNoTimestamps = errors.New("transcript has no timestamps")
+
+// TranscriptFormat returns the format named by the extension of path, or
+// fallback when the extension is not a transcript format.
+func TranscriptFormat(path, fallback string) string {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if contains(TranscriptFormats, ext) {
+		return ext
+	}
+	return fallback
+}
+
+// IsTimedFormat reports whether format needs segment times.
+func IsTimedFormat(format string) bool {
+	switch format {
+	case TranscriptSRT, TranscriptVTT, TranscriptASS, TranscriptTTML:
+		return true
+	}
+	return false
+}
+
+// Subtitle layout defaults.
+const (
+	DefaultMaxLineLength = 42
+	DefaultMaxLines      = 2
+)
+
+// SubtitleOptions controls how a transcript is laid out as subtitles.
+type SubtitleOptions struct {
+	MaxLineLength int
+	MaxLines      int
+	SpeakerLabels bool
+}
+
+// AddSubtitleFlags registers --max-line-length, --max-lines and
+// --speaker-labels.
+func AddSubtitleFlags(cmd *cobra.Command, opts *SubtitleOptions) {
+	cmd.Flags().IntVar(&opts.MaxLineLength, "max-line-length", DefaultMaxLineLength, "Max characters per subtitle line")
+	cmd.Flags().IntVar(&opts.MaxLines, "max-lines", DefaultMaxLines, "Max lines per subtitle cue")
+	cmd.Flags().BoolVar(&opts.SpeakerLabels, "speaker-labels", false, "Prefix transcript lines and cues with the speaker")
+}
+
+// Validate checks the subtitle layout options.
+func (o SubtitleOptions) Validate() error {
+	if o.MaxLineLength < 1 {
+		return errors.New("--max-line-length must be at least 1")
+	}
+	if o.MaxLines < 1 {
+		return errors.New("--max-lines must be at least 1")
+	}
+	return nil
+}
+
+// TranscriptErrorCode maps a WriteTranscript error to the JSON error code.
+func TranscriptErrorCode(err error) string {
+	if errors.Is(err, ErrNoTimestamps) {
+		return "missing_timestamps"
+	}
+	return "output_write_error"
+}
+
+// Normalize trims segment text and fills the fields that can be derived
+// from others: segments from words, the text from segments, the speaker
+// list and the overall confidence from word or segment confidences.
+func (t *Transcript) Normalize() {
+	if len(t.Segments) == 0 && len(t.Words) > 0 {
+		t.Segments = segmentsFromWords(t.Words)
+	}
+	for i := range t.Segments {
+		t.Segments[i].Text = strings.TrimSpace(t.Segments[i].Text)
+	}
+	if t.Text == "" {
+		var parts []string
+		for _, s := range t.Segments {
+			parts = append(parts, s.Text)
+		}
+		t.Text = strings.Join(parts, " ")
+	}
+	t.Text = strings.TrimSpace(t.Text)
+
+	if len(t.Speakers) == 0 {
+		seen := map[string]bool{}
+		add := func(speaker string) {
+			if speaker != "" && !seen[speaker] {
+				seen[speaker] = true
+				t.Speakers = append(t.Speakers, speaker)
+			}
+		}
+		for _, s := range t.Segments {
+			add(s.Speaker)
+		}
+		for _, w := range t.Words {
+			add(w.Speaker)
+		}
+	}
+
+	if t.Confidence == 0 {
+		var confidences []float64
+		for _, w := range t.Words {
+			confidences = append(confidences, w.Confidence)
+		}
+		if t.Confidence = meanConfidence(confidences); t.Confidence == 0 {
+			confidences = confidences[:0]
+			for _, s := range t.Segments {
+				confidences = append(confidences, s.Confidence)
+			}
+			t.Confidence = meanConfidence(confidences)
+		}
+	}
+}
+
+// meanConfidence averages the known (non-zero) confidences, rounded to
+// three decimals. It returns 0 when none are known.
+func meanConfidence(values []float64) float64 {
+	var sum float64
+	var n int
+	for _, v := range values {
+		if v > 0 {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Round(sum/float64(n)*1000) / 1000
+}
+
+// segmentsFromWords groups words into sentences, starting a new one when
+// the speaker changes.
+func segmentsFromWords(words []TranscriptWord) []TranscriptSegment {
+	var segments []TranscriptSegment
+	var current *TranscriptSegment
+	for _, w := range words {
+		if current != nil && w.Speaker != current.Speaker {
+			current = nil
+		}
+		if current == nil {
+			segments = append(segments, TranscriptSegment{Start: w.Start, End: w.End, Text: w.Text, Speaker: w.Speaker})
+			current = &segments[len(segments)-1]
+		} else {
+			current.Text = joinWords(current.Text, w.Text)
+			current.End = w.End
+		}
+		if endsSentence(w.Text) {
+			current = nil
+		}
+	}
+	return segments
+}
+
+// WriteTranscript writes t to path in the given format, see TranscriptFormat.
+// A path without an extension gets the format's extension. It returns the
+// absolute path written.
+func WriteTranscript(path string, t *Transcript, format string, opts SubtitleOptions) (string, error) {
+	path = DefaultExt(path, "."+format)
+	data, err := FormatTranscript(t, format, opts)
+	if err != nil {
+		absPath, _ := filepath.Abs(path)
+		return absPath, err
+	}
+	return WriteOutput(path, data, OutputVars{})
+}
+
+// FormatTranscript renders t in the given format.
+func FormatTranscript(t *Transcript, format string, opts SubtitleOptions) ([]byte, error) {
+	switch format {
+	case TranscriptJSON:
+		return json.MarshalIndent(t, "", "  ")
+	case TranscriptText:
+		return []byte(formatTranscriptText(t, opts)), nil
+	}
+
+	cues, err := TranscriptCues(t, opts)
+	if err != nil {
+		return nil, err
+	}
+	return formatCues(cues, format, t.Language, opts)
+}
+
+// formatCues renders cues in one of the subtitle formats.
+func formatCues(cues []TranscriptCue, format, language string, opts SubtitleOptions) ([]byte, error) {
+	switch format {
+	case TranscriptSRT:
+		return []byte(formatSRT(labelledCues(cues, opts, false))), nil
+	case TranscriptVTT:
+		return []byte(formatVTT(labelledCues(cues, opts, true))), nil
+	case TranscriptASS:
+		return []byte(FormatASS(cues, opts)), nil
+	case TranscriptTTML:
+		return []byte(FormatTTML(cues, language, opts)), nil
+	}
+	return nil, fmt.Errorf("unsupported transcript format %q", format)
+}
+
+// formatTranscriptText returns the plain text, one segment per line with
+// its speaker when speaker labels are on.
+func formatTranscriptText(t *Transcript, opts SubtitleOptions) string {
+	if !opts.SpeakerLabels || len(t.Speakers) == 0 {
+		return t.Text + "\n"
+	}
+	var b strings.Builder
+	for _, s := range t.Segments {
+		b.WriteString(speakerLabel(s.Speaker, s.Text))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func speakerLabel(speaker, text string) string {
+	if speaker == "" {
+		return text
+	}
+	return speaker + ": " + text
+}
+
+// TranscriptCue is one subtitle cue: up to MaxLines lines of up to
+// MaxLineLength characters.
+type TranscriptCue struct {
+	Start   float64
+	End     float64
+	Speaker string
+	Lines   []string
+}
+
+// TranscriptCues lays the segments of t out as subtitle cues. Each segment
+// is wrapped into lines and split into cues of at most opts.MaxLines lines.
+// Cue times come from word timings where the transcript has them and are
+// interpolated within the segment otherwise.
+func TranscriptCues(t *Transcript, opts SubtitleOptions) ([]TranscriptCue, error) {
+	if !hasSegmentTimes(t.Segments) {
+		return nil, ErrNoTimestamps
+	}
+	if opts.MaxLineLength < 1 {
+		opts.MaxLineLength = DefaultMaxLineLength
+	}
+	if opts.MaxLines < 1 {
+		opts.MaxLines = DefaultMaxLines
+	}
+
+	var cues []TranscriptCue
+	next := 0
+	for _, s := range t.Segments {
+		var words []TimedText
+		words, next = segmentWords(t.Words, s, next)
+		if len(words) == 0 {
+			words = interpolateWords(s)
+		}
+		lines := wrapWords(words, opts.MaxLineLength)
+		for i := 0; i < len(lines); i += opts.MaxLines {
+			group := lines[i:min(i+opts.MaxLines, len(lines))]
+			cue := TranscriptCue{Start: group[0].Start, End: group[len(group)-1].End, Speaker: s.Speaker}
+			for _, l := range group {
+				cue.Lines = append(cue.Lines, l.Text)
+			}
+			cues = append(cues, cue)
+		}
+	}
+	return cues, nil
+}
+
+func hasSegmentTimes(segments []TranscriptSegment) bool {
+	for _, s := range segments {
+		if s.End > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentWords returns the words of all that fall inside s, scanning from
+// index from, and the index to continue from for the next segment.
+func segmentWords(all []TranscriptWord, s TranscriptSegment, from int) ([]TimedText, int) {
+	for from < len(all) && all[from].Start < s.Start-timingTolerance {
+		from++
+	}
+	var words []TimedText
+	i := from
+	for ; i < len(all) && all[i].Start < s.End; i++ {
+		words = append(words, TimedText{Text: all[i].Text, Start: all[i].Start, End: all[i].End})
+	}
+	return words, i
+}
+
+// interpolateWords splits the segment text into words with times spread
+// over the segment in proportion to their length.
+func interpolateWords(s TranscriptSegment) []TimedText {
+	runes := []rune(s.Text)
+	if len(runes) == 0 {
+		return nil
+	}
+	step := (s.End - s.Start) / float64(len(runes))
+	chars := make([]TimedText, len(runes))
+	for i, r := range runes {
+		chars[i] = TimedText{Text: string(r), Start: s.Start + float64(i)*step, End: s.Start + float64(i+1)*step}
+	}
+	words := WordsFromCharacters(chars)
+	if len(words) > 0 {
+		words[0].Start = s.Start
+		words[len(words)-1].End = s.End
+	}
+	return words
+}
+
+// wrapWords fills lines of at most maxLength characters with words. A word
+// longer than a line gets a line of its own.
+func wrapWords(words []TimedText, maxLength int) []TimedText {
+	return groupLines(words, func(line, word TimedText) bool {
+		return utf8.RuneCountInString(joinWords(line.Text, word.Text)) > maxLength
+	})
+}
+
+// groupLines is groupWords without the sentence breaks: a segment already
+// is a sentence or an utterance.
+func groupLines(words []TimedText, split func(line, word TimedText) bool) []TimedText {
+	var lines []TimedText
+	for _, w := range words {
+		if len(lines) == 0 || split(lines[len(lines)-1], w) {
+			lines = append(lines, w)
+			continue
+		}
+		last := &lines[len(lines)-1]
+		last.Text = joinWords(last.Text, w.Text)
+		last.End = w.End
+	}
+	return lines
+}
+
+// labelledCues converts cues for formatSRT and formatVTT. Speakers become
+// a "Speaker: " prefix, or a voice span in WebVTT.
+func labelledCues(cues []TranscriptCue, opts SubtitleOptions, vtt bool) []TimedText {
+	out := make([]TimedText, len(cues))
+	for i, c := range cues {
+		text := strings.Join(c.Lines, "\n")
+		switch {
+		case vtt:
+			text = vttEscape(text)
+			if opts.SpeakerLabels && c.Speaker != "" {
+				text = fmt.Sprintf("<v %s>%s", vttEscape(c.Speaker), text)
+			}
+		case opts.SpeakerLabels:
+			text = speakerLabel(c.Speaker, text)
+		}
+		out[i] = TimedText{Text: text, Start: c.Start, End: c.End}
+	}
+	return out
+}
+
+// formatSRT formats cues as SubRip subtitles.
+func formatSRT(cues []TimedText) string {
+	var b strings.Builder
+	for i, c := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, cueTime(c.Start, ","), cueTime(c.End, ","), c.Text)
+	}
+	return b.String()
+}
+
+// formatVTT formats cues as WebVTT subtitles. Text must already be escaped.
+func formatVTT(cues []TimedText) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, c := range cues {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", cueTime(c.Start, "."), cueTime(c.End, "."), c.Text)
+	}
+	return b.String()
+}
+
+// cueTime formats seconds as HH:MM:SS followed by sep and milliseconds.
+func cueTime(seconds float64, sep string) string {
+	ms := int64(math.Round(max(seconds, 0) * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func vttEscape(s string) string {
+	return vttEscaper.Replace(s)
+}
+
+// FormatASS formats cues as Advanced SubStation Alpha subtitles. The
+// speaker goes into the Name field of each event, and is shown before the
+// text when speaker labels are on.
+func FormatASS(cues []TranscriptCue, opts SubtitleOptions) string {
+	var b strings.Builder
+	b.WriteString("[Script Info]\nScriptType: v4.00+\nWrapStyle: 2\nScaledBorderAndShadow: yes\nPlayResX: 1920\nPlayResY: 1080\n\n")
+	b.WriteString("[V4+ Styles]\n")
+	b.WriteString("Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	b.WriteString("Style: Default,Arial,64,&H00FFFFFF,&H000000FF,&H00000000,&H80000000,0,0,0,0,100,100,0,0,1,3,1,2,60,60,50,1\n\n")
+	b.WriteString("[Events]\n")
+	b.WriteString("Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	for _, c := range cues {
+		lines := make([]string, len(c.Lines))
+		for i, l := range c.Lines {
+			lines[i] = assEscape(l)
+		}
+		text := strings.Join(lines, `\N`)
+		if opts.SpeakerLabels {
+			text = speakerLabel(assEscape(c.Speaker), text)
+		}
+		fmt.Fprintf(&b, "Dialogue: 0,%s,%s,Default,%s,0,0,0,,%s\n", assTime(c.Start), assTime(c.End), strings.ReplaceAll(c.Speaker, ",", " "), text)
+	}
+	return b.String()
+}
+
+var assEscaper = strings.NewReplacer(`\`, `\\`, "{", `\{`, "}", `\}`, "\n", " ")
+
+func assEscape(s string) string {
+	return assEscaper.Replace(s)
+}
+
+// assTime formats seconds as H:MM:SS.cc.
+func assTime(seconds float64) string {
+	cs := int64(math.Round(max(seconds, 0) * 100))
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
+
+// FormatTTML formats cues as a Timed Text Markup Language document.
+func FormatTTML(cues []TranscriptCue, language string, opts SubtitleOptions) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	if language != "" {
+		fmt.Fprintf(&b, "<tt xmlns=\"http://www.w3.org/ns/ttml\" xml:lang=\"%s\">\n", html.EscapeString(language))
+	} else {
+		b.WriteString("<tt xmlns=\"http://www.w3.org/ns/ttml\">\n")
+	}
+	b.WriteString("  <body>\n    <div>\n")
+	for _, c := range cues {
+		lines := make([]string, len(c.Lines))
+		for i, l := range c.Lines {
+			lines[i] = html.EscapeString(l)
+		}
+		text := strings.Join(lines, "<br/>")
+		if opts.SpeakerLabels {
+			text = speakerLabel(html.EscapeString(c.Speaker), text)
+		}
+		fmt.Fprintf(&b, "      <p begin=\"%s\" end=\"%s\">%s</p>\n", cueTime(c.Start, "."), cueTime(c.End, "."), text)
+	}
+	b.WriteString("    </div>\n  </body>\n</tt>\n")
+	return b.String()
+}
@@ -0,0 +1,256 @@
+package common
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Text-only transcripts are stitched by finding the overlap audio's words at
+// the end of one part and the start of the next. Matches shorter than
+// minTextOverlap runes are taken as coincidence.
+const (
+	minTextOverlap = 6
+	maxTextOverlap = 400
+)
+
+// MergeTranscripts joins the transcripts of consecutive chunks of one
+// recording, as returned by SplitAudio, into a transcript of the whole.
+// Times are shifted by the chunk offsets. Where two chunks overlap, the
+// earlier chunk keeps what starts before the middle of the overlap and the
+// later one the rest; parts without timestamps drop the text the next part
+// repeats instead. Speaker labels are carried across chunks by matching who
+// talks during the overlap.
+func MergeTranscripts(chunks []AudioChunk, parts []*Transcript) *Transcript {
+	merged := &Transcript{}
+	for i, part := range parts {
+		if part == nil {
+			continue
+		}
+		p := shiftTranscript(part, chunks[i].Offset)
+		if merged.Language == "" {
+			merged.Language = p.Language
+		}
+		if i == 0 {
+			merged.Segments, merged.Words, merged.Text = p.Segments, p.Words, p.Text
+			continue
+		}
+
+		from, to := chunks[i].Offset, chunks[i-1].End()
+		renameSpeakers(p, matchSpeakers(merged, p, from, to))
+		boundary := (from + to) / 2
+
+		switch {
+		case len(merged.Words) > 0 && len(p.Words) > 0:
+			// A sentence cut by the chunk boundary is put back together from
+			// the words on either side.
+			merged.Words = append(wordsBefore(merged.Words, boundary, true), wordsBefore(p.Words, boundary, false)...)
+			merged.Segments, merged.Text = segmentsFromWords(merged.Words), ""
+		case hasSegmentTimes(merged.Segments) || hasSegmentTimes(p.Segments):
+			merged.Segments = append(segmentsBefore(merged.Segments, boundary, true), segmentsBefore(p.Segments, boundary, false)...)
+			merged.Words = append(wordsBefore(merged.Words, boundary, true), wordsBefore(p.Words, boundary, false)...)
+			merged.Text = ""
+		default:
+			if text := dropRepeatedText(merged.Text, p.Text); text != "" {
+				merged.Text = strings.TrimSpace(joinWords(merged.Text, text))
+			}
+		}
+	}
+	if len(chunks) > 0 {
+		merged.Duration = roundMillis(chunks[len(chunks)-1].End())
+	}
+	if merged.Text != "" {
+		merged.Segments, merged.Words = nil, nil
+	}
+	merged.Normalize()
+	return merged
+}
+
+// shiftTranscript returns a copy of t with its times moved by offset.
+func shiftTranscript(t *Transcript, offset float64) *Transcript {
+	out := &Transcript{Text: t.Text, Language: t.Language}
+	timed := hasSegmentTimes(t.Segments) || len(t.Words) > 0
+	if timed {
+		out.Text = ""
+	}
+	for _, s := range t.Segments {
+		if timed {
+			s.Start, s.End = roundMillis(s.Start+offset), roundMillis(s.End+offset)
+		}
+		out.Segments = append(out.Segments, s)
+	}
+	for _, w := range t.Words {
+		w.Start, w.End = roundMillis(w.Start+offset), roundMillis(w.End+offset)
+		out.Words = append(out.Words, w)
+	}
+	return out
+}
+
+// segmentsBefore returns the segments starting before boundary, or with
+// before false those starting at or after it.
+func segmentsBefore(segments []TranscriptSegment, boundary float64, before bool) []TranscriptSegment {
+	var out []TranscriptSegment
+	for _, s := range segments {
+		if (s.Start < boundary) == before {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// wordsBefore is segmentsBefore for words.
+func wordsBefore(words []TranscriptWord, boundary float64, before bool) []TranscriptWord {
+	var out []TranscriptWord
+	for _, w := range words {
+		if (w.Start < boundary) == before {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// speakerSpans returns who talks when in t: its words when they carry
+// speakers, otherwise its segments.
+func speakerSpans(t *Transcript) []TranscriptSegment {
+	var spans []TranscriptSegment
+	for _, w := range t.Words {
+		if w.Speaker != "" {
+			spans = append(spans, TranscriptSegment{Start: w.Start, End: w.End, Speaker: w.Speaker})
+		}
+	}
+	if len(spans) > 0 {
+		return spans
+	}
+	for _, s := range t.Segments {
+		if s.Speaker != "" {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+// matchSpeakers maps the speakers of next to those of prev, pairing the
+// speakers that talk at the same time during the overlap from-to, the
+// longest shared talk first. Speakers that cannot be matched keep their
+// label unless a matched speaker took it, in which case they get a new one.
+func matchSpeakers(prev, next *Transcript, from, to float64) map[string]string {
+	prevSpans, nextSpans := speakerSpans(prev), speakerSpans(next)
+	if len(nextSpans) == 0 {
+		return nil
+	}
+
+	type pair struct {
+		prev, next string
+		shared     float64
+	}
+	shared := map[[2]string]float64{}
+	for _, n := range nextSpans {
+		for _, p := range prevSpans {
+			start, end := max(n.Start, p.Start, from), min(n.End, p.End, to)
+			if end > start {
+				shared[[2]string{p.Speaker, n.Speaker}] += end - start
+			}
+		}
+	}
+	var pairs []pair
+	for k, v := range shared {
+		pairs = append(pairs, pair{k[0], k[1], v})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].shared != pairs[j].shared {
+			return pairs[i].shared > pairs[j].shared
+		}
+		return pairs[i].prev+"\x00"+pairs[i].next < pairs[j].prev+"\x00"+pairs[j].next
+	})
+
+	mapping := map[string]string{}
+	taken := map[string]bool{}
+	for _, p := range pairs {
+		if _, ok := mapping[p.next]; !ok && !taken[p.prev] {
+			mapping[p.next] = p.prev
+			taken[p.prev] = true
+		}
+	}
+
+	used := map[string]bool{}
+	for _, s := range append(prevSpans, nextSpans...) {
+		used[s.Speaker] = true
+	}
+	for _, s := range nextSpans {
+		if _, ok := mapping[s.Speaker]; ok {
+			continue
+		}
+		label := s.Speaker
+		for taken[label] {
+			label = nextSpeakerLabel(label, used)
+		}
+		mapping[s.Speaker] = label
+		taken[label] = true
+	}
+	return mapping
+}
+
+// nextSpeakerLabel returns a label like speaker that is not in used: its
+// trailing number is incremented, or a number is appended.
+func nextSpeakerLabel(speaker string, used map[string]bool) string {
+	prefix := strings.TrimRightFunc(speaker, unicode.IsDigit)
+	n, err := strconv.Atoi(speaker[len(prefix):])
+	if err != nil {
+		prefix, n = speaker+" ", 1
+	}
+	for {
+		n++
+		if label := prefix + strconv.Itoa(n); !used[label] {
+			used[label] = true
+			return label
+		}
+	}
+}
+
+// renameSpeakers relabels the speakers of t.
+func renameSpeakers(t *Transcript, mapping map[string]string) {
+	for i, s := range t.Segments {
+		if label, ok := mapping[s.Speaker]; ok {
+			t.Segments[i].Speaker = label
+		}
+	}
+	for i, w := range t.Words {
+		if label, ok := mapping[w.Speaker]; ok {
+			t.Words[i].Speaker = label
+		}
+	}
+}
+
+// dropRepeatedText returns next without the text at its start that repeats
+// the end of prev. Case, spacing and punctuation are ignored when comparing.
+func dropRepeatedText(prev, next string) string {
+	prevRunes, _ := comparableRunes(prev)
+	nextRunes, positions := comparableRunes(next)
+	nextOriginal := []rune(next)
+
+	for k := min(len(prevRunes), len(nextRunes), maxTextOverlap); k >= minTextOverlap; k-- {
+		if string(prevRunes[len(prevRunes)-k:]) != string(nextRunes[:k]) {
+			continue
+		}
+		rest := nextOriginal[positions[k-1]+1:]
+		return strings.TrimLeftFunc(string(rest), func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.IsPunct(r)
+		})
+	}
+	return strings.TrimSpace(next)
+}
+
+// comparableRunes returns the lower-cased letters and digits of s together
+// with their rune positions in s.
+func comparableRunes(s string) ([]rune, []int) {
+	var runes []rune
+	var positions []int
+	for i, r := range []rune(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, unicode.ToLower(r))
+			positions = append(positions, i)
+		}
+	}
+	return runes, positions
+}
@@ -0,0 +1,113 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeTranscripts_Timed(t *testing.T) {
+	chunks := []AudioChunk{{Offset: 0, Duration: 10}, {Offset: 8, Duration: 7}}
+	parts := []*Transcript{
+		{Language: "en", Segments: []TranscriptSegment{
+			{Start: 1, End: 4, Text: "First sentence."},
+			{Start: 8.5, End: 10, Text: "Second sen"},
+		}},
+		{Language: "en", Segments: []TranscriptSegment{
+			{Start: 0, End: 1.5, Text: "tence."},
+			{Start: 0.5, End: 2, Text: "Second sentence."},
+			{Start: 3, End: 6, Text: "Third sentence."},
+		}},
+	}
+
+	merged := MergeTranscripts(chunks, parts)
+	want := []TranscriptSegment{
+		{Start: 1, End: 4, Text: "First sentence."},
+		{Start: 8.5, End: 10, Text: "Second sen"},
+		{Start: 11, End: 14, Text: "Third sentence."},
+	}
+	// Segments starting in the first half of the overlap (8-10) belong to
+	// the first chunk.
+	if !reflect.DeepEqual(merged.Segments, want) {
+		t.Errorf("unexpected segments: %+v", merged.Segments)
+	}
+	if merged.Text != "First sentence. Second sen Third sentence." {
+		t.Errorf("unexpected text %q", merged.Text)
+	}
+	if merged.Duration != 15 || merged.Language != "en" {
+		t.Errorf("unexpected duration %v or language %q", merged.Duration, merged.Language)
+	}
+}
+
+func TestMergeTranscripts_Words(t *testing.T) {
+	chunks := []AudioChunk{{Offset: 0, Duration: 10}, {Offset: 8, Duration: 4}}
+	parts := []*Transcript{
+		{Words: []TranscriptWord{{Text: "one", Start: 7, End: 7.5}, {Text: "two", Start: 8.5, End: 8.9}}},
+		{Words: []TranscriptWord{{Text: "two", Start: 0.5, End: 0.9}, {Text: "three", Start: 1.2, End: 1.6}}},
+	}
+	for _, p := range parts {
+		p.Normalize()
+	}
+	merged := MergeTranscripts(chunks, parts)
+	if merged.Text != "one two three" {
+		t.Errorf("unexpected text %q", merged.Text)
+	}
+	if len(merged.Words) != 3 || merged.Words[2].Start != 9.2 {
+		t.Errorf("unexpected words %+v", merged.Words)
+	}
+}
+
+func TestMergeTranscripts_Text(t *testing.T) {
+	chunks := []AudioChunk{{Offset: 0, Duration: 10}, {Offset: 8, Duration: 10}}
+	parts := []*Transcript{
+		{Text: "We went to the market and bought apples."},
+		{Text: "Bought apples, then we went home."},
+	}
+	merged := MergeTranscripts(chunks, parts)
+	if merged.Text != "We went to the market and bought apples. then we went home." {
+		t.Errorf("unexpected text %q", merged.Text)
+	}
+
+	parts[1].Text = "Then we went home."
+	if merged := MergeTranscripts(chunks, parts); merged.Text != "We went to the market and bought apples. Then we went home." {
+		t.Errorf("unexpected text without repetition %q", merged.Text)
+	}
+}
+
+func TestMergeTranscripts_Speakers(t *testing.T) {
+	chunks := []AudioChunk{{Offset: 0, Duration: 10}, {Offset: 8, Duration: 10}}
+	parts := []*Transcript{
+		{Segments: []TranscriptSegment{
+			{Start: 0, End: 5, Text: "Hello.", Speaker: "speaker_0"},
+			{Start: 5, End: 9.8, Text: "Hi there.", Speaker: "speaker_1"},
+		}},
+		{Segments: []TranscriptSegment{
+			// The second chunk hears speaker_1 of the first as speaker_0.
+			{Start: 0, End: 1.8, Text: "there.", Speaker: "speaker_0"},
+			{Start: 3, End: 5, Text: "How are you?", Speaker: "speaker_1"},
+			{Start: 6, End: 8, Text: "Fine.", Speaker: "speaker_0"},
+		}},
+	}
+	merged := MergeTranscripts(chunks, parts)
+
+	var got []string
+	for _, s := range merged.Segments {
+		got = append(got, s.Speaker)
+	}
+	want := []string{"speaker_0", "speaker_1", "speaker_2", "speaker_1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected speakers %q, got %q", want, got)
+	}
+	if !reflect.DeepEqual(merged.Speakers, []string{"speaker_0", "speaker_1", "speaker_2"}) {
+		t.Errorf("unexpected speaker list %q", merged.Speakers)
+	}
+}
+
+func TestNextSpeakerLabel(t *testing.T) {
+	used := map[string]bool{"speaker_1": true, "speaker_2": true, "Guest 2": true}
+	if got := nextSpeakerLabel("speaker_1", used); got != "speaker_3" {
+		t.Errorf("expected speaker_3, got %s", got)
+	}
+	if got := nextSpeakerLabel("Guest", used); got != "Guest 3" {
+		t.Errorf("expected Guest 3, got %s", got)
+	}
+}
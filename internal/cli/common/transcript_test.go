@@ -0,0 +1,213 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var defaultSubtitleOptions = SubtitleOptions{MaxLineLength: DefaultMaxLineLength, MaxLines: DefaultMaxLines}
+
+func testTranscript() *Transcript {
+	t := &Transcript{
+		Language: "en",
+		Words: []TranscriptWord{
+			{Text: "Hello", Start: 0, End: 0.4, Speaker: "A", Confidence: 0.9},
+			{Text: "there.", Start: 0.5, End: 1, Speaker: "A", Confidence: 0.7},
+			{Text: "Hi!", Start: 1.5, End: 2, Speaker: "B"},
+		},
+	}
+	t.Normalize()
+	return t
+}
+
+func TestTranscript_Normalize(t *testing.T) {
+	tr := testTranscript()
+
+	want := []TranscriptSegment{
+		{Start: 0, End: 1, Text: "Hello there.", Speaker: "A"},
+		{Start: 1.5, End: 2, Text: "Hi!", Speaker: "B"},
+	}
+	if !reflect.DeepEqual(tr.Segments, want) {
+		t.Errorf("unexpected segments: %+v", tr.Segments)
+	}
+	if tr.Text != "Hello there. Hi!" {
+		t.Errorf("unexpected text %q", tr.Text)
+	}
+	if !reflect.DeepEqual(tr.Speakers, []string{"A", "B"}) {
+		t.Errorf("unexpected speakers %q", tr.Speakers)
+	}
+	if tr.Confidence != 0.8 {
+		t.Errorf("expected confidence 0.8, got %v", tr.Confidence)
+	}
+}
+
+func TestTranscriptFormat(t *testing.T) {
+	tests := map[string]string{
+		"out.srt":  TranscriptSRT,
+		"out.VTT":  TranscriptVTT,
+		"out.ass":  TranscriptASS,
+		"out.ttml": TranscriptTTML,
+		"out.json": TranscriptJSON,
+		"out.md":   TranscriptText,
+		"out":      TranscriptText,
+	}
+	for path, want := range tests {
+		if got := TranscriptFormat(path, TranscriptText); got != want {
+			t.Errorf("%s: expected %s, got %s", path, want, got)
+		}
+	}
+}
+
+func TestTranscriptCues_WrapsLines(t *testing.T) {
+	tr := &Transcript{Segments: []TranscriptSegment{{Start: 0, End: 8, Text: "one two three four five six seven eight"}}}
+	opts := SubtitleOptions{MaxLineLength: 10, MaxLines: 2}
+
+	cues, err := TranscriptCues(tr, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]string
+	for _, c := range cues {
+		got = append(got, c.Lines)
+	}
+	want := [][]string{{"one two", "three four"}, {"five six", "seven"}, {"eight"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if cues[0].Start != 0 || cues[len(cues)-1].End != 8 {
+		t.Errorf("cues should span the segment, got %v-%v", cues[0].Start, cues[len(cues)-1].End)
+	}
+	if cues[1].Start <= cues[0].Start || cues[1].Start >= cues[2].Start {
+		t.Errorf("expected interpolated times in order, got %+v", cues)
+	}
+}
+
+func TestTranscriptCues_UsesWordTimes(t *testing.T) {
+	tr := testTranscript()
+	cues, err := TranscriptCues(tr, SubtitleOptions{MaxLineLength: 6, MaxLines: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cues) != 3 {
+		t.Fatalf("expected 3 cues, got %+v", cues)
+	}
+	if cues[1].Start != 0.5 || cues[1].End != 1 || cues[2].Speaker != "B" {
+		t.Errorf("unexpected cue: %+v", cues[1])
+	}
+}
+
+func TestTranscriptCues_CJK(t *testing.T) {
+	tr := &Transcript{Segments: []TranscriptSegment{{Start: 0, End: 2, Text: "今天天气很好。"}}}
+	cues, err := TranscriptCues(tr, SubtitleOptions{MaxLineLength: 4, MaxLines: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cues) != 1 || !reflect.DeepEqual(cues[0].Lines, []string{"今天天气", "很好。"}) {
+		t.Errorf("unexpected cues: %+v", cues)
+	}
+}
+
+func TestTranscriptCues_NoTimestamps(t *testing.T) {
+	tr := &Transcript{Text: "hello", Segments: []TranscriptSegment{{Text: "hello"}}}
+	if _, err := TranscriptCues(tr, defaultSubtitleOptions); !errors.Is(err, ErrNoTimestamps) {
+		t.Errorf("expected ErrNoTimestamps, got %v", err)
+	}
+	if _, err := FormatTranscript(tr, TranscriptText, defaultSubtitleOptions); err != nil {
+		t.Errorf("text should not need timestamps: %v", err)
+	}
+}
+
+func TestFormatTranscript(t *testing.T) {
+	tr := testTranscript()
+	labels := SubtitleOptions{MaxLineLength: DefaultMaxLineLength, MaxLines: DefaultMaxLines, SpeakerLabels: true}
+
+	tests := []struct {
+		format string
+		opts   SubtitleOptions
+		want   string
+	}{
+		{TranscriptText, defaultSubtitleOptions, "Hello there. Hi!\n"},
+		{TranscriptText, labels, "A: Hello there.\nB: Hi!\n"},
+		{TranscriptSRT, defaultSubtitleOptions, "1\n00:00:00,000 --> 00:00:01,000\nHello there.\n\n"},
+		{TranscriptSRT, labels, "2\n00:00:01,500 --> 00:00:02,000\nB: Hi!\n\n"},
+		{TranscriptVTT, labels, "WEBVTT\n\n00:00:00.000 --> 00:00:01.000\n<v A>Hello there.\n\n"},
+		{TranscriptASS, defaultSubtitleOptions, "Dialogue: 0,0:00:01.50,0:00:02.00,Default,B,0,0,0,,Hi!\n"},
+		{TranscriptTTML, labels, `<p begin="00:00:01.500" end="00:00:02.000">B: Hi!</p>`},
+		{TranscriptTTML, defaultSubtitleOptions, `xml:lang="en"`},
+	}
+	for _, tt := range tests {
+		data, err := FormatTranscript(tr, tt.format, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if !strings.Contains(string(data), tt.want) {
+			t.Errorf("%s: expected %q in:\n%s", tt.format, tt.want, data)
+		}
+	}
+}
+
+func TestFormatTranscript_Escapes(t *testing.T) {
+	tr := &Transcript{Segments: []TranscriptSegment{{Start: 0, End: 1, Text: "a < b & {c}"}}}
+
+	vtt, _ := FormatTranscript(tr, TranscriptVTT, defaultSubtitleOptions)
+	if !strings.Contains(string(vtt), "a &lt; b &amp; {c}") {
+		t.Errorf("unexpected VTT escaping:\n%s", vtt)
+	}
+	ass, _ := FormatTranscript(tr, TranscriptASS, defaultSubtitleOptions)
+	if !strings.Contains(string(ass), `a < b & \{c\}`) {
+		t.Errorf("unexpected ASS escaping:\n%s", ass)
+	}
+	ttml, _ := FormatTranscript(tr, TranscriptTTML, defaultSubtitleOptions)
+	if !strings.Contains(string(ttml), "a &lt; b &amp; {c}") {
+		t.Errorf("unexpected TTML escaping:\n%s", ttml)
+	}
+}
+
+func TestAssTime(t *testing.T) {
+	if got := assTime(3723.456); got != "1:02:03.46" {
+		t.Errorf("expected 1:02:03.46, got %s", got)
+	}
+}
+
+func TestWriteTranscript(t *testing.T) {
+	dir := t.TempDir()
+	tr := testTranscript()
+
+	path, err := WriteTranscript(filepath.Join(dir, "out"), tr, TranscriptJSON, defaultSubtitleOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Ext(path) != ".json" {
+		t.Errorf("expected the format's extension, got %s", path)
+	}
+	data, _ := os.ReadFile(path)
+	var decoded Transcript
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Text != tr.Text {
+		t.Errorf("expected transcript JSON, got %s", data)
+	}
+
+	path, err = WriteTranscript(filepath.Join(dir, "out.srt"), tr, TranscriptSRT, defaultSubtitleOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "1\n00:00:00,000") {
+		t.Errorf("expected SRT, got %s", data)
+	}
+}
+
+func TestSubtitleOptions_Validate(t *testing.T) {
+	if err := defaultSubtitleOptions.Validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := (SubtitleOptions{MaxLineLength: 0, MaxLines: 2}).Validate(); err == nil {
+		t.Error("expected an error for --max-line-length 0")
+	}
+	if err := (SubtitleOptions{MaxLineLength: 42, MaxLines: 0}).Validate(); err == nil {
+		t.Error("expected an error for --max-lines 0")
+	}
+}
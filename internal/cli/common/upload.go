@@ -0,0 +1,196 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// Upload backend names
+const (
+	UploadBackendS3     = "s3"
+	UploadBackendWebDAV = "webdav"
+	UploadBackendNative = "native"
+)
+
+// DefaultUploadExpiry is the default lifetime of URLs for uploaded files.
+const DefaultUploadExpiry = 24 * time.Hour
+
+// ErrUploadNotConfigured is returned when a local file needs uploading but no backend is available.
+var ErrUploadNotConfigured = errors.New("no upload backend configured, set upload_backend with: rawgenai config set upload_backend <s3|webdav>")
+
+// Uploader publishes a local file and returns a URL that a provider can fetch.
+type Uploader interface {
+	Upload(ctx context.Context, path string, expiry time.Duration) (*UploadResult, error)
+}
+
+// UploadResult describes a local file that was uploaded.
+type UploadResult struct {
+	Source    string     `json:"source"`
+	URL       string     `json:"url"`
+	Backend   string     `json:"backend"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Object is the stored name to pass to DeleteUpload (rawgenai upload
+	// delete). Empty if the backend cannot delete.
+	Object string `json:"object,omitempty"`
+}
+
+// uploadDeleter is implemented by backends that can delete uploaded files.
+type uploadDeleter interface {
+	Delete(ctx context.Context, object string) error
+}
+
+// UploadFlags holds the flags that control how local files are uploaded.
+type UploadFlags struct {
+	Backend string
+	Expiry  time.Duration
+}
+
+// AddUploadFlags registers --upload-backend and --upload-expiry.
+func AddUploadFlags(cmd *cobra.Command, flags *UploadFlags) {
+	cmd.Flags().StringVar(&flags.Backend, "upload-backend", "", "Backend for local files: s3, webdav, native (default: upload_backend config)")
+	cmd.Flags().DurationVar(&flags.Expiry, "upload-expiry", DefaultUploadExpiry, "Lifetime of URLs for uploaded local files")
+}
+
+// Uploads resolves inputs that may be local files into URLs, uploading them when needed.
+type Uploads struct {
+	flags   *UploadFlags
+	native  Uploader
+	Results []*UploadResult
+}
+
+// NewUploads creates an upload resolver. native is the provider's own upload
+// backend, or nil if the provider has none.
+func NewUploads(flags *UploadFlags, native Uploader) *Uploads {
+	if flags == nil {
+		flags = &UploadFlags{Expiry: DefaultUploadExpiry}
+	}
+	return &Uploads{flags: flags, native: native}
+}
+
+// IsRemoteInput reports whether input is already a URL (http, https, oss, data, ...).
+func IsRemoteInput(input string) bool {
+	return strings.Contains(input, "://") || strings.HasPrefix(input, "data:")
+}
+
+// Resolve returns input unchanged if it is a URL, otherwise uploads the local
+// file and returns its URL.
+func (u *Uploads) Resolve(ctx context.Context, input string) (string, error) {
+	if input == "" || IsRemoteInput(input) {
+		return input, nil
+	}
+
+	info, err := os.Stat(input)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", input)
+	}
+
+	if u.flags.Expiry <= 0 {
+		return "", fmt.Errorf("upload expiry must be positive")
+	}
+
+	uploader, err := u.uploader()
+	if err != nil {
+		return "", err
+	}
+
+	result, err := uploader.Upload(ctx, input, u.flags.Expiry)
+	if err != nil {
+		return "", fmt.Errorf("cannot upload %s: %w", input, err)
+	}
+	if abs, err := filepath.Abs(input); err == nil {
+		result.Source = abs
+	} else {
+		result.Source = input
+	}
+	u.Results = append(u.Results, result)
+	return result.URL, nil
+}
+
+// uploader picks the backend: --upload-backend, then upload_backend config,
+// then the provider's native backend, then whichever generic backend is configured.
+func (u *Uploads) uploader() (Uploader, error) {
+	backend := strings.ToLower(u.flags.Backend)
+	if backend == "" {
+		backend = strings.ToLower(config.GetAPIKey("UPLOAD_BACKEND"))
+	}
+
+	switch backend {
+	case UploadBackendS3:
+		return newS3UploaderFromConfig()
+	case UploadBackendWebDAV:
+		return newWebDAVUploaderFromConfig()
+	case UploadBackendNative:
+		if u.native == nil {
+			return nil, fmt.Errorf("this provider has no native upload backend, use s3 or webdav")
+		}
+		return u.native, nil
+	case "":
+		if u.native != nil {
+			return u.native, nil
+		}
+		if config.GetAPIKey("UPLOAD_S3_BUCKET") != "" {
+			return newS3UploaderFromConfig()
+		}
+		if config.GetAPIKey("UPLOAD_WEBDAV_URL") != "" {
+			return newWebDAVUploaderFromConfig()
+		}
+		return nil, ErrUploadNotConfigured
+	default:
+		return nil, fmt.Errorf("unknown upload backend '%s', use s3, webdav, or native", backend)
+	}
+}
+
+// DeleteUpload deletes a file uploaded to backend (s3 or webdav, default:
+// upload_backend config) by its object name. Providers fetch uploaded URLs
+// after an async task is submitted, so only delete once the task is done.
+func DeleteUpload(ctx context.Context, backend, object string) error {
+	uploader, err := NewUploads(&UploadFlags{Backend: backend}, nil).uploader()
+	if err != nil {
+		return err
+	}
+	deleter, ok := uploader.(uploadDeleter)
+	if !ok {
+		return fmt.Errorf("upload backend cannot delete files")
+	}
+	return deleter.Delete(ctx, object)
+}
+
+// UploadErrorCode maps an error from Uploads.Resolve to a CLI error code.
+// notFoundCode is used when the local file does not exist.
+func UploadErrorCode(err error, notFoundCode string) string {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return notFoundCode
+	case errors.Is(err, ErrUploadNotConfigured):
+		return "upload_not_configured"
+	default:
+		return "upload_error"
+	}
+}
+
+var unsafeObjectChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// SafeObjectName returns the base name of path with characters that are
+// not URL-safe replaced.
+func SafeObjectName(path string) string {
+	return unsafeObjectChars.ReplaceAllString(filepath.Base(path), "_")
+}
+
+// uploadObjectName returns a unique, URL-safe object name for a local file.
+func uploadObjectName(path string) string {
+	return uuid.NewString() + "-" + SafeObjectName(path)
+}
@@ -0,0 +1,216 @@
+package common
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/WHQ25/rawgenai/internal/config"
+)
+
+// s3MaxPresignExpiry is the longest lifetime SigV4 allows for presigned URLs.
+const s3MaxPresignExpiry = 7 * 24 * time.Hour
+
+// s3Uploader uploads to an S3-compatible bucket with presigned PUT, and hands
+// out a presigned GET URL. Works with AWS S3, R2, MinIO, OSS (S3 mode), etc.
+type s3Uploader struct {
+	endpoint  string // custom endpoint, uses path-style addressing when set
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	prefix    string
+	client    *http.Client
+	now       func() time.Time
+}
+
+func newS3UploaderFromConfig() (*s3Uploader, error) {
+	u := &s3Uploader{
+		endpoint:  strings.TrimRight(config.GetAPIKey("UPLOAD_S3_ENDPOINT"), "/"),
+		region:    config.GetAPIKey("UPLOAD_S3_REGION"),
+		bucket:    config.GetAPIKey("UPLOAD_S3_BUCKET"),
+		accessKey: config.GetAPIKey("UPLOAD_S3_ACCESS_KEY"),
+		secretKey: config.GetAPIKey("UPLOAD_S3_SECRET_KEY"),
+		prefix:    strings.Trim(config.GetAPIKey("UPLOAD_S3_PREFIX"), "/"),
+		client:    &http.Client{Timeout: 10 * time.Minute},
+		now:       time.Now,
+	}
+	if u.bucket == "" || u.accessKey == "" || u.secretKey == "" {
+		return nil, fmt.Errorf("s3 upload requires upload_s3_bucket, upload_s3_access_key and upload_s3_secret_key")
+	}
+	if u.region == "" {
+		u.region = "us-east-1"
+	}
+	return u, nil
+}
+
+func (s *s3Uploader) Upload(ctx context.Context, path string, expiry time.Duration) (*UploadResult, error) {
+	if expiry > s3MaxPresignExpiry {
+		return nil, fmt.Errorf("s3 presigned URLs cannot last longer than %s", s3MaxPresignExpiry)
+	}
+
+	key := uploadObjectName(path)
+	if s.prefix != "" {
+		key = s.prefix + "/" + key
+	}
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	putURL := presignS3("PUT", objectURL, s.region, s.accessKey, s.secretKey, now, 15*time.Minute)
+	req, err := http.NewRequestWithContext(ctx, "PUT", putURL, file)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = info.Size()
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("s3 PUT failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	expiresAt := now.Add(expiry).UTC()
+	return &UploadResult{
+		URL:       presignS3("GET", objectURL, s.region, s.accessKey, s.secretKey, now, expiry),
+		Backend:   UploadBackendS3,
+		ExpiresAt: &expiresAt,
+		Object:    key,
+	}, nil
+}
+
+// Delete removes an uploaded object by its key.
+func (s *s3Uploader) Delete(ctx context.Context, object string) error {
+	objectURL, err := s.objectURL(object)
+	if err != nil {
+		return err
+	}
+	delURL := presignS3("DELETE", objectURL, s.region, s.accessKey, s.secretKey, s.now(), 15*time.Minute)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", delURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("S3 delete failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// objectURL returns the unsigned object URL. A custom endpoint uses path-style
+// addressing, otherwise AWS virtual-hosted style is used.
+func (s *s3Uploader) objectURL(key string) (*url.URL, error) {
+	if s.endpoint != "" {
+		return url.Parse(s.endpoint + "/" + s.bucket + "/" + key)
+	}
+	return url.Parse(fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, key))
+}
+
+// presignS3 returns a SigV4 query-string signed URL for the given object URL.
+func presignS3(method string, u *url.URL, region, accessKey, secretKey string, t time.Time, expires time.Duration) string {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	dateStamp := t.Format("20060102")
+	scope := dateStamp + "/" + region + "/s3/aws4_request"
+
+	query := map[string]string{
+		"X-Amz-Algorithm":     "AWS4-HMAC-SHA256",
+		"X-Amz-Credential":    accessKey + "/" + scope,
+		"X-Amz-Date":          amzDate,
+		"X-Amz-Expires":       fmt.Sprintf("%d", int64(expires/time.Second)),
+		"X-Amz-SignedHeaders": "host",
+	}
+	names := make([]string, 0, len(query))
+	for k := range query {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, k := range names {
+		pairs = append(pairs, awsURIEncode(k, true)+"="+awsURIEncode(query[k], true))
+	}
+	canonicalQuery := strings.Join(pairs, "&")
+	canonicalPath := awsURIEncode(u.Path, false)
+	if canonicalPath == "" {
+		canonicalPath = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalPath,
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), dateStamp)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return u.Scheme + "://" + u.Host + canonicalPath + "?" + canonicalQuery + "&X-Amz-Signature=" + signature
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsURIEncode percent-encodes everything except unreserved characters.
+// Slashes are kept unless encodeSlash is set.
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
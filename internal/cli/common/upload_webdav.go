@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/WHQ25/rawgenai/internal/config"
+)
+
+// webdavUploader uploads with a plain HTTP PUT, which covers WebDAV servers and
+// any HTTP endpoint that accepts PUT. The file is served back from
+// upload_public_url when set, otherwise from the PUT URL itself.
+type webdavUploader struct {
+	baseURL   string
+	publicURL string
+	username  string
+	password  string
+	client    *http.Client
+}
+
+func newWebDAVUploaderFromConfig() (*webdavUploader, error) {
+	u := &webdavUploader{
+		baseURL:   strings.TrimRight(config.GetAPIKey("UPLOAD_WEBDAV_URL"), "/"),
+		publicURL: strings.TrimRight(config.GetAPIKey("UPLOAD_PUBLIC_URL"), "/"),
+		username:  config.GetAPIKey("UPLOAD_WEBDAV_USERNAME"),
+		password:  config.GetAPIKey("UPLOAD_WEBDAV_PASSWORD"),
+		client:    &http.Client{Timeout: 10 * time.Minute},
+	}
+	if u.baseURL == "" {
+		return nil, fmt.Errorf("webdav upload requires upload_webdav_url")
+	}
+	return u, nil
+}
+
+func (w *webdavUploader) Upload(ctx context.Context, path string, expiry time.Duration) (*UploadResult, error) {
+	name := uploadObjectName(path)
+	putURL := w.baseURL + "/" + name
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", putURL, file)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = info.Size()
+	w.authorize(req)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("PUT failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	publicURL := putURL
+	if w.publicURL != "" {
+		publicURL = w.publicURL + "/" + name
+	}
+
+	// The server decides how long the file lives; expiry only applies to signed backends.
+	return &UploadResult{
+		URL:     publicURL,
+		Backend: UploadBackendWebDAV,
+		Object:  name,
+	}, nil
+}
+
+// Delete removes an uploaded file by its object name.
+func (w *webdavUploader) Delete(ctx context.Context, object string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", w.baseURL+"/"+object, nil)
+	if err != nil {
+		return err
+	}
+	w.authorize(req)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("WebDAV delete failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (w *webdavUploader) authorize(req *http.Request) {
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+}
@@ -0,0 +1,140 @@
+package common
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// wavHeaderSize is the size of the canonical 44-byte WAV header.
+const wavHeaderSize = 44
+
+// buildWAVHeader builds a WAV header for dataSize bytes of PCM in format.
+func buildWAVHeader(format AudioFormat, dataSize uint32) []byte {
+	channels := max(format.Channels, 1)
+	blockAlign := channels * format.Encoding.BytesPerSample()
+	audioFormat := uint16(1) // PCM
+	if format.Encoding == PCMF32LE {
+		audioFormat = 3 // IEEE float
+	}
+
+	header := make([]byte, wavHeaderSize)
+
+	// RIFF header; the size excludes the first 8 bytes
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], dataSize+wavHeaderSize-8)
+	copy(header[8:12], "WAVE")
+
+	// fmt subchunk
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], audioFormat)
+	binary.LittleEndian.PutUint16(header[22:24], uint16(channels))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(format.SampleRate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(format.SampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(header[32:34], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(header[34:36], uint16(format.Encoding.BytesPerSample()*8))
+
+	// data subchunk
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], dataSize)
+
+	return header
+}
+
+// PCMToWAV wraps raw PCM samples in a WAV container.
+func PCMToWAV(pcm []byte, format AudioFormat) []byte {
+	wav := make([]byte, 0, wavHeaderSize+len(pcm))
+	wav = append(wav, buildWAVHeader(format, uint32(len(pcm)))...)
+	return append(wav, pcm...)
+}
+
+// WAVWriter writes streamed PCM samples as a WAV file. The sizes in the
+// header are filled in by Close, so the file is only complete after Close.
+type WAVWriter struct {
+	w      io.WriteSeeker
+	format AudioFormat
+	size   int64
+}
+
+// NewWAVWriter writes a placeholder header to w and returns a writer for the
+// samples that follow it.
+func NewWAVWriter(w io.WriteSeeker, format AudioFormat) (*WAVWriter, error) {
+	if _, err := w.Write(buildWAVHeader(format, 0)); err != nil {
+		return nil, err
+	}
+	return &WAVWriter{w: w, format: format}, nil
+}
+
+func (w *WAVWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close rewrites the header with the final sizes. It does not close the
+// underlying writer.
+func (w *WAVWriter) Close() error {
+	if w.size > int64(^uint32(0))-wavHeaderSize {
+		return fmt.Errorf("audio too long for a WAV file")
+	}
+	if _, err := w.w.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(buildWAVHeader(w.format, uint32(w.size))); err != nil {
+		return err
+	}
+	_, err := w.w.Seek(0, io.SeekEnd)
+	return err
+}
+
+// IsWAVPath reports whether path names a WAV file.
+func IsWAVPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".wav")
+}
+
+// CreatePCMFile creates path for raw PCM samples in format. A .wav path gets
+// a WAV header, any other path receives the samples as they are.
+func CreatePCMFile(path string, format AudioFormat) (io.WriteCloser, error) {
+	if IsWAVPath(path) {
+		return CreateWAVFile(path, format)
+	}
+	return CreateOutput(path)
+}
+
+// CreateWAVFile creates a WAV file for PCM samples in format. The header is
+// completed when the returned writer is closed.
+func CreateWAVFile(path string, format AudioFormat) (io.WriteCloser, error) {
+	file, err := CreateOutput(path)
+	if err != nil {
+		return nil, err
+	}
+	wav, err := NewWAVWriter(file, format)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &wavFile{WAVWriter: wav, file: file}, nil
+}
+
+// wavFile finishes the WAV header before closing its file.
+type wavFile struct {
+	*WAVWriter
+	file   *os.File
+	closed bool
+}
+
+func (f *wavFile) Close() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	f.closed = true
+	err := f.WAVWriter.Close()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPCMToWAV(t *testing.T) {
+	pcm := []byte{0x01, 0x02, 0x03, 0x04}
+	wav := PCMToWAV(pcm, AudioFormat{SampleRate: 24000, Channels: 1, Encoding: PCMS16LE})
+
+	if string(wav[0:4]) != "RIFF" || string(wav[8:12]) != "WAVE" {
+		t.Error("expected RIFF/WAVE header")
+	}
+	if string(wav[12:16]) != "fmt " || string(wav[36:40]) != "data" {
+		t.Error("expected fmt and data subchunks")
+	}
+	if got := binary.LittleEndian.Uint32(wav[4:8]); got != 36+4 {
+		t.Errorf("RIFF size = %d, want %d", got, 36+4)
+	}
+	if got := binary.LittleEndian.Uint32(wav[28:32]); got != 48000 {
+		t.Errorf("byte rate = %d, want 48000", got)
+	}
+	if !bytes.Equal(wav[44:], pcm) {
+		t.Error("PCM data not correctly appended")
+	}
+}
+
+func TestPCMToWAV_RoundTrip(t *testing.T) {
+	format := AudioFormat{SampleRate: 22050, Channels: 2, Encoding: PCMS16LE}
+	wav := PCMToWAV(make([]byte, 400), format)
+
+	stream, err := DecodeAudio(bytes.NewReader(wav), ".wav", DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(stream)
+	if stream.Format != format || len(data) != 400 {
+		t.Errorf("round trip = %+v, %d bytes", stream.Format, len(data))
+	}
+}
+
+func TestCreatePCMFile(t *testing.T) {
+	dir := t.TempDir()
+	format := AudioFormat{SampleRate: 16000, Channels: 1, Encoding: PCMS16LE}
+
+	wavPath := filepath.Join(dir, "out.WAV")
+	w, err := CreatePCMFile(wavPath, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(make([]byte, 100))
+	w.Write(make([]byte, 60))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(wavPath)
+	if len(data) != 44+160 {
+		t.Fatalf("expected %d bytes, got %d", 44+160, len(data))
+	}
+	if got := binary.LittleEndian.Uint32(data[40:44]); got != 160 {
+		t.Errorf("data size = %d, want 160", got)
+	}
+	if got := binary.LittleEndian.Uint32(data[4:8]); got != 36+160 {
+		t.Errorf("RIFF size = %d, want %d", got, 36+160)
+	}
+
+	pcmPath := filepath.Join(dir, "out.pcm")
+	w, err = CreatePCMFile(pcmPath, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(make([]byte, 100))
+	w.Close()
+	if data, _ := os.ReadFile(pcmPath); len(data) != 100 {
+		t.Errorf("expected raw PCM of 100 bytes, got %d", len(data))
+	}
+}
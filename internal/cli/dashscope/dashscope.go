@@ -5,7 +5,7 @@ import "github.com/spf13/cobra"
 var Cmd = &cobra.Command{
 	Use:   "dashscope",
 	Short: "DashScope (Tongyi Wanxiang) commands",
-	Long:  "Access Alibaba Tongyi capabilities via DashScope API (Video, Image, TTS, STT).",
+	Long:  "Access Alibaba Tongyi capabilities via DashScope API (Video, Image, TTS, STT, Vocabulary).",
 }
 
 func init() {
@@ -13,4 +13,5 @@ func init() {
 	Cmd.AddCommand(imageCmd)
 	Cmd.AddCommand(ttsCmd)
 	Cmd.AddCommand(sttCmd)
+	Cmd.AddCommand(vocabularyCmd)
 }
@@ -334,22 +334,17 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 			}
 		}
 
-		if len(imageURLs) == 1 {
-			if err := downloadFile(imageURLs[0], absPath); err != nil {
-				return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download image: %s", err.Error()))
+		var files []string
+		for i, url := range imageURLs {
+			outputPath := common.IndexedPath(absPath, "image", i, len(imageURLs), "")
+			if err := downloadFile(url, outputPath); err != nil {
+				return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download image %d: %s", i, err.Error()))
 			}
-			output.File = absPath
+			files = append(files, outputPath)
+		}
+		if len(files) == 1 {
+			output.File = files[0]
 		} else {
-			baseName := strings.TrimSuffix(absPath, filepath.Ext(absPath))
-			extName := filepath.Ext(absPath)
-			var files []string
-			for i, url := range imageURLs {
-				outputPath := fmt.Sprintf("%s_%d%s", baseName, i, extName)
-				if err := downloadFile(url, outputPath); err != nil {
-					return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download image %d: %s", i, err.Error()))
-				}
-				files = append(files, outputPath)
-			}
 			output.Files = files
 		}
 	}
@@ -405,7 +400,7 @@ func downloadFile(url, outputPath string) error {
 		return fmt.Errorf("download failed with status %d", resp.StatusCode)
 	}
 
-	outFile, err := os.Create(outputPath)
+	outFile, err := common.CreateOutput(outputPath)
 	if err != nil {
 		return err
 	}
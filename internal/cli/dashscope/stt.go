@@ -2,6 +2,7 @@ package dashscope
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -25,6 +27,10 @@ const (
 	sttMaxSyncFileSize   = 10 * 1024 * 1024 // 10 MB
 )
 
+// Larger WAV and MP3 files are transcribed by sync models in chunks of at
+// most sttSyncChunkDuration seconds, within the 5 minute limit of a request.
+var sttSyncChunkDuration = 180.0
+
 // Valid STT models
 var (
 	validSyncSTTModels = map[string]bool{
@@ -145,6 +151,8 @@ type sttFlags struct {
 	disfluencyRemoval bool
 	languageHints     string
 	sampleRate        int
+	subtitles         common.SubtitleOptions
+	stream            common.StreamFlags
 }
 
 type sttCreateFlags struct {
@@ -160,8 +168,9 @@ type sttCreateFlags struct {
 }
 
 type sttStatusFlags struct {
-	verbose bool
-	output  string
+	verbose   bool
+	output    string
+	subtitles common.SubtitleOptions
 }
 
 // Commands
@@ -187,11 +196,13 @@ func newSTTCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&flags.language, "language", "l", "", "Language code (zh, en, ja, etc.)")
 	cmd.Flags().BoolVar(&flags.noITN, "no-itn", false, "Disable inverse text normalization")
 	cmd.Flags().BoolVarP(&flags.verbose, "verbose", "v", false, "Include timestamps and segments")
-	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file (.json, .txt, .srt, .vtt, .ass, .ttml)")
 	cmd.Flags().StringVar(&flags.vocabularyID, "vocabulary-id", "", "Hot words vocabulary ID (realtime only)")
 	cmd.Flags().BoolVar(&flags.disfluencyRemoval, "disfluency-removal", false, "Remove filler words (paraformer/fun-asr realtime only)")
 	cmd.Flags().StringVar(&flags.languageHints, "language-hints", "", "Comma-separated language hints (paraformer-realtime-v2 only)")
 	cmd.Flags().IntVar(&flags.sampleRate, "sample-rate", 0, "Sample rate in Hz (realtime only, auto-detected from file)")
+	common.AddSubtitleFlags(cmd, &flags.subtitles)
+	common.AddStreamFlags(cmd, &flags.stream)
 
 	cmd.AddCommand(newSTTCreateCmd())
 	cmd.AddCommand(newSTTStatusCmd())
@@ -239,7 +250,8 @@ func newSTTStatusCmd() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&flags.verbose, "verbose", "v", false, "Show full output including per-file details")
-	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Save transcription result(s) to file")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Save transcription result(s) to file (.json, .txt, .srt, .vtt, .ass, .ttml)")
+	common.AddSubtitleFlags(cmd, &flags.subtitles)
 
 	return cmd
 }
@@ -247,16 +259,31 @@ func newSTTStatusCmd() *cobra.Command {
 // ===== STT Default Command =====
 
 func runSTTDefault(cmd *cobra.Command, args []string, flags *sttFlags) error {
-	// Get audio input
-	audioFile, cleanup, err := getSTTAudioInput(args, flags.file, cmd.InOrStdin())
-	if err != nil {
-		if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "does not exist") {
-			return common.WriteError(cmd, "file_not_found", err.Error())
+	// Get audio input; streaming reads stdin as it arrives
+	var audioFile string
+	var err error
+	if flags.stream.Enabled {
+		if len(args) > 0 || flags.file != "" {
+			return common.WriteError(cmd, "incompatible_stream", "--stream reads audio from stdin, do not pass an audio file")
+		}
+		if err := flags.stream.Validate(); err != nil {
+			return common.WriteError(cmd, "invalid_parameter", err.Error())
+		}
+		if !cmd.Flags().Changed("model") {
+			flags.model = sttStreamModel
+		}
+	} else {
+		var cleanup func()
+		audioFile, cleanup, err = getSTTAudioInput(args, flags.file, cmd.InOrStdin())
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "does not exist") {
+				return common.WriteError(cmd, "file_not_found", err.Error())
+			}
+			return common.WriteError(cmd, "missing_input", err.Error())
+		}
+		if cleanup != nil {
+			defer cleanup()
 		}
-		return common.WriteError(cmd, "missing_input", err.Error())
-	}
-	if cleanup != nil {
-		defer cleanup()
 	}
 
 	// Validate model
@@ -268,14 +295,21 @@ func runSTTDefault(cmd *cobra.Command, args []string, flags *sttFlags) error {
 		return common.WriteError(cmd, "invalid_model", fmt.Sprintf("invalid model '%s' for stt command", flags.model))
 	}
 
+	if flags.stream.Enabled && isSync {
+		return common.WriteError(cmd, "incompatible_stream", fmt.Sprintf("--stream requires a realtime model such as %s, fun-asr-realtime or qwen3-asr-flash-realtime", sttStreamModel))
+	}
+
 	// Validate file size (sync models only)
+	var chunks []common.AudioChunk
 	if isSync {
 		info, statErr := os.Stat(audioFile)
 		if statErr != nil {
 			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot access file: %s", statErr.Error()))
 		}
 		if info.Size() > sttMaxSyncFileSize {
-			return common.WriteError(cmd, "file_too_large", fmt.Sprintf("file size %d bytes exceeds 10 MB limit for sync model, use a realtime model for larger files", info.Size()))
+			if chunks, err = splitSTTAudio(audioFile); err != nil {
+				return common.WriteError(cmd, "file_too_large", fmt.Sprintf("file size %d bytes exceeds 10 MB limit for sync model and cannot be split (%s), use a realtime model for larger files", info.Size(), err.Error()))
+			}
 		}
 	}
 
@@ -313,20 +347,34 @@ func runSTTDefault(cmd *cobra.Command, args []string, flags *sttFlags) error {
 		}
 	}
 
+	// Subtitle outputs need sentence timestamps, which only run-task models return
+	outputFormat := common.TranscriptFormat(flags.output, common.TranscriptJSON)
+	if common.IsTimedFormat(outputFormat) && !isRunTask {
+		return common.WriteError(cmd, "missing_timestamps", fmt.Sprintf("--output .%s requires a model with timestamps, such as paraformer-realtime-v2 or fun-asr-realtime", outputFormat))
+	}
+	if err := flags.subtitles.Validate(); err != nil {
+		return common.WriteError(cmd, "invalid_parameter", err.Error())
+	}
+
 	// Check API key
 	apiKey := config.GetAPIKey("DASHSCOPE_API_KEY")
 	if apiKey == "" {
 		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("DASHSCOPE_API_KEY"))
 	}
 
+	if flags.stream.Enabled {
+		return runSTTStream(cmd, apiKey, flags, outputFormat, isRunTask)
+	}
+
 	// Call appropriate API
 	var result map[string]any
+	var transcript *common.Transcript
 	if isSync {
-		result, err = runSTTSync(cmd, audioFile, apiKey, flags)
+		result, transcript, err = runSTTSync(cmd, audioFile, chunks, apiKey, flags)
 	} else if isRunTask {
-		result, err = runSTTRunTask(cmd, audioFile, apiKey, flags)
+		result, transcript, err = runSTTRunTask(cmd, audioFile, apiKey, flags)
 	} else {
-		result, err = runSTTSessionUpdate(cmd, audioFile, apiKey, flags)
+		result, transcript, err = runSTTSessionUpdate(cmd, audioFile, apiKey, flags)
 	}
 
 	if err != nil {
@@ -335,13 +383,9 @@ func runSTTDefault(cmd *cobra.Command, args []string, flags *sttFlags) error {
 
 	// Write to output file if specified
 	if flags.output != "" {
-		absPath, pathErr := filepath.Abs(flags.output)
-		if pathErr != nil {
-			absPath = flags.output
-		}
-		outputData, _ := json.MarshalIndent(result, "", "  ")
-		if writeErr := os.WriteFile(absPath, outputData, 0644); writeErr != nil {
-			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write to output file: %s", writeErr.Error()))
+		absPath, writeErr := common.WriteTranscript(flags.output, transcript, outputFormat, flags.subtitles)
+		if writeErr != nil {
+			return common.WriteError(cmd, common.TranscriptErrorCode(writeErr), fmt.Sprintf("cannot write to output file: %s", writeErr.Error()))
 		}
 		result["file"] = absPath
 	}
@@ -539,6 +583,10 @@ func runSTTStatus(cmd *cobra.Command, args []string, flags *sttStatusFlags) erro
 	}
 	taskID := args[0]
 
+	if err := flags.subtitles.Validate(); err != nil {
+		return common.WriteError(cmd, "invalid_parameter", err.Error())
+	}
+
 	apiKey := config.GetAPIKey("DASHSCOPE_API_KEY")
 	if apiKey == "" {
 		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("DASHSCOPE_API_KEY"))
@@ -613,6 +661,7 @@ func runSTTStatus(cmd *cobra.Command, args []string, flags *sttStatusFlags) erro
 		"task_id": taskResult.Output.TaskID,
 		"status":  status,
 	}
+	var transcripts []*common.Transcript
 
 	if status == "succeeded" && taskResult.Output.Results != nil {
 		if taskResult.Usage != nil {
@@ -634,6 +683,9 @@ func runSTTStatus(cmd *cobra.Command, args []string, flags *sttStatusFlags) erro
 				if dlErr == nil && transcript != nil {
 					text := extractTranscriptText(transcript)
 					fileResult["text"] = text
+					if t, parseErr := newAsyncTranscript(transcript); parseErr == nil {
+						transcripts = append(transcripts, t)
+					}
 					if combinedText.Len() > 0 {
 						combinedText.WriteString("\n")
 					}
@@ -674,13 +726,28 @@ func runSTTStatus(cmd *cobra.Command, args []string, flags *sttStatusFlags) erro
 
 	// Write to output file if specified
 	if flags.output != "" && status == "succeeded" {
-		absPath, pathErr := filepath.Abs(flags.output)
-		if pathErr != nil {
-			absPath = flags.output
-		}
-		outputData, _ := json.MarshalIndent(output, "", "  ")
-		if writeErr := os.WriteFile(absPath, outputData, 0644); writeErr != nil {
-			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write to output file: %s", writeErr.Error()))
+		var absPath string
+		outputFormat := common.TranscriptFormat(flags.output, common.TranscriptJSON)
+		if outputFormat == common.TranscriptJSON {
+			var pathErr error
+			absPath, pathErr = filepath.Abs(flags.output)
+			if pathErr != nil {
+				absPath = flags.output
+			}
+			outputData, _ := json.MarshalIndent(output, "", "  ")
+			if _, writeErr := common.WriteOutput(absPath, outputData, common.OutputVars{}); writeErr != nil {
+				return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write to output file: %s", writeErr.Error()))
+			}
+		} else {
+			// Text and subtitles describe a single recording
+			if len(transcripts) != 1 {
+				return common.WriteError(cmd, "incompatible_output", fmt.Sprintf("--output .%s requires a task with exactly one transcribed file, use .json for several", outputFormat))
+			}
+			var writeErr error
+			absPath, writeErr = common.WriteTranscript(flags.output, transcripts[0], outputFormat, flags.subtitles)
+			if writeErr != nil {
+				return common.WriteError(cmd, common.TranscriptErrorCode(writeErr), fmt.Sprintf("cannot write to output file: %s", writeErr.Error()))
+			}
 		}
 		// Replace full results with file reference
 		output = map[string]any{
@@ -699,18 +766,72 @@ func runSTTStatus(cmd *cobra.Command, args []string, flags *sttStatusFlags) erro
 
 // ===== Sync HTTP Implementation =====
 
-func runSTTSync(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags) (map[string]any, error) {
-	// Read and base64-encode audio
-	audioData, err := os.ReadFile(audioFile)
-	if err != nil {
-		return nil, common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read audio file: %s", err.Error()))
+// runSTTSync transcribes audio with a sync model. Files over the request
+// size limit come as chunks, which are transcribed separately and merged.
+func runSTTSync(cmd *cobra.Command, audioFile string, chunks []common.AudioChunk, apiKey string, flags *sttFlags) (map[string]any, *common.Transcript, error) {
+	ctx := context.Background()
+	var transcript *common.Transcript
+	var emotion string
+	if chunks == nil {
+		audioData, err := os.ReadFile(audioFile)
+		if err != nil {
+			return nil, nil, common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read audio file: %s", err.Error()))
+		}
+
+		ext := strings.ToLower(filepath.Ext(audioFile))
+		mimeType := mime.TypeByExtension(ext)
+		if mimeType == "" {
+			mimeType = "audio/wav"
+		}
+		if transcript, emotion, err = transcribeSync(ctx, audioData, mimeType, apiKey, flags); err != nil {
+			return nil, nil, handleChunkError(cmd, err)
+		}
+	} else {
+		parts, err := common.TranscribeChunks(ctx, chunks, common.DefaultChunkConcurrency, func(ctx context.Context, _ int, chunk common.AudioChunk) (*common.Transcript, error) {
+			t, _, err := transcribeSync(ctx, chunk.Data, "audio/wav", apiKey, flags)
+			return t, err
+		})
+		if err != nil {
+			return nil, nil, handleChunkError(cmd, err)
+		}
+		transcript = common.MergeTranscripts(chunks, parts)
+	}
+
+	result := map[string]any{
+		"success": true,
+		"text":    transcript.Text,
+		"model":   flags.model,
+	}
+	if transcript.Language != "" {
+		result["language"] = transcript.Language
 	}
+	if emotion != "" {
+		result["emotion"] = emotion
+	}
+	if len(chunks) > 1 {
+		result["chunks"] = len(chunks)
+	}
+	return result, transcript, nil
+}
 
+// splitSTTAudio splits a WAV or MP3 file that is too large for a sync
+// request into chunks.
+func splitSTTAudio(audioFile string) ([]common.AudioChunk, error) {
 	ext := strings.ToLower(filepath.Ext(audioFile))
-	mimeType := mime.TypeByExtension(ext)
-	if mimeType == "" {
-		mimeType = "audio/wav"
+	if !common.CanSplitAudio(ext) {
+		return nil, fmt.Errorf("only WAV and MP3 files are split")
+	}
+	f, err := os.Open(audioFile)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
+	return common.SplitAudio(f, ext, sttSyncChunkDuration, common.DefaultChunkOverlap)
+}
+
+// transcribeSync sends one request to the sync API and returns the
+// transcript and the detected emotion.
+func transcribeSync(ctx context.Context, audioData []byte, mimeType, apiKey string, flags *sttFlags) (*common.Transcript, string, error) {
 	audioBase64 := fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(audioData))
 
 	// Build request (OpenAI-compatible protocol)
@@ -750,16 +871,16 @@ func runSTTSync(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags) (
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, common.WriteError(cmd, "request_error", fmt.Sprintf("cannot marshal request: %s", err.Error()))
+		return nil, "", &chunkError{code: "request_error", message: fmt.Sprintf("cannot marshal request: %s", err.Error())}
 	}
 
 	// Use compatible-mode URL
 	baseURL := getBaseURL()
 	compatURL := strings.Replace(baseURL, "/api/v1", "/compatible-mode/v1", 1)
 
-	req, err := http.NewRequest("POST", compatURL+"/chat/completions", bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", compatURL+"/chat/completions", bytes.NewReader(jsonBody))
 	if err != nil {
-		return nil, common.WriteError(cmd, "request_error", fmt.Sprintf("cannot create request: %s", err.Error()))
+		return nil, "", &chunkError{code: "request_error", message: fmt.Sprintf("cannot create request: %s", err.Error())}
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+apiKey)
@@ -767,17 +888,17 @@ func runSTTSync(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags) (
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, handleAPIError(cmd, err)
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, common.WriteError(cmd, "response_error", fmt.Sprintf("cannot read response: %s", err.Error()))
+		return nil, "", &chunkError{code: "response_error", message: fmt.Sprintf("cannot read response: %s", err.Error())}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, handleHTTPError(cmd, resp.StatusCode, string(respBody))
+		return nil, "", &chunkError{statusCode: resp.StatusCode, message: string(respBody)}
 	}
 
 	// Parse OpenAI-compatible response
@@ -795,51 +916,73 @@ func runSTTSync(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags) (
 	}
 
 	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return nil, common.WriteError(cmd, "response_error", fmt.Sprintf("cannot parse response: %s", err.Error()))
+		return nil, "", &chunkError{code: "response_error", message: fmt.Sprintf("cannot parse response: %s", err.Error())}
 	}
 
 	if len(chatResp.Choices) == 0 {
-		return nil, common.WriteError(cmd, "response_error", "no transcription result in response")
+		return nil, "", &chunkError{code: "response_error", message: "no transcription result in response"}
 	}
 
-	result := map[string]any{
-		"success": true,
-		"text":    chatResp.Choices[0].Message.Content,
-		"model":   flags.model,
-	}
+	transcript := &common.Transcript{Text: chatResp.Choices[0].Message.Content}
 
 	// Extract language and emotion from annotations
+	var emotion string
 	for _, ann := range chatResp.Choices[0].Message.Annotations {
 		if ann.Type == "audio_info" {
 			if ann.Language != "" {
-				result["language"] = ann.Language
+				transcript.Language = ann.Language
 			}
 			if ann.Emotion != "" {
-				result["emotion"] = ann.Emotion
+				emotion = ann.Emotion
 			}
 		}
 	}
 
-	return result, nil
+	transcript.Normalize()
+	return transcript, emotion, nil
 }
 
-// ===== WebSocket run-task Implementation =====
+// ===== Streaming from stdin =====
 
-func runSTTRunTask(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags) (map[string]any, error) {
-	baseURL := getBaseURL()
-	wsURL := strings.Replace(baseURL, "https://", "wss://", 1)
-	wsURL = strings.Replace(wsURL, "http://", "ws://", 1)
-	wsURL = strings.Replace(wsURL, "/api/v1", "/api-ws/v1/inference/", 1)
+// sttStreamModel is the model --stream uses unless --model is given.
+const sttStreamModel = "paraformer-realtime-v2"
 
-	header := http.Header{}
-	header.Set("Authorization", "Bearer "+apiKey)
+// runSTTStream transcribes PCM or WAV audio from stdin as it arrives,
+// writing partial and final results as NDJSON events.
+func runSTTStream(cmd *cobra.Command, apiKey string, flags *sttFlags, outputFormat string, isRunTask bool) error {
+	sampleRate := flags.sampleRate
+	if sampleRate == 0 {
+		sampleRate = 16000
+	}
+	audio, err := flags.stream.OpenPCMStream(cmd.InOrStdin(), sampleRate)
+	if err != nil {
+		return common.WriteError(cmd, "invalid_audio", fmt.Sprintf("cannot read audio from stdin: %s", err.Error()))
+	}
 
-	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	events := common.NewTranscriptStream(cmd.OutOrStdout())
+	var transcript *common.Transcript
+	if isRunTask {
+		_, transcript, err = recognizeRunTask(cmd, audio, "pcm", sampleRate, apiKey, flags, events)
+	} else {
+		_, transcript, err = recognizeSessionUpdate(cmd, audio, sampleRate, apiKey, flags, events)
+	}
 	if err != nil {
-		return nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot connect to WebSocket: %s", err.Error()))
+		return err
 	}
-	defer conn.Close()
 
+	var file string
+	if flags.output != "" {
+		file, err = common.WriteTranscript(flags.output, transcript, outputFormat, flags.subtitles)
+		if err != nil {
+			return common.WriteError(cmd, common.TranscriptErrorCode(err), fmt.Sprintf("cannot write to output file: %s", err.Error()))
+		}
+	}
+	return events.Done(file)
+}
+
+// ===== WebSocket run-task Implementation =====
+
+func runSTTRunTask(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags) (map[string]any, *common.Transcript, error) {
 	// Determine audio format
 	ext := strings.ToLower(filepath.Ext(audioFile))
 	audioFormat, ok := sttAudioFormats[ext]
@@ -853,6 +996,34 @@ func runSTTRunTask(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags
 		sampleRate = 16000 // default
 	}
 
+	// Open audio file
+	audioFileHandle, err := os.Open(audioFile)
+	if err != nil {
+		return nil, nil, common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot open audio file: %s", err.Error()))
+	}
+	defer audioFileHandle.Close()
+
+	return recognizeRunTask(cmd, audioFileHandle, audioFormat, sampleRate, apiKey, flags, nil)
+}
+
+// recognizeRunTask streams audio to a run-task model. With events, partial
+// and final results are written as they arrive and audio is sent as fast
+// as it is read; otherwise audio is paced so the server is not overwhelmed.
+func recognizeRunTask(cmd *cobra.Command, audio io.Reader, audioFormat string, sampleRate int, apiKey string, flags *sttFlags, events *common.TranscriptStream) (map[string]any, *common.Transcript, error) {
+	baseURL := getBaseURL()
+	wsURL := strings.Replace(baseURL, "https://", "wss://", 1)
+	wsURL = strings.Replace(wsURL, "http://", "ws://", 1)
+	wsURL = strings.Replace(wsURL, "/api/v1", "/api-ws/v1/inference/", 1)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+apiKey)
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		return nil, nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot connect to WebSocket: %s", err.Error()))
+	}
+	defer conn.Close()
+
 	taskID := uuid.New().String()
 
 	// Build parameters
@@ -892,24 +1063,19 @@ func runSTTRunTask(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags
 	}
 
 	if err := conn.WriteJSON(runTask); err != nil {
-		return nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot send run-task: %s", err.Error()))
+		return nil, nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot send run-task: %s", err.Error()))
 	}
 
 	// Wait for task-started
 	if err := waitForRunTaskEvent(conn, "task-started"); err != nil {
-		return nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("task start failed: %s", err.Error()))
+		return nil, nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("task start failed: %s", err.Error()))
 	}
 
-	// Open audio file and stream
-	audioFileHandle, err := os.Open(audioFile)
-	if err != nil {
-		return nil, common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot open audio file: %s", err.Error()))
-	}
-	defer audioFileHandle.Close()
-
 	// Start reading results in background
 	type wsResult struct {
 		sentences []map[string]any
+		segments  []common.TranscriptSegment
+		words     []common.TranscriptWord
 		err       error
 		duration  float64
 	}
@@ -917,12 +1083,14 @@ func runSTTRunTask(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags
 
 	go func() {
 		var sentences []map[string]any
+		var segments []common.TranscriptSegment
+		var words []common.TranscriptWord
 		var totalDuration float64
 
 		for {
 			_, message, readErr := conn.ReadMessage()
 			if readErr != nil {
-				resultCh <- wsResult{sentences: sentences, err: nil, duration: totalDuration}
+				resultCh <- wsResult{sentences: sentences, segments: segments, words: words, duration: totalDuration}
 				return
 			}
 
@@ -959,6 +1127,9 @@ func runSTTRunTask(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags
 
 			switch event.Header.Event {
 			case "result-generated":
+				if !event.Payload.Output.Sentence.SentenceEnd && events != nil {
+					_ = events.Partial(event.Payload.Output.Sentence.Text)
+				}
 				if event.Payload.Output.Sentence.SentenceEnd {
 					sentence := map[string]any{
 						"text": event.Payload.Output.Sentence.Text,
@@ -982,12 +1153,34 @@ func runSTTRunTask(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags
 						sentence["words"] = words
 					}
 					sentences = append(sentences, sentence)
+
+					segment := common.TranscriptSegment{Text: event.Payload.Output.Sentence.Text}
+					if event.Payload.Output.Sentence.BeginTime != nil {
+						segment.Start = *event.Payload.Output.Sentence.BeginTime / 1000.0
+					}
+					if event.Payload.Output.Sentence.EndTime != nil {
+						segment.End = *event.Payload.Output.Sentence.EndTime / 1000.0
+					}
+					segments = append(segments, segment)
+					var sentenceWords []common.TranscriptWord
+					for _, w := range event.Payload.Output.Sentence.Words {
+						sentenceWords = append(sentenceWords, common.TranscriptWord{
+							Text:  w.Text + w.Punctuation,
+							Start: w.BeginTime / 1000.0,
+							End:   w.EndTime / 1000.0,
+						})
+					}
+					words = append(words, sentenceWords...)
+					if events != nil {
+						_ = events.Final(segment, "", sentenceWords)
+					}
+
 					if event.Payload.Usage.Duration > 0 {
 						totalDuration = event.Payload.Usage.Duration
 					}
 				}
 			case "task-finished":
-				resultCh <- wsResult{sentences: sentences, err: nil, duration: totalDuration}
+				resultCh <- wsResult{sentences: sentences, segments: segments, words: words, duration: totalDuration}
 				return
 			case "task-failed":
 				resultCh <- wsResult{err: fmt.Errorf("%s: %s", event.Header.ErrorCode, event.Header.ErrorMessage)}
@@ -1000,12 +1193,14 @@ func runSTTRunTask(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags
 	chunkSize := sampleRate * 2 / 10 // 16-bit mono, 100ms
 	buf := make([]byte, chunkSize)
 	for {
-		n, readErr := audioFileHandle.Read(buf)
+		n, readErr := audio.Read(buf)
 		if n > 0 {
 			if writeErr := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); writeErr != nil {
 				break
 			}
-			time.Sleep(10 * time.Millisecond) // Small delay to avoid overwhelming
+			if events == nil {
+				time.Sleep(10 * time.Millisecond) // Small delay to avoid overwhelming
+			}
 		}
 		if readErr != nil {
 			break
@@ -1028,7 +1223,7 @@ func runSTTRunTask(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags
 	// Wait for results
 	wsRes := <-resultCh
 	if wsRes.err != nil {
-		return nil, common.WriteError(cmd, "server_error", fmt.Sprintf("recognition failed: %s", wsRes.err.Error()))
+		return nil, nil, common.WriteError(cmd, "server_error", fmt.Sprintf("recognition failed: %s", wsRes.err.Error()))
 	}
 
 	// Build result
@@ -1053,12 +1248,39 @@ func runSTTRunTask(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags
 		result["segments"] = wsRes.sentences
 	}
 
-	return result, nil
+	transcript := &common.Transcript{
+		Text:     result["text"].(string),
+		Duration: wsRes.duration,
+		Segments: wsRes.segments,
+		Words:    wsRes.words,
+	}
+	transcript.Normalize()
+	return result, transcript, nil
 }
 
 // ===== WebSocket session.update Implementation =====
 
-func runSTTSessionUpdate(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags) (map[string]any, error) {
+func runSTTSessionUpdate(cmd *cobra.Command, audioFile, apiKey string, flags *sttFlags) (map[string]any, *common.Transcript, error) {
+	// Determine sample rate
+	sampleRate := flags.sampleRate
+	if sampleRate == 0 {
+		sampleRate = 16000
+	}
+
+	// Open audio file
+	audioFileHandle, err := os.Open(audioFile)
+	if err != nil {
+		return nil, nil, common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot open audio file: %s", err.Error()))
+	}
+	defer audioFileHandle.Close()
+
+	return recognizeSessionUpdate(cmd, audioFileHandle, sampleRate, apiKey, flags, nil)
+}
+
+// recognizeSessionUpdate streams PCM audio to a session.update model. With
+// events, partial and final results are written as they arrive and audio
+// is sent as fast as it is read.
+func recognizeSessionUpdate(cmd *cobra.Command, audio io.Reader, sampleRate int, apiKey string, flags *sttFlags, events *common.TranscriptStream) (map[string]any, *common.Transcript, error) {
 	baseURL := getBaseURL()
 	wsURL := strings.Replace(baseURL, "https://", "wss://", 1)
 	wsURL = strings.Replace(wsURL, "http://", "ws://", 1)
@@ -1071,19 +1293,13 @@ func runSTTSessionUpdate(cmd *cobra.Command, audioFile, apiKey string, flags *st
 
 	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
 	if err != nil {
-		return nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot connect to WebSocket: %s", err.Error()))
+		return nil, nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot connect to WebSocket: %s", err.Error()))
 	}
 	defer conn.Close()
 
 	// Wait for session.created
 	if err := waitForEvent(conn, "session.created"); err != nil {
-		return nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("session creation failed: %s", err.Error()))
-	}
-
-	// Determine sample rate and format
-	sampleRate := flags.sampleRate
-	if sampleRate == 0 {
-		sampleRate = 16000
+		return nil, nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("session creation failed: %s", err.Error()))
 	}
 
 	// Send session.update
@@ -1102,20 +1318,13 @@ func runSTTSessionUpdate(cmd *cobra.Command, audioFile, apiKey string, flags *st
 	}
 
 	if err := conn.WriteJSON(sessionUpdate); err != nil {
-		return nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot send session update: %s", err.Error()))
+		return nil, nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot send session update: %s", err.Error()))
 	}
 
 	// Wait for session.updated
 	if err := waitForEvent(conn, "session.updated"); err != nil {
-		return nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("session update failed: %s", err.Error()))
-	}
-
-	// Open audio file
-	audioFileHandle, err := os.Open(audioFile)
-	if err != nil {
-		return nil, common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot open audio file: %s", err.Error()))
+		return nil, nil, common.WriteError(cmd, "websocket_error", fmt.Sprintf("session update failed: %s", err.Error()))
 	}
-	defer audioFileHandle.Close()
 
 	// Read results in background
 	type wsResult struct {
@@ -1137,12 +1346,13 @@ func runSTTSessionUpdate(cmd *cobra.Command, audioFile, apiKey string, flags *st
 			}
 
 			var event struct {
-				Type       string `json:"type"`
-				Message    string `json:"message"`
-				Transcript struct {
-					Text    string `json:"text"`
-					Emotion string `json:"emotion"`
-				} `json:"transcript"`
+				Type       string          `json:"type"`
+				Message    string          `json:"message"`
+				Text       string          `json:"text"`
+				Stash      string          `json:"stash"`
+				Language   string          `json:"language"`
+				Emotion    string          `json:"emotion"`
+				Transcript json.RawMessage `json:"transcript"`
 			}
 
 			if jsonErr := json.Unmarshal(message, &event); jsonErr != nil {
@@ -1150,12 +1360,30 @@ func runSTTSessionUpdate(cmd *cobra.Command, audioFile, apiKey string, flags *st
 			}
 
 			switch event.Type {
+			case "conversation.item.input_audio_transcription.text":
+				if events != nil {
+					_ = events.Partial(event.Text + event.Stash)
+				}
 			case "conversation.item.input_audio_transcription.completed":
-				if event.Transcript.Text != "" {
-					fullText.WriteString(event.Transcript.Text)
+				// The transcript is a string, or an object in older responses
+				var transcript struct {
+					Text    string `json:"text"`
+					Emotion string `json:"emotion"`
+				}
+				if json.Unmarshal(event.Transcript, &transcript.Text) != nil {
+					_ = json.Unmarshal(event.Transcript, &transcript)
 				}
-				if event.Transcript.Emotion != "" {
-					emotion = event.Transcript.Emotion
+				if transcript.Emotion == "" {
+					transcript.Emotion = event.Emotion
+				}
+				if transcript.Text != "" {
+					fullText.WriteString(transcript.Text)
+					if events != nil {
+						_ = events.Final(common.TranscriptSegment{Text: transcript.Text}, event.Language, nil)
+					}
+				}
+				if transcript.Emotion != "" {
+					emotion = transcript.Emotion
 				}
 			case "session.finished":
 				resultCh <- wsResult{text: fullText.String(), emotion: emotion}
@@ -1171,7 +1399,7 @@ func runSTTSessionUpdate(cmd *cobra.Command, audioFile, apiKey string, flags *st
 	chunkSize := sampleRate * 2 / 10 // 100ms of 16-bit mono PCM
 	buf := make([]byte, chunkSize)
 	for {
-		n, readErr := audioFileHandle.Read(buf)
+		n, readErr := audio.Read(buf)
 		if n > 0 {
 			appendMsg := map[string]any{
 				"type":  "input_audio_buffer.append",
@@ -1180,7 +1408,9 @@ func runSTTSessionUpdate(cmd *cobra.Command, audioFile, apiKey string, flags *st
 			if writeErr := conn.WriteJSON(appendMsg); writeErr != nil {
 				break
 			}
-			time.Sleep(10 * time.Millisecond)
+			if events == nil {
+				time.Sleep(10 * time.Millisecond)
+			}
 		}
 		if readErr != nil {
 			break
@@ -1194,7 +1424,7 @@ func runSTTSessionUpdate(cmd *cobra.Command, audioFile, apiKey string, flags *st
 	// Wait for results
 	wsRes := <-resultCh
 	if wsRes.err != nil {
-		return nil, common.WriteError(cmd, "server_error", fmt.Sprintf("recognition failed: %s", wsRes.err.Error()))
+		return nil, nil, common.WriteError(cmd, "server_error", fmt.Sprintf("recognition failed: %s", wsRes.err.Error()))
 	}
 
 	result := map[string]any{
@@ -1207,7 +1437,9 @@ func runSTTSessionUpdate(cmd *cobra.Command, audioFile, apiKey string, flags *st
 		result["emotion"] = wsRes.emotion
 	}
 
-	return result, nil
+	transcript := &common.Transcript{Text: wsRes.text}
+	transcript.Normalize()
+	return result, transcript, nil
 }
 
 // ===== Helper Functions =====
@@ -1239,21 +1471,16 @@ func getSTTAudioInput(args []string, fileFlag string, stdin io.Reader) (string,
 			}
 		}
 
-		tmpFile, err := os.CreateTemp("", "stt-stdin-*.wav")
+		// Named after its format, so that large input is split like a file
+		tmpFile, err := common.SpoolAudio(stdin, "stt-stdin-", ".wav")
 		if err != nil {
-			return "", nil, fmt.Errorf("cannot create temp file: %s", err.Error())
-		}
-		if _, err := io.Copy(tmpFile, stdin); err != nil {
-			tmpFile.Close()
-			os.Remove(tmpFile.Name())
-			return "", nil, fmt.Errorf("cannot read stdin: %s", err.Error())
+			return "", nil, err
 		}
-		tmpFile.Close()
 
 		cleanup := func() {
-			os.Remove(tmpFile.Name())
+			os.Remove(tmpFile)
 		}
-		return tmpFile.Name(), cleanup, nil
+		return tmpFile, cleanup, nil
 	}
 
 	return "", nil, fmt.Errorf("no audio file provided, use positional argument, --file flag, or pipe from stdin")
@@ -1325,3 +1552,70 @@ func extractTranscriptText(transcript map[string]any) string {
 	}
 	return strings.Join(texts, "\n")
 }
+
+// asyncTranscription is the result file of an async transcription task.
+// Times are in milliseconds.
+type asyncTranscription struct {
+	Properties struct {
+		OriginalDurationInMilliseconds float64 `json:"original_duration_in_milliseconds"`
+	} `json:"properties"`
+	Transcripts []struct {
+		Sentences []struct {
+			BeginTime float64 `json:"begin_time"`
+			EndTime   float64 `json:"end_time"`
+			Text      string  `json:"text"`
+			SpeakerID *int    `json:"speaker_id"`
+			Words     []struct {
+				BeginTime   float64 `json:"begin_time"`
+				EndTime     float64 `json:"end_time"`
+				Text        string  `json:"text"`
+				Punctuation string  `json:"punctuation"`
+			} `json:"words"`
+		} `json:"sentences"`
+	} `json:"transcripts"`
+}
+
+// newAsyncTranscript converts a downloaded transcription result. Sentences
+// of all channels are merged in time order; diarized speakers are named
+// speaker_<id>.
+func newAsyncTranscript(result map[string]any) (*common.Transcript, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var parsed asyncTranscription
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+
+	t := &common.Transcript{
+		Text:     extractTranscriptText(result),
+		Duration: parsed.Properties.OriginalDurationInMilliseconds / 1000.0,
+	}
+	for _, channel := range parsed.Transcripts {
+		for _, sentence := range channel.Sentences {
+			speaker := ""
+			if sentence.SpeakerID != nil {
+				speaker = fmt.Sprintf("speaker_%d", *sentence.SpeakerID)
+			}
+			t.Segments = append(t.Segments, common.TranscriptSegment{
+				Start:   sentence.BeginTime / 1000.0,
+				End:     sentence.EndTime / 1000.0,
+				Text:    sentence.Text,
+				Speaker: speaker,
+			})
+			for _, w := range sentence.Words {
+				t.Words = append(t.Words, common.TranscriptWord{
+					Text:    w.Text + w.Punctuation,
+					Start:   w.BeginTime / 1000.0,
+					End:     w.EndTime / 1000.0,
+					Speaker: speaker,
+				})
+			}
+		}
+	}
+	sort.SliceStable(t.Segments, func(i, j int) bool { return t.Segments[i].Start < t.Segments[j].Start })
+	sort.SliceStable(t.Words, func(i, j int) bool { return t.Words[i].Start < t.Words[j].Start })
+	t.Normalize()
+	return t, nil
+}
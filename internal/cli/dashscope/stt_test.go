@@ -1,11 +1,18 @@
 package dashscope
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/gorilla/websocket"
 )
 
 // ===== STT Default Command: Required Field Validation =====
@@ -101,6 +108,135 @@ func TestSTT_FileTooLargeRealtimeSkipped(t *testing.T) {
 	expectErrorCode(t, stderr, "missing_api_key")
 }
 
+func TestSTT_SplitsLargeSyncFile(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"好。","annotations":[{"type":"audio_info","language":"zh"}]}}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("DASHSCOPE_API_KEY", "sk-test")
+	t.Setenv("DASHSCOPE_BASE_URL", server.URL)
+
+	// 11 MB of 16 kHz mono silence is about 6 minutes
+	audio := filepath.Join(t.TempDir(), "long.wav")
+	format := common.AudioFormat{SampleRate: 16000, Channels: 1, Encoding: common.PCMS16LE}
+	os.WriteFile(audio, common.PCMToWAV(make([]byte, 11*1024*1024), format), 0644)
+
+	cmd := newSTTCmd()
+	stdout, stderr, err := executeVideoCommand(cmd, audio)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var resp map[string]any
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp["chunks"] != float64(3) || requests.Load() != 3 {
+		t.Errorf("expected 3 chunks, got %d requests: %s", requests.Load(), stdout)
+	}
+	if resp["text"] != "好。好。好。" || resp["language"] != "zh" {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+}
+
+func TestSTT_StreamRejectsFile(t *testing.T) {
+	cmd := newSTTCmd()
+	_, stderr, err := executeVideoCommand(cmd, "--stream", "audio.wav")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	expectErrorCode(t, stderr, "incompatible_stream")
+}
+
+func TestSTT_StreamRequiresRealtimeModel(t *testing.T) {
+	cmd := newSTTCmd()
+	_, stderr, err := executeVideoCommand(cmd, "--stream", "-m", "qwen3-asr-flash")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	expectErrorCode(t, stderr, "incompatible_stream")
+}
+
+func TestSTT_StreamInvalidInputRate(t *testing.T) {
+	cmd := newSTTCmd()
+	_, stderr, err := executeVideoCommand(cmd, "--stream", "--input-rate", "100")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	expectErrorCode(t, stderr, "invalid_parameter")
+}
+
+func TestSTT_StreamRunTask(t *testing.T) {
+	var model string
+	var audioBytes int
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			kind, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if kind == websocket.BinaryMessage {
+				if audioBytes == 0 {
+					conn.WriteJSON(map[string]any{"header": map[string]any{"event": "result-generated"},
+						"payload": map[string]any{"output": map[string]any{"sentence": map[string]any{"text": "你好", "sentence_end": false}}}})
+				}
+				audioBytes += len(message)
+				continue
+			}
+			var req struct {
+				Header  struct{ Action string } `json:"header"`
+				Payload struct{ Model string }  `json:"payload"`
+			}
+			json.Unmarshal(message, &req)
+			switch req.Header.Action {
+			case "run-task":
+				model = req.Payload.Model
+				conn.WriteJSON(map[string]any{"header": map[string]any{"event": "task-started"}})
+			case "finish-task":
+				conn.WriteJSON(map[string]any{"header": map[string]any{"event": "result-generated"},
+					"payload": map[string]any{"output": map[string]any{"sentence": map[string]any{
+						"text": "你好。", "sentence_end": true, "begin_time": 0, "end_time": 900}}}})
+				conn.WriteJSON(map[string]any{"header": map[string]any{"event": "task-finished"}})
+				return
+			}
+		}
+	}))
+	defer server.Close()
+	t.Setenv("DASHSCOPE_API_KEY", "sk-test")
+	t.Setenv("DASHSCOPE_BASE_URL", server.URL+"/api/v1")
+
+	// One second of 8 kHz stereo PCM, sent as 16 kHz mono
+	cmd := newSTTCmd()
+	cmd.SetIn(bytes.NewReader(make([]byte, 8000*4)))
+	stdout, stderr, err := executeVideoCommand(cmd, "--stream", "--input-rate", "8000", "--input-channels", "2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if model != sttStreamModel {
+		t.Errorf("expected the default stream model, got %q", model)
+	}
+	if audioBytes != 16000*2 {
+		t.Errorf("expected one second of 16 kHz audio, got %d bytes", audioBytes)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	want := []string{
+		`{"type":"partial","text":"你好"}`,
+		`{"type":"final","text":"你好。","start":0,"end":0.9}`,
+		`{"type":"done","text":"你好。"}`,
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected events:\n%s", stdout)
+	}
+}
+
 // ===== STT Default Command: Invalid Parameters =====
 
 func TestSTT_InvalidModel(t *testing.T) {
@@ -813,3 +949,90 @@ func TestSTTStatus_AllFlags(t *testing.T) {
 		}
 	}
 }
+
+// ===== Transcript Output =====
+
+func TestSTT_SubtitlesRequireTimestamps(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "audio.wav")
+	os.WriteFile(tmpFile, []byte("fake audio data"), 0644)
+
+	cmd := newSTTCmd()
+	_, stderr, err := executeVideoCommand(cmd, tmpFile, "-o", "out.srt")
+
+	if err == nil {
+		t.Fatal("expected error for subtitles from a model without timestamps")
+	}
+	expectErrorCode(t, stderr, "missing_timestamps")
+}
+
+func TestSTT_SubtitlesWithRunTaskModel(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	t.Setenv("DASHSCOPE_API_KEY", "")
+
+	tmpFile := filepath.Join(t.TempDir(), "audio.wav")
+	os.WriteFile(tmpFile, []byte("fake audio data"), 0644)
+
+	cmd := newSTTCmd()
+	_, stderr, err := executeVideoCommand(cmd, tmpFile, "-m", "paraformer-realtime-v2", "-o", "out.srt")
+
+	if err == nil {
+		t.Fatal("expected error for missing API key")
+	}
+	expectErrorCode(t, stderr, "missing_api_key")
+}
+
+func TestSTTStatus_WritesSubtitles(t *testing.T) {
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/result.json") {
+			w.Write([]byte(`{"properties":{"original_duration_in_milliseconds":3000},"transcripts":[{"text":"你好。再见。","sentences":[
+				{"begin_time":0,"end_time":1200,"text":"你好。","speaker_id":0},
+				{"begin_time":1500,"end_time":3000,"text":"再见。","speaker_id":1}]}]}`))
+			return
+		}
+		w.Write([]byte(`{"output":{"task_id":"task-123","task_status":"SUCCEEDED","results":[
+			{"file_url":"https://example.com/a.wav","transcription_url":"` + serverURL + `/result.json","subtask_status":"SUCCEEDED"}]}}`))
+	}))
+	defer server.Close()
+	serverURL = server.URL
+	t.Setenv("DASHSCOPE_API_KEY", "sk-test")
+	t.Setenv("DASHSCOPE_BASE_URL", server.URL)
+
+	output := filepath.Join(t.TempDir(), "out.vtt")
+	cmd := newSTTCmd()
+	_, stderr, err := executeVideoCommand(cmd, "status", "task-123", "-o", output, "--speaker-labels")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	data, _ := os.ReadFile(output)
+	want := "WEBVTT\n\n00:00:00.000 --> 00:00:01.200\n<v speaker_0>你好。\n\n00:00:01.500 --> 00:00:03.000\n<v speaker_1>再见。\n\n"
+	if string(data) != want {
+		t.Errorf("unexpected VTT:\n%q", data)
+	}
+}
+
+func TestNewAsyncTranscript(t *testing.T) {
+	var result map[string]any
+	body := `{"properties":{"original_duration_in_milliseconds":2500},"transcripts":[
+		{"channel_id":0,"text":"Hi.","sentences":[{"begin_time":1000,"end_time":2000,"text":"Hi.","words":[{"begin_time":1000,"end_time":2000,"text":"Hi","punctuation":"."}]}]},
+		{"channel_id":1,"text":"Yo.","sentences":[{"begin_time":0,"end_time":500,"text":"Yo."}]}]}`
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	transcript, err := newAsyncTranscript(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transcript.Duration != 2.5 || transcript.Text != "Hi.\nYo." {
+		t.Errorf("unexpected transcript: %+v", transcript)
+	}
+	if len(transcript.Segments) != 2 || transcript.Segments[0].Text != "Yo." {
+		t.Errorf("expected channels merged in time order, got %+v", transcript.Segments)
+	}
+	if len(transcript.Words) != 1 || transcript.Words[0].Text != "Hi." {
+		t.Errorf("expected punctuation joined to words, got %+v", transcript.Words)
+	}
+}
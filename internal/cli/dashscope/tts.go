@@ -2,8 +2,10 @@ package dashscope
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -63,12 +65,13 @@ var (
 		".wav": true,
 	}
 
-	// Realtime models support multiple formats
+	// Realtime models support multiple formats; WAV is streamed as PCM and
+	// wrapped locally so the header carries the real sizes
 	realtimeTTSFormats = map[string]string{
 		".mp3":  "mp3",
 		".pcm":  "pcm",
 		".opus": "opus",
-		".wav":  "wav",
+		".wav":  "pcm",
 	}
 )
 
@@ -84,10 +87,12 @@ type ttsFlags struct {
 }
 
 type ttsSuccessResponse struct {
-	Success bool   `json:"success"`
-	File    string `json:"file,omitempty"`
-	Model   string `json:"model"`
-	Voice   string `json:"voice"`
+	Success            bool   `json:"success"`
+	File               string `json:"file,omitempty"`
+	Model              string `json:"model"`
+	Voice              string `json:"voice"`
+	Chunks             int    `json:"chunks,omitempty"`
+	TimeToFirstAudioMs int64  `json:"time_to_first_audio_ms,omitempty"`
 }
 
 var ttsCmd = newTTSCmd()
@@ -126,6 +131,10 @@ func isInstructModel(model string) bool {
 	return strings.Contains(model, "-instruct-")
 }
 
+// maxHTTPCharacters is the longest text, as counted by countCharacters, the
+// HTTP models accept per request; longer text is synthesized in chunks.
+const maxHTTPCharacters = 600
+
 // countCharacters counts characters using DashScope rules:
 // 1 Chinese character = 2 characters, 1 ASCII char = 1 character
 func countCharacters(text string) int {
@@ -164,7 +173,11 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	var ext string
 
 	if flags.output != "" {
-		outputPath = flags.output
+		if realtime {
+			outputPath = common.DefaultExt(flags.output, ".mp3")
+		} else {
+			outputPath = common.DefaultExt(flags.output, ".wav")
+		}
 		ext = strings.ToLower(filepath.Ext(outputPath))
 	} else {
 		// --speak only: use temp file
@@ -213,11 +226,6 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		}
 	}
 
-	// Validate text length for HTTP models
-	if !realtime && countCharacters(text) > 600 {
-		return common.WriteError(cmd, "text_too_long", fmt.Sprintf("text exceeds 600 characters (got %d), use a realtime model for longer text", countCharacters(text)))
-	}
-
 	// Check API key
 	apiKey := config.GetAPIKey("DASHSCOPE_API_KEY")
 	if apiKey == "" {
@@ -233,11 +241,19 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		absPath = outputPath
 	}
 
+	// Realtime audio is played while it streams in
+	var player *common.StreamPlayer
+	if realtime && flags.speak {
+		player = common.NewStreamPlayer("."+realtimeTTSFormats[ext], common.DecodeOptions{SampleRate: flags.sampleRate})
+		defer player.Abort(nil)
+	}
+
 	// Call appropriate API
+	chunks := 1
 	if realtime {
-		err = runTTSRealtime(cmd, text, absPath, ext, apiKey, flags)
+		err = runTTSRealtime(cmd, text, absPath, ext, apiKey, flags, player)
 	} else {
-		err = runTTSHTTP(cmd, text, absPath, apiKey, flags)
+		chunks, err = runTTSHTTP(cmd, text, absPath, apiKey, flags)
 	}
 
 	if err != nil {
@@ -247,9 +263,19 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		return err
 	}
 
-	// Play audio if --speak is set
-	if flags.speak {
-		if playErr := common.PlayFile(absPath); playErr != nil {
+	// Play audio if --speak is set; realtime audio only needs to finish
+	var timeToFirstAudio int64
+	if player != nil {
+		playErr := player.Close()
+		if useTempFile {
+			os.Remove(absPath)
+		}
+		if playErr != nil {
+			return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", playErr.Error()))
+		}
+		timeToFirstAudio = player.TimeToFirstAudio().Milliseconds()
+	} else if flags.speak {
+		if playErr := common.PlayFileWithOptions(absPath, common.DecodeOptions{SampleRate: flags.sampleRate}); playErr != nil {
 			if useTempFile {
 				os.Remove(absPath)
 			}
@@ -262,10 +288,14 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 
 	// Return success
 	result := ttsSuccessResponse{
-		Success: true,
-		File:    absPath,
-		Model:   flags.model,
-		Voice:   flags.voice,
+		Success:            true,
+		File:               absPath,
+		Model:              flags.model,
+		Voice:              flags.voice,
+		TimeToFirstAudioMs: timeToFirstAudio,
+	}
+	if chunks > 1 {
+		result.Chunks = chunks
 	}
 	if useTempFile {
 		result.File = ""
@@ -273,8 +303,55 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	return common.WriteSuccess(cmd, result)
 }
 
-// runTTSHTTP calls the synchronous HTTP API for non-realtime models
-func runTTSHTTP(cmd *cobra.Command, text, outputPath, apiKey string, flags *ttsFlags) error {
+// runTTSHTTP calls the synchronous HTTP API for non-realtime models. Text
+// over the length limit is synthesized in chunks and joined; the number of
+// chunks is returned.
+func runTTSHTTP(cmd *cobra.Command, text, outputPath, apiKey string, flags *ttsFlags) (int, error) {
+	chunks := common.SplitText(text, maxHTTPCharacters, countCharacters)
+	parts, err := common.SynthesizeChunks(context.Background(), chunks, common.DefaultChunkConcurrency, func(ctx context.Context, _ int, chunk string) ([]byte, error) {
+		return synthesizeHTTP(ctx, chunk, apiKey, flags)
+	})
+	if err != nil {
+		return 0, handleChunkError(cmd, err)
+	}
+
+	audio, err := common.JoinAudio(".wav", parts)
+	if err != nil {
+		return 0, common.WriteError(cmd, "join_error", fmt.Sprintf("cannot join audio chunks: %s", err.Error()))
+	}
+	if _, err := common.WriteOutput(outputPath, audio, common.OutputVars{}); err != nil {
+		return 0, common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+	}
+	return len(chunks), nil
+}
+
+// chunkError is a failed chunk request. A non-zero statusCode is an HTTP error
+// whose body is message; anything else uses code.
+type chunkError struct {
+	statusCode int
+	code       string
+	message    string
+}
+
+func (e *chunkError) Error() string {
+	return e.message
+}
+
+// handleChunkError writes the error of a chunk request.
+func handleChunkError(cmd *cobra.Command, err error) error {
+	var cErr *chunkError
+	switch {
+	case !errors.As(err, &cErr):
+		return handleAPIError(cmd, err)
+	case cErr.statusCode != 0:
+		return handleHTTPError(cmd, cErr.statusCode, cErr.message)
+	default:
+		return common.WriteError(cmd, cErr.code, cErr.message)
+	}
+}
+
+// synthesizeHTTP returns the WAV audio for one chunk of text.
+func synthesizeHTTP(ctx context.Context, text, apiKey string, flags *ttsFlags) ([]byte, error) {
 	baseURL := getBaseURL()
 
 	// Build request body
@@ -289,12 +366,12 @@ func runTTSHTTP(cmd *cobra.Command, text, outputPath, apiKey string, flags *ttsF
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return common.WriteError(cmd, "request_error", fmt.Sprintf("cannot marshal request: %s", err.Error()))
+		return nil, &chunkError{code: "request_error", message: fmt.Sprintf("cannot marshal request: %s", err.Error())}
 	}
 
-	req, err := http.NewRequest("POST", baseURL+ttsGenerationPath, bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+ttsGenerationPath, bytes.NewReader(jsonBody))
 	if err != nil {
-		return common.WriteError(cmd, "request_error", fmt.Sprintf("cannot create request: %s", err.Error()))
+		return nil, &chunkError{code: "request_error", message: fmt.Sprintf("cannot create request: %s", err.Error())}
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -303,17 +380,17 @@ func runTTSHTTP(cmd *cobra.Command, text, outputPath, apiKey string, flags *ttsF
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return handleAPIError(cmd, err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot read response: %s", err.Error()))
+		return nil, &chunkError{code: "response_error", message: fmt.Sprintf("cannot read response: %s", err.Error())}
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return handleHTTPError(cmd, resp.StatusCode, string(respBody))
+		return nil, &chunkError{statusCode: resp.StatusCode, message: string(respBody)}
 	}
 
 	// Parse response
@@ -329,39 +406,40 @@ func runTTSHTTP(cmd *cobra.Command, text, outputPath, apiKey string, flags *ttsF
 	}
 
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot parse response: %s", err.Error()))
+		return nil, &chunkError{code: "response_error", message: fmt.Sprintf("cannot parse response: %s", err.Error())}
 	}
 
 	if result.Code != "" {
 		if strings.Contains(result.Code, "DataInspection") || strings.Contains(result.Code, "Infringement") {
-			return common.WriteError(cmd, "content_policy", result.Message)
+			return nil, &chunkError{code: "content_policy", message: result.Message}
 		}
-		return common.WriteError(cmd, "invalid_request", result.Message)
+		return nil, &chunkError{code: "invalid_request", message: result.Message}
 	}
 
 	if result.Output == nil || result.Output.Audio == nil {
-		return common.WriteError(cmd, "response_error", "no audio in response")
+		return nil, &chunkError{code: "response_error", message: "no audio in response"}
 	}
 
 	// Download audio from URL
 	if result.Output.Audio.URL != "" {
-		return downloadAudioURL(cmd, result.Output.Audio.URL, outputPath)
+		return downloadAudioURL(ctx, result.Output.Audio.URL)
 	}
 
 	// Or decode base64 audio data
 	if result.Output.Audio.Data != "" {
 		audioData, decErr := base64.StdEncoding.DecodeString(result.Output.Audio.Data)
 		if decErr != nil {
-			return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot decode audio data: %s", decErr.Error()))
+			return nil, &chunkError{code: "response_error", message: fmt.Sprintf("cannot decode audio data: %s", decErr.Error())}
 		}
-		return os.WriteFile(outputPath, audioData, 0644)
+		return audioData, nil
 	}
 
-	return common.WriteError(cmd, "response_error", "no audio URL or data in response")
+	return nil, &chunkError{code: "response_error", message: "no audio URL or data in response"}
 }
 
 // runTTSRealtime connects via WebSocket for realtime TTS models
-func runTTSRealtime(cmd *cobra.Command, text, outputPath, ext, apiKey string, flags *ttsFlags) error {
+// runTTSRealtime also feeds the audio to player when it is not nil.
+func runTTSRealtime(cmd *cobra.Command, text, outputPath, ext, apiKey string, flags *ttsFlags, player *common.StreamPlayer) error {
 	baseURL := getBaseURL()
 	// Convert HTTP URL to WebSocket URL
 	wsURL := strings.Replace(baseURL, "https://", "wss://", 1)
@@ -428,12 +506,22 @@ func runTTSRealtime(cmd *cobra.Command, text, outputPath, ext, apiKey string, fl
 	}
 
 	// Collect audio chunks until session ends
-	outFile, err := os.Create(outputPath)
+	var outFile io.WriteCloser
+	if realtimeTTSFormats[ext] == "pcm" {
+		outFile, err = common.CreatePCMFile(outputPath, common.AudioFormat{SampleRate: flags.sampleRate, Channels: 1, Encoding: common.PCMS16LE})
+	} else {
+		outFile, err = common.CreateOutput(outputPath)
+	}
 	if err != nil {
 		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
 	}
 	defer outFile.Close()
 
+	var audioOut io.Writer = outFile
+	if player != nil {
+		audioOut = io.MultiWriter(outFile, player)
+	}
+
 	for {
 		_, message, readErr := conn.ReadMessage()
 		if readErr != nil {
@@ -456,43 +544,50 @@ func runTTSRealtime(cmd *cobra.Command, text, outputPath, ext, apiKey string, fl
 			if decErr != nil {
 				return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot decode audio chunk: %s", decErr.Error()))
 			}
-			if _, writeErr := outFile.Write(audioData); writeErr != nil {
+			if _, writeErr := audioOut.Write(audioData); writeErr != nil {
+				if player != nil && player.Err() != nil {
+					return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", writeErr.Error()))
+				}
 				return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write audio: %s", writeErr.Error()))
 			}
 		case "session.finished":
-			_ = outFile.Sync()
-			return nil
+			return closeOutput(cmd, outFile)
 		case "error":
 			return common.WriteError(cmd, "server_error", fmt.Sprintf("server error: %s", event.Message))
 		}
 	}
 
-	_ = outFile.Sync()
+	return closeOutput(cmd, outFile)
+}
+
+// closeOutput closes the realtime output file, which completes a WAV header.
+func closeOutput(cmd *cobra.Command, outFile io.Closer) error {
+	if err := outFile.Close(); err != nil {
+		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+	}
 	return nil
 }
 
-func downloadAudioURL(cmd *cobra.Command, audioURL, outputPath string) error {
-	resp, err := http.Get(audioURL)
+func downloadAudioURL(ctx context.Context, audioURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", audioURL, nil)
 	if err != nil {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download audio: %s", err.Error()))
+		return nil, &chunkError{code: "download_error", message: fmt.Sprintf("cannot download audio: %s", err.Error())}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, &chunkError{code: "download_error", message: fmt.Sprintf("cannot download audio: %s", err.Error())}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("download failed with status %d", resp.StatusCode))
+		return nil, &chunkError{code: "download_error", message: fmt.Sprintf("download failed with status %d", resp.StatusCode)}
 	}
 
-	outFile, err := os.Create(outputPath)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
+		return nil, &chunkError{code: "download_error", message: fmt.Sprintf("cannot download audio: %s", err.Error())}
 	}
-	defer outFile.Close()
-
-	if _, err := io.Copy(outFile, resp.Body); err != nil {
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
-	}
-
-	return nil
+	return data, nil
 }
 
 func waitForEvent(conn *websocket.Conn, expectedType string) error {
@@ -1,8 +1,15 @@
 package dashscope
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
@@ -192,16 +199,53 @@ func TestTTS_IncompatibleSampleRate(t *testing.T) {
 	expectErrorCode(t, stderr, "incompatible_sample_rate")
 }
 
-func TestTTS_TextTooLong(t *testing.T) {
-	// 601 characters
-	longText := strings.Repeat("你", 301) // 301 Chinese chars = 602 characters
+func TestTTS_LongTextChunked(t *testing.T) {
+	format := common.AudioFormat{SampleRate: 24000, Channels: 1, Encoding: common.PCMS16LE}
+	var mu sync.Mutex
+	var lengths []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input struct {
+				Text string `json:"text"`
+			} `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		lengths = append(lengths, countCharacters(body.Input.Text))
+		mu.Unlock()
+		wav := common.PCMToWAV([]byte{1, 0}, format)
+		json.NewEncoder(w).Encode(map[string]any{
+			"output": map[string]any{"audio": map[string]any{"data": base64.StdEncoding.EncodeToString(wav)}},
+		})
+	}))
+	defer server.Close()
+	t.Setenv("DASHSCOPE_API_KEY", "test-key")
+	t.Setenv("DASHSCOPE_BASE_URL", server.URL)
+
+	// 301 Chinese characters count as 602
+	longText := strings.Repeat("你", 301)
+	output := filepath.Join(t.TempDir(), "out.wav")
 	cmd := newTTSCmd()
-	_, stderr, err := executeVideoCommand(cmd, longText, "-o", "out.wav")
+	stdout, stderr, err := executeVideoCommand(cmd, longText, "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
 
-	if err == nil {
-		t.Fatal("expected error for text too long")
+	if len(lengths) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(lengths))
+	}
+	for _, n := range lengths {
+		if n > maxHTTPCharacters {
+			t.Errorf("chunk of %d characters exceeds the limit", n)
+		}
+	}
+	if !strings.Contains(stdout, `"chunks":2`) {
+		t.Errorf("expected chunks in response, got: %s", stdout)
+	}
+	data, _ := os.ReadFile(output)
+	if expected := common.PCMToWAV([]byte{1, 0, 1, 0}, format); !bytes.Equal(data, expected) {
+		t.Errorf("expected the joined WAV, got %v", data)
 	}
-	expectErrorCode(t, stderr, "text_too_long")
 }
 
 func TestTTS_TextTooLongRealtime(t *testing.T) {
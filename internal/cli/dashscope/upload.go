@@ -0,0 +1,168 @@
+package dashscope
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+)
+
+const (
+	uploadPolicyPath = "/uploads"
+
+	// Files uploaded to DashScope temporary storage are kept for 48 hours
+	ossUploadLifetime = 48 * time.Hour
+
+	// ossResolveHeader must be set on requests that reference oss:// URLs
+	ossResolveHeader = "X-DashScope-OssResourceResolve"
+)
+
+// ossUploader uploads local files to DashScope's temporary OSS storage.
+// The returned oss:// URL is only valid for the model it was requested for.
+type ossUploader struct {
+	apiKey string
+	model  string
+}
+
+func newOSSUploader(apiKey, model string) *ossUploader {
+	return &ossUploader{apiKey: apiKey, model: model}
+}
+
+type uploadPolicy struct {
+	Policy              string `json:"policy"`
+	Signature           string `json:"signature"`
+	UploadDir           string `json:"upload_dir"`
+	UploadHost          string `json:"upload_host"`
+	OSSAccessKeyID      string `json:"oss_access_key_id"`
+	XOSSObjectACL       string `json:"x_oss_object_acl"`
+	XOSSForbidOverwrite string `json:"x_oss_forbid_overwrite"`
+	MaxFileSizeMB       int    `json:"max_file_size_mb"`
+}
+
+func (u *ossUploader) Upload(ctx context.Context, path string, expiry time.Duration) (*common.UploadResult, error) {
+	policy, err := u.getPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if policy.MaxFileSizeMB > 0 && info.Size() > int64(policy.MaxFileSizeMB)*1024*1024 {
+		return nil, fmt.Errorf("file exceeds %d MB upload limit", policy.MaxFileSizeMB)
+	}
+
+	key := policy.UploadDir + "/" + common.SafeObjectName(path)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	fields := [][2]string{
+		{"OSSAccessKeyId", policy.OSSAccessKeyID},
+		{"Signature", policy.Signature},
+		{"policy", policy.Policy},
+		{"x-oss-object-acl", policy.XOSSObjectACL},
+		{"x-oss-forbid-overwrite", policy.XOSSForbidOverwrite},
+		{"key", key},
+		{"success_action_status", "200"},
+	}
+	for _, f := range fields {
+		if err := writer.WriteField(f[0], f[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// OSS requires the file to be the last form field
+	part, err := writer.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", policy.UploadHost, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("OSS upload failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	// Lifetime is fixed by DashScope, --upload-expiry does not apply
+	expiresAt := time.Now().Add(ossUploadLifetime).UTC()
+	return &common.UploadResult{
+		URL:       "oss://" + key,
+		Backend:   common.UploadBackendNative,
+		ExpiresAt: &expiresAt,
+	}, nil
+}
+
+func (u *ossUploader) getPolicy(ctx context.Context) (*uploadPolicy, error) {
+	query := url.Values{}
+	query.Set("action", "getPolicy")
+	query.Set("model", u.model)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", getBaseURL()+uploadPolicyPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+u.apiKey)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data    *uploadPolicy `json:"data"`
+		Code    string        `json:"code"`
+		Message string        `json:"message"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("cannot parse upload policy: %s", err.Error())
+	}
+	if result.Code != "" {
+		return nil, fmt.Errorf("%s: %s", result.Code, result.Message)
+	}
+	if resp.StatusCode != http.StatusOK || result.Data == nil {
+		return nil, fmt.Errorf("cannot get upload policy (HTTP %d)", resp.StatusCode)
+	}
+	return result.Data, nil
+}
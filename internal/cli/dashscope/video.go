@@ -3,12 +3,14 @@ package dashscope
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/WHQ25/rawgenai/internal/config"
@@ -22,6 +24,9 @@ const (
 	videoSynthesisPath = "/services/aigc/video-generation/video-synthesis"
 	kf2vSynthesisPath  = "/services/aigc/image2video/video-synthesis"
 	taskQueryPath      = "/tasks/"
+
+	// Task results are deleted this long after the task ends
+	resultRetention = 24 * time.Hour
 )
 
 // Valid values
@@ -57,7 +62,7 @@ var (
 		"wan2.6-r2v":       true,
 	}
 	validKF2VModels = map[string]bool{
-		"wan2.2-kf2v-flash":  true,
+		"wan2.2-kf2v-flash": true,
 		"wanx2.1-kf2v-plus": true,
 	}
 
@@ -110,6 +115,7 @@ type videoCreateFlags struct {
 	noAudio      bool
 	audioURL     string
 	shotType     string
+	upload       common.UploadFlags
 	promptExtend bool
 	watermark    bool
 	seed         int
@@ -159,11 +165,12 @@ func newVideoCreateCmd() *cobra.Command {
 	cmd.Flags().StringVar(&flags.negative, "negative", "", "Negative prompt (max 500 chars)")
 	cmd.Flags().BoolVar(&flags.audio, "audio", false, "Enable auto audio generation (wan2.6-i2v-flash only)")
 	cmd.Flags().BoolVar(&flags.noAudio, "no-audio", false, "Disable audio for r2v-flash (r2v has audio on by default)")
-	cmd.Flags().StringVar(&flags.audioURL, "audio-url", "", "Custom audio URL (wav/mp3, 3-30s)")
+	cmd.Flags().StringVar(&flags.audioURL, "audio-url", "", "Custom audio, URL or local file uploaded automatically (wav/mp3, 3-30s)")
 	cmd.Flags().StringVar(&flags.shotType, "shot-type", "", "Shot type: single, multi (wan2.6 only)")
 	cmd.Flags().BoolVar(&flags.promptExtend, "prompt-extend", true, "Enable prompt smart rewriting")
 	cmd.Flags().BoolVar(&flags.watermark, "watermark", false, "Add AI generated watermark")
 	cmd.Flags().IntVar(&flags.seed, "seed", 0, "Random seed [0, 2147483647]")
+	common.AddUploadFlags(cmd, &flags.upload)
 
 	return cmd
 }
@@ -329,6 +336,11 @@ func runVideoCreate(cmd *cobra.Command, args []string, flags *videoCreateFlags)
 		if !audioURLModels[model] {
 			return common.WriteError(cmd, "incompatible_audio_url", fmt.Sprintf("--audio-url not supported by model '%s'", model))
 		}
+		if !common.IsRemoteInput(flags.audioURL) {
+			if _, err := os.Stat(flags.audioURL); os.IsNotExist(err) {
+				return common.WriteError(cmd, "audio_not_found", fmt.Sprintf("audio file not found: %s", flags.audioURL))
+			}
+		}
 	}
 
 	// Check API key
@@ -337,6 +349,13 @@ func runVideoCreate(cmd *cobra.Command, args []string, flags *videoCreateFlags)
 		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("DASHSCOPE_API_KEY"))
 	}
 
+	// Upload local audio so the API can fetch it
+	uploads := common.NewUploads(&flags.upload, newOSSUploader(apiKey, model))
+	audioURL, err := uploads.Resolve(cmd.Context(), flags.audioURL)
+	if err != nil {
+		return common.WriteError(cmd, common.UploadErrorCode(err, "audio_not_found"), err.Error())
+	}
+
 	// Build request body
 	input := map[string]any{
 		"prompt": prompt,
@@ -350,8 +369,8 @@ func runVideoCreate(cmd *cobra.Command, args []string, flags *videoCreateFlags)
 	switch mode {
 	case modeI2V:
 		input["img_url"] = flags.image
-		if flags.audioURL != "" {
-			input["audio_url"] = flags.audioURL
+		if audioURL != "" {
+			input["audio_url"] = audioURL
 		}
 	case modeR2V:
 		input["reference_urls"] = flags.refs
@@ -361,8 +380,8 @@ func runVideoCreate(cmd *cobra.Command, args []string, flags *videoCreateFlags)
 			input["last_frame_url"] = flags.lastFrame
 		}
 	default: // t2v
-		if flags.audioURL != "" {
-			input["audio_url"] = flags.audioURL
+		if audioURL != "" {
+			input["audio_url"] = audioURL
 		}
 	}
 
@@ -429,6 +448,9 @@ func runVideoCreate(cmd *cobra.Command, args []string, flags *videoCreateFlags)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 	req.Header.Set("X-DashScope-Async", "enable")
+	if strings.HasPrefix(audioURL, "oss://") {
+		req.Header.Set(ossResolveHeader, "enable")
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -471,11 +493,16 @@ func runVideoCreate(cmd *cobra.Command, args []string, flags *videoCreateFlags)
 		status = strings.ToLower(result.Output.TaskStatus)
 	}
 
-	return common.WriteSuccess(cmd, map[string]any{
+	response := map[string]any{
 		"success": true,
 		"task_id": taskID,
 		"status":  status,
-	})
+	}
+	if len(uploads.Results) > 0 {
+		response["uploads"] = uploads.Results
+	}
+
+	return common.WriteSuccess(cmd, response)
 }
 
 // ===== Status Command =====
@@ -581,6 +608,9 @@ func runVideoStatus(cmd *cobra.Command, args []string, verbose bool) error {
 			output["duration"] = result.Usage.OutputVideoDuration
 			output["resolution"] = result.Usage.SR
 		}
+		if expiresAt := common.ExpiresAt(result.Output.VideoURL); expiresAt != "" {
+			output["expires_at"] = expiresAt
+		}
 		if verbose {
 			output["video_url"] = result.Output.VideoURL
 			if result.Output.OrigPrompt != "" {
@@ -599,6 +629,7 @@ func runVideoStatus(cmd *cobra.Command, args []string, verbose bool) error {
 
 func newVideoDownloadCmd() *cobra.Command {
 	var output string
+	var download common.DownloadFlags
 
 	cmd := &cobra.Command{
 		Use:           "download <task_id>",
@@ -606,16 +637,17 @@ func newVideoDownloadCmd() *cobra.Command {
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runVideoDownload(cmd, args, output)
+			return runVideoDownload(cmd, args, output, download)
 		},
 	}
 
 	cmd.Flags().StringVarP(&output, "output", "o", "", "Output file path (.mp4)")
+	common.AddDownloadFlags(cmd, &download)
 
 	return cmd
 }
 
-func runVideoDownload(cmd *cobra.Command, args []string, output string) error {
+func runVideoDownload(cmd *cobra.Command, args []string, output string, download common.DownloadFlags) error {
 	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
 		return common.WriteError(cmd, "missing_task_id", "task ID is required")
 	}
@@ -624,7 +656,11 @@ func runVideoDownload(cmd *cobra.Command, args []string, output string) error {
 	if output == "" {
 		return common.WriteError(cmd, "missing_output", "output file path is required (-o)")
 	}
+	if err := download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
 
+	output = common.DefaultExt(output, ".mp4")
 	ext := strings.ToLower(filepath.Ext(output))
 	if ext != ".mp4" {
 		return common.WriteError(cmd, "invalid_format", fmt.Sprintf("unsupported format '%s', use .mp4", ext))
@@ -659,6 +695,7 @@ func runVideoDownload(cmd *cobra.Command, args []string, output string) error {
 		Output *struct {
 			TaskStatus string `json:"task_status"`
 			VideoURL   string `json:"video_url"`
+			EndTime    string `json:"end_time"`
 		} `json:"output"`
 		Code    string `json:"code"`
 		Message string `json:"message"`
@@ -690,38 +727,20 @@ func runVideoDownload(cmd *cobra.Command, args []string, output string) error {
 	}
 
 	// Download video
-	dlResp, err := http.Get(videoURL)
+	saved, err := common.DownloadURL(cmd.Context(), videoURL, output, common.DownloadOptions{DownloadFlags: download, Vars: common.OutputVars{TaskID: taskID}})
 	if err != nil {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download video: %s", err.Error()))
-	}
-	defer dlResp.Body.Close()
-
-	if dlResp.StatusCode != http.StatusOK {
-		if dlResp.StatusCode == http.StatusForbidden || dlResp.StatusCode == http.StatusNotFound {
-			return common.WriteError(cmd, "url_expired", "video URL has expired (24h limit)")
+		var statusErr *common.HTTPStatusError
+		if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusForbidden || statusErr.StatusCode == http.StatusNotFound) {
+			// Result URLs are kept for 24h and cannot be re-issued
+			err = &common.ResultExpiredError{ExpiresAt: resultExpiry(result.Output.EndTime), StatusCode: statusErr.StatusCode}
 		}
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("download failed with status %d", dlResp.StatusCode))
-	}
-
-	absOutput, err := filepath.Abs(output)
-	if err != nil {
-		absOutput = output
-	}
-
-	file, err := os.Create(absOutput)
-	if err != nil {
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
-	}
-	defer file.Close()
-
-	if _, err := io.Copy(file, dlResp.Body); err != nil {
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		return common.WriteError(cmd, common.DownloadErrorCode(err), err.Error())
 	}
 
 	return common.WriteSuccess(cmd, map[string]any{
 		"success": true,
 		"task_id": taskID,
-		"file":    absOutput,
+		"file":    saved.Path,
 	})
 }
 
@@ -762,6 +781,17 @@ func isURL(s string) bool {
 	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
 }
 
+// resultExpiry returns when the result of a task that ended at endTime is
+// deleted, zero when endTime cannot be parsed. Task times are in China
+// Standard Time.
+func resultExpiry(endTime string) time.Time {
+	end, err := time.ParseInLocation("2006-01-02 15:04:05.999", endTime, time.FixedZone("CST", 8*3600))
+	if err != nil {
+		return time.Time{}
+	}
+	return end.Add(resultRetention)
+}
+
 func getBaseURL() string {
 	if url := config.GetAPIKey("DASHSCOPE_BASE_URL"); url != "" {
 		return url
@@ -3,7 +3,10 @@ package dashscope
 import (
 	"bytes"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -293,6 +296,32 @@ func TestVideoCreate_IncompatibleAudioURL(t *testing.T) {
 	expectErrorCode(t, stderr, "incompatible_audio_url")
 }
 
+func TestVideoCreate_AudioFileNotFound(t *testing.T) {
+	cmd := newVideoCmd()
+	_, stderr, err := executeVideoCommand(cmd, "create", "A cat", "--audio-url", "/nonexistent/audio.wav")
+
+	if err == nil {
+		t.Fatal("expected error for missing audio file")
+	}
+	expectErrorCode(t, stderr, "audio_not_found")
+}
+
+func TestVideoCreate_LocalAudioPassesValidation(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	t.Setenv("DASHSCOPE_API_KEY", "")
+
+	audioPath := filepath.Join(t.TempDir(), "voice.wav")
+	os.WriteFile(audioPath, []byte("fake audio"), 0644)
+
+	cmd := newVideoCmd()
+	_, stderr, err := executeVideoCommand(cmd, "create", "A cat", "--audio-url", audioPath)
+
+	if err == nil {
+		t.Fatal("expected error (missing api key)")
+	}
+	expectErrorCode(t, stderr, "missing_api_key")
+}
+
 func TestVideoCreate_ValidResolutions(t *testing.T) {
 	common.SetupNoConfigEnv(t)
 	t.Setenv("DASHSCOPE_API_KEY", "")
@@ -604,6 +633,16 @@ func TestVideoDownload_MissingOutput(t *testing.T) {
 	expectErrorCode(t, stderr, "missing_output")
 }
 
+func TestVideoDownload_ConflictingFlags(t *testing.T) {
+	cmd := newVideoCmd()
+	_, stderr, err := executeVideoCommand(cmd, "download", "task-xxxx", "-o", "output.mp4", "--overwrite", "--no-clobber")
+
+	if err == nil {
+		t.Fatal("expected error for conflicting flags")
+	}
+	expectErrorCode(t, stderr, "conflicting_flags")
+}
+
 func TestVideoDownload_InvalidFormat(t *testing.T) {
 	cmd := newVideoCmd()
 	_, stderr, err := executeVideoCommand(cmd, "download", "task-xxxx", "-o", "output.avi")
@@ -627,6 +666,36 @@ func TestVideoDownload_MissingAPIKey(t *testing.T) {
 	expectErrorCode(t, stderr, "missing_api_key")
 }
 
+func TestVideoDownload_ResultExpired(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, taskQueryPath) {
+			w.Write([]byte(`{"output":{"task_status":"SUCCEEDED","video_url":"` + server.URL + `/video.mp4","end_time":"2026-01-02 08:00:00.000"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	t.Setenv("DASHSCOPE_API_KEY", "sk-test")
+	t.Setenv("DASHSCOPE_BASE_URL", server.URL)
+	output := filepath.Join(t.TempDir(), "output.mp4")
+
+	cmd := newVideoCmd()
+	_, stderr, err := executeVideoCommand(cmd, "download", "task-xxxx", "-o", output)
+
+	if err == nil {
+		t.Fatal("expected error for expired result")
+	}
+	expectErrorCode(t, stderr, "result_expired")
+	if !strings.Contains(stderr, "2026-01-03T00:00:00Z") {
+		t.Errorf("expected expiry time in error, got: %s", stderr)
+	}
+	if _, statErr := os.Stat(output + ".part"); !os.IsNotExist(statErr) {
+		t.Error("expected no .part file to be left")
+	}
+}
+
 func TestVideoDownload_AllFlags(t *testing.T) {
 	cmd := newVideoDownloadCmd()
 
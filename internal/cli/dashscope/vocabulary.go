@@ -0,0 +1,554 @@
+package dashscope
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	vocabularyPath  = "/services/audio/asr/customization"
+	vocabularyModel = "speech-biasing"
+
+	defaultVocabularyTargetModel = "paraformer-realtime-v2"
+	maxVocabularyTerms           = 500
+	minTermWeight                = 1
+	maxTermWeight                = 5
+)
+
+// Vocabulary prefixes are lowercase letters and digits, shorter than 10
+// characters.
+var vocabularyPrefixPattern = regexp.MustCompile(`^[a-z0-9]{1,9}$`)
+
+// vocabularyTerm is a hot word and how strongly recognition favors it.
+type vocabularyTerm struct {
+	Text   string `json:"text"`
+	Weight int    `json:"weight"`
+	Lang   string `json:"lang,omitempty"`
+}
+
+// Flag structs
+type vocabularyCreateFlags struct {
+	prefix      string
+	targetModel string
+	weight      int
+	lang        string
+}
+
+type vocabularyUpdateFlags struct {
+	weight int
+	lang   string
+}
+
+type vocabularyListFlags struct {
+	prefix   string
+	page     int
+	pageSize int
+}
+
+// Commands
+var vocabularyCmd = newVocabularyCmd()
+
+func newVocabularyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vocabulary",
+		Short: "Manage custom ASR vocabularies (hot words)",
+		Long:  "Create and manage hot word vocabularies for DashScope ASR. Pass the vocabulary ID to stt with --vocabulary-id.",
+	}
+
+	cmd.AddCommand(newVocabularyCreateCmd())
+	cmd.AddCommand(newVocabularyListCmd())
+	cmd.AddCommand(newVocabularyGetCmd())
+	cmd.AddCommand(newVocabularyUpdateCmd())
+	cmd.AddCommand(newVocabularyDeleteCmd())
+
+	return cmd
+}
+
+// ===== Create Command =====
+
+func newVocabularyCreateCmd() *cobra.Command {
+	flags := &vocabularyCreateFlags{}
+
+	cmd := &cobra.Command{
+		Use:           "create <terms-file>",
+		Short:         "Create a vocabulary from a JSON or CSV terms file",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVocabularyCreate(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.prefix, "prefix", "", "Vocabulary ID prefix: lowercase letters and digits, under 10 characters")
+	cmd.Flags().StringVarP(&flags.targetModel, "target-model", "m", defaultVocabularyTargetModel, "STT model the vocabulary is used with")
+	cmd.Flags().IntVar(&flags.weight, "weight", 4, "Weight of terms without one (1-5)")
+	cmd.Flags().StringVarP(&flags.lang, "lang", "l", "", "Language of terms without one (zh, en, ja, etc.)")
+
+	return cmd
+}
+
+func runVocabularyCreate(cmd *cobra.Command, args []string, flags *vocabularyCreateFlags) error {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		return common.WriteError(cmd, "missing_terms_file", "a JSON or CSV terms file is required")
+	}
+	if flags.prefix == "" {
+		return common.WriteError(cmd, "missing_prefix", "--prefix is required")
+	}
+	if !vocabularyPrefixPattern.MatchString(flags.prefix) {
+		return common.WriteError(cmd, "invalid_prefix", "--prefix must be 1-9 lowercase letters or digits")
+	}
+	if !sttVocabularyModels[flags.targetModel] && !sttAsyncVocabularyModels[flags.targetModel] {
+		return common.WriteError(cmd, "invalid_model", fmt.Sprintf("model '%s' does not support vocabularies", flags.targetModel))
+	}
+
+	terms, err := readVocabularyTerms(cmd, args[0], flags.weight, flags.lang)
+	if err != nil {
+		return err
+	}
+
+	apiKey := config.GetAPIKey("DASHSCOPE_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("DASHSCOPE_API_KEY"))
+	}
+
+	var output struct {
+		VocabularyID string `json:"vocabulary_id"`
+	}
+	input := map[string]any{
+		"action":       "create_vocabulary",
+		"target_model": flags.targetModel,
+		"prefix":       flags.prefix,
+		"vocabulary":   terms,
+	}
+	if err := callVocabularyAPI(cmd, apiKey, input, &output); err != nil {
+		return err
+	}
+
+	return common.WriteSuccess(cmd, map[string]any{
+		"success":       true,
+		"vocabulary_id": output.VocabularyID,
+		"target_model":  flags.targetModel,
+		"count":         len(terms),
+	})
+}
+
+// ===== List Command =====
+
+func newVocabularyListCmd() *cobra.Command {
+	flags := &vocabularyListFlags{}
+
+	cmd := &cobra.Command{
+		Use:           "list",
+		Short:         "List vocabularies",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVocabularyList(cmd, flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.prefix, "prefix", "", "Only list vocabularies with this prefix")
+	cmd.Flags().IntVar(&flags.page, "page", 0, "Page index, from 0")
+	cmd.Flags().IntVar(&flags.pageSize, "page-size", 10, "Vocabularies per page (1-100)")
+
+	return cmd
+}
+
+func runVocabularyList(cmd *cobra.Command, flags *vocabularyListFlags) error {
+	if flags.page < 0 {
+		return common.WriteError(cmd, "invalid_parameter", "--page must not be negative")
+	}
+	if flags.pageSize < 1 || flags.pageSize > 100 {
+		return common.WriteError(cmd, "invalid_parameter", "--page-size must be between 1 and 100")
+	}
+
+	apiKey := config.GetAPIKey("DASHSCOPE_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("DASHSCOPE_API_KEY"))
+	}
+
+	var output struct {
+		VocabularyList []struct {
+			VocabularyID string `json:"vocabulary_id"`
+			Status       string `json:"status"`
+			GmtCreate    string `json:"gmt_create"`
+			GmtModified  string `json:"gmt_modified"`
+		} `json:"vocabulary_list"`
+	}
+	input := map[string]any{
+		"action":     "list_vocabulary",
+		"page_index": flags.page,
+		"page_size":  flags.pageSize,
+	}
+	if flags.prefix != "" {
+		input["prefix"] = flags.prefix
+	}
+	if err := callVocabularyAPI(cmd, apiKey, input, &output); err != nil {
+		return err
+	}
+
+	vocabularies := []map[string]any{}
+	for _, v := range output.VocabularyList {
+		vocabularies = append(vocabularies, map[string]any{
+			"vocabulary_id": v.VocabularyID,
+			"status":        strings.ToLower(v.Status),
+			"created_at":    v.GmtCreate,
+			"updated_at":    v.GmtModified,
+		})
+	}
+
+	return common.WriteSuccess(cmd, map[string]any{
+		"success":      true,
+		"vocabularies": vocabularies,
+		"count":        len(vocabularies),
+	})
+}
+
+// ===== Get Command =====
+
+func newVocabularyGetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "get <vocabulary_id>",
+		Short:         "Show a vocabulary and its terms",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVocabularyGet(cmd, args)
+		},
+	}
+
+	return cmd
+}
+
+func runVocabularyGet(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		return common.WriteError(cmd, "missing_vocabulary_id", "vocabulary ID is required")
+	}
+	vocabularyID := args[0]
+
+	apiKey := config.GetAPIKey("DASHSCOPE_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("DASHSCOPE_API_KEY"))
+	}
+
+	var output struct {
+		Vocabulary  []vocabularyTerm `json:"vocabulary"`
+		TargetModel string           `json:"target_model"`
+		Status      string           `json:"status"`
+		GmtCreate   string           `json:"gmt_create"`
+		GmtModified string           `json:"gmt_modified"`
+	}
+	input := map[string]any{
+		"action":        "query_vocabulary",
+		"vocabulary_id": vocabularyID,
+	}
+	if err := callVocabularyAPI(cmd, apiKey, input, &output); err != nil {
+		return err
+	}
+
+	terms := output.Vocabulary
+	if terms == nil {
+		terms = []vocabularyTerm{}
+	}
+
+	return common.WriteSuccess(cmd, map[string]any{
+		"success":       true,
+		"vocabulary_id": vocabularyID,
+		"target_model":  output.TargetModel,
+		"status":        strings.ToLower(output.Status),
+		"created_at":    output.GmtCreate,
+		"updated_at":    output.GmtModified,
+		"terms":         terms,
+	})
+}
+
+// ===== Update Command =====
+
+func newVocabularyUpdateCmd() *cobra.Command {
+	flags := &vocabularyUpdateFlags{}
+
+	cmd := &cobra.Command{
+		Use:           "update <vocabulary_id> <terms-file>",
+		Short:         "Replace the terms of a vocabulary",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVocabularyUpdate(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().IntVar(&flags.weight, "weight", 4, "Weight of terms without one (1-5)")
+	cmd.Flags().StringVarP(&flags.lang, "lang", "l", "", "Language of terms without one (zh, en, ja, etc.)")
+
+	return cmd
+}
+
+func runVocabularyUpdate(cmd *cobra.Command, args []string, flags *vocabularyUpdateFlags) error {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		return common.WriteError(cmd, "missing_vocabulary_id", "vocabulary ID is required")
+	}
+	if len(args) < 2 || strings.TrimSpace(args[1]) == "" {
+		return common.WriteError(cmd, "missing_terms_file", "a JSON or CSV terms file is required")
+	}
+	vocabularyID := args[0]
+
+	terms, err := readVocabularyTerms(cmd, args[1], flags.weight, flags.lang)
+	if err != nil {
+		return err
+	}
+
+	apiKey := config.GetAPIKey("DASHSCOPE_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("DASHSCOPE_API_KEY"))
+	}
+
+	input := map[string]any{
+		"action":        "update_vocabulary",
+		"vocabulary_id": vocabularyID,
+		"vocabulary":    terms,
+	}
+	if err := callVocabularyAPI(cmd, apiKey, input, nil); err != nil {
+		return err
+	}
+
+	return common.WriteSuccess(cmd, map[string]any{
+		"success":       true,
+		"vocabulary_id": vocabularyID,
+		"count":         len(terms),
+	})
+}
+
+// ===== Delete Command =====
+
+func newVocabularyDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "delete <vocabulary_id>",
+		Short:         "Delete a vocabulary",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVocabularyDelete(cmd, args)
+		},
+	}
+
+	return cmd
+}
+
+func runVocabularyDelete(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		return common.WriteError(cmd, "missing_vocabulary_id", "vocabulary ID is required")
+	}
+	vocabularyID := args[0]
+
+	apiKey := config.GetAPIKey("DASHSCOPE_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("DASHSCOPE_API_KEY"))
+	}
+
+	input := map[string]any{
+		"action":        "delete_vocabulary",
+		"vocabulary_id": vocabularyID,
+	}
+	if err := callVocabularyAPI(cmd, apiKey, input, nil); err != nil {
+		return err
+	}
+
+	return common.WriteSuccess(cmd, map[string]any{
+		"success":       true,
+		"vocabulary_id": vocabularyID,
+		"status":        "deleted",
+	})
+}
+
+// ===== Helpers =====
+
+// callVocabularyAPI sends an action to the customization API and decodes
+// the output of the response into output, if it is not nil. Errors are
+// written to the command.
+func callVocabularyAPI(cmd *cobra.Command, apiKey string, input map[string]any, output any) error {
+	body := map[string]any{
+		"model": vocabularyModel,
+		"input": input,
+	}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return common.WriteError(cmd, "request_error", fmt.Sprintf("cannot serialize request: %s", err.Error()))
+	}
+
+	req, err := http.NewRequest("POST", getBaseURL()+vocabularyPath, bytes.NewReader(jsonBody))
+	if err != nil {
+		return common.WriteError(cmd, "request_error", fmt.Sprintf("cannot create request: %s", err.Error()))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return handleAPIError(cmd, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot read response: %s", err.Error()))
+	}
+
+	var result struct {
+		Output  json.RawMessage `json:"output"`
+		Code    string          `json:"code"`
+		Message string          `json:"message"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return handleHTTPError(cmd, resp.StatusCode, string(respBody))
+		}
+		return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot parse response: %s", err.Error()))
+	}
+
+	if result.Code != "" {
+		return common.WriteError(cmd, result.Code, result.Message)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return handleHTTPError(cmd, resp.StatusCode, string(respBody))
+	}
+
+	if output != nil && len(result.Output) > 0 {
+		if err := json.Unmarshal(result.Output, output); err != nil {
+			return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot parse response: %s", err.Error()))
+		}
+	}
+	return nil
+}
+
+// readVocabularyTerms reads and validates the terms in path. Terms without
+// a weight or language get weight and lang. Errors are written to the
+// command.
+func readVocabularyTerms(cmd *cobra.Command, path string, weight int, lang string) ([]vocabularyTerm, error) {
+	if weight < minTermWeight || weight > maxTermWeight {
+		return nil, common.WriteError(cmd, "invalid_parameter", fmt.Sprintf("--weight must be between %d and %d", minTermWeight, maxTermWeight))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, common.WriteError(cmd, "file_not_found", fmt.Sprintf("terms file '%s' does not exist", path))
+		}
+		return nil, common.WriteError(cmd, "file_read_error", fmt.Sprintf("cannot read terms file: %s", err.Error()))
+	}
+
+	var terms []vocabularyTerm
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		terms, err = parseJSONTerms(data)
+	case ".csv":
+		terms, err = parseCSVTerms(data)
+	default:
+		return nil, common.WriteError(cmd, "invalid_terms_file", fmt.Sprintf("unsupported terms file '%s', use .json or .csv", ext))
+	}
+	if err != nil {
+		return nil, common.WriteError(cmd, "invalid_terms_file", fmt.Sprintf("cannot parse terms file: %s", err.Error()))
+	}
+
+	if len(terms) == 0 {
+		return nil, common.WriteError(cmd, "invalid_terms_file", "terms file contains no terms")
+	}
+	if len(terms) > maxVocabularyTerms {
+		return nil, common.WriteError(cmd, "invalid_terms_file", fmt.Sprintf("a vocabulary holds at most %d terms, got %d", maxVocabularyTerms, len(terms)))
+	}
+	for i := range terms {
+		terms[i].Text = strings.TrimSpace(terms[i].Text)
+		if terms[i].Text == "" {
+			return nil, common.WriteError(cmd, "invalid_terms_file", fmt.Sprintf("term %d has no text", i+1))
+		}
+		if terms[i].Weight == 0 {
+			terms[i].Weight = weight
+		}
+		if terms[i].Weight < minTermWeight || terms[i].Weight > maxTermWeight {
+			return nil, common.WriteError(cmd, "invalid_terms_file", fmt.Sprintf("term '%s' has weight %d, must be between %d and %d", terms[i].Text, terms[i].Weight, minTermWeight, maxTermWeight))
+		}
+		if terms[i].Lang == "" {
+			terms[i].Lang = lang
+		}
+	}
+	return terms, nil
+}
+
+// parseJSONTerms reads an array of terms, where a term is an object or just
+// its text, or an object holding that array under "terms" or "vocabulary",
+// such as the output of vocabulary get.
+func parseJSONTerms(data []byte) ([]vocabularyTerm, error) {
+	var list []json.RawMessage
+	if err := json.Unmarshal(data, &list); err != nil {
+		var wrapper struct {
+			Terms      []json.RawMessage `json:"terms"`
+			Vocabulary []json.RawMessage `json:"vocabulary"`
+		}
+		if json.Unmarshal(data, &wrapper) != nil {
+			return nil, err
+		}
+		list = append(wrapper.Terms, wrapper.Vocabulary...)
+	}
+
+	terms := make([]vocabularyTerm, 0, len(list))
+	for i, raw := range list {
+		var term vocabularyTerm
+		if err := json.Unmarshal(raw, &term.Text); err != nil {
+			if err := json.Unmarshal(raw, &term); err != nil {
+				return nil, fmt.Errorf("term %d: %w", i+1, err)
+			}
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+// parseCSVTerms reads rows of text, weight and language; weight and
+// language may be left out. A first row starting with "text" is a header.
+func parseCSVTerms(data []byte) ([]vocabularyTerm, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(rows[0][0], "\ufeff")), "text") {
+		rows = rows[1:]
+	}
+
+	terms := make([]vocabularyTerm, 0, len(rows))
+	for i, row := range rows {
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		term := vocabularyTerm{Text: row[0]}
+		if len(row) > 1 && strings.TrimSpace(row[1]) != "" {
+			weight, err := strconv.Atoi(strings.TrimSpace(row[1]))
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid weight '%s'", i+1, row[1])
+			}
+			term.Weight = weight
+		}
+		if len(row) > 2 {
+			term.Lang = strings.TrimSpace(row[2])
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
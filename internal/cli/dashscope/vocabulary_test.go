@@ -0,0 +1,309 @@
+package dashscope
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+)
+
+func writeTermsFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// vocabularyServer answers the customization API with output and records
+// the input of the last request.
+func vocabularyServer(t *testing.T, output string) *map[string]any {
+	t.Helper()
+	input := map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string         `json:"model"`
+			Input map[string]any `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/api/v1"+vocabularyPath || body.Model != vocabularyModel {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"InvalidParameter","message":"unexpected request"}`))
+			return
+		}
+		input = body.Input
+		w.Write([]byte(`{"output":` + output + `,"request_id":"req-1"}`))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("DASHSCOPE_API_KEY", "sk-test")
+	t.Setenv("DASHSCOPE_BASE_URL", server.URL+"/api/v1")
+	return &input
+}
+
+// ===== Create Command =====
+
+func TestVocabularyCreate_MissingFile(t *testing.T) {
+	cmd := newVocabularyCmd()
+	_, stderr, err := executeVideoCommand(cmd, "create", "--prefix", "med")
+
+	if err == nil {
+		t.Fatal("expected error for missing terms file")
+	}
+	expectErrorCode(t, stderr, "missing_terms_file")
+}
+
+func TestVocabularyCreate_MissingPrefix(t *testing.T) {
+	cmd := newVocabularyCmd()
+	_, stderr, err := executeVideoCommand(cmd, "create", "terms.json")
+
+	if err == nil {
+		t.Fatal("expected error for missing prefix")
+	}
+	expectErrorCode(t, stderr, "missing_prefix")
+}
+
+func TestVocabularyCreate_InvalidPrefix(t *testing.T) {
+	for _, prefix := range []string{"Med", "med-terms", "abcdefghij"} {
+		cmd := newVocabularyCmd()
+		_, stderr, err := executeVideoCommand(cmd, "create", "terms.json", "--prefix", prefix)
+
+		if err == nil {
+			t.Fatalf("expected error for prefix %q", prefix)
+		}
+		expectErrorCode(t, stderr, "invalid_prefix")
+	}
+}
+
+func TestVocabularyCreate_InvalidTargetModel(t *testing.T) {
+	cmd := newVocabularyCmd()
+	_, stderr, err := executeVideoCommand(cmd, "create", "terms.json", "--prefix", "med", "-m", "qwen3-asr-flash")
+
+	if err == nil {
+		t.Fatal("expected error for a model without vocabularies")
+	}
+	expectErrorCode(t, stderr, "invalid_model")
+}
+
+func TestVocabularyCreate_FileNotFound(t *testing.T) {
+	cmd := newVocabularyCmd()
+	_, stderr, err := executeVideoCommand(cmd, "create", "/nonexistent/terms.json", "--prefix", "med")
+
+	if err == nil {
+		t.Fatal("expected error for missing file")
+	}
+	expectErrorCode(t, stderr, "file_not_found")
+}
+
+func TestVocabularyCreate_InvalidTerms(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+	}{
+		{"unsupported extension", "terms.txt", "Kubernetes"},
+		{"invalid json", "terms.json", "{"},
+		{"empty", "terms.json", "[]"},
+		{"blank text", "terms.json", `[{"text":" "}]`},
+		{"weight out of range", "terms.csv", "Kubernetes,9"},
+		{"invalid weight", "terms.csv", "Kubernetes,high"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newVocabularyCmd()
+			_, stderr, err := executeVideoCommand(cmd, "create", writeTermsFile(t, tt.file, tt.content), "--prefix", "med")
+
+			if err == nil {
+				t.Fatal("expected error for invalid terms")
+			}
+			expectErrorCode(t, stderr, "invalid_terms_file")
+		})
+	}
+}
+
+func TestVocabularyCreate_InvalidWeight(t *testing.T) {
+	cmd := newVocabularyCmd()
+	_, stderr, err := executeVideoCommand(cmd, "create", writeTermsFile(t, "terms.csv", "Kubernetes"), "--prefix", "med", "--weight", "0")
+
+	if err == nil {
+		t.Fatal("expected error for invalid weight")
+	}
+	expectErrorCode(t, stderr, "invalid_parameter")
+}
+
+func TestVocabularyCreate_MissingAPIKey(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	t.Setenv("DASHSCOPE_API_KEY", "")
+
+	cmd := newVocabularyCmd()
+	_, stderr, err := executeVideoCommand(cmd, "create", writeTermsFile(t, "terms.csv", "Kubernetes"), "--prefix", "med")
+
+	if err == nil {
+		t.Fatal("expected error for missing API key")
+	}
+	expectErrorCode(t, stderr, "missing_api_key")
+}
+
+func TestVocabularyCreate(t *testing.T) {
+	input := vocabularyServer(t, `{"vocabulary_id":"vocab-med-123"}`)
+	terms := writeTermsFile(t, "terms.csv", "text,weight,lang\nKubernetes,5,en\n阿莫西林,,zh\n")
+
+	cmd := newVocabularyCmd()
+	stdout, stderr, err := executeVideoCommand(cmd, "create", terms, "--prefix", "med", "-m", "fun-asr-realtime")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var resp map[string]any
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp["vocabulary_id"] != "vocab-med-123" || resp["count"] != float64(2) || resp["target_model"] != "fun-asr-realtime" {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	if (*input)["action"] != "create_vocabulary" || (*input)["prefix"] != "med" || (*input)["target_model"] != "fun-asr-realtime" {
+		t.Errorf("unexpected request input: %v", *input)
+	}
+	sent, _ := json.Marshal((*input)["vocabulary"])
+	want := `[{"lang":"en","text":"Kubernetes","weight":5},{"lang":"zh","text":"阿莫西林","weight":4}]`
+	if string(sent) != want {
+		t.Errorf("expected vocabulary %s, got %s", want, sent)
+	}
+}
+
+func TestVocabularyCreate_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"ResourceLimitExceeded","message":"too many vocabularies"}`))
+	}))
+	defer server.Close()
+	t.Setenv("DASHSCOPE_API_KEY", "sk-test")
+	t.Setenv("DASHSCOPE_BASE_URL", server.URL)
+
+	cmd := newVocabularyCmd()
+	_, stderr, err := executeVideoCommand(cmd, "create", writeTermsFile(t, "terms.csv", "Kubernetes"), "--prefix", "med")
+
+	if err == nil {
+		t.Fatal("expected error from the API")
+	}
+	expectErrorCode(t, stderr, "ResourceLimitExceeded")
+}
+
+// ===== List, Get, Update and Delete Commands =====
+
+func TestVocabularyList(t *testing.T) {
+	input := vocabularyServer(t, `{"vocabulary_list":[{"vocabulary_id":"vocab-med-123","status":"OK","gmt_create":"2026-10-01 10:00:00","gmt_modified":"2026-10-02 10:00:00"}]}`)
+
+	cmd := newVocabularyCmd()
+	stdout, stderr, err := executeVideoCommand(cmd, "list", "--prefix", "med", "--page-size", "50")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var resp struct {
+		Vocabularies []map[string]string `json:"vocabularies"`
+		Count        int                 `json:"count"`
+	}
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp.Count != 1 || resp.Vocabularies[0]["vocabulary_id"] != "vocab-med-123" || resp.Vocabularies[0]["status"] != "ok" {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+	if (*input)["action"] != "list_vocabulary" || (*input)["prefix"] != "med" || (*input)["page_size"] != float64(50) {
+		t.Errorf("unexpected request input: %v", *input)
+	}
+}
+
+func TestVocabularyList_InvalidPageSize(t *testing.T) {
+	cmd := newVocabularyCmd()
+	_, stderr, err := executeVideoCommand(cmd, "list", "--page-size", "0")
+
+	if err == nil {
+		t.Fatal("expected error for invalid page size")
+	}
+	expectErrorCode(t, stderr, "invalid_parameter")
+}
+
+func TestVocabularyGet_MissingID(t *testing.T) {
+	cmd := newVocabularyCmd()
+	_, stderr, err := executeVideoCommand(cmd, "get")
+
+	if err == nil {
+		t.Fatal("expected error for missing vocabulary ID")
+	}
+	expectErrorCode(t, stderr, "missing_vocabulary_id")
+}
+
+func TestVocabularyGet(t *testing.T) {
+	vocabularyServer(t, `{"vocabulary":[{"text":"Kubernetes","weight":5,"lang":"en"}],"target_model":"paraformer-realtime-v2","status":"OK"}`)
+
+	cmd := newVocabularyCmd()
+	stdout, stderr, err := executeVideoCommand(cmd, "get", "vocab-med-123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var resp struct {
+		VocabularyID string           `json:"vocabulary_id"`
+		TargetModel  string           `json:"target_model"`
+		Terms        []vocabularyTerm `json:"terms"`
+	}
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp.VocabularyID != "vocab-med-123" || resp.TargetModel != "paraformer-realtime-v2" || len(resp.Terms) != 1 || resp.Terms[0].Text != "Kubernetes" {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	// The output of get is a valid terms file for update
+	terms, err := parseJSONTerms([]byte(stdout))
+	if err != nil || len(terms) != 1 || terms[0].Weight != 5 {
+		t.Errorf("expected get output to parse as terms, got %+v, %v", terms, err)
+	}
+}
+
+func TestVocabularyUpdate_MissingFile(t *testing.T) {
+	cmd := newVocabularyCmd()
+	_, stderr, err := executeVideoCommand(cmd, "update", "vocab-med-123")
+
+	if err == nil {
+		t.Fatal("expected error for missing terms file")
+	}
+	expectErrorCode(t, stderr, "missing_terms_file")
+}
+
+func TestVocabularyUpdate(t *testing.T) {
+	input := vocabularyServer(t, `{}`)
+	terms := writeTermsFile(t, "terms.json", `["Kubernetes", {"text":"Docker","weight":2}]`)
+
+	cmd := newVocabularyCmd()
+	stdout, stderr, err := executeVideoCommand(cmd, "update", "vocab-med-123", terms, "--lang", "en")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var resp map[string]any
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp["vocabulary_id"] != "vocab-med-123" || resp["count"] != float64(2) {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+	sent, _ := json.Marshal((*input)["vocabulary"])
+	want := `[{"lang":"en","text":"Kubernetes","weight":4},{"lang":"en","text":"Docker","weight":2}]`
+	if (*input)["action"] != "update_vocabulary" || (*input)["vocabulary_id"] != "vocab-med-123" || string(sent) != want {
+		t.Errorf("unexpected request input: %v", *input)
+	}
+}
+
+func TestVocabularyDelete(t *testing.T) {
+	input := vocabularyServer(t, `{}`)
+
+	cmd := newVocabularyCmd()
+	stdout, stderr, err := executeVideoCommand(cmd, "delete", "vocab-med-123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var resp map[string]any
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp["status"] != "deleted" || (*input)["action"] != "delete_vocabulary" || (*input)["vocabulary_id"] != "vocab-med-123" {
+		t.Errorf("unexpected response %s for input %v", stdout, *input)
+	}
+}
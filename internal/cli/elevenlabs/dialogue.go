@@ -158,7 +158,7 @@ func runDialogue(cmd *cobra.Command, args []string, flags *dialogueFlags) error
 	// Determine output path
 	var outputPath string
 	var useTempFile bool
-	outputFormat := flags.format
+	outputFormat := outputFormatFor(cmd, flags.output, flags.format)
 
 	if flags.output != "" {
 		outputPath = flags.output
@@ -258,7 +258,7 @@ func runDialogue(cmd *cobra.Command, args []string, flags *dialogueFlags) error
 	}
 
 	// Write response to file
-	outFile, err := os.Create(absPath)
+	outFile, err := createOutput(absPath, outputFormat)
 	if err != nil {
 		if useTempFile {
 			os.Remove(outputPath)
@@ -278,7 +278,7 @@ func runDialogue(cmd *cobra.Command, args []string, flags *dialogueFlags) error
 	// Play audio if --speak is set
 	if flags.speak {
 		outFile.Close()
-		if err := common.PlayFile(absPath); err != nil {
+		if err := playFile(absPath, outputFormat); err != nil {
 			if useTempFile {
 				os.Remove(absPath)
 			}
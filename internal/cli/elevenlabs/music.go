@@ -118,7 +118,7 @@ func runMusic(cmd *cobra.Command, args []string, flags *musicFlags) error {
 	// Determine output path
 	var outputPath string
 	var useTempFile bool
-	outputFormat := flags.format
+	outputFormat := outputFormatFor(cmd, flags.output, flags.format)
 
 	if flags.output != "" {
 		outputPath = flags.output
@@ -205,7 +205,7 @@ func runMusic(cmd *cobra.Command, args []string, flags *musicFlags) error {
 	}
 
 	// Write response to file
-	outFile, err := os.Create(absPath)
+	outFile, err := createOutput(absPath, outputFormat)
 	if err != nil {
 		if useTempFile {
 			os.Remove(outputPath)
@@ -225,7 +225,7 @@ func runMusic(cmd *cobra.Command, args []string, flags *musicFlags) error {
 	// Play audio if --speak is set
 	if flags.speak {
 		outFile.Close()
-		if err := common.PlayFile(absPath); err != nil {
+		if err := playFile(absPath, outputFormat); err != nil {
 			if useTempFile {
 				os.Remove(absPath)
 			}
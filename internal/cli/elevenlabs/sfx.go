@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"path/filepath"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
@@ -146,7 +145,7 @@ func runSFX(cmd *cobra.Command, args []string, flags *sfxFlags) error {
 	}
 
 	// Write to file
-	outFile, err := os.Create(absPath)
+	outFile, err := common.CreateOutput(absPath)
 	if err != nil {
 		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
 	}
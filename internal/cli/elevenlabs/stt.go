@@ -2,18 +2,24 @@ package elevenlabs
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/gorilla/websocket"
 	"github.com/spf13/cobra"
 )
 
@@ -40,6 +46,8 @@ type sttFlags struct {
 	speakers   int
 	timestamps string
 	output     string
+	subtitles  common.SubtitleOptions
+	stream     common.StreamFlags
 }
 
 type sttResponse struct {
@@ -74,10 +82,12 @@ type sttAPIResponse struct {
 }
 
 type sttAPIWord struct {
-	Text  string  `json:"text"`
-	Start float64 `json:"start"`
-	End   float64 `json:"end"`
-	Type  string  `json:"type"`
+	Text      string   `json:"text"`
+	Start     float64  `json:"start"`
+	End       float64  `json:"end"`
+	Type      string   `json:"type"`
+	SpeakerID string   `json:"speaker_id,omitempty"`
+	Logprob   *float64 `json:"logprob,omitempty"`
 }
 
 type sttAPIUtterance struct {
@@ -104,17 +114,23 @@ func newSTTCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&flags.file, "file", "f", "", "Input audio file path")
-	cmd.Flags().StringVarP(&flags.model, "model", "m", "scribe_v1", "Model: scribe_v1, scribe_v2")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", "scribe_v1", "Model: scribe_v1, scribe_v2 (scribe_v2_realtime with --stream)")
 	cmd.Flags().StringVarP(&flags.language, "language", "l", "", "ISO-639 language code (auto-detect if empty)")
 	cmd.Flags().BoolVar(&flags.diarize, "diarize", false, "Enable speaker identification")
 	cmd.Flags().IntVar(&flags.speakers, "speakers", 0, "Max speakers (1-32, requires --diarize)")
 	cmd.Flags().StringVar(&flags.timestamps, "timestamps", "word", "Timestamp granularity: none, word, character")
-	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file (.txt, .srt, .json)")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file (.txt, .json, .srt, .vtt, .ass, .ttml)")
+	common.AddSubtitleFlags(cmd, &flags.subtitles)
+	common.AddStreamFlags(cmd, &flags.stream)
 
 	return cmd
 }
 
 func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
+	if flags.stream.Enabled {
+		return runSTTStream(cmd, args, flags)
+	}
+
 	// Get audio file from args, --file flag, or stdin
 	audioFile, cleanup, err := getSTTAudioInput(args, flags.file, cmd.InOrStdin())
 	if err != nil {
@@ -162,6 +178,15 @@ func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
 		return common.WriteError(cmd, "invalid_parameter", "--speakers must be between 1 and 32")
 	}
 
+	// Validate output format
+	outputFormat := common.TranscriptFormat(flags.output, common.TranscriptText)
+	if common.IsTimedFormat(outputFormat) && flags.timestamps == "none" {
+		return common.WriteError(cmd, "missing_timestamps", fmt.Sprintf("--output .%s requires --timestamps word or character", outputFormat))
+	}
+	if err := flags.subtitles.Validate(); err != nil {
+		return common.WriteError(cmd, "invalid_parameter", err.Error())
+	}
+
 	// Check API key
 	apiKey := config.GetAPIKey("ELEVENLABS_API_KEY")
 	if apiKey == "" {
@@ -275,26 +300,9 @@ func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
 
 	// Write to output file if specified
 	if flags.output != "" {
-		absPath, err := filepath.Abs(flags.output)
+		absPath, err := common.WriteTranscript(flags.output, newTranscript(apiResp), outputFormat, flags.subtitles)
 		if err != nil {
-			absPath = flags.output
-		}
-
-		ext := strings.ToLower(filepath.Ext(flags.output))
-		var content string
-
-		switch ext {
-		case ".srt":
-			content = generateSTTSRT(apiResp)
-		case ".json":
-			jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-			content = string(jsonBytes)
-		default: // .txt or other
-			content = apiResp.Text
-		}
-
-		if err := os.WriteFile(absPath, []byte(content), 0644); err != nil {
-			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+			return common.WriteError(cmd, common.TranscriptErrorCode(err), fmt.Sprintf("cannot write output file: %s", err.Error()))
 		}
 
 		result.File = absPath
@@ -356,57 +364,230 @@ func getSTTAudioInput(args []string, filePath string, stdin io.Reader) (file str
 	return "", nil, errors.New("no audio file provided, use positional argument, --file flag, or pipe from stdin")
 }
 
-func generateSTTSRT(resp sttAPIResponse) string {
-	// Filter to only word tokens
-	var wordTokens []sttAPIWord
+// newTranscript converts a Scribe response. Spacing and audio event
+// tokens are dropped; utterances, when diarization returned them, become
+// the segments.
+func newTranscript(resp sttAPIResponse) *common.Transcript {
+	t := &common.Transcript{
+		Text:     resp.Text,
+		Language: resp.LanguageCode,
+		Duration: resp.AudioDurationS,
+	}
 	for _, w := range resp.Words {
-		if w.Type == "word" {
-			wordTokens = append(wordTokens, w)
+		if w.Type != "word" {
+			continue
+		}
+		word := common.TranscriptWord{Text: w.Text, Start: w.Start, End: w.End, Speaker: w.SpeakerID}
+		if w.Logprob != nil {
+			word.Confidence = math.Round(math.Exp(*w.Logprob)*1000) / 1000
 		}
+		t.Words = append(t.Words, word)
 	}
+	for _, u := range resp.Utterances {
+		t.Segments = append(t.Segments, common.TranscriptSegment{Start: u.StartS, End: u.EndS, Text: u.Text, Speaker: u.SpeakerID})
+	}
+	t.Normalize()
+	return t
+}
 
-	if len(wordTokens) == 0 {
-		return ""
+// sttRealtimeModel is the model that transcribes streamed audio.
+const sttRealtimeModel = "scribe_v2_realtime"
+
+// sttRealtimeURL is the realtime transcription WebSocket.
+var sttRealtimeURL = "wss://api.elevenlabs.io/v1/speech-to-text/realtime"
+
+// sttRealtimeRate is the sample rate of the PCM sent to the realtime API.
+const sttRealtimeRate = 16000
+
+// sttFlushTimeout bounds the wait for the last transcript after the end of
+// the audio.
+var sttFlushTimeout = 10 * time.Second
+
+// runSTTStream transcribes PCM or WAV audio from stdin as it arrives with
+// Scribe realtime, writing partial and final results as NDJSON events. The
+// server commits a transcript at every pause; the rest is committed when
+// stdin ends.
+func runSTTStream(cmd *cobra.Command, args []string, flags *sttFlags) error {
+	if len(args) > 0 || flags.file != "" {
+		return common.WriteError(cmd, "incompatible_stream", "--stream reads audio from stdin, do not pass an audio file")
+	}
+	if err := flags.stream.Validate(); err != nil {
+		return common.WriteError(cmd, "invalid_parameter", err.Error())
+	}
+	if !cmd.Flags().Changed("model") {
+		flags.model = sttRealtimeModel
+	}
+	if flags.model != sttRealtimeModel {
+		return common.WriteError(cmd, "incompatible_stream", fmt.Sprintf("--stream requires model %s", sttRealtimeModel))
+	}
+	if flags.diarize {
+		return common.WriteError(cmd, "incompatible_stream", "--diarize is not supported with --stream")
+	}
+	if flags.timestamps != "none" && flags.timestamps != "word" && flags.timestamps != "character" {
+		return common.WriteError(cmd, "invalid_parameter", fmt.Sprintf("invalid timestamps value '%s', supported: none, word, character", flags.timestamps))
+	}
+	outputFormat := common.TranscriptFormat(flags.output, common.TranscriptText)
+	if common.IsTimedFormat(outputFormat) && flags.timestamps == "none" {
+		return common.WriteError(cmd, "missing_timestamps", fmt.Sprintf("--output .%s requires --timestamps word or character", outputFormat))
+	}
+	if err := flags.subtitles.Validate(); err != nil {
+		return common.WriteError(cmd, "invalid_parameter", err.Error())
 	}
 
-	var sb strings.Builder
-	// Group words into segments (roughly 10 words per segment)
-	const wordsPerSegment = 10
-	segmentNum := 1
+	apiKey := config.GetAPIKey("ELEVENLABS_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("ELEVENLABS_API_KEY"))
+	}
 
-	for i := 0; i < len(wordTokens); i += wordsPerSegment {
-		end := i + wordsPerSegment
-		if end > len(wordTokens) {
-			end = len(wordTokens)
-		}
+	audio, err := flags.stream.OpenPCMStream(cmd.InOrStdin(), sttRealtimeRate)
+	if err != nil {
+		return common.WriteError(cmd, "invalid_audio", fmt.Sprintf("cannot read audio from stdin: %s", err.Error()))
+	}
 
-		segment := wordTokens[i:end]
-		if len(segment) == 0 {
-			continue
+	timestamps := flags.timestamps != "none"
+	query := url.Values{}
+	query.Set("model_id", flags.model)
+	query.Set("audio_format", fmt.Sprintf("pcm_%d", sttRealtimeRate))
+	query.Set("commit_strategy", "vad")
+	query.Set("include_timestamps", strconv.FormatBool(timestamps))
+	if flags.language != "" {
+		query.Set("language_code", flags.language)
+	}
+
+	header := http.Header{}
+	header.Set("xi-api-key", apiKey)
+	conn, resp, err := websocket.DefaultDialer.Dial(sttRealtimeURL+"?"+query.Encode(), header)
+	if err != nil {
+		if resp != nil {
+			return handleAPIErrorResponse(cmd, resp)
 		}
+		return common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot connect to WebSocket: %s", err.Error()))
+	}
+	defer conn.Close()
+
+	// Read results in background; every committed transcript is signalled
+	// so the end of the stream can wait for the last one
+	events := common.NewTranscriptStream(cmd.OutOrStdout())
+	committed := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		for {
+			_, message, readErr := conn.ReadMessage()
+			if readErr != nil {
+				done <- nil
+				return
+			}
 
-		startTime := segment[0].Start
-		endTime := segment[len(segment)-1].End
+			var event struct {
+				MessageType  string       `json:"message_type"`
+				Text         string       `json:"text"`
+				LanguageCode string       `json:"language_code"`
+				Words        []sttAPIWord `json:"words"`
+				Error        string       `json:"error"`
+			}
+			if jsonErr := json.Unmarshal(message, &event); jsonErr != nil {
+				continue
+			}
 
-		var words []string
-		for _, w := range segment {
-			words = append(words, w.Text)
+			switch event.MessageType {
+			case "session_started":
+			case "partial_transcript":
+				_ = events.Partial(event.Text)
+			case "committed_transcript", "committed_transcript_with_timestamps":
+				// With timestamps both are sent; the timed one is final
+				if timestamps != (event.MessageType == "committed_transcript_with_timestamps") {
+					continue
+				}
+				t := newTranscript(sttAPIResponse{Text: event.Text, LanguageCode: event.LanguageCode, Words: event.Words})
+				segment := common.TranscriptSegment{Text: t.Text}
+				if len(t.Words) > 0 {
+					segment.Start, segment.End = t.Words[0].Start, t.Words[len(t.Words)-1].End
+				}
+				_ = events.Final(segment, t.Language, t.Words)
+				select {
+				case committed <- struct{}{}:
+				default:
+				}
+			default:
+				if event.Error != "" || strings.HasSuffix(event.MessageType, "error") {
+					done <- fmt.Errorf("%s: %s", event.MessageType, event.Error)
+					return
+				}
+			}
 		}
+	}()
+
+	sendChunk := func(data []byte, commit bool) error {
+		return conn.WriteJSON(map[string]any{
+			"message_type":  "input_audio_chunk",
+			"audio_base_64": base64.StdEncoding.EncodeToString(data),
+			"commit":        commit,
+			"sample_rate":   sttRealtimeRate,
+		})
+	}
 
-		sb.WriteString(fmt.Sprintf("%d\n", segmentNum))
-		sb.WriteString(fmt.Sprintf("%s --> %s\n", formatSRTTime(startTime), formatSRTTime(endTime)))
-		sb.WriteString(strings.Join(words, " "))
-		sb.WriteString("\n\n")
-		segmentNum++
+	// Forward audio as it arrives, ~100ms per message, until stdin ends or
+	// the server stops the session
+	var streamErr error
+	closed := false
+	buf := make([]byte, sttRealtimeRate*2/10)
+	for !closed {
+		n, readErr := audio.Read(buf)
+		if n > 0 {
+			if writeErr := sendChunk(buf[:n], false); writeErr != nil {
+				break
+			}
+		}
+		if readErr != nil {
+			break
+		}
+		select {
+		case streamErr = <-done:
+			closed = true
+		default:
+		}
+	}
+
+	// Commit what is left and wait for its transcript
+	if !closed {
+		select {
+		case <-committed:
+		default:
+		}
+		_ = sendChunk(nil, true)
+		streamErr = waitForCommit(committed, done, events)
+	}
+	if streamErr != nil {
+		return common.WriteError(cmd, "server_error", fmt.Sprintf("recognition failed: %s", streamErr.Error()))
 	}
 
-	return sb.String()
+	var file string
+	if flags.output != "" {
+		file, err = common.WriteTranscript(flags.output, events.Transcript(), outputFormat, flags.subtitles)
+		if err != nil {
+			return common.WriteError(cmd, common.TranscriptErrorCode(err), fmt.Sprintf("cannot write output file: %s", err.Error()))
+		}
+	}
+	return events.Done(file)
 }
 
-func formatSRTTime(seconds float64) string {
-	h := int(seconds) / 3600
-	m := (int(seconds) % 3600) / 60
-	s := int(seconds) % 60
-	ms := int((seconds-float64(int(seconds)))*1000 + 0.5) // Round to nearest
-	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
+// waitForCommit waits for the next committed transcript, an error or the
+// end of the connection. Without speech left to settle, a quiet second ends
+// the wait.
+func waitForCommit(committed <-chan struct{}, done <-chan error, events *common.TranscriptStream) error {
+	deadline := time.After(sttFlushTimeout)
+	for {
+		select {
+		case <-committed:
+			return nil
+		case err := <-done:
+			return err
+		case <-deadline:
+			return nil
+		case <-time.After(time.Second):
+			if !events.Pending() {
+				return nil
+			}
+		}
+	}
 }
@@ -1,12 +1,19 @@
 package elevenlabs
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/gorilla/websocket"
 )
 
 func TestSTT_MissingInput(t *testing.T) {
@@ -315,90 +322,163 @@ func TestSTT_FromStdin(t *testing.T) {
 	}
 }
 
-func TestSTT_FormatSRTTime(t *testing.T) {
-	tests := []struct {
-		seconds  float64
-		expected string
-	}{
-		{0, "00:00:00,000"},
-		{1.5, "00:00:01,500"},
-		{61.123, "00:01:01,123"},
-		{3661.999, "01:01:01,999"},
-	}
-
-	for _, tt := range tests {
-		result := formatSRTTime(tt.seconds)
-		if result != tt.expected {
-			t.Errorf("formatSRTTime(%f) = %q, want %q", tt.seconds, result, tt.expected)
+func TestSTT_SubtitlesRequireTimestamps(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "audio.mp3")
+	os.WriteFile(tmpFile, []byte("fake audio"), 0644)
+
+	cmd := newSTTCmd()
+	_, stderr, err := executeCommand(cmd, tmpFile, "--timestamps", "none", "-o", "out.vtt")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "missing_timestamps" {
+		t.Errorf("expected error code 'missing_timestamps', got: %s", errorObj["code"])
+	}
+}
+
+func TestSTT_StreamRequiresRealtimeModel(t *testing.T) {
+	cmd := newSTTCmd()
+	_, stderr, err := executeCommand(cmd, "--stream", "-m", "scribe_v1")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "incompatible_stream" {
+		t.Errorf("expected error code 'incompatible_stream', got: %s", errorObj["code"])
+	}
+}
+
+func TestSTT_Stream(t *testing.T) {
+	var query url.Values
+	var audioBytes int
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
 		}
+		defer conn.Close()
+		conn.WriteJSON(map[string]any{"message_type": "session_started"})
+		for {
+			var msg struct {
+				Audio  string `json:"audio_base_64"`
+				Commit bool   `json:"commit"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			data, _ := base64.StdEncoding.DecodeString(msg.Audio)
+			if audioBytes == 0 {
+				conn.WriteJSON(map[string]any{"message_type": "partial_transcript", "text": "Hello"})
+			}
+			audioBytes += len(data)
+			if msg.Commit {
+				conn.WriteJSON(map[string]any{"message_type": "committed_transcript", "text": "Hello there."})
+				conn.WriteJSON(map[string]any{"message_type": "committed_transcript_with_timestamps", "text": "Hello there.", "language_code": "en",
+					"words": []map[string]any{
+						{"text": "Hello", "start": 0.1, "end": 0.4, "type": "word"},
+						{"text": " ", "start": 0.4, "end": 0.5, "type": "spacing"},
+						{"text": "there.", "start": 0.5, "end": 0.9, "type": "word"},
+					}})
+			}
+		}
+	}))
+	defer server.Close()
+	t.Setenv("ELEVENLABS_API_KEY", "test-key")
+	defer func(u string) { sttRealtimeURL = u }(sttRealtimeURL)
+	sttRealtimeURL = "ws" + strings.TrimPrefix(server.URL, "http")
+
+	output := filepath.Join(t.TempDir(), "out.srt")
+	cmd := newSTTCmd()
+	cmd.SetIn(bytes.NewReader(make([]byte, 16000*2)))
+	stdout, stderr, err := executeCommand(cmd, "--stream", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if query.Get("model_id") != "scribe_v2_realtime" || query.Get("audio_format") != "pcm_16000" || query.Get("include_timestamps") != "true" {
+		t.Errorf("unexpected query: %v", query)
+	}
+	if audioBytes != 16000*2 {
+		t.Errorf("expected one second of audio, got %d bytes", audioBytes)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 events, got:\n%s", stdout)
+	}
+	if lines[0] != `{"type":"partial","text":"Hello"}` {
+		t.Errorf("unexpected partial event: %s", lines[0])
+	}
+	var final common.TranscriptEvent
+	json.Unmarshal([]byte(lines[1]), &final)
+	if final.Type != "final" || final.Text != "Hello there." || *final.Start != 0.1 || *final.End != 0.9 || len(final.Words) != 2 {
+		t.Errorf("unexpected final event: %s", lines[1])
+	}
+	if !strings.Contains(lines[2], `"type":"done"`) || !strings.Contains(lines[2], output) {
+		t.Errorf("unexpected done event: %s", lines[2])
+	}
+	if data, _ := os.ReadFile(output); !strings.Contains(string(data), "00:00:00,100 --> 00:00:00,900") {
+		t.Errorf("unexpected SRT:\n%s", data)
 	}
 }
 
-func TestGenerateSTTSRT(t *testing.T) {
-	tests := []struct {
-		name     string
-		response sttAPIResponse
-		expected string
-	}{
-		{
-			name: "filters spacing tokens",
-			response: sttAPIResponse{
-				Text: "Hello world",
-				Words: []sttAPIWord{
-					{Text: "Hello", Start: 0.1, End: 0.5, Type: "word"},
-					{Text: " ", Start: 0.5, End: 0.6, Type: "spacing"},
-					{Text: "world", Start: 0.6, End: 1.0, Type: "word"},
-				},
-			},
-			expected: "1\n00:00:00,100 --> 00:00:01,000\nHello world\n\n",
-		},
-		{
-			name: "empty words returns empty string",
-			response: sttAPIResponse{
-				Text:  "Hello",
-				Words: []sttAPIWord{},
-			},
-			expected: "",
+func TestNewTranscript(t *testing.T) {
+	logprob := -0.1
+	resp := sttAPIResponse{
+		Text:         "Hello world. Hi!",
+		LanguageCode: "eng",
+		Words: []sttAPIWord{
+			{Text: "Hello", Start: 0.1, End: 0.5, Type: "word", SpeakerID: "speaker_0", Logprob: &logprob},
+			{Text: " ", Start: 0.5, End: 0.6, Type: "spacing", SpeakerID: "speaker_0"},
+			{Text: "world.", Start: 0.6, End: 1.0, Type: "word", SpeakerID: "speaker_0"},
+			{Text: "(laughs)", Start: 1.0, End: 1.4, Type: "audio_event"},
+			{Text: "Hi!", Start: 1.5, End: 1.8, Type: "word", SpeakerID: "speaker_1"},
 		},
-		{
-			name: "only spacing tokens returns empty",
-			response: sttAPIResponse{
-				Text: " ",
-				Words: []sttAPIWord{
-					{Text: " ", Start: 0.0, End: 0.1, Type: "spacing"},
-				},
-			},
-			expected: "",
-		},
-		{
-			name: "segments long text",
-			response: sttAPIResponse{
-				Text: "One two three four five six seven eight nine ten eleven",
-				Words: []sttAPIWord{
-					{Text: "One", Start: 0.0, End: 0.1, Type: "word"},
-					{Text: "two", Start: 0.2, End: 0.3, Type: "word"},
-					{Text: "three", Start: 0.4, End: 0.5, Type: "word"},
-					{Text: "four", Start: 0.6, End: 0.7, Type: "word"},
-					{Text: "five", Start: 0.8, End: 0.9, Type: "word"},
-					{Text: "six", Start: 1.0, End: 1.1, Type: "word"},
-					{Text: "seven", Start: 1.2, End: 1.3, Type: "word"},
-					{Text: "eight", Start: 1.4, End: 1.5, Type: "word"},
-					{Text: "nine", Start: 1.6, End: 1.7, Type: "word"},
-					{Text: "ten", Start: 1.8, End: 1.9, Type: "word"},
-					{Text: "eleven", Start: 2.0, End: 2.1, Type: "word"},
-				},
-			},
-			// First segment: 10 words, second segment: 1 word
-			expected: "1\n00:00:00,000 --> 00:00:01,900\nOne two three four five six seven eight nine ten\n\n2\n00:00:02,000 --> 00:00:02,100\neleven\n\n",
+	}
+
+	transcript := newTranscript(resp)
+	if len(transcript.Words) != 3 {
+		t.Fatalf("expected 3 words, got %+v", transcript.Words)
+	}
+	if c := transcript.Words[0].Confidence; c < 0.9 || c > 0.91 {
+		t.Errorf("expected confidence from logprob, got %v", c)
+	}
+	if len(transcript.Segments) != 2 || transcript.Segments[0].Text != "Hello world." || transcript.Segments[1].Speaker != "speaker_1" {
+		t.Errorf("unexpected segments: %+v", transcript.Segments)
+	}
+	if len(transcript.Speakers) != 2 {
+		t.Errorf("expected 2 speakers, got %q", transcript.Speakers)
+	}
+}
+
+func TestNewTranscript_Utterances(t *testing.T) {
+	resp := sttAPIResponse{
+		Text: "Hi. Bye.",
+		Utterances: []sttAPIUtterance{
+			{SpeakerID: "speaker_0", Text: "Hi.", StartS: 0, EndS: 1},
+			{SpeakerID: "speaker_1", Text: "Bye.", StartS: 1, EndS: 2},
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := generateSTTSRT(tt.response)
-			if result != tt.expected {
-				t.Errorf("generateSTTSRT() =\n%q\nwant\n%q", result, tt.expected)
-			}
-		})
+	transcript := newTranscript(resp)
+	data, err := common.FormatTranscript(transcript, common.TranscriptSRT, common.SubtitleOptions{MaxLineLength: 42, MaxLines: 2, SpeakerLabels: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1\n00:00:00,000 --> 00:00:01,000\nspeaker_0: Hi.\n\n2\n00:00:01,000 --> 00:00:02,000\nspeaker_1: Bye.\n\n"
+	if string(data) != want {
+		t.Errorf("unexpected SRT:\n%q", data)
 	}
 }
@@ -2,6 +2,7 @@ package elevenlabs
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
@@ -68,6 +70,77 @@ var ttsOutputFormats = map[string]bool{
 	"ulaw_8000": true,
 }
 
+// playbackFormat returns the player extension and options for an output
+// format such as "pcm_24000".
+func playbackFormat(format string) (string, common.DecodeOptions) {
+	codec, rest, _ := strings.Cut(format, "_")
+	rate, _, _ := strings.Cut(rest, "_")
+	opts := common.DecodeOptions{}
+	opts.SampleRate, _ = strconv.Atoi(rate)
+	if codec == "opus" {
+		return ".ogg", opts
+	}
+	return "." + codec, opts
+}
+
+// playFile plays a file written in the given output format.
+func playFile(path, format string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open file: %w", err)
+	}
+	defer file.Close()
+
+	ext, opts := playbackFormat(format)
+	if ext == ".pcm" && common.IsWAVPath(path) {
+		ext = ".wav"
+	}
+	return common.PlayAudio(file, ext, opts)
+}
+
+// defaultWAVFormat is requested for .wav outputs when --format is not given.
+const defaultWAVFormat = "pcm_24000"
+
+// outputFormatFor returns the format to request for output; .wav outputs
+// are requested as PCM unless --format is given.
+func outputFormatFor(cmd *cobra.Command, output, format string) string {
+	if common.IsWAVPath(output) && !cmd.Flags().Changed("format") {
+		return defaultWAVFormat
+	}
+	return format
+}
+
+// createOutput creates the output file; PCM written to a .wav path gets a
+// WAV header.
+func createOutput(path, format string) (io.WriteCloser, error) {
+	ext, opts := playbackFormat(format)
+	if ext != ".pcm" {
+		return common.CreateOutput(path)
+	}
+	return common.CreatePCMFile(path, common.AudioFormat{SampleRate: opts.SampleRate, Channels: 1, Encoding: common.PCMS16LE})
+}
+
+// modelCharacterLimits is the longest text each model accepts per request;
+// longer text is synthesized in chunks.
+var modelCharacterLimits = map[string]int{
+	"eleven_v3":              5000,
+	"eleven_multilingual_v2": 10000,
+	"eleven_flash_v2_5":      40000,
+	"eleven_turbo_v2_5":      40000,
+	"eleven_flash_v2":        30000,
+	"eleven_turbo_v2":        30000,
+}
+
+// defaultCharacterLimit applies to models not listed above.
+const defaultCharacterLimit = 5000
+
+func maxCharacters(model string) int {
+	if limit, ok := modelCharacterLimits[model]; ok {
+		return limit
+	}
+	return defaultCharacterLimit
+}
+
 type ttsFlags struct {
 	output            string
 	promptFile        string
@@ -83,15 +156,20 @@ type ttsFlags struct {
 	textNormalization string
 	stream            bool
 	speak             bool
+	timing            common.TimestampFlags
 }
 
 type ttsResponse struct {
-	Success    bool   `json:"success"`
-	File       string `json:"file,omitempty"`
-	Voice      string `json:"voice,omitempty"`
-	Model      string `json:"model,omitempty"`
-	Characters int    `json:"characters,omitempty"`
-	Stream     bool   `json:"stream,omitempty"`
+	Success            bool               `json:"success"`
+	File               string             `json:"file,omitempty"`
+	Voice              string             `json:"voice,omitempty"`
+	Model              string             `json:"model,omitempty"`
+	Characters         int                `json:"characters,omitempty"`
+	Stream             bool               `json:"stream,omitempty"`
+	Chunks             int                `json:"chunks,omitempty"`
+	TimeToFirstAudioMs int64              `json:"time_to_first_audio_ms,omitempty"`
+	Timestamps         []common.TimedText `json:"timestamps,omitempty"`
+	Subtitles          string             `json:"subtitles,omitempty"`
 }
 
 type ttsRequestBody struct {
@@ -100,6 +178,8 @@ type ttsRequestBody struct {
 	LanguageCode           string        `json:"language_code,omitempty"`
 	VoiceSettings          voiceSettings `json:"voice_settings"`
 	ApplyTextNormalization string        `json:"apply_text_normalization,omitempty"`
+	PreviousText           string        `json:"previous_text,omitempty"`
+	NextText               string        `json:"next_text,omitempty"`
 }
 
 type voiceSettings struct {
@@ -122,6 +202,7 @@ func newTTSCmd() *cobra.Command {
   rawgenai elevenlabs tts "Hello" --speak
   rawgenai elevenlabs tts "Hello" --stream --speak -m eleven_flash_v2_5
   rawgenai elevenlabs tts "你好世界" -o zh.mp3 -m eleven_v3 -l zh
+  rawgenai elevenlabs tts "Hello world" -o hello.mp3 --timestamps --subtitles hello.srt
   echo "Hello" | rawgenai elevenlabs tts -o hello.mp3`,
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -144,6 +225,7 @@ func newTTSCmd() *cobra.Command {
 	cmd.Flags().StringVar(&flags.textNormalization, "text-normalization", "auto", "Text normalization: auto, on, off")
 	cmd.Flags().BoolVar(&flags.stream, "stream", false, "Use streaming mode for lower latency")
 	cmd.Flags().BoolVar(&flags.speak, "speak", false, "Play audio after generation")
+	common.AddTimestampFlags(cmd, &flags.timing, common.TimestampsWord, common.TimestampsCharacter, common.TimestampsSentence)
 
 	return cmd
 }
@@ -163,7 +245,7 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	// Determine output path and format
 	var outputPath string
 	var useTempFile bool
-	outputFormat := flags.format
+	outputFormat := outputFormatFor(cmd, flags.output, flags.format)
 
 	if flags.output != "" {
 		outputPath = flags.output
@@ -210,6 +292,11 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		return common.WriteError(cmd, "invalid_style", "style must be between 0.0 and 1.0")
 	}
 
+	// Validate timestamps and subtitles
+	if err := flags.timing.Validate(); err != nil {
+		return common.WriteError(cmd, common.TimestampErrorCode(err), err.Error())
+	}
+
 	// Check API key
 	apiKey := config.GetAPIKey("ELEVENLABS_API_KEY")
 	if apiKey == "" {
@@ -219,6 +306,18 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	// Resolve voice ID
 	voiceID := resolveVoiceID(flags.voice)
 
+	// Long text is split on sentence boundaries and the audio joined
+	chunks := common.SplitText(text, maxCharacters(flags.model), nil)
+	ext, _ := playbackFormat(outputFormat)
+	if len(chunks) > 1 {
+		if flags.stream {
+			return common.WriteError(cmd, "text_too_long", fmt.Sprintf("text longer than %d characters cannot be streamed with model '%s'", maxCharacters(flags.model), flags.model))
+		}
+		if !common.CanJoinAudio(ext) {
+			return common.WriteError(cmd, "text_too_long", fmt.Sprintf("text longer than %d characters can only be written as mp3, wav or pcm", maxCharacters(flags.model)))
+		}
+	}
+
 	// Build request body
 	reqBody := ttsRequestBody{
 		Text:         text,
@@ -236,88 +335,148 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		reqBody.ApplyTextNormalization = flags.textNormalization
 	}
 
-	bodyBytes, err := json.Marshal(reqBody)
-	if err != nil {
-		return common.WriteError(cmd, "internal_error", fmt.Sprintf("cannot marshal request: %s", err.Error()))
+	// Get absolute path for output
+	var absPath string
+	if outputPath != "" {
+		absPath, err = filepath.Abs(outputPath)
+		if err != nil {
+			absPath = outputPath
+		}
 	}
 
-	// Make API request
-	var apiURL string
-	if flags.stream {
-		apiURL = fmt.Sprintf("%s/text-to-speech/%s/stream?output_format=%s", baseURL, voiceID, outputFormat)
-	} else {
-		apiURL = fmt.Sprintf("%s/text-to-speech/%s?output_format=%s", baseURL, voiceID, outputFormat)
+	// The with-timestamps endpoints return the audio with character alignment
+	endpoint := ""
+	if flags.timing.Enabled() {
+		endpoint = "/with-timestamps"
 	}
-	req, err := http.NewRequest("POST", apiURL, bytes.NewReader(bodyBytes))
-	if err != nil {
-		return common.WriteError(cmd, "internal_error", fmt.Sprintf("cannot create request: %s", err.Error()))
-	}
-
-	req.Header.Set("xi-api-key", apiKey)
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		if useTempFile {
-			os.Remove(outputPath)
+	var timeToFirstAudio int64
+	var chars []common.TimedText
+	if flags.stream {
+		apiURL := fmt.Sprintf("%s/text-to-speech/%s/stream%s?output_format=%s", baseURL, voiceID, endpoint, outputFormat)
+		req, err := newTTSRequest(context.Background(), apiURL, apiKey, reqBody)
+		if err != nil {
+			return common.WriteError(cmd, "internal_error", err.Error())
 		}
-		return handleHTTPError(cmd, err)
-	}
-	defer resp.Body.Close()
 
-	// Handle API errors
-	if resp.StatusCode != http.StatusOK {
-		if useTempFile {
-			os.Remove(outputPath)
+		// Streaming playback starts with the request so time to first audio covers it
+		var player *common.StreamPlayer
+		if flags.speak {
+			ext, opts := playbackFormat(outputFormat)
+			player = common.NewStreamPlayer(ext, opts)
+			defer player.Abort(nil)
 		}
-		return handleAPIErrorResponse(cmd, resp)
-	}
 
-	// Get absolute path for output
-	var absPath string
-	if outputPath != "" {
-		absPath, err = filepath.Abs(outputPath)
+		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
-			absPath = outputPath
+			if useTempFile {
+				os.Remove(outputPath)
+			}
+			return handleHTTPError(cmd, err)
+		}
+		defer resp.Body.Close()
+
+		// Handle API errors
+		if resp.StatusCode != http.StatusOK {
+			if useTempFile {
+				os.Remove(outputPath)
+			}
+			return handleAPIErrorResponse(cmd, resp)
 		}
-	}
 
-	// Handle streaming playback: play directly from HTTP response
-	if flags.stream && flags.speak {
-		var audioReader io.Reader = resp.Body
+		if player != nil {
+			// Play while the HTTP response arrives
+			var w io.Writer = player
 
-		// If output file specified, tee to file while playing
-		if absPath != "" {
-			outFile, err := os.Create(absPath)
+			// If output file specified, also write to file while playing
+			if absPath != "" && !useTempFile {
+				outFile, err := createOutput(absPath, outputFormat)
+				if err != nil {
+					return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
+				}
+				defer outFile.Close()
+				w = io.MultiWriter(outFile, player)
+			}
+			if useTempFile {
+				os.Remove(absPath)
+			}
+
+			if chars, err = copyAudio(w, resp.Body, flags.timing.Enabled(), outputFormat); err != nil {
+				if playErr := player.Abort(err); playErr != nil {
+					return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", playErr.Error()))
+				}
+				return common.WriteError(cmd, "connection_error", fmt.Sprintf("cannot read audio stream: %s", err.Error()))
+			}
+			if err := player.Close(); err != nil {
+				return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", err.Error()))
+			}
+			timeToFirstAudio = player.TimeToFirstAudio().Milliseconds()
+		} else {
+			outFile, err := createOutput(absPath, outputFormat)
 			if err != nil {
 				return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
 			}
 			defer outFile.Close()
-			audioReader = io.TeeReader(resp.Body, outFile)
+
+			if chars, err = copyAudio(outFile, resp.Body, flags.timing.Enabled(), outputFormat); err != nil {
+				return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+			}
+		}
+	} else {
+		// Non-streaming: synthesize every chunk, then write the joined audio
+		apiURL := fmt.Sprintf("%s/text-to-speech/%s%s?output_format=%s", baseURL, voiceID, endpoint, outputFormat)
+		parts, err := common.SynthesizeChunks(context.Background(), chunks, common.DefaultChunkConcurrency, func(ctx context.Context, i int, chunk string) ([]byte, error) {
+			body := reqBody
+			body.Text = chunk
+			// Neighbouring text keeps the prosody continuous across chunks
+			if i > 0 {
+				body.PreviousText = chunks[i-1]
+			}
+			if i+1 < len(chunks) {
+				body.NextText = chunks[i+1]
+			}
+			return synthesize(ctx, apiURL, apiKey, body)
+		})
+		if err != nil {
+			if useTempFile {
+				os.Remove(outputPath)
+			}
+			var statusErr *responseError
+			if errors.As(err, &statusErr) {
+				return handleAPIErrorResponse(cmd, statusErr.resp)
+			}
+			return handleHTTPError(cmd, err)
 		}
 
-		// Stream directly to player
-		if err := common.PlayMP3(audioReader); err != nil {
-			if useTempFile && absPath != "" {
-				os.Remove(absPath)
+		if flags.timing.Enabled() {
+			_, opts := playbackFormat(outputFormat)
+			if parts, chars, err = splitTimedAudio(parts, ext, opts); err != nil {
+				if useTempFile {
+					os.Remove(outputPath)
+				}
+				return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot parse timestamped audio: %s", err.Error()))
 			}
-			return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", err.Error()))
 		}
-		if useTempFile && absPath != "" {
-			os.Remove(absPath)
+
+		audio, err := common.JoinAudio(ext, parts)
+		if err != nil {
+			if useTempFile {
+				os.Remove(outputPath)
+			}
+			return common.WriteError(cmd, "join_error", fmt.Sprintf("cannot join audio chunks: %s", err.Error()))
 		}
-	} else {
-		// Non-streaming: write to file first
-		outFile, err := os.Create(absPath)
+
+		outFile, err := createOutput(absPath, outputFormat)
 		if err != nil {
 			if useTempFile {
 				os.Remove(outputPath)
 			}
 			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
 		}
-		defer outFile.Close()
-
-		_, err = io.Copy(outFile, resp.Body)
+		_, err = outFile.Write(audio)
+		if closeErr := outFile.Close(); err == nil {
+			err = closeErr
+		}
 		if err != nil {
 			if useTempFile {
 				os.Remove(outputPath)
@@ -327,8 +486,7 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 
 		// Play audio if --speak is set
 		if flags.speak {
-			outFile.Close() // Close before playing
-			if err := common.PlayFile(absPath); err != nil {
+			if err := playFile(absPath, outputFormat); err != nil {
 				if useTempFile {
 					os.Remove(absPath)
 				}
@@ -342,12 +500,22 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 
 	// Return success
 	result := ttsResponse{
-		Success:    true,
-		File:       absPath,
-		Voice:      flags.voice,
-		Model:      flags.model,
-		Characters: len(text),
-		Stream:     flags.stream,
+		Success:            true,
+		File:               absPath,
+		Voice:              flags.voice,
+		Model:              flags.model,
+		Characters:         len(text),
+		Stream:             flags.stream,
+		TimeToFirstAudioMs: timeToFirstAudio,
+	}
+	if len(chunks) > 1 {
+		result.Chunks = len(chunks)
+	}
+	if flags.timing.Timestamps != "" {
+		result.Timestamps = common.AlignCharacters(chars, flags.timing.Timestamps)
+	}
+	if result.Subtitles, err = flags.timing.WriteSubtitles(common.SubtitleCues(common.WordsFromCharacters(chars))); err != nil {
+		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write subtitles: %s", err.Error()))
 	}
 	if useTempFile {
 		result.File = "" // Don't report temp file path
@@ -355,6 +523,137 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	return common.WriteSuccess(cmd, result)
 }
 
+func newTTSRequest(ctx context.Context, apiURL, apiKey string, body ttsRequestBody) (*http.Request, error) {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create request: %w", err)
+	}
+	req.Header.Set("xi-api-key", apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// responseError carries a failed API response, with its body buffered, out
+// of a chunk request.
+type responseError struct {
+	resp *http.Response
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("API error: %d", e.resp.StatusCode)
+}
+
+// synthesize requests the audio for one chunk of text.
+func synthesize(ctx context.Context, apiURL, apiKey string, body ttsRequestBody) ([]byte, error) {
+	req, err := newTTSRequest(ctx, apiURL, apiKey, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		return nil, &responseError{resp: resp}
+	}
+	return data, nil
+}
+
+// alignment is the character timing returned by the with-timestamps endpoints.
+type alignment struct {
+	Characters []string  `json:"characters"`
+	Starts     []float64 `json:"character_start_times_seconds"`
+	Ends       []float64 `json:"character_end_times_seconds"`
+}
+
+// timedAudio is a response, or stream chunk, of the with-timestamps endpoints.
+type timedAudio struct {
+	Audio     []byte     `json:"audio_base64"`
+	Alignment *alignment `json:"alignment"`
+}
+
+// timings returns the character timings shifted by offset seconds.
+func (a *alignment) timings(offset float64) []common.TimedText {
+	if a == nil {
+		return nil
+	}
+	chars := make([]common.TimedText, 0, len(a.Characters))
+	for i, c := range a.Characters {
+		if i >= len(a.Starts) || i >= len(a.Ends) {
+			break
+		}
+		chars = append(chars, common.TimedText{Text: c, Start: a.Starts[i] + offset, End: a.Ends[i] + offset})
+	}
+	return chars
+}
+
+// splitTimedAudio decodes with-timestamps responses, one per chunk, into
+// their audio and the character timings of the joined audio.
+func splitTimedAudio(parts [][]byte, ext string, opts common.DecodeOptions) ([][]byte, []common.TimedText, error) {
+	audio := make([][]byte, len(parts))
+	var chars []common.TimedText
+	var offset float64
+	for i, part := range parts {
+		var timed timedAudio
+		if err := json.Unmarshal(part, &timed); err != nil {
+			return nil, nil, err
+		}
+		audio[i] = timed.Audio
+		chars = append(chars, timed.Alignment.timings(offset)...)
+		if i+1 < len(parts) {
+			duration, err := common.AudioDuration(timed.Audio, ext, opts)
+			if err != nil {
+				return nil, nil, fmt.Errorf("chunk %d: %w", i+1, err)
+			}
+			offset += duration
+		}
+	}
+	return audio, chars, nil
+}
+
+// copyAudio copies a stream response to w. Timed streams are a sequence of
+// JSON chunks carrying the audio and its character timings, in the given
+// output format.
+func copyAudio(w io.Writer, r io.Reader, timed bool, format string) ([]common.TimedText, error) {
+	if !timed {
+		_, err := io.Copy(w, r)
+		return nil, err
+	}
+	var chars []common.TimedText
+	var chunkStart float64
+	clock := common.NewAudioClock(playbackFormat(format))
+	dec := json.NewDecoder(r)
+	for {
+		var chunk timedAudio
+		if err := dec.Decode(&chunk); err == io.EOF {
+			return chars, nil
+		} else if err != nil {
+			return chars, err
+		}
+		if _, err := w.Write(chunk.Audio); err != nil {
+			return chars, err
+		}
+		chars = common.AppendTimings(chars, chunk.Alignment.timings(0), chunkStart)
+		clock.Write(chunk.Audio)
+		duration, err := clock.Duration()
+		if err != nil {
+			return chars, fmt.Errorf("cannot time audio: %w", err)
+		}
+		chunkStart = duration
+	}
+}
+
 func resolveVoiceID(voice string) string {
 	// Check if it's a known voice name (case-insensitive)
 	if id, ok := defaultVoices[strings.ToLower(voice)]; ok {
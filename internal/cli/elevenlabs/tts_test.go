@@ -2,10 +2,12 @@ package elevenlabs
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -575,3 +577,171 @@ func (m *mockHTTPResponse) toHTTPResponse() *http.Response {
 		Body:       io.NopCloser(strings.NewReader(m.body)),
 	}
 }
+
+func TestPlaybackFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		ext    string
+		rate   int
+	}{
+		{"mp3_44100_128", ".mp3", 44100},
+		{"pcm_24000", ".pcm", 24000},
+		{"opus_48000_64", ".ogg", 48000},
+		{"wav_16000", ".wav", 16000},
+	}
+	for _, tt := range tests {
+		ext, opts := playbackFormat(tt.format)
+		if ext != tt.ext || opts.SampleRate != tt.rate {
+			t.Errorf("playbackFormat(%q) = %q, %d, want %q, %d", tt.format, ext, opts.SampleRate, tt.ext, tt.rate)
+		}
+	}
+}
+
+func TestCreateOutput_PCMToWAV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+	w, err := createOutput(path, "pcm_16000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(make([]byte, 10))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if len(data) != 44+10 || string(data[0:4]) != "RIFF" {
+		t.Fatalf("expected a WAV file with 10 bytes of samples, got %d bytes", len(data))
+	}
+	if rate := binary.LittleEndian.Uint32(data[24:28]); rate != 16000 {
+		t.Errorf("expected sample rate 16000, got %d", rate)
+	}
+}
+
+func TestTTS_StreamTextTooLong(t *testing.T) {
+	t.Setenv("ELEVENLABS_API_KEY", "test-key")
+
+	text := strings.Repeat("Hello there. ", 500)
+	cmd := newTTSCmd()
+	_, stderr, err := executeCommand(cmd, text, "-o", "out.mp3", "--stream", "-m", "eleven_v3")
+	if err == nil {
+		t.Fatal("expected error for streaming text over the model limit")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "text_too_long" {
+		t.Errorf("expected error code 'text_too_long', got: %s", errorObj["code"])
+	}
+}
+
+func TestMaxCharacters(t *testing.T) {
+	tests := map[string]int{
+		"eleven_v3":              5000,
+		"eleven_multilingual_v2": 10000,
+		"eleven_flash_v2_5":      40000,
+		"some_future_model":      defaultCharacterLimit,
+	}
+	for model, expected := range tests {
+		if got := maxCharacters(model); got != expected {
+			t.Errorf("maxCharacters(%q) = %d, want %d", model, got, expected)
+		}
+	}
+}
+
+func TestTTS_InvalidTimestamps(t *testing.T) {
+	cmd := newTTSCmd()
+	_, stderr, err := executeCommand(cmd, "Hello", "-o", "out.mp3", "--timestamps=phoneme")
+	if err == nil {
+		t.Fatal("expected error for invalid timestamps")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_timestamps" {
+		t.Errorf("expected error code 'invalid_timestamps', got: %s", errorObj["code"])
+	}
+}
+
+func TestTTS_InvalidSubtitles(t *testing.T) {
+	cmd := newTTSCmd()
+	_, stderr, err := executeCommand(cmd, "Hello", "-o", "out.mp3", "--subtitles", "out.txt")
+	if err == nil {
+		t.Fatal("expected error for invalid subtitles path")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_subtitles" {
+		t.Errorf("expected error code 'invalid_subtitles', got: %s", errorObj["code"])
+	}
+}
+
+func TestTTS_TimestampsDefaultGranularity(t *testing.T) {
+	cmd := newTTSCmd()
+	if err := cmd.ParseFlags([]string{"--timestamps"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cmd.Flags().GetString("timestamps"); got != "word" {
+		t.Errorf("expected bare --timestamps to mean word, got %q", got)
+	}
+}
+
+func TestSplitTimedAudio(t *testing.T) {
+	// Two chunks of 0.5 s of 16 kHz PCM
+	part := func(text string) []byte {
+		data, _ := json.Marshal(map[string]any{
+			"audio_base64": make([]byte, 16000),
+			"alignment": map[string]any{
+				"characters":                    strings.Split(text, ""),
+				"character_start_times_seconds": []float64{0, 0.2},
+				"character_end_times_seconds":   []float64{0.2, 0.4},
+			},
+		})
+		return data
+	}
+
+	audio, chars, err := splitTimedAudio([][]byte{part("Hi"), part("yo")}, ".pcm", common.DecodeOptions{SampleRate: 16000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audio) != 2 || len(audio[1]) != 16000 {
+		t.Fatalf("expected the decoded audio of both chunks, got %d parts", len(audio))
+	}
+	if len(chars) != 4 {
+		t.Fatalf("expected 4 characters, got %d", len(chars))
+	}
+	if chars[2].Text != "y" || chars[2].Start != 0.5 || chars[3].End != 0.9 {
+		t.Errorf("expected second chunk offset by 0.5 s, got %+v", chars[2:])
+	}
+}
+
+func TestCopyAudio_Timed(t *testing.T) {
+	stream := `{"audio_base64":"AQI=","alignment":{"characters":["H","i"],"character_start_times_seconds":[0,0.1],"character_end_times_seconds":[0.1,0.2]}}
+{"audio_base64":"AwQ=","alignment":null}
+{"audio_base64":"BQY=","alignment":{"characters":["!"],"character_start_times_seconds":[0],"character_end_times_seconds":[0.1]}}
+`
+	var out bytes.Buffer
+	chars, err := copyAudio(&out, strings.NewReader(stream), true, "pcm_16000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), []byte{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("unexpected audio: %v", out.Bytes())
+	}
+	if len(chars) != 3 || chars[1].Text != "i" {
+		t.Fatalf("unexpected characters: %+v", chars)
+	}
+	// The restarted timeline follows the 2 samples of audio before it
+	if chars[2].Start != 2.0/16000 {
+		t.Errorf("expected restarted timings shifted by the audio duration, got %+v", chars[2])
+	}
+}
@@ -278,12 +278,8 @@ func runVoiceDesign(cmd *cobra.Command, args []string, flags *voiceDesignFlags)
 			return common.WriteError(cmd, "decode_error", fmt.Sprintf("cannot decode audio: %s", err.Error()))
 		}
 
-		absPath, err := filepath.Abs(flags.output)
+		absPath, err := common.WriteOutput(flags.output, audioData, common.OutputVars{})
 		if err != nil {
-			absPath = flags.output
-		}
-
-		if err := os.WriteFile(absPath, audioData, 0644); err != nil {
 			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
 		}
 		result.File = absPath
@@ -494,7 +490,7 @@ func runVoicePreview(cmd *cobra.Command, args []string, flags *voicePreviewFlags
 	}
 
 	// Write to file
-	outFile, err := os.Create(absPath)
+	outFile, err := common.CreateOutput(absPath)
 	if err != nil {
 		if useTempFile {
 			os.Remove(outputPath)
@@ -0,0 +1,586 @@
+package google
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/spf13/cobra"
+	"google.golang.org/genai"
+)
+
+// batchJobInfo describes a batch job
+type batchJobInfo struct {
+	BatchID     string `json:"batch_id"`
+	DisplayName string `json:"display_name,omitempty"`
+	Status      string `json:"status"`
+	Type        string `json:"type,omitempty"`
+	Model       string `json:"model,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+	EndedAt     string `json:"ended_at,omitempty"`
+	Error       string `json:"error_message,omitempty"`
+}
+
+type batchResult struct {
+	Key   string `json:"key"`
+	File  string `json:"file,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+var batchCmd = newBatchCmd()
+
+func newBatchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "batch",
+		Short: "Run image, TTS and transcription requests with the Gemini Batch API",
+		Long: `Run many image, TTS or transcription requests as one Gemini batch job, at half
+the price of interactive calls. Jobs usually finish within hours and at most in
+24 hours. Save the batch_id returned by 'create' to check status and download
+the results.`,
+	}
+
+	cmd.AddCommand(newBatchCreateCmd())
+	cmd.AddCommand(newBatchStatusCmd())
+	cmd.AddCommand(newBatchListCmd())
+	cmd.AddCommand(newBatchCancelCmd())
+	cmd.AddCommand(newBatchDownloadCmd())
+
+	return cmd
+}
+
+// ===== Create Command =====
+
+type batchCreateFlags struct {
+	kind string
+	name string
+}
+
+func newBatchCreateCmd() *cobra.Command {
+	flags := &batchCreateFlags{}
+
+	cmd := &cobra.Command{
+		Use:           "create <requests.jsonl>",
+		Short:         "Submit a JSONL file of image, tts or stt requests as a batch job",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBatchCreate(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.kind, "type", "t", "", "Request type for lines without one: image, tts, stt")
+	cmd.Flags().StringVar(&flags.name, "name", "", "Display name of the job (default: the file name)")
+
+	return cmd
+}
+
+func runBatchCreate(cmd *cobra.Command, args []string, flags *batchCreateFlags) error {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		return common.WriteError(cmd, "missing_request_file", "request file is required")
+	}
+	path := args[0]
+	if flags.kind != "" {
+		if _, ok := batchModels[flags.kind]; !ok {
+			return common.WriteError(cmd, "invalid_type", fmt.Sprintf("invalid type '%s', use image, tts or stt", flags.kind))
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot open request file: %s", err.Error()))
+	}
+	lines, err := readBatchLines(f, flags.kind)
+	f.Close()
+	if err != nil {
+		return writeBatchLineError(cmd, err)
+	}
+
+	ctx := context.Background()
+	client, err := newBatchClient(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	// Build and upload the requests
+	upload := func(ctx context.Context, path, mimeType string) (*genai.File, error) {
+		return client.Files.UploadFromPath(ctx, path, &genai.UploadFileConfig{MIMEType: mimeType})
+	}
+	var buf bytes.Buffer
+	if err := writeBatchRequests(ctx, &buf, lines, upload); err != nil {
+		return writeBatchLineError(cmd, err)
+	}
+
+	name := flags.name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	input, err := client.Files.Upload(ctx, &buf, &genai.UploadFileConfig{MIMEType: "jsonl", DisplayName: name})
+	if err != nil {
+		return common.WriteError(cmd, "upload_error", fmt.Sprintf("failed to upload requests: %s", err.Error()))
+	}
+
+	job, err := client.Batches.Create(ctx, lines[0].modelID, &genai.BatchJobSource{FileName: input.Name}, &genai.CreateBatchJobConfig{DisplayName: name})
+	if err != nil {
+		return handleBatchError(cmd, err)
+	}
+
+	info := newBatchJobInfo(job)
+	if info.Model == "" {
+		info.Model = lines[0].modelID
+	}
+	info.Type = lines[0].Type
+	return common.WriteSuccess(cmd, struct {
+		Success bool `json:"success"`
+		batchJobInfo
+		Requests  int    `json:"requests"`
+		InputFile string `json:"input_file"`
+	}{true, info, len(lines), input.Name})
+}
+
+// writeBatchLineError writes an invalid request with its own code.
+func writeBatchLineError(cmd *cobra.Command, err error) error {
+	var lineErr *batchLineError
+	if errors.As(err, &lineErr) {
+		return common.WriteError(cmd, lineErr.code, lineErr.message)
+	}
+	return common.WriteError(cmd, "invalid_request_file", err.Error())
+}
+
+// ===== Status Command =====
+
+func newBatchStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "status <batch_id>",
+		Short:         "Get the status of a batch job",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBatchStatus(cmd, args)
+		},
+	}
+
+	return cmd
+}
+
+func runBatchStatus(cmd *cobra.Command, args []string) error {
+	batchID, err := batchIDArg(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := newBatchClient(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	job, err := client.Batches.Get(ctx, batchID, nil)
+	if err != nil {
+		return handleBatchError(cmd, err)
+	}
+
+	return common.WriteSuccess(cmd, struct {
+		Success bool `json:"success"`
+		batchJobInfo
+	}{true, newBatchJobInfo(job)})
+}
+
+// ===== List Command =====
+
+type batchListFlags struct {
+	limit     int
+	pageToken string
+}
+
+func newBatchListCmd() *cobra.Command {
+	flags := &batchListFlags{}
+
+	cmd := &cobra.Command{
+		Use:           "list",
+		Short:         "List batch jobs",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBatchList(cmd, flags)
+		},
+	}
+
+	cmd.Flags().IntVarP(&flags.limit, "limit", "l", 20, "Jobs per page (1-100)")
+	cmd.Flags().StringVar(&flags.pageToken, "page-token", "", "Page token from a previous list")
+
+	return cmd
+}
+
+func runBatchList(cmd *cobra.Command, flags *batchListFlags) error {
+	if flags.limit < 1 || flags.limit > 100 {
+		return common.WriteError(cmd, "invalid_parameter", "--limit must be between 1 and 100")
+	}
+
+	ctx := context.Background()
+	client, err := newBatchClient(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	page, err := client.Batches.List(ctx, &genai.ListBatchJobsConfig{PageSize: int32(flags.limit), PageToken: flags.pageToken})
+	if err != nil {
+		return handleBatchError(cmd, err)
+	}
+
+	batches := make([]batchJobInfo, 0, len(page.Items))
+	for _, job := range page.Items {
+		batches = append(batches, newBatchJobInfo(job))
+	}
+	resp := map[string]any{
+		"success": true,
+		"batches": batches,
+		"count":   len(batches),
+	}
+	if page.NextPageToken != "" {
+		resp["next_page_token"] = page.NextPageToken
+	}
+	return common.WriteSuccess(cmd, resp)
+}
+
+// ===== Cancel Command =====
+
+func newBatchCancelCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "cancel <batch_id>",
+		Short:         "Cancel a pending or running batch job",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBatchCancel(cmd, args)
+		},
+	}
+
+	return cmd
+}
+
+func runBatchCancel(cmd *cobra.Command, args []string) error {
+	batchID, err := batchIDArg(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := newBatchClient(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Batches.Cancel(ctx, batchID, nil); err != nil {
+		return handleBatchError(cmd, err)
+	}
+	job, err := client.Batches.Get(ctx, batchID, nil)
+	if err != nil {
+		return handleBatchError(cmd, err)
+	}
+
+	return common.WriteSuccess(cmd, struct {
+		Success bool `json:"success"`
+		batchJobInfo
+	}{true, newBatchJobInfo(job)})
+}
+
+// ===== Download Command =====
+
+type batchDownloadFlags struct {
+	output   string
+	format   string
+	download common.DownloadFlags
+}
+
+func newBatchDownloadCmd() *cobra.Command {
+	flags := &batchDownloadFlags{}
+
+	cmd := &cobra.Command{
+		Use:           "download <batch_id>",
+		Short:         "Save the results of a finished batch job, one file per request key",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBatchDownload(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output directory")
+	cmd.Flags().StringVarP(&flags.format, "format", "f", common.TranscriptJSON, "Transcript format for stt results: json, txt, srt, vtt, ass, ttml")
+	common.AddDownloadFlags(cmd, &flags.download)
+
+	return cmd
+}
+
+func runBatchDownload(cmd *cobra.Command, args []string, flags *batchDownloadFlags) error {
+	batchID, err := batchIDArg(cmd, args)
+	if err != nil {
+		return err
+	}
+	if flags.output == "" {
+		return common.WriteError(cmd, "missing_output", "output directory is required, use -o flag")
+	}
+	if common.TranscriptFormat("."+flags.format, "") == "" {
+		return common.WriteError(cmd, "invalid_format", fmt.Sprintf("invalid format '%s', use json, txt, srt, vtt, ass or ttml", flags.format))
+	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
+
+	ctx := context.Background()
+	client, err := newBatchClient(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	job, err := client.Batches.Get(ctx, batchID, nil)
+	if err != nil {
+		return handleBatchError(cmd, err)
+	}
+	info := newBatchJobInfo(job)
+	switch job.State {
+	case genai.JobStateSucceeded:
+	case genai.JobStatePending, genai.JobStateQueued, genai.JobStateRunning, genai.JobStateUpdating:
+		return common.WriteError(cmd, "batch_not_ready", fmt.Sprintf("batch is not finished, current status: %s", info.Status))
+	default:
+		msg := fmt.Sprintf("batch did not succeed, status: %s", info.Status)
+		if info.Error != "" {
+			msg += ": " + info.Error
+		}
+		return common.WriteError(cmd, "batch_failed", msg)
+	}
+	if info.Type == "" {
+		return common.WriteError(cmd, "unsupported_model", fmt.Sprintf("cannot unpack results of model '%s'", info.Model))
+	}
+
+	// Results come in a file, or inline for jobs created with inline requests
+	var responses []batchResponse
+	if job.Dest != nil && job.Dest.FileName != "" {
+		data, err := client.Files.Download(ctx, &genai.File{DownloadURI: job.Dest.FileName}, nil)
+		if err != nil {
+			return handleBatchError(cmd, err)
+		}
+		if responses, err = decodeBatchResponses(bytes.NewReader(data)); err != nil {
+			return common.WriteError(cmd, "invalid_response", fmt.Sprintf("cannot read batch results: %s", err.Error()))
+		}
+	} else if job.Dest != nil {
+		for i, inlined := range job.Dest.InlinedResponses {
+			resp := batchResponse{Key: inlined.Metadata["key"], Response: inlined.Response}
+			if resp.Key == "" {
+				resp.Key = fmt.Sprint(i + 1)
+			}
+			if inlined.Error != nil {
+				resp.Error = &batchResponseError{Message: inlined.Error.Message}
+			}
+			responses = append(responses, resp)
+		}
+	}
+	if len(responses) == 0 {
+		return common.WriteError(cmd, "no_results", "batch has no results")
+	}
+
+	flags.output = common.ResolveOutput(flags.output, common.OutputVars{TaskID: filepath.Base(batchID)})
+	if err := os.MkdirAll(flags.output, 0755); err != nil {
+		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output directory: %s", err.Error()))
+	}
+
+	results := make([]batchResult, 0, len(responses))
+	failed := 0
+	for i, resp := range responses {
+		// Keys become file names, so only safe ones are kept
+		key := resp.Key
+		if !batchKeyPattern.MatchString(key) {
+			key = fmt.Sprintf("request-%d", i+1)
+		}
+		result := batchResult{Key: resp.Key}
+		saved, err := saveBatchResponse(info.Type, resp, filepath.Join(flags.output, key), flags)
+		if err != nil {
+			result.Error = err.Error()
+			failed++
+		} else {
+			result.File = saved.Path
+		}
+		results = append(results, result)
+	}
+
+	return common.WriteSuccess(cmd, map[string]any{
+		"success":   true,
+		"batch_id":  info.BatchID,
+		"type":      info.Type,
+		"results":   results,
+		"succeeded": len(results) - failed,
+		"failed":    failed,
+	})
+}
+
+// batchResponse is a line of the results file of a batch job.
+type batchResponse struct {
+	Key      string                         `json:"key"`
+	Response *genai.GenerateContentResponse `json:"response,omitempty"`
+	Error    *batchResponseError            `json:"error,omitempty"`
+}
+
+type batchResponseError struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+func decodeBatchResponses(r io.Reader) ([]batchResponse, error) {
+	var responses []batchResponse
+	dec := json.NewDecoder(r)
+	for {
+		var resp batchResponse
+		if err := dec.Decode(&resp); err == io.EOF {
+			return responses, nil
+		} else if err != nil {
+			return nil, err
+		}
+		responses = append(responses, resp)
+	}
+}
+
+// saveBatchResponse writes one result to base plus the extension of its
+// kind: the image, the TTS audio as WAV, or the transcript.
+func saveBatchResponse(kind string, resp batchResponse, base string, flags *batchDownloadFlags) (*common.DownloadResult, error) {
+	if resp.Error != nil {
+		return nil, fmt.Errorf("request failed: %s", resp.Error.Message)
+	}
+	if resp.Response == nil || len(resp.Response.Candidates) == 0 || resp.Response.Candidates[0].Content == nil {
+		if resp.Response != nil && resp.Response.PromptFeedback != nil && resp.Response.PromptFeedback.BlockReason != "" {
+			return nil, fmt.Errorf("prompt blocked: %s", resp.Response.PromptFeedback.BlockReason)
+		}
+		return nil, fmt.Errorf("no content in response")
+	}
+
+	candidate := resp.Response.Candidates[0]
+	switch kind {
+	case "image":
+		part := inlinePart(candidate.Content, "image/")
+		if part == nil {
+			return nil, missingOutputError("image", candidate)
+		}
+		ext := common.ExtFromContentType(part.InlineData.MIMEType)
+		if ext == "" {
+			ext = ".png"
+		}
+		return common.SaveBytes(part.InlineData.Data, base+ext, common.DownloadOptions{DownloadFlags: flags.download})
+
+	case "tts":
+		part := inlinePart(candidate.Content, "audio/")
+		if part == nil {
+			return nil, missingOutputError("audio", candidate)
+		}
+		return common.SaveBytes(common.PCMToWAV(part.InlineData.Data, pcmFormat), base+".wav", common.DownloadOptions{DownloadFlags: flags.download})
+
+	default:
+		geminiResp, err := parseTranscription(resp.Response)
+		if err != nil {
+			return nil, err
+		}
+		data, err := common.FormatTranscript(newTranscript(geminiResp), flags.format, common.SubtitleOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return common.SaveBytes(data, base+"."+flags.format, common.DownloadOptions{DownloadFlags: flags.download})
+	}
+}
+
+// inlinePart returns the first inline data part of the MIME type prefix,
+// skipping thoughts.
+func inlinePart(content *genai.Content, prefix string) *genai.Part {
+	for _, part := range content.Parts {
+		if !part.Thought && part.InlineData != nil && strings.HasPrefix(part.InlineData.MIMEType, prefix) {
+			return part
+		}
+	}
+	return nil
+}
+
+func missingOutputError(what string, candidate *genai.Candidate) error {
+	if candidate.FinishReason != "" && candidate.FinishReason != genai.FinishReasonStop {
+		return fmt.Errorf("no %s in response, finish reason: %s", what, candidate.FinishReason)
+	}
+	return fmt.Errorf("no %s in response", what)
+}
+
+// ===== Helpers =====
+
+// newBatchJobInfo describes a job, with its status in lowercase
+// (pending, running, succeeded, failed, cancelled, expired).
+func newBatchJobInfo(job *genai.BatchJob) batchJobInfo {
+	info := batchJobInfo{
+		BatchID:     job.Name,
+		DisplayName: job.DisplayName,
+		Status:      strings.ToLower(strings.TrimPrefix(string(job.State), "JOB_STATE_")),
+		Model:       strings.TrimPrefix(job.Model, "models/"),
+		CreatedAt:   formatBatchTime(job.CreateTime),
+		UpdatedAt:   formatBatchTime(job.UpdateTime),
+		EndedAt:     formatBatchTime(job.EndTime),
+	}
+	for kind, models := range batchModels {
+		for _, id := range models {
+			if id == info.Model {
+				info.Type = kind
+			}
+		}
+	}
+	if job.Error != nil {
+		info.Error = job.Error.Message
+	}
+	return info
+}
+
+func formatBatchTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// batchIDArg reads the batch ID, which may be given without "batches/".
+// Errors are written to the command.
+func batchIDArg(cmd *cobra.Command, args []string) (string, error) {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		return "", common.WriteError(cmd, "missing_batch_id", "batch_id is required")
+	}
+	batchID := strings.TrimSpace(args[0])
+	if !strings.HasPrefix(batchID, "batches/") {
+		batchID = "batches/" + batchID
+	}
+	return batchID, nil
+}
+
+// newBatchClient creates a Gemini API client; batches are not available on
+// Vertex AI through this command. Errors are written to the command.
+func newBatchClient(ctx context.Context, cmd *cobra.Command) (*genai.Client, error) {
+	apiKey := config.GetAPIKey("GEMINI_API_KEY", "GOOGLE_API_KEY")
+	if apiKey == "" {
+		return nil, common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("GEMINI_API_KEY", "GOOGLE_API_KEY"))
+	}
+
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return nil, common.WriteError(cmd, "client_error", fmt.Sprintf("failed to create client: %s", err.Error()))
+	}
+	return client, nil
+}
+
+func handleBatchError(cmd *cobra.Command, err error) error {
+	if strings.Contains(err.Error(), "404") {
+		return common.WriteError(cmd, "batch_not_found", "batch or its results do not exist")
+	}
+	return handleAPIError(cmd, err)
+}
@@ -0,0 +1,337 @@
+package google
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// Kinds of batch requests, and the models each can run on
+var batchModels = map[string]map[string]string{
+	"image": {"flash": modelIDs["flash"], "pro": modelIDs["pro"]},
+	"tts":   ttsModelIDs,
+	"stt":   {"flash": "gemini-2.5-flash"},
+}
+
+// batchMaxInlineSize is the largest stt audio sent inline, measured as
+// encoded: a request may not exceed 20 MB and base64 grows data by a third,
+// so files over about 14 MB are uploaded with the Files API instead.
+const batchMaxInlineSize = 19 * 1024 * 1024
+
+// Request keys name the result files
+var batchKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// batchLine is one request of a batch input file. Its fields are the flags
+// of the image, tts and stt commands.
+type batchLine struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Model string `json:"model"`
+
+	// image
+	Prompt string   `json:"prompt"`
+	Image  []string `json:"image"`
+	Aspect string   `json:"aspect"`
+	Size   string   `json:"size"`
+	Search bool     `json:"search"`
+
+	// tts
+	Text  string `json:"text"`
+	Voice string `json:"voice"`
+
+	// stt
+	File       string `json:"file"`
+	Language   string `json:"language"`
+	Timestamps bool   `json:"timestamps"`
+
+	// Speakers is "Name=Voice,..." for tts and diarization on/off for stt
+	Speakers any `json:"speakers"`
+
+	line       int
+	modelID    string
+	speakerMap map[string]string
+	diarize    bool
+}
+
+// batchLineError reports an invalid request with the error code the
+// matching command would return.
+type batchLineError struct {
+	code    string
+	message string
+}
+
+func (e *batchLineError) Error() string {
+	return e.message
+}
+
+func lineError(line int, code, format string, args ...any) *batchLineError {
+	return &batchLineError{code: code, message: fmt.Sprintf("line %d: %s", line, fmt.Sprintf(format, args...))}
+}
+
+// batchRequestLine is a line of the JSONL file the Batch API reads, holding
+// a GenerateContentRequest in its REST form.
+type batchRequestLine struct {
+	Key     string       `json:"key"`
+	Request batchRequest `json:"request"`
+}
+
+type batchRequest struct {
+	Contents         []*genai.Content      `json:"contents"`
+	Tools            []*genai.Tool         `json:"tools,omitempty"`
+	GenerationConfig batchGenerationConfig `json:"generationConfig"`
+}
+
+type batchGenerationConfig struct {
+	ResponseModalities []string            `json:"responseModalities,omitempty"`
+	ResponseMIMEType   string              `json:"responseMimeType,omitempty"`
+	ImageConfig        *genai.ImageConfig  `json:"imageConfig,omitempty"`
+	SpeechConfig       *genai.SpeechConfig `json:"speechConfig,omitempty"`
+}
+
+// readBatchLines parses and validates a batch input file. Every request must
+// be of the same kind and run on the same model, as a batch job has one
+// model; kind is the --type flag, if set.
+func readBatchLines(r io.Reader, kind string) ([]*batchLine, error) {
+	var lines []*batchLine
+	keys := map[string]int{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		line := &batchLine{line: n}
+		if err := json.Unmarshal([]byte(text), line); err != nil {
+			return nil, lineError(n, "invalid_request_file", "invalid JSON: %s", err.Error())
+		}
+
+		// Kind and model, shared by all requests
+		if line.Type == "" {
+			line.Type = kind
+		}
+		models, ok := batchModels[line.Type]
+		if !ok {
+			if line.Type == "" {
+				return nil, lineError(n, "missing_type", "request type is required, set \"type\" or use --type")
+			}
+			return nil, lineError(n, "invalid_type", "invalid type '%s', use image, tts or stt", line.Type)
+		}
+		if kind == "" {
+			kind = line.Type
+		} else if line.Type != kind {
+			return nil, lineError(n, "mixed_types", "type '%s' differs from '%s', a batch holds one type of request", line.Type, kind)
+		}
+		if line.Model == "" {
+			line.Model = "flash"
+		}
+		if line.modelID, ok = models[line.Model]; !ok {
+			return nil, lineError(n, "invalid_model", "invalid %s model '%s' for a batch", line.Type, line.Model)
+		}
+		if len(lines) > 0 && line.modelID != lines[0].modelID {
+			return nil, lineError(n, "mixed_models", "model '%s' differs from '%s', a batch runs on one model", line.Model, lines[0].Model)
+		}
+
+		// Keys default to the request number
+		if line.Key == "" {
+			line.Key = strconv.Itoa(len(lines) + 1)
+		}
+		if !batchKeyPattern.MatchString(line.Key) {
+			return nil, lineError(n, "invalid_key", "invalid key '%s', use letters, digits, '.', '-' or '_'", line.Key)
+		}
+		if first, ok := keys[line.Key]; ok {
+			return nil, lineError(n, "duplicate_key", "key '%s' is already used on line %d", line.Key, first)
+		}
+		keys[line.Key] = n
+
+		var err *batchLineError
+		switch line.Type {
+		case "image":
+			err = validateBatchImage(line)
+		case "tts":
+			err = validateBatchTTS(line)
+		case "stt":
+			err = validateBatchSTT(line)
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &batchLineError{code: "invalid_request_file", message: fmt.Sprintf("cannot read request file: %s", err.Error())}
+	}
+	if len(lines) == 0 {
+		return nil, &batchLineError{code: "invalid_request_file", message: "request file has no requests"}
+	}
+	return lines, nil
+}
+
+func validateBatchImage(line *batchLine) *batchLineError {
+	if strings.TrimSpace(line.Prompt) == "" {
+		return lineError(line.line, "missing_prompt", "prompt is required")
+	}
+	if line.Aspect == "" {
+		line.Aspect = "1:1"
+	}
+	if !validAspects[line.Aspect] {
+		return lineError(line.line, "invalid_aspect", "invalid aspect ratio '%s'", line.Aspect)
+	}
+	if line.Size == "" {
+		line.Size = "1K"
+	}
+	if !validSizes[line.Size] {
+		return lineError(line.line, "invalid_size", "invalid size '%s', use '1K', '2K', or '4K'", line.Size)
+	}
+	if line.Size != "1K" && line.Model != "pro" {
+		return lineError(line.line, "size_requires_pro", "size 2K/4K requires model pro")
+	}
+	if line.Search && line.Model != "pro" {
+		return lineError(line.line, "search_requires_pro", "search requires model pro")
+	}
+	if maxImg := maxImages[line.Model]; len(line.Image) > maxImg {
+		return lineError(line.line, "too_many_images", "maximum %d reference images allowed for model '%s'", maxImg, line.Model)
+	}
+	for _, img := range line.Image {
+		if _, err := os.Stat(img); err != nil {
+			return lineError(line.line, "image_not_found", "image file not found: %s", img)
+		}
+	}
+	return nil
+}
+
+func validateBatchTTS(line *batchLine) *batchLineError {
+	if strings.TrimSpace(line.Text) == "" {
+		return lineError(line.line, "missing_text", "text is required")
+	}
+	switch speakers := line.Speakers.(type) {
+	case nil:
+	case string:
+		if line.Voice != "" {
+			return lineError(line.line, "conflicting_flags", "cannot use voice and speakers together")
+		}
+		speakerMap, err := parseSpeakers(speakers)
+		if err != nil {
+			return lineError(line.line, "invalid_speakers", "%s", err.Error())
+		}
+		if len(speakerMap) > 2 {
+			return lineError(line.line, "too_many_speakers", "maximum 2 speakers allowed")
+		}
+		line.speakerMap = speakerMap
+		return nil
+	default:
+		return lineError(line.line, "invalid_speakers", "speakers must be a string like \"Name1=Voice1,Name2=Voice2\"")
+	}
+	if line.Voice == "" {
+		line.Voice = "Kore"
+	}
+	if !validVoices[line.Voice] {
+		return lineError(line.line, "invalid_voice", "voice '%s' is not a valid prebuilt voice", line.Voice)
+	}
+	return nil
+}
+
+func validateBatchSTT(line *batchLine) *batchLineError {
+	if line.File == "" {
+		return lineError(line.line, "missing_input", "file is required")
+	}
+	info, err := os.Stat(line.File)
+	if err != nil {
+		return lineError(line.line, "file_not_found", "audio file '%s' does not exist", line.File)
+	}
+	ext := strings.ToLower(filepath.Ext(line.File))
+	if _, ok := sttSupportedFormats[ext]; !ok {
+		return lineError(line.line, "unsupported_format", "unsupported audio format '%s'", ext)
+	}
+	if info.Size() > 100*1024*1024 {
+		return lineError(line.line, "file_too_large", "audio file size %d bytes exceeds 100 MB limit", info.Size())
+	}
+	switch speakers := line.Speakers.(type) {
+	case nil:
+	case bool:
+		line.diarize = speakers
+	default:
+		return lineError(line.line, "invalid_parameter", "speakers must be true or false for stt")
+	}
+	return nil
+}
+
+// writeBatchRequests writes the requests in the form the Batch API reads.
+// Images and audio are sent inline; audio over the inline limit is uploaded
+// with upload and referenced.
+func writeBatchRequests(ctx context.Context, w io.Writer, lines []*batchLine, upload func(ctx context.Context, path, mimeType string) (*genai.File, error)) error {
+	enc := json.NewEncoder(w)
+	for _, line := range lines {
+		req, err := buildBatchRequest(ctx, line, upload)
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(batchRequestLine{Key: line.Key, Request: *req}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func buildBatchRequest(ctx context.Context, line *batchLine, upload func(ctx context.Context, path, mimeType string) (*genai.File, error)) (*batchRequest, error) {
+	req := &batchRequest{}
+	switch line.Type {
+	case "image":
+		parts := []*genai.Part{genai.NewPartFromText(line.Prompt)}
+		for _, img := range line.Image {
+			data, err := os.ReadFile(img)
+			if err != nil {
+				return nil, lineError(line.line, "image_not_found", "cannot read image file: %s", err.Error())
+			}
+			parts = append(parts, genai.NewPartFromBytes(data, getMimeType(img)))
+		}
+		req.Contents = []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)}
+		req.GenerationConfig.ResponseModalities = []string{"IMAGE"}
+		req.GenerationConfig.ImageConfig = &genai.ImageConfig{AspectRatio: line.Aspect}
+		if line.Model == "pro" {
+			req.GenerationConfig.ImageConfig.ImageSize = line.Size
+		}
+		if line.Search {
+			req.Tools = []*genai.Tool{{GoogleSearch: &genai.GoogleSearch{}}}
+		}
+
+	case "tts":
+		req.Contents = genai.Text(line.Text)
+		req.GenerationConfig.ResponseModalities = []string{"AUDIO"}
+		req.GenerationConfig.SpeechConfig = newSpeechConfig(line.Voice, line.speakerMap)
+
+	case "stt":
+		mimeType := sttSupportedFormats[strings.ToLower(filepath.Ext(line.File))]
+		var audio *genai.Part
+		if info, err := os.Stat(line.File); err == nil && inlineSize(info.Size()) > batchMaxInlineSize {
+			file, err := upload(ctx, line.File, mimeType)
+			if err != nil {
+				return nil, lineError(line.line, "upload_error", "failed to upload audio file: %s", err.Error())
+			}
+			audio = genai.NewPartFromFile(*file)
+		} else {
+			data, err := os.ReadFile(line.File)
+			if err != nil {
+				return nil, lineError(line.line, "file_not_found", "cannot read audio file: %s", err.Error())
+			}
+			audio = genai.NewPartFromBytes(data, mimeType)
+		}
+		prompt := buildTranscriptionPrompt(line.Language, line.Timestamps, line.diarize)
+		req.Contents = []*genai.Content{genai.NewContentFromParts([]*genai.Part{genai.NewPartFromText(prompt), audio}, genai.RoleUser)}
+		req.GenerationConfig.ResponseMIMEType = "application/json"
+	}
+	return req, nil
+}
@@ -0,0 +1,348 @@
+package google
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"google.golang.org/genai"
+)
+
+// pngData is enough of a PNG to pass the container check
+var pngData = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+func writeRequestFile(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// batchServer fakes the Files and Batch endpoints of the Gemini API. It
+// serves job for batch lookups and results for the result file download.
+type batchServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests map[string]string
+	job      string
+	results  string
+}
+
+func newBatchServer(t *testing.T) *batchServer {
+	s := &batchServer{requests: map[string]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		key := r.Method + " " + r.URL.Path
+		s.requests[key] = string(body)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case key == "POST /upload/v1beta/files":
+			w.Header().Set("X-Goog-Upload-Url", s.URL+"/upload-session")
+			w.Write([]byte(`{}`))
+		case key == "POST /upload-session":
+			w.Header().Set("X-Goog-Upload-Status", "final")
+			w.Write([]byte(`{"file":{"name":"files/input1"}}`))
+		case strings.HasSuffix(key, ":batchGenerateContent"):
+			w.Write([]byte(`{"name":"batches/b1","metadata":{"displayName":"requests","state":"BATCH_STATE_PENDING","model":"models/gemini-2.5-flash-image","createTime":"2026-10-18T09:00:00Z"}}`))
+		case key == "POST /v1beta/batches/b1:cancel":
+			s.job = `{"name":"batches/b1","metadata":{"state":"BATCH_STATE_CANCELLED","model":"models/gemini-2.5-flash-image"}}`
+			w.Write([]byte(`{}`))
+		case key == "GET /v1beta/batches/b1":
+			w.Write([]byte(s.job))
+		case key == "GET /v1beta/batches":
+			w.Write([]byte(`{"operations":[` + s.job + `],"nextPageToken":"next"}`))
+		case strings.HasSuffix(key, "/files/out1:download"):
+			w.Write([]byte(s.results))
+		default:
+			t.Errorf("unexpected request: %s", key)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"not found"}}`))
+		}
+	}))
+	t.Cleanup(s.Close)
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", s.URL)
+	return s
+}
+
+func TestBatchCreate_Validation(t *testing.T) {
+	img := filepath.Join(t.TempDir(), "ref.png")
+	os.WriteFile(img, pngData, 0644)
+
+	tests := []struct {
+		name  string
+		lines []string
+		args  []string
+		code  string
+	}{
+		{"invalid json", []string{`{"prompt":`}, nil, "invalid_request_file"},
+		{"empty", []string{""}, nil, "invalid_request_file"},
+		{"missing type", []string{`{"prompt":"A cat"}`}, nil, "missing_type"},
+		{"invalid type flag", []string{`{"prompt":"A cat"}`}, []string{"--type", "video"}, "invalid_type"},
+		{"mixed types", []string{`{"type":"image","prompt":"A cat"}`, `{"type":"tts","text":"Hi"}`}, nil, "mixed_types"},
+		{"mixed models", []string{`{"prompt":"A cat"}`, `{"prompt":"A dog","model":"pro"}`}, []string{"-t", "image"}, "mixed_models"},
+		{"imagen", []string{`{"prompt":"A cat","model":"imagen-4"}`}, []string{"-t", "image"}, "invalid_model"},
+		{"duplicate key", []string{`{"key":"a","prompt":"A cat"}`, `{"key":"a","prompt":"A dog"}`}, []string{"-t", "image"}, "duplicate_key"},
+		{"invalid key", []string{`{"key":"../a","prompt":"A cat"}`}, []string{"-t", "image"}, "invalid_key"},
+		{"missing prompt", []string{`{"aspect":"1:1"}`}, []string{"-t", "image"}, "missing_prompt"},
+		{"invalid aspect", []string{`{"prompt":"A cat","aspect":"1:2"}`}, []string{"-t", "image"}, "invalid_aspect"},
+		{"size requires pro", []string{`{"prompt":"A cat","size":"2K"}`}, []string{"-t", "image"}, "size_requires_pro"},
+		{"image not found", []string{`{"prompt":"A cat","image":["/nonexistent.png"]}`}, []string{"-t", "image"}, "image_not_found"},
+		{"invalid voice", []string{`{"text":"Hi","voice":"Nobody"}`}, []string{"-t", "tts"}, "invalid_voice"},
+		{"voice and speakers", []string{`{"text":"Hi","voice":"Puck","speakers":"A=Kore"}`}, []string{"-t", "tts"}, "conflicting_flags"},
+		{"stt file not found", []string{`{"file":"/nonexistent.mp3"}`}, []string{"-t", "stt"}, "file_not_found"},
+		{"stt format", []string{`{"file":"` + img + `"}`}, []string{"-t", "stt"}, "unsupported_format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			t.Setenv("GEMINI_API_KEY", "")
+			t.Setenv("GOOGLE_API_KEY", "")
+			args := append([]string{"create", writeRequestFile(t, tt.lines...)}, tt.args...)
+			_, stderr, err := executeCommand(newBatchCmd(), args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+func TestBatchCreate_MissingFile(t *testing.T) {
+	_, stderr, err := executeCommand(newBatchCmd(), "create")
+	if err == nil || !strings.Contains(stderr, `"code":"missing_request_file"`) {
+		t.Errorf("expected missing_request_file, got: %s", stderr)
+	}
+}
+
+func TestBatchCreate(t *testing.T) {
+	server := newBatchServer(t)
+
+	img := filepath.Join(t.TempDir(), "ref.png")
+	os.WriteFile(img, pngData, 0644)
+	path := writeRequestFile(t,
+		`{"key":"cat","type":"image","prompt":"A cute cat","aspect":"16:9","image":["`+img+`"]}`,
+		``,
+		`{"type":"image","prompt":"A dog"}`,
+	)
+
+	stdout, stderr, err := executeCommand(newBatchCmd(), "create", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var resp map[string]any
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp["batch_id"] != "batches/b1" || resp["status"] != "pending" || resp["type"] != "image" || resp["requests"] != float64(2) || resp["input_file"] != "files/input1" {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	create := server.requests["POST /v1beta/models/gemini-2.5-flash-image:batchGenerateContent"]
+	if !strings.Contains(create, `"fileName":"files/input1"`) || !strings.Contains(create, `"displayName":"requests"`) {
+		t.Errorf("unexpected create request: %s", create)
+	}
+
+	// The uploaded file holds one GenerateContentRequest per key
+	var lines []batchRequestLine
+	scanner := bufio.NewScanner(strings.NewReader(server.requests["POST /upload-session"]))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var line batchRequestLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid request line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[0].Key != "cat" || lines[1].Key != "2" {
+		t.Fatalf("unexpected request lines: %+v", lines)
+	}
+	first := lines[0].Request
+	if first.GenerationConfig.ImageConfig.AspectRatio != "16:9" || first.GenerationConfig.ResponseModalities[0] != "IMAGE" {
+		t.Errorf("unexpected generation config: %+v", first.GenerationConfig)
+	}
+	parts := first.Contents[0].Parts
+	if len(parts) != 2 || parts[0].Text != "A cute cat" || !bytes.Equal(parts[1].InlineData.Data, pngData) || parts[1].InlineData.MIMEType != "image/png" {
+		t.Errorf("unexpected contents: %+v", first.Contents[0])
+	}
+}
+
+func TestBatchCreate_TTSAndSTTRequests(t *testing.T) {
+	audio := filepath.Join(t.TempDir(), "call.mp3")
+	os.WriteFile(audio, []byte("mp3 data"), 0644)
+
+	lines, err := readBatchLines(strings.NewReader(`{"text":"Hello","speakers":"Joe=Kore,Jane=Puck"}`), "tts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := buildBatchRequest(context.Background(), lines[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines[0].modelID != "gemini-2.5-flash-preview-tts" || req.GenerationConfig.ResponseModalities[0] != "AUDIO" || len(req.GenerationConfig.SpeechConfig.MultiSpeakerVoiceConfig.SpeakerVoiceConfigs) != 2 {
+		t.Errorf("unexpected tts request: %+v", req.GenerationConfig)
+	}
+
+	lines, err = readBatchLines(strings.NewReader(`{"file":"`+audio+`","language":"en","speakers":true}`), "stt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err = buildBatchRequest(context.Background(), lines[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := req.Contents[0].Parts
+	if req.GenerationConfig.ResponseMIMEType != "application/json" || !strings.Contains(parts[0].Text, "Speaker 1") || parts[1].InlineData.MIMEType != "audio/mpeg" {
+		t.Errorf("unexpected stt request: %+v", req)
+	}
+}
+
+func TestBatchCreate_STTUploadsPastInlineLimit(t *testing.T) {
+	dir := t.TempDir()
+	// 14 MB fits in a request once encoded, 15 MB does not
+	small := filepath.Join(dir, "small.wav")
+	os.WriteFile(small, make([]byte, 14*1024*1024), 0644)
+	large := filepath.Join(dir, "large.wav")
+	os.WriteFile(large, make([]byte, 15*1024*1024), 0644)
+
+	var uploaded []string
+	upload := func(ctx context.Context, path, mimeType string) (*genai.File, error) {
+		uploaded = append(uploaded, path)
+		return &genai.File{Name: "files/f1", URI: "https://files/f1", MIMEType: mimeType}, nil
+	}
+	lines, err := readBatchLines(strings.NewReader(`{"file":"`+small+`"}`+"\n"+`{"file":"`+large+`"}`), "stt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inline, err := buildBatchRequest(context.Background(), lines[0], upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadedReq, err := buildBatchRequest(context.Background(), lines[1], upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inline.Contents[0].Parts[1].InlineData == nil {
+		t.Errorf("expected the 14 MB file inline")
+	}
+	if part := uploadedReq.Contents[0].Parts[1]; part.FileData == nil || part.FileData.FileURI != "https://files/f1" {
+		t.Errorf("expected the 15 MB file uploaded, got %+v", part)
+	}
+	if len(uploaded) != 1 || uploaded[0] != large {
+		t.Errorf("unexpected uploads: %v", uploaded)
+	}
+}
+
+func TestBatchStatusListCancel(t *testing.T) {
+	server := newBatchServer(t)
+	server.job = `{"name":"batches/b1","metadata":{"displayName":"night","state":"BATCH_STATE_RUNNING","model":"models/gemini-2.5-flash-preview-tts","createTime":"2026-10-18T09:00:00Z"}}`
+
+	stdout, stderr, err := executeCommand(newBatchCmd(), "status", "b1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var info batchJobInfo
+	json.Unmarshal([]byte(stdout), &info)
+	if info.BatchID != "batches/b1" || info.Status != "running" || info.Type != "tts" || info.DisplayName != "night" || info.CreatedAt != "2026-10-18T09:00:00Z" {
+		t.Errorf("unexpected status: %s", stdout)
+	}
+
+	stdout, _, err = executeCommand(newBatchCmd(), "list", "--limit", "5")
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if !strings.Contains(stdout, `"count":1`) || !strings.Contains(stdout, `"next_page_token":"next"`) {
+		t.Errorf("unexpected list: %s", stdout)
+	}
+
+	stdout, _, err = executeCommand(newBatchCmd(), "cancel", "batches/b1")
+	if err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	if !strings.Contains(stdout, `"status":"cancelled"`) {
+		t.Errorf("unexpected cancel: %s", stdout)
+	}
+}
+
+func TestBatchDownload(t *testing.T) {
+	server := newBatchServer(t)
+	server.job = `{"name":"batches/b1","metadata":{"state":"BATCH_STATE_SUCCEEDED","model":"models/gemini-2.5-flash-image","output":{"responsesFile":"files/out1"}}}`
+	image := base64.StdEncoding.EncodeToString(pngData)
+	server.results = `{"key":"cat","response":{"candidates":[{"content":{"role":"model","parts":[{"text":"A cat"},{"inlineData":{"mimeType":"image/png","data":"` + image + `"}}]}}]}}
+{"key":"dog","error":{"code":3,"message":"invalid argument"}}
+{"key":"fox","response":{"candidates":[{"content":{"role":"model","parts":[{"text":"I cannot draw that"}]},"finishReason":"IMAGE_SAFETY"}]}}
+`
+
+	dir := filepath.Join(t.TempDir(), "out")
+	stdout, stderr, err := executeCommand(newBatchCmd(), "download", "b1", "-o", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var resp struct {
+		Results   []batchResult `json:"results"`
+		Succeeded int           `json:"succeeded"`
+		Failed    int           `json:"failed"`
+	}
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp.Succeeded != 1 || resp.Failed != 2 || len(resp.Results) != 3 {
+		t.Fatalf("unexpected response: %s", stdout)
+	}
+	if want := filepath.Join(dir, "cat.png"); resp.Results[0].File != want {
+		t.Errorf("expected %s, got %s", want, resp.Results[0].File)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "cat.png")); !bytes.Equal(data, pngData) {
+		t.Errorf("unexpected image data: %q", data)
+	}
+	if !strings.Contains(resp.Results[1].Error, "invalid argument") || !strings.Contains(resp.Results[2].Error, "IMAGE_SAFETY") {
+		t.Errorf("unexpected errors: %+v", resp.Results)
+	}
+}
+
+func TestBatchDownload_Transcripts(t *testing.T) {
+	server := newBatchServer(t)
+	server.job = `{"name":"batches/b1","metadata":{"state":"BATCH_STATE_SUCCEEDED","model":"models/gemini-2.5-flash","output":{"responsesFile":"files/out1"}}}`
+	transcript, _ := json.Marshal(`{"text":"Hello there","language":"en","segments":[{"start":"00:00.000","end":"00:01.500","text":"Hello there"}]}`)
+	server.results = `{"key":"call","response":{"candidates":[{"content":{"role":"model","parts":[{"text":` + string(transcript) + `}]}}]}}` + "\n"
+
+	dir := t.TempDir()
+	stdout, stderr, err := executeCommand(newBatchCmd(), "download", "b1", "-o", dir, "--format", "srt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"type":"stt"`) {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "call.srt"))
+	if err != nil || !strings.Contains(string(data), "00:00:00,000 --> 00:00:01,500") {
+		t.Errorf("unexpected subtitles: %q (%v)", data, err)
+	}
+}
+
+func TestBatchDownload_NotReady(t *testing.T) {
+	server := newBatchServer(t)
+	server.job = `{"name":"batches/b1","metadata":{"state":"BATCH_STATE_RUNNING","model":"models/gemini-2.5-flash-image"}}`
+
+	_, stderr, err := executeCommand(newBatchCmd(), "download", "b1", "-o", t.TempDir())
+	if err == nil || !strings.Contains(stderr, `"code":"batch_not_ready"`) {
+		t.Errorf("expected batch_not_ready, got: %s", stderr)
+	}
+}
@@ -0,0 +1,261 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/spf13/cobra"
+	"google.golang.org/genai"
+)
+
+// Request limits of batchEmbedContents: 100 inputs, and images are sent
+// inline, so a request is kept within 20 MB once images are base64 encoded,
+// which grows them by a third. A single image over about 14 MB cannot be sent.
+const (
+	embedMaxInputs = 100
+	embedMaxBytes  = 19 * 1024 * 1024
+)
+
+// Embedding models that only take text
+var embedTextOnlyModels = map[string]bool{
+	"gemini-embedding-001": true,
+	"text-embedding-004":   true,
+}
+
+// Task types that tune embeddings for their use
+var embedTaskTypes = []string{
+	"SEMANTIC_SIMILARITY",
+	"CLASSIFICATION",
+	"CLUSTERING",
+	"RETRIEVAL_DOCUMENT",
+	"RETRIEVAL_QUERY",
+	"CODE_RETRIEVAL_QUERY",
+	"QUESTION_ANSWERING",
+	"FACT_VERIFICATION",
+}
+
+// Embed flags
+type embedFlags struct {
+	file       string
+	output     string
+	images     []string
+	model      string
+	dimensions int
+	task       string
+	title      string
+}
+
+type embedResponse struct {
+	Success    bool   `json:"success"`
+	File       string `json:"file"`
+	Format     string `json:"format"`
+	Model      string `json:"model"`
+	Count      int    `json:"count"`
+	Dimensions int    `json:"dimensions"`
+	Requests   int    `json:"requests"`
+}
+
+// embedInput is one text or image to embed
+type embedInput struct {
+	text    string
+	image   string
+	content *genai.Content
+	// size is what the input adds to a request, images base64 encoded
+	size int
+}
+
+// Command
+var embedCmd = newEmbedCmd()
+
+func newEmbedCmd() *cobra.Command {
+	flags := &embedFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "embed [text...]",
+		Short: "Create text and image embeddings using Gemini",
+		Long: `Create embeddings using Gemini embedding models.
+
+Each argument is one text; without arguments, each non-empty line of --file or
+stdin is one. Images given with -i are embedded after the texts and need a
+multimodal embedding model. Inputs are sent in batches of up to 100. Vectors are
+written to -o as JSONL (one object per input) or .npy (a float32 matrix).
+
+Vectors reduced with --dimensions are not normalized.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEmbed(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.file, "file", "f", "", "Input file, one text per line")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file (.jsonl, .npy)")
+	cmd.Flags().StringArrayVarP(&flags.images, "image", "i", nil, "Image file to embed, can be repeated")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", "gemini-embedding-001", "Model name")
+	cmd.Flags().IntVarP(&flags.dimensions, "dimensions", "d", 0, "Reduce vectors to this many dimensions")
+	cmd.Flags().StringVar(&flags.task, "task", "", "Task type: semantic_similarity, classification, clustering, retrieval_document, retrieval_query, code_retrieval_query, question_answering, fact_verification")
+	cmd.Flags().StringVar(&flags.title, "title", "", "Document title (with --task retrieval_document)")
+
+	return cmd
+}
+
+func runEmbed(cmd *cobra.Command, args []string, flags *embedFlags) error {
+	// Get inputs
+	texts, err := common.ReadEmbeddingInputs(args, flags.file, cmd.InOrStdin())
+	if err != nil {
+		return common.WriteError(cmd, "file_not_found", err.Error())
+	}
+	if len(texts) == 0 && len(flags.images) == 0 {
+		return common.WriteError(cmd, "missing_input", "no input provided, use arguments, --file flag, stdin, or -i for images")
+	}
+	for _, image := range flags.images {
+		if _, err := os.Stat(image); err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("file not found: %s", image))
+		}
+		if _, ok := visionImageFormats[strings.ToLower(filepath.Ext(image))]; !ok {
+			return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported image format '%s', supported: png, jpg, webp, heic, heif", filepath.Ext(image)))
+		}
+	}
+
+	// Validate output
+	if flags.output == "" {
+		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
+	}
+	format, err := common.EmbeddingFormat(flags.output)
+	if err != nil {
+		return common.WriteError(cmd, "unsupported_format", err.Error())
+	}
+
+	// Validate model and options
+	if len(flags.images) > 0 && embedTextOnlyModels[flags.model] {
+		return common.WriteError(cmd, "unsupported_input", fmt.Sprintf("model '%s' only embeds text, use -m with a multimodal embedding model", flags.model))
+	}
+	if flags.dimensions < 0 {
+		return common.WriteError(cmd, "invalid_dimensions", "dimensions must be positive")
+	}
+	task := strings.ToUpper(flags.task)
+	if task != "" && !slices.Contains(embedTaskTypes, task) {
+		return common.WriteError(cmd, "invalid_task", fmt.Sprintf("invalid task '%s', use one of: %s", flags.task, strings.ToLower(strings.Join(embedTaskTypes, ", "))))
+	}
+	if flags.title != "" && task != "RETRIEVAL_DOCUMENT" {
+		return common.WriteError(cmd, "invalid_title", "--title requires --task retrieval_document")
+	}
+
+	// Check API key
+	apiKey := config.GetAPIKey("GEMINI_API_KEY", "GOOGLE_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("GEMINI_API_KEY", "GOOGLE_API_KEY"))
+	}
+
+	// Build inputs: texts first, then images
+	inputs := make([]embedInput, 0, len(texts)+len(flags.images))
+	for _, text := range texts {
+		inputs = append(inputs, embedInput{text: text, content: genai.NewContentFromText(text, genai.RoleUser), size: len(text)})
+	}
+	for _, image := range flags.images {
+		data, err := os.ReadFile(image)
+		if err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read file: %s", err.Error()))
+		}
+		size := int(inlineSize(int64(len(data))))
+		if size > embedMaxBytes {
+			return common.WriteError(cmd, "file_too_large", fmt.Sprintf("image %s does not fit in a 20 MB request once encoded", image))
+		}
+		mimeType := visionImageFormats[strings.ToLower(filepath.Ext(image))]
+		inputs = append(inputs, embedInput{image: image, content: genai.NewContentFromBytes(data, mimeType, genai.RoleUser), size: size})
+	}
+
+	// Create client
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return common.WriteError(cmd, "client_error", fmt.Sprintf("failed to create client: %s", err.Error()))
+	}
+
+	embedConfig := &genai.EmbedContentConfig{
+		TaskType: task,
+		Title:    flags.title,
+	}
+	if flags.dimensions > 0 {
+		dimensions := int32(flags.dimensions)
+		embedConfig.OutputDimensionality = &dimensions
+	}
+
+	// Embed in batches; vectors come back in input order
+	embeddings := make([]common.Embedding, 0, len(inputs))
+	batches := batchEmbedInputs(inputs)
+	for _, batch := range batches {
+		contents := make([]*genai.Content, len(batch))
+		for i, input := range batch {
+			contents[i] = input.content
+		}
+
+		result, err := client.Models.EmbedContent(ctx, flags.model, contents, embedConfig)
+		if err != nil {
+			return handleAPIError(cmd, err)
+		}
+		if len(result.Embeddings) != len(batch) {
+			return common.WriteError(cmd, "invalid_response", fmt.Sprintf("expected %d embeddings, got %d", len(batch), len(result.Embeddings)))
+		}
+		for i, e := range result.Embeddings {
+			if e == nil {
+				return common.WriteError(cmd, "invalid_response", "empty embedding in response")
+			}
+			image := batch[i].image
+			if image != "" {
+				if abs, err := filepath.Abs(image); err == nil {
+					image = abs
+				}
+			}
+			embeddings = append(embeddings, common.Embedding{
+				Index:  len(embeddings),
+				Text:   batch[i].text,
+				Image:  image,
+				Vector: e.Values,
+			})
+		}
+	}
+
+	// Write vectors
+	absPath, err := common.WriteEmbeddings(flags.output, embeddings)
+	if err != nil {
+		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+	}
+
+	return common.WriteSuccess(cmd, embedResponse{
+		Success:    true,
+		File:       absPath,
+		Format:     format,
+		Model:      flags.model,
+		Count:      len(embeddings),
+		Dimensions: len(embeddings[0].Vector),
+		Requests:   len(batches),
+	})
+}
+
+// batchEmbedInputs splits inputs into requests of at most embedMaxInputs
+// inputs and embedMaxBytes bytes as sent, with images base64 encoded.
+func batchEmbedInputs(inputs []embedInput) [][]embedInput {
+	var batches [][]embedInput
+	start, size := 0, 0
+	for i, input := range inputs {
+		if i > start && (i-start >= embedMaxInputs || size+input.size > embedMaxBytes) {
+			batches = append(batches, inputs[start:i])
+			start, size = i, 0
+		}
+		size += input.size
+	}
+	if start < len(inputs) {
+		batches = append(batches, inputs[start:])
+	}
+	return batches
+}
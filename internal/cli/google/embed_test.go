@@ -0,0 +1,207 @@
+package google
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+)
+
+func TestEmbed_Validation(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "cat.png")
+	os.WriteFile(image, []byte("png"), 0644)
+	doc := filepath.Join(dir, "notes.pdf")
+	os.WriteFile(doc, []byte("pdf"), 0644)
+
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"missing input", []string{"-o", "out.jsonl"}, "missing_input"},
+		{"text file not found", []string{"-f", filepath.Join(dir, "nope.txt"), "-o", "out.jsonl"}, "file_not_found"},
+		{"image not found", []string{"-i", filepath.Join(dir, "nope.png"), "-o", "out.jsonl"}, "file_not_found"},
+		{"unsupported image", []string{"-i", doc, "-o", "out.jsonl"}, "unsupported_format"},
+		{"missing output", []string{"a cat"}, "missing_output"},
+		{"unsupported output", []string{"a cat", "-o", "out.csv"}, "unsupported_format"},
+		{"image with text model", []string{"-i", image, "-o", "out.jsonl"}, "unsupported_input"},
+		{"negative dimensions", []string{"a cat", "-o", "out.npy", "-d", "-1"}, "invalid_dimensions"},
+		{"invalid task", []string{"a cat", "-o", "out.npy", "--task", "ranking"}, "invalid_task"},
+		{"title without document task", []string{"a cat", "-o", "out.npy", "--title", "Cats"}, "invalid_title"},
+		{"missing api key", []string{"a cat", "-o", "out.npy"}, "missing_api_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			cmd := newEmbedCmd()
+			cmd.SetIn(strings.NewReader(""))
+			_, stderr, err := executeCommand(cmd, tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+// embedServer fakes batchEmbedContents, returning [position, batch size] for
+// each request, and records the request bodies
+func embedServer(t *testing.T) func() []map[string]any {
+	var mu sync.Mutex
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, ":batchEmbedContents") {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		bodies = append(bodies, req)
+		mu.Unlock()
+
+		requests, _ := req["requests"].([]any)
+		var embeddings []string
+		for i := range requests {
+			embeddings = append(embeddings, fmt.Sprintf(`{"values":[%d,%d]}`, i, len(requests)))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"embeddings":[%s]}`, strings.Join(embeddings, ","))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", server.URL)
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+
+	return func() []map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return bodies
+	}
+}
+
+func TestEmbed_TextsAndImages(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	bodies := embedServer(t)
+	dir := t.TempDir()
+	image := filepath.Join(dir, "cat.webp")
+	os.WriteFile(image, []byte("webp data"), 0644)
+	output := filepath.Join(dir, "vectors.jsonl")
+
+	stdout, stderr, err := executeCommand(newEmbedCmd(), "a cat", "a dog", "-i", image, "-o", output,
+		"-m", "multimodal-embedding", "-d", "768", "--task", "retrieval_document", "--title", "Pets")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp embedResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if !resp.Success || resp.File != output || resp.Format != "jsonl" || resp.Model != "multimodal-embedding" || resp.Count != 3 || resp.Dimensions != 2 || resp.Requests != 1 {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	reqs := bodies()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(reqs))
+	}
+	data, _ := json.Marshal(reqs[0])
+	for _, want := range []string{`"taskType":"RETRIEVAL_DOCUMENT"`, `"title":"Pets"`, `"outputDimensionality":768`, `"mimeType":"image/webp"`, `"text":"a dog"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected request to contain %s, got %s", want, data)
+		}
+	}
+
+	f, _ := os.Open(output)
+	defer f.Close()
+	var lines []common.Embedding
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e common.Embedding
+		json.Unmarshal(scanner.Bytes(), &e)
+		lines = append(lines, e)
+	}
+	if len(lines) != 3 || lines[0].Text != "a cat" || lines[1].Text != "a dog" || lines[2].Image != image || lines[2].Text != "" || lines[2].Vector[0] != 2 {
+		t.Errorf("unexpected vectors: %+v", lines)
+	}
+}
+
+func TestEmbed_Batches(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	bodies := embedServer(t)
+	output := filepath.Join(t.TempDir(), "vectors.npy")
+
+	lines := make([]string, embedMaxInputs+1)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("prompt %d", i)
+	}
+	cmd := newEmbedCmd()
+	cmd.SetIn(strings.NewReader(strings.Join(lines, "\n")))
+	stdout, stderr, err := executeCommand(cmd, "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"requests":2`) || !strings.Contains(stdout, fmt.Sprintf(`"count":%d`, embedMaxInputs+1)) {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	reqs := bodies()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+	if second, _ := reqs[1]["requests"].([]any); len(second) != 1 {
+		t.Errorf("expected 1 input in the second request, got %d", len(second))
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(128 + (embedMaxInputs+1)*2*4); info.Size() != want {
+		t.Errorf("expected .npy of %d bytes, got %d", want, info.Size())
+	}
+}
+
+func TestEmbed_BatchesImagesByEncodedSize(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	bodies := embedServer(t)
+	dir := t.TempDir()
+	// Together 16 MB, which exceeds the request limit once encoded
+	first := filepath.Join(dir, "first.png")
+	os.WriteFile(first, make([]byte, 8*1024*1024), 0644)
+	second := filepath.Join(dir, "second.png")
+	os.WriteFile(second, make([]byte, 8*1024*1024), 0644)
+
+	stdout, stderr, err := executeCommand(newEmbedCmd(), "-i", first, "-i", second, "-o", filepath.Join(dir, "vectors.jsonl"), "-m", "multimodal-embedding")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"requests":2`) || len(bodies()) != 2 {
+		t.Errorf("expected the images in separate requests, got: %s", stdout)
+	}
+}
+
+func TestEmbed_ImageTooLargeOnceEncoded(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	// Under 20 MB on disk, over the request limit once encoded
+	image := filepath.Join(t.TempDir(), "large.png")
+	os.WriteFile(image, make([]byte, 15*1024*1024), 0644)
+
+	_, stderr, err := executeCommand(newEmbedCmd(), "-i", image, "-o", filepath.Join(t.TempDir(), "vectors.jsonl"), "-m", "multimodal-embedding")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(stderr, `"code":"file_too_large"`) {
+		t.Errorf("expected file_too_large, got: %s", stderr)
+	}
+}
@@ -8,7 +8,7 @@ import (
 var Cmd = &cobra.Command{
 	Use:   "google",
 	Short: "Google Gemini provider commands",
-	Long:  "Commands for Google Gemini services including TTS, STT, Image and Video generation.",
+	Long:  "Commands for Google Gemini services including TTS, STT, Image, Video and Text generation, Live speech sessions, image and audio understanding, embeddings, and batch jobs.",
 }
 
 func init() {
@@ -16,4 +16,9 @@ func init() {
 	Cmd.AddCommand(video.Cmd)
 	Cmd.AddCommand(ttsCmd)
 	Cmd.AddCommand(sttCmd)
+	Cmd.AddCommand(batchCmd)
+	Cmd.AddCommand(visionCmd)
+	Cmd.AddCommand(textCmd)
+	Cmd.AddCommand(embedCmd)
+	Cmd.AddCommand(liveCmd)
 }
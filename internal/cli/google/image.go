@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/WHQ25/rawgenai/internal/config"
@@ -16,8 +17,55 @@ import (
 
 // Model name mapping
 var modelIDs = map[string]string{
-	"flash": "gemini-2.5-flash-image",
-	"pro":   "gemini-3-pro-image-preview",
+	"flash":          "gemini-2.5-flash-image",
+	"pro":            "gemini-3-pro-image-preview",
+	"imagen-4":       "imagen-4.0-generate-001",
+	"imagen-4-ultra": "imagen-4.0-ultra-generate-001",
+	"imagen-4-fast":  "imagen-4.0-fast-generate-001",
+}
+
+// Imagen models generate with GenerateImages instead of GenerateContent
+var imagenModels = map[string]bool{
+	"imagen-4":       true,
+	"imagen-4-ultra": true,
+	"imagen-4-fast":  true,
+}
+
+// Aspect ratios supported by Imagen
+var imagenAspects = map[string]bool{
+	"1:1":  true,
+	"3:4":  true,
+	"4:3":  true,
+	"9:16": true,
+	"16:9": true,
+}
+
+// Models that accept --size above 1K, and the largest size they accept
+var maxSizes = map[string]string{
+	"pro":            "4K",
+	"imagen-4":       "2K",
+	"imagen-4-ultra": "2K",
+}
+
+// Images per Imagen request
+var maxImagenCount = map[string]int{
+	"imagen-4":       4,
+	"imagen-4-ultra": 1,
+	"imagen-4-fast":  4,
+}
+
+// Person generation policies for Imagen
+var personGenerations = map[string]genai.PersonGeneration{
+	"dont_allow":  genai.PersonGenerationDontAllow,
+	"allow_adult": genai.PersonGenerationAllowAdult,
+	"allow_all":   genai.PersonGenerationAllowAll,
+}
+
+// Output formats for Imagen
+var imagenFormats = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
 }
 
 // Valid aspect ratios
@@ -49,11 +97,17 @@ var maxImages = map[string]int{
 
 // Response type
 type imageResponse struct {
-	Success bool   `json:"success"`
-	File    string `json:"file,omitempty"`
-	Model   string `json:"model,omitempty"`
-	Aspect  string `json:"aspect,omitempty"`
-	Size    string `json:"size,omitempty"`
+	Success        bool     `json:"success"`
+	File           string   `json:"file,omitempty"`
+	Files          []string `json:"files,omitempty"`
+	Model          string   `json:"model,omitempty"`
+	Aspect         string   `json:"aspect,omitempty"`
+	Size           string   `json:"size,omitempty"`
+	Filtered       int      `json:"filtered,omitempty"`
+	EnhancedPrompt string   `json:"enhanced_prompt,omitempty"`
+	Text           string   `json:"text,omitempty"`
+	Session        string   `json:"session,omitempty"`
+	Turn           int      `json:"turn,omitempty"`
 }
 
 // Flag struct
@@ -65,6 +119,13 @@ type imageFlags struct {
 	aspect     string
 	size       string
 	search     bool
+	session    string
+
+	// Imagen only
+	count         int
+	person        string
+	negative      string
+	enhancePrompt bool
 }
 
 // Command
@@ -75,22 +136,30 @@ func newImageCmd() *cobra.Command {
 
 	cmd := &cobra.Command{
 		Use:           "image [prompt]",
-		Short:         "Generate and edit images using Google Gemini Nano Banana models",
-		Long:          "Generate and edit images using Google Gemini Nano Banana models (flash and pro).",
+		Short:         "Generate and edit images using Google Gemini and Imagen models",
+		Long:          "Generate and edit images using Google Gemini Nano Banana models (flash and pro), or generate them with Imagen 4 (imagen-4, imagen-4-ultra, imagen-4-fast).",
 		SilenceErrors: true,
 		SilenceUsage:  true,
+		Args:          cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runImage(cmd, args, flags)
 		},
 	}
 
-	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.png)")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.png, or .jpg with Imagen)")
 	cmd.Flags().StringArrayVarP(&flags.images, "image", "i", nil, "Reference image(s), can be repeated")
 	cmd.Flags().StringVar(&flags.promptFile, "prompt-file", "", "Input prompt file")
-	cmd.Flags().StringVarP(&flags.model, "model", "m", "flash", "Model: flash, pro")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", "flash", "Model: flash, pro, imagen-4, imagen-4-ultra, imagen-4-fast")
 	cmd.Flags().StringVarP(&flags.aspect, "aspect", "a", "1:1", "Aspect ratio")
-	cmd.Flags().StringVarP(&flags.size, "size", "s", "1K", "Image size (Pro only): 1K, 2K, 4K")
+	cmd.Flags().StringVarP(&flags.size, "size", "s", "1K", "Image size: 1K, 2K (pro, imagen-4, imagen-4-ultra), 4K (pro)")
 	cmd.Flags().BoolVar(&flags.search, "search", false, "Enable Google Search grounding (Pro only)")
+	cmd.Flags().StringVar(&flags.session, "session", "", "Continue the editing session with this ID, or start it (Gemini only)")
+	cmd.Flags().IntVarP(&flags.count, "count", "n", 1, "Number of images (Imagen only): 1-4, 1 for imagen-4-ultra")
+	cmd.Flags().StringVar(&flags.person, "person", "allow_adult", "People in images (Imagen only): dont_allow, allow_adult, allow_all")
+	cmd.Flags().StringVar(&flags.negative, "negative", "", "Negative prompt (Imagen on Vertex AI only)")
+	cmd.Flags().BoolVar(&flags.enhancePrompt, "enhance-prompt", false, "Rewrite the prompt for more detail (Imagen on Vertex AI only)")
+
+	cmd.AddCommand(newImageSessionCmd())
 
 	return cmd
 }
@@ -107,19 +176,37 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
 	}
 
-	// Validate format (only PNG supported)
-	ext := strings.ToLower(filepath.Ext(flags.output))
-	if ext != ".png" {
-		return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported format '%s', only .png is supported", ext))
+	// A session continues with the model it was started with
+	var session *imageSession
+	if flags.session != "" {
+		session, err = openImageSession(cmd, flags)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Validate model
 	modelID, ok := modelIDs[flags.model]
 	if !ok {
-		return common.WriteError(cmd, "invalid_model", fmt.Sprintf("invalid model '%s', use 'flash' or 'pro'", flags.model))
+		return common.WriteError(cmd, "invalid_model", fmt.Sprintf("invalid model '%s', use flash, pro, imagen-4, imagen-4-ultra or imagen-4-fast", flags.model))
+	}
+	isImagen := imagenModels[flags.model]
+
+	// Validate format (PNG, or PNG/JPEG for Imagen)
+	flags.output = common.DefaultExt(flags.output, ".png")
+	ext := strings.ToLower(filepath.Ext(flags.output))
+	if isImagen {
+		if _, ok := imagenFormats[ext]; !ok {
+			return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported format '%s', Imagen supports .png and .jpg", ext))
+		}
+	} else if ext != ".png" {
+		return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported format '%s', only .png is supported (.jpg with Imagen models)", ext))
 	}
 
 	// Validate aspect ratio
+	if isImagen && validAspects[flags.aspect] && !imagenAspects[flags.aspect] {
+		return common.WriteError(cmd, "invalid_aspect", fmt.Sprintf("invalid aspect ratio '%s' for Imagen, valid: 1:1, 3:4, 4:3, 9:16, 16:9", flags.aspect))
+	}
 	if !validAspects[flags.aspect] {
 		validList := make([]string, 0, len(validAspects))
 		for k := range validAspects {
@@ -133,9 +220,15 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 		return common.WriteError(cmd, "invalid_size", fmt.Sprintf("invalid size '%s', use '1K', '2K', or '4K' (uppercase K required)", flags.size))
 	}
 
-	// Size only supported in Pro model
-	if flags.size != "1K" && flags.model != "pro" {
-		return common.WriteError(cmd, "size_requires_pro", "--size 2K/4K requires --model pro")
+	// Sizes above 1K depend on the model; valid sizes order as strings
+	if flags.size != "1K" {
+		maxSize, ok := maxSizes[flags.model]
+		if !ok {
+			return common.WriteError(cmd, "size_requires_pro", "--size 2K/4K requires --model pro (2K also imagen-4 or imagen-4-ultra)")
+		}
+		if flags.size > maxSize {
+			return common.WriteError(cmd, "invalid_size", fmt.Sprintf("model '%s' supports sizes up to %s", flags.model, maxSize))
+		}
 	}
 
 	// Search only supported in Pro model
@@ -143,6 +236,11 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 		return common.WriteError(cmd, "search_requires_pro", "--search requires --model pro")
 	}
 
+	// Imagen options
+	if err := validateImagenFlags(cmd, flags, isImagen); err != nil {
+		return err
+	}
+
 	// Validate image count
 	maxImg := maxImages[flags.model]
 	if len(flags.images) > maxImg {
@@ -156,10 +254,15 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 		}
 	}
 
-	// Check API key
-	apiKey := config.GetAPIKey("GEMINI_API_KEY", "GOOGLE_API_KEY")
-	if apiKey == "" {
-		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("GEMINI_API_KEY", "GOOGLE_API_KEY"))
+	// Create client
+	ctx := context.Background()
+	client, err := newImageClient(ctx, cmd)
+	if err != nil {
+		return err
+	}
+
+	if isImagen {
+		return runImagen(ctx, cmd, client, modelID, prompt, flags)
 	}
 
 	// Build content parts
@@ -184,16 +287,6 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 		})
 	}
 
-	// Create client
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  apiKey,
-		Backend: genai.BackendGeminiAPI,
-	})
-	if err != nil {
-		return common.WriteError(cmd, "client_error", fmt.Sprintf("failed to create client: %s", err.Error()))
-	}
-
 	// Build config
 	config := &genai.GenerateContentConfig{
 		ResponseModalities: []string{"IMAGE"},
@@ -214,10 +307,13 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 		}
 	}
 
-	// Build content
+	// Build content, after the history of the session
 	contents := []*genai.Content{
 		genai.NewContentFromParts(parts, genai.RoleUser),
 	}
+	if session != nil {
+		contents = append(session.History, contents...)
+	}
 
 	// Call API
 	result, err := client.Models.GenerateContent(ctx, modelID, contents, config)
@@ -225,13 +321,21 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 		return handleAPIError(cmd, err)
 	}
 
-	// Extract image from response
+	// Extract image and text from response, skipping interim images of thoughts
 	var imageBytes []byte
-	if result.Candidates != nil && len(result.Candidates) > 0 {
-		for _, part := range result.Candidates[0].Content.Parts {
-			if part.InlineData != nil {
+	var texts []string
+	var modelContent *genai.Content
+	if len(result.Candidates) > 0 && result.Candidates[0].Content != nil {
+		modelContent = result.Candidates[0].Content
+		for _, part := range modelContent.Parts {
+			if part.Thought {
+				continue
+			}
+			if part.InlineData != nil && imageBytes == nil {
 				imageBytes = part.InlineData.Data
-				break
+			}
+			if part.Text != "" {
+				texts = append(texts, part.Text)
 			}
 		}
 	}
@@ -246,7 +350,7 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 		absPath = flags.output
 	}
 
-	if err := os.WriteFile(absPath, imageBytes, 0644); err != nil {
+	if _, err := common.WriteOutput(absPath, imageBytes, common.OutputVars{}); err != nil {
 		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
 	}
 
@@ -256,11 +360,191 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 		File:    absPath,
 		Model:   modelID,
 		Aspect:  flags.aspect,
+		Text:    strings.TrimSpace(strings.Join(texts, "\n")),
 	}
 	if flags.model == "pro" {
 		resp.Size = flags.size
 	}
 
+	// Keep the model's content as returned, thought signatures included
+	if session != nil {
+		turn := imageSessionTurn{
+			Prompt: prompt,
+			File:   absPath,
+			Text:   resp.Text,
+			Time:   time.Now().UTC(),
+		}
+		for _, img := range flags.images {
+			if abs, err := filepath.Abs(img); err == nil {
+				img = abs
+			}
+			turn.Images = append(turn.Images, img)
+		}
+		session.History = append(contents, modelContent)
+		session.Turns = append(session.Turns, turn)
+		session.UpdatedAt = turn.Time
+		if err := session.save(); err != nil {
+			return common.WriteError(cmd, "session_write_error", fmt.Sprintf("image saved, but cannot save session: %s", err.Error()))
+		}
+		resp.Session = session.ID
+		resp.Turn = len(session.Turns)
+	}
+
+	return common.WriteSuccess(cmd, resp)
+}
+
+// validateImagenFlags checks the flags that only apply to Imagen models.
+func validateImagenFlags(cmd *cobra.Command, flags *imageFlags, isImagen bool) error {
+	if !isImagen {
+		if flags.count != 1 {
+			return common.WriteError(cmd, "count_requires_imagen", "--count requires an Imagen model, Gemini models return one image")
+		}
+		if cmd.Flags().Changed("person") {
+			return common.WriteError(cmd, "person_requires_imagen", "--person requires an Imagen model")
+		}
+		if flags.negative != "" {
+			return common.WriteError(cmd, "negative_requires_imagen", "--negative requires an Imagen model")
+		}
+		if flags.enhancePrompt {
+			return common.WriteError(cmd, "enhance_prompt_requires_imagen", "--enhance-prompt requires an Imagen model")
+		}
+		return nil
+	}
+
+	if len(flags.images) > 0 {
+		return common.WriteError(cmd, "image_requires_gemini", "reference images require --model flash or pro, Imagen only generates from text")
+	}
+	if maxCount := maxImagenCount[flags.model]; flags.count < 1 || flags.count > maxCount {
+		return common.WriteError(cmd, "invalid_count", fmt.Sprintf("--count must be between 1 and %d for model '%s'", maxCount, flags.model))
+	}
+	if _, ok := personGenerations[flags.person]; !ok {
+		return common.WriteError(cmd, "invalid_person", fmt.Sprintf("invalid person generation '%s', use dont_allow, allow_adult or allow_all", flags.person))
+	}
+	// The Gemini API rejects these parameters, only Vertex AI takes them
+	if !useVertexAI() {
+		if flags.negative != "" {
+			return common.WriteError(cmd, "negative_requires_vertex", "--negative is only supported on Vertex AI, set GOOGLE_GENAI_USE_VERTEXAI=true")
+		}
+		if flags.enhancePrompt {
+			return common.WriteError(cmd, "enhance_prompt_requires_vertex", "--enhance-prompt is only supported on Vertex AI, set GOOGLE_GENAI_USE_VERTEXAI=true")
+		}
+	}
+	return nil
+}
+
+// useVertexAI reports whether image requests go to Vertex AI instead of the
+// Gemini API, as GOOGLE_GENAI_USE_VERTEXAI asks.
+func useVertexAI() bool {
+	v := strings.ToLower(os.Getenv("GOOGLE_GENAI_USE_VERTEXAI"))
+	return v == "true" || v == "1"
+}
+
+// newImageClient creates a client for the Gemini API with an API key, or
+// for Vertex AI with application default credentials and the project and
+// location in GOOGLE_CLOUD_PROJECT and GOOGLE_CLOUD_LOCATION. Errors are
+// written to the command.
+func newImageClient(ctx context.Context, cmd *cobra.Command) (*genai.Client, error) {
+	clientConfig := &genai.ClientConfig{Backend: genai.BackendGeminiAPI}
+	if useVertexAI() {
+		project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+		if project == "" {
+			return nil, common.WriteError(cmd, "missing_project", "GOOGLE_CLOUD_PROJECT is required with GOOGLE_GENAI_USE_VERTEXAI")
+		}
+		location := os.Getenv("GOOGLE_CLOUD_LOCATION")
+		if location == "" {
+			location = "us-central1"
+		}
+		clientConfig = &genai.ClientConfig{Backend: genai.BackendVertexAI, Project: project, Location: location}
+	} else {
+		clientConfig.APIKey = config.GetAPIKey("GEMINI_API_KEY", "GOOGLE_API_KEY")
+		if clientConfig.APIKey == "" {
+			return nil, common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("GEMINI_API_KEY", "GOOGLE_API_KEY"))
+		}
+	}
+
+	client, err := genai.NewClient(ctx, clientConfig)
+	if err != nil {
+		return nil, common.WriteError(cmd, "client_error", fmt.Sprintf("failed to create client: %s", err.Error()))
+	}
+	return client, nil
+}
+
+// runImagen generates images with an Imagen model and saves them, adding
+// "_<index>" to the file name when there are several.
+func runImagen(ctx context.Context, cmd *cobra.Command, client *genai.Client, modelID, prompt string, flags *imageFlags) error {
+	imageConfig := &genai.GenerateImagesConfig{
+		NumberOfImages:   int32(flags.count),
+		AspectRatio:      flags.aspect,
+		PersonGeneration: personGenerations[flags.person],
+		OutputMIMEType:   imagenFormats[strings.ToLower(filepath.Ext(flags.output))],
+		IncludeRAIReason: true,
+		NegativePrompt:   flags.negative,
+		EnhancePrompt:    flags.enhancePrompt,
+	}
+	if _, ok := maxSizes[flags.model]; ok {
+		imageConfig.ImageSize = flags.size
+	}
+
+	result, err := client.Models.GenerateImages(ctx, modelID, prompt, imageConfig)
+	if err != nil {
+		return handleAPIError(cmd, err)
+	}
+
+	// Images blocked by safety filters come back without data
+	var images [][]byte
+	var filterReason, enhancedPrompt string
+	for _, generated := range result.GeneratedImages {
+		if generated == nil {
+			continue
+		}
+		if enhancedPrompt == "" {
+			enhancedPrompt = generated.EnhancedPrompt
+		}
+		if generated.Image == nil || len(generated.Image.ImageBytes) == 0 {
+			if filterReason == "" {
+				filterReason = generated.RAIFilteredReason
+			}
+			continue
+		}
+		images = append(images, generated.Image.ImageBytes)
+	}
+
+	if len(images) == 0 {
+		if filterReason != "" {
+			return common.WriteError(cmd, "content_policy", fmt.Sprintf("all images were filtered: %s", filterReason))
+		}
+		return common.WriteError(cmd, "no_image", "no image generated in response")
+	}
+
+	var files []string
+	for i, data := range images {
+		path := common.IndexedPath(flags.output, "image", i, len(images), "")
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			absPath = path
+		}
+		if _, err := common.WriteOutput(absPath, data, common.OutputVars{}); err != nil {
+			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		}
+		files = append(files, absPath)
+	}
+
+	resp := imageResponse{
+		Success:        true,
+		Model:          modelID,
+		Aspect:         flags.aspect,
+		Filtered:       flags.count - len(images),
+		EnhancedPrompt: enhancedPrompt,
+	}
+	if len(files) == 1 {
+		resp.File = files[0]
+	} else {
+		resp.Files = files
+	}
+	if imageConfig.ImageSize != "" {
+		resp.Size = imageConfig.ImageSize
+	}
+
 	return common.WriteSuccess(cmd, resp)
 }
 
@@ -0,0 +1,279 @@
+package google
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/spf13/cobra"
+	"google.golang.org/genai"
+)
+
+// Session IDs name files in the state dir
+var sessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// imageSessionInfo describes a session without its conversation history
+type imageSessionInfo struct {
+	ID        string             `json:"session_id"`
+	Model     string             `json:"model"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Turns     []imageSessionTurn `json:"turns"`
+}
+
+type imageSessionTurn struct {
+	Prompt string    `json:"prompt"`
+	Images []string  `json:"images,omitempty"`
+	File   string    `json:"file"`
+	Text   string    `json:"text,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// imageSession is a multi-turn image editing conversation. The history keeps
+// every content as the API returned it, including generated images and
+// thought signatures, which Gemini needs to continue editing.
+type imageSession struct {
+	imageSessionInfo
+	History []*genai.Content `json:"history"`
+}
+
+func imageSessionDir() string {
+	return filepath.Join(config.StateDir(), "google", "image-sessions")
+}
+
+func imageSessionPath(id string) string {
+	return filepath.Join(imageSessionDir(), id+".json")
+}
+
+// loadImageSession reads a session, returning an error that wraps
+// os.ErrNotExist when there is none with this ID.
+func loadImageSession(id string) (*imageSession, error) {
+	data, err := os.ReadFile(imageSessionPath(id))
+	if err != nil {
+		return nil, err
+	}
+	var session imageSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("invalid session file: %w", err)
+	}
+	return &session, nil
+}
+
+// save writes the session through a temp file, so a failed write never
+// leaves a truncated history behind.
+func (s *imageSession) save() error {
+	if err := os.MkdirAll(imageSessionDir(), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	path := imageSessionPath(s.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// openImageSession loads the session of --session, or starts a new one with
+// the requested model. A session keeps the model it was started with.
+// Errors are written to the command.
+func openImageSession(cmd *cobra.Command, flags *imageFlags) (*imageSession, error) {
+	if !sessionIDPattern.MatchString(flags.session) {
+		return nil, common.WriteError(cmd, "invalid_session_id", "session ID must be 1-64 letters, digits, '-' or '_', starting with a letter or digit")
+	}
+	if imagenModels[flags.model] {
+		return nil, common.WriteError(cmd, "session_requires_gemini", "--session requires --model flash or pro, Imagen does not edit across turns")
+	}
+
+	session, err := loadImageSession(flags.session)
+	if errors.Is(err, os.ErrNotExist) {
+		now := time.Now().UTC()
+		return &imageSession{imageSessionInfo: imageSessionInfo{
+			ID:        flags.session,
+			Model:     flags.model,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}}, nil
+	}
+	if err != nil {
+		return nil, common.WriteError(cmd, "session_read_error", fmt.Sprintf("cannot read session '%s': %s", flags.session, err.Error()))
+	}
+
+	if cmd.Flags().Changed("model") && flags.model != session.Model {
+		return nil, common.WriteError(cmd, "incompatible_session", fmt.Sprintf("session '%s' uses model '%s', start a new session to change models", session.ID, session.Model))
+	}
+	flags.model = session.Model
+	return session, nil
+}
+
+// ===== Session Commands =====
+
+func newImageSessionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session",
+		Short: "Manage multi-turn image editing sessions",
+		Long:  "List, show and delete the image editing sessions started with --session. Sessions are kept in the local state dir.",
+	}
+
+	cmd.AddCommand(newImageSessionListCmd())
+	cmd.AddCommand(newImageSessionShowCmd())
+	cmd.AddCommand(newImageSessionDeleteCmd())
+
+	return cmd
+}
+
+type sessionSummary struct {
+	ID        string    `json:"session_id"`
+	Model     string    `json:"model"`
+	Turns     int       `json:"turns"`
+	LastFile  string    `json:"last_file,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newImageSessionListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "list",
+		Short:         "List sessions, most recently updated first",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImageSessionList(cmd)
+		},
+	}
+
+	return cmd
+}
+
+func runImageSessionList(cmd *cobra.Command) error {
+	entries, err := os.ReadDir(imageSessionDir())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return common.WriteError(cmd, "session_read_error", fmt.Sprintf("cannot read sessions: %s", err.Error()))
+	}
+
+	sessions := []sessionSummary{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(imageSessionDir(), name))
+		if err != nil {
+			continue
+		}
+		// Decoding into the info skips the history and its images
+		var info imageSessionInfo
+		if err := json.Unmarshal(data, &info); err != nil || info.ID == "" {
+			continue
+		}
+		summary := sessionSummary{
+			ID:        info.ID,
+			Model:     info.Model,
+			Turns:     len(info.Turns),
+			CreatedAt: info.CreatedAt,
+			UpdatedAt: info.UpdatedAt,
+		}
+		if len(info.Turns) > 0 {
+			summary.LastFile = info.Turns[len(info.Turns)-1].File
+		}
+		sessions = append(sessions, summary)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+
+	return common.WriteSuccess(cmd, map[string]any{
+		"success":  true,
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+func newImageSessionShowCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "show <session_id>",
+		Short:         "Show the turns of a session",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImageSessionShow(cmd, args)
+		},
+	}
+
+	return cmd
+}
+
+func runImageSessionShow(cmd *cobra.Command, args []string) error {
+	session, err := findImageSession(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	return common.WriteSuccess(cmd, struct {
+		Success bool `json:"success"`
+		imageSessionInfo
+	}{true, session.imageSessionInfo})
+}
+
+func newImageSessionDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "delete <session_id>",
+		Short:         "Delete a session",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImageSessionDelete(cmd, args)
+		},
+	}
+
+	return cmd
+}
+
+func runImageSessionDelete(cmd *cobra.Command, args []string) error {
+	session, err := findImageSession(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(imageSessionPath(session.ID)); err != nil {
+		return common.WriteError(cmd, "session_write_error", fmt.Sprintf("cannot delete session: %s", err.Error()))
+	}
+
+	return common.WriteSuccess(cmd, map[string]any{
+		"success":    true,
+		"session_id": session.ID,
+		"status":     "deleted",
+	})
+}
+
+// findImageSession loads the session named by the first argument. Errors are
+// written to the command.
+func findImageSession(cmd *cobra.Command, args []string) (*imageSession, error) {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		return nil, common.WriteError(cmd, "missing_session_id", "session ID is required")
+	}
+	id := args[0]
+	if !sessionIDPattern.MatchString(id) {
+		return nil, common.WriteError(cmd, "invalid_session_id", "session ID must be 1-64 letters, digits, '-' or '_', starting with a letter or digit")
+	}
+
+	session, err := loadImageSession(id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, common.WriteError(cmd, "session_not_found", fmt.Sprintf("session '%s' not found", id))
+	}
+	if err != nil {
+		return nil, common.WriteError(cmd, "session_read_error", fmt.Sprintf("cannot read session '%s': %s", id, err.Error()))
+	}
+	return session, nil
+}
@@ -0,0 +1,187 @@
+package google
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+)
+
+func TestImage_SessionValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"invalid id", []string{"--session", "../escape"}, "invalid_session_id"},
+		{"imagen", []string{"--session", "cat", "-m", "imagen-4"}, "session_requires_gemini"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			cmd := newImageCmd()
+			args := append([]string{"A cute cat", "-o", "out.png"}, tt.args...)
+			_, stderr, err := executeCommand(cmd, args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+func TestImage_SessionModelMismatch(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	session := &imageSession{imageSessionInfo: imageSessionInfo{ID: "cat", Model: "pro"}}
+	if err := session.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := newImageCmd()
+	_, stderr, err := executeCommand(cmd, "Make it blue", "-o", "out.png", "--session", "cat", "-m", "flash")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(stderr, `"code":"incompatible_session"`) {
+		t.Errorf("expected incompatible_session, got: %s", stderr)
+	}
+}
+
+func TestImage_Session(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+
+	var mu sync.Mutex
+	var paths []string
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		requests = append(requests, body)
+		turn := len(requests)
+		mu.Unlock()
+
+		image := base64.StdEncoding.EncodeToString([]byte("png " + string(rune('0'+turn))))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[
+			{"text":"thinking","thought":true},
+			{"text":"Here you go","thoughtSignature":"c2lnbmF0dXJl"},
+			{"inlineData":{"mimeType":"image/png","data":"` + image + `"}}
+		]}}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", server.URL)
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "cat.png")
+	stdout, stderr, err := executeCommand(newImageCmd(), "A cute cat", "-o", first, "-m", "pro", "--session", "cat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp imageResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp.Session != "cat" || resp.Turn != 1 || resp.Text != "Here you go" {
+		t.Fatalf("unexpected response: %s", stdout)
+	}
+
+	// The second turn keeps the model of the session without -m
+	second := filepath.Join(dir, "cat_dark.png")
+	stdout, stderr, err = executeCommand(newImageCmd(), "make the sky darker", "-o", second, "--session", "cat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	resp = imageResponse{}
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp.Turn != 2 || resp.Model != "gemini-3-pro-image-preview" {
+		t.Fatalf("unexpected response: %s", stdout)
+	}
+	if data, _ := os.ReadFile(second); string(data) != "png 2" {
+		t.Errorf("expected second image, got %q", data)
+	}
+
+	if len(requests) != 2 || !strings.Contains(paths[1], "gemini-3-pro-image-preview:generateContent") {
+		t.Fatalf("unexpected requests: %v", paths)
+	}
+	contents, _ := requests[1]["contents"].([]any)
+	if len(contents) != 3 {
+		t.Fatalf("expected history of 3 contents, got %d", len(contents))
+	}
+	history, _ := json.Marshal(contents[1])
+	for _, want := range []string{`"role":"model"`, `"thoughtSignature":"c2lnbmF0dXJl"`, `"data":"` + base64.StdEncoding.EncodeToString([]byte("png 1")) + `"`} {
+		if !strings.Contains(string(history), want) {
+			t.Errorf("expected model turn in history to contain %s, got %s", want, history)
+		}
+	}
+	if last, _ := json.Marshal(contents[2]); !strings.Contains(string(last), "make the sky darker") {
+		t.Errorf("expected new prompt last, got %s", last)
+	}
+
+	// Manage the session
+	stdout, _, err = executeCommand(newImageCmd(), "session", "list")
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	var list struct {
+		Sessions []sessionSummary `json:"sessions"`
+		Count    int              `json:"count"`
+	}
+	json.Unmarshal([]byte(stdout), &list)
+	if list.Count != 1 || list.Sessions[0].ID != "cat" || list.Sessions[0].Turns != 2 || list.Sessions[0].LastFile != second {
+		t.Errorf("unexpected list: %s", stdout)
+	}
+
+	stdout, _, err = executeCommand(newImageCmd(), "session", "show", "cat")
+	if err != nil {
+		t.Fatalf("show failed: %v", err)
+	}
+	var info imageSessionInfo
+	json.Unmarshal([]byte(stdout), &info)
+	if info.Model != "pro" || len(info.Turns) != 2 || info.Turns[1].Prompt != "make the sky darker" || info.Turns[0].File != first {
+		t.Errorf("unexpected show: %s", stdout)
+	}
+	if strings.Contains(stdout, "history") {
+		t.Errorf("show should not print the history: %s", stdout)
+	}
+
+	if _, stderr, err = executeCommand(newImageCmd(), "session", "delete", "cat"); err != nil {
+		t.Fatalf("delete failed: %v\n%s", err, stderr)
+	}
+	_, stderr, err = executeCommand(newImageCmd(), "session", "show", "cat")
+	if err == nil || !strings.Contains(stderr, `"code":"session_not_found"`) {
+		t.Errorf("expected session_not_found after delete, got: %s", stderr)
+	}
+}
+
+func TestImageSession_MissingID(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	for _, sub := range []string{"show", "delete"} {
+		_, stderr, err := executeCommand(newImageCmd(), "session", sub)
+		if err == nil || !strings.Contains(stderr, `"code":"missing_session_id"`) {
+			t.Errorf("%s: expected missing_session_id, got: %s", sub, stderr)
+		}
+	}
+}
+
+func TestImageSession_ListEmpty(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	stdout, _, err := executeCommand(newImageCmd(), "session", "list")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, `"sessions":[]`) || !strings.Contains(stdout, `"count":0`) {
+		t.Errorf("unexpected output: %s", stdout)
+	}
+}
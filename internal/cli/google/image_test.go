@@ -2,9 +2,13 @@ package google
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -939,3 +943,110 @@ func TestHandleAPIError_Generic(t *testing.T) {
 		t.Errorf("expected 'api_error', got: %s", errorObj["code"])
 	}
 }
+
+func TestImage_ImagenValidation(t *testing.T) {
+	refImage := filepath.Join(t.TempDir(), "ref.png")
+	os.WriteFile(refImage, []byte("fake png"), 0644)
+
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"jpeg requires imagen", []string{"-o", "out.jpg"}, "unsupported_format"},
+		{"imagen rejects webp", []string{"-m", "imagen-4", "-o", "out.webp"}, "unsupported_format"},
+		{"imagen aspect", []string{"-m", "imagen-4", "-o", "out.png", "-a", "21:9"}, "invalid_aspect"},
+		{"imagen 4K", []string{"-m", "imagen-4", "-o", "out.png", "-s", "4K"}, "invalid_size"},
+		{"imagen fast 2K", []string{"-m", "imagen-4-fast", "-o", "out.png", "-s", "2K"}, "size_requires_pro"},
+		{"count requires imagen", []string{"-o", "out.png", "-n", "2"}, "count_requires_imagen"},
+		{"person requires imagen", []string{"-o", "out.png", "--person", "dont_allow"}, "person_requires_imagen"},
+		{"negative requires imagen", []string{"-o", "out.png", "--negative", "blur"}, "negative_requires_imagen"},
+		{"ultra count", []string{"-m", "imagen-4-ultra", "-o", "out.png", "-n", "2"}, "invalid_count"},
+		{"count too high", []string{"-m", "imagen-4", "-o", "out.png", "-n", "5"}, "invalid_count"},
+		{"invalid person", []string{"-m", "imagen-4", "-o", "out.png", "--person", "everyone"}, "invalid_person"},
+		{"reference image", []string{"-m", "imagen-4", "-o", "out.png", "-i", refImage}, "image_requires_gemini"},
+		{"negative on gemini api", []string{"-m", "imagen-4", "-o", "out.png", "--negative", "blur"}, "negative_requires_vertex"},
+		{"enhance on gemini api", []string{"-m", "imagen-4", "-o", "out.png", "--enhance-prompt"}, "enhance_prompt_requires_vertex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+			cmd := newImageCmd()
+			_, stderr, err := executeCommand(cmd, append([]string{"A cute cat"}, tt.args...)...)
+
+			if err == nil {
+				t.Fatalf("expected error %s", tt.code)
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code '%s', got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+func TestImage_VertexRequiresProject(t *testing.T) {
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "true")
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+
+	cmd := newImageCmd()
+	_, stderr, err := executeCommand(cmd, "A cute cat", "-m", "imagen-4", "-o", filepath.Join(t.TempDir(), "out.png"), "--enhance-prompt")
+
+	if err == nil {
+		t.Fatal("expected error for missing project")
+	}
+	if !strings.Contains(stderr, `"code":"missing_project"`) {
+		t.Errorf("expected error code 'missing_project', got: %s", stderr)
+	}
+}
+
+func TestImage_Imagen(t *testing.T) {
+	var path string
+	var params map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		var body struct {
+			Parameters map[string]any `json:"parameters"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		params = body.Parameters
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"predictions":[
+			{"bytesBase64Encoded":"` + base64.StdEncoding.EncodeToString([]byte("jpeg 1")) + `","mimeType":"image/jpeg"},
+			{"raiFilteredReason":"filtered for safety"},
+			{"bytesBase64Encoded":"` + base64.StdEncoding.EncodeToString([]byte("jpeg 2")) + `","mimeType":"image/jpeg"}
+		]}`))
+	}))
+	defer server.Close()
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", server.URL)
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+
+	output := filepath.Join(t.TempDir(), "cat.jpg")
+	cmd := newImageCmd()
+	stdout, stderr, err := executeCommand(cmd, "A cute cat", "-m", "imagen-4", "-o", output, "-n", "3", "-a", "16:9", "-s", "2K", "--person", "dont_allow")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	if !strings.HasSuffix(path, "/models/imagen-4.0-generate-001:predict") {
+		t.Errorf("unexpected request path: %s", path)
+	}
+	if params["sampleCount"] != float64(3) || params["aspectRatio"] != "16:9" || params["sampleImageSize"] != "2K" || params["personGeneration"] != "DONT_ALLOW" {
+		t.Errorf("unexpected parameters: %v", params)
+	}
+	if options, _ := params["outputOptions"].(map[string]any); options["mimeType"] != "image/jpeg" {
+		t.Errorf("expected JPEG output, got parameters %v", params)
+	}
+
+	var resp imageResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if len(resp.Files) != 2 || resp.Filtered != 1 || resp.Size != "2K" {
+		t.Fatalf("unexpected response: %s", stdout)
+	}
+	for i, file := range resp.Files {
+		data, _ := os.ReadFile(file)
+		if want := fmt.Sprintf("jpeg %d", i+1); string(data) != want || filepath.Ext(file) != ".jpg" {
+			t.Errorf("file %s: expected %q, got %q", file, want, data)
+		}
+	}
+}
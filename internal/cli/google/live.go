@@ -0,0 +1,443 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/spf13/cobra"
+	"google.golang.org/genai"
+)
+
+const defaultLiveModel = "gemini-2.5-flash-native-audio-preview-09-2025"
+
+// liveInputRate is the sample rate of the PCM the Live API takes; replies
+// come back in pcmFormat.
+const liveInputRate = 16000
+
+// After the input has ended, the session ends once the server has been
+// quiet for liveTurnGrace since a reply, or for liveIdleTimeout without one.
+var (
+	liveTurnGrace   = 2 * time.Second
+	liveIdleTimeout = 15 * time.Second
+)
+
+// Live event types
+const (
+	liveEventInput       = "input"
+	liveEventOutput      = "output"
+	liveEventInterrupted = "interrupted"
+	liveEventTurn        = "turn"
+	liveEventDone        = "done"
+)
+
+// liveEvent is a line of the NDJSON output. Input and output events carry
+// the transcripts of the user and the model as they arrive; a turn event
+// closes a reply with its full transcripts and seconds of audio. The done
+// event closes the session with everything said and the file written.
+type liveEvent struct {
+	Type     string     `json:"type"`
+	Turn     int        `json:"turn,omitempty"`
+	Text     string     `json:"text,omitempty"`
+	Input    string     `json:"input,omitempty"`
+	Duration float64    `json:"duration,omitempty"`
+	File     string     `json:"file,omitempty"`
+	Model    string     `json:"model,omitempty"`
+	Voice    string     `json:"voice,omitempty"`
+	Turns    int        `json:"turns,omitempty"`
+	Usage    *liveUsage `json:"usage,omitempty"`
+}
+
+type liveUsage struct {
+	InputTokens  int32 `json:"input_tokens"`
+	OutputTokens int32 `json:"output_tokens"`
+	TotalTokens  int32 `json:"total_tokens"`
+}
+
+// Live flags
+type liveFlags struct {
+	output     string
+	system     string
+	systemFile string
+	voice      string
+	model      string
+	speak      bool
+	input      common.StreamFlags
+}
+
+// Command
+var liveCmd = newLiveCmd()
+
+func newLiveCmd() *cobra.Command {
+	flags := &liveFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "live [audio-file]",
+		Short: "Talk to Gemini with a Live API speech session",
+		Long: `Talk to Gemini with a Live API session and record its spoken reply.
+
+The audio file, or PCM or WAV audio piped to stdin as it arrives, is streamed
+as the user's speech. The server detects the turns and the model answers each
+one by voice. Transcripts of both sides are printed as NDJSON events, and the
+replies are written to -o as WAV or played with --speak.`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLive(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path for the replies (.wav)")
+	cmd.Flags().StringVar(&flags.system, "system", "", "System instructions")
+	cmd.Flags().StringVar(&flags.systemFile, "system-file", "", "System instructions file")
+	cmd.Flags().StringVarP(&flags.voice, "voice", "v", "Kore", "Voice name")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", defaultLiveModel, "Live model name")
+	cmd.Flags().BoolVar(&flags.speak, "speak", false, "Play the replies as they arrive")
+	cmd.Flags().IntVar(&flags.input.SampleRate, "input-rate", 16000, "Sample rate of raw PCM input (WAV carries its own)")
+	cmd.Flags().IntVar(&flags.input.Channels, "input-channels", 1, "Channels of raw PCM input (WAV carries its own)")
+
+	return cmd
+}
+
+func runLive(cmd *cobra.Command, args []string, flags *liveFlags) error {
+	// Validate input
+	var inputFile string
+	if len(args) > 0 {
+		inputFile = args[0]
+		if _, err := os.Stat(inputFile); err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("file not found: %s", inputFile))
+		}
+	} else if f, ok := cmd.InOrStdin().(*os.File); ok {
+		if stat, _ := f.Stat(); stat != nil && stat.Mode()&os.ModeCharDevice != 0 {
+			return common.WriteError(cmd, "missing_input", "no audio provided, pass an audio file or pipe PCM or WAV audio to stdin")
+		}
+	}
+	if err := flags.input.Validate(); err != nil {
+		return common.WriteError(cmd, "invalid_parameter", err.Error())
+	}
+
+	// Validate output or speak
+	if flags.output == "" && !flags.speak {
+		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag or --speak")
+	}
+	var outputPath string
+	if flags.output != "" {
+		outputPath = common.DefaultExt(flags.output, ".wav")
+		if ext := strings.ToLower(filepath.Ext(outputPath)); ext != ".wav" {
+			return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported format '%s', only .wav is supported", ext))
+		}
+	}
+
+	// Get system instructions
+	if flags.system != "" && flags.systemFile != "" {
+		return common.WriteError(cmd, "conflicting_flags", "cannot use --system and --system-file together")
+	}
+	system := flags.system
+	if flags.systemFile != "" {
+		data, err := os.ReadFile(flags.systemFile)
+		if err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read system file: %s", err.Error()))
+		}
+		system = strings.TrimSpace(string(data))
+	}
+
+	// Validate voice
+	if !validVoices[flags.voice] {
+		return common.WriteError(cmd, "invalid_voice", fmt.Sprintf("voice '%s' is not a valid prebuilt voice", flags.voice))
+	}
+
+	// Check API key
+	apiKey := config.GetAPIKey("GEMINI_API_KEY", "GOOGLE_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("GEMINI_API_KEY", "GOOGLE_API_KEY"))
+	}
+
+	// Open the input as 16 kHz mono PCM
+	var audio io.Reader
+	if inputFile != "" {
+		f, err := os.Open(inputFile)
+		if err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read file: %s", err.Error()))
+		}
+		defer f.Close()
+		stream, err := common.DecodeAudio(f, filepath.Ext(inputFile), common.DecodeOptions{SampleRate: flags.input.SampleRate, Channels: flags.input.Channels})
+		if err != nil {
+			return common.WriteError(cmd, "invalid_audio", fmt.Sprintf("cannot decode audio: %s", err.Error()))
+		}
+		audio = common.ConvertPCM(stream, liveInputRate)
+	} else {
+		stream, err := flags.input.OpenPCMStream(cmd.InOrStdin(), liveInputRate)
+		if err != nil {
+			return common.WriteError(cmd, "invalid_audio", fmt.Sprintf("cannot read audio from stdin: %s", err.Error()))
+		}
+		audio = stream
+	}
+
+	// Create client and connect
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return common.WriteError(cmd, "client_error", fmt.Sprintf("failed to create client: %s", err.Error()))
+	}
+
+	liveConfig := &genai.LiveConnectConfig{
+		ResponseModalities:       []genai.Modality{genai.ModalityAudio},
+		SpeechConfig:             newSpeechConfig(flags.voice, nil),
+		InputAudioTranscription:  &genai.AudioTranscriptionConfig{},
+		OutputAudioTranscription: &genai.AudioTranscriptionConfig{},
+	}
+	if system != "" {
+		liveConfig.SystemInstruction = genai.NewContentFromText(system, genai.RoleUser)
+	}
+	session, err := client.Live.Connect(ctx, flags.model, liveConfig)
+	if err != nil {
+		return handleAPIError(cmd, err)
+	}
+	defer session.Close()
+
+	var player *common.StreamPlayer
+	if flags.speak {
+		player = common.NewStreamPlayer(".pcm", common.DecodeOptions{SampleRate: pcmFormat.SampleRate})
+		defer player.Abort(nil)
+	}
+
+	live := &liveSession{
+		session: session,
+		events:  json.NewEncoder(cmd.OutOrStdout()),
+		player:  player,
+		turn:    1,
+	}
+	if code, err := live.run(audio); err != nil {
+		if code == "" {
+			return handleAPIError(cmd, err)
+		}
+		return common.WriteError(cmd, code, err.Error())
+	}
+	if len(live.audio) == 0 {
+		return common.WriteError(cmd, "no_audio", "no audio reply received")
+	}
+
+	// Save the replies
+	var absPath string
+	if outputPath != "" {
+		if absPath, err = common.WriteOutput(outputPath, common.PCMToWAV(live.audio, pcmFormat), common.OutputVars{}); err != nil {
+			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		}
+	}
+	if player != nil {
+		if err := player.Close(); err != nil {
+			return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", err.Error()))
+		}
+	}
+
+	return live.events.Encode(liveEvent{
+		Type:     liveEventDone,
+		Text:     strings.Join(live.outputs, "\n"),
+		Input:    strings.Join(live.inputs, "\n"),
+		Duration: pcmSeconds(len(live.audio)),
+		File:     absPath,
+		Model:    flags.model,
+		Voice:    flags.voice,
+		Turns:    live.turn - 1,
+		Usage:    live.usage,
+	})
+}
+
+// liveSession streams the input and collects the replies of a Live API
+// session.
+type liveSession struct {
+	session *genai.Session
+	events  *json.Encoder
+	player  *common.StreamPlayer
+
+	turn       int
+	input      strings.Builder // transcripts of the current turn
+	output     strings.Builder
+	turnAudio  int
+	replying   bool // the model has answered since the last turn ended
+	inputs     []string
+	outputs    []string
+	audio      []byte
+	usage      *liveUsage
+	inputEnded bool
+	replied    bool // a turn ended after the input did
+}
+
+type liveMessage struct {
+	msg *genai.LiveServerMessage
+	err error
+}
+
+// run streams audio once the session is set up and handles server messages
+// until the input has ended and the replies are done. A failure returns an
+// error code, or none for errors of the API.
+func (l *liveSession) run(audio io.Reader) (string, error) {
+	done := make(chan struct{})
+	defer close(done)
+	messages := make(chan liveMessage, 16)
+	go func() {
+		for {
+			msg, err := l.session.Receive()
+			select {
+			case messages <- liveMessage{msg, err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	type sendResult struct {
+		code string
+		err  error
+	}
+	sent := make(chan sendResult, 1)
+	started := false
+
+	for {
+		var idle <-chan time.Time
+		if l.inputEnded && !l.replying {
+			if l.replied {
+				idle = time.After(liveTurnGrace)
+			} else {
+				idle = time.After(liveIdleTimeout)
+			}
+		}
+
+		select {
+		case m := <-messages:
+			if m.err != nil {
+				if l.inputEnded && l.replied {
+					return "", nil
+				}
+				return "", m.err
+			}
+			if m.msg.SetupComplete != nil && !started {
+				started = true
+				go func() {
+					code, err := l.sendAudio(audio)
+					sent <- sendResult{code, err}
+				}()
+			}
+			if code, err := l.handle(m.msg); err != nil {
+				return code, err
+			}
+		case r := <-sent:
+			if r.err != nil {
+				return r.code, r.err
+			}
+			l.inputEnded = true
+		case <-idle:
+			return "", nil
+		}
+	}
+}
+
+// sendAudio streams the input in 100 ms messages and marks its end.
+func (l *liveSession) sendAudio(audio io.Reader) (string, error) {
+	buf := make([]byte, liveInputRate*2/10)
+	for {
+		n, readErr := io.ReadFull(audio, buf)
+		if n > 0 {
+			blob := &genai.Blob{Data: buf[:n], MIMEType: fmt.Sprintf("audio/pcm;rate=%d", liveInputRate)}
+			if err := l.session.SendRealtimeInput(genai.LiveRealtimeInput{Audio: blob}); err != nil {
+				return "websocket_error", fmt.Errorf("cannot send audio: %w", err)
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return "invalid_audio", fmt.Errorf("cannot read audio: %w", readErr)
+		}
+	}
+	if err := l.session.SendRealtimeInput(genai.LiveRealtimeInput{AudioStreamEnd: true}); err != nil {
+		return "websocket_error", fmt.Errorf("cannot send end of audio: %w", err)
+	}
+	return "", nil
+}
+
+// handle writes the events of a server message and collects its audio. A
+// failure returns its error code.
+func (l *liveSession) handle(msg *genai.LiveServerMessage) (string, error) {
+	if u := msg.UsageMetadata; u != nil {
+		l.usage = &liveUsage{InputTokens: u.PromptTokenCount, OutputTokens: u.ResponseTokenCount, TotalTokens: u.TotalTokenCount}
+	}
+	content := msg.ServerContent
+	if content == nil {
+		return "", nil
+	}
+
+	if t := content.InputTranscription; t != nil && t.Text != "" {
+		l.input.WriteString(t.Text)
+		if err := l.events.Encode(liveEvent{Type: liveEventInput, Turn: l.turn, Text: t.Text}); err != nil {
+			return "output_error", err
+		}
+	}
+	if content.ModelTurn != nil {
+		l.replying = true
+		for _, part := range content.ModelTurn.Parts {
+			if part.InlineData == nil || !strings.HasPrefix(part.InlineData.MIMEType, "audio/") {
+				continue
+			}
+			l.audio = append(l.audio, part.InlineData.Data...)
+			l.turnAudio += len(part.InlineData.Data)
+			if l.player != nil {
+				if _, err := l.player.Write(part.InlineData.Data); err != nil {
+					return "playback_error", fmt.Errorf("cannot play audio: %w", err)
+				}
+			}
+		}
+	}
+	if t := content.OutputTranscription; t != nil && t.Text != "" {
+		l.replying = true
+		l.output.WriteString(t.Text)
+		if err := l.events.Encode(liveEvent{Type: liveEventOutput, Turn: l.turn, Text: t.Text}); err != nil {
+			return "output_error", err
+		}
+	}
+	if content.Interrupted {
+		if err := l.events.Encode(liveEvent{Type: liveEventInterrupted, Turn: l.turn}); err != nil {
+			return "output_error", err
+		}
+	}
+
+	if content.TurnComplete {
+		input, output := strings.TrimSpace(l.input.String()), strings.TrimSpace(l.output.String())
+		if err := l.events.Encode(liveEvent{Type: liveEventTurn, Turn: l.turn, Text: output, Input: input, Duration: pcmSeconds(l.turnAudio)}); err != nil {
+			return "output_error", err
+		}
+		if input != "" {
+			l.inputs = append(l.inputs, input)
+		}
+		if output != "" {
+			l.outputs = append(l.outputs, output)
+		}
+		l.input.Reset()
+		l.output.Reset()
+		l.turnAudio = 0
+		l.turn++
+		l.replying = false
+		l.replied = l.inputEnded
+	}
+	return "", nil
+}
+
+// pcmSeconds is the duration of n bytes of pcmFormat audio.
+func pcmSeconds(n int) float64 {
+	seconds := float64(n) / float64(pcmFormat.SampleRate*pcmFormat.Channels*pcmFormat.Encoding.BytesPerSample())
+	return roundSeconds(seconds)
+}
@@ -0,0 +1,208 @@
+package google
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/gorilla/websocket"
+)
+
+func TestLive_Validation(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "hello.wav")
+	os.WriteFile(audio, common.PCMToWAV(make([]byte, 3200), common.AudioFormat{SampleRate: 16000, Channels: 1, Encoding: common.PCMS16LE}), 0644)
+
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"file not found", []string{filepath.Join(dir, "nope.wav"), "-o", "out.wav"}, "file_not_found"},
+		{"missing output", []string{audio}, "missing_output"},
+		{"unsupported format", []string{audio, "-o", "out.mp3"}, "unsupported_format"},
+		{"conflicting system", []string{audio, "-o", "out.wav", "--system", "Be brief.", "--system-file", "system.txt"}, "conflicting_flags"},
+		{"system file not found", []string{audio, "-o", "out.wav", "--system-file", filepath.Join(dir, "system.txt")}, "file_not_found"},
+		{"invalid voice", []string{audio, "-o", "out.wav", "-v", "Nobody"}, "invalid_voice"},
+		{"invalid input rate", []string{audio, "-o", "out.wav", "--input-rate", "100"}, "invalid_parameter"},
+		{"missing api key", []string{audio, "-o", "out.wav"}, "missing_api_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			_, stderr, err := executeCommand(newLiveCmd(), tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+// liveServer fakes a Live API session: it answers the setup, takes audio
+// until the end of the stream, then replies with one turn of 0.5s of audio.
+// It returns the setup message and the bytes of audio received.
+func liveServer(t *testing.T) func() (map[string]any, int) {
+	var mu sync.Mutex
+	var setup map[string]any
+	received := 0
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "GenerativeService.BidiGenerateContent") {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var msg map[string]any
+		if conn.ReadJSON(&msg) != nil {
+			return
+		}
+		mu.Lock()
+		setup = msg
+		mu.Unlock()
+		conn.WriteJSON(map[string]any{"setupComplete": map[string]any{}})
+
+		for {
+			var input struct {
+				RealtimeInput struct {
+					Audio *struct {
+						Data     []byte `json:"data"`
+						MIMEType string `json:"mimeType"`
+					} `json:"audio"`
+					AudioStreamEnd bool `json:"audioStreamEnd"`
+				} `json:"realtimeInput"`
+			}
+			if conn.ReadJSON(&input) != nil {
+				return
+			}
+			if input.RealtimeInput.Audio != nil {
+				mu.Lock()
+				received += len(input.RealtimeInput.Audio.Data)
+				mu.Unlock()
+			}
+			if input.RealtimeInput.AudioStreamEnd {
+				break
+			}
+		}
+
+		reply := base64.StdEncoding.EncodeToString(make([]byte, 12000))
+		for _, msg := range []string{
+			`{"serverContent":{"inputTranscription":{"text":"What time "}}}`,
+			`{"serverContent":{"inputTranscription":{"text":"is it?"}}}`,
+			fmt.Sprintf(`{"serverContent":{"modelTurn":{"parts":[{"inlineData":{"mimeType":"audio/pcm;rate=24000","data":"%s"}}]}}}`, reply),
+			`{"serverContent":{"outputTranscription":{"text":"It is noon."}}}`,
+			fmt.Sprintf(`{"serverContent":{"modelTurn":{"parts":[{"inlineData":{"mimeType":"audio/pcm;rate=24000","data":"%s"}}]}}}`, reply),
+			`{"serverContent":{"turnComplete":true},"usageMetadata":{"promptTokenCount":20,"responseTokenCount":30,"totalTokenCount":50}}`,
+		} {
+			conn.WriteMessage(websocket.TextMessage, []byte(msg))
+		}
+		// Keep the session open like the real server
+		conn.ReadMessage()
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", strings.Replace(server.URL, "http://", "ws://", 1))
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+
+	grace := liveTurnGrace
+	liveTurnGrace = 50 * time.Millisecond
+	t.Cleanup(func() { liveTurnGrace = grace })
+
+	return func() (map[string]any, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return setup, received
+	}
+}
+
+func TestLive_File(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	state := liveServer(t)
+	dir := t.TempDir()
+	// 1s of 8 kHz stereo input, resampled to 16 kHz mono
+	input := filepath.Join(dir, "question.wav")
+	os.WriteFile(input, common.PCMToWAV(make([]byte, 8000*2*2), common.AudioFormat{SampleRate: 8000, Channels: 2, Encoding: common.PCMS16LE}), 0644)
+	output := filepath.Join(dir, "reply.wav")
+
+	stdout, stderr, err := executeCommand(newLiveCmd(), input, "-o", output, "-v", "Puck", "--system", "Answer briefly.")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var events []liveEvent
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		var e liveEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid event %q: %v", scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	if got := strings.Join(types, ","); got != "input,input,output,turn,done" {
+		t.Fatalf("unexpected events: %s\n%s", got, stdout)
+	}
+	turn := events[3]
+	if turn.Turn != 1 || turn.Input != "What time is it?" || turn.Text != "It is noon." || turn.Duration != 0.5 {
+		t.Errorf("unexpected turn event: %+v", turn)
+	}
+	done := events[4]
+	if done.File != output || done.Turns != 1 || done.Duration != 0.5 || done.Text != "It is noon." || done.Voice != "Puck" || done.Model != defaultLiveModel || done.Usage == nil || done.Usage.TotalTokens != 50 {
+		t.Errorf("unexpected done event: %+v", done)
+	}
+
+	setup, received := state()
+	data, _ := json.Marshal(setup)
+	for _, want := range []string{`"voiceName":"Puck"`, `"text":"Answer briefly."`, `"inputAudioTranscription":{}`, `"outputAudioTranscription":{}`, `"responseModalities":["AUDIO"]`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected setup to contain %s, got %s", want, data)
+		}
+	}
+	if received < 31000 || received > 32000 {
+		t.Errorf("expected about 1s of 16 kHz audio, got %d bytes", received)
+	}
+
+	info, err := os.Stat(output)
+	if err != nil || info.Size() != 44+24000 {
+		t.Errorf("expected 0.5s of WAV, got %v, %v", info, err)
+	}
+}
+
+func TestLive_Stdin(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	state := liveServer(t)
+	output := filepath.Join(t.TempDir(), "reply.wav")
+
+	cmd := newLiveCmd()
+	cmd.SetIn(strings.NewReader(string(make([]byte, 6400))))
+	stdout, stderr, err := executeCommand(cmd, "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"type":"done"`) {
+		t.Errorf("expected a done event, got %s", stdout)
+	}
+	if _, received := state(); received != 6400 {
+		t.Errorf("expected 6400 bytes of audio, got %d", received)
+	}
+}
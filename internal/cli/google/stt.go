@@ -2,10 +2,14 @@ package google
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
@@ -25,8 +29,23 @@ var sttSupportedFormats = map[string]string{
 	".webm": "audio/webm",
 }
 
-// Max file size (20 MB for inline, larger files use Files API)
-const sttMaxInlineSize = 20 * 1024 * 1024
+// Gemini rejects requests over 20 MB, and inline data is base64 encoded.
+// sttMaxInlineSize is the encoded size inline audio may use, leaving room
+// for the rest of the request; larger files use the Files API.
+const sttMaxInlineSize = 19 * 1024 * 1024
+
+// inlineSize returns the size of n bytes of data once encoded in a request.
+func inlineSize(n int64) int64 {
+	return int64(base64.StdEncoding.EncodedLen(int(n)))
+}
+
+// Larger WAV and MP3 files are transcribed in chunks of at most
+// sttChunkDuration seconds instead, which keeps each response within the
+// output limit and its timestamps accurate. Chunks are 16 kHz mono WAV, so
+// 400 s (12.8 MB) still fits inline once encoded.
+var sttChunkDuration = 400.0
+
+var errNoTranscription = errors.New("no transcription generated in response")
 
 // STT response types
 type sttResponse struct {
@@ -35,6 +54,7 @@ type sttResponse struct {
 	Language string       `json:"language,omitempty"`
 	Model    string       `json:"model,omitempty"`
 	Segments []sttSegment `json:"segments,omitempty"`
+	Chunks   int          `json:"chunks,omitempty"`
 	File     string       `json:"file,omitempty"`
 }
 
@@ -65,6 +85,7 @@ type sttFlags struct {
 	timestamps bool
 	speakers   bool
 	model      string
+	subtitles  common.SubtitleOptions
 }
 
 // Command
@@ -85,11 +106,12 @@ func newSTTCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&flags.file, "file", "f", "", "Input audio file (alternative to positional arg)")
-	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (prints to stdout if not set)")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file (.json, .txt, .srt, .vtt, .ass, .ttml)")
 	cmd.Flags().StringVarP(&flags.language, "language", "l", "", "Language hint (ISO 639-1 code, e.g., en, zh, ja)")
 	cmd.Flags().BoolVarP(&flags.timestamps, "timestamps", "t", false, "Include timestamps in output")
 	cmd.Flags().BoolVarP(&flags.speakers, "speakers", "s", false, "Enable speaker diarization")
 	cmd.Flags().StringVarP(&flags.model, "model", "m", "flash", "Model: flash")
+	common.AddSubtitleFlags(cmd, &flags.subtitles)
 
 	return cmd
 }
@@ -127,9 +149,29 @@ func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
 		return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported audio format '%s', supported: %s", ext, strings.Join(validFormats, ", ")))
 	}
 
-	// Check file size (warn if too large, but still proceed with Files API)
-	if info.Size() > 100*1024*1024 { // 100 MB limit
-		return common.WriteError(cmd, "file_too_large", fmt.Sprintf("audio file size %d bytes exceeds 100 MB limit", info.Size()))
+	// Split large WAV and MP3 files, others proceed with the Files API
+	var chunks []common.AudioChunk
+	var splitErr error
+	if inlineSize(info.Size()) > sttMaxInlineSize && common.CanSplitAudio(ext) {
+		chunks, splitErr = splitSTTAudio(audioFile, ext)
+	}
+
+	// Check file size
+	if chunks == nil && info.Size() > 100*1024*1024 { // 100 MB limit
+		msg := fmt.Sprintf("audio file size %d bytes exceeds 100 MB limit", info.Size())
+		if splitErr != nil {
+			msg += fmt.Sprintf(" and cannot be split: %s", splitErr.Error())
+		}
+		return common.WriteError(cmd, "file_too_large", msg)
+	}
+
+	// Subtitle outputs need segment timestamps
+	outputFormat := common.TranscriptFormat(flags.output, common.TranscriptJSON)
+	if common.IsTimedFormat(outputFormat) {
+		flags.timestamps = true
+	}
+	if err := flags.subtitles.Validate(); err != nil {
+		return common.WriteError(cmd, "invalid_parameter", err.Error())
 	}
 
 	// Check API key
@@ -151,42 +193,120 @@ func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
 	// Build prompt for transcription
 	prompt := buildTranscriptionPrompt(flags.language, flags.timestamps, flags.speakers)
 
-	// Prepare audio content
-	var parts []*genai.Part
-	parts = append(parts, genai.NewPartFromText(prompt))
+	// Model ID
+	modelID := "gemini-2.5-flash"
 
-	// For smaller files, use inline data; for larger files, use Files API
-	if info.Size() <= sttMaxInlineSize {
-		// Read audio file and embed inline
-		audioData, err := os.ReadFile(audioFile)
+	var transcript *common.Transcript
+	resp := sttResponse{
+		Success: true,
+		Model:   modelID,
+	}
+	if chunks != nil {
+		parts, err := common.TranscribeChunks(ctx, chunks, common.DefaultChunkConcurrency, func(ctx context.Context, _ int, chunk common.AudioChunk) (*common.Transcript, error) {
+			audio := &genai.Part{InlineData: &genai.Blob{MIMEType: "audio/wav", Data: chunk.Data}}
+			geminiResp, err := transcribeAudio(ctx, client, modelID, prompt, audio)
+			if err != nil {
+				return nil, err
+			}
+			return newTranscript(geminiResp), nil
+		})
 		if err != nil {
-			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read audio file: %s", err.Error()))
+			return handleTranscribeError(cmd, err)
+		}
+		transcript = common.MergeTranscripts(chunks, parts)
+
+		resp.Text = transcript.Text
+		resp.Language = transcript.Language
+		for _, seg := range transcript.Segments {
+			segment := sttSegment{Speaker: seg.Speaker, Text: seg.Text}
+			if flags.timestamps {
+				segment.Start, segment.End = formatClockTime(seg.Start), formatClockTime(seg.End)
+			}
+			resp.Segments = append(resp.Segments, segment)
+		}
+		if len(chunks) > 1 {
+			resp.Chunks = len(chunks)
 		}
-		parts = append(parts, &genai.Part{
-			InlineData: &genai.Blob{
-				MIMEType: mimeType,
-				Data:     audioData,
-			},
-		})
 	} else {
-		// Upload file using Files API
-		uploadedFile, err := client.Files.UploadFromPath(ctx, audioFile, &genai.UploadFileConfig{
-			MIMEType: mimeType,
-		})
+		// For smaller files, use inline data; for larger files, use Files API
+		var audio *genai.Part
+		if inlineSize(info.Size()) <= sttMaxInlineSize {
+			// Read audio file and embed inline
+			audioData, err := os.ReadFile(audioFile)
+			if err != nil {
+				return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read audio file: %s", err.Error()))
+			}
+			audio = &genai.Part{
+				InlineData: &genai.Blob{
+					MIMEType: mimeType,
+					Data:     audioData,
+				},
+			}
+		} else {
+			// Upload file using Files API
+			uploadedFile, err := client.Files.UploadFromPath(ctx, audioFile, &genai.UploadFileConfig{
+				MIMEType: mimeType,
+			})
+			if err != nil {
+				return common.WriteError(cmd, "upload_error", fmt.Sprintf("failed to upload audio file: %s", err.Error()))
+			}
+			// Schedule deletion after we're done
+			defer func() {
+				_, _ = client.Files.Delete(ctx, uploadedFile.Name, nil)
+			}()
+
+			audio = genai.NewPartFromFile(*uploadedFile)
+		}
+
+		geminiResp, err := transcribeAudio(ctx, client, modelID, prompt, audio)
 		if err != nil {
-			return common.WriteError(cmd, "upload_error", fmt.Sprintf("failed to upload audio file: %s", err.Error()))
+			return handleTranscribeError(cmd, err)
 		}
-		// Schedule deletion after we're done
-		defer func() {
-			_, _ = client.Files.Delete(ctx, uploadedFile.Name, nil)
-		}()
+		transcript = newTranscript(geminiResp)
+
+		resp.Text = geminiResp.Text
+		resp.Language = geminiResp.Language
+
+		// Add segments if available
+		if len(geminiResp.Segments) > 0 {
+			resp.Segments = make([]sttSegment, len(geminiResp.Segments))
+			for i, seg := range geminiResp.Segments {
+				resp.Segments[i] = sttSegment{
+					Speaker: seg.Speaker,
+					Start:   seg.Start,
+					End:     seg.End,
+					Text:    seg.Text,
+				}
+			}
+		}
+	}
 
-		parts = append(parts, genai.NewPartFromFile(*uploadedFile))
+	// Write to output file if specified
+	if flags.output != "" {
+		absPath, err := common.WriteTranscript(flags.output, transcript, outputFormat, flags.subtitles)
+		if err != nil {
+			return common.WriteError(cmd, common.TranscriptErrorCode(err), fmt.Sprintf("cannot write output file: %s", err.Error()))
+		}
+		resp.File = absPath
 	}
 
-	// Model ID
-	modelID := "gemini-2.5-flash"
+	return common.WriteSuccess(cmd, resp)
+}
+
+// buildTranscriptionPrompt creates the prompt for transcription
+// splitSTTAudio splits a WAV or MP3 file that is too large to send inline
+// into chunks.
+func splitSTTAudio(audioFile, ext string) ([]common.AudioChunk, error) {
+	f, err := os.Open(audioFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return common.SplitAudio(f, ext, sttChunkDuration, common.DefaultChunkOverlap)
+}
 
+// transcribeAudio asks the model to transcribe audio and parses its answer.
+func transcribeAudio(ctx context.Context, client *genai.Client, modelID, prompt string, audio *genai.Part) (geminiSTTResponse, error) {
 	// Build config
 	config := &genai.GenerateContentConfig{
 		ResponseMIMEType: "application/json",
@@ -194,18 +314,22 @@ func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
 
 	// Build content
 	contents := []*genai.Content{
-		genai.NewContentFromParts(parts, genai.RoleUser),
+		genai.NewContentFromParts([]*genai.Part{genai.NewPartFromText(prompt), audio}, genai.RoleUser),
 	}
 
 	// Call API
 	result, err := client.Models.GenerateContent(ctx, modelID, contents, config)
 	if err != nil {
-		return handleAPIError(cmd, err)
+		return geminiSTTResponse{}, err
 	}
+	return parseTranscription(result)
+}
 
+// parseTranscription reads the JSON transcription from the model's answer.
+func parseTranscription(result *genai.GenerateContentResponse) (geminiSTTResponse, error) {
 	// Extract text from response
 	responseText := ""
-	if result.Candidates != nil && len(result.Candidates) > 0 {
+	if len(result.Candidates) > 0 && result.Candidates[0].Content != nil {
 		for _, part := range result.Candidates[0].Content.Parts {
 			if part.Text != "" {
 				responseText = part.Text
@@ -215,7 +339,7 @@ func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
 	}
 
 	if responseText == "" {
-		return common.WriteError(cmd, "no_transcription", "no transcription generated in response")
+		return geminiSTTResponse{}, errNoTranscription
 	}
 
 	// Parse the JSON response
@@ -224,46 +348,16 @@ func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
 		// If parsing fails, treat the whole response as plain text
 		geminiResp = geminiSTTResponse{Text: responseText}
 	}
+	return geminiResp, nil
+}
 
-	// Build response
-	resp := sttResponse{
-		Success:  true,
-		Text:     geminiResp.Text,
-		Language: geminiResp.Language,
-		Model:    modelID,
-	}
-
-	// Add segments if available
-	if len(geminiResp.Segments) > 0 {
-		resp.Segments = make([]sttSegment, len(geminiResp.Segments))
-		for i, seg := range geminiResp.Segments {
-			resp.Segments[i] = sttSegment{
-				Speaker: seg.Speaker,
-				Start:   seg.Start,
-				End:     seg.End,
-				Text:    seg.Text,
-			}
-		}
-	}
-
-	// Write to output file if specified
-	if flags.output != "" {
-		absPath, err := filepath.Abs(flags.output)
-		if err != nil {
-			absPath = flags.output
-		}
-
-		output, _ := json.MarshalIndent(resp, "", "  ")
-		if err := os.WriteFile(absPath, output, 0644); err != nil {
-			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
-		}
-		resp.File = absPath
+func handleTranscribeError(cmd *cobra.Command, err error) error {
+	if errors.Is(err, errNoTranscription) {
+		return common.WriteError(cmd, "no_transcription", err.Error())
 	}
-
-	return common.WriteSuccess(cmd, resp)
+	return handleAPIError(cmd, err)
 }
 
-// buildTranscriptionPrompt creates the prompt for transcription
 func buildTranscriptionPrompt(language string, timestamps, speakers bool) string {
 	var sb strings.Builder
 
@@ -285,8 +379,8 @@ func buildTranscriptionPrompt(language string, timestamps, speakers bool) string
 			sb.WriteString("      \"speaker\": \"<speaker identifier>\",\n")
 		}
 		if timestamps {
-			sb.WriteString("      \"start\": \"<start time in MM:SS format>\",\n")
-			sb.WriteString("      \"end\": \"<end time in MM:SS format>\",\n")
+			sb.WriteString("      \"start\": \"<start time in MM:SS.mmm format>\",\n")
+			sb.WriteString("      \"end\": \"<end time in MM:SS.mmm format>\",\n")
 		}
 		sb.WriteString("      \"text\": \"<segment text>\"\n")
 		sb.WriteString("    }\n")
@@ -296,7 +390,7 @@ func buildTranscriptionPrompt(language string, timestamps, speakers bool) string
 	sb.WriteString("}\n")
 
 	if timestamps {
-		sb.WriteString("\nInclude timestamps for each segment.")
+		sb.WriteString("\nInclude timestamps for each segment, with millisecond precision. Keep segments to one sentence each.")
 	}
 	if speakers {
 		sb.WriteString("\nIdentify different speakers and label them (Speaker 1, Speaker 2, etc.).")
@@ -304,3 +398,47 @@ func buildTranscriptionPrompt(language string, timestamps, speakers bool) string
 
 	return sb.String()
 }
+
+// newTranscript converts the Gemini transcription. Segment times that
+// cannot be parsed are left at zero.
+func newTranscript(resp geminiSTTResponse) *common.Transcript {
+	t := &common.Transcript{
+		Text:     resp.Text,
+		Language: resp.Language,
+	}
+	for _, seg := range resp.Segments {
+		start, _ := parseClockTime(seg.Start)
+		end, _ := parseClockTime(seg.End)
+		t.Segments = append(t.Segments, common.TranscriptSegment{Start: start, End: end, Text: seg.Text, Speaker: seg.Speaker})
+	}
+	t.Normalize()
+	return t
+}
+
+// formatClockTime formats seconds as "MM:SS.mmm", or "H:MM:SS.mmm" from an
+// hour on, the way the model is asked to.
+func formatClockTime(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	h, m, s := ms/3600000, ms/60000%60, float64(ms%60000)/1000
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%06.3f", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%06.3f", m, s)
+}
+
+// parseClockTime parses "HH:MM:SS.mmm", "MM:SS.mmm" or plain seconds.
+func parseClockTime(value string) (float64, error) {
+	value = strings.TrimSpace(strings.ReplaceAll(value, ",", "."))
+	if value == "" {
+		return 0, nil
+	}
+	var seconds float64
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %q", value)
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds, nil
+}
@@ -3,8 +3,13 @@ package google
 import (
 	"bytes"
 	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
@@ -257,3 +262,117 @@ func TestBuildTranscriptionPrompt(t *testing.T) {
 		})
 	}
 }
+
+func TestParseClockTime(t *testing.T) {
+	tests := map[string]float64{
+		"":            0,
+		"00:05":       5,
+		"01:02.500":   62.5,
+		"1:00:01,250": 3601.25,
+		"12.75":       12.75,
+	}
+	for value, want := range tests {
+		got, err := parseClockTime(value)
+		if err != nil || math.Abs(got-want) > 1e-9 {
+			t.Errorf("parseClockTime(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	if _, err := parseClockTime("soon"); err == nil {
+		t.Error("expected an error for an invalid time")
+	}
+}
+
+func TestFormatClockTime(t *testing.T) {
+	tests := map[float64]string{
+		0:        "00:00.000",
+		62.5:     "01:02.500",
+		3601.25:  "1:00:01.250",
+		59.9999:  "01:00.000",
+		125.0004: "02:05.000",
+	}
+	for seconds, want := range tests {
+		if got := formatClockTime(seconds); got != want {
+			t.Errorf("formatClockTime(%v) = %q, want %q", seconds, got, want)
+		}
+	}
+}
+
+func TestSTT_TranscribesLargeFileInChunks(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		answer := `{"text":"Part.","language":"en","segments":[{"start":"00:10.000","end":"00:12.000","text":"Part."}]}`
+		body, _ := json.Marshal(map[string]any{"candidates": []any{map[string]any{"content": map[string]any{"parts": []any{map[string]any{"text": answer}}}}}})
+		w.Write(body)
+	}))
+	defer server.Close()
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", server.URL)
+
+	defer func(d float64) { sttChunkDuration = d }(sttChunkDuration)
+	sttChunkDuration = 60
+
+	// 21 MB of 44.1 kHz stereo silence is about 125 seconds
+	audio := filepath.Join(t.TempDir(), "long.wav")
+	format := common.AudioFormat{SampleRate: 44100, Channels: 2, Encoding: common.PCMS16LE}
+	os.WriteFile(audio, common.PCMToWAV(make([]byte, 21*1024*1024), format), 0644)
+
+	cmd := newSTTCmd()
+	stdout, stderr, err := executeCommand(cmd, audio, "--timestamps")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	var resp sttResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp.Chunks != 3 || requests.Load() != 3 || resp.Text != "Part. Part. Part." {
+		t.Fatalf("unexpected response: %s", stdout)
+	}
+	if resp.Segments[0].Start != "00:10.000" || !strings.HasPrefix(resp.Segments[1].Start, "01:") {
+		t.Errorf("expected segments shifted by the chunk offsets, got %+v", resp.Segments)
+	}
+}
+
+func TestNewTranscript(t *testing.T) {
+	var resp geminiSTTResponse
+	body := `{"text":"Hello. Hi.","language":"en","segments":[
+		{"speaker":"Speaker 1","start":"00:00.000","end":"00:01.200","text":"Hello."},
+		{"speaker":"Speaker 2","start":"00:01.500","end":"00:02.000","text":"Hi."}]}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	transcript := newTranscript(resp)
+	if len(transcript.Segments) != 2 || transcript.Segments[0].End != 1.2 || transcript.Segments[1].Start != 1.5 {
+		t.Errorf("unexpected segments: %+v", transcript.Segments)
+	}
+	if len(transcript.Speakers) != 2 || transcript.Speakers[0] != "Speaker 1" {
+		t.Errorf("unexpected speakers: %q", transcript.Speakers)
+	}
+}
+
+func TestSTT_InvalidMaxLineLength(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "test.mp3")
+	if err := os.WriteFile(tmpFile, []byte("test audio data"), 0644); err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+
+	cmd := newSTTCmd()
+	errOut := &bytes.Buffer{}
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(errOut)
+	cmd.SetArgs([]string{tmpFile, "-o", "out.srt", "--max-line-length", "0"})
+
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	var resp common.ErrorResponse
+	if err := json.Unmarshal(errOut.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse error response: %v", err)
+	}
+	if resp.Error.Code != "invalid_parameter" {
+		t.Errorf("expected error code 'invalid_parameter', got '%s'", resp.Error.Code)
+	}
+}
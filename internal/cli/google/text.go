@@ -0,0 +1,265 @@
+package google
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/spf13/cobra"
+	"google.golang.org/genai"
+)
+
+// Model name mapping for text generation
+var textModelIDs = map[string]string{
+	"flash":      "gemini-2.5-flash",
+	"flash-lite": "gemini-2.5-flash-lite",
+	"pro":        "gemini-2.5-pro",
+}
+
+// Response IDs name the stored conversations
+var textResponseIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+// Text flags
+type textFlags struct {
+	promptFile  string
+	system      string
+	systemFile  string
+	schema      string
+	continueID  string
+	model       string
+	temperature float64
+	maxTokens   int
+}
+
+type textResponse struct {
+	Success    bool   `json:"success"`
+	Text       any    `json:"text"`
+	Model      string `json:"model"`
+	ResponseID string `json:"response_id"`
+}
+
+// textConversation is the history up to a response, stored under its ID so
+// that --continue can carry on from it. Gemini keeps no state between calls.
+type textConversation struct {
+	System  string           `json:"system,omitempty"`
+	History []*genai.Content `json:"history"`
+}
+
+// Command
+var textCmd = newTextCmd()
+
+func newTextCmd() *cobra.Command {
+	flags := &textFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "text [prompt]",
+		Short: "Generate text using Google Gemini",
+		Long: `Generate text using Google Gemini models.
+
+The prompt is read from the argument, --prompt-file or stdin. Use --schema for
+output in JSON matching a schema, and --continue with the response_id of a
+previous call to carry on the conversation. Conversations are kept locally,
+as Gemini keeps no state between calls.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runText(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.promptFile, "prompt-file", "", "Input prompt file")
+	cmd.Flags().StringVar(&flags.system, "system", "", "System instructions")
+	cmd.Flags().StringVar(&flags.systemFile, "system-file", "", "System instructions file")
+	cmd.Flags().StringVar(&flags.schema, "schema", "", "JSON schema of the output (file or inline JSON)")
+	cmd.Flags().StringVarP(&flags.continueID, "continue", "c", "", "Previous response ID for multi-turn conversation")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", "flash", "Model: flash, flash-lite, pro")
+	cmd.Flags().Float64VarP(&flags.temperature, "temperature", "t", 1, "Sampling temperature (0-2)")
+	cmd.Flags().IntVar(&flags.maxTokens, "max-tokens", 0, "Maximum output tokens (0 = model default)")
+
+	return cmd
+}
+
+func runText(cmd *cobra.Command, args []string, flags *textFlags) error {
+	// Get prompt
+	prompt, err := getPrompt(args, flags.promptFile, cmd.InOrStdin())
+	if err != nil {
+		return common.WriteError(cmd, "missing_prompt", err.Error())
+	}
+
+	// Get system instructions
+	if flags.system != "" && flags.systemFile != "" {
+		return common.WriteError(cmd, "conflicting_flags", "cannot use --system and --system-file together")
+	}
+	system := flags.system
+	if flags.systemFile != "" {
+		data, err := os.ReadFile(flags.systemFile)
+		if err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read system file: %s", err.Error()))
+		}
+		system = strings.TrimSpace(string(data))
+	}
+
+	// Validate model
+	modelID, ok := textModelIDs[flags.model]
+	if !ok {
+		return common.WriteError(cmd, "invalid_model", fmt.Sprintf("invalid model '%s', use 'flash', 'flash-lite' or 'pro'", flags.model))
+	}
+
+	// Validate sampling
+	if flags.temperature < 0 || flags.temperature > 2 {
+		return common.WriteError(cmd, "invalid_temperature", "temperature must be between 0 and 2")
+	}
+	if flags.maxTokens < 0 {
+		return common.WriteError(cmd, "invalid_max_tokens", "max-tokens must not be negative")
+	}
+
+	// Validate schema
+	var schema map[string]any
+	if flags.schema != "" {
+		if schema, err = common.LoadJSONSchema(flags.schema); err != nil {
+			return common.WriteError(cmd, "invalid_schema", err.Error())
+		}
+	}
+
+	// Load the conversation to continue; its instructions apply unless new
+	// ones are given
+	conversation := &textConversation{}
+	if flags.continueID != "" {
+		if !textResponseIDPattern.MatchString(flags.continueID) {
+			return common.WriteError(cmd, "invalid_response_id", fmt.Sprintf("invalid response ID '%s'", flags.continueID))
+		}
+		conversation, err = loadTextConversation(flags.continueID)
+		if errors.Is(err, os.ErrNotExist) {
+			return common.WriteError(cmd, "response_not_found", fmt.Sprintf("no stored conversation for response '%s'", flags.continueID))
+		}
+		if err != nil {
+			return common.WriteError(cmd, "conversation_read_error", err.Error())
+		}
+	}
+	if system != "" {
+		conversation.System = system
+	}
+
+	// Check API key
+	apiKey := config.GetAPIKey("GEMINI_API_KEY", "GOOGLE_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("GEMINI_API_KEY", "GOOGLE_API_KEY"))
+	}
+
+	// Create client
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return common.WriteError(cmd, "client_error", fmt.Sprintf("failed to create client: %s", err.Error()))
+	}
+
+	// Build config
+	genConfig := &genai.GenerateContentConfig{}
+	if conversation.System != "" {
+		genConfig.SystemInstruction = genai.NewContentFromText(conversation.System, genai.RoleUser)
+	}
+	if cmd.Flags().Changed("temperature") {
+		temperature := float32(flags.temperature)
+		genConfig.Temperature = &temperature
+	}
+	if flags.maxTokens > 0 {
+		genConfig.MaxOutputTokens = int32(flags.maxTokens)
+	}
+	if schema != nil {
+		genConfig.ResponseMIMEType = "application/json"
+		genConfig.ResponseJsonSchema = schema
+	}
+
+	// Call API
+	contents := append(conversation.History, genai.NewContentFromText(prompt, genai.RoleUser))
+	result, err := client.Models.GenerateContent(ctx, modelID, contents, genConfig)
+	if err != nil {
+		return handleAPIError(cmd, err)
+	}
+
+	text := strings.TrimSpace(result.Text())
+	if text == "" {
+		reason := ""
+		if len(result.Candidates) > 0 {
+			reason = string(result.Candidates[0].FinishReason)
+		}
+		return common.WriteError(cmd, "no_text", fmt.Sprintf("no text generated in response, finish reason: %s", reason))
+	}
+
+	// Store the conversation under the new response ID
+	responseID := result.ResponseID
+	if !textResponseIDPattern.MatchString(responseID) {
+		responseID = newTextResponseID()
+	}
+	conversation.History = append(contents, result.Candidates[0].Content)
+	if err := conversation.save(responseID); err != nil {
+		return common.WriteError(cmd, "conversation_write_error", fmt.Sprintf("cannot save conversation: %s", err.Error()))
+	}
+
+	resp := textResponse{
+		Success:    true,
+		Text:       text,
+		Model:      modelID,
+		ResponseID: responseID,
+	}
+	if schema != nil {
+		var output any
+		if err := json.Unmarshal([]byte(text), &output); err != nil {
+			return common.WriteError(cmd, "invalid_response", fmt.Sprintf("output is not valid JSON: %s", err.Error()))
+		}
+		resp.Text = output
+	}
+
+	return common.WriteSuccess(cmd, resp)
+}
+
+func textConversationDir() string {
+	return filepath.Join(config.StateDir(), "google", "text-conversations")
+}
+
+func loadTextConversation(id string) (*textConversation, error) {
+	data, err := os.ReadFile(filepath.Join(textConversationDir(), id+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var conversation textConversation
+	if err := json.Unmarshal(data, &conversation); err != nil {
+		return nil, fmt.Errorf("invalid conversation file: %w", err)
+	}
+	return &conversation, nil
+}
+
+func (c *textConversation) save(id string) error {
+	if err := os.MkdirAll(textConversationDir(), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(textConversationDir(), id+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// newTextResponseID names a conversation when the API returned no response ID
+func newTextResponseID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
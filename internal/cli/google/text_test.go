@@ -0,0 +1,153 @@
+package google
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+)
+
+func TestText_Validation(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"missing prompt", nil, "missing_prompt"},
+		{"system and system file", []string{"Hi", "--system", "Be brief", "--system-file", "sys.txt"}, "conflicting_flags"},
+		{"missing system file", []string{"Hi", "--system-file", filepath.Join(dir, "sys.txt")}, "file_not_found"},
+		{"invalid model", []string{"Hi", "-m", "ultra"}, "invalid_model"},
+		{"temperature too high", []string{"Hi", "--temperature", "3"}, "invalid_temperature"},
+		{"negative max tokens", []string{"Hi", "--max-tokens", "-5"}, "invalid_max_tokens"},
+		{"invalid schema", []string{"Hi", "--schema", "{oops"}, "invalid_schema"},
+		{"invalid response id", []string{"Hi", "-c", "../etc/passwd"}, "invalid_response_id"},
+		{"unknown response id", []string{"Hi", "-c", "abc123"}, "response_not_found"},
+		{"missing api key", []string{"Hi"}, "missing_api_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			cmd := newTextCmd()
+			cmd.SetIn(strings.NewReader(""))
+			_, stderr, err := executeCommand(cmd, tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+// textServer answers generateContent with the given outputs in turn and
+// records the request bodies
+func textServer(t *testing.T, outputs ...string) func() []map[string]any {
+	var mu sync.Mutex
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]any
+		json.Unmarshal(body, &req)
+		mu.Lock()
+		requests = append(requests, req)
+		n := len(requests)
+		mu.Unlock()
+
+		text, _ := json.Marshal(outputs[n-1])
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"responseId":"resp` + string(rune('0'+n)) + `","candidates":[{"content":{"role":"model","parts":[{"text":` + string(text) + `}]},"finishReason":"STOP"}]}`))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", server.URL)
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+
+	return func() []map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func TestText_Continue(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	requests := textServer(t, "A fox in the snow.", "A fox in the rain.")
+	system := filepath.Join(t.TempDir(), "system.txt")
+	os.WriteFile(system, []byte("You write image prompts.\n"), 0644)
+
+	stdout, stderr, err := executeCommand(newTextCmd(), "Write a prompt about a fox", "--system-file", system, "--temperature", "0.5", "--max-tokens", "100", "-m", "pro")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp textResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if !resp.Success || resp.Text != "A fox in the snow." || resp.Model != "gemini-2.5-pro" || resp.ResponseID != "resp1" {
+		t.Fatalf("unexpected response: %s", stdout)
+	}
+
+	// The second turn sends the history and keeps the instructions
+	stdout, stderr, err = executeCommand(newTextCmd(), "Now make it rain", "-c", "resp1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	resp = textResponse{}
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp.Text != "A fox in the rain." || resp.ResponseID != "resp2" {
+		t.Fatalf("unexpected response: %s", stdout)
+	}
+
+	reqs := requests()
+	config, _ := json.Marshal(reqs[0]["generationConfig"])
+	for _, want := range []string{`"temperature":0.5`, `"maxOutputTokens":100`} {
+		if !strings.Contains(string(config), want) {
+			t.Errorf("expected first config to contain %s, got %s", want, config)
+		}
+	}
+	contents, _ := reqs[1]["contents"].([]any)
+	if len(contents) != 3 {
+		t.Fatalf("expected history of 3 contents, got %d", len(contents))
+	}
+	history, _ := json.Marshal(contents)
+	for _, want := range []string{"Write a prompt about a fox", `"role":"model"`, "A fox in the snow.", "Now make it rain"} {
+		if !strings.Contains(string(history), want) {
+			t.Errorf("expected history to contain %s, got %s", want, history)
+		}
+	}
+	instruction, _ := json.Marshal(reqs[1]["systemInstruction"])
+	if !strings.Contains(string(instruction), "You write image prompts.") {
+		t.Errorf("expected instructions to carry over, got %s", instruction)
+	}
+	if _, ok := reqs[1]["generationConfig"].(map[string]any)["temperature"]; ok {
+		t.Errorf("expected temperature to be left to the API default")
+	}
+}
+
+func TestText_Schema(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	requests := textServer(t, `{"shots":["wide","close-up"]}`)
+
+	stdout, stderr, err := executeCommand(newTextCmd(), "Plan two shots", "--schema", `{"type":"object","properties":{"shots":{"type":"array","items":{"type":"string"}}}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"text":{"shots":["wide","close-up"]}`) {
+		t.Errorf("expected JSON output, got: %s", stdout)
+	}
+	config, _ := json.Marshal(requests()[0]["generationConfig"])
+	for _, want := range []string{`"responseMimeType":"application/json"`, `"responseJsonSchema":{"properties":{"shots"`} {
+		if !strings.Contains(string(config), want) {
+			t.Errorf("expected config to contain %s, got %s", want, config)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package google
 
 import (
 	"context"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -39,8 +38,15 @@ type ttsResponse struct {
 	Model    string            `json:"model,omitempty"`
 	Voice    string            `json:"voice,omitempty"`
 	Speakers map[string]string `json:"speakers,omitempty"`
+	Timeline string            `json:"timeline,omitempty"`
+	Turns    int               `json:"turns,omitempty"`
+	Chunks   int               `json:"chunks,omitempty"`
+	Duration float64           `json:"duration,omitempty"`
 }
 
+// pcmFormat is the audio Gemini TTS returns: 24kHz 16-bit mono.
+var pcmFormat = common.AudioFormat{SampleRate: 24000, Channels: 1, Encoding: common.PCMS16LE}
+
 // TTS flags
 type ttsFlags struct {
 	output     string
@@ -49,6 +55,9 @@ type ttsFlags struct {
 	speakers   string
 	model      string
 	speak      bool
+	script     string
+	timeline   string
+	download   common.DownloadFlags
 }
 
 // Command
@@ -68,7 +77,12 @@ style instructions to control voice characteristics like tone, pace, and emotion
 Examples:
   rawgenai google tts "Hello world" -o hello.wav
   rawgenai google tts "Say cheerfully: Hello everyone!" -o cheerful.wav
-  rawgenai google tts "Whisper: This is a secret" -o whisper.wav`,
+  rawgenai google tts "Whisper: This is a secret" -o whisper.wav
+  rawgenai google tts --script dialogue.md --speakers "Joe=Kore,Jane=Puck" -o dialogue.wav
+
+With --script, a dialogue of any length and number of speakers is read in
+requests of at most two speakers, cut between turns, and joined into one file
+with a JSON timeline of the turns.`,
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -82,11 +96,18 @@ Examples:
 	cmd.Flags().StringVar(&flags.speakers, "speakers", "", "Multi-speaker config: \"Name1=Voice1,Name2=Voice2\"")
 	cmd.Flags().StringVarP(&flags.model, "model", "m", "flash", "Model: flash, pro")
 	cmd.Flags().BoolVar(&flags.speak, "speak", false, "Play audio after generation")
+	cmd.Flags().StringVar(&flags.script, "script", "", "Dialogue script (.json, .md) to read in turns")
+	cmd.Flags().StringVar(&flags.timeline, "timeline", "", "Timeline output path (default: <output>.timeline.json, with --script)")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
 
 func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
+	if flags.script != "" {
+		return runTTSScript(cmd, args, flags)
+	}
+
 	// Get text from args, file, or stdin
 	text, err := getPrompt(args, flags.promptFile, cmd.InOrStdin())
 	if err != nil {
@@ -97,13 +118,16 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	if flags.output == "" && !flags.speak {
 		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag or --speak")
 	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
 
 	// Determine output path
 	var outputPath string
 	var useTempFile bool
 
 	if flags.output != "" {
-		outputPath = flags.output
+		outputPath = common.DefaultExt(flags.output, ".wav")
 		// Validate format (only WAV supported)
 		ext := strings.ToLower(filepath.Ext(outputPath))
 		if ext != ".wav" {
@@ -165,33 +189,7 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	// Build config
 	config := &genai.GenerateContentConfig{
 		ResponseModalities: []string{"AUDIO"},
-		SpeechConfig:       &genai.SpeechConfig{},
-	}
-
-	// Configure voice
-	if speakerMap != nil {
-		// Multi-speaker mode
-		var speakerConfigs []*genai.SpeakerVoiceConfig
-		for speaker, voice := range speakerMap {
-			speakerConfigs = append(speakerConfigs, &genai.SpeakerVoiceConfig{
-				Speaker: speaker,
-				VoiceConfig: &genai.VoiceConfig{
-					PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{
-						VoiceName: voice,
-					},
-				},
-			})
-		}
-		config.SpeechConfig.MultiSpeakerVoiceConfig = &genai.MultiSpeakerVoiceConfig{
-			SpeakerVoiceConfigs: speakerConfigs,
-		}
-	} else {
-		// Single speaker mode
-		config.SpeechConfig.VoiceConfig = &genai.VoiceConfig{
-			PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{
-				VoiceName: flags.voice,
-			},
-		}
+		SpeechConfig:       newSpeechConfig(flags.voice, speakerMap),
 	}
 
 	// Call API
@@ -222,20 +220,21 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	}
 
 	// Convert PCM to WAV
-	wavBytes := pcmToWAV(audioBytes, 24000, 16, 1)
+	wavBytes := common.PCMToWAV(audioBytes, pcmFormat)
 
 	// Save audio
-	absPath, err := filepath.Abs(outputPath)
-	if err != nil {
-		absPath = outputPath
+	opts := common.DownloadOptions{DownloadFlags: flags.download}
+	if useTempFile {
+		opts = common.DownloadOptions{}
 	}
-
-	if err := os.WriteFile(absPath, wavBytes, 0644); err != nil {
+	saved, err := common.SaveBytes(wavBytes, outputPath, opts)
+	if err != nil {
 		if useTempFile {
 			os.Remove(outputPath)
 		}
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		return common.WriteError(cmd, common.DownloadErrorCode(err), fmt.Sprintf("cannot write output file: %s", err.Error()))
 	}
+	absPath := saved.Path
 
 	// Play audio if --speak is set
 	if flags.speak {
@@ -268,6 +267,31 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	return common.WriteSuccess(cmd, resp)
 }
 
+// newSpeechConfig configures a single prebuilt voice, or one voice per
+// speaker when speakers is set.
+func newSpeechConfig(voice string, speakers map[string]string) *genai.SpeechConfig {
+	if speakers == nil {
+		return &genai.SpeechConfig{
+			VoiceConfig: &genai.VoiceConfig{
+				PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{VoiceName: voice},
+			},
+		}
+	}
+
+	var speakerConfigs []*genai.SpeakerVoiceConfig
+	for speaker, voice := range speakers {
+		speakerConfigs = append(speakerConfigs, &genai.SpeakerVoiceConfig{
+			Speaker: speaker,
+			VoiceConfig: &genai.VoiceConfig{
+				PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{VoiceName: voice},
+			},
+		})
+	}
+	return &genai.SpeechConfig{
+		MultiSpeakerVoiceConfig: &genai.MultiSpeakerVoiceConfig{SpeakerVoiceConfigs: speakerConfigs},
+	}
+}
+
 // parseSpeakers parses the speaker config string
 // Format: "Name1=Voice1,Name2=Voice2"
 func parseSpeakers(config string) (map[string]string, error) {
@@ -298,42 +322,6 @@ func parseSpeakers(config string) (map[string]string, error) {
 	return result, nil
 }
 
-// pcmToWAV converts raw PCM audio to WAV format
-// PCM format: 16-bit signed little-endian
-func pcmToWAV(pcm []byte, sampleRate, bitsPerSample, channels int) []byte {
-	// WAV header is 44 bytes
-	dataSize := len(pcm)
-	fileSize := dataSize + 36 // Total file size minus 8 bytes for RIFF header
-
-	header := make([]byte, 44)
-
-	// RIFF header
-	copy(header[0:4], "RIFF")
-	binary.LittleEndian.PutUint32(header[4:8], uint32(fileSize))
-	copy(header[8:12], "WAVE")
-
-	// fmt subchunk
-	copy(header[12:16], "fmt ")
-	binary.LittleEndian.PutUint32(header[16:20], 16)                                              // Subchunk1Size (16 for PCM)
-	binary.LittleEndian.PutUint16(header[20:22], 1)                                               // AudioFormat (1 for PCM)
-	binary.LittleEndian.PutUint16(header[22:24], uint16(channels))                                // NumChannels
-	binary.LittleEndian.PutUint32(header[24:28], uint32(sampleRate))                              // SampleRate
-	binary.LittleEndian.PutUint32(header[28:32], uint32(sampleRate*channels*bitsPerSample/8))     // ByteRate
-	binary.LittleEndian.PutUint16(header[32:34], uint16(channels*bitsPerSample/8))                // BlockAlign
-	binary.LittleEndian.PutUint16(header[34:36], uint16(bitsPerSample))                           // BitsPerSample
-
-	// data subchunk
-	copy(header[36:40], "data")
-	binary.LittleEndian.PutUint32(header[40:44], uint32(dataSize))
-
-	// Combine header and data
-	wav := make([]byte, 44+dataSize)
-	copy(wav[0:44], header)
-	copy(wav[44:], pcm)
-
-	return wav
-}
-
 // Helper to get text from various sources (reusing from image.go pattern)
 func getTTSText(args []string, filePath string, stdin io.Reader) (string, error) {
 	// Priority 1: Positional argument
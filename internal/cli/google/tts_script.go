@@ -0,0 +1,428 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/spf13/cobra"
+	"google.golang.org/genai"
+)
+
+// ttsScriptMaxChars bounds the text of one request, about two minutes of
+// speech, well within the audio a TTS request can return.
+const ttsScriptMaxChars = 2000
+
+// ttsScript is a dialogue read from --script. Speakers maps names to voices
+// and may be left to --speakers.
+type ttsScript struct {
+	Speakers map[string]string `json:"speakers,omitempty"`
+	Turns    []ttsTurn         `json:"turns"`
+}
+
+// ttsTurn is one line of a dialogue, with an optional style direction such
+// as "excitedly".
+type ttsTurn struct {
+	Speaker string `json:"speaker"`
+	Text    string `json:"text"`
+	Style   string `json:"style,omitempty"`
+}
+
+// ttsScriptChunk is the run of turns synthesized in one request. turns
+// holds the index of each turn in the script, as long turns are split.
+type ttsScriptChunk struct {
+	speakers []string
+	lines    []ttsTurn
+	turns    []int
+}
+
+// ttsTimeline records which speaker spoke when, in seconds. Chunk
+// boundaries are measured; turns that share a chunk are marked estimated,
+// as their times are shared out by text length.
+type ttsTimeline struct {
+	File     string            `json:"file,omitempty"`
+	Duration float64           `json:"duration"`
+	Turns    []ttsTimelineTurn `json:"turns"`
+}
+
+type ttsTimelineTurn struct {
+	Speaker   string  `json:"speaker"`
+	Voice     string  `json:"voice"`
+	Text      string  `json:"text"`
+	Style     string  `json:"style,omitempty"`
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+	Chunk     int     `json:"chunk"`
+	Estimated bool    `json:"estimated,omitempty"`
+}
+
+// Markdown script lines: "Name: text" or "Name (style): text", optionally
+// as a list item
+var ttsScriptLinePattern = regexp.MustCompile(`^([^:()\[\]]{1,40}?)\s*(?:\(([^)]*)\))?\s*:\s*(.*)$`)
+
+var errNoAudio = errors.New("no audio generated in response")
+
+func runTTSScript(cmd *cobra.Command, args []string, flags *ttsFlags) error {
+	if len(args) > 0 || flags.promptFile != "" {
+		return common.WriteError(cmd, "conflicting_flags", "cannot use --script with a prompt or --prompt-file")
+	}
+	if cmd.Flags().Changed("voice") {
+		return common.WriteError(cmd, "conflicting_flags", "cannot use --voice with --script, set voices with --speakers")
+	}
+
+	// Read script
+	script, err := parseTTSScript(flags.script)
+	if err != nil {
+		if os.IsNotExist(errors.Unwrap(err)) {
+			return common.WriteError(cmd, "file_not_found", err.Error())
+		}
+		return common.WriteError(cmd, "invalid_script", err.Error())
+	}
+
+	// Resolve voices; --speakers overrides the script
+	voices := make(map[string]string)
+	for speaker, voice := range script.Speakers {
+		if !validVoices[voice] {
+			return common.WriteError(cmd, "invalid_speakers", fmt.Sprintf("voice '%s' is not a valid prebuilt voice", voice))
+		}
+		voices[speaker] = voice
+	}
+	if flags.speakers != "" {
+		speakerMap, err := parseSpeakers(flags.speakers)
+		if err != nil {
+			return common.WriteError(cmd, "invalid_speakers", err.Error())
+		}
+		for speaker, voice := range speakerMap {
+			voices[speaker] = voice
+		}
+	}
+	used := make(map[string]string)
+	for _, turn := range script.Turns {
+		voice, ok := voices[turn.Speaker]
+		if !ok {
+			return common.WriteError(cmd, "missing_voice", fmt.Sprintf("no voice for speaker '%s', add it to --speakers", turn.Speaker))
+		}
+		used[turn.Speaker] = voice
+	}
+
+	// Validate output or speak
+	if flags.output == "" && !flags.speak {
+		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag or --speak")
+	}
+	var outputPath string
+	if flags.output != "" {
+		outputPath = common.DefaultExt(flags.output, ".wav")
+		ext := strings.ToLower(filepath.Ext(outputPath))
+		if ext != ".wav" {
+			return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported format '%s', only .wav is supported", ext))
+		}
+	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
+
+	// Validate model
+	modelID, ok := ttsModelIDs[flags.model]
+	if !ok {
+		return common.WriteError(cmd, "invalid_model", fmt.Sprintf("invalid model '%s', use 'flash' or 'pro'", flags.model))
+	}
+
+	// Check API key
+	apiKey := config.GetAPIKey("GEMINI_API_KEY", "GOOGLE_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("GEMINI_API_KEY", "GOOGLE_API_KEY"))
+	}
+
+	// Create client
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return common.WriteError(cmd, "client_error", fmt.Sprintf("failed to create client: %s", err.Error()))
+	}
+
+	// Synthesize the chunks; each returns raw PCM
+	chunks := chunkTTSScript(script.Turns, ttsScriptMaxChars)
+	prompts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		prompts[i] = ttsScriptPrompt(chunk)
+	}
+	parts, err := common.SynthesizeChunks(ctx, prompts, common.DefaultChunkConcurrency, func(ctx context.Context, i int, prompt string) ([]byte, error) {
+		chunk := chunks[i]
+		var speechConfig *genai.SpeechConfig
+		if len(chunk.speakers) == 1 {
+			speechConfig = newSpeechConfig(voices[chunk.speakers[0]], nil)
+		} else {
+			speechConfig = newSpeechConfig("", map[string]string{
+				chunk.speakers[0]: voices[chunk.speakers[0]],
+				chunk.speakers[1]: voices[chunk.speakers[1]],
+			})
+		}
+		result, err := client.Models.GenerateContent(ctx, modelID, genai.Text(prompt), &genai.GenerateContentConfig{
+			ResponseModalities: []string{"AUDIO"},
+			SpeechConfig:       speechConfig,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Candidates) > 0 && result.Candidates[0].Content != nil {
+			for _, part := range result.Candidates[0].Content.Parts {
+				if part.InlineData != nil && strings.HasPrefix(part.InlineData.MIMEType, "audio/") {
+					return part.InlineData.Data, nil
+				}
+			}
+		}
+		return nil, errNoAudio
+	})
+	if errors.Is(err, errNoAudio) {
+		return common.WriteError(cmd, "no_audio", err.Error())
+	}
+	if err != nil {
+		return handleAPIError(cmd, err)
+	}
+
+	// Join the chunks and place every turn on the timeline
+	timeline := buildTTSTimeline(script.Turns, chunks, parts, voices)
+	var pcm []byte
+	for _, part := range parts {
+		pcm = append(pcm, part...)
+	}
+	wavBytes := common.PCMToWAV(pcm, pcmFormat)
+
+	// Save audio, or play it from a temp file
+	var absPath string
+	if outputPath != "" {
+		result, err := common.SaveBytes(wavBytes, outputPath, common.DownloadOptions{DownloadFlags: flags.download})
+		if err != nil {
+			return common.WriteError(cmd, common.DownloadErrorCode(err), fmt.Sprintf("cannot write output file: %s", err.Error()))
+		}
+		absPath = result.Path
+		timeline.File = absPath
+	}
+
+	timelinePath := flags.timeline
+	if timelinePath == "" && absPath != "" {
+		timelinePath = strings.TrimSuffix(absPath, filepath.Ext(absPath)) + ".timeline.json"
+	}
+	var absTimeline string
+	if timelinePath != "" {
+		data, _ := json.MarshalIndent(timeline, "", "  ")
+		result, err := common.SaveBytes(append(data, '\n'), timelinePath, common.DownloadOptions{DownloadFlags: flags.download})
+		if err != nil {
+			return common.WriteError(cmd, common.DownloadErrorCode(err), fmt.Sprintf("cannot write timeline file: %s", err.Error()))
+		}
+		absTimeline = result.Path
+	}
+
+	if flags.speak {
+		playPath := absPath
+		if playPath == "" {
+			tmpFile, err := os.CreateTemp("", "tts-*.wav")
+			if err != nil {
+				return common.WriteError(cmd, "internal_error", fmt.Sprintf("cannot create temp file: %s", err.Error()))
+			}
+			playPath = tmpFile.Name()
+			defer os.Remove(playPath)
+			_, err = tmpFile.Write(wavBytes)
+			tmpFile.Close()
+			if err != nil {
+				return common.WriteError(cmd, "internal_error", fmt.Sprintf("cannot write temp file: %s", err.Error()))
+			}
+		}
+		if err := common.PlayFile(playPath); err != nil {
+			return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", err.Error()))
+		}
+	}
+
+	return common.WriteSuccess(cmd, ttsResponse{
+		Success:  true,
+		File:     absPath,
+		Model:    modelID,
+		Speakers: used,
+		Timeline: absTimeline,
+		Turns:    len(script.Turns),
+		Chunks:   len(chunks),
+		Duration: timeline.Duration,
+	})
+}
+
+// parseTTSScript reads a dialogue from a .json or .md file. JSON is either
+// a ttsScript or a bare list of turns; Markdown has a "Name: text" or
+// "Name (style): text" line per turn, and other lines continue the turn
+// before them.
+func parseTTSScript(path string) (*ttsScript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read script: %w", err)
+	}
+
+	script := &ttsScript{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		trimmed := strings.TrimSpace(string(data))
+		if strings.HasPrefix(trimmed, "[") {
+			err = json.Unmarshal(data, &script.Turns)
+		} else {
+			err = json.Unmarshal(data, script)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON script: %s", err.Error())
+		}
+	case ".md", ".markdown", ".txt":
+		for n, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(strings.ReplaceAll(line, "**", ""))
+			line = strings.TrimSpace(strings.TrimLeft(line, "-*"))
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if m := ttsScriptLinePattern.FindStringSubmatch(line); m != nil {
+				script.Turns = append(script.Turns, ttsTurn{Speaker: strings.TrimSpace(m[1]), Style: strings.TrimSpace(m[2]), Text: m[3]})
+				continue
+			}
+			if len(script.Turns) == 0 {
+				return nil, fmt.Errorf("line %d: expected 'Speaker: text'", n+1)
+			}
+			last := &script.Turns[len(script.Turns)-1]
+			last.Text = strings.TrimSpace(last.Text + " " + line)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported script format '%s', use .json or .md", ext)
+	}
+
+	if len(script.Turns) == 0 {
+		return nil, errors.New("script has no turns")
+	}
+	for i := range script.Turns {
+		turn := &script.Turns[i]
+		turn.Speaker = strings.TrimSpace(turn.Speaker)
+		turn.Text = strings.TrimSpace(turn.Text)
+		turn.Style = strings.TrimSpace(turn.Style)
+		if turn.Speaker == "" {
+			return nil, fmt.Errorf("turn %d has no speaker", i+1)
+		}
+		if turn.Text == "" {
+			return nil, fmt.Errorf("turn %d has no text", i+1)
+		}
+	}
+	return script, nil
+}
+
+// chunkTTSScript groups consecutive turns into requests of at most two
+// speakers and maxChars characters, cutting only between turns. A turn
+// longer than maxChars is split into sentences first.
+func chunkTTSScript(turns []ttsTurn, maxChars int) []ttsScriptChunk {
+	var chunks []ttsScriptChunk
+	var current ttsScriptChunk
+	chars := 0
+	flush := func() {
+		if len(current.lines) > 0 {
+			chunks = append(chunks, current)
+		}
+		current = ttsScriptChunk{}
+		chars = 0
+	}
+
+	for i, turn := range turns {
+		for _, text := range common.SplitText(turn.Text, maxChars, nil) {
+			n := utf8.RuneCountInString(text)
+			known := slices.Contains(current.speakers, turn.Speaker)
+			if (!known && len(current.speakers) == 2) || (len(current.lines) > 0 && chars+n > maxChars) {
+				flush()
+				known = false
+			}
+			if !known {
+				current.speakers = append(current.speakers, turn.Speaker)
+			}
+			current.lines = append(current.lines, ttsTurn{Speaker: turn.Speaker, Text: text, Style: turn.Style})
+			current.turns = append(current.turns, i)
+			chars += n
+		}
+	}
+	flush()
+	return chunks
+}
+
+// ttsScriptPrompt renders a chunk as a transcript with the style directions
+// in brackets. A single speaker is read without names.
+func ttsScriptPrompt(chunk ttsScriptChunk) string {
+	var b strings.Builder
+	if len(chunk.speakers) == 1 {
+		b.WriteString("Read aloud:\n")
+	} else {
+		fmt.Fprintf(&b, "TTS the following conversation between %s and %s:\n", chunk.speakers[0], chunk.speakers[1])
+	}
+	for _, line := range chunk.lines {
+		if len(chunk.speakers) > 1 {
+			b.WriteString(line.Speaker + ": ")
+		}
+		if line.Style != "" {
+			b.WriteString("[" + line.Style + "] ")
+		}
+		b.WriteString(line.Text + "\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// buildTTSTimeline places every turn in the joined audio. Chunk boundaries
+// are exact; within a chunk, time is shared out by text length and the
+// turns are marked estimated.
+func buildTTSTimeline(turns []ttsTurn, chunks []ttsScriptChunk, parts [][]byte, voices map[string]string) ttsTimeline {
+	bytesPerSecond := float64(pcmFormat.SampleRate * pcmFormat.Channels * pcmFormat.Encoding.BytesPerSample())
+	timeline := ttsTimeline{Turns: []ttsTimelineTurn{}}
+	offset := 0.0
+	last := -1
+	for c, chunk := range chunks {
+		duration := float64(len(parts[c])) / bytesPerSecond
+		total := 0
+		for _, line := range chunk.lines {
+			total += utf8.RuneCountInString(line.Text)
+		}
+
+		start := offset
+		for j, line := range chunk.lines {
+			end := start + duration*float64(utf8.RuneCountInString(line.Text))/float64(total)
+			if j == len(chunk.lines)-1 {
+				end = offset + duration
+			}
+			// The pieces of a split turn share one entry
+			if chunk.turns[j] == last {
+				prev := &timeline.Turns[len(timeline.Turns)-1]
+				prev.End = roundSeconds(end)
+				prev.Estimated = prev.Estimated || len(chunk.lines) > 1
+			} else {
+				turn := turns[chunk.turns[j]]
+				timeline.Turns = append(timeline.Turns, ttsTimelineTurn{
+					Speaker:   turn.Speaker,
+					Voice:     voices[turn.Speaker],
+					Text:      turn.Text,
+					Style:     turn.Style,
+					Start:     roundSeconds(start),
+					End:       roundSeconds(end),
+					Chunk:     c,
+					Estimated: len(chunk.lines) > 1,
+				})
+			}
+			last = chunk.turns[j]
+			start = end
+		}
+		offset += duration
+	}
+	timeline.Duration = roundSeconds(offset)
+	return timeline
+}
+
+func roundSeconds(s float64) float64 {
+	return math.Round(s*1000) / 1000
+}
@@ -0,0 +1,260 @@
+package google
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+)
+
+func writeScript(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseTTSScript(t *testing.T) {
+	want := []ttsTurn{
+		{Speaker: "Joe", Text: "How's it going?"},
+		{Speaker: "Jane", Text: "Not bad. You?", Style: "tired"},
+	}
+
+	object := writeScript(t, "chat.json", `{"speakers":{"Joe":"Kore"},"turns":[{"speaker":"Joe","text":"How's it going?"},{"speaker":"Jane","text":" Not bad. You? ","style":"tired"}]}`)
+	script, err := parseTTSScript(object)
+	if err != nil || !reflect.DeepEqual(script.Turns, want) || script.Speakers["Joe"] != "Kore" {
+		t.Errorf("json object: got %+v, %v", script, err)
+	}
+
+	list := writeScript(t, "chat.json", `[{"speaker":"Joe","text":"How's it going?"},{"speaker":"Jane","text":"Not bad. You?","style":"tired"}]`)
+	script, err = parseTTSScript(list)
+	if err != nil || !reflect.DeepEqual(script.Turns, want) {
+		t.Errorf("json list: got %+v, %v", script, err)
+	}
+
+	markdown := writeScript(t, "chat.md", "# Episode 1\n\n- **Joe:** How's it going?\n\n**Jane** (tired): Not bad.\nYou?\n")
+	script, err = parseTTSScript(markdown)
+	if err != nil || !reflect.DeepEqual(script.Turns, want) {
+		t.Errorf("markdown: got %+v, %v", script, err)
+	}
+}
+
+func TestParseTTSScript_Errors(t *testing.T) {
+	tests := map[string]string{
+		"chat.json": `{"turns":[]}`,
+		"bad.json":  `[{"speaker":"Joe"`,
+		"nobody.md": "just some text",
+		"empty.md":  "Joe:",
+		"chat.yaml": "Joe: hi",
+	}
+	for name, content := range tests {
+		if _, err := parseTTSScript(writeScript(t, name, content)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestChunkTTSScript(t *testing.T) {
+	turns := []ttsTurn{
+		{Speaker: "A", Text: "one"},
+		{Speaker: "B", Text: "two"},
+		{Speaker: "A", Text: "three"},
+		{Speaker: "C", Text: "four"},
+		{Speaker: "C", Text: "five"},
+		{Speaker: "B", Text: "six"},
+		{Speaker: "B", Text: "First sentence here. Second sentence here."},
+	}
+
+	chunks := chunkTTSScript(turns, 25)
+	var speakers [][]string
+	var indexes [][]int
+	for _, chunk := range chunks {
+		speakers = append(speakers, chunk.speakers)
+		indexes = append(indexes, chunk.turns)
+	}
+	wantSpeakers := [][]string{{"A", "B"}, {"C", "B"}, {"B"}, {"B"}}
+	wantIndexes := [][]int{{0, 1, 2}, {3, 4, 5}, {6}, {6}}
+	if !reflect.DeepEqual(speakers, wantSpeakers) || !reflect.DeepEqual(indexes, wantIndexes) {
+		t.Errorf("got speakers %v, turns %v", speakers, indexes)
+	}
+	if chunks[2].lines[0].Text != "First sentence here." || chunks[3].lines[0].Text != "Second sentence here." {
+		t.Errorf("expected the long turn split into sentences, got %+v %+v", chunks[2].lines, chunks[3].lines)
+	}
+}
+
+func TestTTSScriptPrompt(t *testing.T) {
+	dialogue := newScriptChunk([]string{"Joe", "Jane"}, ttsTurn{Speaker: "Joe", Text: "Hi!"}, ttsTurn{Speaker: "Jane", Text: "Hello.", Style: "sleepily"})
+	want := "TTS the following conversation between Joe and Jane:\nJoe: Hi!\nJane: [sleepily] Hello."
+	if got := ttsScriptPrompt(dialogue); got != want {
+		t.Errorf("got %q", got)
+	}
+
+	monologue := newScriptChunk([]string{"Joe"}, ttsTurn{Speaker: "Joe", Text: "Hi!", Style: "loudly"})
+	if got := ttsScriptPrompt(monologue); got != "Read aloud:\n[loudly] Hi!" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func newScriptChunk(speakers []string, lines ...ttsTurn) ttsScriptChunk {
+	chunk := ttsScriptChunk{speakers: speakers, lines: lines}
+	for i := range lines {
+		chunk.turns = append(chunk.turns, i)
+	}
+	return chunk
+}
+
+func TestTTS_ScriptValidation(t *testing.T) {
+	script := writeScript(t, "chat.md", "Joe: Hi!\nJane: Hello.")
+
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"with prompt", []string{"Hello", "--script", script, "-o", "out.wav"}, "conflicting_flags"},
+		{"with voice", []string{"--script", script, "-v", "Puck", "-o", "out.wav"}, "conflicting_flags"},
+		{"script not found", []string{"--script", filepath.Join(t.TempDir(), "nope.md"), "-o", "out.wav"}, "file_not_found"},
+		{"invalid script", []string{"--script", writeScript(t, "chat.json", "{"), "-o", "out.wav"}, "invalid_script"},
+		{"missing voice", []string{"--script", script, "--speakers", "Joe=Kore", "-o", "out.wav"}, "missing_voice"},
+		{"invalid voice", []string{"--script", script, "--speakers", "Joe=Kore,Jane=Nobody", "-o", "out.wav"}, "invalid_speakers"},
+		{"missing output", []string{"--script", script, "--speakers", "Joe=Kore,Jane=Puck"}, "missing_output"},
+		{"unsupported format", []string{"--script", script, "--speakers", "Joe=Kore,Jane=Puck", "-o", "out.mp3"}, "unsupported_format"},
+		{"missing api key", []string{"--script", script, "--speakers", "Joe=Kore,Jane=Puck", "-o", "out.wav"}, "missing_api_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			_, stderr, err := executeCommand(newTTSCmd(), tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+// ttsScriptServer answers every request with 0.5s of silence and records
+// the request bodies
+func ttsScriptServer(t *testing.T) func() []string {
+	var mu sync.Mutex
+	var bodies []string
+	audio := base64.StdEncoding.EncodeToString(make([]byte, 24000))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"candidates":[{"content":{"role":"model","parts":[{"inlineData":{"mimeType":"audio/L16;codec=pcm;rate=24000","data":"%s"}}]},"finishReason":"STOP"}]}`, audio)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", server.URL)
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return bodies
+	}
+}
+
+func TestTTS_Script(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	bodies := ttsScriptServer(t)
+	script := writeScript(t, "chat.json", `{
+  "speakers": {"Joe": "Kore", "Jane": "Puck"},
+  "turns": [
+    {"speaker": "Joe", "text": "Hi Jane."},
+    {"speaker": "Jane", "text": "Hi Joe.", "style": "cheerfully"},
+    {"speaker": "Narrator", "text": "And then the doorbell rang."}
+  ]
+}`)
+	output := filepath.Join(t.TempDir(), "chat.wav")
+
+	stdout, stderr, err := executeCommand(newTTSCmd(), "--script", script, "--speakers", "Narrator=Charon", "-o", output, "-m", "pro")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp ttsResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	timelinePath := filepath.Join(filepath.Dir(output), "chat.timeline.json")
+	wantSpeakers := map[string]string{"Joe": "Kore", "Jane": "Puck", "Narrator": "Charon"}
+	if !resp.Success || resp.File != output || resp.Timeline != timelinePath || resp.Turns != 3 || resp.Chunks != 2 || resp.Duration != 1 || !reflect.DeepEqual(resp.Speakers, wantSpeakers) {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	reqs := bodies()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+	var dialogue, narration string
+	for _, body := range reqs {
+		if strings.Contains(body, "multiSpeakerVoiceConfig") {
+			dialogue = body
+		} else {
+			narration = body
+		}
+	}
+	if !strings.Contains(dialogue, `Jane: [cheerfully] Hi Joe.`) || !strings.Contains(dialogue, `"speaker":"Jane"`) {
+		t.Errorf("unexpected dialogue request: %s", dialogue)
+	}
+	if !strings.Contains(narration, `"voiceName":"Charon"`) || !strings.Contains(narration, "And then the doorbell rang.") {
+		t.Errorf("unexpected narration request: %s", narration)
+	}
+
+	info, err := os.Stat(output)
+	if err != nil || info.Size() != 44+2*24000 {
+		t.Errorf("expected a 1s WAV file, got %v, %v", info, err)
+	}
+
+	data, err := os.ReadFile(timelinePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var timeline ttsTimeline
+	json.Unmarshal(data, &timeline)
+	if timeline.File != output || timeline.Duration != 1 || len(timeline.Turns) != 3 {
+		t.Fatalf("unexpected timeline: %s", data)
+	}
+	joe, jane, narrator := timeline.Turns[0], timeline.Turns[1], timeline.Turns[2]
+	if joe.Start != 0 || joe.End != jane.Start || jane.End != 0.5 || jane.Style != "cheerfully" || narrator.Start != 0.5 || narrator.End != 1 || narrator.Chunk != 1 || narrator.Voice != "Charon" {
+		t.Errorf("unexpected timeline turns: %+v", timeline.Turns)
+	}
+	if !joe.Estimated || !jane.Estimated || narrator.Estimated {
+		t.Errorf("unexpected timeline turns: %+v", timeline.Turns)
+	}
+}
+
+func TestTTS_ScriptNoClobber(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	ttsScriptServer(t)
+	script := writeScript(t, "chat.md", "Joe: Hi.\n")
+	output := filepath.Join(t.TempDir(), "chat.wav")
+	os.WriteFile(output, []byte("keep"), 0644)
+
+	_, stderr, err := executeCommand(newTTSCmd(), "--script", script, "--speakers", "Joe=Kore", "-o", output, "--no-clobber")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(stderr, `"code":"output_exists"`) {
+		t.Errorf("expected error code output_exists, got: %s", stderr)
+	}
+	if data, _ := os.ReadFile(output); string(data) != "keep" {
+		t.Errorf("existing output was replaced: %q", data)
+	}
+}
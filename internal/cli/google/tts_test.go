@@ -302,36 +302,6 @@ func TestParseSpeakers(t *testing.T) {
 	}
 }
 
-func TestPcmToWAV(t *testing.T) {
-	// Simple test with known input
-	pcm := []byte{0x01, 0x02, 0x03, 0x04}
-	wav := pcmToWAV(pcm, 24000, 16, 1)
-
-	// Check header
-	if string(wav[0:4]) != "RIFF" {
-		t.Error("expected RIFF header")
-	}
-	if string(wav[8:12]) != "WAVE" {
-		t.Error("expected WAVE format")
-	}
-	if string(wav[12:16]) != "fmt " {
-		t.Error("expected fmt subchunk")
-	}
-	if string(wav[36:40]) != "data" {
-		t.Error("expected data subchunk")
-	}
-
-	// Check data
-	if !bytes.Equal(wav[44:], pcm) {
-		t.Error("PCM data not correctly appended")
-	}
-
-	// Total size should be 44 + len(pcm)
-	if len(wav) != 44+len(pcm) {
-		t.Errorf("expected length %d, got %d", 44+len(pcm), len(wav))
-	}
-}
-
 func TestTTS_FlagDefaults(t *testing.T) {
 	cmd := newTTSCmd()
 
@@ -0,0 +1,370 @@
+package video
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/spf13/cobra"
+	"google.golang.org/genai"
+)
+
+// Model name mapping for video understanding
+var analyzeModelIDs = map[string]string{
+	"flash": "gemini-2.5-flash",
+	"pro":   "gemini-2.5-pro",
+}
+
+// Video formats accepted by Gemini
+var analyzeVideoFormats = map[string]string{
+	".mp4":  "video/mp4",
+	".mpeg": "video/mpeg",
+	".mpg":  "video/mpg",
+	".mov":  "video/mov",
+	".avi":  "video/avi",
+	".flv":  "video/x-flv",
+	".webm": "video/webm",
+	".wmv":  "video/wmv",
+	".3gp":  "video/3gpp",
+}
+
+// Videos up to this size are sent inline, larger ones are uploaded. Requests
+// are limited to 20 MB and inline data is base64 encoded, which leaves about
+// 14 MB for the video.
+const analyzeMaxInlineSize = 14 * 1024 * 1024
+
+// Upper bound of --fps
+const analyzeMaxFPS = 24
+
+// Interval between checks of an uploaded video still being processed, and
+// how long to wait for processing before giving up
+var (
+	analyzePollInterval      = 5 * time.Second
+	analyzeProcessingTimeout = 10 * time.Minute
+)
+
+const defaultAnalyzeQuestion = "Describe this video in detail. Give the time of each scene and notable event as MM:SS."
+
+// checkPromptSchema is the answer of --check-prompt
+var checkPromptSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"score":   map[string]any{"type": "integer", "minimum": 0, "maximum": 10, "description": "How closely the video follows the prompt, 0 (not at all) to 10 (fully)"},
+		"verdict": map[string]any{"type": "string", "enum": []string{"pass", "partial", "fail"}},
+		"summary": map[string]any{"type": "string", "description": "One or two sentences on how the video relates to the prompt"},
+		"matched": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Elements of the prompt that the video shows"},
+		"missing": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Elements of the prompt that the video lacks or gets wrong"},
+		"artifacts": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"time":        map[string]any{"type": "string", "description": "MM:SS"},
+					"description": map[string]any{"type": "string"},
+				},
+				"required": []string{"time", "description"},
+			},
+			"description": "Visual glitches such as warped bodies, flicker or garbled text",
+		},
+	},
+	"required": []string{"score", "verdict", "summary", "matched", "missing", "artifacts"},
+}
+
+const checkPromptTemplate = `You are reviewing a generated video against the prompt it was generated from.
+
+Prompt:
+%s
+
+Score how closely the video follows the prompt from 0 to 10. List the elements of the prompt the video shows and those it lacks or gets wrong, and note visual artifacts with their time as MM:SS. The verdict is "pass" for a score of 8 or more, "partial" for 4 to 7 and "fail" below 4.`
+
+type analyzeFlags struct {
+	promptFile  string
+	schema      string
+	checkPrompt string
+	start       float64
+	end         float64
+	fps         float64
+	model       string
+}
+
+type analyzeResponse struct {
+	Success bool   `json:"success"`
+	Answer  any    `json:"answer"`
+	Model   string `json:"model"`
+	Source  string `json:"source"`
+}
+
+var analyzeCmd = newAnalyzeCmd()
+
+func newAnalyzeCmd() *cobra.Command {
+	flags := &analyzeFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "analyze <file|url> [question]",
+		Short: "Ask Gemini about a video",
+		Long: `Ask Gemini a question about a video and return its answer.
+
+The video can be a local file, an http(s) URL or a YouTube URL. Files up to
+14 MB are sent inline; larger ones are uploaded with the Files API and deleted
+afterwards. Without a question, the video is described scene by scene.
+
+Use --schema for an answer in JSON matching a schema, or --check-prompt to
+score how closely a generated video follows its prompt.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAnalyze(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.promptFile, "prompt-file", "", "Read the question from a file")
+	cmd.Flags().StringVar(&flags.schema, "schema", "", "JSON schema of the answer (file or inline JSON)")
+	cmd.Flags().StringVar(&flags.checkPrompt, "check-prompt", "", "Score how closely the video follows this generation prompt")
+	cmd.Flags().Float64Var(&flags.start, "start", 0, "Start of the analyzed range in seconds")
+	cmd.Flags().Float64Var(&flags.end, "end", 0, "End of the analyzed range in seconds (0 = end of video)")
+	cmd.Flags().Float64Var(&flags.fps, "fps", 0, "Frames sampled per second (default 1, max 24)")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", "flash", "Model: flash, pro")
+
+	return cmd
+}
+
+func runAnalyze(cmd *cobra.Command, args []string, flags *analyzeFlags) error {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		return common.WriteError(cmd, "missing_input", "video file or URL is required")
+	}
+	source := strings.TrimSpace(args[0])
+
+	// Question from args or file; stdin is left alone so the default applies
+	question := strings.TrimSpace(strings.Join(args[1:], " "))
+	if question == "" && flags.promptFile != "" {
+		data, err := os.ReadFile(flags.promptFile)
+		if err != nil {
+			return common.WriteError(cmd, "prompt_file_not_found", fmt.Sprintf("cannot read prompt file: %s", err.Error()))
+		}
+		question = strings.TrimSpace(string(data))
+	}
+
+	// Validate modes
+	if flags.checkPrompt != "" && (question != "" || flags.schema != "") {
+		return common.WriteError(cmd, "conflicting_flags", "--check-prompt cannot be used with a question or --schema")
+	}
+	var schema map[string]any
+	if flags.schema != "" {
+		var err error
+		if schema, err = common.LoadJSONSchema(flags.schema); err != nil {
+			return common.WriteError(cmd, "invalid_schema", err.Error())
+		}
+	}
+
+	// Validate model
+	modelID, ok := analyzeModelIDs[flags.model]
+	if !ok {
+		return common.WriteError(cmd, "invalid_model", fmt.Sprintf("invalid model '%s', use 'flash' or 'pro'", flags.model))
+	}
+
+	// Validate range and sampling
+	if flags.start < 0 || flags.end < 0 {
+		return common.WriteError(cmd, "invalid_range", "--start and --end must not be negative")
+	}
+	if flags.end > 0 && flags.end <= flags.start {
+		return common.WriteError(cmd, "invalid_range", "--end must be after --start")
+	}
+	if flags.fps < 0 || flags.fps > analyzeMaxFPS {
+		return common.WriteError(cmd, "invalid_fps", fmt.Sprintf("--fps must be between 0 and %d", analyzeMaxFPS))
+	}
+
+	// Validate local file
+	youtube := isYouTubeURL(source)
+	remote := !youtube && isHTTPURL(source)
+	if !youtube && !remote {
+		if _, err := os.Stat(source); err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("video file not found: %s", source))
+		}
+		if _, ok := analyzeVideoFormats[strings.ToLower(filepath.Ext(source))]; !ok {
+			return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported video format '%s', supported: mp4, mpeg, mov, avi, flv, webm, wmv, 3gp", filepath.Ext(source)))
+		}
+	}
+
+	// Check API key
+	apiKey := config.GetAPIKey("GEMINI_API_KEY", "GOOGLE_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("GEMINI_API_KEY", "GOOGLE_API_KEY"))
+	}
+
+	// Create client
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return common.WriteError(cmd, "client_error", fmt.Sprintf("failed to create client: %s", err.Error()))
+	}
+
+	// Other URLs are fetched and handled as local files
+	path := source
+	if remote {
+		dir, err := os.MkdirTemp("", "rawgenai-video-")
+		if err != nil {
+			return common.WriteError(cmd, "download_error", err.Error())
+		}
+		defer os.RemoveAll(dir)
+		result, err := common.DownloadURL(ctx, source, filepath.Join(dir, "video"), common.DownloadOptions{})
+		if err != nil {
+			return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download video: %s", err.Error()))
+		}
+		path = result.Path
+		if _, ok := analyzeVideoFormats[strings.ToLower(filepath.Ext(path))]; !ok {
+			return common.WriteError(cmd, "unsupported_format", "cannot tell the video format from the URL, supported: mp4, mpeg, mov, avi, flv, webm, wmv, 3gp")
+		}
+	}
+
+	// Build video part
+	var video *genai.Part
+	if youtube {
+		video = &genai.Part{FileData: &genai.FileData{FileURI: source}}
+	} else {
+		part, cleanup, partErr := videoPart(ctx, client, path)
+		if cleanup != nil {
+			defer cleanup()
+		}
+		if partErr != nil {
+			return common.WriteError(cmd, partErr.code, partErr.message)
+		}
+		video = part
+	}
+	if flags.start > 0 || flags.end > 0 || flags.fps > 0 {
+		video.VideoMetadata = &genai.VideoMetadata{
+			StartOffset: time.Duration(flags.start * float64(time.Second)),
+			EndOffset:   time.Duration(flags.end * float64(time.Second)),
+		}
+		if flags.fps > 0 {
+			fps := flags.fps
+			video.VideoMetadata.FPS = &fps
+		}
+	}
+
+	// Build request
+	genConfig := &genai.GenerateContentConfig{}
+	switch {
+	case flags.checkPrompt != "":
+		question = fmt.Sprintf(checkPromptTemplate, flags.checkPrompt)
+		schema = checkPromptSchema
+	case question == "":
+		question = defaultAnalyzeQuestion
+	}
+	if schema != nil {
+		genConfig.ResponseMIMEType = "application/json"
+		genConfig.ResponseJsonSchema = schema
+	}
+	contents := []*genai.Content{genai.NewContentFromParts([]*genai.Part{video, genai.NewPartFromText(question)}, genai.RoleUser)}
+
+	// Call API
+	result, err := client.Models.GenerateContent(ctx, modelID, contents, genConfig)
+	if err != nil {
+		return handleAPIError(cmd, err)
+	}
+	text := strings.TrimSpace(result.Text())
+	if text == "" {
+		reason := ""
+		if len(result.Candidates) > 0 {
+			reason = string(result.Candidates[0].FinishReason)
+		}
+		return common.WriteError(cmd, "no_answer", fmt.Sprintf("no answer in response, finish reason: %s", reason))
+	}
+
+	resp := analyzeResponse{
+		Success: true,
+		Answer:  text,
+		Model:   modelID,
+		Source:  source,
+	}
+	if schema != nil {
+		var answer any
+		if err := json.Unmarshal([]byte(text), &answer); err != nil {
+			return common.WriteError(cmd, "invalid_response", fmt.Sprintf("answer is not valid JSON: %s", err.Error()))
+		}
+		resp.Answer = answer
+	}
+
+	return common.WriteSuccess(cmd, resp)
+}
+
+// analyzeError carries the error code of a failure while preparing the video
+type analyzeError struct {
+	code    string
+	message string
+}
+
+// videoPart sends small videos inline and uploads larger ones, waiting until
+// the Files API has processed them. cleanup deletes the uploaded file.
+func videoPart(ctx context.Context, client *genai.Client, path string) (*genai.Part, func(), *analyzeError) {
+	mimeType := analyzeVideoFormats[strings.ToLower(filepath.Ext(path))]
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, &analyzeError{"file_not_found", fmt.Sprintf("video file not found: %s", path)}
+	}
+
+	if info.Size() <= analyzeMaxInlineSize {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, &analyzeError{"file_not_found", fmt.Sprintf("cannot read video file: %s", err.Error())}
+		}
+		return genai.NewPartFromBytes(data, mimeType), nil, nil
+	}
+
+	file, err := client.Files.UploadFromPath(ctx, path, &genai.UploadFileConfig{MIMEType: mimeType})
+	if err != nil {
+		return nil, nil, &analyzeError{"upload_error", fmt.Sprintf("failed to upload video file: %s", err.Error())}
+	}
+	name := file.Name
+	cleanup := func() {
+		_, _ = client.Files.Delete(ctx, name, nil)
+	}
+
+	// Videos must be processed before they can be used
+	deadline := time.Now().Add(analyzeProcessingTimeout)
+	for file.State == genai.FileStateProcessing {
+		if time.Now().After(deadline) {
+			return nil, cleanup, &analyzeError{"processing_timeout", fmt.Sprintf("uploaded video was still processing after %s", analyzeProcessingTimeout)}
+		}
+		time.Sleep(analyzePollInterval)
+		if file, err = client.Files.Get(ctx, name, nil); err != nil {
+			return nil, cleanup, &analyzeError{"upload_error", fmt.Sprintf("failed to check uploaded video: %s", err.Error())}
+		}
+	}
+	if file.State == genai.FileStateFailed {
+		message := "processing failed"
+		if file.Error != nil && file.Error.Message != "" {
+			message = file.Error.Message
+		}
+		return nil, cleanup, &analyzeError{"upload_error", fmt.Sprintf("uploaded video cannot be used: %s", message)}
+	}
+	return genai.NewPartFromFile(*file), cleanup, nil
+}
+
+func isHTTPURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+func isYouTubeURL(s string) bool {
+	if !isHTTPURL(s) {
+		return false
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	switch strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.") {
+	case "youtube.com", "m.youtube.com", "youtu.be":
+		return true
+	}
+	return false
+}
@@ -10,8 +10,9 @@ import (
 // Cmd is the video parent command
 var Cmd = &cobra.Command{
 	Use:   "video",
-	Short: "Video generation commands using Google Veo",
-	Long: `Commands for video generation using Google Veo 3.1 models (veo-3.1, veo-3.1-fast).
+	Short: "Video generation and understanding commands using Google Veo and Gemini",
+	Long: `Commands for video generation using Google Veo 3.1 models (veo-3.1, veo-3.1-fast),
+and for video understanding with Gemini ('analyze').
 
 IMPORTANT: Save the operation_id returned by 'create' and 'extend' commands.
 You need it to check status, download video, and extend. Videos are stored
@@ -23,6 +24,7 @@ func init() {
 	Cmd.AddCommand(extendCmd)
 	Cmd.AddCommand(statusCmd)
 	Cmd.AddCommand(downloadCmd)
+	Cmd.AddCommand(analyzeCmd)
 }
 
 func handleAPIError(cmd *cobra.Command, err error) error {
@@ -3,7 +3,8 @@ package video
 import (
 	"context"
 	"fmt"
-	"os"
+	"net/http"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -14,7 +15,9 @@ import (
 )
 
 type downloadFlags struct {
-	output string
+	output   string
+	all      bool
+	download common.DownloadFlags
 }
 
 type downloadResponse struct {
@@ -41,6 +44,8 @@ func newDownloadCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.mp4)")
+	cmd.Flags().BoolVar(&flags.all, "all", false, "Download every generated video (-o is a directory or {index} template)")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
@@ -55,9 +60,23 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	if flags.output == "" {
 		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
 	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
 
+	if flags.all {
+		if err := common.ValidateAllOutput(flags.output); err != nil {
+			return common.WriteError(cmd, "invalid_output", err.Error())
+		}
+	} else if err := common.ValidateSingleOutput(flags.output); err != nil {
+		return common.WriteError(cmd, "invalid_output", err.Error())
+	}
+
+	if !flags.all {
+		flags.output = common.DefaultExt(flags.output, ".mp4")
+	}
 	ext := strings.ToLower(filepath.Ext(flags.output))
-	if ext != ".mp4" {
+	if !flags.all && ext != ".mp4" {
 		return common.WriteError(cmd, "invalid_format", "output file must be .mp4")
 	}
 
@@ -68,7 +87,7 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	}
 
 	// Create client
-	ctx := context.Background()
+	ctx := cmd.Context()
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
 		APIKey:  apiKey,
 		Backend: genai.BackendGeminiAPI,
@@ -105,38 +124,54 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 		return common.WriteError(cmd, "no_video", "no video generated in response")
 	}
 
-	video := op.Response.GeneratedVideos[0].Video
-	if video == nil {
-		return common.WriteError(cmd, "no_video", "video data not available")
-	}
-
-	// Get absolute path for output
-	absPath, err := filepath.Abs(flags.output)
-	if err != nil {
-		absPath = flags.output
-	}
-
-	// Download video content using SDK (handles authentication)
-	if len(video.VideoBytes) == 0 && video.URI != "" {
-		_, err = client.Files.Download(ctx, video, nil)
-		if err != nil {
-			return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download video: %s", err.Error()))
+	name := path.Base(operationID)
+	if flags.all {
+		files := make([]common.OutputFile, 0, len(op.Response.GeneratedVideos))
+		for i, generated := range op.Response.GeneratedVideos {
+			if generated.Video == nil {
+				continue
+			}
+			output := common.IndexedPath(flags.output, name, i, len(op.Response.GeneratedVideos), ".mp4")
+			saved, err := saveVideo(ctx, generated.Video, output, apiKey, common.DownloadOptions{DownloadFlags: flags.download, Vars: common.OutputVars{TaskID: name}})
+			if err != nil {
+				return common.WriteDownloadAllError(cmd, fmt.Errorf("video %d: %w", i, err), files)
+			}
+			files = append(files, common.OutputFile{Index: i, Kind: "video", File: saved.Path})
 		}
+		return common.WriteSuccess(cmd, map[string]any{
+			"success":      true,
+			"operation_id": operationID,
+			"files":        files,
+		})
 	}
 
-	// Write video bytes to file
-	if len(video.VideoBytes) == 0 {
-		return common.WriteError(cmd, "no_video", "video data not available after download")
+	video := op.Response.GeneratedVideos[0].Video
+	if video == nil || (len(video.VideoBytes) == 0 && video.URI == "") {
+		return common.WriteError(cmd, "no_video", "video data not available")
 	}
 
-	if err := os.WriteFile(absPath, video.VideoBytes, 0644); err != nil {
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+	saved, err := saveVideo(ctx, video, common.IndexedPath(flags.output, name, 0, 1, ".mp4"), apiKey, common.DownloadOptions{DownloadFlags: flags.download, Vars: common.OutputVars{TaskID: name}})
+	if err != nil {
+		return common.WriteError(cmd, common.DownloadErrorCode(err), err.Error())
 	}
 
 	result := downloadResponse{
 		Success:     true,
 		OperationID: operationID,
-		File:        absPath,
+		File:        saved.Path,
 	}
 	return common.WriteSuccess(cmd, result)
 }
+
+// saveVideo writes inline bytes directly, otherwise streams the file URI (needs the API key).
+func saveVideo(ctx context.Context, video *genai.Video, output, apiKey string, opts common.DownloadOptions) (*common.DownloadResult, error) {
+	if len(video.VideoBytes) > 0 {
+		return common.SaveBytes(video.VideoBytes, output, opts)
+	}
+	if video.URI == "" {
+		return nil, fmt.Errorf("video data not available")
+	}
+	opts.Header = http.Header{}
+	opts.Header.Set("x-goog-api-key", apiKey)
+	return common.DownloadURL(ctx, video.URI, output, opts)
+}
@@ -4,9 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/spf13/cobra"
@@ -571,6 +577,25 @@ func TestDownload_MissingOutput(t *testing.T) {
 	}
 }
 
+func TestDownload_ConflictingFlags(t *testing.T) {
+	cmd := newDownloadCmd()
+	_, stderr, err := executeCommand(cmd, "operations/generate-videos-abc123", "-o", "output.mp4", "--overwrite", "--no-clobber")
+
+	if err == nil {
+		t.Fatal("expected error for conflicting flags")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "conflicting_flags" {
+		t.Errorf("expected error code 'conflicting_flags', got: %s", errorObj["code"])
+	}
+}
+
 func TestDownload_InvalidFormat(t *testing.T) {
 	cmd := newDownloadCmd()
 	_, stderr, err := executeCommand(cmd, "operations/generate-videos-abc123", "-o", "out.avi")
@@ -900,11 +925,16 @@ func TestDownload_UppercaseMP4(t *testing.T) {
 }
 
 func TestDownload_NoExtension(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY", "")
+
+	// A missing extension defaults to .mp4, so validation passes
 	cmd := newDownloadCmd()
 	_, stderr, err := executeCommand(cmd, "operations/generate-videos-abc123", "-o", "output")
 
 	if err == nil {
-		t.Fatal("expected error for no extension")
+		t.Fatal("expected error for missing API key")
 	}
 
 	var resp map[string]any
@@ -913,8 +943,8 @@ func TestDownload_NoExtension(t *testing.T) {
 	}
 
 	errorObj := resp["error"].(map[string]any)
-	if errorObj["code"] != "invalid_format" {
-		t.Errorf("expected error code 'invalid_format', got: %s", errorObj["code"])
+	if errorObj["code"] != "missing_api_key" {
+		t.Errorf("expected error code 'missing_api_key', got: %s", errorObj["code"])
 	}
 }
 
@@ -1594,3 +1624,339 @@ func TestCreate_RefInvalidFormat(t *testing.T) {
 		t.Errorf("expected error code 'invalid_image_format', got: %s", errorObj["code"])
 	}
 }
+
+func TestDownload_AllRequiresMultiFileOutput(t *testing.T) {
+	cmd := newDownloadCmd()
+	_, stderr, err := executeCommand(cmd, "operations/generate-videos-abc123", "-o", "out.mp4", "--all")
+	if err == nil {
+		t.Fatal("expected error for --all with a single-file output")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_output" {
+		t.Errorf("expected error code 'invalid_output', got: %s", errorObj["code"])
+	}
+}
+
+// ============ video analyze tests ============
+
+func TestAnalyze_Validation(t *testing.T) {
+	dir := t.TempDir()
+	video := filepath.Join(dir, "clip.mp4")
+	os.WriteFile(video, []byte("mp4"), 0644)
+	text := filepath.Join(dir, "clip.txt")
+	os.WriteFile(text, []byte("text"), 0644)
+
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"missing input", nil, "missing_input"},
+		{"file not found", []string{filepath.Join(dir, "nope.mp4")}, "file_not_found"},
+		{"unsupported format", []string{text}, "unsupported_format"},
+		{"invalid model", []string{video, "-m", "ultra"}, "invalid_model"},
+		{"negative start", []string{video, "--start", "-1"}, "invalid_range"},
+		{"end before start", []string{video, "--start", "10", "--end", "5"}, "invalid_range"},
+		{"fps too high", []string{video, "--fps", "30"}, "invalid_fps"},
+		{"check prompt with question", []string{video, "what happens?", "--check-prompt", "A cat"}, "conflicting_flags"},
+		{"check prompt with schema", []string{video, "--check-prompt", "A cat", "--schema", `{"type":"object"}`}, "conflicting_flags"},
+		{"invalid inline schema", []string{video, "--schema", "{not json"}, "invalid_schema"},
+		{"missing schema file", []string{video, "--schema", filepath.Join(dir, "schema.json")}, "invalid_schema"},
+		{"missing api key", []string{video}, "missing_api_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			_, stderr, err := executeCommand(newAnalyzeCmd(), tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+// analyzeServer fakes generateContent and the Files API
+type analyzeServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	answer   string
+	requests map[string]string
+	polls    int
+	pollFail bool
+	stuck    bool
+}
+
+func newAnalyzeServer(t *testing.T, answer string) *analyzeServer {
+	s := &analyzeServer{answer: answer, requests: map[string]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		key := r.Method + " " + r.URL.Path
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !strings.HasPrefix(key, "POST /upload-session") {
+			s.requests[key] = string(body)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case key == "POST /upload/v1beta/files":
+			w.Header().Set("X-Goog-Upload-Url", s.URL+"/upload-session")
+			w.Write([]byte(`{}`))
+		case key == "POST /upload-session":
+			if strings.Contains(r.Header.Get("X-Goog-Upload-Command"), "finalize") {
+				w.Header().Set("X-Goog-Upload-Status", "final")
+				w.Write([]byte(`{"file":{"name":"files/vid1","uri":"https://files/vid1","mimeType":"video/mp4","state":"PROCESSING"}}`))
+				return
+			}
+			w.Header().Set("X-Goog-Upload-Status", "active")
+		case key == "GET /v1beta/files/vid1":
+			s.polls++
+			if s.pollFail {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"error":{"code":500,"message":"internal","status":"INTERNAL"}}`))
+				return
+			}
+			if s.stuck {
+				w.Write([]byte(`{"name":"files/vid1","uri":"https://files/vid1","mimeType":"video/mp4","state":"PROCESSING"}`))
+				return
+			}
+			w.Write([]byte(`{"name":"files/vid1","uri":"https://files/vid1","mimeType":"video/mp4","state":"ACTIVE"}`))
+		case key == "DELETE /v1beta/files/vid1":
+			w.Write([]byte(`{}`))
+		case strings.HasSuffix(key, ":generateContent"):
+			text, _ := json.Marshal(s.answer)
+			w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":` + string(text) + `}]},"finishReason":"STOP"}]}`))
+		default:
+			t.Errorf("unexpected request: %s", key)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", s.URL)
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+	return s
+}
+
+func (s *analyzeServer) request(t *testing.T, model string) map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, ok := s.requests["POST /v1beta/models/"+model+":generateContent"]
+	if !ok {
+		t.Fatalf("no generateContent request for %s, got: %v", model, s.requests)
+	}
+	var req map[string]any
+	json.Unmarshal([]byte(body), &req)
+	return req
+}
+
+func TestAnalyze_Inline(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	server := newAnalyzeServer(t, "A cat plays the piano at 00:03.")
+	video := filepath.Join(t.TempDir(), "clip.mp4")
+	os.WriteFile(video, []byte("mp4 data"), 0644)
+
+	stdout, stderr, err := executeCommand(newAnalyzeCmd(), video, "what", "happens?", "--start", "2", "--end", "8", "--fps", "2", "-m", "pro")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp analyzeResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if !resp.Success || resp.Answer != "A cat plays the piano at 00:03." || resp.Model != "gemini-2.5-pro" || resp.Source != video {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	req := server.request(t, "gemini-2.5-pro")
+	body, _ := json.Marshal(req["contents"])
+	for _, want := range []string{`"mimeType":"video/mp4"`, `"videoMetadata":{`, `"startOffset":"2s"`, `"endOffset":"8s"`, `"fps":2`, `"text":"what happens?"`} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected request to contain %s, got %s", want, body)
+		}
+	}
+	if _, ok := req["generationConfig"].(map[string]any)["responseJsonSchema"]; ok {
+		t.Errorf("expected no schema for a question: %v", req["generationConfig"])
+	}
+}
+
+func TestAnalyze_Schema(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	server := newAnalyzeServer(t, `{"people": 2}`)
+	dir := t.TempDir()
+	video := filepath.Join(dir, "clip.webm")
+	os.WriteFile(video, []byte("webm data"), 0644)
+	schema := filepath.Join(dir, "schema.json")
+	os.WriteFile(schema, []byte(`{"type":"object","properties":{"people":{"type":"integer"}}}`), 0644)
+
+	stdout, stderr, err := executeCommand(newAnalyzeCmd(), video, "How many people?", "--schema", schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"answer":{"people":2}`) {
+		t.Errorf("expected JSON answer, got: %s", stdout)
+	}
+
+	config, _ := json.Marshal(server.request(t, "gemini-2.5-flash")["generationConfig"])
+	for _, want := range []string{`"responseMimeType":"application/json"`, `"responseJsonSchema":{"properties":{"people"`} {
+		if !strings.Contains(string(config), want) {
+			t.Errorf("expected config to contain %s, got %s", want, config)
+		}
+	}
+}
+
+func TestAnalyze_SchemaInvalidAnswer(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	newAnalyzeServer(t, "not json")
+	video := filepath.Join(t.TempDir(), "clip.mp4")
+	os.WriteFile(video, []byte("mp4 data"), 0644)
+
+	_, stderr, err := executeCommand(newAnalyzeCmd(), video, "--schema", `{"type":"object"}`)
+	if err == nil || !strings.Contains(stderr, `"code":"invalid_response"`) {
+		t.Errorf("expected invalid_response, got: %s", stderr)
+	}
+}
+
+func TestAnalyze_CheckPrompt(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	server := newAnalyzeServer(t, `{"score":7,"verdict":"partial","summary":"The cat plays a guitar.","matched":["cat"],"missing":["piano"],"artifacts":[{"time":"00:04","description":"extra paw"}]}`)
+	video := filepath.Join(t.TempDir(), "clip.mp4")
+	os.WriteFile(video, []byte("mp4 data"), 0644)
+
+	stdout, stderr, err := executeCommand(newAnalyzeCmd(), video, "--check-prompt", "A cat playing piano on stage")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp struct {
+		Answer struct {
+			Score     int    `json:"score"`
+			Verdict   string `json:"verdict"`
+			Artifacts []struct {
+				Time string `json:"time"`
+			} `json:"artifacts"`
+		} `json:"answer"`
+	}
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp.Answer.Score != 7 || resp.Answer.Verdict != "partial" || len(resp.Answer.Artifacts) != 1 {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	req, _ := json.Marshal(server.request(t, "gemini-2.5-flash"))
+	for _, want := range []string{"A cat playing piano on stage", `"verdict":{"enum":["pass","partial","fail"]`} {
+		if !strings.Contains(string(req), want) {
+			t.Errorf("expected request to contain %s, got %s", want, req)
+		}
+	}
+}
+
+func TestAnalyze_YouTube(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	server := newAnalyzeServer(t, "A talk about Go.")
+	url := "https://www.youtube.com/watch?v=abc123"
+
+	stdout, stderr, err := executeCommand(newAnalyzeCmd(), url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"source":"`+url+`"`) {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	body, _ := json.Marshal(server.request(t, "gemini-2.5-flash")["contents"])
+	if !strings.Contains(string(body), `"fileData":{"fileUri":"`+url+`"}`) || !strings.Contains(string(body), "MM:SS") {
+		t.Errorf("expected YouTube file data and default question, got %s", body)
+	}
+}
+
+func TestAnalyze_Upload(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	server := newAnalyzeServer(t, "A long video.")
+	video := filepath.Join(t.TempDir(), "long.mp4")
+	f, _ := os.Create(video)
+	f.Truncate(analyzeMaxInlineSize + 1)
+	f.Close()
+
+	interval := analyzePollInterval
+	analyzePollInterval = time.Millisecond
+	t.Cleanup(func() { analyzePollInterval = interval })
+
+	_, stderr, err := executeCommand(newAnalyzeCmd(), video)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	body, _ := json.Marshal(server.request(t, "gemini-2.5-flash")["contents"])
+	if !strings.Contains(string(body), `"fileUri":"https://files/vid1"`) {
+		t.Errorf("expected uploaded file in request, got %s", body)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.polls != 1 {
+		t.Errorf("expected 1 status check, got %d", server.polls)
+	}
+	if _, ok := server.requests["DELETE /v1beta/files/vid1"]; !ok {
+		t.Error("expected uploaded file to be deleted")
+	}
+}
+
+func TestAnalyze_UploadCheckFails(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	server := newAnalyzeServer(t, "A long video.")
+	server.pollFail = true
+	video := filepath.Join(t.TempDir(), "long.mp4")
+	f, _ := os.Create(video)
+	f.Truncate(analyzeMaxInlineSize + 1)
+	f.Close()
+
+	interval := analyzePollInterval
+	analyzePollInterval = time.Millisecond
+	t.Cleanup(func() { analyzePollInterval = interval })
+
+	_, stderr, err := executeCommand(newAnalyzeCmd(), video)
+	if err == nil {
+		t.Fatal("expected error when the uploaded video cannot be checked")
+	}
+	if !strings.Contains(stderr, `"code":"upload_error"`) {
+		t.Errorf("expected upload_error, got: %s", stderr)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if _, ok := server.requests["DELETE /v1beta/files/vid1"]; !ok {
+		t.Error("expected uploaded file to be deleted")
+	}
+}
+
+func TestAnalyze_ProcessingTimeout(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	server := newAnalyzeServer(t, "A long video.")
+	server.stuck = true
+	video := filepath.Join(t.TempDir(), "long.mp4")
+	f, _ := os.Create(video)
+	f.Truncate(analyzeMaxInlineSize + 1)
+	f.Close()
+
+	interval, timeout := analyzePollInterval, analyzeProcessingTimeout
+	analyzePollInterval, analyzeProcessingTimeout = time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { analyzePollInterval, analyzeProcessingTimeout = interval, timeout })
+
+	_, stderr, err := executeCommand(newAnalyzeCmd(), video)
+	if err == nil {
+		t.Fatal("expected error when the uploaded video never finishes processing")
+	}
+	if !strings.Contains(stderr, `"code":"processing_timeout"`) {
+		t.Errorf("expected processing_timeout, got: %s", stderr)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if _, ok := server.requests["DELETE /v1beta/files/vid1"]; !ok {
+		t.Error("expected uploaded file to be deleted")
+	}
+}
@@ -0,0 +1,219 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/spf13/cobra"
+	"google.golang.org/genai"
+)
+
+// Model name mapping for image and audio understanding
+var visionModelIDs = map[string]string{
+	"flash": "gemini-2.5-flash",
+	"pro":   "gemini-2.5-pro",
+}
+
+// Image formats Gemini can read; audio formats are those of stt
+var visionImageFormats = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".webp": "image/webp",
+	".heic": "image/heic",
+	".heif": "image/heif",
+}
+
+// visionMaxInlineSize is how much inline data a request may carry, measured
+// as encoded: a request may not exceed 20 MB and base64 grows data by a
+// third, so a single file over about 14 MB is uploaded instead.
+const visionMaxInlineSize = 19 * 1024 * 1024
+
+const defaultVisionQuestion = "Describe the attached files in detail."
+
+// Vision flags
+type visionFlags struct {
+	inputs     []string
+	promptFile string
+	schema     string
+	model      string
+}
+
+type visionResponse struct {
+	Success bool   `json:"success"`
+	Answer  any    `json:"answer"`
+	Model   string `json:"model"`
+}
+
+// Command
+var visionCmd = newVisionCmd()
+
+func newVisionCmd() *cobra.Command {
+	flags := &visionFlags{}
+
+	cmd := &cobra.Command{
+		Use:     "vision [question]",
+		Aliases: []string{"describe"},
+		Short:   "Ask Gemini about images and audio",
+		Long: `Ask Gemini a question about one or more images or audio files and return its answer.
+
+Images and audio can be mixed in one request. Files are sent inline while the
+request stays within 20 MB once base64 encoded (about 14 MB of files); the rest
+are uploaded with the Files API and deleted afterwards. Without a question, the files are described. Use --schema for an answer in JSON matching
+a schema.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVision(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&flags.inputs, "input", "i", nil, "Image or audio file, can be repeated")
+	cmd.Flags().StringVar(&flags.promptFile, "prompt-file", "", "Read the question from a file")
+	cmd.Flags().StringVar(&flags.schema, "schema", "", "JSON schema of the answer (file or inline JSON)")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", "flash", "Model: flash, pro")
+
+	return cmd
+}
+
+func runVision(cmd *cobra.Command, args []string, flags *visionFlags) error {
+	// Get question; without one the files are described
+	question := strings.TrimSpace(strings.Join(args, " "))
+	if question == "" && flags.promptFile != "" {
+		data, err := os.ReadFile(flags.promptFile)
+		if err != nil {
+			return common.WriteError(cmd, "prompt_file_not_found", fmt.Sprintf("cannot read prompt file: %s", err.Error()))
+		}
+		question = strings.TrimSpace(string(data))
+	}
+	if question == "" {
+		question = defaultVisionQuestion
+	}
+
+	// Validate inputs
+	if len(flags.inputs) == 0 {
+		return common.WriteError(cmd, "missing_input", "at least one image or audio file is required, use -i flag")
+	}
+	for _, input := range flags.inputs {
+		if _, err := os.Stat(input); err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("file not found: %s", input))
+		}
+		if visionMimeType(input) == "" {
+			return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported format '%s', supported images: png, jpg, webp, heic, heif; audio: mp3, wav, flac, ogg, aac, m4a, webm", filepath.Ext(input)))
+		}
+	}
+
+	// Validate schema
+	var schema map[string]any
+	if flags.schema != "" {
+		var err error
+		if schema, err = common.LoadJSONSchema(flags.schema); err != nil {
+			return common.WriteError(cmd, "invalid_schema", err.Error())
+		}
+	}
+
+	// Validate model
+	modelID, ok := visionModelIDs[flags.model]
+	if !ok {
+		return common.WriteError(cmd, "invalid_model", fmt.Sprintf("invalid model '%s', use 'flash' or 'pro'", flags.model))
+	}
+
+	// Check API key
+	apiKey := config.GetAPIKey("GEMINI_API_KEY", "GOOGLE_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("GEMINI_API_KEY", "GOOGLE_API_KEY"))
+	}
+
+	// Create client
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return common.WriteError(cmd, "client_error", fmt.Sprintf("failed to create client: %s", err.Error()))
+	}
+
+	// Build parts: files first, then the question. Files are sent inline
+	// while the request stays within its size limit, the rest are uploaded.
+	parts := make([]*genai.Part, 0, len(flags.inputs)+1)
+	inlineLeft := int64(visionMaxInlineSize)
+	for _, input := range flags.inputs {
+		mimeType := visionMimeType(input)
+		info, err := os.Stat(input)
+		if err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot access file: %s", err.Error()))
+		}
+		if size := inlineSize(info.Size()); size <= inlineLeft {
+			data, err := os.ReadFile(input)
+			if err != nil {
+				return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read file: %s", err.Error()))
+			}
+			inlineLeft -= size
+			parts = append(parts, genai.NewPartFromBytes(data, mimeType))
+			continue
+		}
+
+		uploadedFile, err := client.Files.UploadFromPath(ctx, input, &genai.UploadFileConfig{MIMEType: mimeType})
+		if err != nil {
+			return common.WriteError(cmd, "upload_error", fmt.Sprintf("failed to upload file: %s", err.Error()))
+		}
+		defer func() {
+			_, _ = client.Files.Delete(ctx, uploadedFile.Name, nil)
+		}()
+		parts = append(parts, genai.NewPartFromFile(*uploadedFile))
+	}
+	parts = append(parts, genai.NewPartFromText(question))
+
+	genConfig := &genai.GenerateContentConfig{}
+	if schema != nil {
+		genConfig.ResponseMIMEType = "application/json"
+		genConfig.ResponseJsonSchema = schema
+	}
+
+	// Call API
+	contents := []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)}
+	result, err := client.Models.GenerateContent(ctx, modelID, contents, genConfig)
+	if err != nil {
+		return handleAPIError(cmd, err)
+	}
+	text := strings.TrimSpace(result.Text())
+	if text == "" {
+		reason := ""
+		if len(result.Candidates) > 0 {
+			reason = string(result.Candidates[0].FinishReason)
+		}
+		return common.WriteError(cmd, "no_answer", fmt.Sprintf("no answer in response, finish reason: %s", reason))
+	}
+
+	resp := visionResponse{
+		Success: true,
+		Answer:  text,
+		Model:   modelID,
+	}
+	if schema != nil {
+		var answer any
+		if err := json.Unmarshal([]byte(text), &answer); err != nil {
+			return common.WriteError(cmd, "invalid_response", fmt.Sprintf("answer is not valid JSON: %s", err.Error()))
+		}
+		resp.Answer = answer
+	}
+
+	return common.WriteSuccess(cmd, resp)
+}
+
+// visionMimeType returns the MIME type of an image or audio file, or "" if
+// Gemini cannot read it.
+func visionMimeType(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if mimeType, ok := visionImageFormats[ext]; ok {
+		return mimeType
+	}
+	return sttSupportedFormats[ext]
+}
@@ -0,0 +1,202 @@
+package google
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+)
+
+func TestVision_Validation(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "cat.png")
+	os.WriteFile(image, []byte("png"), 0644)
+	doc := filepath.Join(dir, "notes.pdf")
+	os.WriteFile(doc, []byte("pdf"), 0644)
+
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"missing input", []string{"What is this?"}, "missing_input"},
+		{"file not found", []string{"-i", filepath.Join(dir, "nope.png")}, "file_not_found"},
+		{"unsupported format", []string{"-i", doc}, "unsupported_format"},
+		{"invalid schema", []string{"-i", image, "--schema", "{oops"}, "invalid_schema"},
+		{"invalid model", []string{"-i", image, "-m", "ultra"}, "invalid_model"},
+		{"missing prompt file", []string{"-i", image, "--prompt-file", filepath.Join(dir, "q.txt")}, "prompt_file_not_found"},
+		{"missing api key", []string{"-i", image}, "missing_api_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			_, stderr, err := executeCommand(newVisionCmd(), tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+// visionServer fakes generateContent and the Files API, and records the
+// generateContent request body
+func visionServer(t *testing.T, answer string) (*httptest.Server, func() map[string]any) {
+	var mu sync.Mutex
+	var body []byte
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/upload/v1beta/files":
+			w.Header().Set("X-Goog-Upload-Url", server.URL+"/upload-session")
+			w.Write([]byte(`{}`))
+		case r.URL.Path == "/upload-session":
+			io.Copy(io.Discard, r.Body)
+			w.Header().Set("X-Goog-Upload-Status", "final")
+			w.Write([]byte(`{"file":{"name":"files/f1","uri":"https://files/f1","mimeType":"audio/wav","state":"ACTIVE"}}`))
+		case r.Method == http.MethodDelete:
+			w.Write([]byte(`{}`))
+		default:
+			mu.Lock()
+			body, _ = io.ReadAll(r.Body)
+			mu.Unlock()
+			text, _ := json.Marshal(answer)
+			w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":` + string(text) + `}]},"finishReason":"STOP"}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", server.URL)
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "")
+	return server, func() map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		var req map[string]any
+		json.Unmarshal(body, &req)
+		return req
+	}
+}
+
+func TestVision_ImagesAndAudio(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	_, request := visionServer(t, "A cat next to a meowing sound.")
+	dir := t.TempDir()
+	image := filepath.Join(dir, "cat.jpg")
+	os.WriteFile(image, []byte("jpeg data"), 0644)
+	audio := filepath.Join(dir, "meow.mp3")
+	os.WriteFile(audio, []byte("mp3 data"), 0644)
+
+	stdout, stderr, err := executeCommand(newVisionCmd(), "Does", "the sound match?", "-i", image, "-i", audio, "-m", "pro")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp visionResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if !resp.Success || resp.Answer != "A cat next to a meowing sound." || resp.Model != "gemini-2.5-pro" {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	contents, _ := json.Marshal(request()["contents"])
+	for _, want := range []string{`"mimeType":"image/jpeg"`, `"mimeType":"audio/mpeg"`, `"text":"Does the sound match?"`} {
+		if !strings.Contains(string(contents), want) {
+			t.Errorf("expected request to contain %s, got %s", want, contents)
+		}
+	}
+	if strings.Index(string(contents), "image/jpeg") > strings.Index(string(contents), "Does the sound") {
+		t.Errorf("expected files before the question, got %s", contents)
+	}
+}
+
+func TestVision_UploadsPastInlineLimit(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	_, request := visionServer(t, "Two recordings.")
+	dir := t.TempDir()
+	// Each fits inline, together they exceed the request limit once encoded
+	first := filepath.Join(dir, "first.wav")
+	os.WriteFile(first, make([]byte, 8*1024*1024), 0644)
+	second := filepath.Join(dir, "second.wav")
+	os.WriteFile(second, make([]byte, 8*1024*1024), 0644)
+
+	if _, stderr, err := executeCommand(newVisionCmd(), "-i", first, "-i", second); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	contents, _ := json.Marshal(request()["contents"])
+	if strings.Count(string(contents), `"inlineData"`) != 1 || !strings.Contains(string(contents), `"fileUri":"https://files/f1"`) {
+		t.Errorf("expected one inline and one uploaded file, got %.300s", contents)
+	}
+}
+
+func TestVision_UploadsFileTooLargeOnceEncoded(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	_, request := visionServer(t, "A long recording.")
+	// Under 20 MB on disk, over the request limit once encoded
+	audio := filepath.Join(t.TempDir(), "long.wav")
+	os.WriteFile(audio, make([]byte, 15*1024*1024), 0644)
+
+	if _, stderr, err := executeCommand(newVisionCmd(), "-i", audio); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	contents, _ := json.Marshal(request()["contents"])
+	if strings.Contains(string(contents), `"inlineData"`) || !strings.Contains(string(contents), `"fileUri":"https://files/f1"`) {
+		t.Errorf("expected the file uploaded, got %.300s", contents)
+	}
+}
+
+func TestVision_DefaultQuestion(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	_, request := visionServer(t, "A cat.")
+	image := filepath.Join(t.TempDir(), "cat.png")
+	os.WriteFile(image, []byte("png data"), 0644)
+
+	if _, stderr, err := executeCommand(newVisionCmd(), "-i", image); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	contents, _ := json.Marshal(request()["contents"])
+	if !strings.Contains(string(contents), defaultVisionQuestion) {
+		t.Errorf("expected default question, got %s", contents)
+	}
+}
+
+func TestVision_Schema(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	_, request := visionServer(t, `{"animal":"cat","count":2}`)
+	image := filepath.Join(t.TempDir(), "cats.webp")
+	os.WriteFile(image, []byte("webp data"), 0644)
+
+	stdout, stderr, err := executeCommand(newVisionCmd(), "Which animal, how many?", "-i", image, "--schema", `{"type":"object","properties":{"animal":{"type":"string"},"count":{"type":"integer"}}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"answer":{"animal":"cat","count":2}`) {
+		t.Errorf("expected JSON answer, got: %s", stdout)
+	}
+	config, _ := json.Marshal(request()["generationConfig"])
+	for _, want := range []string{`"responseMimeType":"application/json"`, `"responseJsonSchema":{"properties":{"animal"`} {
+		if !strings.Contains(string(config), want) {
+			t.Errorf("expected config to contain %s, got %s", want, config)
+		}
+	}
+}
+
+func TestVision_SchemaInvalidAnswer(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	visionServer(t, "a cat")
+	image := filepath.Join(t.TempDir(), "cat.png")
+	os.WriteFile(image, []byte("png data"), 0644)
+
+	_, stderr, err := executeCommand(newVisionCmd(), "-i", image, "--schema", `{"type":"object"}`)
+	if err == nil || !strings.Contains(stderr, `"code":"invalid_response"`) {
+		t.Errorf("expected invalid_response, got: %s", stderr)
+	}
+}
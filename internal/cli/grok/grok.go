@@ -8,10 +8,11 @@ import (
 var Cmd = &cobra.Command{
 	Use:   "grok",
 	Short: "xAI Grok provider commands",
-	Long:  "Commands for xAI Grok services including Image generation/editing and Video generation/editing.",
+	Long:  "Commands for xAI Grok services including Image generation/editing, Video generation/editing and Text generation.",
 }
 
 func init() {
 	Cmd.AddCommand(imageCmd)
+	Cmd.AddCommand(textCmd)
 	Cmd.AddCommand(video.Cmd)
 }
@@ -18,8 +18,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// xaiBaseURL is a variable so tests can point it at a local server
+var xaiBaseURL = "https://api.x.ai/v1"
+
 const (
-	xaiBaseURL           = "https://api.x.ai/v1"
 	imageGenerationsPath = "/images/generations"
 	imageEditsPath       = "/images/edits"
 )
@@ -49,9 +51,10 @@ type imageFlags struct {
 }
 
 type imageResponse struct {
-	Success bool   `json:"success"`
-	File    string `json:"file,omitempty"`
-	Mode    string `json:"mode,omitempty"`
+	Success bool     `json:"success"`
+	File    string   `json:"file,omitempty"`
+	Files   []string `json:"files,omitempty"`
+	Mode    string   `json:"mode,omitempty"`
 }
 
 // API response types
@@ -87,7 +90,7 @@ With --image: Edit mode (POST /v1/images/edits)`,
 		},
 	}
 
-	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (required)")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path, may contain {index} (required)")
 	cmd.Flags().StringVar(&flags.promptFile, "prompt-file", "", "Read prompt from file")
 	cmd.Flags().StringVarP(&flags.image, "image", "i", "", "Input image for edit mode")
 	cmd.Flags().IntVarP(&flags.n, "n", "n", 1, "Number of images to generate (1-10, generation mode only)")
@@ -109,6 +112,7 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 	}
 
 	// Validate output format
+	flags.output = common.DefaultExt(flags.output, ".jpg")
 	ext := strings.ToLower(filepath.Ext(flags.output))
 	if _, ok := supportedImageFormats[ext]; !ok {
 		return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported format '%s', supported: png, jpeg, jpg", ext))
@@ -194,26 +198,36 @@ func runImageGenerate(cmd *cobra.Command, prompt string, flags *imageFlags) erro
 		return common.WriteError(cmd, "no_image", "no image generated in response")
 	}
 
-	// Decode and save image
-	imgData, err := base64.StdEncoding.DecodeString(apiResp.Data[0].B64JSON)
-	if err != nil {
-		return common.WriteError(cmd, "decode_error", fmt.Sprintf("cannot decode image: %s", err.Error()))
-	}
+	// Decode and save every image, "_<index>" is added to the name when n > 1
+	var files []string
+	for i, item := range apiResp.Data {
+		imgData, err := base64.StdEncoding.DecodeString(item.B64JSON)
+		if err != nil {
+			return common.WriteError(cmd, "decode_error", fmt.Sprintf("cannot decode image %d: %s", i, err.Error()))
+		}
 
-	absPath, err := filepath.Abs(flags.output)
-	if err != nil {
-		absPath = flags.output
-	}
+		path := common.IndexedPath(flags.output, "image", i, len(apiResp.Data), "")
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			absPath = path
+		}
 
-	if err := os.WriteFile(absPath, imgData, 0644); err != nil {
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		if _, err := common.WriteOutput(absPath, imgData, common.OutputVars{}); err != nil {
+			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		}
+		files = append(files, absPath)
 	}
 
-	return common.WriteSuccess(cmd, imageResponse{
+	result := imageResponse{
 		Success: true,
-		File:    absPath,
 		Mode:    "generate",
-	})
+	}
+	if len(files) == 1 {
+		result.File = files[0]
+	} else {
+		result.Files = files
+	}
+	return common.WriteSuccess(cmd, result)
 }
 
 func runImageEdit(cmd *cobra.Command, prompt string, flags *imageFlags) error {
@@ -305,12 +319,13 @@ func runImageEdit(cmd *cobra.Command, prompt string, flags *imageFlags) error {
 		return common.WriteError(cmd, "decode_error", fmt.Sprintf("cannot decode image: %s", err.Error()))
 	}
 
-	absPath, err := filepath.Abs(flags.output)
+	output := common.IndexedPath(flags.output, "image", 0, 1, "")
+	absPath, err := filepath.Abs(output)
 	if err != nil {
-		absPath = flags.output
+		absPath = output
 	}
 
-	if err := os.WriteFile(absPath, resultData, 0644); err != nil {
+	if _, err := common.WriteOutput(absPath, resultData, common.OutputVars{}); err != nil {
 		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
 	}
 
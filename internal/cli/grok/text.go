@@ -0,0 +1,226 @@
+package grok
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/spf13/cobra"
+)
+
+const responsesPath = "/responses"
+
+type textFlags struct {
+	promptFile  string
+	system      string
+	systemFile  string
+	schema      string
+	continueID  string
+	model       string
+	temperature float64
+	maxTokens   int
+}
+
+type textResponse struct {
+	Success    bool   `json:"success"`
+	Text       any    `json:"text"`
+	Model      string `json:"model"`
+	ResponseID string `json:"response_id,omitempty"`
+}
+
+// API response types
+type xaiTextResponse struct {
+	ID     string `json:"id"`
+	Output []struct {
+		Type    string `json:"type"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"output"`
+	Error *xaiError `json:"error,omitempty"`
+}
+
+var textCmd = newTextCmd()
+
+func newTextCmd() *cobra.Command {
+	flags := &textFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "text [prompt]",
+		Short: "Generate text using xAI Grok",
+		Long: `Generate text using the xAI Responses API.
+
+The prompt is read from the arguments, --prompt-file or stdin. Use --schema for
+output in JSON matching a schema, and --continue with the response_id of a
+previous call to carry on the conversation. xAI keeps responses for 30 days.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runText(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.promptFile, "prompt-file", "", "Input prompt file")
+	cmd.Flags().StringVar(&flags.system, "system", "", "System instructions")
+	cmd.Flags().StringVar(&flags.systemFile, "system-file", "", "System instructions file")
+	cmd.Flags().StringVar(&flags.schema, "schema", "", "JSON schema of the output (file or inline JSON)")
+	cmd.Flags().StringVarP(&flags.continueID, "continue", "c", "", "Previous response ID for multi-turn conversation")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", "grok-4", "Model name")
+	cmd.Flags().Float64VarP(&flags.temperature, "temperature", "t", 1, "Sampling temperature (0-2)")
+	cmd.Flags().IntVar(&flags.maxTokens, "max-tokens", 0, "Maximum output tokens (0 = model default)")
+
+	return cmd
+}
+
+func runText(cmd *cobra.Command, args []string, flags *textFlags) error {
+	// Get prompt
+	prompt, err := getPrompt(args, flags.promptFile, cmd.InOrStdin())
+	if err != nil {
+		return common.WriteError(cmd, "missing_prompt", err.Error())
+	}
+
+	// Get system instructions
+	if flags.system != "" && flags.systemFile != "" {
+		return common.WriteError(cmd, "conflicting_flags", "cannot use --system and --system-file together")
+	}
+	system := flags.system
+	if flags.systemFile != "" {
+		data, err := os.ReadFile(flags.systemFile)
+		if err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read system file: %s", err.Error()))
+		}
+		system = strings.TrimSpace(string(data))
+	}
+
+	// Validate sampling
+	if flags.temperature < 0 || flags.temperature > 2 {
+		return common.WriteError(cmd, "invalid_temperature", "temperature must be between 0 and 2")
+	}
+	if flags.maxTokens < 0 {
+		return common.WriteError(cmd, "invalid_max_tokens", "max-tokens must not be negative")
+	}
+
+	// Validate schema
+	var schema map[string]any
+	if flags.schema != "" {
+		if schema, err = common.LoadJSONSchema(flags.schema); err != nil {
+			return common.WriteError(cmd, "invalid_schema", err.Error())
+		}
+	}
+
+	// Check API key
+	apiKey := config.GetAPIKey("XAI_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("XAI_API_KEY"))
+	}
+
+	// Build request body
+	reqBody := map[string]any{
+		"model": flags.model,
+		"input": prompt,
+	}
+	if system != "" {
+		reqBody["instructions"] = system
+	}
+	if flags.continueID != "" {
+		reqBody["previous_response_id"] = flags.continueID
+	}
+	if cmd.Flags().Changed("temperature") {
+		reqBody["temperature"] = flags.temperature
+	}
+	if flags.maxTokens > 0 {
+		reqBody["max_output_tokens"] = flags.maxTokens
+	}
+	if schema != nil {
+		reqBody["text"] = map[string]any{
+			"format": map[string]any{
+				"type":   "json_schema",
+				"name":   "output",
+				"schema": schema,
+			},
+		}
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return common.WriteError(cmd, "json_error", err.Error())
+	}
+
+	// Make request
+	req, err := http.NewRequest("POST", xaiBaseURL+responsesPath, bytes.NewReader(jsonBody))
+	if err != nil {
+		return common.WriteError(cmd, "request_error", err.Error())
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return handleHTTPError(cmd, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return handleHTTPError(cmd, err)
+	}
+
+	// Parse response
+	var apiResp xaiTextResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return handleXAIError(cmd, resp.StatusCode, &xaiError{Message: strings.TrimSpace(string(body))})
+		}
+		return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot parse response: %s", err.Error()))
+	}
+
+	// Check for API error
+	if apiResp.Error != nil {
+		return handleXAIError(cmd, resp.StatusCode, apiResp.Error)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return common.WriteError(cmd, "api_error", fmt.Sprintf("API returned status %d", resp.StatusCode))
+	}
+
+	// Extract text from the message output
+	var text strings.Builder
+	for _, output := range apiResp.Output {
+		if output.Type != "message" {
+			continue
+		}
+		for _, content := range output.Content {
+			if content.Type == "output_text" {
+				text.WriteString(content.Text)
+			}
+		}
+	}
+	output := strings.TrimSpace(text.String())
+	if output == "" {
+		return common.WriteError(cmd, "no_text", "no text generated in response")
+	}
+
+	result := textResponse{
+		Success:    true,
+		Text:       output,
+		Model:      flags.model,
+		ResponseID: apiResp.ID,
+	}
+	if schema != nil {
+		var parsed any
+		if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+			return common.WriteError(cmd, "invalid_response", fmt.Sprintf("output is not valid JSON: %s", err.Error()))
+		}
+		result.Text = parsed
+	}
+
+	return common.WriteSuccess(cmd, result)
+}
@@ -0,0 +1,142 @@
+package grok
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+)
+
+func TestText_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"missing prompt", nil, "missing_prompt"},
+		{"system and system file", []string{"Hi", "--system", "Be brief", "--system-file", "sys.txt"}, "conflicting_flags"},
+		{"missing system file", []string{"Hi", "--system-file", filepath.Join(t.TempDir(), "sys.txt")}, "file_not_found"},
+		{"negative temperature", []string{"Hi", "--temperature", "-0.1"}, "invalid_temperature"},
+		{"negative max tokens", []string{"Hi", "--max-tokens", "-1"}, "invalid_max_tokens"},
+		{"invalid schema", []string{"Hi", "--schema", "[1]"}, "invalid_schema"},
+		{"missing api key", []string{"Hi"}, "missing_api_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			t.Setenv("XAI_API_KEY", "")
+			cmd := newTextCmd()
+			cmd.SetIn(strings.NewReader(""))
+			_, stderr, err := executeCommand(cmd, tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+// textServer answers the Responses API and records the last request body
+func textServer(t *testing.T, status int, response string) func() map[string]any {
+	var mu sync.Mutex
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/responses" || r.Header.Get("Authorization") != "Bearer xai-test" {
+			t.Errorf("unexpected request: %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		mu.Lock()
+		body, _ = io.ReadAll(r.Body)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("XAI_API_KEY", "xai-test")
+
+	baseURL := xaiBaseURL
+	xaiBaseURL = server.URL
+	t.Cleanup(func() { xaiBaseURL = baseURL })
+
+	return func() map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		var req map[string]any
+		json.Unmarshal(body, &req)
+		return req
+	}
+}
+
+func TestText_Generate(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	request := textServer(t, http.StatusOK, `{"id":"resp_2","output":[
+		{"type":"reasoning","content":[]},
+		{"type":"message","content":[{"type":"output_text","text":"Shot 1: wide."}]}
+	]}`)
+
+	stdout, stderr, err := executeCommand(newTextCmd(), "Plan", "a shot", "--system", "You are a director.", "-c", "resp_1", "-t", "0.3", "--max-tokens", "50")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp textResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if !resp.Success || resp.Text != "Shot 1: wide." || resp.Model != "grok-4" || resp.ResponseID != "resp_2" {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	req := request()
+	want := map[string]any{
+		"model":                "grok-4",
+		"input":                "Plan a shot",
+		"instructions":         "You are a director.",
+		"previous_response_id": "resp_1",
+		"temperature":          0.3,
+		"max_output_tokens":    float64(50),
+	}
+	for key, value := range want {
+		if req[key] != value {
+			t.Errorf("expected %s = %v, got %v", key, value, req[key])
+		}
+	}
+}
+
+func TestText_Schema(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	request := textServer(t, http.StatusOK, `{"id":"resp_1","output":[{"type":"message","content":[{"type":"output_text","text":"{\"shots\":2}"}]}]}`)
+
+	stdout, stderr, err := executeCommand(newTextCmd(), "Count shots", "--schema", `{"type":"object","properties":{"shots":{"type":"integer"}}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"text":{"shots":2}`) {
+		t.Errorf("expected JSON output, got: %s", stdout)
+	}
+
+	req := request()
+	format, _ := json.Marshal(req["text"])
+	if !strings.Contains(string(format), `"format":{"name":"output","schema":{"properties":{"shots"`) || !strings.Contains(string(format), `"type":"json_schema"`) {
+		t.Errorf("unexpected text format: %s", format)
+	}
+	if _, ok := req["temperature"]; ok {
+		t.Error("expected temperature to be left to the API default")
+	}
+}
+
+func TestText_APIError(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	textServer(t, http.StatusBadRequest, `{"error":{"message":"previous response not found","type":"invalid_request_error"}}`)
+
+	_, stderr, err := executeCommand(newTextCmd(), "Hi", "-c", "resp_old")
+	if err == nil || !strings.Contains(stderr, `"code":"invalid_request"`) || !strings.Contains(stderr, "previous response not found") {
+		t.Errorf("expected invalid_request, got: %s", stderr)
+	}
+}
@@ -3,9 +3,7 @@ package video
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -15,7 +13,8 @@ import (
 )
 
 type downloadFlags struct {
-	output string
+	output   string
+	download common.DownloadFlags
 }
 
 type downloadResponse struct {
@@ -50,6 +49,7 @@ func newDownloadCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.mp4)")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
@@ -64,7 +64,11 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	if flags.output == "" {
 		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
 	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
 
+	flags.output = common.DefaultExt(flags.output, ".mp4")
 	ext := strings.ToLower(filepath.Ext(flags.output))
 	if ext != ".mp4" {
 		return common.WriteError(cmd, "invalid_format", "output file must be .mp4")
@@ -125,38 +129,15 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 		return common.WriteError(cmd, "no_video", "video URL not available")
 	}
 
-	// Download video
-	videoResp, err := http.Get(apiResp.VideoURL)
-	if err != nil {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download video: %s", err.Error()))
-	}
-	defer videoResp.Body.Close()
-
-	if videoResp.StatusCode != http.StatusOK {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("video download failed with status %d", videoResp.StatusCode))
-	}
-
-	// Get absolute path
-	absPath, err := filepath.Abs(flags.output)
-	if err != nil {
-		absPath = flags.output
-	}
-
-	// Write to file
-	outFile, err := os.Create(absPath)
-	if err != nil {
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
-	}
-	defer outFile.Close()
-
-	_, err = io.Copy(outFile, videoResp.Body)
+	// Download the file
+	result, err := common.DownloadURL(cmd.Context(), apiResp.VideoURL, flags.output, common.DownloadOptions{DownloadFlags: flags.download, Vars: common.OutputVars{TaskID: requestID}})
 	if err != nil {
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		return common.WriteError(cmd, common.DownloadErrorCode(err), err.Error())
 	}
 
 	return common.WriteSuccess(cmd, downloadResponse{
 		Success:   true,
 		RequestID: requestID,
-		File:      absPath,
+		File:      result.Path,
 	})
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/WHQ25/rawgenai/internal/config"
@@ -16,12 +17,15 @@ const videoEditsPath = "/videos/edits"
 type editFlags struct {
 	video      string
 	promptFile string
+	upload     common.UploadFlags
 }
 
 type editResponse struct {
 	Success   bool   `json:"success"`
 	RequestID string `json:"request_id"`
 	Status    string `json:"status"`
+
+	Uploads []*common.UploadResult `json:"uploads,omitempty"`
 }
 
 // API response type
@@ -41,7 +45,9 @@ func newEditCmd() *cobra.Command {
 		Short: "Edit a video",
 		Long: `Edit a video using xAI Grok API.
 
-Requires a video URL (--video) and a prompt describing the desired changes.
+Requires a video (--video) and a prompt describing the desired changes.
+A local video file is uploaded first using the configured upload backend
+(see upload_backend in 'rawgenai config').
 
 IMPORTANT: Save the request_id from the response. You need it to check status
 and download the edited video.`,
@@ -52,8 +58,9 @@ and download the edited video.`,
 		},
 	}
 
-	cmd.Flags().StringVarP(&flags.video, "video", "v", "", "Input video URL (required)")
+	cmd.Flags().StringVarP(&flags.video, "video", "v", "", "Input video URL or local file (required)")
 	cmd.Flags().StringVar(&flags.promptFile, "prompt-file", "", "Read prompt from file")
+	common.AddUploadFlags(cmd, &flags.upload)
 
 	return cmd
 }
@@ -69,6 +76,11 @@ func runEdit(cmd *cobra.Command, args []string, flags *editFlags) error {
 	if flags.video == "" {
 		return common.WriteError(cmd, "missing_video", "video URL is required, use --video flag")
 	}
+	if !common.IsRemoteInput(flags.video) {
+		if _, err := os.Stat(flags.video); os.IsNotExist(err) {
+			return common.WriteError(cmd, "video_not_found", fmt.Sprintf("video file not found: %s", flags.video))
+		}
+	}
 
 	// Check API key
 	apiKey := config.GetAPIKey("XAI_API_KEY")
@@ -76,11 +88,18 @@ func runEdit(cmd *cobra.Command, args []string, flags *editFlags) error {
 		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("XAI_API_KEY"))
 	}
 
+	// Upload local video so the API can fetch it
+	uploads := common.NewUploads(&flags.upload, nil)
+	videoURL, err := uploads.Resolve(cmd.Context(), flags.video)
+	if err != nil {
+		return common.WriteError(cmd, common.UploadErrorCode(err, "video_not_found"), err.Error())
+	}
+
 	// Build request body
 	reqBody := map[string]any{
 		"model":  "grok-2-video",
 		"prompt": prompt,
-		"video":  flags.video,
+		"video":  videoURL,
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -127,5 +146,6 @@ func runEdit(cmd *cobra.Command, args []string, flags *editFlags) error {
 		Success:   true,
 		RequestID: apiResp.RequestID,
 		Status:    status,
+		Uploads:   uploads.Results,
 	})
 }
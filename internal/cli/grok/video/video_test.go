@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -443,10 +444,54 @@ func TestEdit_MissingAPIKey(t *testing.T) {
 	}
 }
 
+func TestEdit_VideoNotFound(t *testing.T) {
+	cmd := newEditCmd()
+	_, stderr, err := executeCommand(cmd, "Make it faster", "-v", "/nonexistent/video.mp4")
+
+	if err == nil {
+		t.Fatal("expected error for missing video file")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "video_not_found" {
+		t.Errorf("expected error code 'video_not_found', got: %s", errorObj["code"])
+	}
+}
+
+func TestEdit_LocalVideoUploadNotConfigured(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	t.Setenv("XAI_API_KEY", "test-key")
+
+	videoPath := filepath.Join(t.TempDir(), "input.mp4")
+	os.WriteFile(videoPath, []byte("fake video"), 0644)
+
+	cmd := newEditCmd()
+	_, stderr, err := executeCommand(cmd, "Make it faster", "-v", videoPath)
+
+	if err == nil {
+		t.Fatal("expected error for unconfigured upload backend")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "upload_not_configured" {
+		t.Errorf("expected error code 'upload_not_configured', got: %s", errorObj["code"])
+	}
+}
+
 func TestEdit_ValidFlags(t *testing.T) {
 	cmd := newEditCmd()
 
-	flags := []string{"video", "prompt-file"}
+	flags := []string{"video", "prompt-file", "upload-backend", "upload-expiry"}
 	for _, flag := range flags {
 		if cmd.Flag(flag) == nil {
 			t.Errorf("expected --%s flag", flag)
@@ -555,6 +600,25 @@ func TestDownload_MissingOutput(t *testing.T) {
 	}
 }
 
+func TestDownload_ConflictingFlags(t *testing.T) {
+	cmd := newDownloadCmd()
+	_, stderr, err := executeCommand(cmd, "req_abc123", "-o", "output.mp4", "--overwrite", "--no-clobber")
+
+	if err == nil {
+		t.Fatal("expected error for conflicting flags")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "conflicting_flags" {
+		t.Errorf("expected error code 'conflicting_flags', got: %s", errorObj["code"])
+	}
+}
+
 func TestDownload_InvalidFormat(t *testing.T) {
 	cmd := newDownloadCmd()
 	_, stderr, err := executeCommand(cmd, "req_abc123", "-o", "output.avi")
@@ -12,9 +12,11 @@ import (
 )
 
 type downloadFlags struct {
-	output string
-	index  int
-	region string
+	output   string
+	index    int
+	region   string
+	all      bool
+	download common.DownloadFlags
 }
 
 func newDownloadCmd() *cobra.Command {
@@ -30,9 +32,11 @@ func newDownloadCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (required)")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path, directory or {index} template (required)")
 	cmd.Flags().IntVar(&flags.index, "index", 0, "Image index (0-based)")
+	cmd.Flags().BoolVar(&flags.all, "all", false, "Download every image of the job")
 	cmd.Flags().StringVar(&flags.region, "region", shared.DefaultRegion, "Tencent Cloud region")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
@@ -47,15 +51,19 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	if strings.TrimSpace(flags.output) == "" {
 		return common.WriteError(cmd, "missing_output", "output file path is required (-o)")
 	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
 
 	// Direct URL download
 	if shared.IsURL(arg) {
-		if err := shared.DownloadFile(cmd, arg, flags.output); err != nil {
+		result, err := shared.DownloadFile(cmd, arg, flags.output, "", flags.download)
+		if err != nil {
 			return err
 		}
 		return common.WriteSuccess(cmd, map[string]any{
 			"success": true,
-			"file":    shared.AbsPath(flags.output),
+			"file":    result.Path,
 		})
 	}
 
@@ -65,6 +73,13 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	if flags.index < 0 {
 		return common.WriteError(cmd, "invalid_index", "index must be >= 0")
 	}
+	if flags.all {
+		if err := common.ValidateAllOutput(flags.output); err != nil {
+			return common.WriteError(cmd, "invalid_output", err.Error())
+		}
+	} else if err := common.ValidateSingleOutput(flags.output); err != nil {
+		return common.WriteError(cmd, "invalid_output", err.Error())
+	}
 
 	// Check credentials
 	secretID, secretKey, err := shared.CheckCredentials(cmd)
@@ -120,6 +135,24 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 		return common.WriteError(cmd, "no_result", "no images in result")
 	}
 
+	if flags.all {
+		var items []common.DownloadItem
+		for i, u := range r.ResultImage {
+			if u != nil && *u != "" {
+				items = append(items, common.DownloadItem{Index: i, Kind: "image", Ext: common.URLExt(*u, ".png"), URL: *u})
+			}
+		}
+		files, err := common.DownloadAll(cmd.Context(), flags.output, jobID, items, common.DownloadOptions{DownloadFlags: flags.download, Vars: common.OutputVars{TaskID: jobID}})
+		if err != nil {
+			return common.WriteDownloadAllError(cmd, err, files)
+		}
+		return common.WriteSuccess(cmd, map[string]any{
+			"success": true,
+			"job_id":  jobID,
+			"files":   files,
+		})
+	}
+
 	if flags.index >= len(r.ResultImage) {
 		return common.WriteError(cmd, "invalid_index", fmt.Sprintf("index %d out of range (total: %d)", flags.index, len(r.ResultImage)))
 	}
@@ -133,13 +166,14 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	}
 
 	// Download file
-	if err := shared.DownloadFile(cmd, imageURL, flags.output); err != nil {
+	result, err := shared.DownloadFile(cmd, imageURL, common.IndexedPath(flags.output, jobID, flags.index, 1, common.URLExt(imageURL, ".png")), jobID, flags.download)
+	if err != nil {
 		return err
 	}
 
 	return common.WriteSuccess(cmd, map[string]any{
 		"success": true,
 		"job_id":  jobID,
-		"file":    shared.AbsPath(flags.output),
+		"file":    result.Path,
 	})
 }
@@ -115,7 +115,7 @@ func TestDownload_AllFlags(t *testing.T) {
 		t.Fatal("download command not found")
 	}
 
-	expectedFlags := []string{"output", "index", "region"}
+	expectedFlags := []string{"output", "index", "region", "all"}
 	for _, name := range expectedFlags {
 		if dlCmd.Flags().Lookup(name) == nil {
 			t.Errorf("expected flag --%s not found", name)
@@ -138,3 +138,21 @@ func TestDownload_ShortFlags(t *testing.T) {
 		t.Errorf("expected short flag -o for --output, got -%s", flag.Shorthand)
 	}
 }
+
+func TestDownload_AllRequiresMultiFileOutput(t *testing.T) {
+	cmd := NewCmd()
+	_, stderr, err := executeCommand(cmd, "download", "job-123", "-o", "out.png", "--all")
+	if err == nil {
+		t.Fatal("expected error for --all with a single-file output")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_output" {
+		t.Errorf("expected error code 'invalid_output', got: %s", errorObj["code"])
+	}
+}
@@ -4,11 +4,9 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/WHQ25/rawgenai/internal/config"
@@ -146,38 +144,15 @@ func ResolveImageBase64(path string) (string, error) {
 	return base64.StdEncoding.EncodeToString(data), nil
 }
 
-// DownloadFile downloads a URL to the given output path and returns the absolute path.
-func DownloadFile(cmd *cobra.Command, url, output string) error {
-	// Create output directory if needed
-	dir := filepath.Dir(output)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot create directory: %s", err.Error()))
-		}
-	}
-
-	client := &http.Client{Timeout: 5 * time.Minute}
-	resp, err := client.Get(url)
+// DownloadFile downloads a URL to the given output path, filling {task_id}
+// with jobID. On failure the error has already been written to the command
+// output.
+func DownloadFile(cmd *cobra.Command, url, output, jobID string, flags common.DownloadFlags) (*common.DownloadResult, error) {
+	result, err := common.DownloadURL(cmd.Context(), url, output, common.DownloadOptions{DownloadFlags: flags, Vars: common.OutputVars{TaskID: jobID}})
 	if err != nil {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download file: %s", err.Error()))
+		return nil, common.WriteError(cmd, common.DownloadErrorCode(err), err.Error())
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("download failed with status: %d", resp.StatusCode))
-	}
-
-	outFile, err := os.Create(output)
-	if err != nil {
-		return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot create file: %s", err.Error()))
-	}
-	defer outFile.Close()
-
-	if _, err := io.Copy(outFile, resp.Body); err != nil {
-		return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot write file: %s", err.Error()))
-	}
-
-	return nil
+	return result, nil
 }
 
 // AbsPath returns the absolute path, falling back to the input if it fails.
@@ -12,8 +12,9 @@ import (
 )
 
 type downloadFlags struct {
-	output string
-	region string
+	output   string
+	region   string
+	download common.DownloadFlags
 }
 
 func newDownloadCmd() *cobra.Command {
@@ -31,6 +32,7 @@ func newDownloadCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (required)")
 	cmd.Flags().StringVar(&flags.region, "region", shared.DefaultRegion, "Tencent Cloud region")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
@@ -45,15 +47,19 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	if strings.TrimSpace(flags.output) == "" {
 		return common.WriteError(cmd, "missing_output", "output file path is required (-o)")
 	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
 
 	// Direct URL download
 	if shared.IsURL(arg) {
-		if err := shared.DownloadFile(cmd, arg, flags.output); err != nil {
+		result, err := shared.DownloadFile(cmd, arg, flags.output, "", flags.download)
+		if err != nil {
 			return err
 		}
 		return common.WriteSuccess(cmd, map[string]any{
 			"success": true,
-			"file":    shared.AbsPath(flags.output),
+			"file":    result.Path,
 		})
 	}
 
@@ -119,13 +125,14 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	}
 
 	// Download file
-	if err := shared.DownloadFile(cmd, videoURL, flags.output); err != nil {
+	result, err := shared.DownloadFile(cmd, videoURL, flags.output, jobID, flags.download)
+	if err != nil {
 		return err
 	}
 
 	return common.WriteSuccess(cmd, map[string]any{
 		"success": true,
 		"job_id":  jobID,
-		"file":    shared.AbsPath(flags.output),
+		"file":    result.Path,
 	})
 }
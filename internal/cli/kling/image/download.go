@@ -1,12 +1,11 @@
 package image
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -20,6 +19,8 @@ type downloadFlags struct {
 	output    string
 	index     int
 	watermark bool
+	all       bool
+	download  common.DownloadFlags
 }
 
 func newDownloadCmd() *cobra.Command {
@@ -35,9 +36,11 @@ func newDownloadCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path, directory or {index} template (with --all)")
 	cmd.Flags().IntVar(&flags.index, "index", 0, "Image index (0-based)")
 	cmd.Flags().BoolVar(&flags.watermark, "watermark", false, "Download watermarked image")
+	cmd.Flags().BoolVar(&flags.all, "all", false, "Download every image and its watermark variant")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
@@ -53,12 +56,23 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	if strings.TrimSpace(flags.output) == "" {
 		return common.WriteError(cmd, "missing_output", "output file path is required (-o)")
 	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
 
 	// Validate index
 	if flags.index < 0 {
 		return common.WriteError(cmd, "invalid_index", "index must be >= 0")
 	}
 
+	if flags.all {
+		if err := common.ValidateAllOutput(flags.output); err != nil {
+			return common.WriteError(cmd, "invalid_output", err.Error())
+		}
+	} else if err := common.ValidateSingleOutput(flags.output); err != nil {
+		return common.WriteError(cmd, "invalid_output", err.Error())
+	}
+
 	// Check API keys
 	accessKey := config.GetAPIKey("KLING_ACCESS_KEY")
 	secretKey := config.GetAPIKey("KLING_SECRET_KEY")
@@ -72,60 +86,92 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 		return common.WriteError(cmd, "auth_error", fmt.Sprintf("failed to generate JWT: %s", err.Error()))
 	}
 
-	downloadURL, err := getDownloadURL(token, taskID, flags.index, flags.watermark)
+	images, err := getImages(token, taskID)
 	if err != nil {
 		return common.WriteError(cmd, "download_error", err.Error())
 	}
 
-	// Download the file
-	client := &http.Client{Timeout: 5 * time.Minute}
-	resp, err := client.Get(downloadURL)
-	if err != nil {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download file: %s", err.Error()))
+	opts := common.DownloadOptions{
+		DownloadFlags: flags.download,
+		Vars:          common.OutputVars{TaskID: taskID},
+		Refresh: common.RefreshFromList(imageURLs(images), func(ctx context.Context) ([]string, error) {
+			fresh, err := getImages(token, taskID)
+			if err != nil {
+				return nil, err
+			}
+			return imageURLs(fresh), nil
+		}),
+	}
+
+	if flags.all {
+		var items []common.DownloadItem
+		for i, img := range images {
+			if img.URL != "" {
+				items = append(items, common.DownloadItem{Index: i, Kind: "image", Ext: common.URLExt(img.URL, ".png"), URL: img.URL})
+			}
+			if img.WatermarkURL != "" {
+				items = append(items, common.DownloadItem{Index: i, Kind: "watermark", Suffix: "_watermark", Ext: common.URLExt(img.WatermarkURL, ".png"), URL: img.WatermarkURL})
+			}
+		}
+		files, err := common.DownloadAll(cmd.Context(), flags.output, taskID, items, opts)
+		if err != nil {
+			return common.WriteDownloadAllError(cmd, err, files)
+		}
+		return common.WriteSuccess(cmd, map[string]any{
+			"success": true,
+			"task_id": taskID,
+			"files":   files,
+		})
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("download failed with status: %d", resp.StatusCode))
+	if flags.index >= len(images) {
+		return common.WriteError(cmd, "download_error", "index out of range")
 	}
-
-	// Create output directory if needed
-	dir := filepath.Dir(flags.output)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot create directory: %s", err.Error()))
+	img := images[flags.index]
+	downloadURL := img.URL
+	if flags.watermark {
+		if img.WatermarkURL == "" {
+			return common.WriteError(cmd, "download_error", "watermark URL not available")
 		}
+		downloadURL = img.WatermarkURL
 	}
-
-	// Create output file
-	outFile, err := os.Create(flags.output)
-	if err != nil {
-		return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot create file: %s", err.Error()))
-	}
-	defer outFile.Close()
-
-	// Copy data
-	if _, err := io.Copy(outFile, resp.Body); err != nil {
-		return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot write file: %s", err.Error()))
+	if downloadURL == "" {
+		return common.WriteError(cmd, "download_error", "image URL not available")
 	}
 
-	absPath, err := filepath.Abs(flags.output)
+	// Download the file
+	output := common.IndexedPath(flags.output, taskID, flags.index, 1, common.URLExt(downloadURL, ".png"))
+	result, err := common.DownloadURL(cmd.Context(), downloadURL, output, opts)
 	if err != nil {
-		absPath = flags.output
+		return common.WriteError(cmd, common.DownloadErrorCode(err), err.Error())
 	}
 
 	return common.WriteSuccess(cmd, map[string]any{
 		"success": true,
 		"task_id": taskID,
-		"file":    absPath,
+		"file":    result.Path,
 	})
 }
 
-func getDownloadURL(token, taskID string, index int, watermark bool) (string, error) {
+type klingImage struct {
+	URL          string `json:"url"`
+	WatermarkURL string `json:"watermark_url"`
+}
+
+// imageURLs lists the image and watermark URLs of a task in a stable order.
+func imageURLs(images []klingImage) []string {
+	urls := make([]string, 0, 2*len(images))
+	for _, img := range images {
+		urls = append(urls, img.URL, img.WatermarkURL)
+	}
+	return urls
+}
+
+func getImages(token, taskID string) ([]klingImage, error) {
 	// Create HTTP request
 	req, err := http.NewRequest("GET", video.GetKlingAPIBase()+"/v1/images/generations/"+taskID, nil)
 	if err != nil {
-		return "", fmt.Errorf("cannot create request: %s", err.Error())
+		return nil, fmt.Errorf("cannot create request: %s", err.Error())
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
@@ -134,14 +180,14 @@ func getDownloadURL(token, taskID string, index int, watermark bool) (string, er
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("cannot get status: %s", err.Error())
+		return nil, fmt.Errorf("cannot get status: %s", err.Error())
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("cannot read response: %s", err.Error())
+		return nil, fmt.Errorf("cannot read response: %s", err.Error())
 	}
 
 	// Parse response
@@ -152,24 +198,21 @@ func getDownloadURL(token, taskID string, index int, watermark bool) (string, er
 			TaskStatus    string `json:"task_status"`
 			TaskStatusMsg string `json:"task_status_msg"`
 			TaskResult    *struct {
-				Images []struct {
-					URL          string `json:"url"`
-					WatermarkURL string `json:"watermark_url"`
-				} `json:"images"`
+				Images []klingImage `json:"images"`
 			} `json:"task_result"`
 		} `json:"data"`
 	}
 
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", fmt.Errorf("cannot parse response: %s", err.Error())
+		return nil, fmt.Errorf("cannot parse response: %s", err.Error())
 	}
 
 	if result.Code != 0 {
-		return "", fmt.Errorf("kling error: %s", result.Message)
+		return nil, fmt.Errorf("kling error: %s", result.Message)
 	}
 
 	if result.Data == nil {
-		return "", fmt.Errorf("no data in response")
+		return nil, fmt.Errorf("no data in response")
 	}
 
 	if result.Data.TaskStatus == "failed" {
@@ -177,32 +220,16 @@ func getDownloadURL(token, taskID string, index int, watermark bool) (string, er
 		if msg == "" {
 			msg = "image generation failed"
 		}
-		return "", fmt.Errorf("%s", msg)
+		return nil, fmt.Errorf("%s", msg)
 	}
 
 	if result.Data.TaskStatus != "succeed" {
-		return "", fmt.Errorf("task is not finished (status: %s)", result.Data.TaskStatus)
+		return nil, fmt.Errorf("task is not finished (status: %s)", result.Data.TaskStatus)
 	}
 
 	if result.Data.TaskResult == nil || len(result.Data.TaskResult.Images) == 0 {
-		return "", fmt.Errorf("no images in result")
-	}
-
-	if index >= len(result.Data.TaskResult.Images) {
-		return "", fmt.Errorf("index out of range")
-	}
-
-	img := result.Data.TaskResult.Images[index]
-	if watermark {
-		if img.WatermarkURL == "" {
-			return "", fmt.Errorf("watermark URL not available")
-		}
-		return img.WatermarkURL, nil
-	}
-
-	if img.URL == "" {
-		return "", fmt.Errorf("image URL not available")
+		return nil, fmt.Errorf("no images in result")
 	}
 
-	return img.URL, nil
+	return result.Data.TaskResult.Images, nil
 }
@@ -97,3 +97,21 @@ func TestImageCreate_MissingAPIKey(t *testing.T) {
 		t.Errorf("expected error code 'missing_api_key', got: %s", errorObj["code"])
 	}
 }
+
+func TestDownload_AllRequiresMultiFileOutput(t *testing.T) {
+	cmd := NewCmd()
+	_, stderr, err := executeCommand(cmd, "download", "task-123", "-o", "out.png", "--all")
+	if err == nil {
+		t.Fatal("expected error for --all with a single-file output")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_output" {
+		t.Errorf("expected error code 'invalid_output', got: %s", errorObj["code"])
+	}
+}
@@ -124,6 +124,13 @@ func runStatus(cmd *cobra.Command, args []string, flags *statusFlags) error {
 
 	if result.Data.TaskResult != nil && len(result.Data.TaskResult.Images) > 0 {
 		output["image_count"] = len(result.Data.TaskResult.Images)
+		var urls []string
+		for _, img := range result.Data.TaskResult.Images {
+			urls = append(urls, img.URL, img.WatermarkURL)
+		}
+		if expiresAt := common.ExpiresAt(urls...); expiresAt != "" {
+			output["expires_at"] = expiresAt
+		}
 		if flags.verbose {
 			images := make([]map[string]any, 0, len(result.Data.TaskResult.Images))
 			for _, img := range result.Data.TaskResult.Images {
@@ -2,7 +2,9 @@ package tts
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +12,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +22,13 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// maxTextLength is the longest text the TTS endpoint accepts; longer text
+// is synthesized in chunks.
+const maxTextLength = 1000
+
+// audioExt is the format Kling TTS returns, used when the URL has no extension
+const audioExt = ".mp3"
+
 type ttsFlags struct {
 	output     string
 	promptFile string
@@ -91,24 +101,152 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		return common.WriteError(cmd, "auth_error", fmt.Sprintf("failed to generate JWT: %s", err.Error()))
 	}
 
-	// Build request body
-	body := map[string]any{
-		"text":           text,
-		"voice_id":       flags.voiceID,
-		"voice_language": flags.language,
-		"voice_speed":    flags.speed,
+	// Long text is split on sentence boundaries and the audio joined
+	chunks := common.SplitText(text, maxTextLength, nil)
+	if len(chunks) > 1 && !common.CanJoinAudio(audioExt) {
+		return common.WriteError(cmd, "text_too_long", fmt.Sprintf("text longer than %d characters cannot be joined as %s", maxTextLength, audioExt))
+	}
+	tasks := make([]ttsTask, len(chunks))
+	parts, err := common.SynthesizeChunks(context.Background(), chunks, common.DefaultChunkConcurrency, func(ctx context.Context, i int, chunk string) ([]byte, error) {
+		task, err := createTTS(ctx, token, map[string]any{
+			"text":           chunk,
+			"voice_id":       flags.voiceID,
+			"voice_language": flags.language,
+			"voice_speed":    flags.speed,
+		})
+		if err != nil {
+			return nil, err
+		}
+		tasks[i] = *task
+		return downloadAudio(ctx, task.URL)
+	})
+	if err != nil {
+		var tErr *ttsError
+		if !errors.As(err, &tErr) {
+			return video.HandleAPIError(cmd, err)
+		}
+		if tErr.klingCode != 0 {
+			return video.HandleKlingError(cmd, tErr.klingCode, tErr.message)
+		}
+		return common.WriteError(cmd, tErr.code, tErr.message)
+	}
+
+	ext := getURLExt(tasks[0].URL)
+	if ext == "" {
+		ext = audioExt
+	}
+	audio, err := common.JoinAudio(ext, parts)
+	if err != nil {
+		return common.WriteError(cmd, "join_error", fmt.Sprintf("cannot join audio chunks: %s", err.Error()))
+	}
+
+	outputPath := flags.output
+	useTempFile := false
+
+	if outputPath == "" {
+		tmpFile, err := os.CreateTemp("", "kling-tts-*"+ext)
+		if err != nil {
+			return common.WriteError(cmd, "internal_error", fmt.Sprintf("cannot create temp file: %s", err.Error()))
+		}
+		outputPath = tmpFile.Name()
+		tmpFile.Close()
+		useTempFile = true
+	}
+
+	if !useTempFile {
+		// Create output directory if needed
+		dir := filepath.Dir(outputPath)
+		if dir != "" && dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot create directory: %s", err.Error()))
+			}
+		}
+	}
+
+	if _, err := common.WriteOutput(outputPath, audio, common.OutputVars{}); err != nil {
+		if useTempFile {
+			os.Remove(outputPath)
+		}
+		return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot write file: %s", err.Error()))
+	}
+
+	if flags.speak {
+		if err := common.PlayFile(outputPath); err != nil {
+			if useTempFile {
+				os.Remove(outputPath)
+			}
+			return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", err.Error()))
+		}
+		if useTempFile {
+			os.Remove(outputPath)
+		}
+	}
+
+	absPath, err := filepath.Abs(outputPath)
+	if err != nil {
+		absPath = outputPath
+	}
+
+	resultPayload := map[string]any{
+		"success":  true,
+		"task_id":  tasks[0].TaskID,
+		"status":   tasks[0].Status,
+		"voice_id": flags.voiceID,
+		"duration": tasks[0].Duration,
+	}
+	if len(chunks) > 1 {
+		taskIDs := make([]string, len(tasks))
+		var duration float64
+		for i, task := range tasks {
+			taskIDs[i] = task.TaskID
+			seconds, _ := strconv.ParseFloat(task.Duration, 64)
+			duration += seconds
+		}
+		delete(resultPayload, "task_id")
+		resultPayload["task_ids"] = taskIDs
+		resultPayload["chunks"] = len(chunks)
+		resultPayload["duration"] = strconv.FormatFloat(duration, 'f', -1, 64)
 	}
 
+	if !useTempFile {
+		resultPayload["file"] = absPath
+	}
+
+	return common.WriteSuccess(cmd, resultPayload)
+}
+
+// ttsTask is a finished TTS task.
+type ttsTask struct {
+	TaskID   string
+	Status   string
+	URL      string
+	Duration string
+}
+
+// ttsError is a failure reported by the API, carried out of a chunk request.
+// A non-zero klingCode is an API error code, anything else uses code.
+type ttsError struct {
+	klingCode int
+	code      string
+	message   string
+}
+
+func (e *ttsError) Error() string {
+	return e.message
+}
+
+// createTTS synthesizes one chunk of text.
+func createTTS(ctx context.Context, token string, body map[string]any) (*ttsTask, error) {
 	// Serialize request
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
-		return common.WriteError(cmd, "request_error", fmt.Sprintf("cannot serialize request: %s", err.Error()))
+		return nil, &ttsError{code: "request_error", message: fmt.Sprintf("cannot serialize request: %s", err.Error())}
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", video.GetKlingAPIBase()+"/v1/audio/tts", bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", video.GetKlingAPIBase()+"/v1/audio/tts", bytes.NewReader(jsonBody))
 	if err != nil {
-		return common.WriteError(cmd, "request_error", fmt.Sprintf("cannot create request: %s", err.Error()))
+		return nil, &ttsError{code: "request_error", message: fmt.Sprintf("cannot create request: %s", err.Error())}
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -118,14 +256,14 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	client := &http.Client{Timeout: 60 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return video.HandleAPIError(cmd, err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot read response: %s", err.Error()))
+		return nil, &ttsError{code: "response_error", message: fmt.Sprintf("cannot read response: %s", err.Error())}
 	}
 
 	// Parse response
@@ -147,15 +285,15 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	}
 
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot parse response: %s", err.Error()))
+		return nil, &ttsError{code: "response_error", message: fmt.Sprintf("cannot parse response: %s", err.Error())}
 	}
 
 	if result.Code != 0 {
-		return video.HandleKlingError(cmd, result.Code, result.Message)
+		return nil, &ttsError{klingCode: result.Code, message: result.Message}
 	}
 
 	if result.Data == nil {
-		return common.WriteError(cmd, "response_error", "no data in response")
+		return nil, &ttsError{code: "response_error", message: "no data in response"}
 	}
 
 	if result.Data.TaskStatus == "failed" {
@@ -163,124 +301,52 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		if msg == "" {
 			msg = "tts failed"
 		}
-		return common.WriteError(cmd, "tts_failed", msg)
+		return nil, &ttsError{code: "tts_failed", message: msg}
 	}
 
 	if result.Data.TaskStatus != "succeed" {
-		return common.WriteError(cmd, "tts_not_ready", "task is not finished")
+		return nil, &ttsError{code: "tts_not_ready", message: "task is not finished"}
 	}
 
 	if result.Data.TaskResult == nil || len(result.Data.TaskResult.Audios) == 0 {
-		return common.WriteError(cmd, "response_error", "no audio in response")
+		return nil, &ttsError{code: "response_error", message: "no audio in response"}
 	}
 
 	audio := result.Data.TaskResult.Audios[0]
 	if strings.TrimSpace(audio.URL) == "" {
-		return common.WriteError(cmd, "response_error", "audio URL is empty")
-	}
-
-	outputPath := flags.output
-	useTempFile := false
-
-	if outputPath == "" {
-		ext := getURLExt(audio.URL)
-		if ext == "" {
-			ext = ".mp3"
-		}
-		tmpFile, err := os.CreateTemp("", "kling-tts-*"+ext)
-		if err != nil {
-			return common.WriteError(cmd, "internal_error", fmt.Sprintf("cannot create temp file: %s", err.Error()))
-		}
-		outputPath = tmpFile.Name()
-		tmpFile.Close()
-		useTempFile = true
-	}
-
-	if flags.speak {
-		ext := strings.ToLower(filepath.Ext(outputPath))
-		if ext != ".mp3" && ext != ".wav" {
-			if useTempFile {
-				os.Remove(outputPath)
-			}
-			return common.WriteError(cmd, "unsupported_format", "--speak only supports mp3 or wav")
-		}
+		return nil, &ttsError{code: "response_error", message: "audio URL is empty"}
 	}
 
-	if !useTempFile {
-		// Create output directory if needed
-		dir := filepath.Dir(outputPath)
-		if dir != "" && dir != "." {
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot create directory: %s", err.Error()))
-			}
-		}
-	}
+	return &ttsTask{
+		TaskID:   result.Data.TaskID,
+		Status:   result.Data.TaskStatus,
+		URL:      audio.URL,
+		Duration: audio.Duration,
+	}, nil
+}
 
-	// Download audio
-	downloadClient := &http.Client{Timeout: 5 * time.Minute}
-	downloadResp, err := downloadClient.Get(audio.URL)
+// downloadAudio fetches the audio of a finished task.
+func downloadAudio(ctx context.Context, audioURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", audioURL, nil)
 	if err != nil {
-		if useTempFile {
-			os.Remove(outputPath)
-		}
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download audio: %s", err.Error()))
-	}
-	defer downloadResp.Body.Close()
-
-	if downloadResp.StatusCode != http.StatusOK {
-		if useTempFile {
-			os.Remove(outputPath)
-		}
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("download failed with status: %d", downloadResp.StatusCode))
+		return nil, &ttsError{code: "download_error", message: fmt.Sprintf("cannot download audio: %s", err.Error())}
 	}
-
-	outFile, err := os.Create(outputPath)
+	downloadClient := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := downloadClient.Do(req)
 	if err != nil {
-		if useTempFile {
-			os.Remove(outputPath)
-		}
-		return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot create file: %s", err.Error()))
+		return nil, &ttsError{code: "download_error", message: fmt.Sprintf("cannot download audio: %s", err.Error())}
 	}
+	defer resp.Body.Close()
 
-	if _, err := io.Copy(outFile, downloadResp.Body); err != nil {
-		outFile.Close()
-		if useTempFile {
-			os.Remove(outputPath)
-		}
-		return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot write file: %s", err.Error()))
-	}
-	outFile.Close()
-
-	if flags.speak {
-		if err := common.PlayFile(outputPath); err != nil {
-			if useTempFile {
-				os.Remove(outputPath)
-			}
-			return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", err.Error()))
-		}
-		if useTempFile {
-			os.Remove(outputPath)
-		}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &ttsError{code: "download_error", message: fmt.Sprintf("download failed with status: %d", resp.StatusCode)}
 	}
 
-	absPath, err := filepath.Abs(outputPath)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		absPath = outputPath
+		return nil, &ttsError{code: "download_error", message: fmt.Sprintf("cannot download audio: %s", err.Error())}
 	}
-
-	resultPayload := map[string]any{
-		"success":  true,
-		"task_id":  result.Data.TaskID,
-		"status":   result.Data.TaskStatus,
-		"voice_id": flags.voiceID,
-		"duration": audio.Duration,
-	}
-
-	if !useTempFile {
-		resultPayload["file"] = absPath
-	}
-
-	return common.WriteSuccess(cmd, resultPayload)
+	return data, nil
 }
 
 func getURLExt(raw string) string {
@@ -3,7 +3,13 @@ package tts
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
@@ -229,3 +235,52 @@ func TestTTS_SpeakWithoutOutput(t *testing.T) {
 		t.Errorf("expected error code 'missing_api_key', got: %s", errorObj["code"])
 	}
 }
+
+func TestTTS_LongTextChunked(t *testing.T) {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+
+	var requests int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/audio.mp3" {
+			w.Write(frame)
+			return
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if n := len([]rune(body["text"].(string))); n > maxTextLength {
+			t.Errorf("chunk of %d characters exceeds the limit", n)
+		}
+		id := atomic.AddInt32(&requests, 1)
+		fmt.Fprintf(w, `{"code":0,"data":{"task_id":"task_%d","task_status":"succeed","task_result":{"audios":[{"url":"%s/audio.mp3","duration":"1.5"}]}}}`, id, server.URL)
+	}))
+	defer server.Close()
+	t.Setenv("KLING_ACCESS_KEY", "ak")
+	t.Setenv("KLING_SECRET_KEY", "sk")
+	t.Setenv("KLING_BASE_URL", server.URL)
+
+	text := strings.Repeat("这是一个用于测试的句子。", 200)
+	output := filepath.Join(t.TempDir(), "long.mp3")
+	cmd := newTTSCmd()
+	stdout, stderr, err := executeCommand(cmd, text, "--voice", "voice_123", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+
+	var resp map[string]any
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp["chunks"] != float64(3) {
+		t.Fatalf("expected 3 chunks, got: %v", resp["chunks"])
+	}
+	if ids := resp["task_ids"].([]any); len(ids) != 3 {
+		t.Errorf("expected 3 task ids, got: %v", ids)
+	}
+	if resp["duration"] != "4.5" {
+		t.Errorf("expected summed duration 4.5, got: %v", resp["duration"])
+	}
+	data, _ := os.ReadFile(output)
+	if len(data) != 3*len(frame) {
+		t.Errorf("expected 3 joined frames, got %d bytes", len(data))
+	}
+}
@@ -49,6 +49,7 @@ type createFlags struct {
 	duration        int
 	ratio           string
 	watermark       bool
+	upload          common.UploadFlags
 }
 
 func newCreateCmd() *cobra.Command {
@@ -68,14 +69,15 @@ func newCreateCmd() *cobra.Command {
 	cmd.Flags().StringVar(&flags.lastFrame, "last-frame", "", "Last frame image path (requires --first-frame)")
 	cmd.Flags().StringArrayVar(&flags.refImages, "ref-image", nil, "Reference image(s), use <<<image_N>>> in prompt")
 	cmd.Flags().Int64SliceVar(&flags.elements, "element", nil, "Element ID(s), use <<<element_N>>> in prompt")
-	cmd.Flags().StringVar(&flags.refVideo, "ref-video", "", "Reference video URL (style/camera reference)")
-	cmd.Flags().StringVar(&flags.baseVideo, "base-video", "", "Base video URL for editing")
+	cmd.Flags().StringVar(&flags.refVideo, "ref-video", "", "Reference video URL or local file (style/camera reference)")
+	cmd.Flags().StringVar(&flags.baseVideo, "base-video", "", "Base video URL or local file for editing")
 	cmd.Flags().BoolVar(&flags.refExcludeSound, "ref-exclude-sound", false, "Exclude sound from ref/base video")
 	cmd.Flags().StringVarP(&flags.promptFile, "prompt-file", "f", "", "Read prompt from file")
 	cmd.Flags().StringVar(&flags.mode, "mode", "pro", "Generation mode: std, pro")
 	cmd.Flags().IntVarP(&flags.duration, "duration", "d", 5, "Video duration in seconds (3-10)")
 	cmd.Flags().StringVarP(&flags.ratio, "ratio", "r", "16:9", "Aspect ratio: 16:9, 9:16, 1:1")
 	cmd.Flags().BoolVar(&flags.watermark, "watermark", false, "Include watermark")
+	common.AddUploadFlags(cmd, &flags.upload)
 
 	return cmd
 }
@@ -135,6 +137,15 @@ func runCreate(cmd *cobra.Command, args []string, flags *createFlags) error {
 		return common.WriteError(cmd, "conflicting_video_flags", "cannot use --ref-video and --base-video together")
 	}
 
+	// Validate ref/base video (local files are uploaded later)
+	for _, video := range []string{flags.refVideo, flags.baseVideo} {
+		if video != "" && !isURL(video) {
+			if _, err := os.Stat(video); os.IsNotExist(err) {
+				return common.WriteError(cmd, "video_not_found", fmt.Sprintf("video file not found: %s", video))
+			}
+		}
+	}
+
 	// Check API keys
 	accessKey := config.GetAPIKey("KLING_ACCESS_KEY")
 	secretKey := config.GetAPIKey("KLING_SECRET_KEY")
@@ -214,15 +225,21 @@ func runCreate(cmd *cobra.Command, args []string, flags *createFlags) error {
 		body["element_list"] = elementList
 	}
 
-	// Build video_list
+	// Build video_list, uploading a local video so the API can fetch it
+	uploads := common.NewUploads(&flags.upload, nil)
 	if flags.refVideo != "" || flags.baseVideo != "" {
-		videoURL := flags.refVideo
+		videoInput := flags.refVideo
 		referType := "feature"
 		if flags.baseVideo != "" {
-			videoURL = flags.baseVideo
+			videoInput = flags.baseVideo
 			referType = "base"
 		}
 
+		videoURL, err := uploads.Resolve(cmd.Context(), videoInput)
+		if err != nil {
+			return common.WriteError(cmd, common.UploadErrorCode(err, "video_not_found"), err.Error())
+		}
+
 		keepSound := "yes"
 		if flags.refExcludeSound {
 			keepSound = "no"
@@ -295,11 +312,15 @@ func runCreate(cmd *cobra.Command, args []string, flags *createFlags) error {
 	}
 
 	// Return success
-	return common.WriteSuccess(cmd, map[string]any{
+	response := map[string]any{
 		"success": true,
 		"task_id": result.Data.TaskID,
 		"status":  result.Data.TaskStatus,
-	})
+	}
+	if len(uploads.Results) > 0 {
+		response["uploads"] = uploads.Results
+	}
+	return common.WriteSuccess(cmd, response)
 }
 
 func getPrompt(args []string, filePath string, stdin io.Reader) (string, error) {
@@ -3,6 +3,7 @@ package video
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -490,6 +491,51 @@ func TestCreate_WithRefVideo(t *testing.T) {
 	}
 }
 
+func TestCreate_RefVideoNotFound(t *testing.T) {
+	cmd := NewCmd()
+	_, stderr, cmdErr := executeCommand(cmd, "create", "Same style as <<<video_1>>>", "--ref-video", "/nonexistent/ref.mp4")
+
+	if cmdErr == nil {
+		t.Fatal("expected error for missing ref video")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "video_not_found" {
+		t.Errorf("expected error code 'video_not_found', got: %s", errorObj["code"])
+	}
+}
+
+func TestCreate_LocalBaseVideoUploadNotConfigured(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	t.Setenv("KLING_ACCESS_KEY", "test-access")
+	t.Setenv("KLING_SECRET_KEY", "test-secret")
+
+	videoPath := filepath.Join(t.TempDir(), "base.mp4")
+	os.WriteFile(videoPath, []byte("fake video"), 0644)
+
+	cmd := NewCmd()
+	_, stderr, cmdErr := executeCommand(cmd, "create", "Edit <<<video_1>>>", "--base-video", videoPath)
+
+	if cmdErr == nil {
+		t.Fatal("expected error for unconfigured upload backend")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "upload_not_configured" {
+		t.Errorf("expected error code 'upload_not_configured', got: %s", errorObj["code"])
+	}
+}
+
 func TestCreate_WithBaseVideo(t *testing.T) {
 	common.SetupNoConfigEnv(t)
 	t.Setenv("KLING_ACCESS_KEY", "")
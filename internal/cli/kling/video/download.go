@@ -1,12 +1,11 @@
 package video
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -20,6 +19,8 @@ type downloadFlags struct {
 	taskType  string
 	watermark bool
 	format    string
+	all       bool
+	download  common.DownloadFlags
 }
 
 func newDownloadCmd() *cobra.Command {
@@ -35,10 +36,12 @@ func newDownloadCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path, directory or {index} template (with --all)")
 	cmd.Flags().StringVarP(&flags.taskType, "type", "t", "create", "Task type: create, text2video, image2video, motion-control, avatar, extend, add-sound")
 	cmd.Flags().BoolVar(&flags.watermark, "watermark", false, "Download watermarked version")
 	cmd.Flags().StringVar(&flags.format, "format", "video", "Download format: video, mp3, wav (mp3/wav only for add-sound)")
+	cmd.Flags().BoolVar(&flags.all, "all", false, "Download every video, watermark variant and audio track")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
@@ -54,6 +57,9 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	if flags.output == "" {
 		return common.WriteError(cmd, "missing_output", "output file path is required (-o)")
 	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
 
 	// Validate format flag
 	validFormats := map[string]bool{"video": true, "mp3": true, "wav": true}
@@ -66,6 +72,14 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 		return common.WriteError(cmd, "invalid_format", "mp3/wav format only supported for --type add-sound")
 	}
 
+	if flags.all {
+		if err := common.ValidateAllOutput(flags.output); err != nil {
+			return common.WriteError(cmd, "invalid_output", err.Error())
+		}
+	} else if err := common.ValidateSingleOutput(flags.output); err != nil {
+		return common.WriteError(cmd, "invalid_output", err.Error())
+	}
+
 	// Validate output file extension
 	lowerOutput := strings.ToLower(flags.output)
 	expectedExt := ".mp4"
@@ -74,7 +88,7 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	} else if flags.format == "wav" {
 		expectedExt = ".wav"
 	}
-	if !strings.HasSuffix(lowerOutput, expectedExt) {
+	if !flags.all && !common.IsDirOutput(flags.output) && !strings.HasSuffix(lowerOutput, expectedExt) {
 		return common.WriteError(cmd, "invalid_format", fmt.Sprintf("output file must be %s for format %s", expectedExt, flags.format))
 	}
 
@@ -91,58 +105,104 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 		return common.WriteError(cmd, "auth_error", fmt.Sprintf("failed to generate JWT: %s", err.Error()))
 	}
 
-	// First, get the download URL from status
-	downloadURL, err := getDownloadURL(token, taskID, flags.taskType, flags.watermark, flags.format)
-	if err != nil {
-		return err
-	}
-
-	// Download the file
-	client := &http.Client{Timeout: 5 * time.Minute}
-	resp, err := client.Get(downloadURL)
+	// First, get the task result from status
+	taskResult, err := getTaskResult(token, taskID, flags.taskType)
 	if err != nil {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download file: %s", err.Error()))
+		return common.WriteError(cmd, "download_error", err.Error())
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("download failed with status: %d", resp.StatusCode))
+	opts := common.DownloadOptions{
+		DownloadFlags: flags.download,
+		Vars:          common.OutputVars{TaskID: taskID},
+		Refresh:       refreshTaskResult(token, taskID, flags.taskType, taskResult),
 	}
 
-	// Create output directory if needed
-	dir := filepath.Dir(flags.output)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot create directory: %s", err.Error()))
+	if flags.all {
+		files, err := common.DownloadAll(cmd.Context(), flags.output, taskID, downloadItems(taskResult), opts)
+		if err != nil {
+			return common.WriteDownloadAllError(cmd, err, files)
 		}
+		return common.WriteSuccess(cmd, map[string]any{
+			"success": true,
+			"task_id": taskID,
+			"files":   files,
+		})
 	}
 
-	// Create output file
-	outFile, err := os.Create(flags.output)
+	downloadURL, err := selectDownloadURL(taskResult, flags.watermark, flags.format)
 	if err != nil {
-		return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot create file: %s", err.Error()))
-	}
-	defer outFile.Close()
-
-	// Copy data
-	if _, err := io.Copy(outFile, resp.Body); err != nil {
-		return common.WriteError(cmd, "write_error", fmt.Sprintf("cannot write file: %s", err.Error()))
+		return common.WriteError(cmd, "download_error", err.Error())
 	}
 
-	// Get absolute path
-	absPath, err := filepath.Abs(flags.output)
+	// Download the file
+	output := common.IndexedPath(flags.output, taskID, 0, 1, common.URLExt(downloadURL, expectedExt))
+	result, err := common.DownloadURL(cmd.Context(), downloadURL, output, opts)
 	if err != nil {
-		absPath = flags.output
+		return common.WriteError(cmd, common.DownloadErrorCode(err), err.Error())
 	}
 
 	return common.WriteSuccess(cmd, map[string]any{
 		"success": true,
 		"task_id": taskID,
-		"file":    absPath,
+		"file":    result.Path,
+	})
+}
+
+type klingVideoResult struct {
+	Videos []struct {
+		URL          string `json:"url"`
+		WatermarkURL string `json:"watermark_url"`
+	} `json:"videos"`
+	Audios []struct {
+		URLMP3 string `json:"url_mp3"`
+		URLWAV string `json:"url_wav"`
+	} `json:"audios"`
+}
+
+// downloadItems lists every artifact of a task: videos with their watermark
+// variants, then audio tracks (add-sound tasks).
+func downloadItems(r *klingVideoResult) []common.DownloadItem {
+	var items []common.DownloadItem
+	for i, video := range r.Videos {
+		if video.URL != "" {
+			items = append(items, common.DownloadItem{Index: i, Kind: "video", Ext: ".mp4", URL: video.URL})
+		}
+		if video.WatermarkURL != "" {
+			items = append(items, common.DownloadItem{Index: i, Kind: "watermark", Suffix: "_watermark", Ext: ".mp4", URL: video.WatermarkURL})
+		}
+	}
+	for i, audio := range r.Audios {
+		if audio.URLMP3 != "" {
+			items = append(items, common.DownloadItem{Index: i, Kind: "audio_mp3", Suffix: "_audio", Ext: ".mp3", URL: audio.URLMP3})
+		}
+		if audio.URLWAV != "" {
+			items = append(items, common.DownloadItem{Index: i, Kind: "audio_wav", Suffix: "_audio", Ext: ".wav", URL: audio.URLWAV})
+		}
+	}
+	return items
+}
+
+// resultURLs lists every result URL of a task in a stable order.
+func resultURLs(r *klingVideoResult) []string {
+	var urls []string
+	for _, item := range downloadItems(r) {
+		urls = append(urls, item.URL)
+	}
+	return urls
+}
+
+// refreshTaskResult re-queries the task for fresh links when a result URL has expired.
+func refreshTaskResult(token, taskID, taskType string, r *klingVideoResult) common.RefreshFunc {
+	return common.RefreshFromList(resultURLs(r), func(ctx context.Context) ([]string, error) {
+		fresh, err := getTaskResult(token, taskID, taskType)
+		if err != nil {
+			return nil, err
+		}
+		return resultURLs(fresh), nil
 	})
 }
 
-func getDownloadURL(token, taskID, taskType string, watermark bool, format string) (string, error) {
+func getTaskResult(token, taskID, taskType string) (*klingVideoResult, error) {
 	// Determine endpoint based on task type
 	endpoint := "/v1/videos/omni-video/"
 	switch taskType {
@@ -163,7 +223,7 @@ func getDownloadURL(token, taskID, taskType string, watermark bool, format strin
 	// Create HTTP request
 	req, err := http.NewRequest("GET", getKlingAPIBase()+endpoint+taskID, nil)
 	if err != nil {
-		return "", fmt.Errorf("cannot create request: %s", err.Error())
+		return nil, fmt.Errorf("cannot create request: %s", err.Error())
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
@@ -172,14 +232,14 @@ func getDownloadURL(token, taskID, taskType string, watermark bool, format strin
 	client := &http.Client{Timeout: 30 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("cannot get status: %s", err.Error())
+		return nil, fmt.Errorf("cannot get status: %s", err.Error())
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("cannot read response: %s", err.Error())
+		return nil, fmt.Errorf("cannot read response: %s", err.Error())
 	}
 
 	// Parse response - includes audio URLs for add-sound tasks
@@ -187,42 +247,41 @@ func getDownloadURL(token, taskID, taskType string, watermark bool, format strin
 		Code    int    `json:"code"`
 		Message string `json:"message"`
 		Data    *struct {
-			TaskStatus string `json:"task_status"`
-			TaskResult *struct {
-				Videos []struct {
-					URL          string `json:"url"`
-					WatermarkURL string `json:"watermark_url"`
-				} `json:"videos"`
-				Audios []struct {
-					URLMP3 string `json:"url_mp3"`
-					URLWAV string `json:"url_wav"`
-				} `json:"audios"`
-			} `json:"task_result"`
+			TaskStatus string            `json:"task_status"`
+			TaskResult *klingVideoResult `json:"task_result"`
 		} `json:"data"`
 	}
 
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", fmt.Errorf("cannot parse response: %s", err.Error())
+		return nil, fmt.Errorf("cannot parse response: %s", err.Error())
 	}
 
 	if result.Code != 0 {
-		return "", fmt.Errorf("API error: %s", result.Message)
+		return nil, fmt.Errorf("API error: %s", result.Message)
 	}
 
 	if result.Data == nil {
-		return "", fmt.Errorf("no data in response")
+		return nil, fmt.Errorf("no data in response")
 	}
 
 	if result.Data.TaskStatus != "succeed" {
-		return "", fmt.Errorf("task not completed (status: %s)", result.Data.TaskStatus)
+		return nil, fmt.Errorf("task not completed (status: %s)", result.Data.TaskStatus)
+	}
+
+	if result.Data.TaskResult == nil {
+		return nil, fmt.Errorf("no result in response")
 	}
+	return result.Data.TaskResult, nil
+}
 
+// selectDownloadURL picks the file to download for a single-file download.
+func selectDownloadURL(r *klingVideoResult, watermark bool, format string) (string, error) {
 	// Return audio URL if requested
 	if format == "mp3" || format == "wav" {
-		if result.Data.TaskResult == nil || len(result.Data.TaskResult.Audios) == 0 {
+		if len(r.Audios) == 0 {
 			return "", fmt.Errorf("no audio in result")
 		}
-		audio := result.Data.TaskResult.Audios[0]
+		audio := r.Audios[0]
 		if format == "mp3" {
 			if audio.URLMP3 == "" {
 				return "", fmt.Errorf("no MP3 audio in result")
@@ -236,11 +295,11 @@ func getDownloadURL(token, taskID, taskType string, watermark bool, format strin
 	}
 
 	// Return video URL
-	if result.Data.TaskResult == nil || len(result.Data.TaskResult.Videos) == 0 {
+	if len(r.Videos) == 0 {
 		return "", fmt.Errorf("no video in result")
 	}
 
-	video := result.Data.TaskResult.Videos[0]
+	video := r.Videos[0]
 	if watermark && video.WatermarkURL != "" {
 		return video.WatermarkURL, nil
 	}
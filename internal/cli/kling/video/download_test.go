@@ -48,6 +48,25 @@ func TestDownload_MissingOutput(t *testing.T) {
 	}
 }
 
+func TestDownload_ConflictingFlags(t *testing.T) {
+	cmd := NewCmd()
+	_, stderr, err := executeCommand(cmd, "download", "task-123", "-o", "output.mp4", "--overwrite", "--no-clobber")
+
+	if err == nil {
+		t.Fatal("expected error for conflicting flags")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "conflicting_flags" {
+		t.Errorf("expected error code 'conflicting_flags', got: %s", errorObj["code"])
+	}
+}
+
 func TestDownload_InvalidFormat(t *testing.T) {
 	cmd := NewCmd()
 	_, stderr, err := executeCommand(cmd, "download", "task-123", "-o", "output.avi")
@@ -89,3 +108,39 @@ func TestDownload_MissingAPIKey(t *testing.T) {
 		t.Errorf("expected error code 'missing_api_key', got: %s", errorObj["code"])
 	}
 }
+
+func TestDownload_AllRequiresMultiFileOutput(t *testing.T) {
+	cmd := NewCmd()
+	_, stderr, err := executeCommand(cmd, "download", "task-123", "-o", "out.mp4", "--all")
+	if err == nil {
+		t.Fatal("expected error for --all with a single-file output")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_output" {
+		t.Errorf("expected error code 'invalid_output', got: %s", errorObj["code"])
+	}
+}
+
+func TestDownload_IndexWithoutAll(t *testing.T) {
+	cmd := NewCmd()
+	_, stderr, err := executeCommand(cmd, "download", "task-123", "-o", "out_{index}.mp4")
+	if err == nil {
+		t.Fatal("expected error for {index} without --all")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_output" {
+		t.Errorf("expected error code 'invalid_output', got: %s", errorObj["code"])
+	}
+}
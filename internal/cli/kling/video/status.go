@@ -152,21 +152,60 @@ func runStatus(cmd *cobra.Command, args []string, flags *statusFlags) error {
 	}
 
 	if result.Data.TaskStatus == "succeed" && result.Data.TaskResult != nil {
+		var urls []string
+		for _, v := range result.Data.TaskResult.Videos {
+			urls = append(urls, v.URL, v.WatermarkURL)
+		}
+		for _, a := range result.Data.TaskResult.Audios {
+			urls = append(urls, a.URLMP3, a.URLWAV)
+		}
+		if expiresAt := common.ExpiresAt(urls...); expiresAt != "" {
+			output["expires_at"] = expiresAt
+		}
+
 		if len(result.Data.TaskResult.Videos) > 0 {
 			video := result.Data.TaskResult.Videos[0]
 			output["video_id"] = video.ID
 			output["duration"] = video.Duration
+			output["video_count"] = len(result.Data.TaskResult.Videos)
 			if flags.verbose {
 				output["video_url"] = video.URL
 				if video.WatermarkURL != "" {
 					output["watermark_url"] = video.WatermarkURL
 				}
+				videos := make([]map[string]any, 0, len(result.Data.TaskResult.Videos))
+				for i, v := range result.Data.TaskResult.Videos {
+					item := map[string]any{
+						"index":    i,
+						"id":       v.ID,
+						"url":      v.URL,
+						"duration": v.Duration,
+					}
+					if v.WatermarkURL != "" {
+						item["watermark_url"] = v.WatermarkURL
+					}
+					videos = append(videos, item)
+				}
+				output["videos"] = videos
 			}
 		}
-		if len(result.Data.TaskResult.Audios) > 0 && flags.verbose {
-			audio := result.Data.TaskResult.Audios[0]
-			output["audio_mp3_url"] = audio.URLMP3
-			output["audio_wav_url"] = audio.URLWAV
+		if len(result.Data.TaskResult.Audios) > 0 {
+			output["audio_count"] = len(result.Data.TaskResult.Audios)
+			if flags.verbose {
+				audio := result.Data.TaskResult.Audios[0]
+				output["audio_mp3_url"] = audio.URLMP3
+				output["audio_wav_url"] = audio.URLWAV
+				audios := make([]map[string]any, 0, len(result.Data.TaskResult.Audios))
+				for i, a := range result.Data.TaskResult.Audios {
+					audios = append(audios, map[string]any{
+						"index":   i,
+						"id":      a.ID,
+						"mp3_url": a.URLMP3,
+						"wav_url": a.URLWAV,
+					})
+				}
+				output["audios"] = audios
+			}
 		}
 	}
 
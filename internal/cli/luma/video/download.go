@@ -2,12 +2,7 @@ package video
 
 import (
 	"encoding/json"
-	"io"
-	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/WHQ25/rawgenai/internal/cli/luma/shared"
@@ -16,7 +11,8 @@ import (
 )
 
 type downloadFlags struct {
-	output string
+	output   string
+	download common.DownloadFlags
 }
 
 func newDownloadCmd() *cobra.Command {
@@ -35,6 +31,7 @@ func newDownloadCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.mp4)")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
@@ -49,6 +46,9 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	if flags.output == "" {
 		return common.WriteError(cmd, "missing_output", "output file path is required (-o)")
 	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
 
 	// Validate output extension
 	if !strings.HasSuffix(strings.ToLower(flags.output), ".mp4") {
@@ -95,43 +95,14 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	downloadURL := gen.Assets.Video
 
 	// Download file
-	client := &http.Client{Timeout: 5 * time.Minute}
-	downloadResp, err := client.Get(downloadURL)
+	result, err := common.DownloadURL(cmd.Context(), downloadURL, flags.output, common.DownloadOptions{DownloadFlags: flags.download, Vars: common.OutputVars{TaskID: taskID}})
 	if err != nil {
-		return common.WriteError(cmd, "download_error", "failed to download: "+err.Error())
-	}
-	defer downloadResp.Body.Close()
-
-	if downloadResp.StatusCode != 200 {
-		return common.WriteError(cmd, "download_error",
-			"download failed with status: "+downloadResp.Status)
+		return common.WriteError(cmd, common.DownloadErrorCode(err), err.Error())
 	}
 
-	// Create output directory if needed
-	dir := filepath.Dir(flags.output)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return common.WriteError(cmd, "output_error", "failed to create directory: "+err.Error())
-		}
-	}
-
-	// Write file
-	outFile, err := os.Create(flags.output)
-	if err != nil {
-		return common.WriteError(cmd, "output_error", "failed to create output file: "+err.Error())
-	}
-	defer outFile.Close()
-
-	if _, err := io.Copy(outFile, downloadResp.Body); err != nil {
-		return common.WriteError(cmd, "output_error", "failed to write output file: "+err.Error())
-	}
-
-	// Return absolute path
-	absPath, _ := filepath.Abs(flags.output)
-
 	return common.WriteSuccess(cmd, map[string]any{
 		"success": true,
 		"task_id": taskID,
-		"file":    absPath,
+		"file":    result.Path,
 	})
 }
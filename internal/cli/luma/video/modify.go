@@ -3,6 +3,7 @@ package video
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
@@ -28,6 +29,7 @@ type modifyFlags struct {
 	model      string
 	firstFrame string
 	promptFile string
+	upload     common.UploadFlags
 }
 
 func newModifyCmd() *cobra.Command {
@@ -41,7 +43,10 @@ func newModifyCmd() *cobra.Command {
 Modes:
   adhere_1, adhere_2, adhere_3  - Stick closely to original motion
   flex_1, flex_2, flex_3        - Balance between original and new motion
-  reimagine_1, reimagine_2, reimagine_3 - More creative freedom`,
+  reimagine_1, reimagine_2, reimagine_3 - More creative freedom
+
+A local source video is uploaded first using the configured upload backend
+(see upload_backend in 'rawgenai config').`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runModify(cmd, args, flags)
@@ -50,11 +55,12 @@ Modes:
 		SilenceUsage:  true,
 	}
 
-	cmd.Flags().StringVarP(&flags.video, "video", "v", "", "Source video (URL required)")
+	cmd.Flags().StringVarP(&flags.video, "video", "v", "", "Source video (URL or local file)")
 	cmd.Flags().StringVar(&flags.mode, "mode", "", "Modification mode (adhere_1-3, flex_1-3, reimagine_1-3)")
 	cmd.Flags().StringVarP(&flags.model, "model", "m", "ray-2", "Model (ray-2, ray-flash-2)")
 	cmd.Flags().StringVar(&flags.firstFrame, "first-frame", "", "First frame image (URL)")
 	cmd.Flags().StringVarP(&flags.promptFile, "prompt-file", "f", "", "Read prompt from file")
+	common.AddUploadFlags(cmd, &flags.upload)
 
 	cmd.MarkFlagRequired("video")
 	cmd.MarkFlagRequired("mode")
@@ -67,6 +73,11 @@ func runModify(cmd *cobra.Command, args []string, flags *modifyFlags) error {
 	if flags.video == "" {
 		return common.WriteError(cmd, "missing_video", "video is required (--video)")
 	}
+	if !common.IsRemoteInput(flags.video) {
+		if _, err := os.Stat(flags.video); os.IsNotExist(err) {
+			return common.WriteError(cmd, "video_not_found", fmt.Sprintf("video file not found: %s", flags.video))
+		}
+	}
 
 	// Validate mode
 	if flags.mode == "" {
@@ -98,13 +109,20 @@ func runModify(cmd *cobra.Command, args []string, flags *modifyFlags) error {
 			"LUMA_API_KEY not found. Set it with: rawgenai config set luma_api_key <your-key>")
 	}
 
+	// Upload local video so the API can fetch it
+	uploads := common.NewUploads(&flags.upload, nil)
+	videoURL, err := uploads.Resolve(cmd.Context(), flags.video)
+	if err != nil {
+		return common.WriteError(cmd, common.UploadErrorCode(err, "video_not_found"), err.Error())
+	}
+
 	// Build request body
 	body := map[string]interface{}{
 		"generation_type": "modify_video",
 		"model":           flags.model,
 		"mode":            flags.mode,
 		"media": map[string]string{
-			"url": flags.video,
+			"url": videoURL,
 		},
 	}
 
@@ -143,10 +161,15 @@ func runModify(cmd *cobra.Command, args []string, flags *modifyFlags) error {
 		return common.WriteError(cmd, "decode_error", err.Error())
 	}
 
-	return common.WriteSuccess(cmd, map[string]interface{}{
+	result := map[string]interface{}{
 		"task_id":    gen.ID,
 		"state":      gen.State,
 		"model":      gen.Model,
 		"created_at": gen.CreatedAt,
-	})
+	}
+	if len(uploads.Results) > 0 {
+		result["uploads"] = uploads.Results
+	}
+
+	return common.WriteSuccess(cmd, result)
 }
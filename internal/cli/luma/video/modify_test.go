@@ -42,6 +42,48 @@ func TestModify_MissingMode(t *testing.T) {
 	}
 }
 
+func TestModify_VideoNotFound(t *testing.T) {
+	setupNoConfigEnv(t)
+	t.Setenv("LUMA_API_KEY", "test-key")
+
+	cmd := newTestCmd()
+	_, stderr, err := executeCommand(cmd, "modify", "--video", "/nonexistent/video.mp4", "--mode", "flex_1")
+
+	if err == nil {
+		t.Fatal("expected error for missing video file")
+	}
+
+	var resp map[string]any
+	json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp)
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "video_not_found" {
+		t.Errorf("expected error code 'video_not_found', got: %s", errorObj["code"])
+	}
+}
+
+func TestModify_LocalVideoUploadNotConfigured(t *testing.T) {
+	setupNoConfigEnv(t)
+	t.Setenv("LUMA_API_KEY", "test-key")
+
+	videoPath := createTempFile(t, "source.mp4", "fake video")
+
+	cmd := newTestCmd()
+	_, stderr, err := executeCommand(cmd, "modify", "--video", videoPath, "--mode", "flex_1")
+
+	if err == nil {
+		t.Fatal("expected error for unconfigured upload backend")
+	}
+
+	var resp map[string]any
+	json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp)
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "upload_not_configured" {
+		t.Errorf("expected error code 'upload_not_configured', got: %s", errorObj["code"])
+	}
+}
+
 func TestModify_InvalidMode(t *testing.T) {
 	setupNoConfigEnv(t)
 	t.Setenv("LUMA_API_KEY", "test-key")
@@ -119,6 +119,7 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 	}
 
 	if flags.output != "" {
+		flags.output = common.DefaultExt(flags.output, ".jpeg")
 		ext := strings.ToLower(filepath.Ext(flags.output))
 		if ext == "" || !validImageFormats[ext] {
 			return common.WriteError(cmd, "unsupported_format", "output file must be png, jpg, jpeg, or webp")
@@ -282,18 +283,9 @@ func saveImages(output string, results []string, isURL bool) ([]string, error) {
 		absPath = output
 	}
 
-	if len(results) == 1 {
-		path := absPath
-		if err := writeImage(path, results[0], isURL); err != nil {
-			return nil, err
-		}
-		return []string{path}, nil
-	}
-
-	baseName := strings.TrimSuffix(absPath, filepath.Ext(absPath))
-	extName := filepath.Ext(absPath)
+	// Multiple images are numbered from 1, "{index}" in the path is honoured too
 	for i, item := range results {
-		path := fmt.Sprintf("%s_%d%s", baseName, i+1, extName)
+		path := common.IndexedPath(absPath, "image", i+1, len(results), "")
 		if err := writeImage(path, item, isURL); err != nil {
 			return nil, err
 		}
@@ -326,7 +318,7 @@ func writeImage(path, data string, isURL bool) error {
 		content = decoded
 	}
 
-	if err := os.WriteFile(path, content, 0644); err != nil {
+	if _, err := common.WriteOutput(path, content, common.OutputVars{}); err != nil {
 		return fmt.Errorf("cannot write output file: %s", err.Error())
 	}
 	return nil
@@ -110,6 +110,7 @@ func runCreate(cmd *cobra.Command, args []string, flags *createFlags) error {
 	}
 
 	if flags.output != "" {
+		flags.output = common.DefaultExt(flags.output, "."+flags.format)
 		ext := strings.ToLower(filepath.Ext(flags.output))
 		expectedExt := "." + flags.format
 		if ext != expectedExt {
@@ -240,7 +241,7 @@ func handleSyncResponse(cmd *cobra.Command, req *http.Request, flags *createFlag
 		useTempFile = true
 	}
 
-	if err := os.WriteFile(absPath, audioData, 0644); err != nil {
+	if _, err := common.WriteOutput(absPath, audioData, common.OutputVars{}); err != nil {
 		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
 	}
 
@@ -2,6 +2,8 @@ package shared
 
 import (
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -95,3 +97,67 @@ func DetectMIMEType(path string, mediaType string) string {
 	}
 	return "application/octet-stream"
 }
+
+// APIError is a failed MiniMax API call with the CLI error code to report.
+type APIError struct {
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// ErrorCode returns the CLI error code for an error from this package.
+func ErrorCode(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return "request_error"
+}
+
+// RetrieveFileURL returns a freshly signed download URL for a generated file.
+// MiniMax signs a new URL on every call, so it also serves to refresh expired links.
+func RetrieveFileURL(fileID string) (string, error) {
+	req, err := CreateRequest("GET", "/v1/files/retrieve?file_id="+fileID, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := DoRequest(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", &APIError{Code: "response_error", Message: fmt.Sprintf("cannot read response: %s", err.Error())}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &APIError{Code: "api_error", Message: fmt.Sprintf("API returned status %d: %s", resp.StatusCode, string(respBody))}
+	}
+
+	var apiResp struct {
+		File struct {
+			DownloadURL string `json:"download_url"`
+		} `json:"file"`
+		BaseResp struct {
+			StatusCode int    `json:"status_code"`
+			StatusMsg  string `json:"status_msg"`
+		} `json:"base_resp"`
+	}
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return "", &APIError{Code: "response_error", Message: fmt.Sprintf("cannot parse response: %s", err.Error())}
+	}
+
+	if apiResp.BaseResp.StatusCode != 0 {
+		return "", &APIError{Code: "api_error", Message: fmt.Sprintf("api error %d: %s", apiResp.BaseResp.StatusCode, apiResp.BaseResp.StatusMsg)}
+	}
+	if apiResp.File.DownloadURL == "" {
+		return "", &APIError{Code: "download_error", Message: "download_url is empty"}
+	}
+	return apiResp.File.DownloadURL, nil
+}
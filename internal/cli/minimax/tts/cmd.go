@@ -28,6 +28,7 @@ type ttsFlags struct {
 	channel    int
 	stream     bool
 	speak      bool
+	timing     common.TimestampFlags
 }
 
 func newTTSCmd() *cobra.Command {
@@ -58,6 +59,7 @@ func newTTSCmd() *cobra.Command {
 	cmd.Flags().IntVar(&flags.channel, "channel", 0, "Channel count (1 or 2)")
 	cmd.Flags().BoolVar(&flags.stream, "stream", false, "Use WebSocket streaming")
 	cmd.Flags().BoolVar(&flags.speak, "speak", false, "Play audio after generation")
+	common.AddTimestampFlags(cmd, &flags.timing, common.TimestampsSentence)
 
 	cmd.AddCommand(newCreateCmd())
 	cmd.AddCommand(newStatusCmd())
@@ -110,14 +112,14 @@ func validateSyncFlags(cmd *cobra.Command, flags *ttsFlags) error {
 		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag or --speak")
 	}
 
+	// A .wav output picks the wav format unless one is given
+	if common.IsWAVPath(flags.output) && !cmd.Flags().Changed("format") {
+		flags.format = "wav"
+	}
 	if !validFormats[flags.format] {
 		return common.WriteError(cmd, "invalid_format", "format must be mp3, pcm, flac, or wav")
 	}
 
-	if flags.speak && flags.format != "mp3" {
-		return common.WriteError(cmd, "invalid_format", "--speak only supports mp3 format")
-	}
-
 	if flags.speed < 0.5 || flags.speed > 2 {
 		return common.WriteError(cmd, "invalid_speed", "speed must be between 0.5 and 2.0")
 	}
@@ -138,5 +140,33 @@ func validateSyncFlags(cmd *cobra.Command, flags *ttsFlags) error {
 		return common.WriteError(cmd, "invalid_channel", "channel must be 1 or 2")
 	}
 
+	if err := flags.timing.Validate(); err != nil {
+		return common.WriteError(cmd, common.TimestampErrorCode(err), err.Error())
+	}
+	if flags.stream && flags.timing.Enabled() {
+		return common.WriteError(cmd, "incompatible_timestamps", "--timestamps and --subtitles are not available with --stream")
+	}
+
 	return nil
 }
+
+// defaultSampleRate is what MiniMax uses when --sample-rate is not set.
+const defaultSampleRate = 32000
+
+// pcmFormat is the raw audio MiniMax returns for the pcm format.
+func pcmFormat(flags *ttsFlags) common.AudioFormat {
+	format := common.AudioFormat{SampleRate: flags.sampleRate, Channels: flags.channel, Encoding: common.PCMS16LE}
+	if format.SampleRate == 0 {
+		format.SampleRate = defaultSampleRate
+	}
+	if format.Channels == 0 {
+		format.Channels = 1
+	}
+	return format
+}
+
+// playOptions describes the audio for the player; only pcm needs it.
+func playOptions(flags *ttsFlags) common.DecodeOptions {
+	format := pcmFormat(flags)
+	return common.DecodeOptions{SampleRate: format.SampleRate, Channels: format.Channels}
+}
@@ -7,8 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/WHQ25/rawgenai/internal/cli/minimax/shared"
@@ -40,10 +40,51 @@ var validBitrates = map[int]bool{
 }
 
 type ttsSyncResponse struct {
-	Success bool   `json:"success"`
-	File    string `json:"file,omitempty"`
-	Model   string `json:"model,omitempty"`
-	Voice   string `json:"voice,omitempty"`
+	Success            bool               `json:"success"`
+	File               string             `json:"file,omitempty"`
+	Model              string             `json:"model,omitempty"`
+	Voice              string             `json:"voice,omitempty"`
+	TimeToFirstAudioMs int64              `json:"time_to_first_audio_ms,omitempty"`
+	Timestamps         []common.TimedText `json:"timestamps,omitempty"`
+	Subtitles          string             `json:"subtitles,omitempty"`
+}
+
+// subtitleSegment is an entry of the subtitle file MiniMax links to when
+// subtitle_enable is set; times are in milliseconds.
+type subtitleSegment struct {
+	Text      string  `json:"text"`
+	TimeBegin float64 `json:"time_begin"`
+	TimeEnd   float64 `json:"time_end"`
+}
+
+// fetchSubtitles downloads a subtitle file and returns its sentence timings.
+func fetchSubtitles(url string) ([]common.TimedText, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := shared.DoRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("subtitle download returned status %d", resp.StatusCode)
+	}
+
+	var segments []subtitleSegment
+	if err := json.NewDecoder(resp.Body).Decode(&segments); err != nil {
+		return nil, fmt.Errorf("cannot parse subtitles: %w", err)
+	}
+	sentences := make([]common.TimedText, 0, len(segments))
+	for _, seg := range segments {
+		sentences = append(sentences, common.TimedText{
+			Text:  strings.TrimSpace(seg.Text),
+			Start: seg.TimeBegin / 1000,
+			End:   seg.TimeEnd / 1000,
+		})
+	}
+	return sentences, nil
 }
 
 func runSync(cmd *cobra.Command, args []string, flags *ttsFlags) error {
@@ -90,6 +131,9 @@ func runSync(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	if flags.channel != 0 {
 		body["audio_setting"].(map[string]any)["channel"] = flags.channel
 	}
+	if flags.timing.Enabled() {
+		body["subtitle_enable"] = true
+	}
 
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
@@ -118,8 +162,9 @@ func runSync(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 
 	var apiResp struct {
 		Data struct {
-			Audio  string `json:"audio"`
-			Status int    `json:"status"`
+			Audio        string `json:"audio"`
+			Status       int    `json:"status"`
+			SubtitleFile string `json:"subtitle_file"`
 		} `json:"data"`
 		BaseResp struct {
 			StatusCode int    `json:"status_code"`
@@ -143,28 +188,53 @@ func runSync(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		return common.WriteError(cmd, "decode_error", fmt.Sprintf("cannot decode audio: %s", err.Error()))
 	}
 
+	// Fetch the subtitles first, so a failure leaves no output behind
+	var sentences []common.TimedText
+	if flags.timing.Enabled() {
+		if apiResp.Data.SubtitleFile == "" {
+			return common.WriteError(cmd, "subtitle_error", "no subtitle file returned")
+		}
+		if sentences, err = fetchSubtitles(apiResp.Data.SubtitleFile); err != nil {
+			return common.WriteError(cmd, "subtitle_error", err.Error())
+		}
+	}
+
 	var absPath string
 	if flags.output != "" {
 		absPath, err = filepath.Abs(flags.output)
 		if err != nil {
 			absPath = flags.output
 		}
-		if err := os.WriteFile(absPath, audioBytes, 0644); err != nil {
+		data := audioBytes
+		if flags.format == "pcm" && common.IsWAVPath(absPath) {
+			data = common.PCMToWAV(audioBytes, pcmFormat(flags))
+		}
+		if _, err := common.WriteOutput(absPath, data, common.OutputVars{}); err != nil {
 			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
 		}
 	}
 
+	result := ttsSyncResponse{
+		Success: true,
+		File:    absPath,
+		Model:   flags.model,
+		Voice:   flags.voice,
+	}
+	if flags.timing.Enabled() {
+		if flags.timing.Timestamps != "" {
+			result.Timestamps = sentences
+		}
+		if result.Subtitles, err = flags.timing.WriteSubtitles(sentences); err != nil {
+			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write subtitles: %s", err.Error()))
+		}
+	}
+
 	if flags.speak {
 		reader := bytes.NewReader(audioBytes)
-		if err := common.PlayMP3(reader); err != nil {
+		if err := common.PlayAudio(reader, "."+flags.format, playOptions(flags)); err != nil {
 			return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", err.Error()))
 		}
 	}
 
-	return common.WriteSuccess(cmd, ttsSyncResponse{
-		Success: true,
-		File:    absPath,
-		Model:   flags.model,
-		Voice:   flags.voice,
-	})
+	return common.WriteSuccess(cmd, result)
 }
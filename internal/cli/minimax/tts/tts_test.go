@@ -2,6 +2,8 @@ package tts
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 )
@@ -77,3 +79,69 @@ func TestTTSCreate_TextAndFileIDConflict(t *testing.T) {
 		t.Fatalf("expected JSON error output, got: %s", stderr)
 	}
 }
+
+func TestTTSDownload_ConflictingFlags(t *testing.T) {
+	cmd := newTTSCmd()
+	_, stderr, err := executeCommand(cmd, "download", "123", "-o", "out.mp3", "--overwrite", "--no-clobber")
+	if err == nil {
+		t.Fatal("expected error for conflicting flags")
+	}
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "conflicting_flags" {
+		t.Errorf("expected error code 'conflicting_flags', got: %s", errorObj["code"])
+	}
+}
+
+func TestTTS_TimestampsWithStream(t *testing.T) {
+	cmd := newTTSCmd()
+	_, stderr, err := executeCommand(cmd, "Hello", "-o", "out.mp3", "--stream", "--subtitles", "out.srt")
+	if err == nil {
+		t.Fatal("expected error for subtitles with --stream")
+	}
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "incompatible_timestamps" {
+		t.Errorf("expected error code 'incompatible_timestamps', got: %s", errorObj["code"])
+	}
+}
+
+func TestTTS_WordTimestampsUnsupported(t *testing.T) {
+	cmd := newTTSCmd()
+	_, stderr, err := executeCommand(cmd, "Hello", "-o", "out.mp3", "--timestamps=word")
+	if err == nil {
+		t.Fatal("expected error for word timestamps")
+	}
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_timestamps" {
+		t.Errorf("expected error code 'invalid_timestamps', got: %s", errorObj["code"])
+	}
+}
+
+func TestFetchSubtitles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"text":"Hello there.","time_begin":0,"time_end":1250.5,"text_begin":0,"text_end":12},{"text":" Bye.","time_begin":1400,"time_end":2000}]`))
+	}))
+	defer server.Close()
+
+	sentences, err := fetchSubtitles(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sentences) != 2 {
+		t.Fatalf("expected 2 sentences, got %d", len(sentences))
+	}
+	if sentences[0].End != 1.2505 || sentences[1].Text != "Bye." || sentences[1].Start != 1.4 {
+		t.Errorf("unexpected sentences: %+v", sentences)
+	}
+}
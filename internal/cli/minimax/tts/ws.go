@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"path/filepath"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
@@ -26,15 +25,23 @@ type wsMessage struct {
 }
 
 func runWebsocket(cmd *cobra.Command, text string, flags *ttsFlags) error {
-	if flags.format != "mp3" && flags.speak {
-		return common.WriteError(cmd, "invalid_format", "--speak only supports mp3 format")
-	}
-
 	apiKey := shared.GetMinimaxAPIKey()
 	if apiKey == "" {
 		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("MINIMAX_API_KEY"))
 	}
 
+	// WAV is not streamed, so it is requested as PCM and wrapped locally
+	streamFormat := flags.format
+	if streamFormat == "wav" {
+		streamFormat = "pcm"
+	}
+
+	var player *common.StreamPlayer
+	if flags.speak {
+		player = common.NewStreamPlayer("."+streamFormat, playOptions(flags))
+		defer player.Abort(nil)
+	}
+
 	header := http.Header{}
 	header.Set("Authorization", "Bearer "+apiKey)
 
@@ -58,7 +65,7 @@ func runWebsocket(cmd *cobra.Command, text string, flags *ttsFlags) error {
 			"pitch":    flags.pitch,
 		},
 		"audio_setting": map[string]any{
-			"format": flags.format,
+			"format": streamFormat,
 		},
 		"continuous_sound": false,
 	}
@@ -93,10 +100,17 @@ func runWebsocket(cmd *cobra.Command, text string, flags *ttsFlags) error {
 		outputPath = absPath
 	}
 
-	var outFile *os.File
+	var outFile io.WriteCloser
 	var errOpen error
 	if outputPath != "" {
-		outFile, errOpen = os.Create(outputPath)
+		switch flags.format {
+		case "wav":
+			outFile, errOpen = common.CreateWAVFile(outputPath, pcmFormat(flags))
+		case "pcm":
+			outFile, errOpen = common.CreatePCMFile(outputPath, pcmFormat(flags))
+		default:
+			outFile, errOpen = common.CreateOutput(outputPath)
+		}
 		if errOpen != nil {
 			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", errOpen.Error()))
 		}
@@ -108,23 +122,12 @@ func runWebsocket(cmd *cobra.Command, text string, flags *ttsFlags) error {
 		writer = outFile
 	}
 
-	var pipeWriter *io.PipeWriter
-	if flags.speak {
-		pr, pw := io.Pipe()
-		pipeWriter = pw
+	if player != nil {
 		if writer != nil {
-			writer = io.MultiWriter(writer, pw)
+			writer = io.MultiWriter(writer, player)
 		} else {
-			writer = pw
+			writer = player
 		}
-		playErr := make(chan error, 1)
-		go func() {
-			playErr <- common.PlayMP3(pr)
-		}()
-		defer func() {
-			pw.Close()
-			_ = <-playErr
-		}()
 	}
 
 	if writer == nil {
@@ -134,9 +137,6 @@ func runWebsocket(cmd *cobra.Command, text string, flags *ttsFlags) error {
 	for {
 		var msg wsMessage
 		if err := conn.ReadJSON(&msg); err != nil {
-			if pipeWriter != nil {
-				pipeWriter.CloseWithError(err)
-			}
 			return common.WriteError(cmd, "stream_error", fmt.Sprintf("websocket read error: %s", err.Error()))
 		}
 
@@ -146,6 +146,9 @@ func runWebsocket(cmd *cobra.Command, text string, flags *ttsFlags) error {
 				return common.WriteError(cmd, "decode_error", fmt.Sprintf("cannot decode audio: %s", err.Error()))
 			}
 			if _, err := writer.Write(chunk); err != nil {
+				if player != nil && player.Err() != nil {
+					return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", err.Error()))
+				}
 				return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write audio: %s", err.Error()))
 			}
 		}
@@ -157,10 +160,17 @@ func runWebsocket(cmd *cobra.Command, text string, flags *ttsFlags) error {
 
 	_ = conn.WriteJSON(map[string]any{"event": "task_finish"})
 
-	return common.WriteSuccess(cmd, ttsSyncResponse{
+	result := ttsSyncResponse{
 		Success: true,
 		File:    outputPath,
 		Model:   flags.model,
 		Voice:   flags.voice,
-	})
+	}
+	if player != nil {
+		if err := player.Close(); err != nil {
+			return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", err.Error()))
+		}
+		result.TimeToFirstAudioMs = player.TimeToFirstAudio().Milliseconds()
+	}
+	return common.WriteSuccess(cmd, result)
 }
@@ -1,14 +1,8 @@
 package video
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"path/filepath"
+	"context"
 	"strings"
-	"time"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/WHQ25/rawgenai/internal/cli/minimax/shared"
@@ -17,7 +11,8 @@ import (
 )
 
 type downloadFlags struct {
-	output string
+	output   string
+	download common.DownloadFlags
 }
 
 func newDownloadCmd() *cobra.Command {
@@ -35,6 +30,7 @@ func newDownloadCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.mp4)")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
@@ -46,6 +42,10 @@ func runDownload(cmd *cobra.Command, fileID string, flags *downloadFlags) error
 	if flags.output == "" {
 		return common.WriteError(cmd, "missing_output", "output file path is required (-o)")
 	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
+	flags.output = common.DefaultExt(flags.output, ".mp4")
 	if !strings.HasSuffix(strings.ToLower(flags.output), ".mp4") {
 		return common.WriteError(cmd, "invalid_output", "output file must have .mp4 extension")
 	}
@@ -55,82 +55,25 @@ func runDownload(cmd *cobra.Command, fileID string, flags *downloadFlags) error
 		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("MINIMAX_API_KEY"))
 	}
 
-	req, err := shared.CreateRequest("GET", "/v1/files/retrieve?file_id="+fileID, nil)
-	if err != nil {
-		return common.WriteError(cmd, "request_error", err.Error())
-	}
-
-	resp, err := shared.DoRequest(req)
+	downloadURL, err := shared.RetrieveFileURL(fileID)
 	if err != nil {
-		return common.WriteError(cmd, "request_error", err.Error())
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot read response: %s", err.Error()))
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return common.WriteError(cmd, "api_error", fmt.Sprintf("API returned status %d: %s", resp.StatusCode, string(respBody)))
-	}
-
-	var apiResp struct {
-		File struct {
-			DownloadURL string `json:"download_url"`
-		} `json:"file"`
-		BaseResp struct {
-			StatusCode int    `json:"status_code"`
-			StatusMsg  string `json:"status_msg"`
-		} `json:"base_resp"`
-	}
-	if err := json.Unmarshal(respBody, &apiResp); err != nil {
-		return common.WriteError(cmd, "response_error", fmt.Sprintf("cannot parse response: %s", err.Error()))
-	}
-
-	if apiResp.BaseResp.StatusCode != 0 {
-		return common.WriteError(cmd, "api_error", fmt.Sprintf("api error %d: %s", apiResp.BaseResp.StatusCode, apiResp.BaseResp.StatusMsg))
-	}
-	if apiResp.File.DownloadURL == "" {
-		return common.WriteError(cmd, "download_error", "download_url is empty")
+		return common.WriteError(cmd, shared.ErrorCode(err), err.Error())
 	}
 
-	client := &http.Client{Timeout: 5 * time.Minute}
-	downloadResp, err := client.Get(apiResp.File.DownloadURL)
-	if err != nil {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download file: %s", err.Error()))
-	}
-	defer downloadResp.Body.Close()
-
-	if downloadResp.StatusCode != http.StatusOK {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("download failed with status: %s", downloadResp.Status))
-	}
-
-	dir := filepath.Dir(flags.output)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return common.WriteError(cmd, "output_error", fmt.Sprintf("failed to create directory: %s", err.Error()))
-		}
-	}
-
-	outFile, err := os.Create(flags.output)
-	if err != nil {
-		return common.WriteError(cmd, "output_error", fmt.Sprintf("failed to create output file: %s", err.Error()))
-	}
-	defer outFile.Close()
-
-	if _, err := io.Copy(outFile, downloadResp.Body); err != nil {
-		return common.WriteError(cmd, "output_error", fmt.Sprintf("failed to write output file: %s", err.Error()))
-	}
-
-	absPath, err := filepath.Abs(flags.output)
+	result, err := common.DownloadURL(cmd.Context(), downloadURL, flags.output, common.DownloadOptions{
+		DownloadFlags: flags.download,
+		Vars:          common.OutputVars{TaskID: fileID},
+		Refresh: func(ctx context.Context, staleURL string) (string, error) {
+			return shared.RetrieveFileURL(fileID)
+		},
+	})
 	if err != nil {
-		absPath = flags.output
+		return common.WriteError(cmd, common.DownloadErrorCode(err), err.Error())
 	}
 
 	return common.WriteSuccess(cmd, map[string]any{
 		"success": true,
 		"file_id": fileID,
-		"file":    absPath,
+		"file":    result.Path,
 	})
 }
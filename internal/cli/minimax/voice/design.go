@@ -8,7 +8,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
@@ -137,11 +136,8 @@ func runDesign(cmd *cobra.Command, flags *designFlags) error {
 
 	var absPath string
 	if flags.output != "" {
-		absPath, err = filepath.Abs(flags.output)
+		absPath, err = common.WriteOutput(flags.output, audioBytes, common.OutputVars{})
 		if err != nil {
-			absPath = flags.output
-		}
-		if err := os.WriteFile(absPath, audioBytes, 0644); err != nil {
 			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
 		}
 	}
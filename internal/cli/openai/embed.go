@@ -0,0 +1,155 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	oai "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/spf13/cobra"
+)
+
+// Request limits of the embeddings API: 2048 inputs and 300k tokens. The
+// token limit is kept by counting bytes, as no token is shorter than a byte.
+const (
+	embedMaxInputs = 2048
+	embedMaxBytes  = 300000
+)
+
+// Response type
+type embedResponse struct {
+	Success    bool   `json:"success"`
+	File       string `json:"file"`
+	Format     string `json:"format"`
+	Model      string `json:"model"`
+	Count      int    `json:"count"`
+	Dimensions int    `json:"dimensions"`
+	Requests   int    `json:"requests"`
+	Tokens     int64  `json:"tokens,omitempty"`
+}
+
+// Flag struct
+type embedFlags struct {
+	file       string
+	output     string
+	model      string
+	dimensions int
+}
+
+// Command
+var embedCmd = newEmbedCmd()
+
+func newEmbedCmd() *cobra.Command {
+	flags := &embedFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "embed [text...]",
+		Short: "Create text embeddings using OpenAI",
+		Long: `Create text embeddings using OpenAI embedding models.
+
+Each argument is one input; without arguments, each non-empty line of --file
+or stdin is one. Inputs are sent in batches within the API limits. Vectors are
+written to -o as JSONL (one object per input) or .npy (a float32 matrix).`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEmbed(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.file, "file", "f", "", "Input file, one text per line")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file (.jsonl, .npy)")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", "text-embedding-3-small", "Model name")
+	cmd.Flags().IntVarP(&flags.dimensions, "dimensions", "d", 0, "Reduce vectors to this many dimensions (text-embedding-3 models)")
+
+	return cmd
+}
+
+func runEmbed(cmd *cobra.Command, args []string, flags *embedFlags) error {
+	// Get inputs
+	texts, err := common.ReadEmbeddingInputs(args, flags.file, cmd.InOrStdin())
+	if err != nil {
+		return common.WriteError(cmd, "file_not_found", err.Error())
+	}
+	if len(texts) == 0 {
+		return common.WriteError(cmd, "missing_input", "no text provided, use arguments, --file flag, or pipe from stdin")
+	}
+
+	// Validate output
+	if flags.output == "" {
+		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
+	}
+	format, err := common.EmbeddingFormat(flags.output)
+	if err != nil {
+		return common.WriteError(cmd, "unsupported_format", err.Error())
+	}
+
+	// Validate dimensions
+	if flags.dimensions < 0 {
+		return common.WriteError(cmd, "invalid_dimensions", "dimensions must be positive")
+	}
+
+	// Check API key
+	apiKey := config.GetAPIKey("OPENAI_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("OPENAI_API_KEY"))
+	}
+
+	client := oai.NewClient(option.WithAPIKey(apiKey))
+	ctx := context.Background()
+
+	// Embed in batches; the API returns each vector with its index in the batch
+	embeddings := make([]common.Embedding, len(texts))
+	batches := common.BatchTexts(texts, embedMaxInputs, embedMaxBytes)
+	var tokens int64
+	offset := 0
+	for _, batch := range batches {
+		params := oai.EmbeddingNewParams{
+			Model: oai.EmbeddingModel(flags.model),
+			Input: oai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: batch},
+		}
+		if flags.dimensions > 0 {
+			params.Dimensions = oai.Int(int64(flags.dimensions))
+		}
+
+		resp, err := client.Embeddings.New(ctx, params)
+		if err != nil {
+			return handleAPIError(cmd, err)
+		}
+		if len(resp.Data) != len(batch) {
+			return common.WriteError(cmd, "invalid_response", fmt.Sprintf("expected %d embeddings, got %d", len(batch), len(resp.Data)))
+		}
+		for _, data := range resp.Data {
+			if data.Index < 0 || int(data.Index) >= len(batch) {
+				return common.WriteError(cmd, "invalid_response", fmt.Sprintf("embedding index %d out of range", data.Index))
+			}
+			i := offset + int(data.Index)
+			vector := make([]float32, len(data.Embedding))
+			for j, v := range data.Embedding {
+				vector[j] = float32(v)
+			}
+			embeddings[i] = common.Embedding{Index: i, Text: texts[i], Vector: vector}
+		}
+		tokens += resp.Usage.TotalTokens
+		offset += len(batch)
+	}
+
+	// Write vectors
+	absPath, err := common.WriteEmbeddings(flags.output, embeddings)
+	if err != nil {
+		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+	}
+
+	return common.WriteSuccess(cmd, embedResponse{
+		Success:    true,
+		File:       absPath,
+		Format:     format,
+		Model:      flags.model,
+		Count:      len(embeddings),
+		Dimensions: len(embeddings[0].Vector),
+		Requests:   len(batches),
+		Tokens:     tokens,
+	})
+}
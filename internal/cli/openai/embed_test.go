@@ -0,0 +1,156 @@
+package openai
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+)
+
+func TestEmbed_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"missing input", []string{"-o", "out.jsonl"}, "missing_input"},
+		{"file not found", []string{"-f", filepath.Join(t.TempDir(), "missing.txt"), "-o", "out.jsonl"}, "file_not_found"},
+		{"missing output", []string{"a cat"}, "missing_output"},
+		{"unsupported format", []string{"a cat", "-o", "out.csv"}, "unsupported_format"},
+		{"negative dimensions", []string{"a cat", "-o", "out.npy", "-d", "-1"}, "invalid_dimensions"},
+		{"missing api key", []string{"a cat", "-o", "out.npy"}, "missing_api_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			t.Setenv("OPENAI_API_KEY", "")
+			cmd := newEmbedCmd()
+			cmd.SetIn(strings.NewReader(""))
+			_, stderr, err := executeCommand(cmd, tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+// embedServer returns [len(text), position] for each input, in reverse
+// order to check that vectors are placed by index
+func embedServer(t *testing.T) func() []map[string]any {
+	var mu sync.Mutex
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+
+		inputs, _ := req["input"].([]any)
+		var data []string
+		for i := len(inputs) - 1; i >= 0; i-- {
+			text, _ := inputs[i].(string)
+			data = append(data, fmt.Sprintf(`{"object":"embedding","index":%d,"embedding":[%d,%d]}`, i, len(text), i))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"object":"list","model":"text-embedding-3-small","data":[%s],"usage":{"prompt_tokens":%d,"total_tokens":%d}}`, strings.Join(data, ","), len(inputs), len(inputs))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+
+	return func() []map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func TestEmbed_JSONL(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	requests := embedServer(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "prompts.txt")
+	os.WriteFile(input, []byte("a cat\n\na small dog\n"), 0644)
+	output := filepath.Join(dir, "vectors.jsonl")
+
+	stdout, stderr, err := executeCommand(newEmbedCmd(), "-f", input, "-o", output, "-d", "256")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp embedResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if !resp.Success || resp.File != output || resp.Format != "jsonl" || resp.Count != 2 || resp.Dimensions != 2 || resp.Requests != 1 || resp.Tokens != 2 || resp.Model != "text-embedding-3-small" {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	reqs := requests()
+	if reqs[0]["dimensions"] != float64(256) {
+		t.Errorf("expected dimensions 256, got %v", reqs[0]["dimensions"])
+	}
+
+	f, _ := os.Open(output)
+	defer f.Close()
+	var lines []common.Embedding
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e common.Embedding
+		json.Unmarshal(scanner.Bytes(), &e)
+		lines = append(lines, e)
+	}
+	if len(lines) != 2 || lines[0].Text != "a cat" || lines[0].Vector[0] != 5 || lines[1].Index != 1 || lines[1].Vector[0] != 11 {
+		t.Errorf("unexpected vectors: %+v", lines)
+	}
+}
+
+func TestEmbed_Batches(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	requests := embedServer(t)
+	output := filepath.Join(t.TempDir(), "vectors.npy")
+
+	// One input over the request limit
+	lines := make([]string, embedMaxInputs+1)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("prompt %d", i)
+	}
+	cmd := newEmbedCmd()
+	cmd.SetIn(strings.NewReader(strings.Join(lines, "\n")))
+	stdout, stderr, err := executeCommand(cmd, "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"requests":2`) || !strings.Contains(stdout, fmt.Sprintf(`"count":%d`, embedMaxInputs+1)) {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	reqs := requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(reqs))
+	}
+	if second, _ := reqs[1]["input"].([]any); len(second) != 1 || second[0] != fmt.Sprintf("prompt %d", embedMaxInputs) {
+		t.Errorf("unexpected second batch: %v", reqs[1]["input"])
+	}
+	if _, ok := reqs[0]["dimensions"]; ok {
+		t.Error("expected dimensions to be left to the model default")
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(128 + (embedMaxInputs+1)*2*4); info.Size() != want {
+		t.Errorf("expected .npy of %d bytes, got %d", want, info.Size())
+	}
+}
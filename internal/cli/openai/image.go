@@ -93,6 +93,7 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 	}
 
 	// Validate format
+	flags.output = common.DefaultExt(flags.output, ".png")
 	ext := strings.ToLower(filepath.Ext(flags.output))
 	outputFormat, ok := supportedImageFormats[ext]
 	if !ok {
@@ -263,7 +264,7 @@ func runImage(cmd *cobra.Command, args []string, flags *imageFlags) error {
 		absPath = flags.output
 	}
 
-	if err := os.WriteFile(absPath, imgData, 0644); err != nil {
+	if _, err := common.WriteOutput(absPath, imgData, common.OutputVars{}); err != nil {
 		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
 	}
 
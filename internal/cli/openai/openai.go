@@ -8,12 +8,16 @@ import (
 var Cmd = &cobra.Command{
 	Use:   "openai",
 	Short: "OpenAI provider commands",
-	Long:  "Commands for OpenAI services including TTS, STT, and Image generation.",
+	Long:  "Commands for OpenAI services including TTS, STT, Image and Text generation, realtime speech, image and audio understanding, and embeddings.",
 }
 
 func init() {
 	Cmd.AddCommand(ttsCmd)
 	Cmd.AddCommand(imageCmd)
 	Cmd.AddCommand(sttCmd)
+	Cmd.AddCommand(visionCmd)
+	Cmd.AddCommand(textCmd)
+	Cmd.AddCommand(embedCmd)
+	Cmd.AddCommand(realtimeCmd)
 	Cmd.AddCommand(video.Cmd)
 }
@@ -0,0 +1,579 @@
+package openai
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+)
+
+const defaultRealtimeModel = "gpt-realtime"
+
+var realtimeVADTypes = []string{"server_vad", "semantic_vad", "none"}
+
+var realtimeEagerness = []string{"low", "medium", "high", "auto"}
+
+// With turn detection, the audio ends with realtimeTrailingSilence seconds
+// of silence so the server closes the last turn itself. The session then
+// ends once the server has been quiet for realtimeTurnGrace since a reply,
+// or for realtimeIdleTimeout at any point.
+var (
+	realtimeTrailingSilence = 1.0
+	realtimeTurnGrace       = 2 * time.Second
+	realtimeIdleTimeout     = 15 * time.Second
+)
+
+type realtimeFlags struct {
+	output       string
+	audio        string
+	promptFile   string
+	instructions string
+	voice        string
+	model        string
+	speak        bool
+	vad          string
+	vadThreshold float64
+	vadSilence   int
+	vadPrefix    int
+	vadEagerness string
+}
+
+type realtimeResponse struct {
+	Success   bool           `json:"success"`
+	Text      string         `json:"text,omitempty"`
+	File      string         `json:"file,omitempty"`
+	Model     string         `json:"model,omitempty"`
+	Voice     string         `json:"voice,omitempty"`
+	Duration  float64        `json:"duration,omitempty"`
+	Responses int            `json:"responses,omitempty"`
+	Usage     *realtimeUsage `json:"usage,omitempty"`
+}
+
+type realtimeUsage struct {
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+	TotalTokens  int64 `json:"total_tokens"`
+}
+
+var realtimeCmd = newRealtimeCmd()
+
+func newRealtimeCmd() *cobra.Command {
+	flags := &realtimeFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "realtime [text]",
+		Short: "Speech to speech using the OpenAI Realtime API",
+		Long: `Talk to an OpenAI realtime model and record its spoken reply.
+
+The user turn is an audio file (--audio) or text. With turn detection, the
+server finds the turns in the audio and answers each one. The replies are
+streamed to -o (.wav or .pcm) or played with --speak, and the transcript and
+token usage are returned as JSON.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRealtime(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path for the replies (.wav, .pcm)")
+	cmd.Flags().StringVarP(&flags.audio, "audio", "a", "", "Audio file to send as the user turn")
+	cmd.Flags().StringVar(&flags.promptFile, "file", "", "Input text file")
+	cmd.Flags().StringVar(&flags.instructions, "instructions", "", "Session instructions")
+	cmd.Flags().StringVar(&flags.voice, "voice", "marin", "Voice name")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", defaultRealtimeModel, "Realtime model name")
+	cmd.Flags().BoolVar(&flags.speak, "speak", false, "Play the replies as they arrive")
+	cmd.Flags().StringVar(&flags.vad, "vad", "server_vad", "Turn detection: server_vad, semantic_vad, none")
+	cmd.Flags().Float64Var(&flags.vadThreshold, "vad-threshold", 0, "Speech threshold, 0 - 1 (server_vad)")
+	cmd.Flags().IntVar(&flags.vadSilence, "vad-silence", 0, "Silence that ends a turn, in ms (server_vad)")
+	cmd.Flags().IntVar(&flags.vadPrefix, "vad-prefix", 0, "Audio kept before speech, in ms (server_vad)")
+	cmd.Flags().StringVar(&flags.vadEagerness, "vad-eagerness", "", "How soon to answer: low, medium, high, auto (semantic_vad)")
+
+	return cmd
+}
+
+func runRealtime(cmd *cobra.Command, args []string, flags *realtimeFlags) error {
+	// Get the user turn from an audio file or text
+	var text string
+	if flags.audio != "" {
+		if len(args) > 0 || flags.promptFile != "" {
+			return common.WriteError(cmd, "conflicting_flags", "cannot use --audio together with text")
+		}
+		if _, err := os.Stat(flags.audio); err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("file not found: %s", flags.audio))
+		}
+	} else {
+		var err error
+		text, err = getText(args, flags.promptFile, cmd.InOrStdin())
+		if err != nil {
+			return common.WriteError(cmd, "missing_input", "no input provided, use --audio, a positional argument, --file flag, or pipe text from stdin")
+		}
+	}
+
+	// Validate output or speak
+	if flags.output == "" && !flags.speak {
+		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag or --speak")
+	}
+	var outputPath string
+	if flags.output != "" {
+		outputPath = common.DefaultExt(flags.output, ".wav")
+		if ext := strings.ToLower(filepath.Ext(outputPath)); ext != ".wav" && ext != ".pcm" {
+			return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported format '%s', supported: wav, pcm", ext))
+		}
+	}
+
+	// Validate turn detection
+	turnDetection, err := realtimeTurnDetection(cmd, flags)
+	if err != nil {
+		return err
+	}
+
+	// Check API key
+	apiKey := config.GetAPIKey("OPENAI_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("OPENAI_API_KEY"))
+	}
+
+	// Open the audio as 24 kHz mono PCM
+	var audio io.Reader
+	if flags.audio != "" {
+		f, err := os.Open(flags.audio)
+		if err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read file: %s", err.Error()))
+		}
+		defer f.Close()
+		stream, err := common.DecodeAudio(f, filepath.Ext(flags.audio), common.DecodeOptions{SampleRate: sttRealtimeRate})
+		if err != nil {
+			return common.WriteError(cmd, "invalid_audio", fmt.Sprintf("cannot decode audio: %s", err.Error()))
+		}
+		audio = common.ConvertPCM(stream, sttRealtimeRate)
+	}
+
+	// Connect
+	baseURL := os.Getenv("OPENAI_BASE_URL")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	wsURL := strings.Replace(strings.TrimSuffix(baseURL, "/"), "https://", "wss://", 1)
+	wsURL = strings.Replace(wsURL, "http://", "ws://", 1)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+apiKey)
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL+"/realtime?model="+url.QueryEscape(flags.model), header)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return common.WriteError(cmd, "invalid_api_key", "API key is invalid or revoked")
+		}
+		return common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot connect to WebSocket: %s", err.Error()))
+	}
+	defer conn.Close()
+
+	session := map[string]any{
+		"type":              "realtime",
+		"output_modalities": []string{"audio"},
+		"audio": map[string]any{
+			"input": map[string]any{
+				"format":         map[string]any{"type": "audio/pcm", "rate": sttRealtimeRate},
+				"turn_detection": turnDetection,
+			},
+			"output": map[string]any{
+				"format": map[string]any{"type": "audio/pcm", "rate": sttRealtimeRate},
+				"voice":  flags.voice,
+			},
+		},
+	}
+	if flags.instructions != "" {
+		session["instructions"] = flags.instructions
+	}
+
+	var player *common.StreamPlayer
+	if flags.speak {
+		player = common.NewStreamPlayer(".pcm", common.DecodeOptions{SampleRate: sttRealtimeRate})
+		defer player.Abort(nil)
+	}
+
+	replies := &realtimeReplies{conn: conn, player: player, changed: make(chan struct{}, 1), done: make(chan struct{})}
+	if err := replies.send(map[string]any{"type": "session.update", "session": session}); err != nil {
+		return common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot send session update: %s", err.Error()))
+	}
+	go replies.read()
+	defer replies.stop()
+
+	// Send the user turn. Text and audio without turn detection are
+	// answered when asked; with turn detection the server answers each
+	// turn it finds
+	grace := time.Duration(0)
+	if audio == nil {
+		item := map[string]any{
+			"type": "conversation.item.create",
+			"item": map[string]any{
+				"type":    "message",
+				"role":    "user",
+				"content": []map[string]any{{"type": "input_text", "text": text}},
+			},
+		}
+		if err := replies.send(item); err != nil {
+			return common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot send text: %s", err.Error()))
+		}
+		if err := replies.respond(); err != nil {
+			return common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot request a response: %s", err.Error()))
+		}
+	} else {
+		if turnDetection != nil {
+			audio = io.MultiReader(audio, io.LimitReader(zeroReader{}, int64(realtimeTrailingSilence*sttRealtimeRate)*2))
+			grace = realtimeTurnGrace
+		}
+		if code, err := replies.sendAudio(audio); err != nil {
+			return common.WriteError(cmd, code, err.Error())
+		}
+		if turnDetection == nil {
+			replies.update(func() { replies.flushing = true })
+			if err := replies.send(map[string]any{"type": "input_audio_buffer.commit"}); err != nil {
+				return common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot commit audio: %s", err.Error()))
+			}
+		}
+	}
+
+	if code, err := replies.wait(grace); err != nil {
+		return common.WriteError(cmd, code, err.Error())
+	}
+	replies.stop()
+	replies.mu.Lock()
+	defer replies.mu.Unlock()
+	if len(replies.audio) == 0 {
+		return common.WriteError(cmd, "no_audio", "no audio reply received")
+	}
+
+	// Save the replies
+	var absPath string
+	if outputPath != "" {
+		data := replies.audio
+		if strings.ToLower(filepath.Ext(outputPath)) == ".wav" {
+			data = common.PCMToWAV(data, common.AudioFormat{SampleRate: sttRealtimeRate, Channels: 1, Encoding: common.PCMS16LE})
+		}
+		if absPath, err = common.WriteOutput(outputPath, data, common.OutputVars{}); err != nil {
+			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		}
+	}
+	if player != nil {
+		if err := player.Close(); err != nil {
+			return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", err.Error()))
+		}
+	}
+
+	result := realtimeResponse{
+		Success:  true,
+		Text:     strings.Join(replies.transcripts, "\n"),
+		File:     absPath,
+		Model:    flags.model,
+		Voice:    flags.voice,
+		Duration: math.Round(float64(len(replies.audio))/(sttRealtimeRate*2)*100) / 100,
+		Usage:    &replies.usage,
+	}
+	if replies.responses > 1 {
+		result.Responses = replies.responses
+	}
+	return common.WriteSuccess(cmd, result)
+}
+
+// realtimeTurnDetection validates the --vad flags and returns the
+// turn_detection of the session, nil for none.
+func realtimeTurnDetection(cmd *cobra.Command, flags *realtimeFlags) (map[string]any, error) {
+	if !slices.Contains(realtimeVADTypes, flags.vad) {
+		return nil, common.WriteError(cmd, "invalid_parameter", fmt.Sprintf("invalid --vad '%s', supported: %s", flags.vad, strings.Join(realtimeVADTypes, ", ")))
+	}
+	serverFlags := cmd.Flags().Changed("vad-threshold") || cmd.Flags().Changed("vad-silence") || cmd.Flags().Changed("vad-prefix")
+	if serverFlags && flags.vad != "server_vad" {
+		return nil, common.WriteError(cmd, "conflicting_flags", "--vad-threshold, --vad-silence and --vad-prefix require --vad server_vad")
+	}
+	if flags.vadEagerness != "" && flags.vad != "semantic_vad" {
+		return nil, common.WriteError(cmd, "conflicting_flags", "--vad-eagerness requires --vad semantic_vad")
+	}
+
+	switch flags.vad {
+	case "none":
+		return nil, nil
+	case "semantic_vad":
+		detection := map[string]any{"type": "semantic_vad"}
+		if flags.vadEagerness != "" {
+			if !slices.Contains(realtimeEagerness, flags.vadEagerness) {
+				return nil, common.WriteError(cmd, "invalid_parameter", fmt.Sprintf("invalid --vad-eagerness '%s', supported: %s", flags.vadEagerness, strings.Join(realtimeEagerness, ", ")))
+			}
+			detection["eagerness"] = flags.vadEagerness
+		}
+		return detection, nil
+	}
+
+	detection := map[string]any{"type": "server_vad"}
+	if cmd.Flags().Changed("vad-threshold") {
+		if flags.vadThreshold < 0 || flags.vadThreshold > 1 {
+			return nil, common.WriteError(cmd, "invalid_parameter", "--vad-threshold must be between 0 and 1")
+		}
+		detection["threshold"] = flags.vadThreshold
+	}
+	if cmd.Flags().Changed("vad-silence") {
+		if flags.vadSilence <= 0 {
+			return nil, common.WriteError(cmd, "invalid_parameter", "--vad-silence must be positive")
+		}
+		detection["silence_duration_ms"] = flags.vadSilence
+	}
+	if cmd.Flags().Changed("vad-prefix") {
+		if flags.vadPrefix < 0 {
+			return nil, common.WriteError(cmd, "invalid_parameter", "--vad-prefix cannot be negative")
+		}
+		detection["prefix_padding_ms"] = flags.vadPrefix
+	}
+	return detection, nil
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// realtimeReplies sends the user turn and collects the replies of a
+// realtime session.
+type realtimeReplies struct {
+	conn    *websocket.Conn
+	player  *common.StreamPlayer
+	writeMu sync.Mutex
+
+	mu          sync.Mutex
+	changed     chan struct{}
+	done        chan struct{} // closed when read returns
+	audio       []byte
+	transcripts []string
+	usage       realtimeUsage
+	responses   int  // responses done
+	expected    int  // responses on the way that have not started
+	active      int  // responses started and not done
+	speaking    bool // the server hears speech
+	stops       int  // speech stops whose commit has not arrived
+	flushing    bool // the final commit was sent
+	closed      bool
+	code        string
+	failure     error
+}
+
+func (r *realtimeReplies) send(v any) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	return r.conn.WriteJSON(v)
+}
+
+// respond asks the server for a response to the conversation so far.
+func (r *realtimeReplies) respond() error {
+	r.update(func() { r.expected++ })
+	return r.send(map[string]any{"type": "response.create"})
+}
+
+// sendAudio appends the audio to the input buffer, ~100ms per message.
+func (r *realtimeReplies) sendAudio(audio io.Reader) (string, error) {
+	buf := make([]byte, sttRealtimeRate*2/10)
+	for r.err() == nil {
+		n, readErr := audio.Read(buf)
+		if n > 0 {
+			appendMsg := map[string]any{
+				"type":  "input_audio_buffer.append",
+				"audio": base64.StdEncoding.EncodeToString(buf[:n]),
+			}
+			if err := r.send(appendMsg); err != nil {
+				return "websocket_error", fmt.Errorf("cannot send audio: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			return "", nil
+		}
+		if readErr != nil {
+			return "invalid_audio", fmt.Errorf("cannot read audio: %w", readErr)
+		}
+	}
+	return "", nil
+}
+
+// read handles server events until the connection closes.
+func (r *realtimeReplies) read() {
+	defer close(r.done)
+	for {
+		_, message, err := r.conn.ReadMessage()
+		if err != nil {
+			r.update(func() { r.closed = true })
+			return
+		}
+
+		var event struct {
+			Type       string `json:"type"`
+			Delta      string `json:"delta"`
+			Transcript string `json:"transcript"`
+			Error      struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+			Response struct {
+				Status        string `json:"status"`
+				StatusDetails struct {
+					Error struct {
+						Message string `json:"message"`
+					} `json:"error"`
+				} `json:"status_details"`
+				Usage realtimeUsage `json:"usage"`
+			} `json:"response"`
+		}
+		if jsonErr := json.Unmarshal(message, &event); jsonErr != nil {
+			continue
+		}
+
+		switch event.Type {
+		case "input_audio_buffer.speech_started":
+			r.update(func() { r.speaking = true })
+		case "input_audio_buffer.speech_stopped":
+			r.update(func() {
+				r.speaking = false
+				r.stops++
+			})
+		case "input_audio_buffer.committed":
+			// The server commits and answers after every speech stop; any
+			// other commit answers the final one, which needs a response
+			r.mu.Lock()
+			final := r.stops == 0 && r.flushing
+			if r.stops > 0 {
+				r.stops--
+				r.expected++
+			}
+			r.mu.Unlock()
+			if final {
+				if err := r.respond(); err != nil {
+					r.fail("websocket_error", fmt.Errorf("cannot request a response: %w", err))
+				}
+			}
+		case "response.created":
+			r.update(func() {
+				if r.expected > 0 {
+					r.expected--
+				}
+				r.active++
+			})
+		case "response.output_audio.delta":
+			data, decodeErr := base64.StdEncoding.DecodeString(event.Delta)
+			if decodeErr != nil {
+				continue
+			}
+			r.mu.Lock()
+			r.audio = append(r.audio, data...)
+			r.mu.Unlock()
+			if r.player != nil {
+				if _, err := r.player.Write(data); err != nil {
+					r.fail("playback_error", fmt.Errorf("cannot play audio: %w", err))
+				}
+			}
+		case "response.output_audio_transcript.done":
+			if text := strings.TrimSpace(event.Transcript); text != "" {
+				r.mu.Lock()
+				r.transcripts = append(r.transcripts, text)
+				r.mu.Unlock()
+			}
+		case "response.done":
+			r.update(func() {
+				if r.active > 0 {
+					r.active--
+				}
+				r.responses++
+				r.usage.InputTokens += event.Response.Usage.InputTokens
+				r.usage.OutputTokens += event.Response.Usage.OutputTokens
+				r.usage.TotalTokens += event.Response.Usage.TotalTokens
+			})
+			if event.Response.Status == "failed" {
+				r.fail("api_error", fmt.Errorf("response failed: %s", event.Response.StatusDetails.Error.Message))
+			}
+		case "error":
+			r.fail("api_error", fmt.Errorf("%s", event.Error.Message))
+		}
+		r.update(func() {})
+	}
+}
+
+// stop closes the connection and waits for read to return, so that the
+// player is no longer written to.
+func (r *realtimeReplies) stop() {
+	r.conn.Close()
+	<-r.done
+}
+
+// update changes the state and wakes wait.
+func (r *realtimeReplies) update(change func()) {
+	r.mu.Lock()
+	change()
+	r.mu.Unlock()
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (r *realtimeReplies) fail(code string, err error) {
+	r.update(func() {
+		if r.failure == nil {
+			r.code, r.failure = code, err
+		}
+	})
+}
+
+func (r *realtimeReplies) err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed && r.failure == nil {
+		return fmt.Errorf("connection closed")
+	}
+	return r.failure
+}
+
+// wait returns once every response asked for is done and, with a grace
+// period, the server has been quiet for that long since. It also returns
+// when the session fails or closes, or after realtimeIdleTimeout without
+// events.
+func (r *realtimeReplies) wait(grace time.Duration) (string, error) {
+	for {
+		r.mu.Lock()
+		code, failure, closed := r.code, r.failure, r.closed
+		busy := r.speaking || r.stops > 0 || r.expected > 0 || r.active > 0
+		replied := r.responses > 0
+		r.mu.Unlock()
+		if failure != nil {
+			return code, failure
+		}
+		if closed {
+			return "", nil
+		}
+
+		var quiet <-chan time.Time
+		if !busy && replied {
+			if grace == 0 {
+				return "", nil
+			}
+			quiet = time.After(grace)
+		}
+		select {
+		case <-r.changed:
+		case <-quiet:
+			return "", nil
+		case <-time.After(realtimeIdleTimeout):
+			return "", nil
+		}
+	}
+}
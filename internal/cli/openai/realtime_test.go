@@ -0,0 +1,239 @@
+package openai
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/gorilla/websocket"
+)
+
+func TestRealtime_Validation(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "hello.wav")
+	os.WriteFile(audio, common.PCMToWAV(make([]byte, 4800), common.AudioFormat{SampleRate: 24000, Channels: 1, Encoding: common.PCMS16LE}), 0644)
+
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"missing input", []string{"-o", "out.wav"}, "missing_input"},
+		{"audio and text", []string{"Hello", "--audio", audio, "-o", "out.wav"}, "conflicting_flags"},
+		{"audio not found", []string{"--audio", filepath.Join(dir, "nope.wav"), "-o", "out.wav"}, "file_not_found"},
+		{"missing output", []string{"Hello"}, "missing_output"},
+		{"unsupported format", []string{"Hello", "-o", "out.mp3"}, "unsupported_format"},
+		{"invalid vad", []string{"Hello", "-o", "out.wav", "--vad", "client_vad"}, "invalid_parameter"},
+		{"invalid threshold", []string{"Hello", "-o", "out.wav", "--vad-threshold", "2"}, "invalid_parameter"},
+		{"invalid silence", []string{"Hello", "-o", "out.wav", "--vad-silence", "0"}, "invalid_parameter"},
+		{"threshold without server vad", []string{"Hello", "-o", "out.wav", "--vad", "semantic_vad", "--vad-threshold", "0.5"}, "conflicting_flags"},
+		{"eagerness without semantic vad", []string{"Hello", "-o", "out.wav", "--vad-eagerness", "low"}, "conflicting_flags"},
+		{"invalid eagerness", []string{"Hello", "-o", "out.wav", "--vad", "semantic_vad", "--vad-eagerness", "eager"}, "invalid_parameter"},
+		{"missing api key", []string{"Hello", "-o", "out.wav"}, "missing_api_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			cmd := newRealtimeCmd()
+			cmd.SetIn(strings.NewReader(""))
+			_, stderr, err := executeCommand(cmd, tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+// realtimeServer fakes a realtime session. With turn detection it detects
+// one turn once speechBytes of audio have arrived; otherwise it answers
+// response.create. Each reply is 0.5s of audio. It returns the client
+// events received and the bytes of audio appended.
+func realtimeServer(t *testing.T, speechBytes int) func() ([]map[string]any, int) {
+	var mu sync.Mutex
+	var received []map[string]any
+	appended := 0
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/realtime" || r.URL.Query().Get("model") != defaultRealtimeModel {
+			http.NotFound(w, r)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reply := func() {
+			audio := base64.StdEncoding.EncodeToString(make([]byte, 24000))
+			conn.WriteJSON(map[string]any{"type": "response.created"})
+			conn.WriteJSON(map[string]any{"type": "response.output_audio.delta", "delta": audio})
+			conn.WriteJSON(map[string]any{"type": "response.output_audio_transcript.done", "transcript": "It is noon."})
+			conn.WriteJSON(map[string]any{"type": "response.done", "response": map[string]any{
+				"status": "completed",
+				"usage":  map[string]any{"input_tokens": 20, "output_tokens": 30, "total_tokens": 50},
+			}})
+		}
+		for {
+			var msg map[string]any
+			if conn.ReadJSON(&msg) != nil {
+				return
+			}
+			mu.Lock()
+			if msg["type"] != "input_audio_buffer.append" {
+				received = append(received, msg)
+			}
+			mu.Unlock()
+
+			switch msg["type"] {
+			case "input_audio_buffer.append":
+				data, _ := base64.StdEncoding.DecodeString(msg["audio"].(string))
+				mu.Lock()
+				before := appended
+				appended += len(data)
+				detected := speechBytes > 0 && before < speechBytes && appended >= speechBytes
+				mu.Unlock()
+				if before == 0 && speechBytes > 0 {
+					conn.WriteJSON(map[string]any{"type": "input_audio_buffer.speech_started"})
+				}
+				if detected {
+					conn.WriteJSON(map[string]any{"type": "input_audio_buffer.speech_stopped"})
+					conn.WriteJSON(map[string]any{"type": "input_audio_buffer.committed"})
+					reply()
+				}
+			case "input_audio_buffer.commit":
+				conn.WriteJSON(map[string]any{"type": "input_audio_buffer.committed"})
+			case "response.create":
+				reply()
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+
+	grace := realtimeTurnGrace
+	realtimeTurnGrace = 50 * time.Millisecond
+	t.Cleanup(func() { realtimeTurnGrace = grace })
+
+	return func() ([]map[string]any, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return received, appended
+	}
+}
+
+func TestRealtime_Audio(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	state := realtimeServer(t, 32000)
+	dir := t.TempDir()
+	// 1s of 16 kHz input, resampled to 24 kHz
+	input := filepath.Join(dir, "question.wav")
+	os.WriteFile(input, common.PCMToWAV(make([]byte, 32000), common.AudioFormat{SampleRate: 16000, Channels: 1, Encoding: common.PCMS16LE}), 0644)
+	output := filepath.Join(dir, "reply.wav")
+
+	stdout, stderr, err := executeCommand(newRealtimeCmd(), "--audio", input, "-o", output, "--voice", "cedar", "--instructions", "Answer briefly.", "--vad-silence", "300")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp realtimeResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if !resp.Success || resp.Text != "It is noon." || resp.File != output || resp.Voice != "cedar" || resp.Duration != 0.5 || resp.Responses != 0 || resp.Usage == nil || resp.Usage.TotalTokens != 50 {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	received, appended := state()
+	var types []string
+	for _, msg := range received {
+		types = append(types, msg["type"].(string))
+	}
+	// The server answers the turn it detects; nothing is committed or
+	// asked for
+	if strings.Join(types, ",") != "session.update" {
+		t.Errorf("unexpected client events: %v", types)
+	}
+	data, _ := json.Marshal(received[0])
+	for _, want := range []string{`"voice":"cedar"`, `"instructions":"Answer briefly."`, `"silence_duration_ms":300`, `"type":"server_vad"`, `"output_modalities":["audio"]`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected session update to contain %s, got %s", want, data)
+		}
+	}
+	// 1s of speech and 1s of trailing silence at 24 kHz
+	if appended < 95990 || appended > 96010 {
+		t.Errorf("expected about 96000 bytes of audio, got %d", appended)
+	}
+
+	info, err := os.Stat(output)
+	if err != nil || info.Size() != 44+24000 {
+		t.Errorf("expected 0.5s of WAV, got %v, %v", info, err)
+	}
+}
+
+func TestRealtime_AudioWithoutVAD(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	state := realtimeServer(t, 0)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "question.wav")
+	os.WriteFile(input, common.PCMToWAV(make([]byte, 4800), common.AudioFormat{SampleRate: 24000, Channels: 1, Encoding: common.PCMS16LE}), 0644)
+	output := filepath.Join(dir, "reply.pcm")
+
+	stdout, stderr, err := executeCommand(newRealtimeCmd(), "--audio", input, "-o", output, "--vad", "none")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"text":"It is noon."`) {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	received, appended := state()
+	var types []string
+	for _, msg := range received {
+		types = append(types, msg["type"].(string))
+	}
+	if strings.Join(types, ",") != "session.update,input_audio_buffer.commit,response.create" {
+		t.Errorf("unexpected client events: %v", types)
+	}
+	if data, _ := json.Marshal(received[0]); !strings.Contains(string(data), `"turn_detection":null`) {
+		t.Errorf("expected turn detection off, got %s", data)
+	}
+	if appended != 4800 {
+		t.Errorf("expected 4800 bytes of audio, got %d", appended)
+	}
+	if info, err := os.Stat(output); err != nil || info.Size() != 24000 {
+		t.Errorf("expected 0.5s of PCM, got %v, %v", info, err)
+	}
+}
+
+func TestRealtime_Text(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	state := realtimeServer(t, 0)
+	output := filepath.Join(t.TempDir(), "reply.wav")
+
+	stdout, stderr, err := executeCommand(newRealtimeCmd(), "What", "time", "is", "it?", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"usage":{"input_tokens":20,"output_tokens":30,"total_tokens":50}`) {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	received, _ := state()
+	if len(received) != 3 || received[1]["type"] != "conversation.item.create" || received[2]["type"] != "response.create" {
+		t.Fatalf("unexpected client events: %v", received)
+	}
+	if data, _ := json.Marshal(received[1]); !strings.Contains(string(data), `"text":"What time is it?"`) {
+		t.Errorf("unexpected item: %s", data)
+	}
+}
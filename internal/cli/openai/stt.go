@@ -1,16 +1,24 @@
 package openai
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/WHQ25/rawgenai/internal/config"
+	"github.com/gorilla/websocket"
 	oai "github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 	"github.com/spf13/cobra"
@@ -35,11 +43,17 @@ var sttResponseFormats = map[string]oai.AudioResponseFormat{
 	"text":         oai.AudioResponseFormatText,
 	"srt":          oai.AudioResponseFormatSRT,
 	"vtt":          oai.AudioResponseFormatVTT,
+	"ass":          oai.AudioResponseFormatVerboseJSON,
+	"ttml":         oai.AudioResponseFormatVerboseJSON,
 	"verbose_json": oai.AudioResponseFormatVerboseJSON,
 }
 
 const maxFileSize = 25 * 1024 * 1024 // 25 MB
 
+// Larger WAV and MP3 files are transcribed in chunks of at most
+// sttChunkDuration seconds; ten minutes of 16 kHz mono WAV is about 19 MB.
+var sttChunkDuration = 600.0
+
 var errFileNotFound = errors.New("file_not_found")
 var errMissingFile = errors.New("missing_file")
 
@@ -52,6 +66,8 @@ type sttFlags struct {
 	verbose     bool
 	format      string
 	output      string
+	subtitles   common.SubtitleOptions
+	stream      common.StreamFlags
 }
 
 type sttResponse struct {
@@ -61,6 +77,7 @@ type sttResponse struct {
 	Language string       `json:"language,omitempty"`
 	Duration float64      `json:"duration,omitempty"`
 	Segments []sttSegment `json:"segments,omitempty"`
+	Chunks   int          `json:"chunks,omitempty"`
 	File     string       `json:"file,omitempty"`
 }
 
@@ -92,13 +109,19 @@ func newSTTCmd() *cobra.Command {
 	cmd.Flags().StringVar(&flags.prompt, "prompt", "", "Text to guide the model's style or terminology")
 	cmd.Flags().Float64Var(&flags.temperature, "temperature", 0, "Sampling temperature (0-1)")
 	cmd.Flags().BoolVarP(&flags.verbose, "verbose", "v", false, "Include timestamps and segments")
-	cmd.Flags().StringVar(&flags.format, "format", "json", "Output format (json, text, srt, vtt)")
-	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file (required for srt/vtt formats)")
+	cmd.Flags().StringVar(&flags.format, "format", "json", "Output format (json, text, srt, vtt, ass, ttml)")
+	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file (required for srt/vtt/ass/ttml formats)")
+	common.AddSubtitleFlags(cmd, &flags.subtitles)
+	common.AddStreamFlags(cmd, &flags.stream)
 
 	return cmd
 }
 
 func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
+	if flags.stream.Enabled {
+		return runSTTStream(cmd, args, flags)
+	}
+
 	// Get audio file from args, --file flag, or stdin
 	audioFile, audioReader, cleanup, err := getAudioInput(args, flags.file, cmd.InOrStdin())
 	if err != nil {
@@ -112,6 +135,7 @@ func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
 	}
 
 	// Validate audio format (if we have a file path)
+	var chunks []common.AudioChunk
 	if audioFile != "" {
 		ext := strings.ToLower(filepath.Ext(audioFile))
 		if !supportedAudioFormats[ext] {
@@ -124,7 +148,9 @@ func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
 			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot access file: %s", err.Error()))
 		}
 		if info.Size() > maxFileSize {
-			return common.WriteError(cmd, "file_too_large", fmt.Sprintf("file size %d bytes exceeds 25 MB limit", info.Size()))
+			if chunks, err = splitAudioFile(audioFile, ext); err != nil {
+				return common.WriteError(cmd, "file_too_large", fmt.Sprintf("file size %d bytes exceeds 25 MB limit and cannot be split: %s", info.Size(), err.Error()))
+			}
 		}
 	}
 
@@ -136,18 +162,27 @@ func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
 	// Validate output format
 	responseFormat, ok := sttResponseFormats[flags.format]
 	if !ok {
-		return common.WriteError(cmd, "invalid_format", fmt.Sprintf("invalid format '%s', supported: json, text, srt, vtt", flags.format))
+		return common.WriteError(cmd, "invalid_format", fmt.Sprintf("invalid format '%s', supported: json, text, srt, vtt, ass, ttml", flags.format))
 	}
 
-	// srt/vtt formats require output file
-	if (flags.format == "srt" || flags.format == "vtt") && flags.output == "" {
+	// Subtitle formats require output file
+	generateSubtitles := common.IsTimedFormat(flags.format)
+	if generateSubtitles && flags.output == "" {
 		return common.WriteError(cmd, "missing_output", fmt.Sprintf("--%s format requires --output flag", flags.format))
 	}
+	if err := flags.subtitles.Validate(); err != nil {
+		return common.WriteError(cmd, "invalid_parameter", err.Error())
+	}
+
+	// The file format follows --format for subtitles, the output extension otherwise
+	outputFormat := flags.format
+	if !generateSubtitles {
+		outputFormat = common.TranscriptFormat(flags.output, common.TranscriptText)
+	}
 
-	// For srt/vtt, we'll use verbose_json and generate the format ourselves
+	// For subtitles, we'll use verbose_json and generate the format ourselves
 	// because openai-go doesn't support non-JSON response formats
-	generateSubtitles := flags.format == "srt" || flags.format == "vtt"
-	if generateSubtitles {
+	if common.IsTimedFormat(outputFormat) {
 		responseFormat = oai.AudioResponseFormatVerboseJSON
 	}
 
@@ -167,156 +202,139 @@ func runSTT(cmd *cobra.Command, args []string, flags *sttFlags) error {
 		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("OPENAI_API_KEY"))
 	}
 
-	// Open file for API
-	var fileReader io.Reader
-	var fileToClose *os.File
-	if audioReader != nil {
-		fileReader = audioReader
-	} else {
-		f, err := os.Open(audioFile)
-		if err != nil {
-			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot open file: %s", err.Error()))
-		}
-		fileToClose = f
-		defer f.Close()
-		fileReader = f
-	}
-
 	// Call OpenAI API
 	client := oai.NewClient(option.WithAPIKey(apiKey))
 	ctx := context.Background()
 
-	params := oai.AudioTranscriptionNewParams{
-		File:           fileReader,
-		Model:          oai.AudioModel(flags.model),
-		ResponseFormat: responseFormat,
-		Temperature:    oai.Float(flags.temperature),
-	}
-
-	if flags.language != "" {
-		params.Language = oai.String(flags.language)
-	}
-
-	if flags.prompt != "" {
-		params.Prompt = oai.String(flags.prompt)
-	}
-
-	// Avoid unused variable warning
-	_ = fileToClose
-
-	resp, err := client.Audio.Transcriptions.New(ctx, params)
-	if err != nil {
-		return handleAPIError(cmd, err)
+	newParams := func(file io.Reader, format oai.AudioResponseFormat) oai.AudioTranscriptionNewParams {
+		params := oai.AudioTranscriptionNewParams{
+			File:           file,
+			Model:          oai.AudioModel(flags.model),
+			ResponseFormat: format,
+			Temperature:    oai.Float(flags.temperature),
+		}
+		if flags.language != "" {
+			params.Language = oai.String(flags.language)
+		}
+		if flags.prompt != "" {
+			params.Prompt = oai.String(flags.prompt)
+		}
+		return params
 	}
 
-	// Handle output based on format
-	if generateSubtitles {
-		// Generate SRT or VTT from verbose_json segments
-		absPath, err := filepath.Abs(flags.output)
-		if err != nil {
-			absPath = flags.output
+	var transcript *common.Transcript
+	if chunks != nil {
+		// Timed segments let the chunks be stitched at the overlap; models
+		// without them are stitched by their text
+		chunkFormat := oai.AudioResponseFormatJSON
+		if responseFormat == oai.AudioResponseFormatVerboseJSON || flags.model == "whisper-1" {
+			chunkFormat = oai.AudioResponseFormatVerboseJSON
 		}
-
-		var subtitleContent string
-		if flags.format == "srt" {
-			subtitleContent = generateSRT(resp.Segments)
-		} else {
-			subtitleContent = generateVTT(resp.Segments)
+		parts, err := common.TranscribeChunks(ctx, chunks, common.DefaultChunkConcurrency, func(ctx context.Context, i int, chunk common.AudioChunk) (*common.Transcript, error) {
+			file := oai.File(bytes.NewReader(chunk.Data), fmt.Sprintf("chunk-%d.wav", i+1), "audio/wav")
+			resp, err := client.Audio.Transcriptions.New(ctx, newParams(file, chunkFormat))
+			if err != nil {
+				return nil, err
+			}
+			return newTranscript(resp), nil
+		})
+		if err != nil {
+			return handleAPIError(cmd, err)
 		}
-
-		if err := os.WriteFile(absPath, []byte(subtitleContent), 0644); err != nil {
-			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		transcript = common.MergeTranscripts(chunks, parts)
+	} else {
+		// Open file for API
+		fileReader := audioReader
+		if fileReader == nil {
+			f, err := os.Open(audioFile)
+			if err != nil {
+				return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot open file: %s", err.Error()))
+			}
+			defer f.Close()
+			fileReader = f
 		}
 
-		result := sttResponse{
-			Success:  true,
-			File:     absPath,
-			Model:    flags.model,
-			Language: resp.Language,
+		resp, err := client.Audio.Transcriptions.New(ctx, newParams(fileReader, responseFormat))
+		if err != nil {
+			return handleAPIError(cmd, err)
 		}
-		return common.WriteSuccess(cmd, result)
+		transcript = newTranscript(resp)
 	}
 
 	// Build response
 	result := sttResponse{
 		Success:  true,
-		Text:     resp.Text,
+		Text:     transcript.Text,
 		Model:    flags.model,
-		Language: resp.Language,
+		Language: transcript.Language,
+	}
+	if len(chunks) > 1 {
+		result.Chunks = len(chunks)
 	}
 
 	// Add verbose info if available
 	if flags.verbose || flags.format == "verbose_json" {
-		result.Duration = resp.Duration
-		if len(resp.Segments) > 0 {
-			result.Segments = make([]sttSegment, len(resp.Segments))
-			for i, seg := range resp.Segments {
-				result.Segments[i] = sttSegment{
-					Start: seg.Start,
-					End:   seg.End,
-					Text:  seg.Text,
-				}
-			}
+		result.Duration = transcript.Duration
+		for _, seg := range transcript.Segments {
+			result.Segments = append(result.Segments, sttSegment{
+				Start: seg.Start,
+				End:   seg.End,
+				Text:  seg.Text,
+			})
 		}
 	}
 
-	// Write to output file if specified (for json/text formats)
+	// Write to output file if specified
 	if flags.output != "" {
-		absPath, err := filepath.Abs(flags.output)
+		absPath, err := common.WriteTranscript(flags.output, transcript, outputFormat, flags.subtitles)
 		if err != nil {
-			absPath = flags.output
+			return common.WriteError(cmd, common.TranscriptErrorCode(err), fmt.Sprintf("cannot write output file: %s", err.Error()))
 		}
+		result.File = absPath
 
-		if err := os.WriteFile(absPath, []byte(resp.Text), 0644); err != nil {
-			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		// Subtitles only report the file
+		if generateSubtitles {
+			result.Text = ""
 		}
-
-		result.File = absPath
 	}
 
 	return common.WriteSuccess(cmd, result)
 }
 
-// formatTimeSRT formats seconds as SRT timestamp (HH:MM:SS,mmm)
-func formatTimeSRT(seconds float64) string {
-	h := int(seconds) / 3600
-	m := (int(seconds) % 3600) / 60
-	s := int(seconds) % 60
-	ms := int((seconds - float64(int(seconds))) * 1000)
-	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
-}
-
-// formatTimeVTT formats seconds as VTT timestamp (HH:MM:SS.mmm)
-func formatTimeVTT(seconds float64) string {
-	h := int(seconds) / 3600
-	m := (int(seconds) % 3600) / 60
-	s := int(seconds) % 60
-	ms := int((seconds - float64(int(seconds))) * 1000)
-	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
-}
-
-// generateSRT generates SRT subtitle content from segments
-func generateSRT(segments []oai.TranscriptionSegment) string {
-	var sb strings.Builder
-	for i, seg := range segments {
-		sb.WriteString(fmt.Sprintf("%d\n", i+1))
-		sb.WriteString(fmt.Sprintf("%s --> %s\n", formatTimeSRT(seg.Start), formatTimeSRT(seg.End)))
-		sb.WriteString(strings.TrimSpace(seg.Text))
-		sb.WriteString("\n\n")
+// splitAudioFile splits a WAV or MP3 file that is too large for a single
+// request into chunks.
+func splitAudioFile(path, ext string) ([]common.AudioChunk, error) {
+	if !common.CanSplitAudio(ext) {
+		return nil, fmt.Errorf("only WAV and MP3 files are split")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
-	return sb.String()
+	defer f.Close()
+	return common.SplitAudio(f, ext, sttChunkDuration, common.DefaultChunkOverlap)
 }
 
-// generateVTT generates WebVTT subtitle content from segments
-func generateVTT(segments []oai.TranscriptionSegment) string {
-	var sb strings.Builder
-	sb.WriteString("WEBVTT\n\n")
-	for _, seg := range segments {
-		sb.WriteString(fmt.Sprintf("%s --> %s\n", formatTimeVTT(seg.Start), formatTimeVTT(seg.End)))
-		sb.WriteString(strings.TrimSpace(seg.Text))
-		sb.WriteString("\n\n")
+// newTranscript converts a transcription response. Segment confidence is
+// derived from the average log probability of its tokens.
+func newTranscript(resp *oai.AudioTranscriptionNewResponseUnion) *common.Transcript {
+	t := &common.Transcript{
+		Text:     resp.Text,
+		Language: resp.Language,
+		Duration: resp.Duration,
+	}
+	for _, seg := range resp.Segments {
+		segment := common.TranscriptSegment{Start: seg.Start, End: seg.End, Text: seg.Text}
+		if seg.AvgLogprob != 0 {
+			segment.Confidence = math.Round(math.Exp(seg.AvgLogprob)*1000) / 1000
+		}
+		t.Segments = append(t.Segments, segment)
+	}
+	for _, w := range resp.Words {
+		t.Words = append(t.Words, common.TranscriptWord{Text: w.Word, Start: w.Start, End: w.End})
 	}
-	return sb.String()
+	t.Normalize()
+	return t
 }
 
 func getAudioInput(args []string, filePath string, stdin io.Reader) (file string, reader io.Reader, cleanup func(), err error) {
@@ -349,26 +367,275 @@ func getAudioInput(args []string, filePath string, stdin io.Reader) (file string
 			}
 		}
 
-		// Read stdin into temp file (API requires file)
-		tmpFile, err := os.CreateTemp("", "stt_stdin_*.mp3")
+		// Read stdin into temp file (API requires file), named after its
+		// format so that large input is split like a file
+		tmpFile, err := common.SpoolAudio(stdin, "stt_stdin_", ".mp3")
 		if err != nil {
-			return "", nil, nil, fmt.Errorf("cannot create temp file: %w", err)
+			return "", nil, nil, err
 		}
 
-		if _, err := io.Copy(tmpFile, stdin); err != nil {
-			tmpFile.Close()
-			os.Remove(tmpFile.Name())
-			return "", nil, nil, fmt.Errorf("cannot read stdin: %w", err)
+		cleanup := func() {
+			os.Remove(tmpFile)
 		}
 
-		tmpFile.Close()
+		return tmpFile, nil, cleanup, nil
+	}
 
-		cleanup := func() {
-			os.Remove(tmpFile.Name())
+	return "", nil, nil, fmt.Errorf("%w: no audio file provided, use positional argument, --file flag, or pipe from stdin", errMissingFile)
+}
+
+// Models the realtime transcription API accepts; --stream uses
+// sttStreamModel unless --model is given.
+var realtimeSTTModels = map[string]bool{
+	"whisper-1":              true,
+	"gpt-4o-transcribe":      true,
+	"gpt-4o-mini-transcribe": true,
+}
+
+const sttStreamModel = "gpt-4o-mini-transcribe"
+
+// sttRealtimeRate is the sample rate of the PCM the realtime API takes.
+const sttRealtimeRate = 24000
+
+// sttFlushTimeout bounds the wait for the last transcripts after the end of
+// the audio.
+var sttFlushTimeout = 15 * time.Second
+
+// runSTTStream transcribes PCM or WAV audio from stdin as it arrives with
+// a realtime transcription session, writing partial and final results as
+// NDJSON events. The server commits the audio at every pause; the rest is
+// committed when stdin ends.
+func runSTTStream(cmd *cobra.Command, args []string, flags *sttFlags) error {
+	if len(args) > 0 || flags.file != "" {
+		return common.WriteError(cmd, "incompatible_stream", "--stream reads audio from stdin, do not pass an audio file")
+	}
+	if err := flags.stream.Validate(); err != nil {
+		return common.WriteError(cmd, "invalid_parameter", err.Error())
+	}
+	if !cmd.Flags().Changed("model") {
+		flags.model = sttStreamModel
+	}
+	if !realtimeSTTModels[flags.model] {
+		return common.WriteError(cmd, "incompatible_stream", fmt.Sprintf("--stream requires a realtime model: whisper-1, gpt-4o-transcribe or %s", sttStreamModel))
+	}
+	outputFormat := common.TranscriptFormat(flags.output, common.TranscriptText)
+	if common.IsTimedFormat(outputFormat) {
+		return common.WriteError(cmd, "missing_timestamps", fmt.Sprintf("--output .%s needs timestamps, which --stream transcripts do not have", outputFormat))
+	}
+
+	apiKey := config.GetAPIKey("OPENAI_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("OPENAI_API_KEY"))
+	}
+
+	audio, err := flags.stream.OpenPCMStream(cmd.InOrStdin(), sttRealtimeRate)
+	if err != nil {
+		return common.WriteError(cmd, "invalid_audio", fmt.Sprintf("cannot read audio from stdin: %s", err.Error()))
+	}
+
+	baseURL := os.Getenv("OPENAI_BASE_URL")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	wsURL := strings.Replace(strings.TrimSuffix(baseURL, "/"), "https://", "wss://", 1)
+	wsURL = strings.Replace(wsURL, "http://", "ws://", 1)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+apiKey)
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"/realtime?intent=transcription", header)
+	if err != nil {
+		return common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot connect to WebSocket: %s", err.Error()))
+	}
+	defer conn.Close()
+
+	transcription := map[string]any{"model": flags.model}
+	if flags.language != "" {
+		transcription["language"] = flags.language
+	}
+	if flags.prompt != "" {
+		transcription["prompt"] = flags.prompt
+	}
+	sessionUpdate := map[string]any{
+		"type": "session.update",
+		"session": map[string]any{
+			"type": "transcription",
+			"audio": map[string]any{
+				"input": map[string]any{
+					"format":         map[string]any{"type": "audio/pcm", "rate": sttRealtimeRate},
+					"transcription":  transcription,
+					"turn_detection": map[string]any{"type": "server_vad"},
+				},
+			},
+		},
+	}
+	if err := conn.WriteJSON(sessionUpdate); err != nil {
+		return common.WriteError(cmd, "websocket_error", fmt.Sprintf("cannot send session update: %s", err.Error()))
+	}
+
+	// Read results in background. Committed audio becomes an item whose
+	// transcript arrives as deltas and then completes; the state lets the
+	// end of the stream wait for every item
+	events := common.NewTranscriptStream(cmd.OutOrStdout())
+	session := &realtimeSession{changed: make(chan struct{}, 1), partial: map[string]string{}, pending: map[string]bool{}}
+	go session.read(conn, events)
+
+	// Forward audio as it arrives, ~100ms per message, until stdin ends or
+	// the server stops the session
+	buf := make([]byte, sttRealtimeRate*2/10)
+	for session.err() == nil {
+		n, readErr := audio.Read(buf)
+		if n > 0 {
+			appendMsg := map[string]any{
+				"type":  "input_audio_buffer.append",
+				"audio": base64.StdEncoding.EncodeToString(buf[:n]),
+			}
+			if writeErr := conn.WriteJSON(appendMsg); writeErr != nil {
+				return common.WriteError(cmd, "stream_error", fmt.Sprintf("cannot send audio: %s", writeErr.Error()))
+			}
 		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return common.WriteError(cmd, "stream_error", fmt.Sprintf("cannot read audio from stdin: %s", readErr.Error()))
+		}
+	}
 
-		return tmpFile.Name(), nil, cleanup, nil
+	// Commit what is left and wait for every transcript
+	session.startFlush()
+	_ = conn.WriteJSON(map[string]any{"type": "input_audio_buffer.commit"})
+	if err := session.wait(sttFlushTimeout); err != nil {
+		return common.WriteError(cmd, "server_error", fmt.Sprintf("recognition failed: %s", err.Error()))
 	}
 
-	return "", nil, nil, fmt.Errorf("%w: no audio file provided, use positional argument, --file flag, or pipe from stdin", errMissingFile)
+	var file string
+	if flags.output != "" {
+		file, err = common.WriteTranscript(flags.output, events.Transcript(), outputFormat, flags.subtitles)
+		if err != nil {
+			return common.WriteError(cmd, common.TranscriptErrorCode(err), fmt.Sprintf("cannot write output file: %s", err.Error()))
+		}
+	}
+	return events.Done(file)
+}
+
+// realtimeSession tracks the items of a realtime transcription session.
+type realtimeSession struct {
+	mu       sync.Mutex
+	changed  chan struct{}
+	partial  map[string]string // item ID to the transcript so far
+	pending  map[string]bool   // committed items without a transcript yet
+	stops    int               // speech stops whose commit has not arrived
+	flushing bool              // the final commit was sent
+	flushed  bool              // the final commit was answered
+	closed   bool
+	failure  error
+}
+
+// read handles server events until the connection closes.
+func (s *realtimeSession) read(conn *websocket.Conn, events *common.TranscriptStream) {
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			s.update(func() { s.closed = true })
+			return
+		}
+
+		var event struct {
+			Type       string `json:"type"`
+			ItemID     string `json:"item_id"`
+			Delta      string `json:"delta"`
+			Transcript string `json:"transcript"`
+			Error      struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if jsonErr := json.Unmarshal(message, &event); jsonErr != nil {
+			continue
+		}
+
+		switch event.Type {
+		case "input_audio_buffer.speech_stopped":
+			s.update(func() { s.stops++ })
+		case "input_audio_buffer.committed":
+			// The server commits after every speech stop; any other commit
+			// answers the final one
+			s.update(func() {
+				s.pending[event.ItemID] = true
+				if s.stops > 0 {
+					s.stops--
+				} else if s.flushing {
+					s.flushed = true
+				}
+			})
+		case "conversation.item.input_audio_transcription.delta":
+			s.mu.Lock()
+			s.partial[event.ItemID] += event.Delta
+			text := s.partial[event.ItemID]
+			s.mu.Unlock()
+			_ = events.Partial(text)
+		case "conversation.item.input_audio_transcription.completed":
+			_ = events.Final(common.TranscriptSegment{Text: strings.TrimSpace(event.Transcript)}, "", nil)
+			s.update(func() {
+				delete(s.partial, event.ItemID)
+				delete(s.pending, event.ItemID)
+			})
+		case "conversation.item.input_audio_transcription.failed", "error":
+			s.update(func() {
+				// The final commit finds nothing left when the server
+				// committed all audio at the last pause
+				if event.Error.Code == "input_audio_buffer_commit_empty" {
+					s.flushed = true
+					return
+				}
+				delete(s.pending, event.ItemID)
+				if s.failure == nil {
+					s.failure = fmt.Errorf("%s", event.Error.Message)
+				}
+			})
+		}
+	}
+}
+
+// update changes the state and wakes wait.
+func (s *realtimeSession) update(change func()) {
+	s.mu.Lock()
+	change()
+	s.mu.Unlock()
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (s *realtimeSession) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed && s.failure == nil {
+		return fmt.Errorf("connection closed")
+	}
+	return s.failure
+}
+
+func (s *realtimeSession) startFlush() {
+	s.update(func() { s.flushing = true })
+}
+
+// wait returns once the final commit has been answered and every item has
+// its transcript, or the session failed or closed.
+func (s *realtimeSession) wait(timeout time.Duration) error {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		failure, done := s.failure, s.closed || s.flushed && len(s.pending) == 0
+		s.mu.Unlock()
+		if failure != nil || done {
+			return failure
+		}
+		select {
+		case <-s.changed:
+		case <-deadline:
+			return nil
+		}
+	}
 }
@@ -1,15 +1,23 @@
 package openai
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/gorilla/websocket"
+	oai "github.com/openai/openai-go/v3"
 )
 
 func TestSTT_MissingFile(t *testing.T) {
@@ -451,101 +459,289 @@ func TestSTT_PromptNotSupportedByModel(t *testing.T) {
 	}
 }
 
-func TestFormatTimeSRT(t *testing.T) {
-	tests := []struct {
-		seconds  float64
-		expected string
-	}{
-		{0, "00:00:00,000"},
-		{1.5, "00:00:01,500"},
-		{61.25, "00:01:01,250"},
-		{3661.5, "01:01:01,500"},
-		{7325.125, "02:02:05,125"},
+func TestSTT_InvalidMaxLines(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "audio.mp3")
+	os.WriteFile(tmpFile, []byte("fake audio content"), 0644)
+
+	cmd := newSTTCmd()
+	_, stderr, cmdErr := executeCommand(cmd, tmpFile, "--format", "ass", "-o", "out.ass", "--max-lines", "0")
+	if cmdErr == nil {
+		t.Fatal("expected error for --max-lines 0")
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.expected, func(t *testing.T) {
-			result := formatTimeSRT(tt.seconds)
-			if result != tt.expected {
-				t.Errorf("formatTimeSRT(%v) = %s, want %s", tt.seconds, result, tt.expected)
-			}
-		})
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_parameter" {
+		t.Errorf("expected error code 'invalid_parameter', got: %s", errorObj["code"])
 	}
 }
 
-func TestFormatTimeVTT(t *testing.T) {
-	tests := []struct {
-		seconds  float64
-		expected string
-	}{
-		{0, "00:00:00.000"},
-		{1.5, "00:00:01.500"},
-		{61.25, "00:01:01.250"},
-		{3661.5, "01:01:01.500"},
-		{7325.125, "02:02:05.125"},
+func TestSTT_WritesSubtitles(t *testing.T) {
+	var responseFormat string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		responseFormat = r.FormValue("response_format")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text":"Hello world.","language":"english","duration":2,"segments":[{"start":0,"end":2,"text":" Hello world."}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "audio.mp3")
+	os.WriteFile(audio, []byte("fake audio content"), 0644)
+	output := filepath.Join(dir, "out.ttml")
+
+	cmd := newSTTCmd()
+	stdout, stderr, err := executeCommand(cmd, audio, "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
 	}
+	if responseFormat != "verbose_json" {
+		t.Errorf("expected verbose_json for a subtitle output, got %q", responseFormat)
+	}
+	if !strings.Contains(stdout, output) {
+		t.Errorf("expected the file in the response, got %s", stdout)
+	}
+	data, _ := os.ReadFile(output)
+	if !strings.Contains(string(data), `<p begin="00:00:00.000" end="00:00:02.000">Hello world.</p>`) {
+		t.Errorf("unexpected TTML:\n%s", data)
+	}
+}
 
-	for _, tt := range tests {
-		t.Run(tt.expected, func(t *testing.T) {
-			result := formatTimeVTT(tt.seconds)
-			if result != tt.expected {
-				t.Errorf("formatTimeVTT(%v) = %s, want %s", tt.seconds, result, tt.expected)
-			}
-		})
+func TestSTT_TranscribesLargeFileInChunks(t *testing.T) {
+	var mu sync.Mutex
+	var files []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(32 << 20)
+		_, header, _ := r.FormFile("file")
+		mu.Lock()
+		files = append(files, header.Filename)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text":"Part.","duration":60,"segments":[{"start":10,"end":12,"text":" Part."}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+
+	defer func(d float64) { sttChunkDuration = d }(sttChunkDuration)
+	sttChunkDuration = 60
+
+	// 26 MB of 44.1 kHz stereo silence is about 155 seconds
+	audio := filepath.Join(t.TempDir(), "long.wav")
+	format := common.AudioFormat{SampleRate: 44100, Channels: 2, Encoding: common.PCMS16LE}
+	os.WriteFile(audio, common.PCMToWAV(make([]byte, 26*1024*1024), format), 0644)
+
+	cmd := newSTTCmd()
+	stdout, stderr, err := executeCommand(cmd, audio, "--verbose")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected 3 chunk requests, got %q", files)
+	}
+
+	var resp sttResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp.Chunks != 3 || resp.Text != "Part. Part. Part." {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+	if len(resp.Segments) != 3 || resp.Segments[1].Start <= 60 {
+		t.Errorf("expected segments shifted by the chunk offsets, got %+v", resp.Segments)
 	}
 }
 
-func TestGenerateSRT(t *testing.T) {
-	// Test SRT format structure: index, timestamp, text
-	// Using formatTimeSRT which is already tested above
+func TestSTT_StreamRejectsFile(t *testing.T) {
+	cmd := newSTTCmd()
+	_, stderr, err := executeCommand(cmd, "audio.mp3", "--stream")
+	if err == nil {
+		t.Fatal("expected error for --stream with a file")
+	}
+	if !strings.Contains(stderr, "incompatible_stream") {
+		t.Errorf("expected incompatible_stream, got: %s", stderr)
+	}
+}
+
+func TestSTT_StreamRequiresRealtimeModel(t *testing.T) {
+	cmd := newSTTCmd()
+	cmd.SetIn(strings.NewReader(""))
+	_, stderr, err := executeCommand(cmd, "--stream", "--model", "gpt-4o-transcribe-diarize")
+	if err == nil {
+		t.Fatal("expected error for a model without realtime support")
+	}
+	if !strings.Contains(stderr, "incompatible_stream") {
+		t.Errorf("expected incompatible_stream, got: %s", stderr)
+	}
+}
+
+func TestSTT_StreamTimedOutput(t *testing.T) {
+	cmd := newSTTCmd()
+	cmd.SetIn(strings.NewReader(""))
+	_, stderr, err := executeCommand(cmd, "--stream", "-o", "out.srt")
+	if err == nil {
+		t.Fatal("expected error for subtitles without timestamps")
+	}
+	if !strings.Contains(stderr, "missing_timestamps") {
+		t.Errorf("expected missing_timestamps, got: %s", stderr)
+	}
+}
+
+func TestSTT_Stream(t *testing.T) {
+	var mu sync.Mutex
+	var session map[string]any
+	var appended, audioBytes int
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/realtime" || r.URL.Query().Get("intent") != "transcription" {
+			http.NotFound(w, r)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		mu.Lock()
+		conn.ReadJSON(&session)
+		mu.Unlock()
+
+		send := func(item string, events ...map[string]any) {
+			if item == "item_1" {
+				conn.WriteJSON(map[string]any{"type": "input_audio_buffer.speech_stopped"})
+			}
+			conn.WriteJSON(map[string]any{"type": "input_audio_buffer.committed", "item_id": item})
+			for _, e := range events {
+				e["item_id"] = item
+				conn.WriteJSON(e)
+			}
+		}
+		for {
+			var msg map[string]any
+			if conn.ReadJSON(&msg) != nil {
+				return
+			}
+			switch msg["type"] {
+			case "input_audio_buffer.append":
+				data, _ := base64.StdEncoding.DecodeString(msg["audio"].(string))
+				mu.Lock()
+				appended++
+				audioBytes += len(data)
+				first := appended == 1
+				mu.Unlock()
+				if first {
+					send("item_1",
+						map[string]any{"type": "conversation.item.input_audio_transcription.delta", "delta": "Hel"},
+						map[string]any{"type": "conversation.item.input_audio_transcription.delta", "delta": "lo."},
+						map[string]any{"type": "conversation.item.input_audio_transcription.completed", "transcript": "Hello."})
+				}
+			case "input_audio_buffer.commit":
+				send("item_2",
+					map[string]any{"type": "conversation.item.input_audio_transcription.delta", "delta": "World."},
+					map[string]any{"type": "conversation.item.input_audio_transcription.completed", "transcript": "World."})
+			}
+		}
+	}))
+	defer server.Close()
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
 
-	// Verify the expected SRT structure
-	expectedLines := []string{
-		"1",
-		"00:00:00,000 --> 00:00:02,500",
-		"Hello world",
+	output := filepath.Join(t.TempDir(), "out.txt")
+	cmd := newSTTCmd()
+	cmd.SetIn(bytes.NewReader(make([]byte, 32000)))
+	stdout, stderr, err := executeCommand(cmd, "--stream", "--language", "en", "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
 	}
 
-	// Build a sample SRT entry manually to verify format
-	var sb strings.Builder
-	sb.WriteString("1\n")
-	sb.WriteString(formatTimeSRT(0) + " --> " + formatTimeSRT(2.5) + "\n")
-	sb.WriteString("Hello world\n\n")
+	mu.Lock()
+	defer mu.Unlock()
+	input := session["session"].(map[string]any)["audio"].(map[string]any)["input"].(map[string]any)
+	if input["transcription"].(map[string]any)["model"] != sttStreamModel || input["transcription"].(map[string]any)["language"] != "en" {
+		t.Errorf("unexpected session update: %v", session)
+	}
+	// One second at 16 kHz is 48000 bytes at 24 kHz
+	if audioBytes < 47990 || audioBytes > 48010 {
+		t.Errorf("expected about 48000 bytes of audio, got %d", audioBytes)
+	}
 
-	result := sb.String()
-	for _, line := range expectedLines {
-		if !strings.Contains(result, line) {
-			t.Errorf("SRT format missing expected line: %s, got: %s", line, result)
+	var events []common.TranscriptEvent
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		var e common.TranscriptEvent
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid event line %q", line)
 		}
+		events = append(events, e)
+	}
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type+":"+e.Text)
+	}
+	want := "partial:Hel partial:Hello. final:Hello. partial:World. final:World. done:Hello. World."
+	if strings.Join(types, " ") != want {
+		t.Errorf("expected events %q, got %q", want, strings.Join(types, " "))
+	}
+	if events[len(events)-1].File != output {
+		t.Errorf("expected done event with the output file, got %+v", events[len(events)-1])
+	}
+	if data, _ := os.ReadFile(output); strings.TrimSpace(string(data)) != "Hello. World." {
+		t.Errorf("unexpected output file: %q", data)
 	}
 }
 
-func TestGenerateVTT(t *testing.T) {
-	// Test VTT header and format
-	segments := []struct {
-		Start float64
-		End   float64
-		Text  string
-	}{
-		{0, 2.5, " Hello world "},
+func TestSTT_StreamReadError(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+
+	cmd := newSTTCmd()
+	cmd.SetIn(io.MultiReader(bytes.NewReader(make([]byte, 3200)), iotest.ErrReader(errors.New("device lost"))))
+	_, stderr, err := executeCommand(cmd, "--stream")
+	if err == nil {
+		t.Fatal("expected error when stdin fails")
+	}
+	if !strings.Contains(stderr, `"code":"stream_error"`) || !strings.Contains(stderr, "device lost") {
+		t.Errorf("expected stream_error with the read error, got: %s", stderr)
 	}
+}
 
-	var sb strings.Builder
-	sb.WriteString("WEBVTT\n\n")
-	for _, seg := range segments {
-		sb.WriteString(formatTimeVTT(seg.Start))
-		sb.WriteString(" --> ")
-		sb.WriteString(formatTimeVTT(seg.End))
-		sb.WriteString("\n")
-		sb.WriteString(strings.TrimSpace(seg.Text))
-		sb.WriteString("\n\n")
+func TestNewTranscript(t *testing.T) {
+	var resp oai.AudioTranscriptionNewResponseUnion
+	body := `{"text":" Hi there.","language":"english","duration":1.5,
+		"segments":[{"start":0,"end":1.5,"text":" Hi there.","avg_logprob":-0.1}],
+		"words":[{"word":"Hi","start":0,"end":0.4},{"word":"there.","start":0.5,"end":1.5}]}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatal(err)
 	}
 
-	result := sb.String()
-	if !strings.HasPrefix(result, "WEBVTT\n\n") {
-		t.Error("VTT must start with WEBVTT header")
+	transcript := newTranscript(&resp)
+	if transcript.Text != "Hi there." || transcript.Language != "english" || transcript.Duration != 1.5 {
+		t.Errorf("unexpected transcript: %+v", transcript)
+	}
+	if len(transcript.Segments) != 1 || transcript.Segments[0].Text != "Hi there." {
+		t.Fatalf("unexpected segments: %+v", transcript.Segments)
+	}
+	if c := transcript.Segments[0].Confidence; c < 0.9 || c > 0.91 {
+		t.Errorf("expected confidence from avg_logprob, got %v", c)
 	}
-	if !strings.Contains(result, "00:00:00.000 --> 00:00:02.500\nHello world") {
-		t.Errorf("VTT format incorrect, got: %s", result)
+	if len(transcript.Words) != 2 || transcript.Words[1].Text != "there." {
+		t.Errorf("unexpected words: %+v", transcript.Words)
 	}
 }
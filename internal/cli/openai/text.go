@@ -0,0 +1,169 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	oai "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/responses"
+	"github.com/spf13/cobra"
+)
+
+// Response type
+type textResponse struct {
+	Success    bool   `json:"success"`
+	Text       any    `json:"text"`
+	Model      string `json:"model"`
+	ResponseID string `json:"response_id,omitempty"`
+}
+
+// Flag struct
+type textFlags struct {
+	promptFile  string
+	system      string
+	systemFile  string
+	schema      string
+	continueID  string
+	model       string
+	temperature float64
+	maxTokens   int
+}
+
+// Command
+var textCmd = newTextCmd()
+
+func newTextCmd() *cobra.Command {
+	flags := &textFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "text [prompt]",
+		Short: "Generate text using OpenAI Responses API",
+		Long: `Generate text using OpenAI Responses API.
+
+The prompt is read from the arguments, --prompt-file or stdin. Use --schema for
+output in JSON matching a schema, and --continue with the response_id of a
+previous call to carry on the conversation.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runText(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.promptFile, "prompt-file", "", "Input prompt file")
+	cmd.Flags().StringVar(&flags.system, "system", "", "System instructions")
+	cmd.Flags().StringVar(&flags.systemFile, "system-file", "", "System instructions file")
+	cmd.Flags().StringVar(&flags.schema, "schema", "", "JSON schema of the output (file or inline JSON)")
+	cmd.Flags().StringVarP(&flags.continueID, "continue", "c", "", "Previous response ID for multi-turn conversation")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", "gpt-4.1", "Model name")
+	cmd.Flags().Float64VarP(&flags.temperature, "temperature", "t", 1, "Sampling temperature (0-2)")
+	cmd.Flags().IntVar(&flags.maxTokens, "max-tokens", 0, "Maximum output tokens (0 = model default)")
+
+	return cmd
+}
+
+func runText(cmd *cobra.Command, args []string, flags *textFlags) error {
+	// Get prompt
+	prompt, err := getText(args, flags.promptFile, cmd.InOrStdin())
+	if err != nil {
+		return common.WriteError(cmd, "missing_prompt", err.Error())
+	}
+
+	// Get system instructions
+	if flags.system != "" && flags.systemFile != "" {
+		return common.WriteError(cmd, "conflicting_flags", "cannot use --system and --system-file together")
+	}
+	system := flags.system
+	if flags.systemFile != "" {
+		data, err := os.ReadFile(flags.systemFile)
+		if err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read system file: %s", err.Error()))
+		}
+		system = strings.TrimSpace(string(data))
+	}
+
+	// Validate sampling
+	if flags.temperature < 0 || flags.temperature > 2 {
+		return common.WriteError(cmd, "invalid_temperature", "temperature must be between 0 and 2")
+	}
+	if flags.maxTokens < 0 {
+		return common.WriteError(cmd, "invalid_max_tokens", "max-tokens must not be negative")
+	}
+
+	// Validate schema
+	var schema map[string]any
+	if flags.schema != "" {
+		if schema, err = common.LoadJSONSchema(flags.schema); err != nil {
+			return common.WriteError(cmd, "invalid_schema", err.Error())
+		}
+	}
+
+	// Check API key
+	apiKey := config.GetAPIKey("OPENAI_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("OPENAI_API_KEY"))
+	}
+
+	// Build request params
+	params := responses.ResponseNewParams{
+		Model: oai.ResponsesModel(flags.model),
+		Input: responses.ResponseNewParamsInputUnion{
+			OfString: oai.String(prompt),
+		},
+	}
+	if system != "" {
+		params.Instructions = oai.String(system)
+	}
+	if cmd.Flags().Changed("temperature") {
+		params.Temperature = oai.Float(flags.temperature)
+	}
+	if flags.maxTokens > 0 {
+		params.MaxOutputTokens = oai.Int(int64(flags.maxTokens))
+	}
+	if schema != nil {
+		params.Text = responses.ResponseTextConfigParam{
+			Format: responses.ResponseFormatTextConfigParamOfJSONSchema("output", schema),
+		}
+	}
+
+	// Add previous response ID for multi-turn conversation
+	if flags.continueID != "" {
+		params.PreviousResponseID = oai.String(flags.continueID)
+	}
+
+	// Call API
+	client := oai.NewClient(option.WithAPIKey(apiKey))
+	ctx := context.Background()
+
+	resp, err := client.Responses.New(ctx, params)
+	if err != nil {
+		return handleAPIError(cmd, err)
+	}
+
+	text := strings.TrimSpace(resp.OutputText())
+	if text == "" {
+		return common.WriteError(cmd, "no_text", "no text generated in response")
+	}
+
+	result := textResponse{
+		Success:    true,
+		Text:       text,
+		Model:      flags.model,
+		ResponseID: resp.ID,
+	}
+	if schema != nil {
+		var output any
+		if err := json.Unmarshal([]byte(text), &output); err != nil {
+			return common.WriteError(cmd, "invalid_response", fmt.Sprintf("output is not valid JSON: %s", err.Error()))
+		}
+		result.Text = output
+	}
+
+	return common.WriteSuccess(cmd, result)
+}
@@ -0,0 +1,156 @@
+package openai
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+)
+
+func TestText_Validation(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"missing prompt", nil, "missing_prompt"},
+		{"system and system file", []string{"Hi", "--system", "Be brief", "--system-file", "sys.txt"}, "conflicting_flags"},
+		{"missing system file", []string{"Hi", "--system-file", filepath.Join(dir, "sys.txt")}, "file_not_found"},
+		{"temperature too high", []string{"Hi", "--temperature", "2.5"}, "invalid_temperature"},
+		{"negative max tokens", []string{"Hi", "--max-tokens", "-1"}, "invalid_max_tokens"},
+		{"invalid schema", []string{"Hi", "--schema", "{oops"}, "invalid_schema"},
+		{"missing api key", []string{"Hi"}, "missing_api_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			t.Setenv("OPENAI_API_KEY", "")
+			cmd := newTextCmd()
+			cmd.SetIn(strings.NewReader(""))
+			_, stderr, err := executeCommand(cmd, tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+// textServer answers the Responses API and records the last request body
+func textServer(t *testing.T, output string) func() map[string]any {
+	var mu sync.Mutex
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		body, _ = io.ReadAll(r.Body)
+		mu.Unlock()
+		text, _ := json.Marshal(output)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"resp_2","object":"response","status":"completed","output":[{"type":"message","id":"msg_1","role":"assistant","status":"completed","content":[{"type":"output_text","text":` + string(text) + `,"annotations":[]}]}]}`))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+
+	return func() map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		var req map[string]any
+		json.Unmarshal(body, &req)
+		return req
+	}
+}
+
+func TestText_Generate(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	request := textServer(t, "A fox in the snow, golden hour.")
+	system := filepath.Join(t.TempDir(), "system.txt")
+	os.WriteFile(system, []byte("You write image prompts.\n"), 0644)
+
+	stdout, stderr, err := executeCommand(newTextCmd(), "Write", "a prompt", "--system-file", system, "-c", "resp_1", "--temperature", "0.7", "--max-tokens", "200")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp textResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if !resp.Success || resp.Text != "A fox in the snow, golden hour." || resp.Model != "gpt-4.1" || resp.ResponseID != "resp_2" {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	req := request()
+	want := map[string]any{
+		"input":                "Write a prompt",
+		"instructions":         "You write image prompts.",
+		"previous_response_id": "resp_1",
+		"temperature":          0.7,
+		"max_output_tokens":    float64(200),
+	}
+	for key, value := range want {
+		if req[key] != value {
+			t.Errorf("expected %s = %v, got %v", key, value, req[key])
+		}
+	}
+}
+
+func TestText_Defaults(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	request := textServer(t, "Hello!")
+
+	cmd := newTextCmd()
+	cmd.SetIn(strings.NewReader("Say hello\n"))
+	if _, stderr, err := executeCommand(cmd); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+
+	req := request()
+	if req["input"] != "Say hello" {
+		t.Errorf("expected prompt from stdin, got %v", req["input"])
+	}
+	for _, key := range []string{"instructions", "temperature", "max_output_tokens", "previous_response_id", "text"} {
+		if _, ok := req[key]; ok {
+			t.Errorf("expected %s to be left to the API default, got %v", key, req[key])
+		}
+	}
+}
+
+func TestText_Schema(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	request := textServer(t, `{"lines":[{"speaker":"Joe","text":"Hi"}]}`)
+
+	stdout, stderr, err := executeCommand(newTextCmd(), "Write a short dialogue", "--schema", `{"type":"object","properties":{"lines":{"type":"array"}}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"text":{"lines":[{"speaker":"Joe","text":"Hi"}]}`) {
+		t.Errorf("expected JSON output, got: %s", stdout)
+	}
+
+	text, _ := json.Marshal(request()["text"])
+	for _, want := range []string{`"type":"json_schema"`, `"name":"output"`, `"lines":{"type":"array"}`} {
+		if !strings.Contains(string(text), want) {
+			t.Errorf("expected text config to contain %s, got %s", want, text)
+		}
+	}
+}
+
+func TestText_SchemaInvalidOutput(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	textServer(t, "not json")
+
+	_, stderr, err := executeCommand(newTextCmd(), "Hi", "--schema", `{"type":"object"}`)
+	if err == nil || !strings.Contains(stderr, `"code":"invalid_response"`) {
+		t.Errorf("expected invalid_response, got: %s", stderr)
+	}
+}
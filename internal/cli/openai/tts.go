@@ -25,6 +25,10 @@ var supportedFormats = map[string]oai.AudioSpeechNewParamsResponseFormat{
 	".pcm":  oai.AudioSpeechNewParamsResponseFormatPCM,
 }
 
+// maxInputChars is the longest input the speech endpoint accepts; longer
+// text is synthesized in chunks.
+const maxInputChars = 4096
+
 type ttsFlags struct {
 	output       string
 	promptFile   string
@@ -40,6 +44,7 @@ type ttsResponse struct {
 	File    string `json:"file,omitempty"`
 	Model   string `json:"model,omitempty"`
 	Voice   string `json:"voice,omitempty"`
+	Chunks  int    `json:"chunks,omitempty"`
 }
 
 var ttsCmd = newTTSCmd()
@@ -87,7 +92,7 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 	var ext string
 
 	if flags.output != "" {
-		outputPath = flags.output
+		outputPath = common.DefaultExt(flags.output, ".mp3")
 		ext = strings.ToLower(filepath.Ext(outputPath))
 	} else {
 		// --speak only: use temp file with mp3 format
@@ -123,50 +128,63 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("OPENAI_API_KEY"))
 	}
 
+	// Long text is split on sentence boundaries and the audio joined
+	chunks := common.SplitText(text, maxInputChars, nil)
+	if len(chunks) > 1 && !common.CanJoinAudio(ext) {
+		if useTempFile {
+			os.Remove(outputPath)
+		}
+		return common.WriteError(cmd, "text_too_long", fmt.Sprintf("text longer than %d characters can only be written as mp3, wav or pcm", maxInputChars))
+	}
+
 	// Call OpenAI API
 	client := oai.NewClient(option.WithAPIKey(apiKey))
 	ctx := context.Background()
 
-	params := oai.AudioSpeechNewParams{
-		Model:          oai.SpeechModel(flags.model),
-		Voice:          oai.AudioSpeechNewParamsVoice(flags.voice),
-		Input:          text,
-		ResponseFormat: responseFormat,
-		Speed:          oai.Float(flags.speed),
-	}
+	parts, err := common.SynthesizeChunks(ctx, chunks, common.DefaultChunkConcurrency, func(ctx context.Context, _ int, chunk string) ([]byte, error) {
+		params := oai.AudioSpeechNewParams{
+			Model:          oai.SpeechModel(flags.model),
+			Voice:          oai.AudioSpeechNewParamsVoice(flags.voice),
+			Input:          chunk,
+			ResponseFormat: responseFormat,
+			Speed:          oai.Float(flags.speed),
+		}
 
-	// Add instructions if provided (only for gpt-4o-mini-tts)
-	if flags.instructions != "" {
-		params.Instructions = oai.String(flags.instructions)
-	}
+		// Add instructions if provided (only for gpt-4o-mini-tts)
+		if flags.instructions != "" {
+			params.Instructions = oai.String(flags.instructions)
+		}
 
-	resp, err := client.Audio.Speech.New(ctx, params)
+		resp, err := client.Audio.Speech.New(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		return io.ReadAll(resp.Body)
+	})
 	if err != nil {
 		if useTempFile {
 			os.Remove(outputPath)
 		}
 		return handleAPIError(cmd, err)
 	}
-	defer resp.Body.Close()
-
-	// Get absolute path for output
-	absPath, err := filepath.Abs(outputPath)
-	if err != nil {
-		absPath = outputPath
-	}
 
-	// Write to file
-	outFile, err := os.Create(absPath)
+	audio, err := common.JoinAudio(ext, parts)
 	if err != nil {
 		if useTempFile {
 			os.Remove(outputPath)
 		}
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
+		return common.WriteError(cmd, "join_error", fmt.Sprintf("cannot join audio chunks: %s", err.Error()))
 	}
-	defer outFile.Close()
 
-	_, err = io.Copy(outFile, resp.Body)
+	// Get absolute path for output
+	absPath, err := filepath.Abs(outputPath)
 	if err != nil {
+		absPath = outputPath
+	}
+
+	// Write to file
+	if _, err := common.WriteOutput(absPath, audio, common.OutputVars{}); err != nil {
 		if useTempFile {
 			os.Remove(outputPath)
 		}
@@ -175,8 +193,8 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 
 	// Play audio if --speak is set
 	if flags.speak {
-		outFile.Close() // Close before playing
-		if err := common.PlayFile(absPath); err != nil {
+		// PCM output is 24kHz 16-bit mono
+		if err := common.PlayFileWithOptions(absPath, common.DecodeOptions{SampleRate: 24000}); err != nil {
 			if useTempFile {
 				os.Remove(absPath)
 			}
@@ -194,6 +212,9 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		Model:   flags.model,
 		Voice:   flags.voice,
 	}
+	if len(chunks) > 1 {
+		result.Chunks = len(chunks)
+	}
 	if useTempFile {
 		result.File = "" // Don't report temp file path
 	}
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
@@ -340,3 +342,65 @@ func TestTTS_InstructionsNotSupportedByModel(t *testing.T) {
 		})
 	}
 }
+
+func TestTTS_LongTextChunked(t *testing.T) {
+	var inputs []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		inputs = append(inputs, body["input"].(string))
+		mu.Unlock()
+		w.Write([]byte{1, 0})
+	}))
+	defer server.Close()
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+
+	sentence := strings.Repeat("word ", 199) + "end. "
+	text := strings.Repeat(sentence, 5)
+	output := filepath.Join(t.TempDir(), "long.pcm")
+
+	cmd := newTTSCmd()
+	stdout, stderr, err := executeCommand(cmd, text, "-o", output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+
+	if len(inputs) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(inputs))
+	}
+	for _, input := range inputs {
+		if len(input) > maxInputChars || !strings.HasSuffix(input, "end.") {
+			t.Errorf("chunk not split on a sentence boundary: %d chars", len(input))
+		}
+	}
+
+	var resp map[string]any
+	json.Unmarshal([]byte(stdout), &resp)
+	if resp["chunks"] != float64(2) {
+		t.Errorf("expected chunks 2, got: %v", resp["chunks"])
+	}
+	data, _ := os.ReadFile(output)
+	if len(data) != 4 {
+		t.Errorf("expected the joined audio of both chunks, got %d bytes", len(data))
+	}
+}
+
+func TestTTS_LongTextUnjoinableFormat(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-key")
+
+	cmd := newTTSCmd()
+	_, stderr, err := executeCommand(cmd, strings.Repeat("Hello there. ", 400), "-o", "out.opus")
+	if err == nil {
+		t.Fatal("expected error for long text in an unjoinable format")
+	}
+
+	var resp map[string]any
+	json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp)
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "text_too_long" {
+		t.Errorf("expected error code 'text_too_long', got: %s", errorObj["code"])
+	}
+}
@@ -27,39 +27,45 @@ func init() {
 }
 
 func handleAPIError(cmd *cobra.Command, err error) error {
+	code, message := apiErrorInfo(err)
+	return common.WriteError(cmd, code, message)
+}
+
+// apiErrorInfo maps an OpenAI API error to an error code and message.
+func apiErrorInfo(err error) (string, string) {
 	var apiErr *oai.Error
 	if errors.As(err, &apiErr) {
 		switch apiErr.StatusCode {
 		case 400:
 			if strings.Contains(strings.ToLower(apiErr.Message), "content") || strings.Contains(strings.ToLower(apiErr.Message), "policy") {
-				return common.WriteError(cmd, "content_policy", apiErr.Message)
+				return "content_policy", apiErr.Message
 			}
 			if strings.Contains(strings.ToLower(apiErr.Message), "model") {
-				return common.WriteError(cmd, "invalid_model", apiErr.Message)
+				return "invalid_model", apiErr.Message
 			}
-			return common.WriteError(cmd, "invalid_request", apiErr.Message)
+			return "invalid_request", apiErr.Message
 		case 401:
-			return common.WriteError(cmd, "invalid_api_key", "API key is invalid or revoked")
+			return "invalid_api_key", "API key is invalid or revoked"
 		case 403:
-			return common.WriteError(cmd, "region_not_supported", "Region/country not supported")
+			return "region_not_supported", "Region/country not supported"
 		case 404:
-			return common.WriteError(cmd, "video_not_found", "Video not found")
+			return "video_not_found", "Video not found"
 		case 429:
 			if strings.Contains(apiErr.Message, "quota") {
-				return common.WriteError(cmd, "quota_exceeded", apiErr.Message)
+				return "quota_exceeded", apiErr.Message
 			}
-			return common.WriteError(cmd, "rate_limit", apiErr.Message)
+			return "rate_limit", apiErr.Message
 		case 500:
-			return common.WriteError(cmd, "server_error", "OpenAI server error")
+			return "server_error", "OpenAI server error"
 		case 503:
-			return common.WriteError(cmd, "server_overloaded", "OpenAI server overloaded")
+			return "server_overloaded", "OpenAI server overloaded"
 		default:
-			return common.WriteError(cmd, "api_error", apiErr.Message)
+			return "api_error", apiErr.Message
 		}
 	}
 
 	if strings.Contains(err.Error(), "timeout") {
-		return common.WriteError(cmd, "timeout", "Request timed out")
+		return "timeout", "Request timed out"
 	}
-	return common.WriteError(cmd, "connection_error", fmt.Sprintf("Cannot connect to OpenAI API: %s", err.Error()))
+	return "connection_error", fmt.Sprintf("Cannot connect to OpenAI API: %s", err.Error())
 }
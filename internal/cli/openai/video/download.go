@@ -2,9 +2,9 @@ package video
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"os"
+	"net/http"
 	"path/filepath"
 	"strings"
 
@@ -28,8 +28,10 @@ var variantExtensions = map[string]string{
 }
 
 type downloadFlags struct {
-	output  string
-	variant string
+	output   string
+	variant  string
+	all      bool
+	download common.DownloadFlags
 }
 
 type downloadResponse struct {
@@ -58,6 +60,8 @@ func newDownloadCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path")
 	cmd.Flags().StringVar(&flags.variant, "variant", "video", "Content type: video, thumbnail, spritesheet")
+	cmd.Flags().BoolVar(&flags.all, "all", false, "Download video, thumbnail and spritesheet (-o is a directory or {index} template)")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
@@ -77,10 +81,24 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	if flags.output == "" {
 		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
 	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
+
+	if flags.all {
+		if err := common.ValidateAllOutput(flags.output); err != nil {
+			return common.WriteError(cmd, "invalid_output", err.Error())
+		}
+	} else if err := common.ValidateSingleOutput(flags.output); err != nil {
+		return common.WriteError(cmd, "invalid_output", err.Error())
+	}
 
 	expectedExt := variantExtensions[flags.variant]
+	if !flags.all {
+		flags.output = common.DefaultExt(flags.output, expectedExt)
+	}
 	ext := strings.ToLower(filepath.Ext(flags.output))
-	if ext != expectedExt {
+	if !flags.all && ext != expectedExt {
 		return common.WriteError(cmd, "invalid_format", fmt.Sprintf("output file must be %s for variant '%s'", expectedExt, flags.variant))
 	}
 
@@ -91,7 +109,7 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	}
 
 	client := oai.NewClient(option.WithAPIKey(apiKey))
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	// Get video status first
 	video, err := client.Videos.Get(ctx, videoID)
@@ -104,40 +122,67 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 		return common.WriteError(cmd, "video_not_ready", fmt.Sprintf("video is not ready for download, current status: %s", video.Status))
 	}
 
-	// Download content
-	params := oai.VideoDownloadContentParams{
-		Variant: oai.VideoDownloadContentParamsVariant(flags.variant),
-	}
-
-	resp, err := client.Videos.DownloadContent(ctx, videoID, params)
-	if err != nil {
-		return handleAPIError(cmd, err)
+	// Download content, resuming with a Range header after an interrupted transfer
+	open := func(variant string) common.OpenFunc {
+		params := oai.VideoDownloadContentParams{
+			Variant: oai.VideoDownloadContentParamsVariant(variant),
+		}
+		return func(ctx context.Context, offset int64) (*http.Response, error) {
+			var opts []option.RequestOption
+			if offset > 0 {
+				opts = append(opts, option.WithHeader("Range", fmt.Sprintf("bytes=%d-", offset)))
+			}
+			return client.Videos.DownloadContent(ctx, videoID, params, opts...)
+		}
 	}
-	defer resp.Body.Close()
 
-	// Get absolute path for output
-	absPath, err := filepath.Abs(flags.output)
-	if err != nil {
-		absPath = flags.output
+	if flags.all {
+		items := []common.DownloadItem{
+			{Kind: "video", Ext: variantExtensions["video"], Open: open("video")},
+			{Kind: "thumbnail", Suffix: "_thumbnail", Ext: variantExtensions["thumbnail"], Open: open("thumbnail")},
+			{Kind: "spritesheet", Suffix: "_spritesheet", Ext: variantExtensions["spritesheet"], Open: open("spritesheet")},
+		}
+		files, err := common.DownloadAll(ctx, flags.output, videoID, items, common.DownloadOptions{DownloadFlags: flags.download, Vars: common.OutputVars{TaskID: videoID}})
+		if err != nil {
+			return writeDownloadAllError(cmd, err, files)
+		}
+		return common.WriteSuccess(cmd, map[string]any{
+			"success":  true,
+			"video_id": videoID,
+			"files":    files,
+		})
 	}
 
-	// Write to file
-	outFile, err := os.Create(absPath)
+	output := common.IndexedPath(flags.output, videoID, 0, 1, expectedExt)
+	saved, err := common.Download(ctx, output, open(flags.variant), common.DownloadOptions{DownloadFlags: flags.download, Vars: common.OutputVars{TaskID: videoID}})
 	if err != nil {
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
-	}
-	defer outFile.Close()
-
-	_, err = io.Copy(outFile, resp.Body)
-	if err != nil {
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		return writeDownloadError(cmd, err)
 	}
 
 	result := downloadResponse{
 		Success: true,
 		VideoID: videoID,
 		Variant: flags.variant,
-		File:    absPath,
+		File:    saved.Path,
 	}
 	return common.WriteSuccess(cmd, result)
 }
+
+func writeDownloadError(cmd *cobra.Command, err error) error {
+	var apiErr *oai.Error
+	if errors.As(err, &apiErr) {
+		return handleAPIError(cmd, err)
+	}
+	return common.WriteError(cmd, common.DownloadErrorCode(err), err.Error())
+}
+
+// writeDownloadAllError is writeDownloadError for --all, listing the files
+// saved before the failure.
+func writeDownloadAllError(cmd *cobra.Command, err error, files []common.OutputFile) error {
+	var apiErr *oai.Error
+	if errors.As(err, &apiErr) {
+		code, message := apiErrorInfo(err)
+		return common.WriteErrorWithFiles(cmd, code, message, files)
+	}
+	return common.WriteDownloadAllError(cmd, err, files)
+}
@@ -0,0 +1,285 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/WHQ25/rawgenai/internal/config"
+	oai "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/responses"
+	"github.com/openai/openai-go/v3/shared"
+	"github.com/spf13/cobra"
+)
+
+// Image formats read through the Responses API
+var visionImageFormats = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".webp": true,
+	".gif":  true,
+}
+
+// Audio formats read by the audio models of Chat Completions
+var visionAudioFormats = map[string]string{
+	".mp3": "mp3",
+	".wav": "wav",
+}
+
+// Default models for image and audio input
+const (
+	defaultVisionModel      = "gpt-4.1"
+	defaultVisionAudioModel = "gpt-4o-audio-preview"
+)
+
+const defaultVisionQuestion = "Describe the attached files in detail."
+
+// Response type
+type visionResponse struct {
+	Success    bool   `json:"success"`
+	Answer     any    `json:"answer"`
+	Model      string `json:"model"`
+	ResponseID string `json:"response_id,omitempty"`
+}
+
+// Flag struct
+type visionFlags struct {
+	inputs     []string
+	promptFile string
+	schema     string
+	model      string
+	detail     string
+}
+
+// Command
+var visionCmd = newVisionCmd()
+
+func newVisionCmd() *cobra.Command {
+	flags := &visionFlags{}
+
+	cmd := &cobra.Command{
+		Use:     "vision [question]",
+		Aliases: []string{"describe"},
+		Short:   "Ask OpenAI models about images or audio",
+		Long: `Ask an OpenAI model a question about one or more images or audio files and return its answer.
+
+Images are read through the Responses API (default model gpt-4.1). Audio files
+(mp3, wav) are read by an audio model through Chat Completions (default model
+gpt-4o-audio-preview); images and audio cannot be mixed in one request.
+Without a question, the files are described. Use --schema for an answer in JSON
+matching a schema.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVision(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&flags.inputs, "input", "i", nil, "Image or audio file, can be repeated")
+	cmd.Flags().StringVar(&flags.promptFile, "prompt-file", "", "Read the question from a file")
+	cmd.Flags().StringVar(&flags.schema, "schema", "", "JSON schema of the answer (file or inline JSON)")
+	cmd.Flags().StringVarP(&flags.model, "model", "m", "", "Model name (default gpt-4.1, or gpt-4o-audio-preview for audio)")
+	cmd.Flags().StringVar(&flags.detail, "detail", "auto", "Image detail: auto, low, high")
+
+	return cmd
+}
+
+func runVision(cmd *cobra.Command, args []string, flags *visionFlags) error {
+	// Get question; without one the files are described
+	question := strings.TrimSpace(strings.Join(args, " "))
+	if question == "" && flags.promptFile != "" {
+		data, err := os.ReadFile(flags.promptFile)
+		if err != nil {
+			return common.WriteError(cmd, "prompt_file_not_found", fmt.Sprintf("cannot read prompt file: %s", err.Error()))
+		}
+		question = strings.TrimSpace(string(data))
+	}
+	if question == "" {
+		question = defaultVisionQuestion
+	}
+
+	// Validate inputs
+	if len(flags.inputs) == 0 {
+		return common.WriteError(cmd, "missing_input", "at least one image or audio file is required, use -i flag")
+	}
+	var images, audio int
+	for _, input := range flags.inputs {
+		if _, err := os.Stat(input); err != nil {
+			return common.WriteError(cmd, "file_not_found", fmt.Sprintf("file not found: %s", input))
+		}
+		ext := strings.ToLower(filepath.Ext(input))
+		if visionImageFormats[ext] {
+			images++
+		} else if _, ok := visionAudioFormats[ext]; ok {
+			audio++
+		} else {
+			return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported format '%s', supported images: png, jpg, webp, gif; audio: mp3, wav", ext))
+		}
+	}
+	if images > 0 && audio > 0 {
+		return common.WriteError(cmd, "conflicting_inputs", "images and audio cannot be mixed in one request")
+	}
+
+	// Validate detail
+	if flags.detail != "auto" && flags.detail != "low" && flags.detail != "high" {
+		return common.WriteError(cmd, "invalid_detail", "detail must be 'auto', 'low' or 'high'")
+	}
+
+	// Validate schema
+	var schema map[string]any
+	if flags.schema != "" {
+		var err error
+		if schema, err = common.LoadJSONSchema(flags.schema); err != nil {
+			return common.WriteError(cmd, "invalid_schema", err.Error())
+		}
+	}
+
+	// Check API key
+	apiKey := config.GetAPIKey("OPENAI_API_KEY")
+	if apiKey == "" {
+		return common.WriteError(cmd, "missing_api_key", config.GetMissingKeyMessage("OPENAI_API_KEY"))
+	}
+
+	client := oai.NewClient(option.WithAPIKey(apiKey))
+	ctx := context.Background()
+
+	var resp visionResponse
+	var err error
+	if audio > 0 {
+		resp, err = describeAudio(ctx, cmd, client, question, schema, flags)
+	} else {
+		resp, err = describeImages(ctx, cmd, client, question, schema, flags)
+	}
+	if err != nil {
+		return err
+	}
+
+	if schema != nil {
+		var answer any
+		if err := json.Unmarshal([]byte(resp.Answer.(string)), &answer); err != nil {
+			return common.WriteError(cmd, "invalid_response", fmt.Sprintf("answer is not valid JSON: %s", err.Error()))
+		}
+		resp.Answer = answer
+	}
+
+	return common.WriteSuccess(cmd, resp)
+}
+
+// describeImages asks about images through the Responses API
+func describeImages(ctx context.Context, cmd *cobra.Command, client oai.Client, question string, schema map[string]any, flags *visionFlags) (visionResponse, error) {
+	model := flags.model
+	if model == "" {
+		model = defaultVisionModel
+	}
+
+	content := make([]responses.ResponseInputContentUnionParam, 0, len(flags.inputs)+1)
+	for _, imgPath := range flags.inputs {
+		imgData, err := os.ReadFile(imgPath)
+		if err != nil {
+			return visionResponse{}, common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read image file: %s", err.Error()))
+		}
+		dataURL := fmt.Sprintf("data:%s;base64,%s", getMimeType(imgPath), base64.StdEncoding.EncodeToString(imgData))
+		content = append(content, responses.ResponseInputContentUnionParam{
+			OfInputImage: &responses.ResponseInputImageParam{
+				ImageURL: oai.String(dataURL),
+				Detail:   responses.ResponseInputImageDetail(flags.detail),
+			},
+		})
+	}
+	content = append(content, responses.ResponseInputContentParamOfInputText(question))
+
+	params := responses.ResponseNewParams{
+		Model: oai.ResponsesModel(model),
+		Input: responses.ResponseNewParamsInputUnion{
+			OfInputItemList: responses.ResponseInputParam{
+				{
+					OfMessage: &responses.EasyInputMessageParam{
+						Role: responses.EasyInputMessageRoleUser,
+						Content: responses.EasyInputMessageContentUnionParam{
+							OfInputItemContentList: content,
+						},
+					},
+				},
+			},
+		},
+	}
+	if schema != nil {
+		params.Text = responses.ResponseTextConfigParam{
+			Format: responses.ResponseFormatTextConfigParamOfJSONSchema("answer", schema),
+		}
+	}
+
+	resp, err := client.Responses.New(ctx, params)
+	if err != nil {
+		return visionResponse{}, handleAPIError(cmd, err)
+	}
+	text := strings.TrimSpace(resp.OutputText())
+	if text == "" {
+		return visionResponse{}, common.WriteError(cmd, "no_answer", "no answer in response")
+	}
+
+	return visionResponse{
+		Success:    true,
+		Answer:     text,
+		Model:      model,
+		ResponseID: resp.ID,
+	}, nil
+}
+
+// describeAudio asks about audio through Chat Completions, as the Responses
+// API does not take audio input
+func describeAudio(ctx context.Context, cmd *cobra.Command, client oai.Client, question string, schema map[string]any, flags *visionFlags) (visionResponse, error) {
+	model := flags.model
+	if model == "" {
+		model = defaultVisionAudioModel
+	}
+
+	parts := make([]oai.ChatCompletionContentPartUnionParam, 0, len(flags.inputs)+1)
+	for _, audioPath := range flags.inputs {
+		audioData, err := os.ReadFile(audioPath)
+		if err != nil {
+			return visionResponse{}, common.WriteError(cmd, "file_not_found", fmt.Sprintf("cannot read audio file: %s", err.Error()))
+		}
+		parts = append(parts, oai.InputAudioContentPart(oai.ChatCompletionContentPartInputAudioInputAudioParam{
+			Data:   base64.StdEncoding.EncodeToString(audioData),
+			Format: visionAudioFormats[strings.ToLower(filepath.Ext(audioPath))],
+		}))
+	}
+	parts = append(parts, oai.TextContentPart(question))
+
+	params := oai.ChatCompletionNewParams{
+		Model:    oai.ChatModel(model),
+		Messages: []oai.ChatCompletionMessageParamUnion{oai.UserMessage(parts)},
+	}
+	if schema != nil {
+		params.ResponseFormat = oai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+				JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   "answer",
+					Schema: schema,
+				},
+			},
+		}
+	}
+
+	resp, err := client.Chat.Completions.New(ctx, params)
+	if err != nil {
+		return visionResponse{}, handleAPIError(cmd, err)
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return visionResponse{}, common.WriteError(cmd, "no_answer", "no answer in response")
+	}
+
+	return visionResponse{
+		Success: true,
+		Answer:  strings.TrimSpace(resp.Choices[0].Message.Content),
+		Model:   model,
+	}, nil
+}
@@ -0,0 +1,182 @@
+package openai
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+)
+
+func TestVision_Validation(t *testing.T) {
+	dir := t.TempDir()
+	image := filepath.Join(dir, "cat.png")
+	os.WriteFile(image, []byte("png"), 0644)
+	audio := filepath.Join(dir, "meow.wav")
+	os.WriteFile(audio, []byte("wav"), 0644)
+	flac := filepath.Join(dir, "meow.flac")
+	os.WriteFile(flac, []byte("flac"), 0644)
+
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"missing input", []string{"What is this?"}, "missing_input"},
+		{"file not found", []string{"-i", filepath.Join(dir, "nope.png")}, "file_not_found"},
+		{"unsupported format", []string{"-i", flac}, "unsupported_format"},
+		{"images and audio", []string{"-i", image, "-i", audio}, "conflicting_inputs"},
+		{"invalid detail", []string{"-i", image, "--detail", "max"}, "invalid_detail"},
+		{"invalid schema", []string{"-i", image, "--schema", "{oops"}, "invalid_schema"},
+		{"missing api key", []string{"-i", image}, "missing_api_key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetupNoConfigEnv(t)
+			t.Setenv("OPENAI_API_KEY", "")
+			_, stderr, err := executeCommand(newVisionCmd(), tt.args...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(stderr, `"code":"`+tt.code+`"`) {
+				t.Errorf("expected error code %s, got: %s", tt.code, stderr)
+			}
+		})
+	}
+}
+
+// visionServer answers both the Responses and Chat Completions APIs and
+// records the path and body of the last request
+func visionServer(t *testing.T, answer string) func() (string, map[string]any) {
+	var mu sync.Mutex
+	var path string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		path = r.URL.Path
+		body, _ = io.ReadAll(r.Body)
+		mu.Unlock()
+
+		text, _ := json.Marshal(answer)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			w.Write([]byte(`{"id":"chatcmpl_1","object":"chat.completion","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":` + string(text) + `}}]}`))
+			return
+		}
+		w.Write([]byte(`{"id":"resp_1","object":"response","status":"completed","output":[{"type":"message","id":"msg_1","role":"assistant","status":"completed","content":[{"type":"output_text","text":` + string(text) + `,"annotations":[]}]}]}`))
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("OPENAI_BASE_URL", server.URL)
+
+	return func() (string, map[string]any) {
+		mu.Lock()
+		defer mu.Unlock()
+		var req map[string]any
+		json.Unmarshal(body, &req)
+		return path, req
+	}
+}
+
+func TestVision_Images(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	request := visionServer(t, "Two cats on a sofa.")
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.png")
+	os.WriteFile(first, []byte("png data"), 0644)
+	second := filepath.Join(dir, "b.jpg")
+	os.WriteFile(second, []byte("jpeg data"), 0644)
+
+	stdout, stderr, err := executeCommand(newVisionCmd(), "What", "is shown?", "-i", first, "-i", second, "--detail", "high")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	var resp visionResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if !resp.Success || resp.Answer != "Two cats on a sofa." || resp.Model != "gpt-4.1" || resp.ResponseID != "resp_1" {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	path, req := request()
+	if !strings.HasSuffix(path, "/responses") {
+		t.Fatalf("expected Responses API, got %s", path)
+	}
+	input, _ := json.Marshal(req["input"])
+	for _, want := range []string{`"image_url":"data:image/png;base64,`, `"image_url":"data:image/jpeg;base64,`, `"detail":"high"`, `"text":"What is shown?"`} {
+		if !strings.Contains(string(input), want) {
+			t.Errorf("expected input to contain %s, got %s", want, input)
+		}
+	}
+}
+
+func TestVision_ImagesSchema(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	request := visionServer(t, `{"cats":2}`)
+	image := filepath.Join(t.TempDir(), "cats.webp")
+	os.WriteFile(image, []byte("webp data"), 0644)
+
+	stdout, stderr, err := executeCommand(newVisionCmd(), "How many cats?", "-i", image, "--schema", `{"type":"object","properties":{"cats":{"type":"integer"}}}`, "-m", "gpt-4.1-mini")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"answer":{"cats":2}`) || !strings.Contains(stdout, `"model":"gpt-4.1-mini"`) {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	_, req := request()
+	text, _ := json.Marshal(req["text"])
+	for _, want := range []string{`"type":"json_schema"`, `"name":"answer"`, `"cats":{"type":"integer"}`} {
+		if !strings.Contains(string(text), want) {
+			t.Errorf("expected text config to contain %s, got %s", want, text)
+		}
+	}
+}
+
+func TestVision_Audio(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	request := visionServer(t, `{"mood":"calm"}`)
+	audio := filepath.Join(t.TempDir(), "call.mp3")
+	os.WriteFile(audio, []byte("mp3 data"), 0644)
+
+	stdout, stderr, err := executeCommand(newVisionCmd(), "What is the mood?", "-i", audio, "--schema", `{"type":"object"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if !strings.Contains(stdout, `"answer":{"mood":"calm"}`) || !strings.Contains(stdout, `"model":"gpt-4o-audio-preview"`) {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+
+	path, req := request()
+	if !strings.HasSuffix(path, "/chat/completions") {
+		t.Fatalf("expected Chat Completions API, got %s", path)
+	}
+	body, _ := json.Marshal(req)
+	for _, want := range []string{`"type":"input_audio"`, `"format":"mp3"`, `"text":"What is the mood?"`, `"response_format":{"json_schema"`} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected request to contain %s, got %s", want, body)
+		}
+	}
+}
+
+func TestVision_DefaultQuestion(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	request := visionServer(t, "A cat.")
+	image := filepath.Join(t.TempDir(), "cat.png")
+	os.WriteFile(image, []byte("png data"), 0644)
+
+	if _, stderr, err := executeCommand(newVisionCmd(), "-i", image); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	_, req := request()
+	input, _ := json.Marshal(req["input"])
+	if !strings.Contains(string(input), defaultVisionQuestion) {
+		t.Errorf("expected default question, got %s", input)
+	}
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"github.com/WHQ25/rawgenai/internal/cli/audio"
+	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/WHQ25/rawgenai/internal/cli/config"
 	"github.com/WHQ25/rawgenai/internal/cli/dashscope"
 	"github.com/WHQ25/rawgenai/internal/cli/elevenlabs"
@@ -13,6 +15,7 @@ import (
 	"github.com/WHQ25/rawgenai/internal/cli/openai"
 	"github.com/WHQ25/rawgenai/internal/cli/runway"
 	"github.com/WHQ25/rawgenai/internal/cli/seed"
+	"github.com/WHQ25/rawgenai/internal/cli/upload"
 	"github.com/spf13/cobra"
 )
 
@@ -24,6 +27,10 @@ var rootCmd = &cobra.Command{
 	Short:   "CLI tool for AI agents to access raw AI capabilities",
 	Long:    "A CLI tool designed for AI agents to access raw AI capabilities including TTS, STT, Image and Video generation.",
 	Version: version,
+	// Expand -o templates ({provider}, {model}, {date}, ...) for every command
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		common.ApplyOutputTemplate(cmd, args)
+	},
 }
 
 func init() {
@@ -38,6 +45,8 @@ func init() {
 	rootCmd.AddCommand(luma.Cmd)
 	rootCmd.AddCommand(minimax.Cmd)
 	rootCmd.AddCommand(dashscope.Cmd)
+	rootCmd.AddCommand(audio.Cmd)
+	rootCmd.AddCommand(upload.Cmd)
 	rootCmd.AddCommand(config.Cmd)
 }
 
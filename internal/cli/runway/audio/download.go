@@ -2,12 +2,8 @@ package audio
 
 import (
 	"encoding/json"
-	"io"
-	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
 	"github.com/WHQ25/rawgenai/internal/cli/runway/shared"
@@ -16,7 +12,9 @@ import (
 )
 
 type downloadFlags struct {
-	output string
+	output   string
+	all      bool
+	download common.DownloadFlags
 }
 
 func newDownloadCmd() *cobra.Command {
@@ -34,6 +32,8 @@ func newDownloadCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path")
+	cmd.Flags().BoolVar(&flags.all, "all", false, "Download every output of the task")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
@@ -49,11 +49,23 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	if flags.output == "" {
 		return common.WriteError(cmd, "missing_output", "output file path is required (-o)")
 	}
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
 
 	// 3. Validate output extension
-	ext := strings.ToLower(filepath.Ext(flags.output))
-	if ext != ".mp3" && ext != ".wav" && ext != ".mp4" {
-		return common.WriteError(cmd, "invalid_output", "output file must have .mp3, .wav, or .mp4 extension")
+	if flags.all {
+		if err := common.ValidateAllOutput(flags.output); err != nil {
+			return common.WriteError(cmd, "invalid_output", err.Error())
+		}
+	} else if err := common.ValidateSingleOutput(flags.output); err != nil {
+		return common.WriteError(cmd, "invalid_output", err.Error())
+	}
+	if !common.IsDirOutput(flags.output) && !common.NeedsExt(flags.output) {
+		ext := strings.ToLower(filepath.Ext(flags.output))
+		if ext != ".mp3" && ext != ".wav" && ext != ".mp4" {
+			return common.WriteError(cmd, "invalid_output", "output file must have .mp3, .wav, or .mp4 extension")
+		}
 	}
 
 	// 4. Check API key
@@ -94,46 +106,32 @@ func runDownload(cmd *cobra.Command, args []string, flags *downloadFlags) error
 	if len(taskStatus.Output) == 0 {
 		return common.WriteError(cmd, "no_output", "task completed but no output available")
 	}
-	downloadURL := taskStatus.Output[0]
-
-	// 8. Download file
-	client := &http.Client{Timeout: 5 * time.Minute}
-	downloadResp, err := client.Get(downloadURL)
-	if err != nil {
-		return common.WriteError(cmd, "download_error", "failed to download: "+err.Error())
-	}
-	defer downloadResp.Body.Close()
-
-	if downloadResp.StatusCode != 200 {
-		return common.WriteError(cmd, "download_error",
-			"download failed with status: "+downloadResp.Status)
-	}
-
-	// 9. Create output directory if needed
-	dir := filepath.Dir(flags.output)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return common.WriteError(cmd, "output_error", "failed to create directory: "+err.Error())
+	if flags.all {
+		items := make([]common.DownloadItem, 0, len(taskStatus.Output))
+		for i, u := range taskStatus.Output {
+			items = append(items, common.DownloadItem{Index: i, Kind: "audio", Ext: common.URLExt(u, ".mp3"), URL: u})
 		}
+		files, err := common.DownloadAll(cmd.Context(), flags.output, taskID, items, common.DownloadOptions{DownloadFlags: flags.download, Vars: common.OutputVars{TaskID: taskID}})
+		if err != nil {
+			return common.WriteDownloadAllError(cmd, err, files)
+		}
+		return common.WriteSuccess(cmd, map[string]any{
+			"success": true,
+			"task_id": taskID,
+			"files":   files,
+		})
 	}
+	downloadURL := taskStatus.Output[0]
 
-	// 10. Write file
-	outFile, err := os.Create(flags.output)
+	// 8. Download file
+	result, err := common.DownloadURL(cmd.Context(), downloadURL, common.IndexedPath(flags.output, taskID, 0, 1, common.URLExt(downloadURL, ".mp3")), common.DownloadOptions{DownloadFlags: flags.download, Vars: common.OutputVars{TaskID: taskID}})
 	if err != nil {
-		return common.WriteError(cmd, "output_error", "failed to create output file: "+err.Error())
-	}
-	defer outFile.Close()
-
-	if _, err := io.Copy(outFile, downloadResp.Body); err != nil {
-		return common.WriteError(cmd, "output_error", "failed to write output file: "+err.Error())
+		return common.WriteError(cmd, common.DownloadErrorCode(err), err.Error())
 	}
 
-	// 11. Return absolute path
-	absPath, _ := filepath.Abs(flags.output)
-
 	return common.WriteSuccess(cmd, map[string]any{
 		"success": true,
 		"task_id": taskID,
-		"file":    absPath,
+		"file":    result.Path,
 	})
 }
@@ -42,6 +42,25 @@ func TestDownload_MissingOutput(t *testing.T) {
 	}
 }
 
+func TestDownload_ConflictingFlags(t *testing.T) {
+	cmd := newTestCmd()
+	_, stderr, err := executeCommand(cmd, "download", "test-task-id", "-o", "output.mp4", "--overwrite", "--no-clobber")
+
+	if err == nil {
+		t.Fatal("expected error for conflicting flags")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "conflicting_flags" {
+		t.Errorf("expected error code 'conflicting_flags', got: %s", errorObj["code"])
+	}
+}
+
 func TestDownload_InvalidOutputExtension(t *testing.T) {
 	cmd := newTestCmd()
 	_, stderr, err := executeCommand(cmd, "download", "test-task-id", "-o", "output.avi")
@@ -119,3 +138,21 @@ func TestDownload_ValidMP4Extension(t *testing.T) {
 		})
 	}
 }
+
+func TestDownload_AllRequiresMultiFileOutput(t *testing.T) {
+	cmd := newTestCmd()
+	_, stderr, err := executeCommand(cmd, "download", "test-task-id", "-o", "out.mp4", "--all")
+	if err == nil {
+		t.Fatal("expected error for --all with a single-file output")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_output" {
+		t.Errorf("expected error code 'invalid_output', got: %s", errorObj["code"])
+	}
+}
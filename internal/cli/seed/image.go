@@ -95,6 +95,7 @@ func runSeedImage(cmd *cobra.Command, args []string, flags *seedImageFlags) erro
 	}
 
 	// Validate format (only JPEG supported)
+	flags.output = common.DefaultExt(flags.output, ".jpg")
 	ext := strings.ToLower(filepath.Ext(flags.output))
 	if ext != ".jpg" && ext != ".jpeg" {
 		return common.WriteError(cmd, "unsupported_format", fmt.Sprintf("unsupported format '%s', only .jpg/.jpeg is supported", ext))
@@ -250,7 +251,7 @@ func runSeedImage(cmd *cobra.Command, args []string, flags *seedImageFlags) erro
 			return common.WriteError(cmd, "decode_error", fmt.Sprintf("cannot decode image: %s", err.Error()))
 		}
 
-		if err := os.WriteFile(absPath, imageBytes, 0644); err != nil {
+		if _, err := common.WriteOutput(absPath, imageBytes, common.OutputVars{}); err != nil {
 			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
 		}
 		savedFiles = append(savedFiles, absPath)
@@ -266,7 +267,7 @@ func runSeedImage(cmd *cobra.Command, args []string, flags *seedImageFlags) erro
 			}
 
 			outputPath := fmt.Sprintf("%s_%d%s", baseName, i+1, extName)
-			if err := os.WriteFile(outputPath, imageBytes, 0644); err != nil {
+			if _, err := common.WriteOutput(outputPath, imageBytes, common.OutputVars{}); err != nil {
 				return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
 			}
 			savedFiles = append(savedFiles, outputPath)
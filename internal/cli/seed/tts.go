@@ -63,12 +63,16 @@ type ttsFlags struct {
 	volume     int
 	speak      bool
 	context    string
+	timing     common.TimestampFlags
 }
 
 type ttsResponse struct {
-	Success bool   `json:"success"`
-	File    string `json:"file,omitempty"`
-	Voice   string `json:"voice,omitempty"`
+	Success            bool               `json:"success"`
+	File               string             `json:"file,omitempty"`
+	Voice              string             `json:"voice,omitempty"`
+	TimeToFirstAudioMs int64              `json:"time_to_first_audio_ms,omitempty"`
+	Timestamps         []common.TimedText `json:"timestamps,omitempty"`
+	Subtitles          string             `json:"subtitles,omitempty"`
 }
 
 var ttsCmd = newTTSCmd()
@@ -96,6 +100,7 @@ func newTTSCmd() *cobra.Command {
 	cmd.Flags().IntVar(&flags.volume, "volume", 0, "Volume: -50 to 100 (0 = normal)")
 	cmd.Flags().BoolVar(&flags.speak, "speak", false, "Play audio after generation")
 	cmd.Flags().StringVar(&flags.context, "context", "", "Emotion/style context (e.g., \"用悲伤的语气说\")")
+	common.AddTimestampFlags(cmd, &flags.timing, common.TimestampsWord, common.TimestampsSentence)
 
 	return cmd
 }
@@ -118,9 +123,9 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		return common.WriteError(cmd, "invalid_format", "format must be mp3, pcm, or ogg_opus")
 	}
 
-	// Streaming playback only supports mp3
-	if flags.speak && flags.format != "mp3" {
-		return common.WriteError(cmd, "invalid_format", "--speak only supports mp3 format")
+	// WAV output is synthesized as PCM and wrapped in a WAV header
+	if common.IsWAVPath(flags.output) && !cmd.Flags().Changed("format") {
+		flags.format = "pcm"
 	}
 
 	// Validate speed
@@ -133,6 +138,11 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 		return common.WriteError(cmd, "invalid_volume", "volume must be between -50 and 100")
 	}
 
+	// Validate timestamps and subtitles
+	if err := flags.timing.Validate(); err != nil {
+		return common.WriteError(cmd, common.TimestampErrorCode(err), err.Error())
+	}
+
 	// Get credentials
 	appID := config.GetAPIKey("SEED_APP_ID")
 	if appID == "" {
@@ -162,69 +172,65 @@ func runTTS(cmd *cobra.Command, args []string, flags *ttsFlags) error {
 }
 
 func runStreamTTS(cmd *cobra.Command, appID, accessToken, text, outputPath string, flags *ttsFlags) error {
-	// Create pipe for streaming playback
-	pr, pw := io.Pipe()
-
-	// Create writers: always include pipe for playback
-	var writers []io.Writer
-	writers = append(writers, pw)
+	// Player receives the audio while it streams in
+	player := common.NewStreamPlayer(audioExt(flags.format), common.DecodeOptions{SampleRate: flags.sampleRate})
+	var w io.Writer = player
 
 	// Optionally write to file
-	var outFile *os.File
 	if outputPath != "" {
-		var err error
-		outFile, err = os.Create(outputPath)
+		outFile, err := createOutput(outputPath, flags)
 		if err != nil {
-			pw.Close()
+			player.Abort(err)
 			return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
 		}
 		defer outFile.Close()
-		writers = append(writers, outFile)
+		w = io.MultiWriter(outFile, player)
 	}
 
-	// Multi-writer for all destinations
-	mw := io.MultiWriter(writers...)
-
-	// Start playback in goroutine
-	playErr := make(chan error, 1)
-	go func() {
-		playErr <- common.PlayMP3(pr)
-	}()
-
 	// Stream audio to writers
-	if err := streamAudio(context.Background(), appID, accessToken, text, flags, mw); err != nil {
-		pw.CloseWithError(err)
+	words, err := streamAudio(context.Background(), appID, accessToken, text, flags, w)
+	if err != nil {
+		if playErr := player.Abort(err); playErr != nil {
+			return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", playErr.Error()))
+		}
 		return common.WriteError(cmd, "api_error", err.Error())
 	}
-	pw.Close()
 
 	// Wait for playback to finish
-	if err := <-playErr; err != nil {
+	if err := player.Close(); err != nil {
 		return common.WriteError(cmd, "playback_error", fmt.Sprintf("cannot play audio: %s", err.Error()))
 	}
 
 	// Return success
 	result := ttsResponse{
-		Success: true,
-		File:    outputPath,
-		Voice:   flags.voice,
+		Success:            true,
+		File:               outputPath,
+		Voice:              flags.voice,
+		TimeToFirstAudioMs: player.TimeToFirstAudio().Milliseconds(),
+	}
+	if err := addTimings(&result, words, flags); err != nil {
+		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write subtitles: %s", err.Error()))
 	}
 	return common.WriteSuccess(cmd, result)
 }
 
 func runFileTTS(cmd *cobra.Command, appID, accessToken, text, outputPath string, flags *ttsFlags) error {
 	// Create output file
-	outFile, err := os.Create(outputPath)
+	outFile, err := createOutput(outputPath, flags)
 	if err != nil {
 		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot create output file: %s", err.Error()))
 	}
-	defer outFile.Close()
 
 	// Stream audio to file
-	if err := streamAudio(context.Background(), appID, accessToken, text, flags, outFile); err != nil {
+	words, err := streamAudio(context.Background(), appID, accessToken, text, flags, outFile)
+	if err != nil {
+		outFile.Close()
 		os.Remove(outputPath)
 		return common.WriteError(cmd, "api_error", err.Error())
 	}
+	if err := outFile.Close(); err != nil {
+		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+	}
 
 	// Return success
 	result := ttsResponse{
@@ -232,10 +238,26 @@ func runFileTTS(cmd *cobra.Command, appID, accessToken, text, outputPath string,
 		File:    outputPath,
 		Voice:   flags.voice,
 	}
+	if err := addTimings(&result, words, flags); err != nil {
+		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write subtitles: %s", err.Error()))
+	}
 	return common.WriteSuccess(cmd, result)
 }
 
-func streamAudio(ctx context.Context, appID, accessToken, text string, flags *ttsFlags, w io.Writer) error {
+// addTimings reports the word timings at the requested granularity and
+// writes the subtitles.
+func addTimings(result *ttsResponse, words []common.TimedText, flags *ttsFlags) error {
+	if flags.timing.Timestamps != "" {
+		result.Timestamps = common.AlignWords(words, flags.timing.Timestamps)
+	}
+	path, err := flags.timing.WriteSubtitles(common.SubtitleCues(words))
+	result.Subtitles = path
+	return err
+}
+
+// streamAudio writes the synthesized audio to w and returns the word
+// timings when timestamps are enabled.
+func streamAudio(ctx context.Context, appID, accessToken, text string, flags *ttsFlags, w io.Writer) ([]common.TimedText, error) {
 	// Setup WebSocket connection headers
 	header := http.Header{}
 	header.Set("X-Api-App-Key", appID)
@@ -249,9 +271,9 @@ func streamAudio(ctx context.Context, appID, accessToken, text string, flags *tt
 		if resp != nil {
 			// Read response body for error details
 			body, _ := io.ReadAll(resp.Body)
-			return fmt.Errorf("connection failed (status %d): %s", resp.StatusCode, string(body))
+			return nil, fmt.Errorf("connection failed (status %d): %s", resp.StatusCode, string(body))
 		}
-		return fmt.Errorf("connection failed: %w", err)
+		return nil, fmt.Errorf("connection failed: %w", err)
 	}
 	defer conn.Close()
 
@@ -259,23 +281,23 @@ func streamAudio(ctx context.Context, appID, accessToken, text string, flags *tt
 
 	// 1. Send StartConnection
 	if err := sendEvent(conn, EventStartConnection, "", nil); err != nil {
-		return fmt.Errorf("StartConnection failed: %w", err)
+		return nil, fmt.Errorf("StartConnection failed: %w", err)
 	}
 
 	// 2. Wait for ConnectionStarted
 	if err := waitForEvent(conn, EventConnectionStarted); err != nil {
-		return fmt.Errorf("ConnectionStarted failed: %w", err)
+		return nil, fmt.Errorf("ConnectionStarted failed: %w", err)
 	}
 
 	// 3. Send StartSession with config
 	sessionPayload := buildSessionPayload(text, flags)
 	if err := sendEvent(conn, EventStartSession, sessionID, sessionPayload); err != nil {
-		return fmt.Errorf("StartSession failed: %w", err)
+		return nil, fmt.Errorf("StartSession failed: %w", err)
 	}
 
 	// 4. Wait for SessionStarted
 	if err := waitForEvent(conn, EventSessionStarted); err != nil {
-		return fmt.Errorf("SessionStarted failed: %w", err)
+		return nil, fmt.Errorf("SessionStarted failed: %w", err)
 	}
 
 	// 5. Send TaskRequest with text
@@ -286,34 +308,50 @@ func streamAudio(ctx context.Context, appID, accessToken, text string, flags *tt
 	}
 	taskBytes, _ := json.Marshal(taskPayload)
 	if err := sendEvent(conn, EventTaskRequest, sessionID, taskBytes); err != nil {
-		return fmt.Errorf("TaskRequest failed: %w", err)
+		return nil, fmt.Errorf("TaskRequest failed: %w", err)
 	}
 
 	// 6. Send FinishSession
 	if err := sendEvent(conn, EventFinishSession, sessionID, nil); err != nil {
-		return fmt.Errorf("FinishSession failed: %w", err)
+		return nil, fmt.Errorf("FinishSession failed: %w", err)
 	}
 
-	// 7. Receive audio chunks until SessionFinished, write to output
+	// 7. Receive audio chunks until SessionFinished, write to output. Word
+	// times of each sentence may restart at zero, so the audio received is
+	// timed to place them.
+	var words []common.TimedText
+	var clock *common.AudioClock
+	var sentenceStart float64
+	if flags.timing.Enabled() {
+		clock = common.NewAudioClock(audioExt(flags.format), common.DecodeOptions{SampleRate: flags.sampleRate})
+		w = io.MultiWriter(w, clock)
+	}
 	for {
 		msgType, eventType, payload, err := receiveMessage(conn)
 		if err != nil {
-			return fmt.Errorf("receive failed: %w", err)
+			return nil, fmt.Errorf("receive failed: %w", err)
 		}
 
 		switch {
 		case msgType == MsgTypeAudioOnlyResponse && eventType == EventTTSResponse:
 			if _, err := w.Write(payload); err != nil {
-				return fmt.Errorf("write failed: %w", err)
+				return nil, fmt.Errorf("write failed: %w", err)
+			}
+		case msgType == MsgTypeFullServerResponse && eventType == EventTTSSentenceEnd:
+			words = common.AppendTimings(words, sentenceWords(payload), sentenceStart)
+			if clock != nil {
+				if sentenceStart, err = clock.Duration(); err != nil {
+					return nil, fmt.Errorf("cannot time audio: %w", err)
+				}
 			}
 		case msgType == MsgTypeFullServerResponse && eventType == EventSessionFinished:
 			// 8. Send FinishConnection
 			sendEvent(conn, EventFinishConnection, "", nil)
-			return nil
+			return words, nil
 		case msgType == MsgTypeFullServerResponse && eventType == EventSessionFailed:
-			return fmt.Errorf("session failed: %s", string(payload))
+			return nil, fmt.Errorf("session failed: %s", string(payload))
 		case msgType == MsgTypeError:
-			return fmt.Errorf("server error: %s", string(payload))
+			return nil, fmt.Errorf("server error: %s", string(payload))
 		}
 	}
 }
@@ -330,6 +368,11 @@ func buildSessionPayload(text string, flags *ttsFlags) []byte {
 		},
 	}
 
+	// Sentence end events carry word timings when timestamps are enabled
+	if flags.timing.Enabled() {
+		reqParams["audio_params"].(map[string]any)["enable_timestamp"] = true
+	}
+
 	// Add context_texts if provided (TTS 2.0 emotion control)
 	if flags.context != "" {
 		reqParams["additions"] = fmt.Sprintf(`{"context_texts":["%s"]}`, flags.context)
@@ -345,6 +388,28 @@ func buildSessionPayload(text string, flags *ttsFlags) []byte {
 	return data
 }
 
+// sentenceWords returns the word timings of a sentence end event.
+func sentenceWords(payload []byte) []common.TimedText {
+	var sentence struct {
+		Words []struct {
+			Word      string  `json:"word"`
+			StartTime float64 `json:"startTime"`
+			EndTime   float64 `json:"endTime"`
+		} `json:"words"`
+	}
+	if err := json.Unmarshal(payload, &sentence); err != nil {
+		return nil
+	}
+	words := make([]common.TimedText, 0, len(sentence.Words))
+	for _, w := range sentence.Words {
+		if strings.TrimSpace(w.Word) == "" {
+			continue
+		}
+		words = append(words, common.TimedText{Text: strings.TrimSpace(w.Word), Start: w.StartTime, End: w.EndTime})
+	}
+	return words
+}
+
 // Binary protocol helpers
 
 func sendEvent(conn *websocket.Conn, eventType int32, sessionID string, payload []byte) error {
@@ -458,6 +523,25 @@ func receiveMessage(conn *websocket.Conn) (msgType uint8, eventType int32, paylo
 	return msgType, eventType, payload, nil
 }
 
+// createOutput creates the output file; PCM written to a .wav path gets a
+// WAV header (16-bit mono at --sample-rate).
+func createOutput(path string, flags *ttsFlags) (io.WriteCloser, error) {
+	if flags.format != "pcm" {
+		return common.CreateOutput(path)
+	}
+	return common.CreatePCMFile(path, common.AudioFormat{SampleRate: flags.sampleRate, Channels: 1, Encoding: common.PCMS16LE})
+}
+
+// audioExt maps a --format value to the file extension the player decodes.
+func audioExt(format string) string {
+	switch format {
+	case "ogg_opus":
+		return ".ogg"
+	default:
+		return "." + format
+	}
+}
+
 func getText(args []string, filePath string, stdin io.Reader) (string, error) {
 	// From positional argument
 	if len(args) > 0 {
@@ -226,12 +226,16 @@ func TestTTS_SpeakWithoutOutput(t *testing.T) {
 	}
 }
 
-func TestTTS_SpeakOnlyMP3(t *testing.T) {
+func TestTTS_SpeakPCM(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+	t.Setenv("SEED_APP_ID", "")
+	t.Setenv("SEED_ACCESS_TOKEN", "")
+
 	cmd := newTTSCmd()
 	_, stderr, err := executeCommand(cmd, "Hello", "--speak", "--format", "pcm")
 
 	if err == nil {
-		t.Fatal("expected error for non-mp3 format with --speak")
+		t.Fatal("expected error (missing credentials), got success")
 	}
 
 	var resp map[string]any
@@ -239,9 +243,10 @@ func TestTTS_SpeakOnlyMP3(t *testing.T) {
 		t.Fatalf("expected JSON error output, got: %s", stderr)
 	}
 
+	// Non-mp3 formats can be played too
 	errorObj := resp["error"].(map[string]any)
-	if errorObj["code"] != "invalid_format" {
-		t.Errorf("expected error code 'invalid_format', got: %s", errorObj["code"])
+	if errorObj["code"] != "missing_credentials" {
+		t.Errorf("expected error code 'missing_credentials', got: %s", errorObj["code"])
 	}
 }
 
@@ -325,3 +330,68 @@ func TestTTS_FromStdin(t *testing.T) {
 		t.Errorf("expected error code 'missing_credentials' (stdin read success), got: %s", errorObj["code"])
 	}
 }
+
+func TestTTS_CharacterTimestampsUnsupported(t *testing.T) {
+	cmd := newTTSCmd()
+	_, stderr, err := executeCommand(cmd, "Hello", "-o", "out.mp3", "--timestamps=character")
+	if err == nil {
+		t.Fatal("expected error for character timestamps")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_timestamps" {
+		t.Errorf("expected error code 'invalid_timestamps', got: %s", errorObj["code"])
+	}
+}
+
+func TestTTS_InvalidSubtitles(t *testing.T) {
+	cmd := newTTSCmd()
+	_, stderr, err := executeCommand(cmd, "Hello", "-o", "out.mp3", "--subtitles", "out.ass")
+	if err == nil {
+		t.Fatal("expected error for invalid subtitles path")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "invalid_subtitles" {
+		t.Errorf("expected error code 'invalid_subtitles', got: %s", errorObj["code"])
+	}
+}
+
+func TestSessionPayload_EnablesTimestamps(t *testing.T) {
+	flags := &ttsFlags{voice: "v", format: "mp3", sampleRate: 24000}
+	flags.timing.Subtitles = "out.srt"
+
+	var payload struct {
+		ReqParams struct {
+			AudioParams map[string]any `json:"audio_params"`
+		} `json:"req_params"`
+	}
+	if err := json.Unmarshal(buildSessionPayload("Hi", flags), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.ReqParams.AudioParams["enable_timestamp"] != true {
+		t.Errorf("expected enable_timestamp, got %v", payload.ReqParams.AudioParams)
+	}
+}
+
+func TestSentenceWords(t *testing.T) {
+	payload := []byte(`{"text":"你好。","words":[{"word":"你","startTime":0.1,"endTime":0.3},{"word":"好。","startTime":0.3,"endTime":0.6}]}`)
+	words := sentenceWords(payload)
+	if len(words) != 2 {
+		t.Fatalf("expected 2 words, got %d", len(words))
+	}
+	if words[1].Text != "好。" || words[1].Start != 0.3 || words[1].End != 0.6 {
+		t.Errorf("unexpected word: %+v", words[1])
+	}
+	if got := sentenceWords([]byte(`{}`)); len(got) != 0 {
+		t.Errorf("expected no words without timestamps, got %+v", got)
+	}
+}
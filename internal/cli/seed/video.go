@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/WHQ25/rawgenai/internal/cli/common"
@@ -61,6 +62,7 @@ type videoCreateFlags struct {
 type videoDownloadFlags struct {
 	output    string
 	lastFrame string
+	download  common.DownloadFlags
 }
 
 type videoListFlags struct {
@@ -375,6 +377,9 @@ func runVideoStatus(cmd *cobra.Command, args []string) error {
 		if result.Content.LastFrameURL != "" {
 			output["last_frame_url"] = result.Content.LastFrameURL
 		}
+		if expiresAt := common.ExpiresAt(result.Content.VideoURL, result.Content.LastFrameURL); expiresAt != "" {
+			output["expires_at"] = expiresAt
+		}
 		output["resolution"] = result.Resolution
 		output["ratio"] = result.Ratio
 		output["duration"] = result.Duration
@@ -403,6 +408,7 @@ func newVideoDownloadCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&flags.output, "output", "o", "", "Output file path (.mp4)")
 	cmd.Flags().StringVar(&flags.lastFrame, "last-frame", "", "Also save last frame to this path")
+	common.AddDownloadFlags(cmd, &flags.download)
 
 	return cmd
 }
@@ -419,7 +425,12 @@ func runVideoDownload(cmd *cobra.Command, args []string, flags *videoDownloadFla
 		return common.WriteError(cmd, "missing_output", "output file is required, use -o flag")
 	}
 
+	if err := flags.download.Validate(); err != nil {
+		return common.WriteError(cmd, "conflicting_flags", err.Error())
+	}
+
 	// Validate format
+	flags.output = common.DefaultExt(flags.output, ".mp4")
 	ext := strings.ToLower(filepath.Ext(flags.output))
 	if ext != ".mp4" {
 		return common.WriteError(cmd, "invalid_format", fmt.Sprintf("unsupported format '%s', only .mp4 is supported", ext))
@@ -454,6 +465,7 @@ func runVideoDownload(cmd *cobra.Command, args []string, flags *videoDownloadFla
 	var result struct {
 		ID      string `json:"id"`
 		Status  string `json:"status"`
+		Seed    int    `json:"seed"`
 		Content *struct {
 			VideoURL     string `json:"video_url"`
 			LastFrameURL string `json:"last_frame_url"`
@@ -492,52 +504,27 @@ func runVideoDownload(cmd *cobra.Command, args []string, flags *videoDownloadFla
 	}
 
 	// Download video
-	videoResp, err := http.Get(result.Content.VideoURL)
-	if err != nil {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot download video: %s", err.Error()))
+	opts := common.DownloadOptions{DownloadFlags: flags.download, Vars: common.OutputVars{TaskID: taskID}}
+	if result.Seed != 0 {
+		opts.Vars.Seed = strconv.Itoa(result.Seed)
 	}
-	defer videoResp.Body.Close()
-
-	if videoResp.StatusCode != http.StatusOK {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("download failed with status: %d", videoResp.StatusCode))
-	}
-
-	videoData, err := io.ReadAll(videoResp.Body)
+	saved, err := common.DownloadURL(cmd.Context(), result.Content.VideoURL, flags.output, opts)
 	if err != nil {
-		return common.WriteError(cmd, "download_error", fmt.Sprintf("cannot read video data: %s", err.Error()))
-	}
-
-	// Save video
-	absPath, err := filepath.Abs(flags.output)
-	if err != nil {
-		absPath = flags.output
-	}
-
-	if err := os.WriteFile(absPath, videoData, 0644); err != nil {
-		return common.WriteError(cmd, "output_write_error", fmt.Sprintf("cannot write output file: %s", err.Error()))
+		return common.WriteError(cmd, common.DownloadErrorCode(err), err.Error())
 	}
 
 	// Build response
 	output := map[string]any{
 		"success": true,
 		"task_id": taskID,
-		"file":    absPath,
+		"file":    saved.Path,
 	}
 
 	// Download last frame if requested
 	if flags.lastFrame != "" && result.Content.LastFrameURL != "" {
-		lastFrameResp, err := http.Get(result.Content.LastFrameURL)
+		frame, err := common.DownloadURL(cmd.Context(), result.Content.LastFrameURL, flags.lastFrame, opts)
 		if err == nil {
-			defer lastFrameResp.Body.Close()
-			if lastFrameResp.StatusCode == http.StatusOK {
-				lastFrameData, err := io.ReadAll(lastFrameResp.Body)
-				if err == nil {
-					lastFramePath, _ := filepath.Abs(flags.lastFrame)
-					if err := os.WriteFile(lastFramePath, lastFrameData, 0644); err == nil {
-						output["last_frame_file"] = lastFramePath
-					}
-				}
-			}
+			output["last_frame_file"] = frame.Path
 		}
 	}
 
@@ -537,6 +537,25 @@ func TestVideoDownload_MissingOutput(t *testing.T) {
 	}
 }
 
+func TestVideoDownload_ConflictingFlags(t *testing.T) {
+	cmd := newVideoCmd()
+	_, stderr, err := executeVideoCommand(cmd, "download", "cgt-2025xxxx", "-o", "output.mp4", "--overwrite", "--no-clobber")
+
+	if err == nil {
+		t.Fatal("expected error for conflicting flags")
+	}
+
+	var resp map[string]any
+	if jsonErr := json.Unmarshal([]byte(strings.TrimSpace(stderr)), &resp); jsonErr != nil {
+		t.Fatalf("expected JSON error output, got: %s", stderr)
+	}
+
+	errorObj := resp["error"].(map[string]any)
+	if errorObj["code"] != "conflicting_flags" {
+		t.Errorf("expected error code 'conflicting_flags', got: %s", errorObj["code"])
+	}
+}
+
 func TestVideoDownload_InvalidFormat(t *testing.T) {
 	cmd := newVideoCmd()
 	_, stderr, err := executeVideoCommand(cmd, "download", "cgt-2025xxxx", "-o", "output.avi")
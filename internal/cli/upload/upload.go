@@ -0,0 +1,88 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/spf13/cobra"
+)
+
+// Cmd is the upload command
+var Cmd = &cobra.Command{
+	Use:   "upload",
+	Short: "Manage uploaded local files",
+	Long:  "Manage local files that commands uploaded with the s3 or webdav backend so providers could fetch them.",
+}
+
+func init() {
+	Cmd.AddCommand(deleteCmd)
+}
+
+type deleteResponse struct {
+	Success bool     `json:"success"`
+	Deleted []string `json:"deleted"`
+}
+
+type deleteFlags struct {
+	backend string
+}
+
+var deleteCmd = newDeleteCmd()
+
+func newDeleteCmd() *cobra.Command {
+	flags := &deleteFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "delete <object>...",
+		Short: "Delete uploaded files",
+		Long: `Delete files uploaded for a command, by the "object" names listed in its "uploads" output.
+
+Providers fetch uploaded URLs after an async task is submitted, so delete
+only once the task has succeeded or failed. Files on the native backend
+cannot be deleted and expire on their own.`,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDelete(cmd, args, flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.backend, "upload-backend", "", "Backend the files were uploaded to: s3, webdav (default: upload_backend config)")
+
+	return cmd
+}
+
+func runDelete(cmd *cobra.Command, args []string, flags *deleteFlags) error {
+	var objects []string
+	for _, arg := range args {
+		if object := strings.TrimSpace(arg); object != "" {
+			objects = append(objects, object)
+		}
+	}
+	if len(objects) == 0 {
+		return common.WriteError(cmd, "missing_object", "at least one object name is required")
+	}
+
+	deleted := []string{}
+	for _, object := range objects {
+		if err := common.DeleteUpload(cmd.Context(), flags.backend, object); err != nil {
+			code := "delete_error"
+			if errors.Is(err, common.ErrUploadNotConfigured) {
+				code = "upload_not_configured"
+			}
+			msg := fmt.Sprintf("cannot delete %s: %s", object, err)
+			if len(deleted) > 0 {
+				msg += fmt.Sprintf(" (already deleted: %s)", strings.Join(deleted, ", "))
+			}
+			return common.WriteError(cmd, code, msg)
+		}
+		deleted = append(deleted, object)
+	}
+
+	return common.WriteSuccess(cmd, deleteResponse{
+		Success: true,
+		Deleted: deleted,
+	})
+}
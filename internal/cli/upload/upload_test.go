@@ -0,0 +1,116 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/WHQ25/rawgenai/internal/cli/common"
+	"github.com/spf13/cobra"
+)
+
+func executeCommand(cmd *cobra.Command, args ...string) (stdout, stderr string, err error) {
+	stdoutBuf := new(bytes.Buffer)
+	stderrBuf := new(bytes.Buffer)
+
+	cmd.SetOut(stdoutBuf)
+	cmd.SetErr(stderrBuf)
+	cmd.SetArgs(args)
+
+	err = cmd.Execute()
+	return stdoutBuf.String(), stderrBuf.String(), err
+}
+
+func TestDelete_MissingObject(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+
+	_, stderr, err := executeCommand(newDeleteCmd())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(stderr, `"code":"missing_object"`) {
+		t.Errorf("expected missing_object error, got: %s", stderr)
+	}
+}
+
+func TestDelete_NotConfigured(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+
+	_, stderr, err := executeCommand(newDeleteCmd(), "clip.mp4")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(stderr, `"code":"upload_not_configured"`) {
+		t.Errorf("expected upload_not_configured error, got: %s", stderr)
+	}
+}
+
+func TestDelete_S3(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Query().Get("X-Amz-Signature") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		deleted = append(deleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	t.Setenv("UPLOAD_S3_ENDPOINT", server.URL)
+	t.Setenv("UPLOAD_S3_BUCKET", "media")
+	t.Setenv("UPLOAD_S3_ACCESS_KEY", "AKIA")
+	t.Setenv("UPLOAD_S3_SECRET_KEY", "secret")
+
+	stdout, stderr, err := executeCommand(newDeleteCmd(), "rawgenai/a-clip.mp4", "rawgenai/b-voice.wav", "--upload-backend", "s3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v, stderr: %s", err, stderr)
+	}
+	var resp deleteResponse
+	json.Unmarshal([]byte(stdout), &resp)
+	if !resp.Success || len(resp.Deleted) != 2 {
+		t.Errorf("unexpected response: %s", stdout)
+	}
+	if len(deleted) != 2 || deleted[0] != "/media/rawgenai/a-clip.mp4" || deleted[1] != "/media/rawgenai/b-voice.wav" {
+		t.Errorf("unexpected DELETE requests: %v", deleted)
+	}
+}
+
+func TestDelete_Failure(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "missing.mp4") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	t.Setenv("UPLOAD_BACKEND", "webdav")
+	t.Setenv("UPLOAD_WEBDAV_URL", server.URL)
+
+	_, stderr, err := executeCommand(newDeleteCmd(), "a-clip.mp4", "missing.mp4")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(stderr, `"code":"delete_error"`) || !strings.Contains(stderr, "already deleted: a-clip.mp4") {
+		t.Errorf("expected delete_error naming the deleted object, got: %s", stderr)
+	}
+}
+
+func TestDelete_NativeBackend(t *testing.T) {
+	common.SetupNoConfigEnv(t)
+
+	_, stderr, err := executeCommand(newDeleteCmd(), "clip.mp4", "--upload-backend", "native")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(stderr, `"code":"delete_error"`) {
+		t.Errorf("expected delete_error, got: %s", stderr)
+	}
+}
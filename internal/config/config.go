@@ -10,68 +10,101 @@ import (
 
 // Config holds API keys and other configuration
 type Config struct {
-	OpenAIAPIKey     string `json:"openai_api_key,omitempty"`
-	GeminiAPIKey     string `json:"gemini_api_key,omitempty"`
-	GoogleAPIKey     string `json:"google_api_key,omitempty"`
-	ElevenLabsAPIKey string `json:"elevenlabs_api_key,omitempty"`
-	XAIAPIKey        string `json:"xai_api_key,omitempty"`
-	ArkAPIKey        string `json:"ark_api_key,omitempty"`
-	SeedAppID        string `json:"seed_app_id,omitempty"`
-	SeedAccessToken  string `json:"seed_access_token,omitempty"`
-	KlingAccessKey   string `json:"kling_access_key,omitempty"`
-	KlingSecretKey   string `json:"kling_secret_key,omitempty"`
-	KlingBaseURL     string `json:"kling_base_url,omitempty"`
-	RunwayAPIKey     string `json:"runway_api_key,omitempty"`
-	LumaAPIKey       string `json:"luma_api_key,omitempty"`
-	MinimaxAPIKey    string `json:"minimax_api_key,omitempty"`
-	DashscopeAPIKey  string `json:"dashscope_api_key,omitempty"`
-	DashscopeBaseURL string `json:"dashscope_base_url,omitempty"`
-	TencentSecretID  string `json:"tencent_secret_id,omitempty"`
-	TencentSecretKey string `json:"tencent_secret_key,omitempty"`
+	OpenAIAPIKey      string `json:"openai_api_key,omitempty"`
+	GeminiAPIKey      string `json:"gemini_api_key,omitempty"`
+	GoogleAPIKey      string `json:"google_api_key,omitempty"`
+	ElevenLabsAPIKey  string `json:"elevenlabs_api_key,omitempty"`
+	XAIAPIKey         string `json:"xai_api_key,omitempty"`
+	ArkAPIKey         string `json:"ark_api_key,omitempty"`
+	SeedAppID         string `json:"seed_app_id,omitempty"`
+	SeedAccessToken   string `json:"seed_access_token,omitempty"`
+	KlingAccessKey    string `json:"kling_access_key,omitempty"`
+	KlingSecretKey    string `json:"kling_secret_key,omitempty"`
+	KlingBaseURL      string `json:"kling_base_url,omitempty"`
+	RunwayAPIKey      string `json:"runway_api_key,omitempty"`
+	LumaAPIKey        string `json:"luma_api_key,omitempty"`
+	MinimaxAPIKey     string `json:"minimax_api_key,omitempty"`
+	DashscopeAPIKey   string `json:"dashscope_api_key,omitempty"`
+	DashscopeBaseURL  string `json:"dashscope_base_url,omitempty"`
+	TencentSecretID   string `json:"tencent_secret_id,omitempty"`
+	TencentSecretKey  string `json:"tencent_secret_key,omitempty"`
+	UploadBackend     string `json:"upload_backend,omitempty"`
+	UploadS3Endpoint  string `json:"upload_s3_endpoint,omitempty"`
+	UploadS3Region    string `json:"upload_s3_region,omitempty"`
+	UploadS3Bucket    string `json:"upload_s3_bucket,omitempty"`
+	UploadS3AccessKey string `json:"upload_s3_access_key,omitempty"`
+	UploadS3SecretKey string `json:"upload_s3_secret_key,omitempty"`
+	UploadS3Prefix    string `json:"upload_s3_prefix,omitempty"`
+	UploadWebDAVURL   string `json:"upload_webdav_url,omitempty"`
+	UploadWebDAVUser  string `json:"upload_webdav_username,omitempty"`
+	UploadWebDAVPass  string `json:"upload_webdav_password,omitempty"`
+	UploadPublicURL   string `json:"upload_public_url,omitempty"`
 }
 
 // validKeys maps normalized key names to their JSON field names
 var validKeys = map[string]string{
-	"openai_api_key":     "openai_api_key",
-	"gemini_api_key":     "gemini_api_key",
-	"google_api_key":     "google_api_key",
-	"elevenlabs_api_key": "elevenlabs_api_key",
-	"xai_api_key":        "xai_api_key",
-	"ark_api_key":        "ark_api_key",
-	"seed_app_id":        "seed_app_id",
-	"seed_access_token":  "seed_access_token",
-	"kling_access_key":   "kling_access_key",
-	"kling_secret_key":   "kling_secret_key",
-	"kling_base_url":     "kling_base_url",
-	"runway_api_key":     "runway_api_key",
-	"luma_api_key":       "luma_api_key",
-	"minimax_api_key":    "minimax_api_key",
-	"dashscope_api_key":  "dashscope_api_key",
-	"dashscope_base_url": "dashscope_base_url",
-	"tencent_secret_id":  "tencent_secret_id",
-	"tencent_secret_key": "tencent_secret_key",
+	"openai_api_key":         "openai_api_key",
+	"gemini_api_key":         "gemini_api_key",
+	"google_api_key":         "google_api_key",
+	"elevenlabs_api_key":     "elevenlabs_api_key",
+	"xai_api_key":            "xai_api_key",
+	"ark_api_key":            "ark_api_key",
+	"seed_app_id":            "seed_app_id",
+	"seed_access_token":      "seed_access_token",
+	"kling_access_key":       "kling_access_key",
+	"kling_secret_key":       "kling_secret_key",
+	"kling_base_url":         "kling_base_url",
+	"runway_api_key":         "runway_api_key",
+	"luma_api_key":           "luma_api_key",
+	"minimax_api_key":        "minimax_api_key",
+	"dashscope_api_key":      "dashscope_api_key",
+	"dashscope_base_url":     "dashscope_base_url",
+	"tencent_secret_id":      "tencent_secret_id",
+	"tencent_secret_key":     "tencent_secret_key",
+	"upload_backend":         "upload_backend",
+	"upload_s3_endpoint":     "upload_s3_endpoint",
+	"upload_s3_region":       "upload_s3_region",
+	"upload_s3_bucket":       "upload_s3_bucket",
+	"upload_s3_access_key":   "upload_s3_access_key",
+	"upload_s3_secret_key":   "upload_s3_secret_key",
+	"upload_s3_prefix":       "upload_s3_prefix",
+	"upload_webdav_url":      "upload_webdav_url",
+	"upload_webdav_username": "upload_webdav_username",
+	"upload_webdav_password": "upload_webdav_password",
+	"upload_public_url":      "upload_public_url",
 }
 
 // envToConfigKey maps environment variable names to config keys
 var envToConfigKey = map[string]string{
-	"OPENAI_API_KEY":     "openai_api_key",
-	"GEMINI_API_KEY":     "gemini_api_key",
-	"GOOGLE_API_KEY":     "google_api_key",
-	"ELEVENLABS_API_KEY": "elevenlabs_api_key",
-	"XAI_API_KEY":        "xai_api_key",
-	"ARK_API_KEY":        "ark_api_key",
-	"SEED_APP_ID":        "seed_app_id",
-	"SEED_ACCESS_TOKEN":  "seed_access_token",
-	"KLING_ACCESS_KEY":   "kling_access_key",
-	"KLING_SECRET_KEY":   "kling_secret_key",
-	"KLING_BASE_URL":     "kling_base_url",
-	"RUNWAY_API_KEY":     "runway_api_key",
-	"LUMA_API_KEY":       "luma_api_key",
-	"MINIMAX_API_KEY":    "minimax_api_key",
-	"DASHSCOPE_API_KEY":  "dashscope_api_key",
-	"DASHSCOPE_BASE_URL": "dashscope_base_url",
-	"TENCENT_SECRET_ID":  "tencent_secret_id",
-	"TENCENT_SECRET_KEY": "tencent_secret_key",
+	"OPENAI_API_KEY":         "openai_api_key",
+	"GEMINI_API_KEY":         "gemini_api_key",
+	"GOOGLE_API_KEY":         "google_api_key",
+	"ELEVENLABS_API_KEY":     "elevenlabs_api_key",
+	"XAI_API_KEY":            "xai_api_key",
+	"ARK_API_KEY":            "ark_api_key",
+	"SEED_APP_ID":            "seed_app_id",
+	"SEED_ACCESS_TOKEN":      "seed_access_token",
+	"KLING_ACCESS_KEY":       "kling_access_key",
+	"KLING_SECRET_KEY":       "kling_secret_key",
+	"KLING_BASE_URL":         "kling_base_url",
+	"RUNWAY_API_KEY":         "runway_api_key",
+	"LUMA_API_KEY":           "luma_api_key",
+	"MINIMAX_API_KEY":        "minimax_api_key",
+	"DASHSCOPE_API_KEY":      "dashscope_api_key",
+	"DASHSCOPE_BASE_URL":     "dashscope_base_url",
+	"TENCENT_SECRET_ID":      "tencent_secret_id",
+	"TENCENT_SECRET_KEY":     "tencent_secret_key",
+	"UPLOAD_BACKEND":         "upload_backend",
+	"UPLOAD_S3_ENDPOINT":     "upload_s3_endpoint",
+	"UPLOAD_S3_REGION":       "upload_s3_region",
+	"UPLOAD_S3_BUCKET":       "upload_s3_bucket",
+	"UPLOAD_S3_ACCESS_KEY":   "upload_s3_access_key",
+	"UPLOAD_S3_SECRET_KEY":   "upload_s3_secret_key",
+	"UPLOAD_S3_PREFIX":       "upload_s3_prefix",
+	"UPLOAD_WEBDAV_URL":      "upload_webdav_url",
+	"UPLOAD_WEBDAV_USERNAME": "upload_webdav_username",
+	"UPLOAD_WEBDAV_PASSWORD": "upload_webdav_password",
+	"UPLOAD_PUBLIC_URL":      "upload_public_url",
 }
 
 // Path returns the config file path
@@ -83,6 +116,19 @@ func Path() string {
 	return filepath.Join(home, ".config", "rawgenai", "config.json")
 }
 
+// StateDir returns the directory for local state kept between runs, such as
+// conversation sessions: $XDG_STATE_HOME/rawgenai, or ~/.local/state/rawgenai
+func StateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "rawgenai")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "rawgenai")
+}
+
 // Load loads config from file
 func Load() (*Config, error) {
 	path := Path()
@@ -221,6 +267,28 @@ func (c *Config) Get(key string) string {
 		return c.TencentSecretID
 	case "tencent_secret_key":
 		return c.TencentSecretKey
+	case "upload_backend":
+		return c.UploadBackend
+	case "upload_s3_endpoint":
+		return c.UploadS3Endpoint
+	case "upload_s3_region":
+		return c.UploadS3Region
+	case "upload_s3_bucket":
+		return c.UploadS3Bucket
+	case "upload_s3_access_key":
+		return c.UploadS3AccessKey
+	case "upload_s3_secret_key":
+		return c.UploadS3SecretKey
+	case "upload_s3_prefix":
+		return c.UploadS3Prefix
+	case "upload_webdav_url":
+		return c.UploadWebDAVURL
+	case "upload_webdav_username":
+		return c.UploadWebDAVUser
+	case "upload_webdav_password":
+		return c.UploadWebDAVPass
+	case "upload_public_url":
+		return c.UploadPublicURL
 	default:
 		return ""
 	}
@@ -265,6 +333,28 @@ func (c *Config) Set(key, value string) error {
 		c.TencentSecretID = value
 	case "tencent_secret_key":
 		c.TencentSecretKey = value
+	case "upload_backend":
+		c.UploadBackend = value
+	case "upload_s3_endpoint":
+		c.UploadS3Endpoint = value
+	case "upload_s3_region":
+		c.UploadS3Region = value
+	case "upload_s3_bucket":
+		c.UploadS3Bucket = value
+	case "upload_s3_access_key":
+		c.UploadS3AccessKey = value
+	case "upload_s3_secret_key":
+		c.UploadS3SecretKey = value
+	case "upload_s3_prefix":
+		c.UploadS3Prefix = value
+	case "upload_webdav_url":
+		c.UploadWebDAVURL = value
+	case "upload_webdav_username":
+		c.UploadWebDAVUser = value
+	case "upload_webdav_password":
+		c.UploadWebDAVPass = value
+	case "upload_public_url":
+		c.UploadPublicURL = value
 	default:
 		return fmt.Errorf("unknown key: %s", key)
 	}
@@ -211,6 +211,10 @@ func TestValidKeys(t *testing.T) {
 		"runway_api_key", "luma_api_key", "minimax_api_key",
 		"dashscope_api_key", "dashscope_base_url",
 		"tencent_secret_id", "tencent_secret_key",
+		"upload_backend", "upload_s3_endpoint", "upload_s3_region", "upload_s3_bucket",
+		"upload_s3_access_key", "upload_s3_secret_key", "upload_s3_prefix",
+		"upload_webdav_url", "upload_webdav_username", "upload_webdav_password",
+		"upload_public_url",
 	}
 
 	if len(keys) != len(expectedKeys) {
@@ -312,3 +316,17 @@ func TestLoad_NoFile(t *testing.T) {
 		t.Error("Empty config should have empty values")
 	}
 }
+
+func TestStateDir(t *testing.T) {
+	t.Setenv("HOME", "/home/test")
+
+	t.Setenv("XDG_STATE_HOME", "")
+	if got, want := StateDir(), filepath.Join("/home/test", ".local", "state", "rawgenai"); got != want {
+		t.Errorf("StateDir() = %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "/var/state")
+	if got, want := StateDir(), filepath.Join("/var/state", "rawgenai"); got != want {
+		t.Errorf("StateDir() with XDG_STATE_HOME = %q, want %q", got, want)
+	}
+}